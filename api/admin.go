@@ -0,0 +1,331 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"regexp"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zaigie/palworld-server-tool/internal/catalog"
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/internal/task"
+	"github.com/zaigie/palworld-server-tool/service"
+)
+
+type TeleportRequest struct {
+	// AdminPlayerUid is the admin character teleported or teleported to
+	AdminPlayerUid string `json:"admin_player_uid"`
+	// Confirm must be true, without it nothing is sent
+	Confirm bool `json:"confirm"`
+}
+
+type GiveItemRequest struct {
+	// ItemId is the key of the item, or its exact name in a language
+	ItemId  string `json:"item_id"`
+	Count   int    `json:"count"`
+	Confirm bool   `json:"confirm"`
+}
+
+type GiveExpRequest struct {
+	Amount  int64 `json:"amount"`
+	Confirm bool  `json:"confirm"`
+}
+
+type UnlockTechRequest struct {
+	TechId  string `json:"tech_id"`
+	Confirm bool   `json:"confirm"`
+}
+
+// maxGiveCount bounds one give_item regardless of admin.give_limits, a
+// mistyped count would flood the player's inventory.
+const maxGiveCount = 9999
+
+var techIdPattern = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+type WorldActionRequest struct {
+	// Vars are hour and minute of set_time, weather of set_weather and
+	// event of trigger_event
+	Vars    map[string]string `json:"vars"`
+	Confirm bool              `json:"confirm"`
+}
+
+type AdminActionResponse struct {
+	Command string `json:"command"`
+	Message string `json:"message"`
+}
+
+// teleportPlayer godoc
+//
+//	@Summary		Teleport To Player or Bring Player
+//	@Description	Teleport the admin character to the player, or bring the player to it, with the command of admin.commands.teleport or admin.commands.bring. The game only teleports from in-game chat, so the commands need a server mod. Sent actions are recorded as admin.command events
+//	@Tags			Player
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			player_uid	path		string			true	"Player UID"
+//	@Param			action		path		string			true	"teleport or bring"
+//	@Param			request		body		TeleportRequest	true	"Admin character and confirmation"
+//	@Param			dry_run		query		bool			false	"Only return the command that would be sent, as a DryRunResponse"
+//	@Success		200			{object}	AdminActionResponse
+//	@Failure		400			{object}	ErrorResponse
+//	@Failure		401			{object}	ErrorResponse
+//	@Failure		404			{object}	ErrorResponse
+//	@Failure		428			{object}	ErrorResponse
+//	@Failure		501			{object}	ErrorResponse
+//	@Router			/api/player/{player_uid}/{action} [post]
+func teleportPlayer(action string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req TeleportRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if req.AdminPlayerUid == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "admin_player_uid is required"})
+			return
+		}
+		target, err := service.GetPlayer(database.GetDB(), c.Param("player_uid"))
+		if err != nil {
+			playerError(c, err)
+			return
+		}
+		admin, err := service.GetPlayer(database.GetDB(), req.AdminPlayerUid)
+		if err != nil {
+			playerError(c, err)
+			return
+		}
+		runAdminAction(c, task.AdminAction{
+			Action: action,
+			Target: target.TersePlayer,
+			Admin:  &admin.TersePlayer,
+			By:     requestBy(c),
+		}, req.Confirm)
+	}
+}
+
+// giveItem godoc
+//
+//	@Summary		Give Item
+//	@Description	Give a player an item of the catalog with the command of admin.commands.give_item, {item_id} and {count} are replaced. Limited by admin.give_limits of the player's group, recorded as an admin.command event
+//	@Tags			Player
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			player_uid	path		string			true	"Player UID"
+//	@Param			request		body		GiveItemRequest	true	"Item, count and confirmation"
+//	@Param			dry_run		query		bool			false	"Only return the command that would be sent, as a DryRunResponse"
+//	@Success		200			{object}	AdminActionResponse
+//	@Failure		400			{object}	ErrorResponse
+//	@Failure		401			{object}	ErrorResponse
+//	@Failure		403			{object}	ErrorResponse
+//	@Failure		404			{object}	ErrorResponse
+//	@Failure		428			{object}	ErrorResponse
+//	@Failure		501			{object}	ErrorResponse
+//	@Router			/api/player/{player_uid}/give_item [post]
+func giveItem(c *gin.Context) {
+	var req GiveItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	item, ok := catalog.FindItem(req.ItemId)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unknown item " + req.ItemId})
+		return
+	}
+	if req.Count < 1 || req.Count > maxGiveCount {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "count must be between 1 and " + strconv.Itoa(maxGiveCount)})
+		return
+	}
+	giveAction(c, task.AdminAction{
+		Action: task.AdminGiveItem,
+		Vars:   map[string]string{"item_id": item.Key, "count": strconv.Itoa(req.Count)},
+	}, item.Key, int64(req.Count), req.Confirm)
+}
+
+// giveExp godoc
+//
+//	@Summary		Give Experience
+//	@Description	Give a player experience with the command of admin.commands.give_exp, {amount} is replaced. Limited by admin.give_limits of the player's group, recorded as an admin.command event
+//	@Tags			Player
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			player_uid	path		string			true	"Player UID"
+//	@Param			request		body		GiveExpRequest	true	"Amount and confirmation"
+//	@Param			dry_run		query		bool			false	"Only return the command that would be sent, as a DryRunResponse"
+//	@Success		200			{object}	AdminActionResponse
+//	@Failure		400			{object}	ErrorResponse
+//	@Failure		401			{object}	ErrorResponse
+//	@Failure		403			{object}	ErrorResponse
+//	@Failure		404			{object}	ErrorResponse
+//	@Failure		428			{object}	ErrorResponse
+//	@Failure		501			{object}	ErrorResponse
+//	@Router			/api/player/{player_uid}/give_exp [post]
+func giveExp(c *gin.Context) {
+	var req GiveExpRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Amount < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "amount must be positive"})
+		return
+	}
+	giveAction(c, task.AdminAction{
+		Action: task.AdminGiveExp,
+		Vars:   map[string]string{"amount": strconv.FormatInt(req.Amount, 10)},
+	}, "", req.Amount, req.Confirm)
+}
+
+// unlockTech godoc
+//
+//	@Summary		Unlock Technology
+//	@Description	Unlock a technology for a player with the command of admin.commands.unlock_tech, {tech_id} is replaced. Limited by admin.give_limits of the player's group, recorded as an admin.command event
+//	@Tags			Player
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			player_uid	path		string				true	"Player UID"
+//	@Param			request		body		UnlockTechRequest	true	"Technology and confirmation"
+//	@Param			dry_run		query		bool				false	"Only return the command that would be sent, as a DryRunResponse"
+//	@Success		200			{object}	AdminActionResponse
+//	@Failure		400			{object}	ErrorResponse
+//	@Failure		401			{object}	ErrorResponse
+//	@Failure		403			{object}	ErrorResponse
+//	@Failure		404			{object}	ErrorResponse
+//	@Failure		428			{object}	ErrorResponse
+//	@Failure		501			{object}	ErrorResponse
+//	@Router			/api/player/{player_uid}/unlock_tech [post]
+func unlockTech(c *gin.Context) {
+	var req UnlockTechRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !techIdPattern.MatchString(req.TechId) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid tech_id"})
+		return
+	}
+	giveAction(c, task.AdminAction{
+		Action: task.AdminUnlockTech,
+		Vars:   map[string]string{"tech_id": req.TechId},
+	}, "", 0, req.Confirm)
+}
+
+// giveAction checks the admin.give_limits of the target of the path and
+// runs the action for it.
+func giveAction(c *gin.Context, action task.AdminAction, item string, amount int64, confirm bool) {
+	target, err := service.GetPlayer(database.GetDB(), c.Param("player_uid"))
+	if err != nil {
+		playerError(c, err)
+		return
+	}
+	if err := task.CheckGiveLimit(database.GetDB(), target.TersePlayer, action.Action, item, amount); err != nil {
+		if errors.Is(err, task.ErrGiveRestricted) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	action.Target = target.TersePlayer
+	action.By = requestBy(c)
+	runAdminAction(c, action, confirm)
+}
+
+// worldAction godoc
+//
+//	@Summary		Control World
+//	@Description	Set the time of day or weather, or trigger a world event, with the command of admin.commands.set_time, set_weather or trigger_event. The game has no such RCON commands, they need a server mod. Schedule them with the start_actions and end_actions of a community event. Recorded as admin.command events
+//	@Tags			Server
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			action	path		string				true	"set_time, set_weather or trigger_event"
+//	@Param			request	body		WorldActionRequest	true	"Values and confirmation"
+//	@Param			dry_run	query		bool				false	"Only return the command that would be sent, as a DryRunResponse"
+//	@Success		200		{object}	AdminActionResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		428		{object}	ErrorResponse
+//	@Failure		501		{object}	ErrorResponse
+//	@Router			/api/world/{action} [post]
+func worldAction(c *gin.Context) {
+	var req WorldActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	action, err := task.WorldAdminAction(database.WorldAction{Action: c.Param("action"), Vars: req.Vars}, requestBy(c))
+	if err != nil {
+		if errors.Is(err, task.ErrAdminCommandUnset) {
+			c.JSON(http.StatusNotImplemented, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	runAdminAction(c, action, req.Confirm)
+}
+
+// listItems godoc
+//
+//	@Summary		List Items
+//	@Description	Search the item catalog by key or name, for the item_id of give_item
+//	@Tags			Player
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			q		query		string	false	"Part of the key or a name"
+//	@Param			limit	query		int		false	"Max number of items"	default(50)
+//	@Success		200		{array}		catalog.Item
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Router			/api/items [get]
+func listItems(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+		return
+	}
+	c.JSON(http.StatusOK, catalog.SearchItems(c.Query("q"), limit))
+}
+
+// playerError writes the error of looking up a player.
+func playerError(c *gin.Context, err error) {
+	if err == service.ErrNoRecord {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Player not found"})
+		return
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+}
+
+// runAdminAction answers a dry run with the command, refuses an unconfirmed
+// action with 428 and otherwise sends it.
+func runAdminAction(c *gin.Context, action task.AdminAction, confirm bool) {
+	command, err := action.Command()
+	if err != nil {
+		if errors.Is(err, task.ErrAdminCommandUnset) {
+			c.JSON(http.StatusNotImplemented, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if isDryRun(c) {
+		writeDryRun(c, []service.Change{commandChange(action.Action, gin.H{"command": command})}, nil)
+		return
+	}
+	if !confirm {
+		c.JSON(http.StatusPreconditionRequired, gin.H{"error": "send confirm: true to run " + command})
+		return
+	}
+	message, err := task.RunAdminAction(c.Request.Context(), database.GetDB(), action)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, AdminActionResponse{Command: command, Message: message})
+}
@@ -0,0 +1,47 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/service"
+)
+
+// getAuditSummary godoc
+//
+//	@Summary		Get Audit Summary
+//	@Description	Summarize what the admins did: actions per admin per day, the most used actions and the latest destructive ones. Admins are told apart by the client address of their requests, bot or cli. Built from the admin.* and whitelist decision events, the removed entries and the snapshots
+//	@Tags			Audit
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			days	query		int	false	"days to summarize, default 30"
+//	@Param			recent	query		int	false	"max number of destructive actions, default 20"
+//	@Success		200		{object}	service.AuditSummary
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Router			/api/audit/summary [get]
+func getAuditSummary(c *gin.Context) {
+	days, err := strconv.Atoi(c.DefaultQuery("days", "30"))
+	if err != nil || days <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid days"})
+		return
+	}
+	recent, err := strconv.Atoi(c.DefaultQuery("recent", "20"))
+	if err != nil || recent < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid recent"})
+		return
+	}
+	// days start at midnight in task.timezone
+	now := time.Now().In(time.Local)
+	since := time.Date(now.Year(), now.Month(), now.Day()-days+1, 0, 0, 0, 0, time.Local)
+	summary, err := service.SummarizeAudit(database.GetDB(), since, recent)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, summary)
+}
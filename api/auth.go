@@ -2,12 +2,15 @@ package api
 
 import (
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
 	"github.com/spf13/viper"
 	"github.com/zaigie/palworld-server-tool/internal/auth"
+	"github.com/zaigie/palworld-server-tool/internal/cache"
+	"github.com/zaigie/palworld-server-tool/internal/logger"
 )
 
 type LoginInfo struct {
@@ -16,7 +19,7 @@ type LoginInfo struct {
 
 // loginHandler godoc
 // @Summary		Login
-// @Description	Login
+// @Description	Login. Failed attempts of an ip are limited to web.login_attempts per web.login_window seconds
 // @Tags			Auth
 // @Accept			json
 // @Produce		json
@@ -24,6 +27,7 @@ type LoginInfo struct {
 // @Success		200			{object}	SuccessResponse
 // @Failure		400			{object}	ErrorResponse
 // @Failure		401			{object}	ErrorResponse
+// @Failure		429			{object}	ErrorResponse
 // @Router			/api/login [post]
 func loginHandler(c *gin.Context) {
 	var loginInfo LoginInfo
@@ -31,18 +35,50 @@ func loginHandler(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	attempts := viper.GetInt64("web.login_attempts")
+	attemptsKey := "login:" + c.ClientIP()
+	if attempts > 0 {
+		failed, err := cache.Default().Get(attemptsKey)
+		if n, _ := strconv.ParseInt(string(failed), 10, 64); err == nil && n >= attempts {
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": "too many failed logins, try again later"})
+			return
+		}
+	}
 	correctPassword := viper.GetString("web.password")
 	if loginInfo.Password != correctPassword {
+		if attempts > 0 {
+			window := time.Duration(viper.GetInt("web.login_window")) * time.Second
+			if _, err := cache.Default().Incr(attemptsKey, window); err != nil {
+				logger.Warnf("Failed to count login attempt: %v\n", err)
+			}
+		}
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "incorrect password"})
 		return
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"exp": time.Now().Add(time.Hour * 24).Unix(),
-	})
-	tokenString, err := token.SignedString(auth.SecretKey)
+	tokenString, err := auth.GenerateToken()
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "could not generate token"})
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"token": tokenString})
 }
+
+// logoutHandler godoc
+// @Summary		Logout
+// @Description	Revoke the token of the request on every replica until it expires, POST /api/me/logout for a player token
+// @Tags			Auth
+// @Accept			json
+// @Produce		json
+// @Security		ApiKeyAuth
+// @Success		200	{object}	SuccessResponse
+// @Failure		400	{object}	ErrorResponse
+// @Failure		401	{object}	ErrorResponse
+// @Router			/api/logout [post]
+func logoutHandler(c *gin.Context) {
+	claims, _ := c.Get("claims")
+	if err := auth.RevokeToken(claims.(jwt.MapClaims)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
@@ -3,13 +3,14 @@ package api
 import (
 	"fmt"
 	"net/http"
-	"os"
 	"path/filepath"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/internal/logger"
+	"github.com/zaigie/palworld-server-tool/internal/task"
 	"github.com/zaigie/palworld-server-tool/internal/tool"
 	"github.com/zaigie/palworld-server-tool/service"
 )
@@ -17,42 +18,64 @@ import (
 // listBackups godoc
 //
 //	@Summary		List backups within a specified time range
-//	@Description	List all backups or backups within a specific time range.
+//	@Description	List backups from the catalog, filtered by time range, trigger reason, server version, size or part of the archive name.
 //	@Tags			backup
 //	@Accept			json
 //	@Produce		json
 //	@Security		ApiKeyAuth
-//	@Param			startTime	query		int	false	"Start time of the backup range in timestamp"
-//	@Param			endTime		query		int	false	"End time of the backup range in timestamp"
-//	@Success		200			{array}		database.Backup
-//	@Failure		400			{object}	ErrorResponse
+//	@Param			startTime		query		int		false	"Start time of the backup range in timestamp"
+//	@Param			endTime			query		int		false	"End time of the backup range in timestamp"
+//	@Param			reason			query		string	false	"Trigger reason"	enum(schedule,manual,bot,import,season)
+//	@Param			world_version	query		string	false	"Server version when backed up"
+//	@Param			season			query		string	false	"Name of the season the backups were archived with, none for the current season"
+//	@Param			q				query		string	false	"Part of the archive name"
+//	@Param			min_size		query		int		false	"Min size in bytes"
+//	@Param			max_size		query		int		false	"Max size in bytes"
+//	@Param			order_by		query		string	false	"order by field"	enum(save_time,size,player_count)
+//	@Param			desc			query		bool	false	"order by desc"
+//	@Param			limit			query		int		false	"max number of backups"
+//	@Success		200				{array}		database.Backup
+//	@Failure		400				{object}	ErrorResponse
 //	@Router			/api/backup [get]
 func listBackups(c *gin.Context) {
-	var startTimestamp, endTimestamp int64
-	var startTime, endTime time.Time
-	var err error
-
-	startTimeStr, endTimeStr := c.Query("startTime"), c.Query("endTime")
-
-	if startTimeStr != "" {
-		startTimestamp, err = strconv.ParseInt(startTimeStr, 10, 64)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid start time"})
-			return
+	filter := service.BackupFilter{
+		Reason:       c.Query("reason"),
+		WorldVersion: c.Query("world_version"),
+		Season:       c.Query("season"),
+		Query:        c.Query("q"),
+		OrderBy:      c.Query("order_by"),
+		Desc:         c.Query("desc") == "true",
+	}
+	times := map[string]*time.Time{"startTime": &filter.StartTime, "endTime": &filter.EndTime}
+	for name, t := range times {
+		if s := c.Query(name); s != "" {
+			ms, err := strconv.ParseInt(s, 10, 64)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid %s", name)})
+				return
+			}
+			*t = time.UnixMilli(ms)
 		}
-		startTime = time.Unix(0, startTimestamp*int64(time.Millisecond))
 	}
-
-	if endTimeStr != "" {
-		endTimestamp, err = strconv.ParseInt(endTimeStr, 10, 64)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid end time"})
+	numbers := map[string]*int64{"min_size": &filter.MinSize, "max_size": &filter.MaxSize}
+	for name, n := range numbers {
+		if s := c.Query(name); s != "" {
+			var err error
+			if *n, err = strconv.ParseInt(s, 10, 64); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid %s", name)})
+				return
+			}
+		}
+	}
+	if s := c.Query("limit"); s != "" {
+		var err error
+		if filter.Limit, err = strconv.Atoi(s); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
 			return
 		}
-		endTime = time.Unix(0, endTimestamp*int64(time.Millisecond))
 	}
 
-	backups, err := service.ListBackups(database.GetDB(), startTime, endTime)
+	backups, err := service.ListBackups(database.GetDB(), filter)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -61,15 +84,37 @@ func listBackups(c *gin.Context) {
 	c.JSON(http.StatusOK, backups)
 }
 
+// createBackup godoc
+//
+//	@Summary		Create Backup
+//	@Description	Back up the save now
+//	@Tags			backup
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Success		200	{object}	database.Backup
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Router			/api/backup [post]
+func createBackup(c *gin.Context) {
+	backup, err := task.RunBackup(database.GetDB(), service.BackupReasonManual)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, backup)
+}
+
 // downloadBackup godoc
 //
 //	@Summary		Download Backup
-//	@Description	Download a backup
+//	@Description	Download a backup as a zip archive, decrypted with the backup keys when it is encrypted. With encrypted the archive is sent encrypted with the active key instead, for offsite copies
 //	@Tags			backup
 //	@Accept			json
 //	@Produce		application/octet-stream
 //	@Security		ApiKeyAuth
 //	@Param			backup_id	path		string	true	"Backup ID"
+//	@Param			encrypted	query		bool	false	"send the archive encrypted"
 //	@Success		200			{file}		"Backupfile"
 //	@Failure		400			{object}	ErrorResponse
 //	@Failure		404			{object}	ErrorResponse
@@ -87,13 +132,48 @@ func downloadBackup(c *gin.Context) {
 		return
 	}
 
-	backupDir, err := tool.GetBackupDir()
+	encrypted := c.Query("encrypted") == "true"
+	if backup.Format != service.BackupFormatChunks && (backup.KeyId != "") == encrypted {
+		backupDir, err := tool.GetBackupDir()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", backup.Path))
+		c.File(filepath.Join(backupDir, backup.Path))
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", tool.BackupFileName(backup, encrypted)))
+	c.Header("Content-Type", "application/octet-stream")
+	if err := tool.WriteBackup(c.Writer, backup, encrypted); err != nil {
+		if !c.Writer.Written() {
+			c.Header("Content-Disposition", "")
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		logger.Errorf("Failed to write backup %s: %v\n", backup.BackupId, err)
+	}
+}
+
+// rekeyBackups godoc
+//
+//	@Summary		Rekey Backups
+//	@Description	Encrypt the backups that are unencrypted or encrypted with an older key with the active backup key. To rotate, append a new key to save.backup_key_file, run this, then drop the old keys
+//	@Tags			backup
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Success		200	{object}	map[string]int
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Router			/api/backup/rekey [post]
+func rekeyBackups(c *gin.Context) {
+	backups, chunks, err := tool.RekeyBackups(database.GetDB())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "backups": backups, "chunks": chunks})
 		return
 	}
-	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", backup.Path))
-	c.File(filepath.Join(backupDir, backup.Path))
+	c.JSON(http.StatusOK, gin.H{"backups": backups, "chunks": chunks})
 }
 
 // deleteBackup godoc
@@ -124,12 +204,7 @@ func deleteBackup(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	backupDir, err := tool.GetBackupDir()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-	err = os.Remove(filepath.Join(backupDir, backup.Path))
+	err = tool.RemoveBackupFile(backup)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -0,0 +1,54 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/internal/task"
+	"github.com/zaigie/palworld-server-tool/service"
+)
+
+// listBadges godoc
+//
+//	@Summary		List Badges
+//	@Description	List the badge rules of badges.rules with the players who earned each, the first first
+//	@Tags			Player
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	[]service.BadgeSummary
+//	@Failure		400	{object}	ErrorResponse
+//	@Router			/api/badge [get]
+func listBadges(c *gin.Context) {
+	rules, err := task.BadgeRules()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	badges, err := service.ListBadges(database.GetDB(), rules)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, badges)
+}
+
+// getPlayerBadges godoc
+//
+//	@Summary		Get Player Badges
+//	@Description	Get the badges a player earned and the days in a row they played
+//	@Tags			Player
+//	@Accept			json
+//	@Produce		json
+//	@Param			player_uid	path		string	true	"Player UID"
+//	@Success		200			{object}	database.PlayerBadges
+//	@Failure		400			{object}	ErrorResponse
+//	@Router			/api/player/{player_uid}/badges [get]
+func getPlayerBadges(c *gin.Context) {
+	badges, err := service.GetPlayerBadges(database.GetDB(), service.CanonicalPlayerUid(c.Param("player_uid")))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, badges)
+}
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+	"github.com/zaigie/palworld-server-tool/internal/bot"
+	"github.com/zaigie/palworld-server-tool/internal/database"
+)
+
+type OneBotEvent struct {
+	PostType string `json:"post_type"`
+	bot.Message
+}
+
+type OneBotReply struct {
+	Reply      string `json:"reply"`
+	AutoEscape bool   `json:"auto_escape"`
+}
+
+// onebotEvent godoc
+//
+//	@Summary		Receive OneBot Event
+//	@Description	Receive events posted by a OneBot v11 implementation and reply to bot commands, signed by X-Signature with bot.secret, events are refused without it
+//	@Tags			Bot
+//	@Accept			json
+//	@Produce		json
+//	@Param			event	body		OneBotEvent	true	"OneBot Event"
+//	@Success		200		{object}	OneBotReply
+//	@Success		204
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		403	{object}	ErrorResponse
+//	@Failure		404	{object}	ErrorResponse
+//	@Router			/api/bot/onebot [post]
+func onebotEvent(c *gin.Context) {
+	if !viper.GetBool("bot.enable") {
+		c.JSON(http.StatusNotFound, gin.H{"error": "bot is disabled"})
+		return
+	}
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	// the sender ids in the body decide the permissions, so an event is
+	// only trusted when signed
+	secret := viper.GetString("bot.secret")
+	if secret == "" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "bot.secret is not set, events are refused"})
+		return
+	}
+	if !validSignature(secret, body, c.GetHeader("X-Signature")) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid signature"})
+		return
+	}
+	var event OneBotEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if event.PostType != "message" {
+		c.Status(http.StatusNoContent)
+		return
+	}
+	reply := bot.Handle(database.GetDB(), event.Message)
+	if reply == "" {
+		c.Status(http.StatusNoContent)
+		return
+	}
+	c.JSON(http.StatusOK, OneBotReply{Reply: reply, AutoEscape: true})
+}
+
+// validSignature checks signature is the X-Signature OneBot sends for body,
+// sha1= and the hex HMAC-SHA1 of it with secret.
+func validSignature(secret string, body []byte, signature string) bool {
+	mac := hmac.New(sha1.New, []byte(secret))
+	mac.Write(body)
+	expected := "sha1=" + hex.EncodeToString(mac.Sum(nil))
+	return hmac.Equal([]byte(signature), []byte(expected))
+}
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+)
+
+func sign(secret, body string) string {
+	mac := hmac.New(sha1.New, []byte(secret))
+	mac.Write([]byte(body))
+	return "sha1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestValidSignature(t *testing.T) {
+	body := `{"post_type":"message","user_id":42,"raw_message":"/kick bob"}`
+	tests := []struct {
+		name      string
+		signature string
+		want      bool
+	}{
+		{"signed", sign("s3cret", body), true},
+		{"other secret", sign("other", body), false},
+		{"other body", sign("s3cret", body+" "), false},
+		{"no prefix", strings.TrimPrefix(sign("s3cret", body), "sha1="), false},
+		{"empty", "", false},
+	}
+	for _, tt := range tests {
+		if got := validSignature("s3cret", []byte(body), tt.signature); got != tt.want {
+			t.Errorf("%s: validSignature = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestOnebotEventRefusesUnsigned(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	viper.Set("bot.enable", true)
+	defer viper.Set("bot.enable", false)
+	defer viper.Set("bot.secret", "")
+	body := `{"post_type":"notice"}`
+	tests := []struct {
+		name      string
+		secret    string
+		signature string
+		want      int
+	}{
+		{"no secret", "", "", http.StatusForbidden},
+		{"no secret signed", "", sign("", body), http.StatusForbidden},
+		{"unsigned", "s3cret", "", http.StatusUnauthorized},
+		{"bad signature", "s3cret", sign("other", body), http.StatusUnauthorized},
+		{"signed", "s3cret", sign("s3cret", body), http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		viper.Set("bot.secret", tt.secret)
+		r := gin.New()
+		r.POST("/api/bot/onebot", onebotEvent)
+		req := httptest.NewRequest(http.MethodPost, "/api/bot/onebot", strings.NewReader(body))
+		req.Header.Set("X-Signature", tt.signature)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tt.want {
+			t.Errorf("%s: status %d, want %d", tt.name, w.Code, tt.want)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/internal/task"
+	"github.com/zaigie/palworld-server-tool/internal/tool"
+	"github.com/zaigie/palworld-server-tool/service"
+)
+
+// listCommunityEvents godoc
+//
+//	@Summary		List Community Events
+//	@Description	List recurring community events
+//	@Tags			Event
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Success		200	{object}	[]database.CommunityEvent
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Router			/api/community_event [get]
+func listCommunityEvents(c *gin.Context) {
+	events, err := service.ListCommunityEvents(database.GetDB())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, events)
+}
+
+// addCommunityEvent godoc
+//
+//	@Summary		Add Community Event
+//	@Description	Add a recurring community event, weekdays use 0 for Sunday and an empty list means every day. start_actions and end_actions are world actions like set_time, their commands must be set in admin.commands
+//	@Tags			Event
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			event	body		database.CommunityEvent	true	"Community Event"
+//	@Success		200		{object}	database.CommunityEvent
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Router			/api/community_event [post]
+func addCommunityEvent(c *gin.Context) {
+	var event database.CommunityEvent
+	if err := c.ShouldBindJSON(&event); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateCommunityEvent(event); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	event.Id = uuid.New().String()
+	event.Active = false
+	event.Announced = false
+	event.Previous = nil
+	if err := service.PutCommunityEvent(database.GetDB(), event); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, event)
+}
+
+// putCommunityEvent godoc
+//
+//	@Summary		Put Community Event
+//	@Description	Update a community event, the running state is kept
+//	@Tags			Event
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			id		path		string					true	"Event ID"
+//	@Param			event	body		database.CommunityEvent	true	"Community Event"
+//	@Success		200		{object}	SuccessResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Router			/api/community_event/{id} [put]
+func putCommunityEvent(c *gin.Context) {
+	var event database.CommunityEvent
+	if err := c.ShouldBindJSON(&event); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateCommunityEvent(event); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	existing, err := service.GetCommunityEvent(database.GetDB(), c.Param("id"))
+	if err != nil {
+		if err == service.ErrNoRecord {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	event.Id = existing.Id
+	event.Active = existing.Active
+	event.Announced = existing.Announced
+	event.Previous = existing.Previous
+	if err := service.PutCommunityEvent(database.GetDB(), event); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// removeCommunityEvent godoc
+//
+//	@Summary		Remove Community Event
+//	@Description	Remove a community event, an active event should be disabled first so its settings are reverted
+//	@Tags			Event
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			id	path		string	true	"Event ID"
+//	@Success		200	{object}	SuccessResponse
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Router			/api/community_event/{id} [delete]
+func removeCommunityEvent(c *gin.Context) {
+	event, err := service.GetCommunityEvent(database.GetDB(), c.Param("id"))
+	if err != nil && err != service.ErrNoRecord {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if event.Active {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "event is active, disable it first"})
+		return
+	}
+	if err := service.RemoveCommunityEvent(database.GetDB(), c.Param("id")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+func validateCommunityEvent(event database.CommunityEvent) error {
+	if event.Name == "" {
+		return errors.New("event name cannot be empty")
+	}
+	if _, err := time.Parse("15:04", event.StartTime); err != nil {
+		return errors.New("start_time must be in HH:MM format")
+	}
+	if event.Duration <= 0 {
+		return errors.New("duration must be positive minutes")
+	}
+	for _, weekday := range event.Weekdays {
+		if weekday < 0 || weekday > 6 {
+			return errors.New("weekdays must be between 0 (Sunday) and 6 (Saturday)")
+		}
+	}
+	for _, action := range append(event.StartActions, event.EndActions...) {
+		if _, err := task.WorldAdminAction(action, ""); err != nil {
+			return err
+		}
+	}
+	return tool.ValidateSettings(event.Overrides)
+}
@@ -0,0 +1,101 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zaigie/palworld-server-tool/internal/crash"
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/internal/logger"
+	"github.com/zaigie/palworld-server-tool/service"
+)
+
+// recoverPanic answers a request whose handler panicked with the crash
+// report written for it.
+func recoverPanic(c *gin.Context, recovered any) {
+	name, err := crash.Write(fmt.Sprintf("request %s %s", c.Request.Method, c.Request.URL.Path), recovered, debug.Stack())
+	if err != nil {
+		logger.Errorf("Write crash report fail: %v\n", err)
+	}
+	c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "internal server error", "crash_report": name})
+}
+
+// listCrashReports godoc
+//
+//	@Summary		List Crash Reports
+//	@Description	List the crash reports written when something panicked, newest first
+//	@Tags			Debug
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Success		200	{array}		crash.Report
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Router			/api/debug/crash-reports [get]
+func listCrashReports(c *gin.Context) {
+	reports, err := crash.List()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, reports)
+}
+
+// downloadCrashReport godoc
+//
+//	@Summary		Download Crash Report
+//	@Description	Download a crash report zip with the stack traces, recent logs, the config without secrets and database stats, to attach to a bug report
+//	@Tags			Debug
+//	@Produce		application/zip
+//	@Security		ApiKeyAuth
+//	@Param			name	path		string	true	"Report name"
+//	@Success		200		{file}		"Report"
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Router			/api/debug/crash-reports/{name} [get]
+func downloadCrashReport(c *gin.Context) {
+	path, err := crash.Path(c.Param("name"))
+	if err != nil {
+		if err == crash.ErrReportNotFound {
+			c.JSON(http.StatusNotFound, gin.H{})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.FileAttachment(path, c.Param("name"))
+}
+
+// getStorageStats godoc
+//
+//	@Summary		Get Storage Stats
+//	@Description	Get the keys, size and average record size of every bucket of the database, the largest first, with their growth since the first sample after since and the samples taken every debug.storage_sample_interval
+//	@Tags			Debug
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			since	query		string	false	"start of the growth, unix seconds or RFC3339, default 7 days ago"
+//	@Success		200		{object}	service.StorageStats
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Router			/api/debug/storage [get]
+func getStorageStats(c *gin.Context) {
+	since := time.Now().AddDate(0, 0, -7)
+	if s := c.Query("since"); s != "" {
+		var err error
+		if since, err = parseSince(s); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid since"})
+			return
+		}
+	}
+	stats, err := service.GetStorageStats(database.GetDB(), since)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
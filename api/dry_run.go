@@ -0,0 +1,79 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zaigie/palworld-server-tool/service"
+)
+
+// DryRunResponse is what a request sent with ?dry_run=true would change,
+// nothing of it is committed.
+type DryRunResponse struct {
+	DryRun  bool             `json:"dry_run"`
+	Changes []service.Change `json:"changes"`
+}
+
+// dryRunRoutes are the mutating routes that can tell what they would change.
+var dryRunRoutes = map[string]bool{
+	"PUT /api/player":                          true,
+	"PUT /api/guild":                           true,
+	"POST /api/player/:player_uid/kick":        true,
+	"POST /api/player/:player_uid/ban":         true,
+	"POST /api/player/:player_uid/unban":       true,
+	"POST /api/player/:player_uid/teleport":    true,
+	"POST /api/player/:player_uid/bring":       true,
+	"POST /api/player/:player_uid/give_item":   true,
+	"POST /api/player/:player_uid/give_exp":    true,
+	"POST /api/player/:player_uid/unlock_tech": true,
+	"POST /api/player/bulk":                    true,
+	"POST /api/ipban":                          true,
+	"DELETE /api/ipban":                        true,
+	"POST /api/whitelist":                      true,
+	"DELETE /api/whitelist":                    true,
+	"PUT /api/whitelist":                       true,
+	"POST /api/world/:action":                  true,
+	"DELETE /api/orphans":                      true,
+	"POST /api/server/settings/preset/:name":   true,
+	"POST /api/setup":                          true,
+}
+
+func isDryRun(c *gin.Context) bool {
+	dryRun, _ := strconv.ParseBool(c.Query("dry_run"))
+	return dryRun
+}
+
+// dryRunMiddleware turns away dry runs of the mutating routes that can't do
+// one, so ?dry_run=true never changes anything.
+func dryRunMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodGet && isDryRun(c) && !dryRunRoutes[c.Request.Method+" "+c.FullPath()] {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "dry_run is not supported by this endpoint"})
+			return
+		}
+		c.Next()
+	}
+}
+
+func writeDryRun(c *gin.Context, changes []service.Change, err error) {
+	if err != nil {
+		switch err {
+		case service.ErrNoRecord:
+			c.JSON(http.StatusNotFound, gin.H{})
+		case service.ErrRevisionConflict:
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			badRequest(c, err)
+		}
+		return
+	}
+	c.JSON(http.StatusOK, DryRunResponse{DryRun: true, Changes: changes})
+}
+
+// commandChange is a call a request would make to the game server.
+func commandChange(command string, args any) service.Change {
+	v, _ := json.Marshal(args)
+	return service.Change{Action: service.ChangeCommand, Target: "server", Key: command, After: v}
+}
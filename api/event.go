@@ -0,0 +1,147 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/zaigie/palworld-server-tool/internal/bus"
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/internal/filter"
+	"github.com/zaigie/palworld-server-tool/internal/logger"
+	"github.com/zaigie/palworld-server-tool/service"
+)
+
+var upgrader = websocket.Upgrader{
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// listEvents godoc
+//
+//	@Summary		List Events
+//	@Description	List stored events newest first. q is a filter expression on id, type, time, player_uid, admin_player_uid, message and the data keys, like type=player.afk_kick AND nickname~bob AND time<24h. Comparisons are joined with AND, OR, NOT and parentheses, operators are = != > >= < <= and ~ for contains, time compared to a duration like 24h compares its age
+//	@Tags			Event
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			q		query		string	false	"filter expression"
+//	@Param			type	query		string	false	"event type, a trailing * matches by prefix"
+//	@Param			limit	query		int		false	"max number of events, default 100"
+//	@Success		200		{object}	[]database.Event
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Router			/api/events [get]
+func listEvents(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+		return
+	}
+	expr, err := eventExpr(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	events, err := service.ListEvents(database.GetDB(), service.EventFilter{
+		Type:  c.Query("type"),
+		Expr:  expr,
+		Limit: limit,
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, events)
+}
+
+// eventExpr parses the q filter expression, nil without one.
+func eventExpr(c *gin.Context) (filter.Expr, error) {
+	q := c.Query("q")
+	if q == "" {
+		return nil, nil
+	}
+	return filter.Parse(q, nil)
+}
+
+// streamEvents godoc
+//
+//	@Summary		Stream Events
+//	@Description	Stream bus events over a WebSocket as JSON, including the ones not stored like player.join, player.leave and sync.done
+//	@Tags			Event
+//	@Param			types	query	string	false	"comma separated event types, a trailing * matches by prefix"
+//	@Param			q		query	string	false	"filter expression like for /api/events"
+//	@Success		101
+//	@Failure		400	{object}	ErrorResponse
+//	@Security		ApiKeyAuth
+//	@Router			/api/events/ws [get]
+func streamEvents(c *gin.Context) {
+	var patterns []string
+	if types := c.Query("types"); types != "" {
+		patterns = strings.Split(types, ",")
+	}
+	expr, err := eventExpr(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	queue := make(chan []database.Event, 16)
+	unsubscribe := bus.Subscribe("websocket "+c.ClientIP(), patterns, func(events []database.Event) {
+		if expr != nil {
+			matched := make([]database.Event, 0, len(events))
+			for _, event := range events {
+				if expr.Match(service.EventRecord(event)) {
+					matched = append(matched, event)
+				}
+			}
+			if len(matched) == 0 {
+				return
+			}
+			events = matched
+		}
+		select {
+		case queue <- events:
+		default:
+		}
+	})
+	defer unsubscribe()
+
+	// the client sends nothing, reading only notices it went away
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ping := time.NewTicker(30 * time.Second)
+	defer ping.Stop()
+	for {
+		select {
+		case events := <-queue:
+			for _, event := range events {
+				if err := conn.WriteJSON(event); err != nil {
+					logger.Warnf("Event stream to %s closed, %s\n", c.ClientIP(), err)
+					return
+				}
+			}
+		case <-ping.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(5*time.Second)); err != nil {
+				return
+			}
+		case <-closed:
+			return
+		}
+	}
+}
@@ -0,0 +1,246 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/internal/logger"
+	"github.com/zaigie/palworld-server-tool/internal/tool"
+	"github.com/zaigie/palworld-server-tool/service"
+)
+
+type exportColumn[T any] struct {
+	name  string
+	value func(T) any
+}
+
+var playerExportColumns = []exportColumn[database.TersePlayer]{
+	{"player_uid", func(p database.TersePlayer) any { return p.PlayerUid }},
+	{"nickname", func(p database.TersePlayer) any { return p.Nickname }},
+	{"steam_id", func(p database.TersePlayer) any { return p.SteamId }},
+	{"level", func(p database.TersePlayer) any { return p.Level }},
+	{"exp", func(p database.TersePlayer) any { return p.Exp }},
+	{"hp", func(p database.TersePlayer) any { return p.Hp / 1000 }},
+	{"max_hp", func(p database.TersePlayer) any { return p.MaxHp / 1000 }},
+	{"ip", func(p database.TersePlayer) any { return p.Ip }},
+	{"location_x", func(p database.TersePlayer) any { return p.LocationX }},
+	{"location_y", func(p database.TersePlayer) any { return p.LocationY }},
+	{"last_online", func(p database.TersePlayer) any { return p.LastOnline }},
+}
+
+var guildExportColumns = []exportColumn[database.Guild]{
+	{"name", func(g database.Guild) any { return g.Name }},
+	{"admin_player_uid", func(g database.Guild) any { return g.AdminPlayerUid }},
+	{"base_camp_level", func(g database.Guild) any { return g.BaseCampLevel }},
+	{"members", func(g database.Guild) any { return len(g.Players) }},
+	{"base_camps", func(g database.Guild) any { return len(g.BaseCamp) }},
+	{"member_names", func(g database.Guild) any {
+		names := make([]string, 0, len(g.Players))
+		for _, p := range g.Players {
+			names = append(names, p.Nickname)
+		}
+		return strings.Join(names, ", ")
+	}},
+}
+
+var whitelistExportColumns = []exportColumn[database.PlayerW]{
+	{"name", func(p database.PlayerW) any { return p.Name }},
+	{"steam_id", func(p database.PlayerW) any { return p.SteamID }},
+	{"player_uid", func(p database.PlayerW) any { return p.PlayerUID }},
+}
+
+// exportSlice ranges over items for writeExport.
+func exportSlice[T any](items []T) func(func(T) error) error {
+	return func(fn func(T) error) error {
+		for _, item := range items {
+			if err := fn(item); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// writeExport writes the items each yields in the format and columns of the
+// query, all columns when none are given, a row at a time as they come. An
+// error before anything was sent is a 400, past that the file is cut short.
+func writeExport[T any](c *gin.Context, name string, all []exportColumn[T], each func(func(T) error) error) {
+	columns := all
+	if query := c.Query("columns"); query != "" {
+		columns = nil
+		for _, col := range strings.Split(query, ",") {
+			col = strings.TrimSpace(col)
+			found := false
+			for _, column := range all {
+				if column.name == col {
+					columns = append(columns, column)
+					found = true
+					break
+				}
+			}
+			if !found {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("unknown column %s", col)})
+				return
+			}
+		}
+	}
+	header := make([]string, len(columns))
+	for i, column := range columns {
+		header[i] = column.name
+	}
+
+	format := c.DefaultQuery("format", "csv")
+	var contentType string
+	switch format {
+	case "csv":
+		contentType = "text/csv"
+	case "xlsx":
+		contentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be csv or xlsx"})
+		return
+	}
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s.%s", name, format))
+	c.Status(http.StatusOK)
+	var w tool.RowWriter
+	var err error
+	if format == "csv" {
+		w, err = tool.NewCSVWriter(c.Writer, header)
+	} else {
+		w, err = tool.NewXLSXWriter(c.Writer, name, header)
+	}
+	if err == nil {
+		row := make([]any, len(columns))
+		err = each(func(item T) error {
+			for i, column := range columns {
+				row[i] = column.value(item)
+			}
+			return w.WriteRow(row)
+		})
+	}
+	if err == nil {
+		err = w.Close()
+	}
+	if err != nil {
+		logger.Warnf("Export %s fail, %v\n", name, err)
+		if c.Request.Context().Err() == nil && !c.Writer.Written() {
+			c.Header("Content-Type", "")
+			c.Header("Content-Disposition", "")
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+	}
+}
+
+// exportPlayers godoc
+//
+//	@Summary		Export Players
+//	@Description	Export players as CSV or Excel, with the chosen columns of player_uid, nickname, steam_id, level, exp, hp, max_hp, ip, location_x, location_y and last_online
+//	@Tags			Player
+//	@Produce		text/csv
+//	@Produce		application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+//	@Security		ApiKeyAuth
+//	@Param			format				query		string	false	"Format"	Enums(csv, xlsx)	default(csv)
+//	@Param			columns				query		string	false	"Comma separated columns, all by default"
+//	@Param			min_level			query		int		false	"Minimum level"
+//	@Param			max_level			query		int		false	"Maximum level"
+//	@Param			online_within_days	query		int		false	"Only players online within the days"
+//	@Success		200					{file}		file
+//	@Failure		400					{object}	ErrorResponse
+//	@Failure		401					{object}	ErrorResponse
+//	@Router			/api/player/export [get]
+func exportPlayers(c *gin.Context) {
+	filters := map[string]int{}
+	for _, key := range []string{"min_level", "max_level", "online_within_days"} {
+		if c.Query(key) == "" {
+			continue
+		}
+		v, err := strconv.Atoi(c.Query(key))
+		if err != nil || v < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid %s", key)})
+			return
+		}
+		filters[key] = v
+	}
+	writeExport(c, "players", playerExportColumns, func(fn func(database.TersePlayer) error) error {
+		return service.StreamPlayers(c.Request.Context(), database.GetDB(), func(p database.TersePlayer) error {
+			if v, ok := filters["min_level"]; ok && int(p.Level) < v {
+				return nil
+			}
+			if v, ok := filters["max_level"]; ok && int(p.Level) > v {
+				return nil
+			}
+			if v, ok := filters["online_within_days"]; ok && time.Since(p.LastOnline) > time.Duration(v)*24*time.Hour {
+				return nil
+			}
+			return fn(p)
+		})
+	})
+}
+
+// exportGuilds godoc
+//
+//	@Summary		Export Guilds
+//	@Description	Export guilds as CSV or Excel, with the chosen columns of name, admin_player_uid, base_camp_level, members, base_camps and member_names
+//	@Tags			Guild
+//	@Produce		text/csv
+//	@Produce		application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+//	@Security		ApiKeyAuth
+//	@Param			format		query		string	false	"Format"	Enums(csv, xlsx)	default(csv)
+//	@Param			columns		query		string	false	"Comma separated columns, all by default"
+//	@Param			min_members	query		int		false	"Minimum members"
+//	@Success		200			{file}		file
+//	@Failure		400			{object}	ErrorResponse
+//	@Failure		401			{object}	ErrorResponse
+//	@Router			/api/guild/export [get]
+func exportGuilds(c *gin.Context) {
+	minMembers, err := strconv.Atoi(c.DefaultQuery("min_members", "0"))
+	if err != nil || minMembers < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid min_members"})
+		return
+	}
+	guilds, err := service.ListGuilds(database.GetDB())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	writeExport(c, "guilds", guildExportColumns, func(fn func(database.Guild) error) error {
+		for _, g := range guilds {
+			if len(g.Players) < minMembers {
+				continue
+			}
+			if err := fn(g); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// exportWhitelist godoc
+//
+//	@Summary		Export White List
+//	@Description	Export the whitelist as CSV or Excel, with the chosen columns of name, steam_id and player_uid
+//	@Tags			Player
+//	@Produce		text/csv
+//	@Produce		application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+//	@Security		ApiKeyAuth
+//	@Param			format	query		string	false	"Format"	Enums(csv, xlsx)	default(csv)
+//	@Param			columns	query		string	false	"Comma separated columns, all by default"
+//	@Success		200		{file}		file
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Router			/api/whitelist/export [get]
+func exportWhitelist(c *gin.Context) {
+	players, err := service.ListWhitelist(database.GetDB())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	writeExport(c, "whitelist", whitelistExportColumns, exportSlice(players))
+}
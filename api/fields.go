@@ -0,0 +1,102 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// projection encodes only the selected json fields of a struct, so clients
+// needing two fields don't pay for marshaling pals and items.
+type projection struct {
+	names []string
+	index [][]int
+}
+
+// jsonFields caches the json name to field index of each struct type.
+var jsonFields sync.Map
+
+func fieldsOf(t reflect.Type) map[string][]int {
+	if v, ok := jsonFields.Load(t); ok {
+		return v.(map[string][]int)
+	}
+	fields := make(map[string][]int)
+	for _, f := range reflect.VisibleFields(t) {
+		if f.Anonymous || !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		// the shallower field wins like in encoding/json
+		if index, ok := fields[name]; ok && len(index) <= len(f.Index) {
+			continue
+		}
+		fields[name] = f.Index
+	}
+	jsonFields.Store(t, fields)
+	return fields
+}
+
+// newProjection parses a comma separated fields list for values of type t,
+// an empty list gives a nil projection that leaves values as they are.
+func newProjection(t reflect.Type, list string) (*projection, error) {
+	if list == "" {
+		return nil, nil
+	}
+	fields := fieldsOf(t)
+	p := &projection{}
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		index, ok := fields[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown field %s", name)
+		}
+		p.names = append(p.names, name)
+		p.index = append(p.index, index)
+	}
+	return p, nil
+}
+
+// apply wraps v so it marshals to the projected fields only.
+func (p *projection) apply(v any) any {
+	if p == nil {
+		return v
+	}
+	return projected{p, reflect.ValueOf(v)}
+}
+
+type projected struct {
+	p *projection
+	v reflect.Value
+}
+
+func (pv projected) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, name := range pv.p.names {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(name)
+		buf.Write(key)
+		buf.WriteByte(':')
+		value, err := json.Marshal(pv.v.FieldByIndex(pv.p.index[i]).Interface())
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
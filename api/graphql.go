@@ -0,0 +1,229 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/internal/graphql"
+	"github.com/zaigie/palworld-server-tool/service"
+)
+
+type GraphqlRequest struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+var schema = &graphql.Schema{
+	MaxDepth:  8,
+	MaxFields: 100000,
+	Query: map[string]graphql.Resolver{
+		"players": func(_ map[string]any, args map[string]any) (any, error) {
+			orderBy, err := graphql.StringArg(args, "order_by", "")
+			if err != nil {
+				return nil, err
+			}
+			desc, err := graphql.BoolArg(args, "desc", false)
+			if err != nil {
+				return nil, err
+			}
+			limit, err := graphql.IntArg(args, "limit", 0)
+			if err != nil {
+				return nil, err
+			}
+			players, err := service.ListPlayers(database.GetDB())
+			if err != nil {
+				return nil, err
+			}
+			switch orderBy {
+			case "level":
+				sort.SliceStable(players, func(i, j int) bool { return (players[i].Level < players[j].Level) != desc })
+			case "last_online":
+				sort.SliceStable(players, func(i, j int) bool { return players[i].LastOnline.Before(players[j].LastOnline) != desc })
+			}
+			if limit > 0 && limit < len(players) {
+				players = players[:limit]
+			}
+			return graphql.Object("Player", players)
+		},
+		"player": func(_ map[string]any, args map[string]any) (any, error) {
+			uid, err := graphql.StringArg(args, "player_uid", "")
+			if err != nil {
+				return nil, err
+			}
+			return graphqlPlayer(uid)
+		},
+		"guilds": func(_ map[string]any, args map[string]any) (any, error) {
+			guilds, err := service.ListGuilds(database.GetDB())
+			if err != nil {
+				return nil, err
+			}
+			v, err := graphql.Object("Guild", guilds)
+			if err != nil {
+				return nil, err
+			}
+			tagGuild(v)
+			return v, nil
+		},
+		"guild": func(_ map[string]any, args map[string]any) (any, error) {
+			uid, err := graphql.StringArg(args, "player_uid", "")
+			if err != nil {
+				return nil, err
+			}
+			return graphqlGuildOf(uid)
+		},
+		"events": func(_ map[string]any, args map[string]any) (any, error) {
+			return graphqlEvents(args, "")
+		},
+	},
+	Types: map[string]map[string]graphql.Resolver{
+		"Player": {
+			"pals": func(parent map[string]any, _ map[string]any) (any, error) {
+				return playerDetail(parent, "pals")
+			},
+			"items": func(parent map[string]any, _ map[string]any) (any, error) {
+				return playerDetail(parent, "items")
+			},
+			"guild": func(parent map[string]any, _ map[string]any) (any, error) {
+				uid, _ := parent["player_uid"].(string)
+				return graphqlGuildOf(uid)
+			},
+			"events": func(parent map[string]any, args map[string]any) (any, error) {
+				uid, _ := parent["player_uid"].(string)
+				return graphqlEvents(args, uid)
+			},
+		},
+		"Guild": {
+			"members": func(parent map[string]any, _ map[string]any) (any, error) {
+				players, _ := parent["players"].([]any)
+				members := make([]any, 0, len(players))
+				for _, p := range players {
+					m, _ := p.(map[string]any)
+					uid, _ := m["player_uid"].(string)
+					player, err := graphqlPlayer(uid)
+					if err != nil {
+						return nil, err
+					}
+					if player != nil {
+						members = append(members, player)
+					}
+				}
+				return members, nil
+			},
+		},
+	},
+}
+
+// graphqlPlayer returns the full player, nil when there is none.
+func graphqlPlayer(uid string) (any, error) {
+	player, err := service.GetPlayer(database.GetDB(), uid)
+	if err != nil {
+		if err == service.ErrNoRecord {
+			return nil, nil
+		}
+		return nil, err
+	}
+	v, err := graphql.Object("Player", player)
+	if err != nil {
+		return nil, err
+	}
+	m := v.(map[string]any)
+	graphql.Tag("Pal", m["pals"])
+	graphql.Tag("Items", m["items"])
+	return m, nil
+}
+
+// playerDetail reads pals or items, loading the full player when the parent
+// came from a player list.
+func playerDetail(parent map[string]any, key string) (any, error) {
+	if v, ok := parent[key]; ok {
+		return v, nil
+	}
+	uid, _ := parent["player_uid"].(string)
+	player, err := graphqlPlayer(uid)
+	if err != nil || player == nil {
+		return nil, err
+	}
+	return player.(map[string]any)[key], nil
+}
+
+func graphqlGuildOf(playerUid string) (any, error) {
+	guild, err := service.GetGuild(database.GetDB(), playerUid)
+	if err != nil {
+		if err == service.ErrNoRecord {
+			return nil, nil
+		}
+		return nil, err
+	}
+	v, err := graphql.Object("Guild", guild)
+	if err != nil {
+		return nil, err
+	}
+	tagGuild(v)
+	return v, nil
+}
+
+// tagGuild types the nested objects of guilds.
+func tagGuild(v any) {
+	guilds, ok := v.([]any)
+	if !ok {
+		guilds = []any{v}
+	}
+	for _, g := range guilds {
+		if m, ok := g.(map[string]any); ok {
+			graphql.Tag("GuildPlayer", m["players"])
+			graphql.Tag("BaseCamp", m["base_camp"])
+		}
+	}
+}
+
+func graphqlEvents(args map[string]any, playerUid string) (any, error) {
+	eventType, err := graphql.StringArg(args, "type", "")
+	if err != nil {
+		return nil, err
+	}
+	if playerUid == "" {
+		if playerUid, err = graphql.StringArg(args, "player_uid", ""); err != nil {
+			return nil, err
+		}
+	}
+	limit, err := graphql.IntArg(args, "limit", 100)
+	if err != nil {
+		return nil, err
+	}
+	events, err := service.ListEvents(database.GetDB(), service.EventFilter{
+		Type:      eventType,
+		PlayerUid: playerUid,
+		Limit:     limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return graphql.Object("Event", events)
+}
+
+// graphqlQuery godoc
+//
+//	@Summary		GraphQL
+//	@Description	Query players, pals, items, guilds and events selecting only the needed fields. Root fields: players(order_by, desc, limit), player(player_uid), guilds, guild(player_uid), events(type, player_uid, limit). Players have guild and events, guilds have members. Queries only, no fragments or directives, nested at most 8 levels and resolving at most 100000 fields. Enabled by web.graphql
+//	@Tags			GraphQL
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			request	body		GraphqlRequest	true	"GraphQL Request"
+//	@Success		200		{object}	graphql.Response
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Router			/api/graphql [post]
+func graphqlQuery(c *gin.Context) {
+	var req GraphqlRequest
+	if c.Request.Method == http.MethodGet {
+		req.Query = c.Query("query")
+	} else if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, schema.Execute(req.Query, req.Variables))
+}
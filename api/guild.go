@@ -1,29 +1,40 @@
 package api
 
 import (
+	"bytes"
+	"context"
+	"encoding/csv"
 	"net/http"
 	"sort"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+	"github.com/zaigie/palworld-server-tool/internal/bus"
 	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/internal/tracing"
 	"github.com/zaigie/palworld-server-tool/service"
 )
 
 // putGuilds godoc
 //
 //	@Summary		Put Guilds
-//	@Description	Put Guilds Only For SavSync
+//	@Description	Put Guilds Only For SavSync. Mode merge adds or updates the guilds sent, replace also deletes the guilds left out and reports them disbanded
 //	@Tags			Guild
 //	@Accept			json
 //	@Produce		json
 //
 //	@Security		ApiKeyAuth
 //
-//	@Param			guilds	body		[]database.Guild	true	"Guilds"
+//	@Param			guilds			body		[]database.Guild	true	"Guilds"
+//	@Param			mode			query		string				false	"merge, the default, or replace"	enum(merge,replace)
+//	@Param			dry_run			query		bool				false	"Only return the records that would be added, overwritten or removed, as a DryRunResponse"
+//	@Param			X-Sync-Batch	header		int					false	"Sync batch journaling the guilds"
 //
-//	@Success		200		{object}	SuccessResponse
-//	@Failure		401		{object}	ErrorResponse
-//	@Failure		400		{object}	ErrorResponse
+//	@Success		200				{object}	SuccessResponse
+//	@Failure		401				{object}	ErrorResponse
+//	@Failure		400				{object}	ErrorResponse
 //	@Router			/api/guild [put]
 func putGuilds(c *gin.Context) {
 	var guilds []database.Guild
@@ -31,13 +42,59 @@ func putGuilds(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	if err := service.PutGuilds(database.GetDB(), guilds); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	mode := c.DefaultQuery("mode", service.GuildsMerge)
+	if isDryRun(c) {
+		changes, err := service.DryRunPutGuilds(database.GetDB(), guilds, mode, raidThreshold())
+		writeDryRun(c, changes, err)
+		return
+	}
+	err := service.JournalStep(database.GetDB(), syncBatch(c), service.SyncStepGuilds, guilds, len(guilds), func(j *service.StepJournal) error {
+		return applyGuilds(c.Request.Context(), guilds, mode, j)
+	})
+	if err != nil {
+		badRequest(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"success": true})
 }
 
+func raidThreshold() service.RaidThreshold {
+	return service.RaidThreshold{
+		Structures: viper.GetInt("manage.base_raid_structures"),
+		HpPercent:  viper.GetFloat64("manage.base_raid_hp_percent"),
+	}
+}
+
+// applyGuilds writes the guilds of a save sync by mode with what derives
+// from them, journaling the guilds in j.
+func applyGuilds(ctx context.Context, guilds []database.Guild, mode string, j *service.StepJournal) error {
+	var events []database.Event
+	if err := tracing.Do(ctx, "service.PutGuilds", func() (err error) {
+		events, err = service.PutGuilds(database.GetDB(), guilds, mode, raidThreshold(), j)
+		return err
+	}); err != nil {
+		return err
+	}
+	bus.Publish(events...)
+	if err := tracing.Do(ctx, "service.TrackGuildStorage", func() (err error) {
+		events, err = service.TrackGuildStorage(database.GetDB(), guilds,
+			viper.GetInt64("manage.storage_withdrawal"),
+			time.Duration(viper.GetInt("manage.storage_keep_days"))*24*time.Hour)
+		return err
+	}); err != nil {
+		return err
+	}
+	bus.Publish(events...)
+	if err := tracing.Do(ctx, "service.RecordGuildStats", func() error {
+		return service.RecordGuildStats(database.GetDB())
+	}); err != nil {
+		return err
+	}
+	return tracing.Do(ctx, "service.RecordDailySnapshot", func() error {
+		return service.RecordDailySnapshot(database.GetDB())
+	})
+}
+
 // listGuilds godoc
 //
 //	@Summary		List Guilds
@@ -45,10 +102,18 @@ func putGuilds(c *gin.Context) {
 //	@Tags			Guild
 //	@Accept			json
 //	@Produce		json
-//	@Success		200	{object}	[]database.Guild
-//	@Failure		400	{object}	ErrorResponse
+//	@Param			location	query		bool	false	"add base_camp_locations computed from the base camp positions"
+//	@Param			precision	query		int		false	"decimals of location values"	default(2)			maximum(6)
+//	@Param			unit		query		string	false	"unit of distance_from_spawn"	Enums(m, cm, km)	default(m)
+//	@Success		200			{object}	[]database.Guild
+//	@Failure		400			{object}	ErrorResponse
 //	@Router			/api/guild [get]
 func listGuilds(c *gin.Context) {
+	l, err := locator(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	guilds, err := service.ListGuilds(database.GetDB())
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -58,7 +123,11 @@ func listGuilds(c *gin.Context) {
 	sort.Slice(guilds, func(i, j int) bool {
 		return guilds[i].BaseCampLevel > guilds[j].BaseCampLevel
 	})
-	c.JSON(http.StatusOK, guilds)
+	located := make([]locatedGuild, len(guilds))
+	for i, guild := range guilds {
+		located[i] = locateGuild(l, guild)
+	}
+	c.JSON(http.StatusOK, located)
 }
 
 // getGuild godoc
@@ -69,11 +138,19 @@ func listGuilds(c *gin.Context) {
 //	@Accept			json
 //	@Produce		json
 //	@Param			admin_player_uid	path		string	true	"Admin Player UID"
+//	@Param			location			query		bool	false	"add base_camp_locations computed from the base camp positions"
+//	@Param			precision			query		int		false	"decimals of location values"	default(2)			maximum(6)
+//	@Param			unit				query		string	false	"unit of distance_from_spawn"	Enums(m, cm, km)	default(m)
 //	@Success		200					{object}	database.Guild
 //	@Failure		400					{object}	ErrorResponse
 //	@Failure		404					{object}	EmptyResponse
 //	@Router			/api/guild/{admin_player_uid} [get]
 func getGuild(c *gin.Context) {
+	l, err := locator(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	guild, err := service.GetGuild(database.GetDB(), c.Param("admin_player_uid"))
 	if err != nil {
 		if err == service.ErrNoRecord {
@@ -83,5 +160,195 @@ func getGuild(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, guild)
+	c.JSON(http.StatusOK, locateGuild(l, guild))
+}
+
+// getGuildHistory godoc
+//
+//	@Summary		Get Guild History
+//	@Description	Get membership changes (guild.create, guild.disband, guild.join, guild.leave) of the guild of a player, newest first. Events are matched by the guild id, so they span admin changes
+//	@Tags			Guild
+//	@Accept			json
+//	@Produce		json
+//	@Param			admin_player_uid	path		string	true	"Admin Player UID"
+//	@Param			limit				query		int		false	"max number of events"
+//	@Success		200					{object}	[]database.Event
+//	@Failure		400					{object}	ErrorResponse
+//	@Router			/api/guild/{admin_player_uid}/history [get]
+func getGuildHistory(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+		return
+	}
+	filter := service.EventFilter{
+		Type:           "guild.*",
+		AdminPlayerUid: c.Param("admin_player_uid"),
+		Limit:          limit,
+	}
+	// the history of a guild goes by its id, kept when the admin changes,
+	// a disbanded guild is only found by its admin
+	guild, err := service.GetGuild(database.GetDB(), filter.AdminPlayerUid)
+	switch {
+	case err == nil:
+		filter.GuildId = guild.Id
+		filter.AdminPlayerUid = guild.AdminPlayerUid
+	case err != service.ErrNoRecord:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	events, err := service.ListEvents(database.GetDB(), filter)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, events)
+}
+
+// getGuildStats godoc
+//
+//	@Summary		Get Guild Stats
+//	@Description	Get member levels, pals, base camps and last activity of a guild, with daily snapshots from save syncs for growth over time
+//	@Tags			Guild
+//	@Accept			json
+//	@Produce		json
+//	@Param			admin_player_uid	path		string	true	"Admin Player UID"
+//	@Param			days				query		int		false	"days of history, default 30"
+//	@Success		200					{object}	service.GuildStatsReport
+//	@Failure		400					{object}	ErrorResponse
+//	@Failure		404					{object}	EmptyResponse
+//	@Router			/api/guild/{admin_player_uid}/stats [get]
+func getGuildStats(c *gin.Context) {
+	days, err := strconv.Atoi(c.DefaultQuery("days", "30"))
+	if err != nil || days < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid days"})
+		return
+	}
+	report, err := service.GetGuildStats(database.GetDB(), c.Param("admin_player_uid"), days)
+	if err != nil {
+		if err == service.ErrNoRecord {
+			c.JSON(http.StatusNotFound, gin.H{})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// listGuildStorage godoc
+//
+//	@Summary		List Guild Storage Changes
+//	@Description	List the item count changes in the chests of the base camps of a guild between save syncs, with the members online in between, to find who emptied a chest
+//	@Tags			Guild
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			admin_player_uid	path		string	true	"Admin Player UID"
+//	@Param			base_camp_id		query		string	false	"only the changes of this base camp"
+//	@Param			since				query		string	false	"unix seconds or RFC3339"
+//	@Param			until				query		string	false	"unix seconds or RFC3339"
+//	@Param			withdrawals			query		int		false	"only withdrawals of at least this many items"
+//	@Param			order_by			query		string	false	"newest first or the largest withdrawal first"	Enums(time, amount)	default(time)
+//	@Param			limit				query		int		false	"max number of changes"							default(100)
+//	@Success		200					{object}	[]database.StorageChange
+//	@Failure		400					{object}	ErrorResponse
+//	@Failure		401					{object}	ErrorResponse
+//	@Router			/api/guild/{admin_player_uid}/storage [get]
+func listGuildStorage(c *gin.Context) {
+	filter := service.StorageChangeFilter{
+		AdminPlayerUid: c.Param("admin_player_uid"),
+		BaseCampId:     c.Query("base_camp_id"),
+		OrderBy:        c.DefaultQuery("order_by", "time"),
+	}
+	if filter.OrderBy != "time" && filter.OrderBy != "amount" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "order_by must be time or amount"})
+		return
+	}
+	times := map[string]*time.Time{"since": &filter.StartTime, "until": &filter.EndTime}
+	for name, t := range times {
+		if s := c.Query(name); s != "" {
+			var err error
+			if *t, err = parseSince(s); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + name})
+				return
+			}
+		}
+	}
+	var err error
+	if filter.Withdrawals, err = strconv.ParseInt(c.DefaultQuery("withdrawals", "0"), 10, 64); err != nil || filter.Withdrawals < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid withdrawals"})
+		return
+	}
+	if filter.Limit, err = strconv.Atoi(c.DefaultQuery("limit", "100")); err != nil || filter.Limit < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+		return
+	}
+	changes, err := service.ListStorageChanges(database.GetDB(), filter)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, changes)
+}
+
+// listAbandonedBases godoc
+//
+//	@Summary		List Abandoned Bases
+//	@Description	List base camps of guilds whose members have all been offline for more than the given days, as JSON or a CSV of coordinates for manual demolition
+//	@Tags			Guild
+//	@Accept			json
+//	@Produce		json
+//	@Produce		text/csv
+//	@Security		ApiKeyAuth
+//	@Param			days	query		int		false	"Inactive days, default manage.abandoned_base_days"
+//	@Param			format	query		string	false	"Format"	Enums(json, csv)	default(json)
+//	@Success		200		{object}	[]service.AbandonedBase
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Router			/api/guild/abandoned [get]
+func listAbandonedBases(c *gin.Context) {
+	days := viper.GetInt("manage.abandoned_base_days")
+	if c.Query("days") != "" {
+		var err error
+		days, err = strconv.Atoi(c.Query("days"))
+		if err != nil || days < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid days"})
+			return
+		}
+	}
+	bases, err := service.ListAbandonedBases(database.GetDB(), days)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	switch c.DefaultQuery("format", "json") {
+	case "json":
+		c.JSON(http.StatusOK, bases)
+	case "csv":
+		var buf bytes.Buffer
+		w := csv.NewWriter(&buf)
+		w.Write([]string{"guild", "admin_player_uid", "base_camp_id", "location_x", "location_y", "structures", "last_online", "inactive_days"})
+		for _, base := range bases {
+			lastOnline := ""
+			if !base.LastOnline.IsZero() {
+				lastOnline = base.LastOnline.Format(time.RFC3339)
+			}
+			w.Write([]string{
+				base.Guild,
+				base.AdminPlayerUid,
+				base.BaseCampId,
+				strconv.FormatFloat(base.LocationX, 'f', 0, 64),
+				strconv.FormatFloat(base.LocationY, 'f', 0, 64),
+				strconv.Itoa(base.Structures),
+				lastOnline,
+				strconv.Itoa(base.InactiveDays),
+			})
+		}
+		w.Flush()
+		c.Header("Content-Disposition", "attachment; filename=abandoned_bases.csv")
+		c.Data(http.StatusOK, "text/csv", buf.Bytes())
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be json or csv"})
+	}
 }
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zaigie/palworld-server-tool/internal/bus"
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/service"
+)
+
+func TestSavSyncDisbandsGuilds(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := database.GetDB()
+	disbanded := make(chan database.Event, 8)
+	unsubscribe := bus.Subscribe("test", []string{service.EventGuildDisband}, func(events []database.Event) {
+		for _, event := range events {
+			disbanded <- event
+		}
+	})
+	defer unsubscribe()
+
+	r := gin.New()
+	r.PUT("/api/guild", putGuilds)
+	// a sync as sav_cli sends it, the guilds of the save in a batch
+	sync := func(guilds []database.Guild) database.SyncBatch {
+		batch, err := service.BeginSyncBatch(db, database.SyncBatch{Source: "test", SaveTime: time.Now()})
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := json.Marshal(guilds)
+		req := httptest.NewRequest(http.MethodPut, "/api/guild?mode=replace", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Sync-Batch", strconv.FormatUint(batch.Id, 10))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("put guilds: status %d, %s", w.Code, w.Body)
+		}
+		batch, err = service.FinishSyncBatch(db, batch.Id, nil, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return batch
+	}
+	guild := func(id, name, admin string) database.Guild {
+		return database.Guild{Id: id, Name: name, AdminPlayerUid: admin, Players: []*database.GuildPlayer{{PlayerUid: admin, Nickname: admin}}}
+	}
+
+	sync([]database.Guild{guild("77", "Wolves", "1001"), guild("78", "Bears", "1002")})
+	batch := sync([]database.Guild{guild("77", "Wolves", "1001")})
+
+	select {
+	case event := <-disbanded:
+		if id := event.Data["guild_id"]; id != "78" {
+			t.Errorf("disbanded guild %q, want 78", id)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no guild.disband for the guild left out of the save")
+	}
+	guilds, err := service.ListGuilds(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(guilds) != 1 || guilds[0].Id != "77" {
+		t.Errorf("guilds after the sync %+v, want only 77", guilds)
+	}
+	// a replay of the batch writes the guilds by the same mode
+	if mode, err := syncStepMode(db, batch.Id, service.SyncStepGuilds, service.GuildsMerge); err != nil || mode != service.GuildsReplace {
+		t.Errorf("journaled guilds mode %q, %v, want replace", mode, err)
+	}
+}
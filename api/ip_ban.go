@@ -0,0 +1,189 @@
+package api
+
+import (
+	"net/http"
+	"net/netip"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/internal/tool"
+	"github.com/zaigie/palworld-server-tool/service"
+)
+
+type IpBanRequest struct {
+	Ip string `json:"ip"`
+	// Reason is why the ban is added, or removed
+	Reason string `json:"reason"`
+	// ExpiresIn is the ban length in seconds, 0 bans for good
+	ExpiresIn int `json:"expires_in"`
+}
+
+// listIpBans godoc
+//
+//	@Summary		List IP Bans
+//	@Description	List the ip and CIDR bans that haven't expired, all unless a page is asked for. X-Total-Count is how many bans match q
+//	@Tags			Player
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			q			query		string	false	"part of the ip or the reason"
+//	@Param			order_by	query		string	false	"order by field"	Enums(ip, created_at, expires_at)	default(ip)
+//	@Param			desc		query		bool	false	"order by desc"
+//	@Param			page		query		int		false	"Page"										default(1)
+//	@Param			page_size	query		int		false	"Page Size, 100 when only page is given"	maximum(1000)
+//	@Success		200			{array}		database.IpBan
+//	@Header			200			{int}		X-Total-Count	"bans matching q"
+//	@Failure		400			{object}	ErrorResponse
+//	@Failure		401			{object}	ErrorResponse
+//	@Router			/api/ipban [get]
+func listIpBans(c *gin.Context) {
+	filter := service.IpBanFilter{
+		Query:   c.Query("q"),
+		OrderBy: c.Query("order_by"),
+		Desc:    c.Query("desc") == "true",
+	}
+	switch filter.OrderBy {
+	case "", "ip", "created_at", "expires_at":
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "order_by must be ip, created_at or expires_at"})
+		return
+	}
+	page, pageSize, err := pageQuery(c, 0)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	filter.Offset = (page - 1) * pageSize
+	filter.Limit = pageSize
+	bans, total, err := service.SearchIpBans(database.GetDB(), filter)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.Header("X-Total-Count", strconv.Itoa(total))
+	c.JSON(http.StatusOK, bans)
+}
+
+// countIpBans godoc
+//
+//	@Summary		Count IP Bans
+//	@Description	Count the bans that haven't expired matching q
+//	@Tags			Player
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			q	query		string	false	"part of the ip or the reason"
+//	@Success		200	{object}	CountResponse
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Router			/api/ipban/count [get]
+func countIpBans(c *gin.Context) {
+	_, total, err := service.SearchIpBans(database.GetDB(), service.IpBanFilter{Query: c.Query("q")})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, CountResponse{Count: total})
+}
+
+// addIpBan godoc
+//
+//	@Summary		Add IP Ban
+//	@Description	Ban an ip or a CIDR range, online players matching it are kicked at every player sync
+//	@Tags			Player
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			ban		body		IpBanRequest	true	"IP Ban"
+//	@Param			dry_run	query		bool			false	"Only return the ban that would be stored, as a DryRunResponse"
+//	@Success		200		{object}	database.IpBan
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Router			/api/ipban [post]
+func addIpBan(c *gin.Context) {
+	var req IpBanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	prefix, err := tool.ParseIpPrefix(req.Ip)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.ExpiresIn < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "expires_in must not be negative"})
+		return
+	}
+	ban := database.IpBan{
+		Ip:        ipBanKey(prefix),
+		Reason:    req.Reason,
+		CreatedAt: time.Now(),
+	}
+	if req.ExpiresIn > 0 {
+		ban.ExpiresAt = ban.CreatedAt.Add(time.Duration(req.ExpiresIn) * time.Second)
+	}
+	if isDryRun(c) {
+		changes, err := service.DryRunPutIpBan(database.GetDB(), ban)
+		writeDryRun(c, changes, err)
+		return
+	}
+	if err := service.PutIpBan(database.GetDB(), ban); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, ban)
+}
+
+// removeIpBan godoc
+//
+//	@Summary		Remove IP Ban
+//	@Description	Remove an ip or CIDR ban, it is kept in the history of removed entries to be restored
+//	@Tags			Player
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			ban		body		IpBanRequest	true	"IP Ban, ip and reason are used"
+//	@Param			dry_run	query		bool			false	"Only return the ban that would be removed, as a DryRunResponse"
+//	@Success		200		{object}	SuccessResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		404		{object}	EmptyResponse
+//	@Router			/api/ipban [delete]
+func removeIpBan(c *gin.Context) {
+	var req IpBanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	prefix, err := tool.ParseIpPrefix(req.Ip)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if isDryRun(c) {
+		changes, err := service.DryRunRemoveIpBan(database.GetDB(), ipBanKey(prefix))
+		writeDryRun(c, changes, err)
+		return
+	}
+	removal := service.Removal{Reason: req.Reason, By: requestBy(c)}
+	if err := service.RemoveIpBan(database.GetDB(), ipBanKey(prefix), removal); err != nil {
+		if err == service.ErrNoRecord {
+			c.JSON(http.StatusNotFound, gin.H{})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// ipBanKey normalizes the ban so the same address or range is stored once,
+// single addresses without the /32 or /128.
+func ipBanKey(prefix netip.Prefix) string {
+	if prefix.Bits() == prefix.Addr().BitLen() {
+		return prefix.Addr().String()
+	}
+	return prefix.String()
+}
@@ -0,0 +1,30 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zaigie/palworld-server-tool/internal/tool"
+)
+
+// checkIpReputation godoc
+//
+//	@Summary		Check IP Reputation
+//	@Description	Check an ip against the ip_reputation lists and provider
+//	@Tags			Player
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			ip	path		string	true	"IP"
+//	@Success		200	{object}	tool.IpReputation
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Router			/api/ip_reputation/{ip} [get]
+func checkIpReputation(c *gin.Context) {
+	reputation, err := tool.CheckIpReputation(c.Param("ip"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, reputation)
+}
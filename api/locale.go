@@ -0,0 +1,108 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/internal/locale"
+	"github.com/zaigie/palworld-server-tool/service"
+)
+
+type LocaleInfo struct {
+	Languages []string `json:"languages"`
+	// Channels is the language used by broadcast, bot and notify
+	Channels map[string]string `json:"channels"`
+}
+
+// listLocales godoc
+//
+//	@Summary		List Locales
+//	@Description	List the languages of backend messages and the one each channel uses, set by locale.default and locale.<channel>
+//	@Tags			Locale
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Success		200	{object}	LocaleInfo
+//	@Failure		401	{object}	ErrorResponse
+//	@Router			/api/locale [get]
+func listLocales(c *gin.Context) {
+	channels := make(map[string]string)
+	for _, channel := range []string{locale.Broadcast, locale.Bot, locale.Notify} {
+		channels[channel] = locale.Lang(channel)
+	}
+	c.JSON(http.StatusOK, LocaleInfo{Languages: locale.Languages(), Channels: channels})
+}
+
+// getLocale godoc
+//
+//	@Summary		Get Locale
+//	@Description	Get the messages of a language, keys it lacks are taken from en
+//	@Tags			Locale
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			lang	path		string	true	"Language, like zh-CN"
+//	@Success		200		{object}	map[string]string
+//	@Failure		401		{object}	ErrorResponse
+//	@Router			/api/locale/{lang} [get]
+func getLocale(c *gin.Context) {
+	c.JSON(http.StatusOK, locale.Messages(c.Param("lang")))
+}
+
+// putLocale godoc
+//
+//	@Summary		Put Locale
+//	@Description	Add a language or override messages of a bundled one, {name} placeholders are the same as in en
+//	@Tags			Locale
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			lang		path		string				true	"Language, like zh-CN"
+//	@Param			messages	body		map[string]string	true	"Messages by key"
+//	@Success		200			{object}	SuccessResponse
+//	@Failure		400			{object}	ErrorResponse
+//	@Failure		401			{object}	ErrorResponse
+//	@Router			/api/locale/{lang} [put]
+func putLocale(c *gin.Context) {
+	var messages map[string]string
+	if err := c.ShouldBindJSON(&messages); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	lang := c.Param("lang")
+	if err := service.PutLocale(database.GetDB(), lang, messages); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	locale.Register(lang, messages)
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// removeLocale godoc
+//
+//	@Summary		Remove Locale
+//	@Description	Remove the custom messages of a language, bundled messages stay
+//	@Tags			Locale
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			lang	path		string	true	"Language, like zh-CN"
+//	@Success		200		{object}	SuccessResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		404		{object}	EmptyResponse
+//	@Router			/api/locale/{lang} [delete]
+func removeLocale(c *gin.Context) {
+	lang := c.Param("lang")
+	if err := service.RemoveLocale(database.GetDB(), lang); err != nil {
+		if err == service.ErrNoRecord {
+			c.JSON(http.StatusNotFound, gin.H{})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	locale.Unregister(lang)
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
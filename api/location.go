@@ -0,0 +1,67 @@
+package api
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/internal/tool"
+)
+
+// locator reads the location, precision and unit query params, it's nil
+// unless location=true asks for computed locations.
+func locator(c *gin.Context) (*tool.Locator, error) {
+	if c.Query("location") != "true" {
+		return nil, nil
+	}
+	precision, err := strconv.Atoi(c.DefaultQuery("precision", strconv.Itoa(tool.DefaultLocationPrecision)))
+	if err != nil {
+		return nil, errors.New("invalid precision")
+	}
+	return tool.NewLocator(database.GetDB(), precision, c.Query("unit"))
+}
+
+// locatePlayer is nil without a locator and for players at 0,0, which the
+// REST API reports for those not in the world.
+func locatePlayer(l *tool.Locator, player database.OnlinePlayer) *tool.Location {
+	if l == nil || (player.LocationX == 0 && player.LocationY == 0) {
+		return nil
+	}
+	return l.Locate(player.LocationX, player.LocationY)
+}
+
+// the located types marshal as the value itself when no location was asked
+
+type locatedTersePlayer struct {
+	database.TersePlayer
+	Location *tool.Location `json:"location,omitempty"`
+}
+
+type locatedPlayer struct {
+	database.Player
+	Location *tool.Location `json:"location,omitempty"`
+}
+
+type locatedGuild struct {
+	database.Guild
+	// BaseCampLocations are keyed by base camp id
+	BaseCampLocations map[string]*tool.Location `json:"base_camp_locations,omitempty"`
+}
+
+func locateGuild(l *tool.Locator, guild database.Guild) locatedGuild {
+	located := locatedGuild{Guild: guild}
+	if l == nil {
+		return located
+	}
+	located.BaseCampLocations = make(map[string]*tool.Location, len(guild.BaseCamp))
+	for _, camp := range guild.BaseCamp {
+		located.BaseCampLocations[camp.Id] = l.Locate(camp.LocationX, camp.LocationY)
+	}
+	return located
+}
+
+type LocatedMapObject struct {
+	database.MapObject
+	Location *tool.Location `json:"location,omitempty"`
+}
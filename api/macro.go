@@ -0,0 +1,148 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/internal/logger"
+	"github.com/zaigie/palworld-server-tool/internal/tool"
+	"github.com/zaigie/palworld-server-tool/service"
+)
+
+type RunMacroRequest struct {
+	Params map[string]string `json:"params"`
+}
+
+// listMacros godoc
+//
+//	@Summary		List Macros
+//	@Description	List Macros
+//	@Tags			Macro
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Success		200	{object}	[]database.Macro
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Router			/api/macros [get]
+func listMacros(c *gin.Context) {
+	macros, err := service.ListMacros(database.GetDB())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, macros)
+}
+
+// putMacro godoc
+//
+//	@Summary		Put Macro
+//	@Description	Create or replace a named macro
+//	@Tags			Macro
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			name	path		string			true	"Macro Name"
+//	@Param			macro	body		database.Macro	true	"Macro"
+//	@Success		200		{object}	SuccessResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Router			/api/macros/{name} [put]
+func putMacro(c *gin.Context) {
+	var macro database.Macro
+	if err := c.ShouldBindJSON(&macro); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	macro.Name = c.Param("name")
+	if err := validateMacro(macro); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := service.PutMacro(database.GetDB(), macro); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// removeMacro godoc
+//
+//	@Summary		Remove Macro
+//	@Description	Remove Macro
+//	@Tags			Macro
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			name	path		string	true	"Macro Name"
+//	@Success		200		{object}	SuccessResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Router			/api/macros/{name} [delete]
+func removeMacro(c *gin.Context) {
+	if err := service.RemoveMacro(database.GetDB(), c.Param("name")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// runMacro godoc
+//
+//	@Summary		Run Macro
+//	@Description	Run a named macro in the background, {key} placeholders in step content are replaced by params
+//	@Tags			Macro
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			name	path		string			true	"Macro Name"
+//	@Param			params	body		RunMacroRequest	false	"Macro Params"
+//	@Success		200		{object}	SuccessResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Router			/api/macros/{name} [post]
+func runMacro(c *gin.Context) {
+	var req RunMacroRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	macro, err := service.GetMacro(database.GetDB(), c.Param("name"))
+	if err != nil {
+		if err == service.ErrNoRecord {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Macro not found"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	// the macro outlives the request, keep its trace but not its cancel
+	ctx := context.WithoutCancel(c.Request.Context())
+	go func() {
+		if err := tool.RunMacro(ctx, macro, req.Params); err != nil {
+			logger.Errorf("%v\n", err)
+		}
+	}()
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+func validateMacro(macro database.Macro) error {
+	if macro.Name == "" {
+		return errors.New("macro name cannot be empty")
+	}
+	if len(macro.Steps) == 0 {
+		return errors.New("macro steps cannot be empty")
+	}
+	for _, step := range macro.Steps {
+		if err := tool.ValidateMacroStep(step); err != nil {
+			return err
+		}
+	}
+	return nil
+}
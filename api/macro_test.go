@@ -0,0 +1,53 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/internal/tool"
+	"github.com/zaigie/palworld-server-tool/service"
+)
+
+func TestRunMacroAfterResponse(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	saved := make(chan struct{}, 1)
+	rest := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/api/save" {
+			saved <- struct{}{}
+		}
+	}))
+	defer rest.Close()
+	viper.Set("rest.address", rest.URL)
+	defer viper.Set("rest.address", "")
+
+	macro := database.Macro{Name: "save", Steps: []database.MacroStep{{Action: tool.MacroActionSave}}}
+	if err := service.PutMacro(database.GetDB(), macro); err != nil {
+		t.Fatal(err)
+	}
+	defer service.RemoveMacro(database.GetDB(), macro.Name)
+
+	r := gin.New()
+	r.POST("/api/macros/:name", runMacro)
+	// a served request, its context is cancelled once the handler returns
+	pst := httptest.NewServer(r)
+	defer pst.Close()
+	resp, err := http.Post(pst.URL+"/api/macros/save", "application/json", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	// the request is done, the save step still has to reach the server
+	select {
+	case <-saved:
+	case <-time.After(5 * time.Second):
+		t.Fatal("save step didn't reach the REST API")
+	}
+}
@@ -0,0 +1,21 @@
+package api
+
+import (
+	"os"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+// TestMain keeps pst.db of the handlers out of the data directory of the
+// user.
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "pst-api")
+	if err != nil {
+		panic(err)
+	}
+	viper.Set("paths.data_dir", dir)
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
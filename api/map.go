@@ -0,0 +1,360 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/internal/logger"
+	"github.com/zaigie/palworld-server-tool/internal/tool"
+	"github.com/zaigie/palworld-server-tool/service"
+)
+
+// getMap godoc
+//
+//	@Summary		Get Map Info
+//	@Description	Get the served map version, max zoom and tile url template
+//	@Tags			Map
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	tool.MapInfo
+//	@Router			/api/map [get]
+func getMap(c *gin.Context) {
+	c.JSON(http.StatusOK, tool.GetMapInfo())
+}
+
+func getMapTile(c *gin.Context) {
+	z, errZ := strconv.Atoi(c.Param("z"))
+	x, errX := strconv.Atoi(c.Param("x"))
+	y, errY := strconv.Atoi(strings.TrimSuffix(c.Param("y"), ".png"))
+	if errZ != nil || errX != nil || errY != nil {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	data, err := tool.ReadTile(z, x, y)
+	if err != nil {
+		if err != tool.ErrTileNotFound {
+			logger.Warnf("Read map tile %d/%d/%d fail, %v\n", z, x, y, err)
+		}
+		c.Status(http.StatusNotFound)
+		return
+	}
+	c.Header("Cache-Control", "public, max-age=86400")
+	c.Data(http.StatusOK, "image/png", data)
+}
+
+// convertMapPosition godoc
+//
+//	@Summary		Convert Map Position
+//	@Description	Convert a position between in-game world coordinates, map latlng and tile pixels using the calibration of the served map
+//	@Tags			Map
+//	@Accept			json
+//	@Produce		json
+//	@Param			from		query		string	false	"Coordinate type of x and y"	Enums(world, latlng, pixel)	default(world)
+//	@Param			x			query		number	true	"World X, Lat or Pixel X"
+//	@Param			y			query		number	true	"World Y, Lng or Pixel Y"
+//	@Param			zoom		query		int		false	"Zoom level of pixel coordinates"
+//	@Param			location	query		bool	false	"add the in-game map coordinates, distance from spawn and region"
+//	@Param			precision	query		int		false	"decimals of location values"	default(2)			maximum(6)
+//	@Param			unit		query		string	false	"unit of distance_from_spawn"	Enums(m, cm, km)	default(m)
+//	@Success		200			{object}	tool.MapPosition
+//	@Failure		400			{object}	ErrorResponse
+//	@Router			/api/map/convert [get]
+func convertMapPosition(c *gin.Context) {
+	x, err := strconv.ParseFloat(c.Query("x"), 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid x"})
+		return
+	}
+	y, err := strconv.ParseFloat(c.Query("y"), 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid y"})
+		return
+	}
+	zoom, err := strconv.Atoi(c.DefaultQuery("zoom", "0"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid zoom"})
+		return
+	}
+	calibration, err := tool.GetMapCalibration(database.GetDB())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	pos, err := tool.ConvertMapPosition(calibration, c.DefaultQuery("from", "world"), x, y, zoom)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	l, err := locator(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if l != nil {
+		pos.Location = l.Locate(pos.WorldX, pos.WorldY)
+	}
+	c.JSON(http.StatusOK, pos)
+}
+
+// getMapCalibration godoc
+//
+//	@Summary		Get Map Calibration
+//	@Description	Get the world bounds of the served map version
+//	@Tags			Map
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	database.MapCalibration
+//	@Failure		400	{object}	ErrorResponse
+//	@Router			/api/map/calibration [get]
+func getMapCalibration(c *gin.Context) {
+	calibration, err := tool.GetMapCalibration(database.GetDB())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, calibration)
+}
+
+// putMapCalibration godoc
+//
+//	@Summary		Put Map Calibration
+//	@Description	Set the world bounds of the served map version
+//	@Tags			Map
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			calibration	body		database.MapCalibration	true	"Map Calibration"
+//	@Success		200			{object}	SuccessResponse
+//	@Failure		400			{object}	ErrorResponse
+//	@Failure		401			{object}	ErrorResponse
+//	@Router			/api/map/calibration [put]
+func putMapCalibration(c *gin.Context) {
+	var calibration database.MapCalibration
+	if err := c.ShouldBindJSON(&calibration); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	calibration.Version = tool.GetMapInfo().Version
+	if err := tool.ValidateMapCalibration(calibration); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := service.PutMapCalibration(database.GetDB(), calibration); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// removeMapCalibration godoc
+//
+//	@Summary		Remove Map Calibration
+//	@Description	Reset the served map version to the default calibration
+//	@Tags			Map
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Success		200	{object}	SuccessResponse
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Router			/api/map/calibration [delete]
+func removeMapCalibration(c *gin.Context) {
+	if err := service.RemoveMapCalibration(database.GetDB(), tool.GetMapInfo().Version); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// getHeatmap godoc
+//
+//	@Summary		Get Heatmap
+//	@Description	Get the player activity heatmap of a day or of the week ending on that day, as a grid in JSON or a png overlay covering the whole map
+//	@Tags			Map
+//	@Accept			json
+//	@Produce		json
+//	@Produce		png
+//	@Security		ApiKeyAuth
+//	@Param			date	query		string	false	"Date as 2006-01-02, default today"
+//	@Param			period	query		string	false	"Period"				Enums(day, week)	default(day)
+//	@Param			format	query		string	false	"Format"				Enums(json, png)	default(json)
+//	@Param			width	query		int		false	"Png width in pixels"	default(512)
+//	@Success		200		{object}	database.Heatmap
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Router			/api/map/heatmap [get]
+func getHeatmap(c *gin.Context) {
+	const layout = "2006-01-02"
+	date := time.Now()
+	if c.Query("date") != "" {
+		var err error
+		date, err = time.ParseInLocation(layout, c.Query("date"), time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "date must be in 2006-01-02 format"})
+			return
+		}
+	}
+	start := date
+	switch c.DefaultQuery("period", "day") {
+	case "day":
+	case "week":
+		start = date.AddDate(0, 0, -6)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "period must be day or week"})
+		return
+	}
+	heatmaps, err := service.ListHeatmaps(database.GetDB(), start.Format(layout), date.Format(layout))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	size := viper.GetInt("map.heatmap_grid")
+	if len(heatmaps) > 0 {
+		size = heatmaps[len(heatmaps)-1].Size
+	}
+	if size <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "heatmap is disabled"})
+		return
+	}
+	heatmap := tool.MergeHeatmaps(heatmaps, size)
+	heatmap.Date = date.Format(layout)
+
+	switch c.DefaultQuery("format", "json") {
+	case "json":
+		c.JSON(http.StatusOK, heatmap)
+	case "png":
+		width, err := strconv.Atoi(c.DefaultQuery("width", "512"))
+		if err != nil || width <= 0 || width > 4096 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "width must be between 1 and 4096"})
+			return
+		}
+		data, err := tool.RenderHeatmap(heatmap, width)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.Data(http.StatusOK, "image/png", data)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be json or png"})
+	}
+}
+
+type MapObjectsResponse struct {
+	Total    int                `json:"total"`
+	Page     int                `json:"page"`
+	PageSize int                `json:"page_size"`
+	Objects  []LocatedMapObject `json:"objects"`
+}
+
+// putMapObjects godoc
+//
+//	@Summary		Put Map Objects
+//	@Description	Put Map Objects Only For SavSync
+//	@Tags			Map
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			objects			body		[]database.MapObject	true	"Map Objects"
+//	@Param			X-Sync-Batch	header		int						false	"Sync batch journaling the map objects"
+//	@Success		200				{object}	SuccessResponse
+//	@Failure		400				{object}	ErrorResponse
+//	@Failure		401				{object}	ErrorResponse
+//	@Router			/api/map/objects [put]
+func putMapObjects(c *gin.Context) {
+	var objects []database.MapObject
+	if err := c.ShouldBindJSON(&objects); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	err := service.JournalStep(database.GetDB(), syncBatch(c), service.SyncStepMapObjects, objects, len(objects), func(j *service.StepJournal) error {
+		return applyMapObjects(objects, j)
+	})
+	if err != nil {
+		badRequest(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+func applyMapObjects(objects []database.MapObject, j *service.StepJournal) error {
+	return service.PutMapObjects(database.GetDB(), objects, j)
+}
+
+// listMapObjects godoc
+//
+//	@Summary		List Map Objects
+//	@Description	List world objects of the latest save within a region, objects outside any base camp are the ones left in the wild
+//	@Tags			Map
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			bbox		query		string	false	"Region in world coordinates as min_x,min_y,max_x,max_y"
+//	@Param			category	query		string	false	"Category"									Enums(pal_box, chest, drop_item, egg, structure)
+//	@Param			in_base		query		string	false	"Only objects inside or outside base camps"	Enums(true, false)
+//	@Param			page		query		int		false	"Page"										default(1)
+//	@Param			page_size	query		int		false	"Page Size"									default(100)
+//	@Param			location	query		bool	false	"add the location computed from location_x and location_y"
+//	@Param			precision	query		int		false	"decimals of location values"	default(2)			maximum(6)
+//	@Param			unit		query		string	false	"unit of distance_from_spawn"	Enums(m, cm, km)	default(m)
+//	@Success		200			{object}	MapObjectsResponse
+//	@Failure		400			{object}	ErrorResponse
+//	@Failure		401			{object}	ErrorResponse
+//	@Router			/api/map/objects [get]
+func listMapObjects(c *gin.Context) {
+	filter := service.MapObjectFilter{
+		Category: c.Query("category"),
+		InBase:   c.Query("in_base"),
+	}
+	if bbox := c.Query("bbox"); bbox != "" {
+		parts := strings.Split(bbox, ",")
+		if len(parts) != 4 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "bbox must be min_x,min_y,max_x,max_y"})
+			return
+		}
+		values := make([]float64, 4)
+		for i, part := range parts {
+			v, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "bbox must be min_x,min_y,max_x,max_y"})
+				return
+			}
+			values[i] = v
+		}
+		filter.MinX, filter.MinY, filter.MaxX, filter.MaxY = values[0], values[1], values[2], values[3]
+	}
+	page, pageSize, err := pageQuery(c, 100)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	filter.Offset = (page - 1) * pageSize
+	filter.Limit = pageSize
+	l, err := locator(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	objects, total, err := service.ListMapObjects(database.GetDB(), filter)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	located := make([]LocatedMapObject, len(objects))
+	for i, object := range objects {
+		located[i].MapObject = object
+		if l != nil {
+			located[i].Location = l.Locate(object.LocationX, object.LocationY)
+		}
+	}
+	c.JSON(http.StatusOK, MapObjectsResponse{
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+		Objects:  located,
+	})
+}
@@ -0,0 +1,150 @@
+package api
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zaigie/palworld-server-tool/internal/tool"
+)
+
+type ModConfigResponse struct {
+	Success bool   `json:"success"`
+	Backup  string `json:"backup"`
+}
+
+// listMods godoc
+//
+//	@Summary		List Mods
+//	@Description	List UE4SS and pak mods installed under mods.server_dir
+//	@Tags			Mod
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Success		200	{array}		tool.Mod
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Router			/api/mods [get]
+func listMods(c *gin.Context) {
+	mods, err := tool.ListMods()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, mods)
+}
+
+// enableMod godoc
+//
+//	@Summary		Enable Mod
+//	@Description	Enable a mod, it takes effect after the server restarts
+//	@Tags			Mod
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			type	path		string	true	"ue4ss or pak"
+//	@Param			name	path		string	true	"Mod name"
+//	@Success		200		{object}	tool.Mod
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Router			/api/mods/{type}/{name}/enable [post]
+func enableMod(c *gin.Context) {
+	setModEnabled(c, true)
+}
+
+// disableMod godoc
+//
+//	@Summary		Disable Mod
+//	@Description	Disable a mod, it takes effect after the server restarts
+//	@Tags			Mod
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			type	path		string	true	"ue4ss or pak"
+//	@Param			name	path		string	true	"Mod name"
+//	@Success		200		{object}	tool.Mod
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Router			/api/mods/{type}/{name}/disable [post]
+func disableMod(c *gin.Context) {
+	setModEnabled(c, false)
+}
+
+func setModEnabled(c *gin.Context, enabled bool) {
+	mod, err := tool.SetModEnabled(c.Param("type"), c.Param("name"), enabled)
+	if err != nil {
+		if err == tool.ErrModNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, mod)
+}
+
+// getModConfig godoc
+//
+//	@Summary		Get Mod Config
+//	@Description	Get the content of a config file listed in config_files of the mod
+//	@Tags			Mod
+//	@Accept			json
+//	@Produce		plain
+//	@Security		ApiKeyAuth
+//	@Param			type	path		string	true	"ue4ss or pak"
+//	@Param			name	path		string	true	"Mod name"
+//	@Param			file	query		string	true	"Config file"
+//	@Success		200		{string}	string
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Router			/api/mods/{type}/{name}/config [get]
+func getModConfig(c *gin.Context) {
+	content, err := tool.ReadModConfig(c.Param("type"), c.Param("name"), c.Query("file"))
+	if err != nil {
+		if err == tool.ErrModNotFound || err == tool.ErrModConfigNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.Data(http.StatusOK, "text/plain; charset=utf-8", content)
+}
+
+// putModConfig godoc
+//
+//	@Summary		Put Mod Config
+//	@Description	Replace a config file of the mod with the request body, the old file is copied to backups/mods first
+//	@Tags			Mod
+//	@Accept			plain
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			type	path		string	true	"ue4ss or pak"
+//	@Param			name	path		string	true	"Mod name"
+//	@Param			file	query		string	true	"Config file"
+//	@Param			content	body		string	true	"Content"
+//	@Success		200		{object}	ModConfigResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Router			/api/mods/{type}/{name}/config [put]
+func putModConfig(c *gin.Context) {
+	content, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	backup, err := tool.WriteModConfig(c.Param("type"), c.Param("name"), c.Query("file"), content)
+	if err != nil {
+		if err == tool.ErrModNotFound || err == tool.ErrModConfigNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, ModConfigResponse{Success: true, Backup: backup})
+}
@@ -0,0 +1,52 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/service"
+)
+
+// listOrphans godoc
+//
+//	@Summary		List Orphans
+//	@Description	Count the records of players no longer in the save and the stale backup index entries, without removing them
+//	@Tags			Maintenance
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Success		200	{object}	service.OrphanReport
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Router			/api/orphans [get]
+func listOrphans(c *gin.Context) {
+	report, err := service.PruneOrphans(database.GetDB(), true)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// pruneOrphans godoc
+//
+//	@Summary		Prune Orphans
+//	@Description	Remove the records of players no longer in the save and the stale backup index entries
+//	@Tags			Maintenance
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			dry_run	query		bool	false	"Only count what would be removed, like GET"
+//	@Success		200		{object}	service.OrphanReport
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Router			/api/orphans [delete]
+func pruneOrphans(c *gin.Context) {
+	report, err := service.PruneOrphans(database.GetDB(), isDryRun(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
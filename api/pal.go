@@ -0,0 +1,46 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/service"
+)
+
+// getPalHistory godoc
+//
+//	@Summary		Get Pal History
+//	@Description	Get the ownership changes (pal.traded), moves between a base and the party or palbox (pal.moved) and the disappearance (pal.released) of a pal instance, newest first, recorded on save syncs
+//	@Tags			Pal
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			instance_id	path		string	true	"Pal Instance ID"
+//	@Param			limit		query		int		false	"max number of events"
+//	@Success		200			{object}	[]database.Event
+//	@Failure		400			{object}	ErrorResponse
+//	@Failure		401			{object}	ErrorResponse
+//	@Failure		404			{object}	EmptyResponse
+//	@Router			/api/pal/{instance_id}/history [get]
+func getPalHistory(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+		return
+	}
+	instanceId := c.Param("instance_id")
+	events, err := service.ListPalHistory(database.GetDB(), instanceId, limit)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(events) == 0 {
+		if _, err := service.GetPalOwner(database.GetDB(), instanceId); err == service.ErrNoRecord {
+			c.JSON(http.StatusNotFound, gin.H{})
+			return
+		}
+	}
+	c.JSON(http.StatusOK, events)
+}
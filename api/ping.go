@@ -0,0 +1,41 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/service"
+)
+
+// getPlayerPing godoc
+//
+//	@Summary		Get Player Ping
+//	@Description	Get the ping percentiles of a player from the sampled history, with a series for a sparkline
+//	@Tags			Player
+//	@Accept			json
+//	@Produce		json
+//	@Param			player_uid	path		string	true	"Player UID"
+//	@Param			points		query		int		false	"max points of the series, default 60"
+//	@Success		200			{object}	service.PingStats
+//	@Failure		400			{object}	ErrorResponse
+//	@Failure		404			{object}	EmptyResponse
+//	@Router			/api/player/{player_uid}/ping [get]
+func getPlayerPing(c *gin.Context) {
+	points, err := strconv.Atoi(c.DefaultQuery("points", "60"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid points"})
+		return
+	}
+	stats, err := service.GetPingStats(database.GetDB(), c.Param("player_uid"), points)
+	if err != nil {
+		if err == service.ErrNoRecord {
+			c.JSON(http.StatusNotFound, gin.H{})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
@@ -1,13 +1,24 @@
 package api
 
 import (
+	"bufio"
+	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"reflect"
 	"sort"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/zaigie/palworld-server-tool/internal/auth"
+	"github.com/zaigie/palworld-server-tool/internal/bus"
 	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/internal/logger"
+	"github.com/zaigie/palworld-server-tool/internal/task"
 	"github.com/zaigie/palworld-server-tool/internal/tool"
+	"github.com/zaigie/palworld-server-tool/internal/tracing"
 	"github.com/zaigie/palworld-server-tool/service"
 )
 
@@ -30,7 +41,7 @@ const (
 //	@Failure		400	{object}	ErrorResponse
 //	@Router			/api/online_player [get]
 func listOnlinePlayers(c *gin.Context) {
-	onlinePLayers, err := tool.ShowPlayers()
+	onlinePLayers, err := tool.ShowPlayers(c.Request.Context())
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -49,11 +60,13 @@ func listOnlinePlayers(c *gin.Context) {
 //
 //	@Security		ApiKeyAuth
 //
-//	@Param			players	body		[]database.Player	true	"Players"
+//	@Param			players			body		[]database.Player	true	"Players"
+//	@Param			dry_run			query		bool				false	"Only return the players and events that would change, as a DryRunResponse"
+//	@Param			X-Sync-Batch	header		int					false	"Sync batch journaling the players"
 //
-//	@Success		200		{object}	SuccessResponse
-//	@Failure		400		{object}	ErrorResponse
-//	@Failure		401		{object}	ErrorResponse
+//	@Success		200				{object}	SuccessResponse
+//	@Failure		400				{object}	ErrorResponse
+//	@Failure		401				{object}	ErrorResponse
 //	@Router			/api/player [put]
 func putPlayers(c *gin.Context) {
 	var players []database.Player
@@ -61,13 +74,44 @@ func putPlayers(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	if err := service.PutPlayers(database.GetDB(), players); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if isDryRun(c) {
+		changes, err := service.DryRunPutPlayers(database.GetDB(), players)
+		writeDryRun(c, changes, err)
+		return
+	}
+	err := service.JournalStep(database.GetDB(), syncBatch(c), service.SyncStepPlayers, players, len(players), func(j *service.StepJournal) error {
+		return applyPlayers(c.Request.Context(), players, j)
+	})
+	if err != nil {
+		badRequest(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"success": true})
 }
 
+// applyPlayers writes the players of a save sync with what derives from
+// them, journaling the players in j.
+func applyPlayers(ctx context.Context, players []database.Player, j *service.StepJournal) error {
+	var events []database.Event
+	if err := tracing.Do(ctx, "service.PutPlayers", func() (err error) {
+		events, err = service.PutPlayers(database.GetDB(), players, j)
+		return err
+	}); err != nil {
+		return err
+	}
+	bus.Publish(events...)
+	if err := tracing.Do(ctx, "service.TrackPals", func() (err error) {
+		events, err = service.TrackPals(database.GetDB(), players)
+		return err
+	}); err != nil {
+		return err
+	}
+	bus.Publish(events...)
+	return tracing.Do(ctx, "service.RecordDailySnapshot", func() error {
+		return service.RecordDailySnapshot(database.GetDB())
+	})
+}
+
 // listPlayers godoc
 //
 //	@Summary		List Players
@@ -76,20 +120,76 @@ func putPlayers(c *gin.Context) {
 //	@Accept			json
 //	@Produce		json
 //
-//	@Param			order_by	query		PlayerOrderBy	false	"order by field"	enum(last_online,level)
-//	@Param			desc		query		bool			false	"order by desc"
+//	@Param			order_by	query		PlayerOrderBy			false	"order by field"	enum(last_online,level)
+//	@Param			desc		query		bool					false	"order by desc"
+//	@Param			since		query		string					false	"only players updated after, unix seconds or RFC3339, pass the X-Server-Time of the last response"
+//	@Param			platform	query		string					false	"only players of the platform"	enum(steam,xbox,playstation)
+//	@Param			fields		query		string					false	"comma separated fields to return, like nickname,level,last_online"
+//	@Param			segment		query		string					false	"only players of the saved segment, needs the login token"
+//	@Param			location	query		bool					false	"add the location computed from location_x and location_y to players in the world"
+//	@Param			precision	query		int						false	"decimals of location values"	default(2)			maximum(6)
+//	@Param			unit		query		string					false	"unit of distance_from_spawn"	Enums(m, cm, km)	default(m)
 //
-//	@Success		200			{object}	[]database.TersePlayer
+//	@Success		200			{object}	[]database.TersePlayer	"without order_by an error midway ends the list with an object of the error"
+//	@Header			200			{string}	X-Server-Time			"time of the response for the next since"
 //	@Failure		400			{object}	ErrorResponse
+//	@Failure		401			{object}	ErrorResponse
+//	@Failure		404			{object}	ErrorResponse
 //	@Router			/api/player [get]
 func listPlayers(c *gin.Context) {
 	orderBy := c.Query("order_by")
 	desc := c.Query("desc")
+	var since time.Time
+	if s := c.Query("since"); s != "" {
+		var err error
+		if since, err = parseSince(s); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid since"})
+			return
+		}
+	}
+	platform := c.Query("platform")
+	fields, err := newProjection(reflect.TypeOf(locatedTersePlayer{}), c.Query("fields"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	l, err := locator(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	inSegment := func(database.TersePlayer) bool { return true }
+	if segment := c.Query("segment"); segment != "" {
+		// segments can tell who isn't whitelisted, they are for admins
+		if !auth.Authenticate(c) {
+			return
+		}
+		if inSegment, err = segmentMatcher(database.GetDB(), segment); err != nil {
+			segmentError(c, err)
+			return
+		}
+	}
+	match := func(p database.TersePlayer) bool {
+		return (since.IsZero() || p.UpdatedAt.After(since)) && (platform == "" || p.Platform == platform) && inSegment(p)
+	}
+	now := time.Now()
+	c.Header("X-Server-Time", now.Format(time.RFC3339Nano))
+	if orderBy == "" {
+		streamPlayers(c, match, l, fields)
+		return
+	}
 	players, err := service.ListPlayers(database.GetDB())
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	matched := players[:0]
+	for _, p := range players {
+		if match(p) {
+			matched = append(matched, p)
+		}
+	}
+	players = matched
 	if orderBy == "level" {
 		sort.Slice(players, func(i, j int) bool {
 			if desc == "true" {
@@ -106,7 +206,61 @@ func listPlayers(c *gin.Context) {
 			return players[i].LastOnline.Sub(players[j].LastOnline) < 0
 		})
 	}
-	c.JSON(http.StatusOK, players)
+	located := make([]any, len(players))
+	for i, p := range players {
+		located[i] = fields.apply(locatedTersePlayer{p, locatePlayer(l, p.OnlinePlayer)})
+	}
+	c.JSON(http.StatusOK, located)
+}
+
+// streamPlayers writes the players as they're read, so large servers don't
+// hold the whole list in memory. An error before anything was sent is a
+// 400, past that the array ends with an object of the error instead of the
+// remaining players.
+func streamPlayers(c *gin.Context, match func(database.TersePlayer) bool, l *tool.Locator, fields *projection) {
+	c.Header("Content-Type", "application/json; charset=utf-8")
+	c.Status(http.StatusOK)
+	w := bufio.NewWriter(c.Writer)
+	enc := json.NewEncoder(w)
+	w.WriteByte('[')
+	first := true
+	err := service.StreamPlayers(c.Request.Context(), database.GetDB(), func(player database.TersePlayer) error {
+		if !match(player) {
+			return nil
+		}
+		if !first {
+			if err := w.WriteByte(','); err != nil {
+				return err
+			}
+		}
+		first = false
+		return enc.Encode(fields.apply(locatedTersePlayer{player, locatePlayer(l, player.OnlinePlayer)}))
+	})
+	if err != nil {
+		logger.Warnf("Stream players fail, %v\n", err)
+		if c.Request.Context().Err() != nil {
+			return
+		}
+		if !c.Writer.Written() {
+			w.Reset(c.Writer)
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if !first {
+			w.WriteByte(',')
+		}
+		enc.Encode(gin.H{"error": err.Error()})
+	}
+	w.WriteByte(']')
+	w.Flush()
+}
+
+// parseSince reads unix seconds or an RFC3339 time.
+func parseSince(s string) (time.Time, error) {
+	if seconds, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(seconds, 0), nil
+	}
+	return time.Parse(time.RFC3339Nano, s)
 }
 
 // getPlayer godoc
@@ -118,12 +272,26 @@ func listPlayers(c *gin.Context) {
 //	@Produce		json
 //
 //	@Param			player_uid	path		string	true	"Player UID"
+//	@Param			fields		query		string	false	"comma separated fields to return, like nickname,level,pals"
+//	@Param			location	query		bool	false	"add the location computed from location_x and location_y when the player is in the world"
+//	@Param			precision	query		int		false	"decimals of location values"	default(2)			maximum(6)
+//	@Param			unit		query		string	false	"unit of distance_from_spawn"	Enums(m, cm, km)	default(m)
 //
 //	@Success		200			{object}	database.Player
 //	@Failure		400			{object}	ErrorResponse
 //	@Failure		404			{object}	EmptyResponse
 //	@Router			/api/player/{player_uid} [get]
 func getPlayer(c *gin.Context) {
+	fields, err := newProjection(reflect.TypeOf(locatedPlayer{}), c.Query("fields"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	l, err := locator(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	player, err := service.GetPlayer(database.GetDB(), c.Param("player_uid"))
 	if err != nil {
 		if err == service.ErrNoRecord {
@@ -133,7 +301,7 @@ func getPlayer(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, player)
+	c.JSON(http.StatusOK, fields.apply(locatedPlayer{player, locatePlayer(l, player.OnlinePlayer)}))
 }
 
 // kickPlayer godoc
@@ -145,6 +313,7 @@ func getPlayer(c *gin.Context) {
 //	@Produce		json
 //	@Security		ApiKeyAuth
 //	@Param			player_uid	path		string	true	"Player UID"
+//	@Param			dry_run		query		bool	false	"Only return the command that would be sent, as a DryRunResponse"
 //
 //	@Success		200			{object}	SuccessResponse
 //	@Failure		400			{object}	ErrorResponse
@@ -162,7 +331,11 @@ func kickPlayer(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	err = tool.KickPlayer(fmt.Sprintf("steam_%s", player.SteamId))
+	if isDryRun(c) {
+		writeDryRun(c, []service.Change{commandChange("kick", tool.RequestUserId{UserId: fmt.Sprintf("steam_%s", player.SteamId)})}, nil)
+		return
+	}
+	err = tool.KickPlayer(c.Request.Context(), fmt.Sprintf("steam_%s", player.SteamId))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -179,6 +352,7 @@ func kickPlayer(c *gin.Context) {
 //	@Produce		json
 //	@Security		ApiKeyAuth
 //	@Param			player_uid	path		string	true	"Player UID"
+//	@Param			dry_run		query		bool	false	"Only return the command that would be sent, as a DryRunResponse"
 //
 //	@Success		200			{object}	SuccessResponse
 //	@Failure		400			{object}	ErrorResponse
@@ -196,7 +370,11 @@ func banPlayer(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	err = tool.BanPlayer(fmt.Sprintf("steam_%s", player.SteamId))
+	if isDryRun(c) {
+		writeDryRun(c, []service.Change{commandChange("ban", tool.RequestUserId{UserId: fmt.Sprintf("steam_%s", player.SteamId)})}, nil)
+		return
+	}
+	err = tool.BanPlayer(c.Request.Context(), fmt.Sprintf("steam_%s", player.SteamId))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -207,12 +385,14 @@ func banPlayer(c *gin.Context) {
 // unbanPlayer godoc
 //
 //	@Summary		Unban Player
-//	@Description	Unban Player
+//	@Description	Unban Player, the ban is kept in the history of removed entries to be restored
 //	@Tags			Player
 //	@Accept			json
 //	@Produce		json
 //	@Security		ApiKeyAuth
 //	@Param			player_uid	path		string	true	"Player UID"
+//	@Param			reason		query		string	false	"Why the ban is lifted"
+//	@Param			dry_run		query		bool	false	"Only return the command that would be sent and the removed entry kept, as a DryRunResponse"
 //
 //	@Success		200			{object}	SuccessResponse
 //	@Failure		400			{object}	ErrorResponse
@@ -230,7 +410,25 @@ func unbanPlayer(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	err = tool.UnBanPlayer(fmt.Sprintf("steam_%s", player.SteamId))
+	unbanned := database.PlayerW{
+		Name:      player.Nickname,
+		SteamID:   player.SteamId,
+		PlayerUID: player.PlayerUid,
+	}
+	if isDryRun(c) {
+		changes, err := service.DryRunAddRemovedBan(database.GetDB(), unbanned, removal(c))
+		if err == nil {
+			changes = append([]service.Change{commandChange("unban", tool.RequestUserId{UserId: fmt.Sprintf("steam_%s", player.SteamId)})}, changes...)
+		}
+		writeDryRun(c, changes, err)
+		return
+	}
+	err = tool.UnBanPlayer(c.Request.Context(), fmt.Sprintf("steam_%s", player.SteamId))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	err = service.AddRemovedBan(database.GetDB(), unbanned, removal(c))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -241,16 +439,18 @@ func unbanPlayer(c *gin.Context) {
 // addWhite godoc
 //
 //	@Summary		Add White List
-//	@Description	Add White List
+//	@Description	Add a player to the White List, or update the entry matching its uid, name or steam id. Sent with the revision of the entry, the update is refused with 409 when the entry changed since
 //	@Tags			Player
 //	@Accept			json
 //	@Produce		json
 //	@Security		ApiKeyAuth
-//	@Param			player_uid	path		string	true	"Player UID"
+//	@Param			player	body		database.PlayerW	true	"Player"
+//	@Param			dry_run	query		bool				false	"Only return the entries that would change, as a DryRunResponse"
 //
-//	@Success		200			{object}	SuccessResponse
-//	@Failure		400			{object}	ErrorResponse
-//	@Failure		401			{object}	ErrorResponse
+//	@Success		200		{object}	SuccessResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		409		{object}	ErrorResponse
 //	@Router			/api/whitelist [post]
 func addWhite(c *gin.Context) {
 	var player database.PlayerW
@@ -258,8 +458,17 @@ func addWhite(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if isDryRun(c) {
+		changes, err := service.DryRunAddWhitelist(database.GetDB(), player)
+		writeDryRun(c, changes, err)
+		return
+	}
 	if err := service.AddWhitelist(database.GetDB(), player); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		if err == service.ErrRevisionConflict {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		badRequest(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"success": true})
@@ -268,35 +477,84 @@ func addWhite(c *gin.Context) {
 // listWhite godoc
 //
 //	@Summary		List White List
-//	@Description	List White List
+//	@Description	List White List, all entries unless a page is asked for. X-Total-Count is how many entries match q
 //	@Tags			Player
 //	@Accept			json
 //	@Produce		json
-//	@Success		200	{object}	[]database.PlayerW
-//	@Failure		400	{object}	ErrorResponse
+//	@Security		ApiKeyAuth
+//	@Param			q			query		string	false	"part of the name, steam id or player uid"
+//	@Param			order_by	query		string	false	"order by field, the stored order without"	Enums(name, steam_id, player_uid)
+//	@Param			desc		query		bool	false	"order by desc"
+//	@Param			page		query		int		false	"Page"										default(1)
+//	@Param			page_size	query		int		false	"Page Size, 100 when only page is given"	maximum(1000)
+//	@Success		200			{object}	[]database.PlayerW
+//	@Header			200			{int}		X-Total-Count	"entries matching q"
+//	@Failure		400			{object}	ErrorResponse
 //	@Router			/api/whitelist [get]
 func listWhite(c *gin.Context) {
-	players, err := service.ListWhitelist(database.GetDB())
+	filter := service.WhitelistFilter{
+		Query:   c.Query("q"),
+		OrderBy: c.Query("order_by"),
+		Desc:    c.Query("desc") == "true",
+	}
+	switch filter.OrderBy {
+	case "", "name", "steam_id", "player_uid":
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "order_by must be name, steam_id or player_uid"})
+		return
+	}
+	page, pageSize, err := pageQuery(c, 0)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	filter.Offset = (page - 1) * pageSize
+	filter.Limit = pageSize
+	players, total, err := service.SearchWhitelist(database.GetDB(), filter)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.Header("X-Total-Count", strconv.Itoa(total))
 	c.JSON(http.StatusOK, players)
 }
 
+// countWhite godoc
+//
+//	@Summary		Count White List
+//	@Description	Count the white list entries matching q
+//	@Tags			Player
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			q	query		string	false	"part of the name, steam id or player uid"
+//	@Success		200	{object}	CountResponse
+//	@Failure		400	{object}	ErrorResponse
+//	@Router			/api/whitelist/count [get]
+func countWhite(c *gin.Context) {
+	_, total, err := service.SearchWhitelist(database.GetDB(), service.WhitelistFilter{Query: c.Query("q")})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, CountResponse{Count: total})
+}
+
 // removeWhite godoc
 //
 //	@Summary		Remove White List
-//	@Description	Remove White List
+//	@Description	Remove White List, the entry is kept in the history of removed entries to be restored
 //	@Tags			Player
 //	@Accept			json
 //	@Produce		json
 //	@Security		ApiKeyAuth
-//	@Param			player_uid	path		string	true	"Player UID"
+//	@Param			player	body		database.PlayerW	true	"Player"
+//	@Param			reason	query		string				false	"Why the player is removed"
+//	@Param			dry_run	query		bool				false	"Only return the entries that would change, as a DryRunResponse"
 //
-//	@Success		200			{object}	SuccessResponse
-//	@Failure		400			{object}	ErrorResponse
-//	@Failure		401			{object}	ErrorResponse
+//	@Success		200		{object}	SuccessResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		409		{object}	ErrorResponse
 //	@Router			/api/whitelist [delete]
 func removeWhite(c *gin.Context) {
 	var player database.PlayerW
@@ -304,7 +562,16 @@ func removeWhite(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	if err := service.RemoveWhitelist(database.GetDB(), player); err != nil {
+	if isDryRun(c) {
+		changes, err := service.DryRunRemoveWhitelist(database.GetDB(), player)
+		writeDryRun(c, changes, err)
+		return
+	}
+	if err := service.RemoveWhitelist(database.GetDB(), player, removal(c)); err != nil {
+		if err == service.ErrRevisionConflict {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -314,16 +581,20 @@ func removeWhite(c *gin.Context) {
 // putWhite godoc
 //
 //	@Summary		Put White List
-//	@Description	Put White List
+//	@Description	Put players in the White List. Mode replace drops the entries left out, keeping them in the history of removed entries, merge adds or updates each player like POST and append only adds the players not listed yet. A snapshot is taken first, the response has its id for /api/snapshot/{id}/revert
 //	@Tags			Player
 //	@Accept			json
 //	@Produce		json
 //	@Security		ApiKeyAuth
 //	@Param			players	body		[]database.PlayerW	true	"Players"
+//	@Param			mode	query		string				false	"replace, the default, merge or append"	enum(replace,merge,append)
+//	@Param			reason	query		string				false	"Why entries are removed"
+//	@Param			dry_run	query		bool				false	"Only return the entries that would be added, overwritten or removed, as a DryRunResponse, no snapshot is taken"
 //
-//	@Success		200		{object}	SuccessResponse
+//	@Success		200		{object}	SnapshotResponse
 //	@Failure		400		{object}	ErrorResponse
 //	@Failure		401		{object}	ErrorResponse
+//	@Failure		409		{object}	ErrorResponse
 //	@Router			/api/whitelist [put]
 func putWhite(c *gin.Context) {
 	var players []database.PlayerW
@@ -331,9 +602,133 @@ func putWhite(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	if err := service.PutWhitelist(database.GetDB(), players); err != nil {
+	mode := c.DefaultQuery("mode", service.WhitelistReplace)
+	var operation string
+	switch mode {
+	case service.WhitelistReplace:
+		operation = service.SnapshotWhitelistReplace
+	case service.WhitelistMerge:
+		operation = service.SnapshotWhitelistMerge
+	case service.WhitelistAppend:
+		operation = service.SnapshotWhitelistAppend
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "mode must be replace, merge or append"})
+		return
+	}
+	if isDryRun(c) {
+		changes, err := service.DryRunPutWhitelist(database.GetDB(), players, mode)
+		writeDryRun(c, changes, err)
+		return
+	}
+	snapshot, err := service.TakeSnapshot(database.GetDB(), operation, requestBy(c), []string{"whitelist"}, "")
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"success": true})
+	if err := service.PutWhitelist(database.GetDB(), players, mode, removal(c)); err != nil {
+		if err == service.ErrRevisionConflict {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		badRequest(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "snapshot_id": snapshot.Id})
+}
+
+// listAfkPlayers godoc
+//
+//	@Summary		List AFK Players
+//	@Description	List online players who haven't moved for manage.afk_minutes, empty when it is 0
+//	@Tags			Player
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Success		200	{array}		task.AfkPlayer
+//	@Failure		401	{object}	ErrorResponse
+//	@Router			/api/afk [get]
+func listAfkPlayers(c *gin.Context) {
+	c.JSON(http.StatusOK, task.ListAfkPlayers())
+}
+
+// listNearbyPlayers godoc
+//
+//	@Summary		List Nearby Players
+//	@Description	List players whose last known location is within radius of a position, nearest first, all in world coordinates
+//	@Tags			Player
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			x		query		number	true	"World X"
+//	@Param			y		query		number	true	"World Y"
+//	@Param			radius	query		number	true	"Radius in world units, centimeters"
+//	@Success		200		{array}		task.NearbyPlayer
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Router			/api/player/near [get]
+func listNearbyPlayers(c *gin.Context) {
+	x, err := strconv.ParseFloat(c.Query("x"), 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid x"})
+		return
+	}
+	y, err := strconv.ParseFloat(c.Query("y"), 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid y"})
+		return
+	}
+	radius, err := strconv.ParseFloat(c.Query("radius"), 64)
+	if err != nil || radius <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid radius"})
+		return
+	}
+	players, err := task.NearbyPlayers(database.GetDB(), x, y, radius)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, players)
+}
+
+// listAltAccounts godoc
+//
+//	@Summary		List Alt Accounts
+//	@Description	Report players seen from the same ip address with their session timelines, concurrent groups are more likely a shared network than alt accounts
+//	@Tags			Player
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Success		200	{array}		service.AltAccounts
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Router			/api/player/alts [get]
+func listAltAccounts(c *gin.Context) {
+	alts, err := service.ListAltAccounts(database.GetDB())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, alts)
+}
+
+// listPlayerIps godoc
+//
+//	@Summary		List Player IPs
+//	@Description	List the ip addresses a player was seen from with the sessions of each
+//	@Tags			Player
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			player_uid	path		string	true	"Player UID"
+//	@Success		200			{array}		database.PlayerIp
+//	@Failure		400			{object}	ErrorResponse
+//	@Failure		401			{object}	ErrorResponse
+//	@Router			/api/player/{player_uid}/ips [get]
+func listPlayerIps(c *gin.Context) {
+	ips, err := service.ListPlayerIps(database.GetDB(), c.Param("player_uid"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, ips)
 }
@@ -0,0 +1,154 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/internal/task"
+	"github.com/zaigie/palworld-server-tool/internal/tool"
+	"github.com/zaigie/palworld-server-tool/service"
+)
+
+// maxBulkPlayers keeps one request from holding the RCON connection for
+// too long
+const maxBulkPlayers = 500
+
+type BulkPlayerRequest struct {
+	PlayerUids []string `json:"player_uids"`
+	// Action is kick, ban, tag or whitelist
+	Action string `json:"action"`
+	// Tag is added to the players by the tag action
+	Tag string `json:"tag"`
+}
+
+type BulkPlayerResult struct {
+	PlayerUid string `json:"player_uid"`
+	Success   bool   `json:"success"`
+	Error     string `json:"error,omitempty"`
+	// Changes are what the action would do, for a dry run
+	Changes []service.Change `json:"changes,omitempty"`
+}
+
+type BulkPlayerResponse struct {
+	DryRun    bool               `json:"dry_run,omitempty"`
+	Succeeded int                `json:"succeeded"`
+	Failed    int                `json:"failed"`
+	Results   []BulkPlayerResult `json:"results"`
+}
+
+// bulkPlayerAction godoc
+//
+//	@Summary		Bulk Player Action
+//	@Description	Kick, ban, tag or whitelist many players at once. Every player is tried, the results tell which failed and why.
+//	@Tags			Player
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			request	body		BulkPlayerRequest	true	"Players and action"
+//	@Param			dry_run	query		bool				false	"Only return the changes of each player, nothing is done"
+//	@Success		200		{object}	BulkPlayerResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Router			/api/player/bulk [post]
+func bulkPlayerAction(c *gin.Context) {
+	var req BulkPlayerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(req.PlayerUids) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "player_uids is required"})
+		return
+	}
+	if len(req.PlayerUids) > maxBulkPlayers {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("at most %d players at once", maxBulkPlayers)})
+		return
+	}
+	req.Tag = strings.TrimSpace(req.Tag)
+	db := database.GetDB()
+	dryRun := isDryRun(c)
+	var action func(player database.Player) error
+	// preview returns what action would do, for a dry run
+	var preview func(player database.Player) ([]service.Change, error)
+	switch req.Action {
+	case "kick", "ban":
+		send := tool.KickPlayer
+		if req.Action == "ban" {
+			send = tool.BanPlayer
+		}
+		action = func(player database.Player) error {
+			if player.SteamId == "" {
+				return errors.New("player has no steam id")
+			}
+			return send(c.Request.Context(), fmt.Sprintf("steam_%s", player.SteamId))
+		}
+		preview = func(player database.Player) ([]service.Change, error) {
+			if player.SteamId == "" {
+				return nil, errors.New("player has no steam id")
+			}
+			return []service.Change{commandChange(req.Action, tool.RequestUserId{UserId: fmt.Sprintf("steam_%s", player.SteamId)})}, nil
+		}
+	case "tag":
+		if req.Tag == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "tag is required"})
+			return
+		}
+		action = func(player database.Player) error {
+			return task.TagPlayer(db, player.PlayerUid, player.Nickname, req.Tag)
+		}
+		preview = func(player database.Player) ([]service.Change, error) {
+			return task.DryRunTagPlayer(db, player.PlayerUid, player.Nickname, req.Tag)
+		}
+	case "whitelist":
+		entry := func(player database.Player) database.PlayerW {
+			return database.PlayerW{
+				Name:      player.Nickname,
+				SteamID:   player.SteamId,
+				PlayerUID: player.PlayerUid,
+			}
+		}
+		action = func(player database.Player) error {
+			return service.AddWhitelist(db, entry(player))
+		}
+		preview = func(player database.Player) ([]service.Change, error) {
+			return service.DryRunAddWhitelist(db, entry(player))
+		}
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "action must be kick, ban, tag or whitelist"})
+		return
+	}
+
+	resp := BulkPlayerResponse{DryRun: dryRun, Results: make([]BulkPlayerResult, 0, len(req.PlayerUids))}
+	seen := make(map[string]bool, len(req.PlayerUids))
+	for _, playerUid := range req.PlayerUids {
+		if seen[playerUid] {
+			continue
+		}
+		seen[playerUid] = true
+		result := BulkPlayerResult{PlayerUid: playerUid}
+		player, err := service.GetPlayer(db, playerUid)
+		if err == service.ErrNoRecord {
+			err = errors.New("player not found")
+		}
+		if err == nil {
+			if dryRun {
+				result.Changes, err = preview(player)
+			} else {
+				err = action(player)
+			}
+		}
+		if err != nil {
+			result.Error = err.Error()
+			resp.Failed++
+		} else {
+			result.Success = true
+			resp.Succeeded++
+		}
+		resp.Results = append(resp.Results, result)
+	}
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,183 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/internal/task"
+	"github.com/zaigie/palworld-server-tool/service"
+	"go.etcd.io/bbolt"
+)
+
+// listPlayerGroups godoc
+//
+//	@Summary		List Player Groups
+//	@Description	List player groups like VIP or admin with their members
+//	@Tags			Player Group
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Success		200	{array}		database.PlayerGroup
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Router			/api/player_group [get]
+func listPlayerGroups(c *gin.Context) {
+	groups, err := service.ListPlayerGroups(database.GetDB())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, groups)
+}
+
+// putPlayerGroup godoc
+//
+//	@Summary		Put Player Group
+//	@Description	Create or replace a player group. Admin members bypass the whitelist and keep their slot, members of reserved slot groups can take a reserved slot, join_message is broadcast when a member joins
+//	@Tags			Player Group
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			name	path		string					true	"Group Name"
+//	@Param			group	body		database.PlayerGroup	true	"Player Group"
+//	@Success		200		{object}	SuccessResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Router			/api/player_group/{name} [put]
+func putPlayerGroup(c *gin.Context) {
+	var group database.PlayerGroup
+	if err := c.ShouldBindJSON(&group); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	group.Name = c.Param("name")
+	if group.Members == nil {
+		group.Members = []database.GroupMember{}
+	}
+	if err := service.PutPlayerGroup(database.GetDB(), group); err != nil {
+		badRequest(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// removePlayerGroup godoc
+//
+//	@Summary		Remove Player Group
+//	@Description	Remove Player Group
+//	@Tags			Player Group
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			name	path		string	true	"Group Name"
+//	@Success		200		{object}	SuccessResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Router			/api/player_group/{name} [delete]
+func removePlayerGroup(c *gin.Context) {
+	if err := service.RemovePlayerGroup(database.GetDB(), c.Param("name")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// addGroupMember godoc
+//
+//	@Summary		Add Group Member
+//	@Description	Add a player to the group by player_uid or steam_id
+//	@Tags			Player Group
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			name	path		string					true	"Group Name"
+//	@Param			member	body		database.GroupMember	true	"Member"
+//	@Success		200		{object}	SuccessResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Router			/api/player_group/{name}/members [post]
+func addGroupMember(c *gin.Context) {
+	changeGroupMember(c, service.AddGroupMember)
+}
+
+// removeGroupMember godoc
+//
+//	@Summary		Remove Group Member
+//	@Description	Remove a player from the group by player_uid or steam_id
+//	@Tags			Player Group
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			name	path		string					true	"Group Name"
+//	@Param			member	body		database.GroupMember	true	"Member"
+//	@Success		200		{object}	SuccessResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Router			/api/player_group/{name}/members [delete]
+func removeGroupMember(c *gin.Context) {
+	changeGroupMember(c, service.RemoveGroupMember)
+}
+
+func changeGroupMember(c *gin.Context, change func(db *bbolt.DB, name string, member database.GroupMember) error) {
+	var member database.GroupMember
+	if err := c.ShouldBindJSON(&member); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := change(database.GetDB(), c.Param("name"), member); err != nil {
+		if err == service.ErrNoRecord {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Group not found"})
+			return
+		}
+		badRequest(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+type ReservedSlotResponse struct {
+	Kicked database.OnlinePlayer `json:"kicked"`
+}
+
+// freeReservedSlot godoc
+//
+//	@Summary		Free Reserved Slot
+//	@Description	When the server is full, kick the most recently joined player outside reserved slot and admin groups so the given member can join
+//	@Tags			Player Group
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			member	body		database.GroupMember	true	"VIP Member"
+//	@Success		200		{object}	ReservedSlotResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		409		{object}	ErrorResponse
+//	@Router			/api/reserved_slot [post]
+func freeReservedSlot(c *gin.Context) {
+	var member database.GroupMember
+	if err := c.ShouldBindJSON(&member); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := service.ValidateGroupMember(member); err != nil {
+		badRequest(c, err)
+		return
+	}
+	kicked, err := task.FreeReservedSlot(database.GetDB(), member)
+	if err != nil {
+		switch err {
+		case task.ErrNotVip:
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case task.ErrServerNotFull, task.ErrNoSlotToFree:
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, ReservedSlotResponse{Kicked: kicked})
+}
@@ -0,0 +1,122 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/service"
+)
+
+type MergePlayersRequest struct {
+	PlayerUid      string `json:"player_uid"`
+	OtherPlayerUid string `json:"other_player_uid"`
+	// Keep is the uid to keep, by default the canonical one or else the one
+	// online last
+	Keep string `json:"keep"`
+	// PlayerRevision and OtherPlayerRevision are the revisions of the players
+	// as read, the merge is refused when one changed since. 0 skips the check
+	PlayerRevision      uint64 `json:"player_revision"`
+	OtherPlayerRevision uint64 `json:"other_player_revision"`
+}
+
+// mergePlayers godoc
+//
+//	@Summary		Merge Players
+//	@Description	Merge two records of the same player, like crossplay duplicates. Playtime and points are summed, ip sessions, point transactions, whitelist entries and group memberships move to the kept uid. Can be undone for manage.merge_undo_hours
+//	@Tags			Player
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			merge	body		MergePlayersRequest	true	"Players to merge"
+//	@Success		200		{object}	database.PlayerMerge
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		404		{object}	EmptyResponse
+//	@Failure		409		{object}	ErrorResponse
+//	@Router			/api/player/merge [post]
+func mergePlayers(c *gin.Context) {
+	var req MergePlayersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	undo := time.Duration(viper.GetInt("manage.merge_undo_hours")) * time.Hour
+	revisions := map[string]uint64{
+		req.PlayerUid:      req.PlayerRevision,
+		req.OtherPlayerUid: req.OtherPlayerRevision,
+	}
+	merge, err := service.MergePlayers(database.GetDB(), req.PlayerUid, req.OtherPlayerUid, req.Keep, revisions, undo)
+	if err != nil {
+		switch err {
+		case service.ErrNoRecord:
+			c.JSON(http.StatusNotFound, gin.H{})
+		case service.ErrRevisionConflict:
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, merge)
+}
+
+// listPlayerMerges godoc
+//
+//	@Summary		List Player Merges
+//	@Description	List the player merges, newest first
+//	@Tags			Player
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Success		200	{array}		database.PlayerMerge
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Router			/api/player/merge [get]
+func listPlayerMerges(c *gin.Context) {
+	merges, err := service.ListPlayerMerges(database.GetDB())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, merges)
+}
+
+// undoPlayerMerge godoc
+//
+//	@Summary		Undo Player Merge
+//	@Description	Restore the records a merge changed, changes made to them since are lost
+//	@Tags			Player
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			id	path		int	true	"Merge ID"
+//	@Success		200	{object}	database.PlayerMerge
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		404	{object}	EmptyResponse
+//	@Failure		409	{object}	ErrorResponse
+//	@Router			/api/player/merge/{id}/undo [post]
+func undoPlayerMerge(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	merge, err := service.UndoPlayerMerge(database.GetDB(), id)
+	if err != nil {
+		switch err {
+		case service.ErrNoRecord:
+			c.JSON(http.StatusNotFound, gin.H{})
+		case service.ErrMergeUndone, service.ErrMergeUndoPassed:
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, merge)
+}
@@ -0,0 +1,213 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/internal/filter"
+	"github.com/zaigie/palworld-server-tool/service"
+	"go.etcd.io/bbolt"
+)
+
+// segmentFields are the fields a segment filter can use besides the json
+// fields of the player, each read from another bucket once per query.
+var segmentFields = map[string]bool{
+	"whitelisted": true,
+	"tags":        true,
+	"playtime":    true,
+	"guild":       true,
+	"groups":      true,
+}
+
+// listPlayerSegments godoc
+//
+//	@Summary		List Player Segments
+//	@Description	List the saved player filters, query one with /api/player?segment=name
+//	@Tags			Player Segment
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Success		200	{array}		database.PlayerSegment
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Router			/api/player_segment [get]
+func listPlayerSegments(c *gin.Context) {
+	segments, err := service.ListPlayerSegments(database.GetDB())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, segments)
+}
+
+// putPlayerSegment godoc
+//
+//	@Summary		Put Player Segment
+//	@Description	Create or replace a saved player filter like level>45 AND last_online<7d AND NOT whitelisted. Fields are the player fields and whitelisted, tags, playtime (seconds), guild and groups, joined with AND, OR, NOT and parentheses. Operators are = != > >= < <= and ~ for contains, a time field compared to a duration like 7d compares its age
+//	@Tags			Player Segment
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			name	path		string					true	"Segment Name"
+//	@Param			segment	body		database.PlayerSegment	true	"Player Segment"
+//	@Success		200		{object}	SuccessResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Router			/api/player_segment/{name} [put]
+func putPlayerSegment(c *gin.Context) {
+	var segment database.PlayerSegment
+	if err := c.ShouldBindJSON(&segment); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	segment.Name = c.Param("name")
+	if _, err := parseSegment(segment.Filter, nil); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := service.PutPlayerSegment(database.GetDB(), segment); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// removePlayerSegment godoc
+//
+//	@Summary		Remove Player Segment
+//	@Description	Remove Player Segment
+//	@Tags			Player Segment
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			name	path		string	true	"Segment Name"
+//	@Success		200		{object}	SuccessResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Router			/api/player_segment/{name} [delete]
+func removePlayerSegment(c *gin.Context) {
+	if err := service.RemovePlayerSegment(database.GetDB(), c.Param("name")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// parseSegment parses the filter of a segment, used collects the segment
+// fields it refers to.
+func parseSegment(src string, used map[string]bool) (filter.Expr, error) {
+	fields := fieldsOf(reflect.TypeOf(database.TersePlayer{}))
+	return filter.Parse(src, func(field string) bool {
+		if segmentFields[field] {
+			if used != nil {
+				used[field] = true
+			}
+			return true
+		}
+		_, ok := fields[field]
+		return ok
+	})
+}
+
+// segmentMatcher returns the match of the players in the named segment.
+// The other buckets the filter needs are read into maps up front, so a
+// player costs map lookups and not a query.
+func segmentMatcher(db *bbolt.DB, name string) (func(database.TersePlayer) bool, error) {
+	segment, err := service.GetPlayerSegment(db, name)
+	if err != nil {
+		return nil, err
+	}
+	used := make(map[string]bool)
+	expr, err := parseSegment(segment.Filter, used)
+	if err != nil {
+		return nil, err
+	}
+
+	whitelisted := make(map[string]bool)
+	if used["whitelisted"] {
+		whitelist, err := service.ListWhitelist(db)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range whitelist {
+			if p.PlayerUID != "" {
+				whitelisted["uid:"+p.PlayerUID] = true
+			}
+			if p.SteamID != "" {
+				whitelisted["steam:"+p.SteamID] = true
+			}
+		}
+	}
+	playtimes := make(map[string]database.Playtime)
+	if used["tags"] || used["playtime"] {
+		list, err := service.ListPlaytimes(db)
+		if err != nil {
+			return nil, err
+		}
+		for _, playtime := range list {
+			playtimes[playtime.PlayerUid] = playtime
+		}
+	}
+	guilds := make(map[string]string)
+	if used["guild"] {
+		list, err := service.ListGuilds(db)
+		if err != nil {
+			return nil, err
+		}
+		for _, guild := range list {
+			for _, member := range guild.Players {
+				guilds[member.PlayerUid] = guild.Name
+			}
+		}
+	}
+	var groups []database.PlayerGroup
+	if used["groups"] {
+		if groups, err = service.ListPlayerGroups(db); err != nil {
+			return nil, err
+		}
+	}
+
+	fields := fieldsOf(reflect.TypeOf(database.TersePlayer{}))
+	return func(p database.TersePlayer) bool {
+		v := reflect.ValueOf(p)
+		return expr.Match(func(field string) any {
+			switch field {
+			case "whitelisted":
+				return whitelisted["uid:"+p.PlayerUid] || p.SteamId != "" && whitelisted["steam:"+p.SteamId]
+			case "tags":
+				return playtimes[p.PlayerUid].Tags
+			case "playtime":
+				return playtimes[p.PlayerUid].Seconds
+			case "guild":
+				return guilds[p.PlayerUid]
+			case "groups":
+				var names []string
+				for _, group := range groups {
+					for _, member := range group.Members {
+						if service.IsGroupMember(member, p.PlayerUid, p.SteamId) {
+							names = append(names, group.Name)
+							break
+						}
+					}
+				}
+				return names
+			}
+			if index, ok := fields[field]; ok {
+				return v.FieldByIndex(index).Interface()
+			}
+			return nil
+		})
+	}, nil
+}
+
+// segmentError writes the error of segmentMatcher.
+func segmentError(c *gin.Context, err error) {
+	if errors.Is(err, service.ErrNoRecord) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "segment not found"})
+		return
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+}
@@ -0,0 +1,82 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/internal/task"
+	"github.com/zaigie/palworld-server-tool/service"
+)
+
+// listPlaytimes godoc
+//
+//	@Summary		List Playtimes
+//	@Description	List playtime of players with the rank tags they reached, longest first
+//	@Tags			Player
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Success		200	{array}		database.Playtime
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Router			/api/playtime [get]
+func listPlaytimes(c *gin.Context) {
+	playtimes, err := service.ListPlaytimes(database.GetDB())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, playtimes)
+}
+
+// getPlaytime godoc
+//
+//	@Summary		Get Playtime
+//	@Description	Get playtime of a player with the rank tags reached
+//	@Tags			Player
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			player_uid	path		string	true	"Player UID"
+//	@Success		200			{object}	database.Playtime
+//	@Failure		400			{object}	ErrorResponse
+//	@Failure		401			{object}	ErrorResponse
+//	@Failure		404			{object}	EmptyResponse
+//	@Router			/api/playtime/{player_uid} [get]
+func getPlaytime(c *gin.Context) {
+	playtime, err := service.GetPlaytime(database.GetDB(), c.Param("player_uid"))
+	if err != nil {
+		if err == service.ErrNoRecord {
+			c.JSON(http.StatusNotFound, gin.H{})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, playtime)
+}
+
+// listRanks godoc
+//
+//	@Summary		List Ranks
+//	@Description	List the playtime ranks of rank.ranks by hours
+//	@Tags			Player
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Success		200	{array}		task.Rank
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Router			/api/rank [get]
+func listRanks(c *gin.Context) {
+	ranks, err := task.Ranks()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if ranks == nil {
+		ranks = []task.Rank{}
+	}
+	c.JSON(http.StatusOK, ranks)
+}
@@ -0,0 +1,156 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/service"
+)
+
+type PointsRequest struct {
+	// Amount is positive, spend takes it off the balance
+	Amount int64  `json:"amount"`
+	Reason string `json:"reason"`
+}
+
+// listPoints godoc
+//
+//	@Summary		List Points
+//	@Description	List the points balances, highest first
+//	@Tags			Points
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Success		200	{array}		database.PointsAccount
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Router			/api/points [get]
+func listPoints(c *gin.Context) {
+	accounts, err := service.ListPoints(database.GetDB())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, accounts)
+}
+
+// getPoints godoc
+//
+//	@Summary		Get Points
+//	@Description	Get the points balance of a player
+//	@Tags			Points
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			player_uid	path		string	true	"Player UID"
+//	@Success		200			{object}	database.PointsAccount
+//	@Failure		400			{object}	ErrorResponse
+//	@Failure		401			{object}	ErrorResponse
+//	@Failure		404			{object}	EmptyResponse
+//	@Router			/api/points/{player_uid} [get]
+func getPoints(c *gin.Context) {
+	account, err := service.GetPoints(database.GetDB(), c.Param("player_uid"))
+	if err != nil {
+		if err == service.ErrNoRecord {
+			c.JSON(http.StatusNotFound, gin.H{})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, account)
+}
+
+// listPointsTransactions godoc
+//
+//	@Summary		List Points Transactions
+//	@Description	List the points transactions of a player, newest first. Online earnings are one transaction per day
+//	@Tags			Points
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			player_uid	path		string	true	"Player UID"
+//	@Param			limit		query		int		false	"max number of transactions"
+//	@Success		200			{array}		database.PointsTransaction
+//	@Failure		400			{object}	ErrorResponse
+//	@Failure		401			{object}	ErrorResponse
+//	@Router			/api/points/{player_uid}/transactions [get]
+func listPointsTransactions(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+		return
+	}
+	transactions, err := service.ListPointsTransactions(database.GetDB(), c.Param("player_uid"), limit)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, transactions)
+}
+
+// grantPoints godoc
+//
+//	@Summary		Grant Points
+//	@Description	Add points to a player known from a save sync
+//	@Tags			Points
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			player_uid	path		string			true	"Player UID"
+//	@Param			points		body		PointsRequest	true	"Points"
+//	@Success		200			{object}	database.PointsAccount
+//	@Failure		400			{object}	ErrorResponse
+//	@Failure		401			{object}	ErrorResponse
+//	@Failure		404			{object}	EmptyResponse
+//	@Router			/api/points/{player_uid}/grant [post]
+func grantPoints(c *gin.Context) {
+	adjustPoints(c, 1)
+}
+
+// spendPoints godoc
+//
+//	@Summary		Spend Points
+//	@Description	Take points off a player's balance, which can't go below zero
+//	@Tags			Points
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			player_uid	path		string			true	"Player UID"
+//	@Param			points		body		PointsRequest	true	"Points"
+//	@Success		200			{object}	database.PointsAccount
+//	@Failure		400			{object}	ErrorResponse
+//	@Failure		401			{object}	ErrorResponse
+//	@Failure		404			{object}	EmptyResponse
+//	@Failure		409			{object}	ErrorResponse
+//	@Router			/api/points/{player_uid}/spend [post]
+func spendPoints(c *gin.Context) {
+	adjustPoints(c, -1)
+}
+
+func adjustPoints(c *gin.Context, sign int64) {
+	var req PointsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Amount <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "amount must be positive"})
+		return
+	}
+	account, err := service.AdjustPoints(database.GetDB(), c.Param("player_uid"), sign*req.Amount, req.Reason)
+	if err != nil {
+		switch err {
+		case service.ErrNoRecord:
+			c.JSON(http.StatusNotFound, gin.H{})
+		case service.ErrInsufficientPoints:
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, account)
+}
@@ -46,7 +46,7 @@ func sendRconCommand(c *gin.Context) {
 		return
 	}
 	execCommand := fmt.Sprintf("%s %s", rcon.Command, req.Content)
-	response, err := tool.CustomCommand(execCommand)
+	response, err := tool.CustomCommand(c.Request.Context(), execCommand)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/internal/tool"
+	"github.com/zaigie/palworld-server-tool/service"
+)
+
+// removal is who removes an entry in the request and why, from the reason
+// query.
+func removal(c *gin.Context) service.Removal {
+	return service.Removal{Reason: c.Query("reason"), By: requestBy(c)}
+}
+
+// requestBy is who sent the request for the history and audit events. The
+// admin token has no user in it, so it is the address the request came
+// from, with the client ip a trusted proxy passed on when there is one.
+func requestBy(c *gin.Context) string {
+	remote := c.RemoteIP()
+	if client := c.ClientIP(); client != remote {
+		return client + " via " + remote
+	}
+	return remote
+}
+
+// listRemoved godoc
+//
+//	@Summary		List Removed Entries
+//	@Description	List removed whitelist entries, ip bans and lifted player bans with who removed them, when and why, newest first
+//	@Tags			Player
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			kind	query		string	false	"Kind"	Enums(whitelist, ipban, ban)
+//	@Param			limit	query		int		false	"max number of entries, default 100"
+//	@Success		200		{array}		database.RemovedEntry
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Router			/api/removed [get]
+func listRemoved(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+		return
+	}
+	switch kind := c.Query("kind"); kind {
+	case "", service.RemovedWhitelist, service.RemovedIpBan, service.RemovedBan:
+		entries, err := service.ListRemoved(database.GetDB(), kind, limit)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, entries)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "kind must be whitelist, ipban or ban"})
+	}
+}
+
+// restoreRemoved godoc
+//
+//	@Summary		Restore Removed Entry
+//	@Description	Put a removed whitelist entry or ip ban back, or ban an unbanned player again
+//	@Tags			Player
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			id	path		int	true	"Entry ID"
+//	@Success		200	{object}	database.RemovedEntry
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		404	{object}	EmptyResponse
+//	@Router			/api/removed/{id}/restore [post]
+func restoreRemoved(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	entry, err := service.GetRemoved(database.GetDB(), id)
+	if err != nil {
+		if err == service.ErrNoRecord {
+			c.JSON(http.StatusNotFound, gin.H{})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if entry.Kind == service.RemovedBan && entry.RestoredAt.IsZero() {
+		if err := tool.BanPlayer(c.Request.Context(), fmt.Sprintf("steam_%s", entry.Player.SteamID)); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	entry, err = service.RestoreRemoved(database.GetDB(), id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, entry)
+}
@@ -0,0 +1,42 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequestBy(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name    string
+		trusted []string
+		remote  string
+		xff     string
+		want    string
+	}{
+		{"direct", nil, "192.0.2.7:40000", "", "192.0.2.7"},
+		{"forged header", nil, "192.0.2.7:40000", "203.0.113.9", "192.0.2.7"},
+		{"trusted proxy", []string{"10.0.0.0/8"}, "10.0.0.2:40000", "203.0.113.9", "203.0.113.9 via 10.0.0.2"},
+		{"untrusted proxy", []string{"10.0.0.0/8"}, "192.0.2.7:40000", "203.0.113.9", "192.0.2.7"},
+	}
+	for _, tt := range tests {
+		r := gin.New()
+		if err := r.SetTrustedProxies(tt.trusted); err != nil {
+			t.Fatal(err)
+		}
+		var got string
+		r.GET("/", func(c *gin.Context) { got = requestBy(c) })
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = tt.remote
+		if tt.xff != "" {
+			req.Header.Set("X-Forwarded-For", tt.xff)
+		}
+		r.ServeHTTP(httptest.NewRecorder(), req)
+		if got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
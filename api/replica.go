@@ -0,0 +1,94 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/internal/replica"
+	"go.etcd.io/bbolt"
+)
+
+// primaryRoutes ask the game server or the tasks rather than the database,
+// a replica forwards them to the primary.
+var primaryRoutes = map[string]bool{
+	"/api/server":                  true,
+	"/api/server/metrics":          true,
+	"/api/online_player":           true,
+	"/api/server/state":            true,
+	"/api/server/endpoints":        true,
+	"/api/server/requests":         true,
+	"/api/server/update_status":    true,
+	"/api/server/settings":         true,
+	"/api/mods":                    true,
+	"/api/mods/:type/:name/config": true,
+	"/api/afk":                     true,
+	"/api/events/ws":               true,
+	"/api/scripts":                 true,
+	"/api/tasks":                   true,
+	"/api/tasks/:name":             true,
+	// the season archives are files of the primary
+	"/api/seasons/:id/players": true,
+	"/api/seasons/:id/guilds":  true,
+}
+
+// replicaMiddleware keeps a replica read-only, writes are refused and the
+// routes needing the game server go to the primary.
+func replicaMiddleware() gin.HandlerFunc {
+	var proxy *httputil.ReverseProxy
+	if primaryUrl, err := url.Parse(replica.PrimaryUrl()); err == nil && primaryUrl.Host != "" {
+		proxy = httputil.NewSingleHostReverseProxy(primaryUrl)
+	}
+	return func(c *gin.Context) {
+		if primaryRoutes[c.FullPath()] {
+			if proxy == nil {
+				c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "not available on a read-only replica"})
+				return
+			}
+			proxy.ServeHTTP(c.Writer, c.Request)
+			c.Abort()
+			return
+		}
+		if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead && c.FullPath() != "/api/logout" && c.FullPath() != "/api/me/logout" {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "read-only replica, write to the primary"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// getReplicaSnapshot godoc
+//
+//	@Summary		Get Replica Snapshot
+//	@Description	Download a consistent copy of the database for a read-only replica. With since set to the txid of the copy the replica has, 304 is answered when nothing changed
+//	@Tags			replica
+//	@Produce		application/octet-stream
+//	@Security		ApiKeyAuth
+//	@Param			since	query		int	false	"txid of the snapshot the replica has"
+//	@Success		200		{file}		"Snapshot"
+//	@Success		304		{string}	string	"Not Modified"
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/api/replica/snapshot [get]
+func getReplicaSnapshot(c *gin.Context) {
+	since, _ := strconv.ParseUint(c.Query("since"), 10, 64)
+	err := database.GetDB().View(func(tx *bbolt.Tx) error {
+		txid := uint64(tx.ID())
+		if since != 0 && txid == since {
+			c.Status(http.StatusNotModified)
+			return nil
+		}
+		c.Header("X-Snapshot-Txid", strconv.FormatUint(txid, 10))
+		c.Header("Content-Type", "application/octet-stream")
+		c.Header("Content-Length", strconv.FormatInt(tx.Size(), 10))
+		c.Status(http.StatusOK)
+		_, err := tx.WriteTo(c.Writer)
+		return err
+	})
+	if err != nil && !c.Writer.Written() {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,40 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/service"
+)
+
+// getDailyReport godoc
+//
+//	@Summary		Get Daily Report
+//	@Description	Get what changed on a day compared with the previous snapshot: new players, level gains, pal gains and guild changes
+//	@Tags			Report
+//	@Accept			json
+//	@Produce		json
+//	@Param			date	path		string	true	"Date, YYYY-MM-DD"
+//	@Success		200		{object}	database.DailyReport
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		404		{object}	EmptyResponse
+//	@Router			/api/reports/daily/{date} [get]
+func getDailyReport(c *gin.Context) {
+	date := c.Param("date")
+	if _, err := time.Parse("2006-01-02", date); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid date"})
+		return
+	}
+	report, err := service.GetDailyReport(database.GetDB(), date)
+	if err != nil {
+		if err == service.ErrNoRecord {
+			c.JSON(http.StatusNotFound, gin.H{})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
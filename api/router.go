@@ -1,13 +1,21 @@
 package api
 
 import (
+	"errors"
 	"fmt"
+	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 	"github.com/zaigie/palworld-server-tool/internal/auth"
+	"github.com/zaigie/palworld-server-tool/internal/replica"
+	"github.com/zaigie/palworld-server-tool/internal/task"
+	"github.com/zaigie/palworld-server-tool/internal/tracing"
+	"github.com/zaigie/palworld-server-tool/internal/validate"
 )
 
 type SuccessResponse struct {
@@ -20,10 +28,48 @@ type MessageResponse struct {
 
 type ErrorResponse struct {
 	Error string `json:"error"`
+	// Fields are the refused fields of an invalid request
+	Fields []validate.FieldError `json:"fields,omitempty"`
 }
 
 type EmptyResponse struct{}
 
+type CountResponse struct {
+	Count int `json:"count"`
+}
+
+// maxPageSize bounds page_size of the paginated lists.
+const maxPageSize = 1000
+
+// pageQuery reads the page and page_size query parameters, the page size
+// is defaultSize without page_size, or 100 when only page is given. A
+// page size of 0 is all items.
+func pageQuery(c *gin.Context, defaultSize int) (page, pageSize int, err error) {
+	page, err = strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		return 0, 0, errors.New("invalid page")
+	}
+	pageSize = defaultSize
+	if s := c.Query("page_size"); s != "" {
+		if pageSize, err = strconv.Atoi(s); err != nil || pageSize < 1 || pageSize > maxPageSize {
+			return 0, 0, fmt.Errorf("page_size must be between 1 and %d", maxPageSize)
+		}
+	} else if pageSize == 0 && c.Query("page") != "" {
+		pageSize = 100
+	}
+	return page, pageSize, nil
+}
+
+// badRequest writes err as a 400, with the fields refused by validation.
+func badRequest(c *gin.Context, err error) {
+	var fields validate.Errors
+	if errors.As(err, &fields) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error(), Fields: fields})
+		return
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+}
+
 func ignoreLogPrefix(path string) bool {
 	prefixes := []string{"/swagger/", "/assets/", "/favicon.ico", "/map"}
 	for _, prefix := range prefixes {
@@ -55,40 +101,146 @@ func Logger() gin.HandlerFunc {
 }
 
 func RegisterRouter(r *gin.Engine) {
-	r.Use(Logger(), gin.Recovery())
+	r.Use(Logger(), gin.CustomRecovery(recoverPanic), tracing.Middleware(), setupMiddleware())
 
 	r.POST("/api/login", loginHandler)
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	r.GET("/map/tiles/:z/:x/:y", getMapTile)
 
 	apiGroup := r.Group("/api")
+	apiGroup.Use(dryRunMiddleware())
+	if replica.Enabled() {
+		apiGroup.Use(replicaMiddleware())
+	}
 
 	anonymousGroup := apiGroup.Group("")
 	{
+		anonymousGroup.GET("/setup", getSetup)
+		anonymousGroup.POST("/setup", runSetup)
 		anonymousGroup.GET("/server", getServer)
 		anonymousGroup.GET("/server/tool", getServerTool)
 		anonymousGroup.GET("/server/metrics", getServerMetrics)
+		anonymousGroup.GET("/server/metrics/history", listServerMetrics)
 		anonymousGroup.GET("/player", listPlayers)
+		anonymousGroup.GET("/player/technology", getTechnologyStats)
 		anonymousGroup.GET("/player/:player_uid", getPlayer)
+		anonymousGroup.GET("/player/:player_uid/ping", getPlayerPing)
+		anonymousGroup.GET("/player/:player_uid/badges", getPlayerBadges)
+		anonymousGroup.GET("/badge", listBadges)
+		anonymousGroup.GET("/seasons", listSeasons)
+		anonymousGroup.GET("/seasons/:id/players", listSeasonPlayers)
+		anonymousGroup.GET("/seasons/:id/guilds", listSeasonGuilds)
 		anonymousGroup.GET("/online_player", listOnlinePlayers)
 		anonymousGroup.GET("/guild", listGuilds)
 		anonymousGroup.GET("/guild/:admin_player_uid", getGuild)
+		anonymousGroup.GET("/guild/:admin_player_uid/history", getGuildHistory)
+		anonymousGroup.GET("/guild/:admin_player_uid/stats", getGuildStats)
+		anonymousGroup.GET("/reports/daily/:date", getDailyReport)
+		anonymousGroup.GET("/map", getMap)
+		anonymousGroup.GET("/map/convert", convertMapPosition)
+		anonymousGroup.GET("/map/calibration", getMapCalibration)
+		anonymousGroup.POST("/bot/onebot", onebotEvent)
+		anonymousGroup.GET("/auth/steam", steamLogin)
+		anonymousGroup.GET("/auth/steam/callback", steamCallback)
+		anonymousGroup.GET("/whitelist/application/form", getApplicationForm)
+		anonymousGroup.POST("/whitelist/application", submitApplication)
+	}
+
+	playerGroup := apiGroup.Group("")
+	playerGroup.Use(auth.PlayerAuthMiddleware())
+	{
+		playerGroup.GET("/me", getMe)
+		playerGroup.POST("/me/logout", logoutHandler)
+		playerGroup.GET("/me/application", getMyApplication)
+		playerGroup.POST("/me/application", submitMyApplication)
 	}
 
 	authGroup := apiGroup.Group("")
 	authGroup.Use(auth.JWTAuthMiddleware())
 	{
+		authGroup.POST("/logout", logoutHandler)
 		authGroup.POST("/server/broadcast", publishBroadcast)
 		authGroup.POST("/server/shutdown", shutdownServer)
+		authGroup.GET("/server/state", getServerState)
+		authGroup.GET("/server/endpoints", listServerEndpoints)
+		authGroup.GET("/server/requests", listServerRequests)
+		authGroup.POST("/server/stop", startServerJob(task.ServerActionStop))
+		authGroup.POST("/server/start", startServerJob(task.ServerActionStart))
+		authGroup.POST("/server/restart", startServerJob(task.ServerActionRestart))
+		authGroup.POST("/server/update", startServerJob(task.ServerActionUpdate))
+		authGroup.GET("/server/jobs", listServerJobs)
+		authGroup.GET("/server/jobs/:id", getServerJob)
+		authGroup.GET("/server/update_status", getUpdateStatus)
+		authGroup.GET("/server/settings", getSettings)
+		authGroup.GET("/server/settings/preset", listSettingsPresets)
+		authGroup.PUT("/server/settings/preset/:name", putSettingsPreset)
+		authGroup.DELETE("/server/settings/preset/:name", removeSettingsPreset)
+		authGroup.POST("/server/settings/preset/:name", applySettingsPreset)
 		authGroup.PUT("/player", putPlayers)
 		authGroup.POST("/player/:player_uid/kick", kickPlayer)
+		authGroup.POST("/player/bulk", bulkPlayerAction)
 		authGroup.POST("/player/:player_uid/ban", banPlayer)
 		authGroup.POST("/player/:player_uid/unban", unbanPlayer)
+		authGroup.POST("/player/:player_uid/teleport", teleportPlayer(task.AdminTeleport))
+		authGroup.POST("/player/:player_uid/bring", teleportPlayer(task.AdminBring))
+		authGroup.POST("/player/:player_uid/give_item", giveItem)
+		authGroup.POST("/player/:player_uid/give_exp", giveExp)
+		authGroup.POST("/player/:player_uid/unlock_tech", unlockTech)
+		authGroup.GET("/items", listItems)
+		authGroup.POST("/world/:action", worldAction)
+		authGroup.GET("/player/merge", listPlayerMerges)
+		authGroup.POST("/player/merge", mergePlayers)
+		authGroup.POST("/player/merge/:id/undo", undoPlayerMerge)
+		authGroup.GET("/pal/:instance_id/history", getPalHistory)
+		authGroup.GET("/player_segment", listPlayerSegments)
+		authGroup.PUT("/player_segment/:name", putPlayerSegment)
+		authGroup.DELETE("/player_segment/:name", removePlayerSegment)
+		authGroup.GET("/player_group", listPlayerGroups)
+		authGroup.PUT("/player_group/:name", putPlayerGroup)
+		authGroup.DELETE("/player_group/:name", removePlayerGroup)
+		authGroup.POST("/player_group/:name/members", addGroupMember)
+		authGroup.DELETE("/player_group/:name/members", removeGroupMember)
+		authGroup.POST("/reserved_slot", freeReservedSlot)
+		authGroup.GET("/playtime", listPlaytimes)
+		authGroup.GET("/playtime/:player_uid", getPlaytime)
+		authGroup.GET("/rank", listRanks)
+		authGroup.GET("/afk", listAfkPlayers)
+		authGroup.GET("/points", listPoints)
+		authGroup.GET("/points/:player_uid", getPoints)
+		authGroup.GET("/points/:player_uid/transactions", listPointsTransactions)
+		authGroup.POST("/points/:player_uid/grant", grantPoints)
+		authGroup.POST("/points/:player_uid/spend", spendPoints)
+		authGroup.GET("/player/export", exportPlayers)
+		authGroup.GET("/player/alts", listAltAccounts)
+		authGroup.GET("/player/near", listNearbyPlayers)
+		authGroup.GET("/player/:player_uid/ips", listPlayerIps)
+		authGroup.GET("/ip_reputation/:ip", checkIpReputation)
+		authGroup.GET("/ipban", listIpBans)
+		authGroup.GET("/ipban/count", countIpBans)
+		authGroup.POST("/ipban", addIpBan)
+		authGroup.DELETE("/ipban", removeIpBan)
 		authGroup.PUT("/guild", putGuilds)
+		authGroup.GET("/guild/abandoned", listAbandonedBases)
+		authGroup.GET("/guild/export", exportGuilds)
+		authGroup.GET("/guild/:admin_player_uid/storage", listGuildStorage)
 		authGroup.POST("/sync", syncData)
+		authGroup.GET("/sync/journal", listSyncJournal)
+		authGroup.GET("/sync/journal/:id", getSyncBatch)
+		authGroup.POST("/sync/journal/:id/rollback", rollbackSyncBatch)
+		authGroup.POST("/sync/journal/:id/replay", replaySyncBatch)
 		authGroup.GET("/whitelist", listWhite)
+		authGroup.GET("/whitelist/count", countWhite)
+		authGroup.GET("/whitelist/export", exportWhitelist)
 		authGroup.POST("/whitelist", addWhite)
 		authGroup.DELETE("/whitelist", removeWhite)
 		authGroup.PUT("/whitelist", putWhite)
+		authGroup.GET("/whitelist/application", listApplications)
+		authGroup.POST("/whitelist/application/:id/:action", decideApplication)
+		authGroup.GET("/removed", listRemoved)
+		authGroup.POST("/removed/:id/restore", restoreRemoved)
+		authGroup.GET("/snapshot", listSnapshots)
+		authGroup.POST("/snapshot/:id/revert", revertSnapshot)
+		authGroup.GET("/audit/summary", getAuditSummary)
 		authGroup.GET("/rcon", listRconCommand)
 		authGroup.POST("/rcon", addRconCommand)
 		authGroup.POST("/rcon/import", importRconCommands)
@@ -96,7 +248,54 @@ func RegisterRouter(r *gin.Engine) {
 		authGroup.PUT("/rcon/:uuid", putRconCommand)
 		authGroup.DELETE("/rcon/:uuid", removeRconCommand)
 		authGroup.GET("/backup", listBackups)
+		authGroup.POST("/backup", createBackup)
+		authGroup.POST("/backup/rekey", rekeyBackups)
 		authGroup.GET("/backup/:backup_id", downloadBackup)
 		authGroup.DELETE("/backup/:backup_id", deleteBackup)
+		authGroup.POST("/seasons", endSeason)
+		authGroup.GET("/macros", listMacros)
+		authGroup.PUT("/macros/:name", putMacro)
+		authGroup.DELETE("/macros/:name", removeMacro)
+		authGroup.POST("/macros/:name", runMacro)
+		authGroup.GET("/community_event", listCommunityEvents)
+		authGroup.POST("/community_event", addCommunityEvent)
+		authGroup.PUT("/community_event/:id", putCommunityEvent)
+		authGroup.DELETE("/community_event/:id", removeCommunityEvent)
+		authGroup.PUT("/map/calibration", putMapCalibration)
+		authGroup.DELETE("/map/calibration", removeMapCalibration)
+		authGroup.GET("/map/heatmap", getHeatmap)
+		authGroup.GET("/map/objects", listMapObjects)
+		authGroup.PUT("/map/objects", putMapObjects)
+		authGroup.GET("/mods", listMods)
+		authGroup.POST("/mods/:type/:name/enable", enableMod)
+		authGroup.POST("/mods/:type/:name/disable", disableMod)
+		authGroup.GET("/mods/:type/:name/config", getModConfig)
+		authGroup.PUT("/mods/:type/:name/config", putModConfig)
+		authGroup.GET("/tasks", listTasks)
+		authGroup.GET("/tasks/:name", getTask)
+		authGroup.POST("/tasks/:name/pause", pauseTask)
+		authGroup.POST("/tasks/:name/resume", resumeTask)
+		authGroup.POST("/tasks/:name/run", runTask)
+		authGroup.GET("/events", listEvents)
+		authGroup.GET("/events/ws", streamEvents)
+		authGroup.GET("/scripts", listScripts)
+		authGroup.GET("/locale", listLocales)
+		authGroup.GET("/locale/:lang", getLocale)
+		authGroup.PUT("/locale/:lang", putLocale)
+		authGroup.DELETE("/locale/:lang", removeLocale)
+		authGroup.GET("/templates", listTemplates)
+		authGroup.PUT("/templates/:key", putTemplate)
+		authGroup.DELETE("/templates/:key", removeTemplate)
+		authGroup.POST("/templates/:key/preview", previewTemplate)
+		authGroup.GET("/replica/snapshot", getReplicaSnapshot)
+		authGroup.GET("/debug/crash-reports", listCrashReports)
+		authGroup.GET("/orphans", listOrphans)
+		authGroup.DELETE("/orphans", pruneOrphans)
+		authGroup.GET("/debug/crash-reports/:name", downloadCrashReport)
+		authGroup.GET("/debug/storage", getStorageStats)
+		if viper.GetBool("web.graphql") {
+			authGroup.GET("/graphql", graphqlQuery)
+			authGroup.POST("/graphql", graphqlQuery)
+		}
 	}
 }
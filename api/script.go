@@ -0,0 +1,29 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zaigie/palworld-server-tool/internal/task"
+)
+
+// listScripts godoc
+//
+//	@Summary		List Scripts
+//	@Description	List the scripts of scripts.dir as loaded, with the error of those that failed to load
+//	@Tags			Script
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Success		200	{object}	[]task.Script
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Router			/api/scripts [get]
+func listScripts(c *gin.Context) {
+	scripts, err := task.ListScripts()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, scripts)
+}
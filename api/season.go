@@ -0,0 +1,145 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/internal/paths"
+	"github.com/zaigie/palworld-server-tool/internal/task"
+	"github.com/zaigie/palworld-server-tool/service"
+	"go.etcd.io/bbolt"
+)
+
+type EndSeasonRequest struct {
+	Name string `json:"name"`
+	// SkipBackup ends the season without backing up the save first
+	SkipBackup bool `json:"skip_backup"`
+	// Confirm must be true, without it nothing is reset
+	Confirm bool `json:"confirm"`
+}
+
+// endSeason godoc
+//
+//	@Summary		End Season
+//	@Description	End the season for a wipe: the save is backed up, the database is archived under the season name with the backups not in a season yet, and the players, guilds and their stats are emptied for the next season. Archived backups aren't cleaned up
+//	@Tags			Season
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			request	body		EndSeasonRequest	true	"Season name and confirmation"
+//	@Success		200		{object}	database.Season
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		409		{object}	ErrorResponse
+//	@Failure		428		{object}	ErrorResponse
+//	@Router			/api/seasons [post]
+func endSeason(c *gin.Context) {
+	var req EndSeasonRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !req.Confirm {
+		c.JSON(http.StatusPreconditionRequired, gin.H{"error": "send confirm: true to end the season and reset the stats"})
+		return
+	}
+	season, err := task.EndSeason(database.GetDB(), req.Name, !req.SkipBackup)
+	if err != nil {
+		if errors.Is(err, service.ErrSeasonExists) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, season)
+}
+
+// listSeasons godoc
+//
+//	@Summary		List Seasons
+//	@Description	List the ended seasons, the first first
+//	@Tags			Season
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{array}		database.Season
+//	@Failure		400	{object}	ErrorResponse
+//	@Router			/api/seasons [get]
+func listSeasons(c *gin.Context) {
+	seasons, err := service.ListSeasons(database.GetDB())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, seasons)
+}
+
+// listSeasonPlayers godoc
+//
+//	@Summary		List Season Players
+//	@Description	List the players as they were when the season ended, read from its archive
+//	@Tags			Season
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		int	true	"Season ID"
+//	@Success		200	{array}		database.TersePlayer
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		404	{object}	ErrorResponse
+//	@Router			/api/seasons/{id}/players [get]
+func listSeasonPlayers(c *gin.Context) {
+	withSeason(c, func(db *bbolt.DB) (any, error) {
+		return service.ListPlayers(db)
+	})
+}
+
+// listSeasonGuilds godoc
+//
+//	@Summary		List Season Guilds
+//	@Description	List the guilds as they were when the season ended, read from its archive
+//	@Tags			Season
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		int	true	"Season ID"
+//	@Success		200	{array}		database.Guild
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		404	{object}	ErrorResponse
+//	@Router			/api/seasons/{id}/guilds [get]
+func listSeasonGuilds(c *gin.Context) {
+	withSeason(c, func(db *bbolt.DB) (any, error) {
+		return service.ListGuilds(db)
+	})
+}
+
+// withSeason answers with what read returns from the archive of the season
+// of the id param.
+func withSeason(c *gin.Context, read func(db *bbolt.DB) (any, error)) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid season id"})
+		return
+	}
+	season, err := service.GetSeason(database.GetDB(), id)
+	if err != nil {
+		if err == service.ErrNoRecord {
+			c.JSON(http.StatusNotFound, gin.H{"error": "season not found"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	db, err := service.OpenSeason(season, paths.Seasons())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer db.Close()
+	result, err := read(db)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
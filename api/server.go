@@ -1,12 +1,21 @@
 package api
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/internal/locale"
 	"github.com/zaigie/palworld-server-tool/internal/logger"
 	"github.com/zaigie/palworld-server-tool/internal/tool"
+	"github.com/zaigie/palworld-server-tool/service"
 )
 
 type ServerInfo struct {
@@ -25,8 +34,24 @@ type ServerMetrics struct {
 
 type BroadcastRequest struct {
 	Message string `json:"message"`
+	// Tags only send to the online players with one of the tags, a rank
+	// tag or the name of a player group like vip
+	Tags []string `json:"tags"`
+	// Segment only sends to the online players of the saved segment
+	Segment string `json:"segment"`
 }
 
+type BroadcastResponse struct {
+	Success bool `json:"success"`
+	// Recipients are the nicknames a targeted broadcast went to, nothing is
+	// sent when none is online. It is null for a broadcast to everyone
+	Recipients []string `json:"recipients"`
+}
+
+// maxMentions is how many players a targeted broadcast names, the others
+// are counted.
+const maxMentions = 10
+
 type ShutdownRequest struct {
 	Seconds int    `json:"seconds"`
 	Message string `json:"message"`
@@ -35,6 +60,12 @@ type ShutdownRequest struct {
 type ServerToolResponse struct {
 	Version string `json:"version"`
 	Latest  string `json:"latest"`
+	// UiVersion is the version the web UI was built for
+	UiVersion string `json:"ui_version"`
+	// TimeZone is task.timezone, or the abbreviation of the host's zone,
+	// UtcOffset its current offset like +08:00
+	TimeZone  string `json:"time_zone"`
+	UtcOffset string `json:"utc_offset"`
 }
 
 // getServerTool godoc
@@ -61,7 +92,18 @@ func getServerTool(c *gin.Context) {
 			logger.Errorf("%v\n", err)
 		}
 	}
-	c.JSON(http.StatusOK, gin.H{"version": version, "latest": latest})
+	now := time.Now()
+	timeZone := time.Local.String()
+	if timeZone == "Local" {
+		timeZone, _ = now.Zone()
+	}
+	c.JSON(http.StatusOK, ServerToolResponse{
+		Version:   fmt.Sprint(version),
+		Latest:    latest,
+		UiVersion: uiVersion,
+		TimeZone:  timeZone,
+		UtcOffset: now.Format("-07:00"),
+	})
 }
 
 // getServer godoc
@@ -75,7 +117,7 @@ func getServerTool(c *gin.Context) {
 //	@Failure		400	{object}	ErrorResponse
 //	@Router			/api/server [get]
 func getServer(c *gin.Context) {
-	info, err := tool.Info()
+	info, err := tool.Info(c.Request.Context())
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -95,7 +137,7 @@ func getServer(c *gin.Context) {
 //	@Failure		400	{object}	ErrorResponse
 //	@Router			/api/server/metrics [get]
 func getServerMetrics(c *gin.Context) {
-	metrics, err := tool.Metrics()
+	metrics, err := tool.Metrics(c.Request.Context())
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -110,19 +152,48 @@ func getServerMetrics(c *gin.Context) {
 	})
 }
 
+// listServerEndpoints godoc
+//
+//	@Summary		List Server Endpoints
+//	@Description	List the RCON and REST API addresses of the game server with their health, the address with active true took the last request. Fallbacks are used while the primary address can't be reached
+//	@Tags			Server
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Success		200	{array}		tool.EndpointStatus
+//	@Failure		401	{object}	ErrorResponse
+//	@Router			/api/server/endpoints [get]
+func listServerEndpoints(c *gin.Context) {
+	c.JSON(http.StatusOK, tool.EndpointStatuses())
+}
+
+// listServerRequests godoc
+//
+//	@Summary		List Server Request Statistics
+//	@Description	List the policy of each class of requests to the game server, query, action, save and shutdown, with the requests, retries and failures since pst started
+//	@Tags			Server
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Success		200	{array}		tool.RequestStats
+//	@Failure		401	{object}	ErrorResponse
+//	@Router			/api/server/requests [get]
+func listServerRequests(c *gin.Context) {
+	c.JSON(http.StatusOK, tool.RequestStatistics())
+}
+
 // publishBroadcast godoc
 //
 //	@Summary		Publish Broadcast
-//	@Description	Publish Broadcast
+//	@Description	Publish Broadcast. With tags or segment it only targets the matching online players, the game has no messages to single players so the broadcast starts with their names
 //	@Tags			Server
 //	@Accept			json
 //	@Produce		json
 //	@Security		ApiKeyAuth
 //	@Param			broadcast	body		BroadcastRequest	true	"Broadcast"
 //
-//	@Success		200			{object}	SuccessResponse
+//	@Success		200			{object}	BroadcastResponse
 //	@Failure		400			{object}	ErrorResponse
 //	@Failure		401			{object}	ErrorResponse
+//	@Failure		404			{object}	ErrorResponse
 //	@Router			/api/server/broadcast [post]
 func publishBroadcast(c *gin.Context) {
 	var req BroadcastRequest
@@ -134,11 +205,99 @@ func publishBroadcast(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	if err := tool.Broadcast(req.Message); err != nil {
+	message := req.Message
+	var recipients []string
+	if len(req.Tags) > 0 || req.Segment != "" {
+		var err error
+		if recipients, err = broadcastRecipients(c.Request.Context(), req); err != nil {
+			segmentError(c, err)
+			return
+		}
+		if len(recipients) == 0 {
+			c.JSON(http.StatusOK, BroadcastResponse{Success: true, Recipients: []string{}})
+			return
+		}
+		message = locale.T(locale.Broadcast, "broadcast.mention", "names", mentions(recipients), "message", req.Message)
+	}
+	if err := tool.Broadcast(c.Request.Context(), message); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"success": true})
+	c.JSON(http.StatusOK, BroadcastResponse{Success: true, Recipients: recipients})
+}
+
+// broadcastRecipients returns the nicknames of the online players with one
+// of the tags of req and in its segment.
+func broadcastRecipients(ctx context.Context, req BroadcastRequest) ([]string, error) {
+	db := database.GetDB()
+	inSegment := func(database.TersePlayer) bool { return true }
+	if req.Segment != "" {
+		var err error
+		if inSegment, err = segmentMatcher(db, req.Segment); err != nil {
+			return nil, err
+		}
+	}
+	tagged := make(map[string]bool)
+	if len(req.Tags) > 0 {
+		playtimes, err := service.ListPlaytimes(db)
+		if err != nil {
+			return nil, err
+		}
+		for _, playtime := range playtimes {
+			if slices.ContainsFunc(playtime.Tags, func(tag string) bool { return slices.Contains(req.Tags, tag) }) {
+				tagged[playtime.PlayerUid] = true
+			}
+		}
+	}
+	var groups []database.PlayerGroup
+	if len(req.Tags) > 0 {
+		all, err := service.ListPlayerGroups(db)
+		if err != nil {
+			return nil, err
+		}
+		for _, group := range all {
+			if slices.Contains(req.Tags, group.Name) {
+				groups = append(groups, group)
+			}
+		}
+	}
+
+	online, err := tool.ShowPlayers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	recipients := make([]string, 0)
+	for _, p := range online {
+		if len(req.Tags) > 0 && !tagged[p.PlayerUid] && !slices.ContainsFunc(groups, func(group database.PlayerGroup) bool {
+			return slices.ContainsFunc(group.Members, func(member database.GroupMember) bool {
+				return service.IsGroupMember(member, p.PlayerUid, p.SteamId)
+			})
+		}) {
+			continue
+		}
+		player, err := service.GetPlayer(db, p.PlayerUid)
+		if err != nil {
+			player.TersePlayer = database.TersePlayer{PlayerUid: p.PlayerUid, Nickname: p.Nickname, Level: p.Level}
+		}
+		player.OnlinePlayer = p
+		if inSegment(player.TersePlayer) {
+			recipients = append(recipients, p.Nickname)
+		}
+	}
+	return recipients, nil
+}
+
+// mentions names the players like @alice @bob +3.
+func mentions(nicknames []string) string {
+	names := make([]string, 0, maxMentions+1)
+	for i, nickname := range nicknames {
+		if i == maxMentions {
+			names = append(names, fmt.Sprintf("+%d", len(nicknames)-maxMentions))
+			break
+		}
+		names = append(names, "@"+nickname)
+	}
+	return strings.Join(names, " ")
 }
 
 // shutdownServer godoc
@@ -168,7 +327,7 @@ func shutdownServer(c *gin.Context) {
 	if req.Seconds == 0 {
 		req.Seconds = 60
 	}
-	if err := tool.Shutdown(req.Seconds, req.Message); err != nil {
+	if err := tool.Shutdown(c.Request.Context(), req.Seconds, req.Message); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -181,3 +340,35 @@ func validateMessage(message string) error {
 	}
 	return nil
 }
+
+// listServerMetrics godoc
+//
+//	@Summary		List Server Metrics History
+//	@Description	List the sampled server FPS and frame time with the player and base camp counts, oldest first
+//	@Tags			Server
+//	@Accept			json
+//	@Produce		json
+//	@Param			hours	query		int	false	"hours back, default 24"
+//	@Param			points	query		int	false	"max samples, averaged down, default 288"
+//	@Success		200		{array}		database.MetricSample
+//	@Failure		400		{object}	ErrorResponse
+//	@Router			/api/server/metrics/history [get]
+func listServerMetrics(c *gin.Context) {
+	hours, err := strconv.Atoi(c.DefaultQuery("hours", "24"))
+	if err != nil || hours <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid hours"})
+		return
+	}
+	points, err := strconv.Atoi(c.DefaultQuery("points", "288"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid points"})
+		return
+	}
+	since := time.Now().Add(-time.Duration(hours) * time.Hour)
+	samples, err := service.ListMetricSamples(database.GetDB(), since, points)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, samples)
+}
@@ -0,0 +1,150 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/internal/task"
+	"github.com/zaigie/palworld-server-tool/service"
+)
+
+type ServerJobRequest struct {
+	Seconds int    `json:"seconds"`
+	Message string `json:"message"`
+	Update  bool   `json:"update"`
+}
+
+// getServerState godoc
+//
+//	@Summary		Get Server State
+//	@Description	Get the lifecycle state of the game server and the running job
+//	@Tags			Server
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Success		200	{object}	task.ServerState
+//	@Failure		401	{object}	ErrorResponse
+//	@Router			/api/server/state [get]
+func getServerState(c *gin.Context) {
+	c.JSON(http.StatusOK, task.GetServerState())
+}
+
+// startServerJob godoc
+//
+//	@Summary		Stop, Start, Restart or Update Server
+//	@Description	Start a job that stops the server after a countdown broadcast, starts it with server.start_command, restarts it or updates it with server.update_command. {action} and {seconds} in message are replaced
+//	@Tags			Server
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			action	path		string				true	"stop, start, restart or update"
+//	@Param			job		body		ServerJobRequest	false	"Options"
+//	@Success		200		{object}	database.ServerJob
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		409		{object}	ErrorResponse
+//	@Router			/api/server/{action} [post]
+func startServerJob(action string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req ServerJobRequest
+		if c.Request.ContentLength > 0 {
+			if err := c.ShouldBindJSON(&req); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+		if req.Seconds < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "seconds cannot be negative"})
+			return
+		}
+		job, err := task.StartServerJob(database.GetDB(), action, task.ServerJobOptions{
+			Seconds: req.Seconds,
+			Message: req.Message,
+			Update:  req.Update,
+		})
+		if err != nil {
+			if err == task.ErrServerBusy {
+				c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, job)
+	}
+}
+
+// listServerJobs godoc
+//
+//	@Summary		List Server Jobs
+//	@Description	List lifecycle jobs, latest first
+//	@Tags			Server
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			limit	query		int	false	"Max number of jobs, default 20"
+//	@Success		200		{array}		database.ServerJob
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Router			/api/server/jobs [get]
+func listServerJobs(c *gin.Context) {
+	limit := 20
+	if s := c.Query("limit"); s != "" {
+		var err error
+		limit, err = strconv.Atoi(s)
+		if err != nil || limit < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+			return
+		}
+	}
+	jobs, err := service.ListServerJobs(database.GetDB(), limit)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, jobs)
+}
+
+// getServerJob godoc
+//
+//	@Summary		Get Server Job
+//	@Description	Get a lifecycle job with the output of its steps
+//	@Tags			Server
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			id	path		string	true	"Job ID"
+//	@Success		200	{object}	database.ServerJob
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		404	{object}	ErrorResponse
+//	@Router			/api/server/jobs/{id} [get]
+func getServerJob(c *gin.Context) {
+	job, err := service.GetServerJob(database.GetDB(), c.Param("id"))
+	if err != nil {
+		if err == service.ErrNoRecord {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, job)
+}
+
+// getUpdateStatus godoc
+//
+//	@Summary		Get Update Status
+//	@Description	Get the result of the last check for a new server build on Steam, run it again by POST /api/tasks/update_check/run
+//	@Tags			Server
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Success		200	{object}	task.UpdateStatus
+//	@Failure		401	{object}	ErrorResponse
+//	@Router			/api/server/update_status [get]
+func getUpdateStatus(c *gin.Context) {
+	c.JSON(http.StatusOK, task.GetUpdateStatus())
+}
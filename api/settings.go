@@ -0,0 +1,239 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/internal/tool"
+	"github.com/zaigie/palworld-server-tool/service"
+)
+
+type ApplyPresetRequest struct {
+	Restart bool   `json:"restart"`
+	Seconds int    `json:"seconds"`
+	Message string `json:"message"`
+}
+
+type ApplyPresetResponse struct {
+	Success bool   `json:"success"`
+	Backup  string `json:"backup"`
+	// SnapshotId reverts the change with /api/snapshot/{id}/revert
+	SnapshotId uint64 `json:"snapshot_id"`
+}
+
+// getSettings godoc
+//
+//	@Summary		Get Server Settings
+//	@Description	Get OptionSettings of PalWorldSettings.ini
+//	@Tags			Settings
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Success		200	{object}	[]tool.SettingOption
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Router			/api/server/settings [get]
+func getSettings(c *gin.Context) {
+	settings, err := tool.ReadSettings()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, settings)
+}
+
+// listSettingsPresets godoc
+//
+//	@Summary		List Settings Presets
+//	@Description	List Settings Presets
+//	@Tags			Settings
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Success		200	{object}	[]database.SettingsPreset
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Router			/api/server/settings/preset [get]
+func listSettingsPresets(c *gin.Context) {
+	presets, err := service.ListSettingsPresets(database.GetDB())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, presets)
+}
+
+// putSettingsPreset godoc
+//
+//	@Summary		Put Settings Preset
+//	@Description	Create or replace a named settings preset
+//	@Tags			Settings
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			name	path		string					true	"Preset Name"
+//	@Param			preset	body		database.SettingsPreset	true	"Settings Preset"
+//	@Success		200		{object}	SuccessResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Router			/api/server/settings/preset/{name} [put]
+func putSettingsPreset(c *gin.Context) {
+	var preset database.SettingsPreset
+	if err := c.ShouldBindJSON(&preset); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	preset.Name = c.Param("name")
+	if len(preset.Values) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "preset values cannot be empty"})
+		return
+	}
+	if err := tool.ValidateSettings(preset.Values); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := service.PutSettingsPreset(database.GetDB(), preset); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// removeSettingsPreset godoc
+//
+//	@Summary		Remove Settings Preset
+//	@Description	Remove Settings Preset
+//	@Tags			Settings
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			name	path		string	true	"Preset Name"
+//	@Success		200		{object}	SuccessResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Router			/api/server/settings/preset/{name} [delete]
+func removeSettingsPreset(c *gin.Context) {
+	if err := service.RemoveSettingsPreset(database.GetDB(), c.Param("name")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// applySettingsPreset godoc
+//
+//	@Summary		Apply Settings Preset
+//	@Description	Backup PalWorldSettings.ini, then write the preset values into it and optionally restart the server. The response has the id of a snapshot for /api/snapshot/{id}/revert
+//	@Tags			Settings
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			name	path		string				true	"Preset Name"
+//	@Param			apply	body		ApplyPresetRequest	false	"Apply Options"
+//	@Param			dry_run	query		bool				false	"Only return the settings that would change and the shutdown, as a DryRunResponse"
+//	@Success		200		{object}	ApplyPresetResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Router			/api/server/settings/preset/{name} [post]
+func applySettingsPreset(c *gin.Context) {
+	var req ApplyPresetRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	preset, err := service.GetSettingsPreset(database.GetDB(), c.Param("name"))
+	if err != nil {
+		if err == service.ErrNoRecord {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Preset not found"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := tool.ValidateSettings(preset.Values); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Restart {
+		if err := validateMessage(req.Message); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if req.Seconds == 0 {
+			req.Seconds = 60
+		}
+	}
+	if isDryRun(c) {
+		changes, err := settingsChanges(preset.Values)
+		if err == nil && req.Restart {
+			changes = append(changes, commandChange("shutdown", tool.RequestShutdown{Waittime: req.Seconds, Message: req.Message}))
+		}
+		writeDryRun(c, changes, err)
+		return
+	}
+	backup, err := tool.BackupSettings()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	snapshot, err := service.TakeSnapshot(database.GetDB(), service.SnapshotSettingsApply, requestBy(c), nil, backup)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if _, err := tool.UpdateSettings(preset.Values); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Restart {
+		if err := tool.Shutdown(c.Request.Context(), req.Seconds, req.Message); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "backup": backup, "snapshot_id": snapshot.Id})
+}
+
+// settingsChanges returns the changes UpdateSettings would make with values.
+func settingsChanges(values map[string]string) ([]service.Change, error) {
+	options, err := tool.ReadSettings()
+	if err != nil {
+		return nil, err
+	}
+	current := make(map[string]string, len(options))
+	for _, option := range options {
+		current[option.Key] = option.Value
+	}
+	changes := make([]service.Change, 0)
+	for key, value := range values {
+		old, ok := current[key]
+		change := service.Change{Target: "PalWorldSettings.ini", Key: key}
+		switch {
+		case value == "" && !ok, value == old:
+			continue
+		case value == "":
+			change.Action = service.ChangeRemove
+		case !ok:
+			change.Action = service.ChangeAdd
+		default:
+			change.Action = service.ChangeUpdate
+		}
+		if ok {
+			change.Before, _ = json.Marshal(old)
+		}
+		if value != "" {
+			change.After, _ = json.Marshal(value)
+		}
+		changes = append(changes, change)
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Key < changes[j].Key
+	})
+	return changes, nil
+}
@@ -0,0 +1,181 @@
+package api
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zaigie/palworld-server-tool/internal/config"
+	"github.com/zaigie/palworld-server-tool/internal/doctor"
+	"github.com/zaigie/palworld-server-tool/internal/logger"
+	"github.com/zaigie/palworld-server-tool/internal/validate"
+	"github.com/zaigie/palworld-server-tool/service"
+)
+
+type SetupRequest struct {
+	WebPassword string `json:"web_password"`
+	// RconAddress is like 127.0.0.1:25575
+	RconAddress string `json:"rcon_address"`
+	// RestAddress is like http://127.0.0.1:8212
+	RestAddress string `json:"rest_address"`
+	// AdminPassword is AdminPassword of PalWorldSettings.ini
+	AdminPassword string `json:"admin_password"`
+	SavePath      string `json:"save_path"`
+}
+
+type SetupStatus struct {
+	// Required is true until the setup wrote the config, the other routes
+	// answer 503 until then
+	Required   bool   `json:"required"`
+	ConfigPath string `json:"config_path"`
+}
+
+var setup struct {
+	sync.Mutex
+	required bool
+	// token must be sent with the setup, it is only in the log so only
+	// who runs pst can set it up
+	token string
+	done  chan struct{}
+}
+
+// StartSetup turns on the first-run setup, only /api/setup answers until it
+// wrote the config. It returns the one-time token the setup must be sent
+// with.
+func StartSetup() string {
+	setup.Lock()
+	defer setup.Unlock()
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		logger.Panicf("Failed to generate the setup token: %v\n", err)
+	}
+	setup.required = true
+	setup.token = hex.EncodeToString(b)
+	setup.done = make(chan struct{})
+	return setup.token
+}
+
+// SetupDone is closed once the setup wrote the config, or right away when
+// there was no setup.
+func SetupDone() <-chan struct{} {
+	setup.Lock()
+	defer setup.Unlock()
+	if setup.done == nil {
+		setup.done = make(chan struct{})
+		close(setup.done)
+	}
+	return setup.done
+}
+
+func setupRequired() bool {
+	setup.Lock()
+	defer setup.Unlock()
+	return setup.required
+}
+
+// setupMiddleware turns away the API during the setup, without a config
+// web.password is empty and anyone could log in.
+func setupMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if strings.HasPrefix(c.Request.URL.Path, "/api/") && c.FullPath() != "/api/setup" && setupRequired() {
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "setup required, see /api/setup"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// getSetup godoc
+//
+//	@Summary		Get Setup Status
+//	@Description	Whether pst started without a config and waits for the first-run setup
+//	@Tags			Setup
+//	@Produce		json
+//	@Success		200	{object}	SetupStatus
+//	@Router			/api/setup [get]
+func getSetup(c *gin.Context) {
+	c.JSON(http.StatusOK, SetupStatus{Required: setupRequired(), ConfigPath: config.SetupPath()})
+}
+
+// runSetup godoc
+//
+//	@Summary		Run Setup
+//	@Description	Check the settings against the game server and the save, then write config.yaml and start the tasks. Only available once, while pst runs without a config, with the setup token pst logs at startup. A failed check is returned as a 400 with the fields at fault
+//	@Tags			Setup
+//	@Accept			json
+//	@Produce		json
+//	@Param			X-Setup-Token	header		string			true	"Setup token from the log"
+//	@Param			request			body		SetupRequest	true	"Settings"
+//	@Param			dry_run			query		bool			false	"Only check the settings, as a DryRunResponse"
+//	@Success		200				{object}	SetupStatus
+//	@Failure		400				{object}	ErrorResponse
+//	@Failure		401				{object}	ErrorResponse
+//	@Failure		409				{object}	ErrorResponse
+//	@Router			/api/setup [post]
+func runSetup(c *gin.Context) {
+	var req SetupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var v validate.Validator
+	v.Required("web_password", req.WebPassword)
+	v.Required("rcon_address", req.RconAddress)
+	v.Required("rest_address", req.RestAddress)
+	v.Required("admin_password", req.AdminPassword)
+	v.Required("save_path", req.SavePath)
+	if err := v.Err(); err != nil {
+		badRequest(c, err)
+		return
+	}
+
+	// one setup at a time
+	setup.Lock()
+	defer setup.Unlock()
+	if !setup.required {
+		c.JSON(http.StatusConflict, gin.H{"error": "setup is done, edit the config instead"})
+		return
+	}
+	// checked before the settings, which connect to what they point to
+	if subtle.ConstantTimeCompare([]byte(c.GetHeader("X-Setup-Token")), []byte(setup.token)) != 1 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "wrong setup token, see the pst log"})
+		return
+	}
+	s := config.Setup{
+		WebPassword:   req.WebPassword,
+		RconAddress:   req.RconAddress,
+		RestAddress:   req.RestAddress,
+		AdminPassword: req.AdminPassword,
+		SavePath:      req.SavePath,
+	}
+	for _, p := range doctor.Setup(s) {
+		v.Add(config.SetupKeys[p.Key], "%s, %s", p.Message, p.Fix)
+	}
+	if err := v.Err(); err != nil {
+		badRequest(c, err)
+		return
+	}
+	path := config.SetupPath()
+	if isDryRun(c) {
+		after, _ := json.Marshal(map[string]string{"rcon_address": req.RconAddress, "rest_address": req.RestAddress, "save_path": req.SavePath})
+		c.JSON(http.StatusOK, DryRunResponse{DryRun: true, Changes: []service.Change{
+			{Action: service.ChangeAdd, Target: "config", Key: path, After: after},
+		}})
+		return
+	}
+	if err := s.Write(path); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	s.Apply()
+	logger.Infof("Setup wrote %s\n", path)
+	setup.required = false
+	setup.token = ""
+	close(setup.done)
+	c.JSON(http.StatusOK, SetupStatus{Required: false, ConfigPath: path})
+}
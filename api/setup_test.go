@@ -0,0 +1,45 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+)
+
+func TestRunSetupNeedsToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	token := StartSetup()
+	defer func() {
+		setup.required = false
+		setup.token = ""
+	}()
+	body := `{"web_password":"pw","rcon_address":"127.0.0.1:1","rest_address":"http://127.0.0.1:1","admin_password":"admin","save_path":"/nowhere"}`
+	tests := []struct {
+		name  string
+		token string
+		want  int
+	}{
+		{"no token", "", http.StatusUnauthorized},
+		{"wrong token", strings.Repeat("0", len(token)), http.StatusUnauthorized},
+		{"prefix", token[:8], http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		r := gin.New()
+		r.POST("/api/setup", runSetup)
+		req := httptest.NewRequest(http.MethodPost, "/api/setup", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Setup-Token", tt.token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tt.want {
+			t.Errorf("%s: status %d, want %d", tt.name, w.Code, tt.want)
+		}
+		if got := viper.GetString("web.password"); got != "" {
+			t.Fatalf("%s: web.password is %q, the refused setup was applied", tt.name, got)
+		}
+	}
+}
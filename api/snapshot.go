@@ -0,0 +1,120 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/internal/task"
+	"github.com/zaigie/palworld-server-tool/internal/tool"
+	"github.com/zaigie/palworld-server-tool/service"
+)
+
+type SnapshotResponse struct {
+	Success    bool   `json:"success"`
+	SnapshotId uint64 `json:"snapshot_id"`
+}
+
+// listSnapshots godoc
+//
+//	@Summary		List Snapshots
+//	@Description	List the snapshots taken before replacing the whitelist, applying settings presets and reverting snapshots, newest first
+//	@Tags			Snapshot
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			limit	query		int	false	"max number of snapshots, default 100"
+//	@Success		200		{array}		database.Snapshot
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Router			/api/snapshot [get]
+func listSnapshots(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+		return
+	}
+	snapshots, err := service.ListSnapshots(database.GetDB(), limit)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, snapshots)
+}
+
+// revertSnapshot godoc
+//
+//	@Summary		Revert Snapshot
+//	@Description	Undo the operation of a snapshot, putting back the whitelist or PalWorldSettings.ini as they were before it. What the revert replaces is kept in a snapshot.revert snapshot, and an admin.restore event is recorded. Changes made since are lost, a settings change takes effect on the next server restart
+//	@Tags			Snapshot
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			id	path		int	true	"Snapshot ID"
+//	@Success		200	{object}	database.Snapshot
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		404	{object}	EmptyResponse
+//	@Router			/api/snapshot/{id}/revert [post]
+func revertSnapshot(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	snapshot, err := service.GetSnapshot(database.GetDB(), id)
+	if err != nil {
+		if err == service.ErrNoRecord {
+			c.JSON(http.StatusNotFound, gin.H{})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !snapshot.RevertedAt.IsZero() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": service.ErrAlreadyReverted.Error()})
+		return
+	}
+	// what the revert replaces is kept in a snapshot of its own
+	var backup string
+	if snapshot.SettingsBackup != "" {
+		if backup, err = tool.BackupSettings(); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	buckets := make([]string, 0, len(snapshot.Buckets))
+	for name := range snapshot.Buckets {
+		buckets = append(buckets, name)
+	}
+	before, err := service.TakeSnapshot(database.GetDB(), service.SnapshotRevert, requestBy(c), buckets, backup)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if snapshot.SettingsBackup != "" {
+		if err := tool.RestoreSettings(snapshot.SettingsBackup); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	snapshot, err = service.RevertSnapshot(database.GetDB(), id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	task.RecordEvent(database.GetDB(), database.Event{
+		Type:    service.EventAdminRestore,
+		Message: fmt.Sprintf("Snapshot %d of %s reverted by %s", snapshot.Id, snapshot.Operation, requestBy(c)),
+		Data: map[string]string{
+			"action":      "snapshot_revert",
+			"by":          requestBy(c),
+			"reverted_id": strconv.FormatUint(snapshot.Id, 10),
+			"operation":   snapshot.Operation,
+			"snapshot_id": strconv.FormatUint(before.Id, 10),
+		},
+	})
+	c.JSON(http.StatusOK, snapshot)
+}
@@ -0,0 +1,167 @@
+package api
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+	"github.com/zaigie/palworld-server-tool/internal/auth"
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/service"
+)
+
+type SteamLoginResponse struct {
+	Token   string `json:"token"`
+	SteamId string `json:"steam_id"`
+	// Players are the characters of the Steam account
+	Players []database.TersePlayer `json:"players"`
+}
+
+type MeResponse struct {
+	SteamId     string                 `json:"steam_id"`
+	Players     []database.TersePlayer `json:"players"`
+	Whitelisted bool                   `json:"whitelisted"`
+}
+
+// steamReturnTo is where Steam sends the player back to, redirect is passed
+// on to the callback.
+func steamReturnTo(redirect string) string {
+	returnTo := strings.TrimSuffix(viper.GetString("web.public_url"), "/") + "/api/auth/steam/callback"
+	if redirect != "" {
+		returnTo += "?redirect=" + url.QueryEscape(redirect)
+	}
+	return returnTo
+}
+
+// sameSite is whether redirect is a path or a url of web.public_url, so the
+// token isn't handed to another site.
+func sameSite(redirect string) bool {
+	if redirect == "" {
+		return true
+	}
+	if strings.HasPrefix(redirect, "/") && !strings.HasPrefix(redirect, "//") && !strings.HasPrefix(redirect, "/\\") {
+		return true
+	}
+	u, err := url.Parse(redirect)
+	if err != nil {
+		return false
+	}
+	public, err := url.Parse(viper.GetString("web.public_url"))
+	return err == nil && u.Scheme == public.Scheme && u.Host == public.Host
+}
+
+// steamLogin godoc
+//
+//	@Summary		Steam Login
+//	@Description	Send the player to Steam to sign in, Steam sends them back to /api/auth/steam/callback. Needs web.steam_login and web.public_url
+//	@Tags			Auth
+//	@Param			redirect	query		string	false	"path or url of web.public_url the callback sends the player to, with the token in the steam_token fragment"
+//	@Success		302			{string}	string	"Redirect to Steam"
+//	@Failure		400			{object}	ErrorResponse
+//	@Failure		404			{object}	ErrorResponse
+//	@Router			/api/auth/steam [get]
+func steamLogin(c *gin.Context) {
+	if !viper.GetBool("web.steam_login") {
+		c.JSON(http.StatusNotFound, gin.H{"error": "steam login is off"})
+		return
+	}
+	redirect := c.Query("redirect")
+	if !sameSite(redirect) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "redirect must be on web.public_url"})
+		return
+	}
+	realm := strings.TrimSuffix(viper.GetString("web.public_url"), "/")
+	c.Redirect(http.StatusFound, auth.SteamLoginUrl(realm, steamReturnTo(redirect)))
+}
+
+// steamCallback godoc
+//
+//	@Summary		Steam Login Callback
+//	@Description	Where Steam sends the player back to. The login is checked with Steam and a player token issued, which only opens the routes of the player. With redirect the player is sent there with the token in the steam_token fragment
+//	@Tags			Auth
+//	@Produce		json
+//	@Param			redirect	query		string	false	"passed on from /api/auth/steam"
+//	@Success		200			{object}	SteamLoginResponse
+//	@Success		302			{string}	string	"Redirect with the token"
+//	@Failure		400			{object}	ErrorResponse
+//	@Failure		401			{object}	ErrorResponse
+//	@Failure		404			{object}	ErrorResponse
+//	@Router			/api/auth/steam/callback [get]
+func steamCallback(c *gin.Context) {
+	if !viper.GetBool("web.steam_login") {
+		c.JSON(http.StatusNotFound, gin.H{"error": "steam login is off"})
+		return
+	}
+	redirect := c.Query("redirect")
+	if !sameSite(redirect) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "redirect must be on web.public_url"})
+		return
+	}
+	query := c.Request.URL.Query()
+	steamId, err := auth.VerifySteamLogin(query, steamReturnTo(redirect))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	token, err := auth.GeneratePlayerToken(steamId, time.Duration(viper.GetInt("web.player_token_hours"))*time.Hour)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "could not generate token"})
+		return
+	}
+	if redirect != "" {
+		c.Redirect(http.StatusFound, redirect+"#steam_token="+url.QueryEscape(token))
+		return
+	}
+	players, err := service.ListPlayersBySteamId(database.GetDB(), steamId)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, SteamLoginResponse{Token: token, SteamId: steamId, Players: players})
+}
+
+// getMe godoc
+//
+//	@Summary		Get Me
+//	@Description	The Steam account of the player token, with its characters and whether it's whitelisted
+//	@Tags			Auth
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Success		200	{object}	MeResponse
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		403	{object}	ErrorResponse
+//	@Router			/api/me [get]
+func getMe(c *gin.Context) {
+	me := MeResponse{SteamId: steamIdOf(c)}
+	var err error
+	if me.Players, err = service.ListPlayersBySteamId(database.GetDB(), me.SteamId); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	whitelist, err := service.ListWhitelist(database.GetDB())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	for _, entry := range whitelist {
+		if entry.SteamID == me.SteamId {
+			me.Whitelisted = true
+			break
+		}
+	}
+	c.JSON(http.StatusOK, me)
+}
+
+// steamIdOf is the Steam account signed in with the player token of the
+// request.
+func steamIdOf(c *gin.Context) string {
+	claims, _ := c.Get("claims")
+	mapClaims, _ := claims.(jwt.MapClaims)
+	steamId, _ := mapClaims["steam_id"].(string)
+	return steamId
+}
@@ -1,11 +1,16 @@
 package api
 
 import (
+	"context"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
 	"github.com/zaigie/palworld-server-tool/internal/database"
 	"github.com/zaigie/palworld-server-tool/internal/task"
+	"github.com/zaigie/palworld-server-tool/service"
+	"go.etcd.io/bbolt"
 )
 
 type From string
@@ -41,3 +46,216 @@ func syncData(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, gin.H{"error": "invalid from"})
 }
+
+// syncBatch is the sync batch sav_cli journals a put in, 0 outside of one.
+func syncBatch(c *gin.Context) uint64 {
+	id, _ := strconv.ParseUint(c.GetHeader("X-Sync-Batch"), 10, 64)
+	return id
+}
+
+// listSyncJournal godoc
+//
+//	@Summary		List Sync Journal
+//	@Description	List the journaled save syncs newest first, with the hash and time of the save and what each step changed. save.journal_keep are kept
+//	@Tags			Sync
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			limit	query		int	false	"at most this many batches"
+//	@Success		200		{array}		database.SyncBatch
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Router			/api/sync/journal [get]
+func listSyncJournal(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	batches, err := service.ListSyncBatches(database.GetDB(), limit)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, batches)
+}
+
+// getSyncBatch godoc
+//
+//	@Summary		Get Sync Batch
+//	@Description	Get a journaled save sync
+//	@Tags			Sync
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			id	path		int	true	"Batch ID"
+//	@Success		200	{object}	database.SyncBatch
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		404	{object}	EmptyResponse
+//	@Router			/api/sync/journal/{id} [get]
+func getSyncBatch(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	batch, err := service.GetSyncBatch(database.GetDB(), id)
+	if err != nil {
+		if err == service.ErrNoRecord {
+			c.JSON(http.StatusNotFound, gin.H{})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, batch)
+}
+
+// rollbackSyncBatch godoc
+//
+//	@Summary		Rollback Sync Batch
+//	@Description	Put the players, guilds and map objects back as they were before the save sync. Records changed since, like a player who came online, are left as they are and counted in rollback_skipped. Only the latest batch not rolled back yet can be, roll back newer ones first. Events and stats derived during the sync stay
+//	@Tags			Sync
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			id	path		int	true	"Batch ID"
+//	@Success		200	{object}	database.SyncBatch
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		404	{object}	EmptyResponse
+//	@Failure		409	{object}	ErrorResponse
+//	@Router			/api/sync/journal/{id}/rollback [post]
+func rollbackSyncBatch(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	batch, err := service.RollbackSyncBatch(database.GetDB(), id)
+	if err != nil {
+		switch err {
+		case service.ErrNoRecord:
+			c.JSON(http.StatusNotFound, gin.H{})
+		case service.ErrNotLatestBatch, service.ErrSyncBatchRolledBack, service.ErrSyncBatchRunning:
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, batch)
+}
+
+// replaySyncBatch godoc
+//
+//	@Summary		Replay Sync Batch
+//	@Description	Apply again what the save sent in a journaled sync, as a new batch, to finish a partial sync or redo one rolled back
+//	@Tags			Sync
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			id	path		int	true	"Batch ID"
+//	@Success		200	{object}	database.SyncBatch
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		404	{object}	EmptyResponse
+//	@Failure		409	{object}	ErrorResponse
+//	@Router			/api/sync/journal/{id}/replay [post]
+func replaySyncBatch(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	db := database.GetDB()
+	source, err := service.GetSyncBatch(db, id)
+	if err != nil {
+		if err == service.ErrNoRecord {
+			c.JSON(http.StatusNotFound, gin.H{})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if source.Status == service.SyncStatusRunning {
+		c.JSON(http.StatusConflict, gin.H{"error": service.ErrSyncBatchRunning.Error()})
+		return
+	}
+	batch, err := service.BeginSyncBatch(db, database.SyncBatch{
+		Source:   "replay",
+		SaveHash: source.SaveHash,
+		SaveTime: source.SaveTime,
+		ReplayOf: id,
+	})
+	if err != nil {
+		if err == service.ErrSyncBatchRunning {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	err = replaySteps(c.Request.Context(), db, id, batch.Id)
+	batch, ferr := service.FinishSyncBatch(db, batch.Id, err, viper.GetInt("save.journal_keep"))
+	if err == nil {
+		err = ferr
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, batch)
+}
+
+// replaySteps applies the payloads journaled for the steps of a batch in
+// the order sav_cli puts them, journaled in batch.
+func replaySteps(ctx context.Context, db *bbolt.DB, id, batch uint64) error {
+	var players []database.Player
+	if err := service.GetSyncPayload(db, id, service.SyncStepPlayers, &players); err == nil {
+		if err := service.JournalStep(db, batch, service.SyncStepPlayers, players, len(players), func(j *service.StepJournal) error {
+			return applyPlayers(ctx, players, j)
+		}); err != nil {
+			return err
+		}
+	} else if err != service.ErrNoRecord {
+		return err
+	}
+	var guilds []database.Guild
+	if err := service.GetSyncPayload(db, id, service.SyncStepGuilds, &guilds); err == nil {
+		mode, err := syncStepMode(db, id, service.SyncStepGuilds, service.GuildsMerge)
+		if err != nil {
+			return err
+		}
+		if err := service.JournalStep(db, batch, service.SyncStepGuilds, guilds, len(guilds), func(j *service.StepJournal) error {
+			return applyGuilds(ctx, guilds, mode, j)
+		}); err != nil {
+			return err
+		}
+	} else if err != service.ErrNoRecord {
+		return err
+	}
+	var objects []database.MapObject
+	if err := service.GetSyncPayload(db, id, service.SyncStepMapObjects, &objects); err == nil {
+		if err := service.JournalStep(db, batch, service.SyncStepMapObjects, objects, len(objects), func(j *service.StepJournal) error {
+			return applyMapObjects(objects, j)
+		}); err != nil {
+			return err
+		}
+	} else if err != service.ErrNoRecord {
+		return err
+	}
+	return nil
+}
+
+// syncStepMode is the mode the step of batch id wrote its records by, def
+// for steps journaled without one.
+func syncStepMode(db *bbolt.DB, id uint64, step, def string) (string, error) {
+	batch, err := service.GetSyncBatch(db, id)
+	if err != nil {
+		return "", err
+	}
+	for _, s := range batch.Steps {
+		if s.Name == step && s.Mode != "" {
+			return s.Mode, nil
+		}
+	}
+	return def, nil
+}
@@ -0,0 +1,119 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zaigie/palworld-server-tool/internal/task"
+)
+
+// listTasks godoc
+//
+//	@Summary		List Tasks
+//	@Description	List scheduled tasks with their schedule, state and last result
+//	@Tags			Task
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Success		200	{object}	[]task.TaskInfo
+//	@Failure		401	{object}	ErrorResponse
+//	@Router			/api/tasks [get]
+func listTasks(c *gin.Context) {
+	c.JSON(http.StatusOK, task.ListTasks())
+}
+
+// getTask godoc
+//
+//	@Summary		Get Task
+//	@Description	Get a task with its last result
+//	@Tags			Task
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			name	path		string	true	"Task Name"
+//	@Success		200		{object}	task.TaskInfo
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Router			/api/tasks/{name} [get]
+func getTask(c *gin.Context) {
+	info, err := task.GetTask(c.Param("name"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, info)
+}
+
+// pauseTask godoc
+//
+//	@Summary		Pause Task
+//	@Description	Skip the scheduled runs of a task until it is resumed
+//	@Tags			Task
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			name	path		string	true	"Task Name"
+//	@Success		200		{object}	SuccessResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Router			/api/tasks/{name}/pause [post]
+func pauseTask(c *gin.Context) {
+	if err := task.PauseTask(c.Param("name")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// resumeTask godoc
+//
+//	@Summary		Resume Task
+//	@Description	Resume a paused task
+//	@Tags			Task
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			name	path		string	true	"Task Name"
+//	@Success		200		{object}	SuccessResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Router			/api/tasks/{name}/resume [post]
+func resumeTask(c *gin.Context) {
+	if err := task.ResumeTask(c.Param("name")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// runTask godoc
+//
+//	@Summary		Run Task
+//	@Description	Run a task now in the background, its result shows up as the last result
+//	@Tags			Task
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			name	path		string	true	"Task Name"
+//	@Success		200		{object}	SuccessResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Router			/api/tasks/{name}/run [post]
+func runTask(c *gin.Context) {
+	info, err := task.GetTask(c.Param("name"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if info.Paused {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "task is paused"})
+		return
+	}
+	if info.Running {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "task is already running"})
+		return
+	}
+	go task.RunTask(info.Name)
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
@@ -0,0 +1,28 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/service"
+)
+
+// getTechnologyStats godoc
+//
+//	@Summary		Get Technology Stats
+//	@Description	Get the average technology points and unlocks of the players and how many researched each technology, the progress of one player is in its technology
+//	@Tags			Player
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	service.TechnologyStats
+//	@Failure		400	{object}	ErrorResponse
+//	@Router			/api/player/technology [get]
+func getTechnologyStats(c *gin.Context) {
+	stats, err := service.GetTechnologyStats(database.GetDB())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
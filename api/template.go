@@ -0,0 +1,152 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/internal/locale"
+	"github.com/zaigie/palworld-server-tool/service"
+)
+
+// emailBodyKey overrides the html body of emails, which isn't a message of
+// the locale bundles.
+const emailBodyKey = "email.body"
+
+type MessageTemplate struct {
+	Key string `json:"key"`
+	// Default is the message in locale.default
+	Default  string `json:"default"`
+	Template string `json:"template"`
+}
+
+type TemplateRequest struct {
+	Template string `json:"template"`
+}
+
+type PreviewTemplateRequest struct {
+	// Template is previewed instead of the current message when set
+	Template string         `json:"template"`
+	Vars     map[string]any `json:"vars"`
+}
+
+// listTemplates godoc
+//
+//	@Summary		List Templates
+//	@Description	List the outbound message keys with their default text and the template overriding it in every language. Templates are Go templates with the vars as data, like {{.username}}, and the functions duration, time, upper, lower, join and player. Older {name} placeholders keep working
+//	@Tags			Template
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Success		200	{array}		MessageTemplate
+//	@Failure		401	{object}	ErrorResponse
+//	@Router			/api/templates [get]
+func listTemplates(c *gin.Context) {
+	defaults := locale.Messages(locale.Lang(""))
+	defaults[emailBodyKey] = ""
+	templates := make([]MessageTemplate, 0, len(defaults))
+	for key, text := range defaults {
+		t := MessageTemplate{Key: key, Default: text}
+		t.Template, _ = locale.Override(key)
+		templates = append(templates, t)
+	}
+	sort.Slice(templates, func(i, j int) bool { return templates[i].Key < templates[j].Key })
+	c.JSON(http.StatusOK, templates)
+}
+
+// putTemplate godoc
+//
+//	@Summary		Put Template
+//	@Description	Override an outbound message in every language, email.body replaces the html of emails
+//	@Tags			Template
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			key			path		string			true	"Message key, like player.join"
+//	@Param			template	body		TemplateRequest	true	"Template"
+//	@Success		200			{object}	SuccessResponse
+//	@Failure		400			{object}	ErrorResponse
+//	@Failure		401			{object}	ErrorResponse
+//	@Router			/api/templates/{key} [put]
+func putTemplate(c *gin.Context) {
+	var req TemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if _, err := locale.Parse(req.Template); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	key := c.Param("key")
+	if err := service.PutTemplate(database.GetDB(), key, req.Template); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	locale.SetTemplate(key, req.Template)
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// removeTemplate godoc
+//
+//	@Summary		Remove Template
+//	@Description	Remove the override of a message, the localized default is used again
+//	@Tags			Template
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			key	path		string	true	"Message key"
+//	@Success		200	{object}	SuccessResponse
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		404	{object}	EmptyResponse
+//	@Router			/api/templates/{key} [delete]
+func removeTemplate(c *gin.Context) {
+	key := c.Param("key")
+	if err := service.RemoveTemplate(database.GetDB(), key); err != nil {
+		if err == service.ErrNoRecord {
+			c.JSON(http.StatusNotFound, gin.H{})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	locale.RemoveTemplate(key)
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// previewTemplate godoc
+//
+//	@Summary		Preview Template
+//	@Description	Render a message with example vars, the template in the body or else the current message of the key
+//	@Tags			Template
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			key		path		string					true	"Message key"
+//	@Param			preview	body		PreviewTemplateRequest	true	"Template and vars"
+//	@Success		200		{object}	map[string]string
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Router			/api/templates/{key}/preview [post]
+func previewTemplate(c *gin.Context) {
+	var req PreviewTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	source := req.Template
+	if source == "" {
+		source = locale.Source("", c.Param("key"), "")
+	}
+	if _, err := locale.Parse(source); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	vars := make([]any, 0, len(req.Vars)*2)
+	for name, value := range req.Vars {
+		vars = append(vars, name, value)
+	}
+	c.JSON(http.StatusOK, gin.H{"text": locale.Render(source, vars...)})
+}
@@ -0,0 +1,111 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+	"github.com/zaigie/palworld-server-tool/internal/logger"
+)
+
+// hashedAsset matches the names vite gives build output, like
+// index-4f2a9c1e.js, which change whenever the content does.
+var hashedAsset = regexp.MustCompile(`-[A-Za-z0-9_-]{8}\.[A-Za-z0-9]+$`)
+
+// uiVersion is the version the served frontend bundle was built for.
+var uiVersion string
+
+// overlayFS opens a file from the first file system having it.
+type overlayFS []fs.FS
+
+func (o overlayFS) Open(name string) (fs.File, error) {
+	for _, fsys := range o {
+		f, err := fsys.Open(name)
+		if err == nil {
+			return f, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+	return nil, fs.ErrNotExist
+}
+
+type uiVersionFile struct {
+	Version string `json:"version"`
+}
+
+// RegisterUI serves the web UI embedded in ui, with the files of web.ui_dir
+// taking precedence so a custom or newer UI can be dropped in without a
+// rebuild. Hashed assets are cached for good, the pages are revalidated so a
+// proxy never keeps a page pointing at assets of an older release.
+func RegisterUI(r *gin.Engine, ui fs.FS, version string) {
+	if dir := viper.GetString("web.ui_dir"); dir != "" {
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			logger.Warnf("web.ui_dir %s is not a directory, serving the embedded UI\n", dir)
+		} else {
+			logger.Infof("Serving the UI from %s\n", dir)
+			ui = overlayFS{os.DirFS(dir), ui}
+		}
+	}
+
+	if data, err := fs.ReadFile(ui, "assets/ui-version.json"); err == nil {
+		var file uiVersionFile
+		if err := json.Unmarshal(data, &file); err == nil {
+			uiVersion = file.Version
+		}
+	}
+	if uiVersion != "" && version != "Develop" && uiVersion != version {
+		logger.Warnf("The UI was built for %s but the API is %s, pages may break\n", uiVersion, version)
+	}
+
+	r.GET("/", serveUIFile(ui, "index.html"))
+	r.GET("/pal-conf", serveUIFile(ui, "pal-conf.html"))
+	r.GET("/assets/*filepath", func(c *gin.Context) {
+		name := path.Clean("assets/" + strings.TrimPrefix(c.Param("filepath"), "/"))
+		if !strings.HasPrefix(name, "assets/") {
+			c.Status(http.StatusNotFound)
+			return
+		}
+		serveUIFile(ui, name)(c)
+	})
+}
+
+func serveUIFile(ui fs.FS, name string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		data, err := fs.ReadFile(ui, name)
+		if err != nil {
+			// an asset of another release, don't let a proxy keep the miss
+			c.Header("Cache-Control", "no-store")
+			c.Status(http.StatusNotFound)
+			return
+		}
+		sum := sha256.Sum256(data)
+		etag := `"` + hex.EncodeToString(sum[:8]) + `"`
+		if hashedAsset.MatchString(name) {
+			c.Header("Cache-Control", "public, max-age=31536000, immutable")
+		} else {
+			c.Header("Cache-Control", "no-cache")
+		}
+		c.Header("ETag", etag)
+		if c.GetHeader("If-None-Match") == etag {
+			c.Status(http.StatusNotModified)
+			return
+		}
+		contentType := mime.TypeByExtension(path.Ext(name))
+		if contentType == "" {
+			contentType = http.DetectContentType(data)
+		}
+		c.Data(http.StatusOK, contentType, data)
+	}
+}
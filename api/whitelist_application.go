@@ -0,0 +1,247 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+	"github.com/zaigie/palworld-server-tool/internal/cache"
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/internal/logger"
+	"github.com/zaigie/palworld-server-tool/internal/task"
+	"github.com/zaigie/palworld-server-tool/service"
+)
+
+type ApplicationFormResponse struct {
+	Enable    bool     `json:"enable"`
+	Questions []string `json:"questions"`
+	// SteamLogin is whether players can apply signed in with Steam, POST
+	// /api/me/application
+	SteamLogin bool `json:"steam_login"`
+}
+
+type ApplicationRequest struct {
+	Name      string `json:"name"`
+	SteamId   string `json:"steam_id"`
+	PlayerUid string `json:"player_uid"`
+	Email     string `json:"email"`
+	// Answers are by question, see GET /api/whitelist/application/form
+	Answers map[string]string `json:"answers"`
+}
+
+// getApplicationForm godoc
+//
+//	@Summary		Get Whitelist Application Form
+//	@Description	Whether players can apply to the whitelist and the questions they must answer
+//	@Tags			Whitelist Application
+//	@Produce		json
+//	@Success		200	{object}	ApplicationFormResponse
+//	@Router			/api/whitelist/application/form [get]
+func getApplicationForm(c *gin.Context) {
+	c.JSON(http.StatusOK, ApplicationFormResponse{
+		Enable:     viper.GetBool("whitelist_application.enable"),
+		Questions:  task.ApplicationQuestions(),
+		SteamLogin: viper.GetBool("web.steam_login"),
+	})
+}
+
+// submitApplication godoc
+//
+//	@Summary		Apply To Whitelist
+//	@Description	Apply to the whitelist with the public form, an ip can send whitelist_application.rate_limit applications per hour, refused ones included
+//	@Tags			Whitelist Application
+//	@Accept			json
+//	@Produce		json
+//	@Param			application	body		ApplicationRequest	true	"Application"
+//	@Success		200			{object}	database.WhitelistApplication
+//	@Failure		400			{object}	ErrorResponse
+//	@Failure		403			{object}	ErrorResponse
+//	@Failure		409			{object}	ErrorResponse
+//	@Failure		429			{object}	ErrorResponse
+//	@Router			/api/whitelist/application [post]
+func submitApplication(c *gin.Context) {
+	var req ApplicationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	// counted before the application is checked, so concurrent requests
+	// can't all pass the limit, refused ones count too
+	if limit := viper.GetInt64("whitelist_application.rate_limit"); limit > 0 {
+		sent, err := cache.Default().Incr("application:"+c.ClientIP(), time.Hour)
+		if err != nil {
+			logger.Warnf("Failed to count application: %v\n", err)
+		} else if sent > limit {
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": "too many applications, try again later"})
+			return
+		}
+	}
+	app, ok := submit(c, database.WhitelistApplication{
+		Name:      req.Name,
+		SteamId:   req.SteamId,
+		PlayerUid: req.PlayerUid,
+		Email:     req.Email,
+		Answers:   req.Answers,
+		Source:    service.ApplicationSourceForm,
+	})
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, app)
+}
+
+// getMyApplication godoc
+//
+//	@Summary		Get My Whitelist Application
+//	@Description	The last whitelist application of the Steam account of the player token
+//	@Tags			Whitelist Application
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Success		200	{object}	database.WhitelistApplication
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		403	{object}	ErrorResponse
+//	@Failure		404	{object}	EmptyResponse
+//	@Router			/api/me/application [get]
+func getMyApplication(c *gin.Context) {
+	app, err := service.GetLatestApplication(database.GetDB(), steamIdOf(c))
+	if err != nil {
+		if err == service.ErrNoRecord {
+			c.JSON(http.StatusNotFound, gin.H{})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, app)
+}
+
+// submitMyApplication godoc
+//
+//	@Summary		Apply To Whitelist With Steam
+//	@Description	Apply to the whitelist as the Steam account of the player token, its steam_id is used whatever is sent
+//	@Tags			Whitelist Application
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			application	body		ApplicationRequest	true	"Application"
+//	@Success		200			{object}	database.WhitelistApplication
+//	@Failure		400			{object}	ErrorResponse
+//	@Failure		401			{object}	ErrorResponse
+//	@Failure		403			{object}	ErrorResponse
+//	@Failure		409			{object}	ErrorResponse
+//	@Router			/api/me/application [post]
+func submitMyApplication(c *gin.Context) {
+	var req ApplicationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	app, ok := submit(c, database.WhitelistApplication{
+		Name:      req.Name,
+		SteamId:   steamIdOf(c),
+		PlayerUid: req.PlayerUid,
+		Email:     req.Email,
+		Answers:   req.Answers,
+		Source:    service.ApplicationSourceSteam,
+	})
+	if ok {
+		c.JSON(http.StatusOK, app)
+	}
+}
+
+// submit queues the application, writing the error when it's refused.
+func submit(c *gin.Context, app database.WhitelistApplication) (database.WhitelistApplication, bool) {
+	app, err := task.SubmitWhitelistApplication(database.GetDB(), app)
+	switch {
+	case err == nil:
+		return app, true
+	case errors.Is(err, task.ErrApplicationsDisabled):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrApplicationPending), errors.Is(err, service.ErrAlreadyWhitelisted):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		badRequest(c, err)
+	}
+	return app, false
+}
+
+// listApplications godoc
+//
+//	@Summary		List Whitelist Applications
+//	@Description	List whitelist applications, newest first
+//	@Tags			Whitelist Application
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			status	query		string	false	"Status"	Enums(pending, approved, denied)
+//	@Param			limit	query		int		false	"max number of applications, default 100"
+//	@Success		200		{array}		database.WhitelistApplication
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Router			/api/whitelist/application [get]
+func listApplications(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+		return
+	}
+	switch status := c.Query("status"); status {
+	case "", service.ApplicationPending, service.ApplicationApproved, service.ApplicationDenied:
+		apps, err := service.ListWhitelistApplications(database.GetDB(), status, limit)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, apps)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be pending, approved or denied"})
+	}
+}
+
+// decideApplication godoc
+//
+//	@Summary		Decide Whitelist Application
+//	@Description	Approve a pending application, adding the applicant to the whitelist, or deny it. The applicant is mailed the decision when they left an email
+//	@Tags			Whitelist Application
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			id		path		int		true	"Application ID"
+//	@Param			action	path		string	true	"Action"	Enums(approve, deny)
+//	@Param			reason	query		string	false	"Reason, told to the applicant"
+//	@Success		200		{object}	database.WhitelistApplication
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		404		{object}	EmptyResponse
+//	@Failure		409		{object}	ErrorResponse
+//	@Router			/api/whitelist/application/{id}/{action} [post]
+func decideApplication(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	decision := service.ApplicationDecision{Reason: c.Query("reason"), By: requestBy(c)}
+	switch c.Param("action") {
+	case "approve":
+		decision.Approve = true
+	case "deny":
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "action must be approve or deny"})
+		return
+	}
+	app, err := task.DecideWhitelistApplication(database.GetDB(), id, decision)
+	if err != nil {
+		switch err {
+		case service.ErrNoRecord:
+			c.JSON(http.StatusNotFound, gin.H{})
+		case service.ErrApplicationDecided:
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, app)
+}
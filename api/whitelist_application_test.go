@@ -0,0 +1,32 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+)
+
+func TestSubmitApplicationRateLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	viper.Set("whitelist_application.enable", false)
+	viper.Set("whitelist_application.rate_limit", 2)
+	defer viper.Set("whitelist_application.rate_limit", 0)
+	r := gin.New()
+	r.POST("/api/whitelist/application", submitApplication)
+	// refused applications count, the third attempt of the hour is limited
+	// before it is checked
+	for i, want := range []int{http.StatusForbidden, http.StatusForbidden, http.StatusTooManyRequests} {
+		req := httptest.NewRequest(http.MethodPost, "/api/whitelist/application", strings.NewReader(`{"name":"alice"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.RemoteAddr = "192.0.2.7:40000"
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != want {
+			t.Errorf("attempt %d: status %d, want %d", i+1, w.Code, want)
+		}
+	}
+}
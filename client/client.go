@@ -0,0 +1,237 @@
+// Package client is the Go client of the pst API, for tools and bots that
+// manage a Palworld server through a running pst. pst's own CLI uses it.
+//
+// The package is versioned with the module: within a major version its
+// methods and types only get additions.
+//
+//	c := client.New("http://127.0.0.1:8080", "password")
+//	players, err := c.ListOnlinePlayers(ctx)
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zaigie/palworld-server-tool/internal/database"
+)
+
+// The types the API returns.
+type (
+	Player         = database.TersePlayer
+	OnlinePlayer   = database.OnlinePlayer
+	Backup         = database.Backup
+	WhitelistEntry = database.PlayerW
+	Event          = database.Event
+)
+
+type Metrics struct {
+	ServerFps        int     `json:"server_fps"`
+	CurrentPlayerNum int     `json:"current_player_num"`
+	ServerFrameTime  float64 `json:"server_frame_time"`
+	MaxPlayerNum     int     `json:"max_player_num"`
+	Uptime           int     `json:"uptime"`
+	Days             int     `json:"days"`
+}
+
+// Error is an error response of the API.
+type Error struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// IsUnauthorized is true for errors of a wrong password or an expired
+// token.
+func IsUnauthorized(err error) bool {
+	var e *Error
+	return errors.As(err, &e) && e.StatusCode == http.StatusUnauthorized
+}
+
+// Client calls the API of one pst instance. It logs in with the password
+// on the first call that needs it and again when the token expired, and
+// is safe for concurrent use.
+type Client struct {
+	server   string
+	password string
+	// HTTPClient sends the requests, its Timeout bounds calls like Backup
+	// that wait for the game server
+	HTTPClient *http.Client
+
+	mu    sync.Mutex
+	token string
+}
+
+// New returns a client of the instance at server, like
+// http://127.0.0.1:8080, logging in with its web.password.
+func New(server, password string) *Client {
+	return &Client{
+		server:     strings.TrimSuffix(server, "/"),
+		password:   password,
+		HTTPClient: &http.Client{Timeout: 5 * time.Minute},
+	}
+}
+
+// Login gets a token, which the calls needing one otherwise do on their
+// own. It is for checking the password up front.
+func (c *Client) Login(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.token = ""
+	_, err := c.login(ctx)
+	return err
+}
+
+// login returns the token, logging in without one. c.mu is held.
+func (c *Client) login(ctx context.Context) (string, error) {
+	if c.token != "" {
+		return c.token, nil
+	}
+	var resp struct {
+		Token string `json:"token"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/api/login", "", map[string]string{"password": c.password}, &resp); err != nil {
+		return "", fmt.Errorf("login: %w", err)
+	}
+	c.token = resp.Token
+	return c.token, nil
+}
+
+func (c *Client) do(ctx context.Context, method, path, token string, body any, out any) error {
+	var reader io.Reader
+	if body != nil {
+		v, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(v)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.server+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var e struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(data, &e) != nil || e.Error == "" {
+			e.Error = fmt.Sprintf("%d %s", resp.StatusCode, data)
+		}
+		return &Error{StatusCode: resp.StatusCode, Message: e.Error}
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(data, out)
+}
+
+// authDo calls a route that needs the token, logging in again once when it
+// expired.
+func (c *Client) authDo(ctx context.Context, method, path string, body any, out any) error {
+	for retried := false; ; retried = true {
+		c.mu.Lock()
+		token, err := c.login(ctx)
+		c.mu.Unlock()
+		if err != nil {
+			return err
+		}
+		err = c.do(ctx, method, path, token, body, out)
+		if retried || !IsUnauthorized(err) {
+			return err
+		}
+		c.mu.Lock()
+		if c.token == token {
+			c.token = ""
+		}
+		c.mu.Unlock()
+	}
+}
+
+// ListPlayers returns the players of the save.
+func (c *Client) ListPlayers(ctx context.Context) ([]Player, error) {
+	var players []Player
+	err := c.do(ctx, http.MethodGet, "/api/player", "", nil, &players)
+	return players, err
+}
+
+// ListOnlinePlayers asks the game server who is online.
+func (c *Client) ListOnlinePlayers(ctx context.Context) ([]OnlinePlayer, error) {
+	var players []OnlinePlayer
+	err := c.do(ctx, http.MethodGet, "/api/online_player", "", nil, &players)
+	return players, err
+}
+
+func (c *Client) Kick(ctx context.Context, playerUid string) error {
+	return c.authDo(ctx, http.MethodPost, "/api/player/"+url.PathEscape(playerUid)+"/kick", nil, nil)
+}
+
+func (c *Client) Ban(ctx context.Context, playerUid string) error {
+	return c.authDo(ctx, http.MethodPost, "/api/player/"+url.PathEscape(playerUid)+"/ban", nil, nil)
+}
+
+func (c *Client) Unban(ctx context.Context, playerUid string) error {
+	return c.authDo(ctx, http.MethodPost, "/api/player/"+url.PathEscape(playerUid)+"/unban", nil, nil)
+}
+
+// Broadcast sends a message to everyone in the game.
+func (c *Client) Broadcast(ctx context.Context, message string) error {
+	return c.authDo(ctx, http.MethodPost, "/api/server/broadcast", map[string]string{"message": message}, nil)
+}
+
+// Backup backs up the save now.
+func (c *Client) Backup(ctx context.Context) (Backup, error) {
+	var backup Backup
+	err := c.authDo(ctx, http.MethodPost, "/api/backup", nil, &backup)
+	return backup, err
+}
+
+func (c *Client) ListBackups(ctx context.Context) ([]Backup, error) {
+	var backups []Backup
+	err := c.authDo(ctx, http.MethodGet, "/api/backup", nil, &backups)
+	return backups, err
+}
+
+func (c *Client) ListWhitelist(ctx context.Context) ([]WhitelistEntry, error) {
+	var players []WhitelistEntry
+	err := c.authDo(ctx, http.MethodGet, "/api/whitelist", nil, &players)
+	return players, err
+}
+
+func (c *Client) AddWhitelist(ctx context.Context, entry WhitelistEntry) error {
+	return c.authDo(ctx, http.MethodPost, "/api/whitelist", entry, nil)
+}
+
+func (c *Client) RemoveWhitelist(ctx context.Context, entry WhitelistEntry) error {
+	return c.authDo(ctx, http.MethodDelete, "/api/whitelist", entry, nil)
+}
+
+// Metrics returns the fps, players and uptime of the game server.
+func (c *Client) Metrics(ctx context.Context) (Metrics, error) {
+	var metrics Metrics
+	err := c.do(ctx, http.MethodGet, "/api/server/metrics", "", nil, &metrics)
+	return metrics, err
+}
@@ -0,0 +1,108 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/websocket"
+)
+
+// Subscription streams the events of the bus as they happen.
+type Subscription struct {
+	// Events is closed when the context is done, Close is called or the
+	// connection is lost, Err tells which
+	Events <-chan Event
+
+	conn *websocket.Conn
+	once sync.Once
+	err  error
+}
+
+// SubscribeEvents streams the events of the types, all without any. A
+// trailing * matches by prefix, like player.*.
+func (c *Client) SubscribeEvents(ctx context.Context, types ...string) (*Subscription, error) {
+	u, err := url.Parse(c.server + "/api/events/ws")
+	if err != nil {
+		return nil, err
+	}
+	u.Scheme = strings.Replace(u.Scheme, "http", "ws", 1)
+	if len(types) > 0 {
+		u.RawQuery = url.Values{"types": {strings.Join(types, ",")}}.Encode()
+	}
+
+	var conn *websocket.Conn
+	for retried := false; ; retried = true {
+		c.mu.Lock()
+		token, err := c.login(ctx)
+		c.mu.Unlock()
+		if err != nil {
+			return nil, err
+		}
+		var resp *http.Response
+		conn, resp, err = websocket.DefaultDialer.DialContext(ctx, u.String(), http.Header{"Authorization": {"Bearer " + token}})
+		if err == nil {
+			break
+		}
+		if resp == nil || resp.StatusCode != http.StatusUnauthorized {
+			return nil, err
+		}
+		if retried {
+			return nil, &Error{StatusCode: resp.StatusCode, Message: "unauthorized"}
+		}
+		c.mu.Lock()
+		if c.token == token {
+			c.token = ""
+		}
+		c.mu.Unlock()
+	}
+
+	events := make(chan Event)
+	s := &Subscription{Events: events, conn: conn}
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			s.close(ctx.Err())
+		case <-done:
+		}
+	}()
+	go func() {
+		defer close(events)
+		defer close(done)
+		for {
+			var event Event
+			if err := conn.ReadJSON(&event); err != nil {
+				s.close(err)
+				return
+			}
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				s.close(ctx.Err())
+				return
+			}
+		}
+	}()
+	return s, nil
+}
+
+func (s *Subscription) close(err error) {
+	s.once.Do(func() {
+		s.err = err
+		s.conn.Close()
+	})
+}
+
+// Close ends the subscription.
+func (s *Subscription) Close() error {
+	s.close(nil)
+	return nil
+}
+
+// Err is why Events was closed, nil after Close.
+func (s *Subscription) Err() error {
+	return s.err
+}
@@ -19,14 +19,14 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/api/backup": {
+        "/api/afk": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "List all backups or backups within a specific time range.",
+                "description": "List online players who haven't moved for manage.afk_minutes, empty when it is 0",
                 "consumes": [
                     "application/json"
                 ],
@@ -34,20 +34,57 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "backup"
+                    "Player"
                 ],
-                "summary": "List backups within a specified time range",
+                "summary": "List AFK Players",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/task.AfkPlayer"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/audit/summary": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Summarize what the admins did: actions per admin per day, the most used actions and the latest destructive ones. Admins are told apart by the client address of their requests, bot or cli. Built from the admin.* and whitelist decision events, the removed entries and the snapshots",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Audit"
+                ],
+                "summary": "Get Audit Summary",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Start time of the backup range in timestamp",
-                        "name": "startTime",
+                        "description": "days to summarize, default 30",
+                        "name": "days",
                         "in": "query"
                     },
                     {
                         "type": "integer",
-                        "description": "End time of the backup range in timestamp",
-                        "name": "endTime",
+                        "description": "max number of destructive actions, default 20",
+                        "name": "recent",
                         "in": "query"
                     }
                 ],
@@ -55,10 +92,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/database.Backup"
-                            }
+                            "$ref": "#/definitions/service.AuditSummary"
                         }
                     },
                     "400": {
@@ -66,42 +100,82 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/api.ErrorResponse"
                         }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
                     }
                 }
             }
         },
-        "/api/backup/{backup_id}": {
+        "/api/auth/steam": {
             "get": {
-                "security": [
+                "description": "Send the player to Steam to sign in, Steam sends them back to /api/auth/steam/callback. Needs web.steam_login and web.public_url",
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Steam Login",
+                "parameters": [
                     {
-                        "ApiKeyAuth": []
+                        "type": "string",
+                        "description": "path or url of web.public_url the callback sends the player to, with the token in the steam_token fragment",
+                        "name": "redirect",
+                        "in": "query"
                     }
                 ],
-                "description": "Download a backup",
-                "consumes": [
-                    "application/json"
-                ],
+                "responses": {
+                    "302": {
+                        "description": "Redirect to Steam",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/auth/steam/callback": {
+            "get": {
+                "description": "Where Steam sends the player back to. The login is checked with Steam and a player token issued, which only opens the routes of the player. With redirect the player is sent there with the token in the steam_token fragment",
                 "produces": [
-                    "application/octet-stream"
+                    "application/json"
                 ],
                 "tags": [
-                    "backup"
+                    "Auth"
                 ],
-                "summary": "Download Backup",
+                "summary": "Steam Login Callback",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Backup ID",
-                        "name": "backup_id",
-                        "in": "path",
-                        "required": true
+                        "description": "passed on from /api/auth/steam",
+                        "name": "redirect",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Backupfile",
+                        "description": "OK",
                         "schema": {
-                            "type": "file"
+                            "$ref": "#/definitions/api.SteamLoginResponse"
+                        }
+                    },
+                    "302": {
+                        "description": "Redirect with the token",
+                        "schema": {
+                            "type": "string"
                         }
                     },
                     "400": {
@@ -110,27 +184,29 @@ const docTemplate = `{
                             "$ref": "#/definitions/api.ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "$ref": "#/definitions/api.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "$ref": "#/definitions/api.ErrorResponse"
                         }
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/api/backup": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Delete a backup",
+                "description": "List backups from the catalog, filtered by time range, trigger reason, server version, size or part of the archive name.",
                 "consumes": [
                     "application/json"
                 ],
@@ -140,21 +216,83 @@ const docTemplate = `{
                 "tags": [
                     "backup"
                 ],
-                "summary": "Delete Backup",
+                "summary": "List backups within a specified time range",
                 "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Start time of the backup range in timestamp",
+                        "name": "startTime",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "End time of the backup range in timestamp",
+                        "name": "endTime",
+                        "in": "query"
+                    },
                     {
                         "type": "string",
-                        "description": "Backup ID",
-                        "name": "backup_id",
-                        "in": "path",
-                        "required": true
+                        "description": "Trigger reason",
+                        "name": "reason",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Server version when backed up",
+                        "name": "world_version",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Name of the season the backups were archived with, none for the current season",
+                        "name": "season",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Part of the archive name",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Min size in bytes",
+                        "name": "min_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Max size in bytes",
+                        "name": "max_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "order by field",
+                        "name": "order_by",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "order by desc",
+                        "name": "desc",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "max number of backups",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/api.SuccessResponse"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/database.Backup"
+                            }
                         }
                     },
                     "400": {
@@ -164,11 +302,14 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/api/guild": {
-            "get": {
-                "description": "List Guilds",
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Back up the save now",
                 "consumes": [
                     "application/json"
                 ],
@@ -176,17 +317,14 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Guild"
+                    "backup"
                 ],
-                "summary": "List Guilds",
+                "summary": "Create Backup",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/database.Guild"
-                            }
+                            "$ref": "#/definitions/database.Backup"
                         }
                     },
                     "400": {
@@ -194,16 +332,24 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/api.ErrorResponse"
                         }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
                     }
                 }
-            },
-            "put": {
+            }
+        },
+        "/api/backup/rekey": {
+            "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Put Guilds Only For SavSync",
+                "description": "Encrypt the backups that are unencrypted or encrypted with an older key with the active backup key. To rotate, append a new key to save.backup_key_file, run this, then drop the old keys",
                 "consumes": [
                     "application/json"
                 ],
@@ -211,28 +357,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Guild"
-                ],
-                "summary": "Put Guilds",
-                "parameters": [
-                    {
-                        "description": "Guilds",
-                        "name": "guilds",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/database.Guild"
-                            }
-                        }
-                    }
+                    "backup"
                 ],
+                "summary": "Rekey Backups",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/api.SuccessResponse"
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "integer"
+                            }
                         }
                     },
                     "400": {
@@ -250,33 +385,44 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/guild/{admin_player_uid}": {
+        "/api/backup/{backup_id}": {
             "get": {
-                "description": "Get Guild",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Download a backup as a zip archive, decrypted with the backup keys when it is encrypted. With encrypted the archive is sent encrypted with the active key instead, for offsite copies",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
-                    "application/json"
+                    "application/octet-stream"
                 ],
                 "tags": [
-                    "Guild"
+                    "backup"
                 ],
-                "summary": "Get Guild",
+                "summary": "Download Backup",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Admin Player UID",
-                        "name": "admin_player_uid",
+                        "description": "Backup ID",
+                        "name": "backup_id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "send the archive encrypted",
+                        "name": "encrypted",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "Backupfile",
                         "schema": {
-                            "$ref": "#/definitions/database.Guild"
+                            "type": "file"
                         }
                     },
                     "400": {
@@ -288,15 +434,24 @@ const docTemplate = `{
                     "404": {
                         "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/api.EmptyResponse"
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
                         }
                     }
                 }
-            }
-        },
-        "/api/login": {
-            "post": {
-                "description": "Login",
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Delete a backup",
                 "consumes": [
                     "application/json"
                 ],
@@ -304,18 +459,16 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Auth"
+                    "backup"
                 ],
-                "summary": "Login",
+                "summary": "Delete Backup",
                 "parameters": [
                     {
-                        "description": "Login Info",
-                        "name": "login_info",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/api.LoginInfo"
-                        }
+                        "type": "string",
+                        "description": "Backup ID",
+                        "name": "backup_id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
@@ -330,19 +483,13 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/api.ErrorResponse"
                         }
-                    },
-                    "401": {
-                        "description": "Unauthorized",
-                        "schema": {
-                            "$ref": "#/definitions/api.ErrorResponse"
-                        }
                     }
                 }
             }
         },
-        "/api/online_player": {
+        "/api/badge": {
             "get": {
-                "description": "List Online Players",
+                "description": "List the badge rules of badges.rules with the players who earned each, the first first",
                 "consumes": [
                     "application/json"
                 ],
@@ -352,14 +499,14 @@ const docTemplate = `{
                 "tags": [
                     "Player"
                 ],
-                "summary": "List Online Players",
+                "summary": "List Badges",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/database.OnlinePlayer"
+                                "$ref": "#/definitions/service.BadgeSummary"
                             }
                         }
                     },
@@ -372,9 +519,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/player": {
-            "get": {
-                "description": "List Players",
+        "/api/bot/onebot": {
+            "post": {
+                "description": "Receive events posted by a OneBot v11 implementation and reply to bot commands, signed by X-Signature with bot.secret, events are refused without it",
                 "consumes": [
                     "application/json"
                 ],
@@ -382,34 +529,82 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Player"
+                    "Bot"
                 ],
-                "summary": "List Players",
+                "summary": "Receive OneBot Event",
                 "parameters": [
                     {
-                        "enum": [
-                            "last_online",
-                            "level"
-                        ],
-                        "type": "string",
-                        "description": "order by field",
-                        "name": "order_by",
-                        "in": "query"
+                        "description": "OneBot Event",
+                        "name": "event",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.OneBotEvent"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.OneBotReply"
+                        }
+                    },
+                    "204": {
+                        "description": "No Content"
                     },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/community_event": {
+            "get": {
+                "security": [
                     {
-                        "type": "boolean",
-                        "description": "order by desc",
-                        "name": "desc",
-                        "in": "query"
+                        "ApiKeyAuth": []
                     }
                 ],
+                "description": "List recurring community events",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Event"
+                ],
+                "summary": "List Community Events",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/database.TersePlayer"
+                                "$ref": "#/definitions/database.CommunityEvent"
                             }
                         }
                     },
@@ -418,16 +613,22 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/api.ErrorResponse"
                         }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
                     }
                 }
             },
-            "put": {
+            "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Put Players Only For SavSync,PlayerSync",
+                "description": "Add a recurring community event, weekdays use 0 for Sunday and an empty list means every day. start_actions and end_actions are world actions like set_time, their commands must be set in admin.commands",
                 "consumes": [
                     "application/json"
                 ],
@@ -435,20 +636,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Player"
+                    "Event"
                 ],
-                "summary": "Put Players",
+                "summary": "Add Community Event",
                 "parameters": [
                     {
-                        "description": "Players",
-                        "name": "players",
+                        "description": "Community Event",
+                        "name": "event",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/database.Player"
-                            }
+                            "$ref": "#/definitions/database.CommunityEvent"
                         }
                     }
                 ],
@@ -456,7 +654,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/api.SuccessResponse"
+                            "$ref": "#/definitions/database.CommunityEvent"
                         }
                     },
                     "400": {
@@ -474,9 +672,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/player/{player_uid}": {
-            "get": {
-                "description": "Get Player",
+        "/api/community_event/{id}": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Update a community event, the running state is kept",
                 "consumes": [
                     "application/json"
                 ],
@@ -484,23 +687,32 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Player"
+                    "Event"
                 ],
-                "summary": "Get Player",
+                "summary": "Put Community Event",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Player UID",
-                        "name": "player_uid",
+                        "description": "Event ID",
+                        "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "Community Event",
+                        "name": "event",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/database.CommunityEvent"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/database.Player"
+                            "$ref": "#/definitions/api.SuccessResponse"
                         }
                     },
                     "400": {
@@ -509,23 +721,27 @@ const docTemplate = `{
                             "$ref": "#/definitions/api.ErrorResponse"
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/api.EmptyResponse"
+                            "$ref": "#/definitions/api.ErrorResponse"
                         }
                     }
                 }
-            }
-        },
-        "/api/player/{player_uid}/ban": {
-            "post": {
+            },
+            "delete": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Ban Player",
+                "description": "Remove a community event, an active event should be disabled first so its settings are reverted",
                 "consumes": [
                     "application/json"
                 ],
@@ -533,14 +749,14 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Player"
+                    "Event"
                 ],
-                "summary": "Ban Player",
+                "summary": "Remove Community Event",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Player UID",
-                        "name": "player_uid",
+                        "description": "Event ID",
+                        "name": "id",
                         "in": "path",
                         "required": true
                     }
@@ -563,24 +779,18 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/api.ErrorResponse"
                         }
-                    },
-                    "404": {
-                        "description": "Not Found",
-                        "schema": {
-                            "$ref": "#/definitions/api.ErrorResponse"
-                        }
                     }
                 }
             }
         },
-        "/api/player/{player_uid}/kick": {
-            "post": {
+        "/api/debug/crash-reports": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Kick Player",
+                "description": "List the crash reports written when something panicked, newest first",
                 "consumes": [
                     "application/json"
                 ],
@@ -588,23 +798,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Player"
-                ],
-                "summary": "Kick Player",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Player UID",
-                        "name": "player_uid",
-                        "in": "path",
-                        "required": true
-                    }
+                    "Debug"
                 ],
+                "summary": "List Crash Reports",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/api.SuccessResponse"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/crash.Report"
+                            }
                         }
                     },
                     "400": {
@@ -618,48 +822,39 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/api.ErrorResponse"
                         }
-                    },
-                    "404": {
-                        "description": "Not Found",
-                        "schema": {
-                            "$ref": "#/definitions/api.ErrorResponse"
-                        }
                     }
                 }
             }
         },
-        "/api/player/{player_uid}/unban": {
-            "post": {
+        "/api/debug/crash-reports/{name}": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Unban Player",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Download a crash report zip with the stack traces, recent logs, the config without secrets and database stats, to attach to a bug report",
                 "produces": [
-                    "application/json"
+                    "application/zip"
                 ],
                 "tags": [
-                    "Player"
+                    "Debug"
                 ],
-                "summary": "Unban Player",
+                "summary": "Download Crash Report",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Player UID",
-                        "name": "player_uid",
+                        "description": "Report name",
+                        "name": "name",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "Report",
                         "schema": {
-                            "$ref": "#/definitions/api.SuccessResponse"
+                            "type": "file"
                         }
                     },
                     "400": {
@@ -683,14 +878,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/rcon": {
+        "/api/debug/storage": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "List Rcon Commands",
+                "description": "Get the keys, size and average record size of every bucket of the database, the largest first, with their growth since the first sample after since and the samples taken every debug.storage_sample_interval",
                 "consumes": [
                     "application/json"
                 ],
@@ -698,17 +893,22 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Rcon"
+                    "Debug"
+                ],
+                "summary": "Get Storage Stats",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "start of the growth, unix seconds or RFC3339, default 7 days ago",
+                        "name": "since",
+                        "in": "query"
+                    }
                 ],
-                "summary": "List Rcon Commands",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/database.RconCommandList"
-                            }
+                            "$ref": "#/definitions/service.StorageStats"
                         }
                     },
                     "400": {
@@ -716,16 +916,24 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/api.ErrorResponse"
                         }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
                     }
                 }
-            },
-            "post": {
+            }
+        },
+        "/api/events": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Add Rcon Command",
+                "description": "List stored events newest first. q is a filter expression on id, type, time, player_uid, admin_player_uid, message and the data keys, like type=player.afk_kick AND nickname~bob AND time\u003c24h. Comparisons are joined with AND, OR, NOT and parentheses, operators are = != \u003e \u003e= \u003c \u003c= and ~ for contains, time compared to a duration like 24h compares its age",
                 "consumes": [
                     "application/json"
                 ],
@@ -733,69 +941,83 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Rcon"
+                    "Event"
                 ],
-                "summary": "Add Rcon Command",
+                "summary": "List Events",
                 "parameters": [
                     {
-                        "description": "Rcon Command",
-                        "name": "command",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/database.RconCommand"
-                        }
+                        "type": "string",
+                        "description": "filter expression",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "event type, a trailing * matches by prefix",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "max number of events, default 100",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/api.SuccessResponse"
-                        }
-                    },
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/database.Event"
+                            }
+                        }
+                    },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
                             "$ref": "#/definitions/api.ErrorResponse"
                         }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
                     }
                 }
             }
         },
-        "/api/rcon/import": {
-            "post": {
+        "/api/events/ws": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Import Rcon Commands from a TXT file",
-                "consumes": [
-                    "multipart/form-data"
-                ],
-                "produces": [
-                    "application/json"
-                ],
+                "description": "Stream bus events over a WebSocket as JSON, including the ones not stored like player.join, player.leave and sync.done",
                 "tags": [
-                    "Rcon"
+                    "Event"
                 ],
-                "summary": "Import Rcon Commands",
+                "summary": "Stream Events",
                 "parameters": [
                     {
-                        "type": "file",
-                        "description": "Upload txt file",
-                        "name": "file",
-                        "in": "formData",
-                        "required": true
+                        "type": "string",
+                        "description": "comma separated event types, a trailing * matches by prefix",
+                        "name": "types",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "filter expression like for /api/events",
+                        "name": "q",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/api.SuccessResponse"
-                        }
+                    "101": {
+                        "description": "Switching Protocols"
                     },
                     "400": {
                         "description": "Bad Request",
@@ -806,14 +1028,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/rcon/send": {
+        "/api/graphql": {
             "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Send Rcon Command",
+                "description": "Query players, pals, items, guilds and events selecting only the needed fields. Root fields: players(order_by, desc, limit), player(player_uid), guilds, guild(player_uid), events(type, player_uid, limit). Players have guild and events, guilds have members. Queries only, no fragments or directives, nested at most 8 levels and resolving at most 100000 fields. Enabled by web.graphql",
                 "consumes": [
                     "application/json"
                 ],
@@ -821,17 +1043,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Rcon"
+                    "GraphQL"
                 ],
-                "summary": "Send Rcon Command",
+                "summary": "GraphQL",
                 "parameters": [
                     {
-                        "description": "Rcon Command",
-                        "name": "command",
+                        "description": "GraphQL Request",
+                        "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/api.SendRconCommandRequest"
+                            "$ref": "#/definitions/api.GraphqlRequest"
                         }
                     }
                 ],
@@ -839,7 +1061,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/api.MessageResponse"
+                            "$ref": "#/definitions/graphql.Response"
                         }
                     },
                     "400": {
@@ -848,8 +1070,8 @@ const docTemplate = `{
                             "$ref": "#/definitions/api.ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "$ref": "#/definitions/api.ErrorResponse"
                         }
@@ -857,14 +1079,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/rcon/{uuid}": {
-            "put": {
-                "security": [
-                    {
-                        "ApiKeyAuth": []
-                    }
-                ],
-                "description": "Put Rcon Command",
+        "/api/guild": {
+            "get": {
+                "description": "List Guilds",
                 "consumes": [
                     "application/json"
                 ],
@@ -872,32 +1089,45 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Rcon"
+                    "Guild"
                 ],
-                "summary": "Put Rcon Command",
+                "summary": "List Guilds",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "UUID",
-                        "name": "uuid",
-                        "in": "path",
-                        "required": true
+                        "type": "boolean",
+                        "description": "add base_camp_locations computed from the base camp positions",
+                        "name": "location",
+                        "in": "query"
                     },
                     {
-                        "description": "Rcon Command",
-                        "name": "command",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/database.RconCommand"
-                        }
+                        "maximum": 6,
+                        "type": "integer",
+                        "default": 2,
+                        "description": "decimals of location values",
+                        "name": "precision",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "m",
+                            "cm",
+                            "km"
+                        ],
+                        "type": "string",
+                        "default": "m",
+                        "description": "unit of distance_from_spawn",
+                        "name": "unit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/api.SuccessResponse"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/database.Guild"
+                            }
                         }
                     },
                     "400": {
@@ -908,13 +1138,13 @@ const docTemplate = `{
                     }
                 }
             },
-            "delete": {
+            "put": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Remove Rcon Command",
+                "description": "Put Guilds Only For SavSync. Mode merge adds or updates the guilds sent, replace also deletes the guilds left out and reports them disbanded",
                 "consumes": [
                     "application/json"
                 ],
@@ -922,16 +1152,39 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Rcon"
+                    "Guild"
                 ],
-                "summary": "Remove Rcon Command",
+                "summary": "Put Guilds",
                 "parameters": [
+                    {
+                        "description": "Guilds",
+                        "name": "guilds",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/database.Guild"
+                            }
+                        }
+                    },
                     {
                         "type": "string",
-                        "description": "UUID",
-                        "name": "uuid",
-                        "in": "path",
-                        "required": true
+                        "description": "merge, the default, or replace",
+                        "name": "mode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only return the records that would be added, overwritten or removed, as a DryRunResponse",
+                        "name": "dry_run",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Sync batch journaling the guilds",
+                        "name": "X-Sync-Batch",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -946,28 +1199,62 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/api.ErrorResponse"
                         }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
                     }
                 }
             }
         },
-        "/api/server": {
+        "/api/guild/abandoned": {
             "get": {
-                "description": "Get Server Info",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "List base camps of guilds whose members have all been offline for more than the given days, as JSON or a CSV of coordinates for manual demolition",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "text/csv"
                 ],
                 "tags": [
-                    "Server"
+                    "Guild"
+                ],
+                "summary": "List Abandoned Bases",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Inactive days, default manage.abandoned_base_days",
+                        "name": "days",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "csv"
+                        ],
+                        "type": "string",
+                        "default": "json",
+                        "description": "Format",
+                        "name": "format",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Get Server Info",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/api.ServerInfo"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/service.AbandonedBase"
+                            }
                         }
                     },
                     "400": {
@@ -975,44 +1262,62 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/api.ErrorResponse"
                         }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
                     }
                 }
             }
         },
-        "/api/server/broadcast": {
-            "post": {
+        "/api/guild/export": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Publish Broadcast",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Export guilds as CSV or Excel, with the chosen columns of name, admin_player_uid, base_camp_level, members, base_camps and member_names",
                 "produces": [
-                    "application/json"
+                    "text/csv",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
                 ],
                 "tags": [
-                    "Server"
+                    "Guild"
                 ],
-                "summary": "Publish Broadcast",
+                "summary": "Export Guilds",
                 "parameters": [
                     {
-                        "description": "Broadcast",
-                        "name": "broadcast",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/api.BroadcastRequest"
-                        }
+                        "enum": [
+                            "csv",
+                            "xlsx"
+                        ],
+                        "type": "string",
+                        "default": "csv",
+                        "description": "Format",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated columns, all by default",
+                        "name": "columns",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Minimum members",
+                        "name": "min_members",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/api.SuccessResponse"
+                            "type": "file"
                         }
                     },
                     "400": {
@@ -1030,9 +1335,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/server/metrics": {
+        "/api/guild/{admin_player_uid}": {
             "get": {
-                "description": "Get Server Metrics",
+                "description": "Get Guild",
                 "consumes": [
                     "application/json"
                 ],
@@ -1040,14 +1345,49 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Server"
+                    "Guild"
+                ],
+                "summary": "Get Guild",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Admin Player UID",
+                        "name": "admin_player_uid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "add base_camp_locations computed from the base camp positions",
+                        "name": "location",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 6,
+                        "type": "integer",
+                        "default": 2,
+                        "description": "decimals of location values",
+                        "name": "precision",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "m",
+                            "cm",
+                            "km"
+                        ],
+                        "type": "string",
+                        "default": "m",
+                        "description": "unit of distance_from_spawn",
+                        "name": "unit",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Get Server Metrics",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/api.ServerMetrics"
+                            "$ref": "#/definitions/database.Guild"
                         }
                     },
                     "400": {
@@ -1055,18 +1395,19 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/api.ErrorResponse"
                         }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.EmptyResponse"
+                        }
                     }
                 }
             }
         },
-        "/api/server/shutdown": {
-            "post": {
-                "security": [
-                    {
-                        "ApiKeyAuth": []
-                    }
-                ],
-                "description": "Shutdown Server",
+        "/api/guild/{admin_player_uid}/history": {
+            "get": {
+                "description": "Get membership changes (guild.create, guild.disband, guild.join, guild.leave) of the guild of a player, newest first. Events are matched by the guild id, so they span admin changes",
                 "consumes": [
                     "application/json"
                 ],
@@ -1074,25 +1415,32 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Server"
+                    "Guild"
                 ],
-                "summary": "Shutdown Server",
+                "summary": "Get Guild History",
                 "parameters": [
                     {
-                        "description": "Shutdown",
-                        "name": "shutdown",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/api.ShutdownRequest"
-                        }
-                    }
-                ],
-                "responses": {
-                    "200": {
+                        "type": "string",
+                        "description": "Admin Player UID",
+                        "name": "admin_player_uid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "max number of events",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/api.SuccessResponse"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/database.Event"
+                            }
                         }
                     },
                     "400": {
@@ -1100,19 +1448,68 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/api.ErrorResponse"
                         }
+                    }
+                }
+            }
+        },
+        "/api/guild/{admin_player_uid}/stats": {
+            "get": {
+                "description": "Get member levels, pals, base camps and last activity of a guild, with daily snapshots from save syncs for growth over time",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Guild"
+                ],
+                "summary": "Get Guild Stats",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Admin Player UID",
+                        "name": "admin_player_uid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "days of history, default 30",
+                        "name": "days",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/service.GuildStatsReport"
+                        }
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
                             "$ref": "#/definitions/api.ErrorResponse"
                         }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.EmptyResponse"
+                        }
                     }
                 }
             }
         },
-        "/api/server/tool": {
+        "/api/guild/{admin_player_uid}/storage": {
             "get": {
-                "description": "Get PalWorld Server Tool",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "List the item count changes in the chests of the base camps of a guild between save syncs, with the members online in between, to find who emptied a chest",
                 "consumes": [
                     "application/json"
                 ],
@@ -1120,27 +1517,93 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Server"
+                    "Guild"
+                ],
+                "summary": "List Guild Storage Changes",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Admin Player UID",
+                        "name": "admin_player_uid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "only the changes of this base camp",
+                        "name": "base_camp_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "unix seconds or RFC3339",
+                        "name": "since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "unix seconds or RFC3339",
+                        "name": "until",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "only withdrawals of at least this many items",
+                        "name": "withdrawals",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "time",
+                            "amount"
+                        ],
+                        "type": "string",
+                        "default": "time",
+                        "description": "newest first or the largest withdrawal first",
+                        "name": "order_by",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 100,
+                        "description": "max number of changes",
+                        "name": "limit",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Get PalWorld Server Tool",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/api.ServerToolResponse"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/database.StorageChange"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/api/sync": {
-            "post": {
+        "/api/ip_reputation/{ip}": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Sync Data",
+                "description": "Check an ip against the ip_reputation lists and provider",
                 "consumes": [
                     "application/json"
                 ],
@@ -1148,19 +1611,15 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Sync"
+                    "Player"
                 ],
-                "summary": "Sync Data",
+                "summary": "Check IP Reputation",
                 "parameters": [
                     {
-                        "enum": [
-                            "rest",
-                            "sav"
-                        ],
                         "type": "string",
-                        "description": "from",
-                        "name": "from",
-                        "in": "query",
+                        "description": "IP",
+                        "name": "ip",
+                        "in": "path",
                         "required": true
                     }
                 ],
@@ -1168,7 +1627,13 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/api.SuccessResponse"
+                            "$ref": "#/definitions/tool.IpReputation"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
                         }
                     },
                     "401": {
@@ -1180,9 +1645,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/whitelist": {
+        "/api/ipban": {
             "get": {
-                "description": "List White List",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "List the ip and CIDR bans that haven't expired, all unless a page is asked for. X-Total-Count is how many bans match q",
                 "consumes": [
                     "application/json"
                 ],
@@ -1192,14 +1662,60 @@ const docTemplate = `{
                 "tags": [
                     "Player"
                 ],
-                "summary": "List White List",
+                "summary": "List IP Bans",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "part of the ip or the reason",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "ip",
+                            "created_at",
+                            "expires_at"
+                        ],
+                        "type": "string",
+                        "default": "ip",
+                        "description": "order by field",
+                        "name": "order_by",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "order by desc",
+                        "name": "desc",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 1000,
+                        "type": "integer",
+                        "description": "Page Size, 100 when only page is given",
+                        "name": "page_size",
+                        "in": "query"
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/database.PlayerW"
+                                "$ref": "#/definitions/database.IpBan"
+                            }
+                        },
+                        "headers": {
+                            "X-Total-Count": {
+                                "type": "int",
+                                "description": "bans matching q"
                             }
                         }
                     },
@@ -1208,16 +1724,22 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/api.ErrorResponse"
                         }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
                     }
                 }
             },
-            "put": {
+            "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Put White List",
+                "description": "Ban an ip or a CIDR range, online players matching it are kicked at every player sync",
                 "consumes": [
                     "application/json"
                 ],
@@ -1227,26 +1749,29 @@ const docTemplate = `{
                 "tags": [
                     "Player"
                 ],
-                "summary": "Put White List",
+                "summary": "Add IP Ban",
                 "parameters": [
                     {
-                        "description": "Players",
-                        "name": "players",
+                        "description": "IP Ban",
+                        "name": "ban",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/database.PlayerW"
-                            }
+                            "$ref": "#/definitions/api.IpBanRequest"
                         }
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only return the ban that would be stored, as a DryRunResponse",
+                        "name": "dry_run",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/api.SuccessResponse"
+                            "$ref": "#/definitions/database.IpBan"
                         }
                     },
                     "400": {
@@ -1263,13 +1788,13 @@ const docTemplate = `{
                     }
                 }
             },
-            "post": {
+            "delete": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Add White List",
+                "description": "Remove an ip or CIDR ban, it is kept in the history of removed entries to be restored",
                 "consumes": [
                     "application/json"
                 ],
@@ -1279,14 +1804,22 @@ const docTemplate = `{
                 "tags": [
                     "Player"
                 ],
-                "summary": "Add White List",
+                "summary": "Remove IP Ban",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Player UID",
-                        "name": "player_uid",
-                        "in": "path",
-                        "required": true
+                        "description": "IP Ban, ip and reason are used",
+                        "name": "ban",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.IpBanRequest"
+                        }
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only return the ban that would be removed, as a DryRunResponse",
+                        "name": "dry_run",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -1307,40 +1840,44 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/api.ErrorResponse"
                         }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.EmptyResponse"
+                        }
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/api/ipban/count": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Remove White List",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Count the bans that haven't expired matching q",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "Player"
                 ],
-                "summary": "Remove White List",
+                "summary": "Count IP Bans",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Player UID",
-                        "name": "player_uid",
-                        "in": "path",
-                        "required": true
+                        "description": "part of the ip or the reason",
+                        "name": "q",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/api.SuccessResponse"
+                            "$ref": "#/definitions/api.CountResponse"
                         }
                     },
                     "400": {
@@ -1357,513 +1894,10170 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "api.BroadcastRequest": {
-            "type": "object",
-            "properties": {
-                "message": {
-                    "type": "string"
-                }
-            }
-        },
-        "api.EmptyResponse": {
-            "type": "object"
         },
-        "api.ErrorResponse": {
-            "type": "object",
-            "properties": {
-                "error": {
-                    "type": "string"
-                }
+        "/api/items": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Search the item catalog by key or name, for the item_id of give_item",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Player"
+                ],
+                "summary": "List Items",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Part of the key or a name",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 50,
+                        "description": "Max number of items",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/catalog.Item"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/locale": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "List the languages of backend messages and the one each channel uses, set by locale.default and locale.\u003cchannel\u003e",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Locale"
+                ],
+                "summary": "List Locales",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.LocaleInfo"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/locale/{lang}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get the messages of a language, keys it lacks are taken from en",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Locale"
+                ],
+                "summary": "Get Locale",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Language, like zh-CN",
+                        "name": "lang",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Add a language or override messages of a bundled one, {name} placeholders are the same as in en",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Locale"
+                ],
+                "summary": "Put Locale",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Language, like zh-CN",
+                        "name": "lang",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Messages by key",
+                        "name": "messages",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Remove the custom messages of a language, bundled messages stay",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Locale"
+                ],
+                "summary": "Remove Locale",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Language, like zh-CN",
+                        "name": "lang",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.EmptyResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/login": {
+            "post": {
+                "description": "Login. Failed attempts of an ip are limited to web.login_attempts per web.login_window seconds",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Login",
+                "parameters": [
+                    {
+                        "description": "Login Info",
+                        "name": "login_info",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.LoginInfo"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/logout": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Revoke the token of the request on every replica until it expires, POST /api/me/logout for a player token",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Logout",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/macros": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "List Macros",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Macro"
+                ],
+                "summary": "List Macros",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/database.Macro"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/macros/{name}": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Create or replace a named macro",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Macro"
+                ],
+                "summary": "Put Macro",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Macro Name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Macro",
+                        "name": "macro",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/database.Macro"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Run a named macro in the background, {key} placeholders in step content are replaced by params",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Macro"
+                ],
+                "summary": "Run Macro",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Macro Name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Macro Params",
+                        "name": "params",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/api.RunMacroRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Remove Macro",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Macro"
+                ],
+                "summary": "Remove Macro",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Macro Name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/map": {
+            "get": {
+                "description": "Get the served map version, max zoom and tile url template",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Map"
+                ],
+                "summary": "Get Map Info",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/tool.MapInfo"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/map/calibration": {
+            "get": {
+                "description": "Get the world bounds of the served map version",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Map"
+                ],
+                "summary": "Get Map Calibration",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/database.MapCalibration"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Set the world bounds of the served map version",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Map"
+                ],
+                "summary": "Put Map Calibration",
+                "parameters": [
+                    {
+                        "description": "Map Calibration",
+                        "name": "calibration",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/database.MapCalibration"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Reset the served map version to the default calibration",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Map"
+                ],
+                "summary": "Remove Map Calibration",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/map/convert": {
+            "get": {
+                "description": "Convert a position between in-game world coordinates, map latlng and tile pixels using the calibration of the served map",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Map"
+                ],
+                "summary": "Convert Map Position",
+                "parameters": [
+                    {
+                        "enum": [
+                            "world",
+                            "latlng",
+                            "pixel"
+                        ],
+                        "type": "string",
+                        "default": "world",
+                        "description": "Coordinate type of x and y",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "World X, Lat or Pixel X",
+                        "name": "x",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "number",
+                        "description": "World Y, Lng or Pixel Y",
+                        "name": "y",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Zoom level of pixel coordinates",
+                        "name": "zoom",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "add the in-game map coordinates, distance from spawn and region",
+                        "name": "location",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 6,
+                        "type": "integer",
+                        "default": 2,
+                        "description": "decimals of location values",
+                        "name": "precision",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "m",
+                            "cm",
+                            "km"
+                        ],
+                        "type": "string",
+                        "default": "m",
+                        "description": "unit of distance_from_spawn",
+                        "name": "unit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/tool.MapPosition"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/map/heatmap": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get the player activity heatmap of a day or of the week ending on that day, as a grid in JSON or a png overlay covering the whole map",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "image/png"
+                ],
+                "tags": [
+                    "Map"
+                ],
+                "summary": "Get Heatmap",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Date as 2006-01-02, default today",
+                        "name": "date",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "day",
+                            "week"
+                        ],
+                        "type": "string",
+                        "default": "day",
+                        "description": "Period",
+                        "name": "period",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "png"
+                        ],
+                        "type": "string",
+                        "default": "json",
+                        "description": "Format",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 512,
+                        "description": "Png width in pixels",
+                        "name": "width",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/database.Heatmap"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/map/objects": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "List world objects of the latest save within a region, objects outside any base camp are the ones left in the wild",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Map"
+                ],
+                "summary": "List Map Objects",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Region in world coordinates as min_x,min_y,max_x,max_y",
+                        "name": "bbox",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "pal_box",
+                            "chest",
+                            "drop_item",
+                            "egg",
+                            "structure"
+                        ],
+                        "type": "string",
+                        "description": "Category",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "true",
+                            "false"
+                        ],
+                        "type": "string",
+                        "description": "Only objects inside or outside base camps",
+                        "name": "in_base",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 100,
+                        "description": "Page Size",
+                        "name": "page_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "add the location computed from location_x and location_y",
+                        "name": "location",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 6,
+                        "type": "integer",
+                        "default": 2,
+                        "description": "decimals of location values",
+                        "name": "precision",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "m",
+                            "cm",
+                            "km"
+                        ],
+                        "type": "string",
+                        "default": "m",
+                        "description": "unit of distance_from_spawn",
+                        "name": "unit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.MapObjectsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Put Map Objects Only For SavSync",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Map"
+                ],
+                "summary": "Put Map Objects",
+                "parameters": [
+                    {
+                        "description": "Map Objects",
+                        "name": "objects",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/database.MapObject"
+                            }
+                        }
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Sync batch journaling the map objects",
+                        "name": "X-Sync-Batch",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/me": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "The Steam account of the player token, with its characters and whether it's whitelisted",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Get Me",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.MeResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/me/application": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "The last whitelist application of the Steam account of the player token",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Whitelist Application"
+                ],
+                "summary": "Get My Whitelist Application",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/database.WhitelistApplication"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.EmptyResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Apply to the whitelist as the Steam account of the player token, its steam_id is used whatever is sent",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Whitelist Application"
+                ],
+                "summary": "Apply To Whitelist With Steam",
+                "parameters": [
+                    {
+                        "description": "Application",
+                        "name": "application",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.ApplicationRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/database.WhitelistApplication"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/mods": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "List UE4SS and pak mods installed under mods.server_dir",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Mod"
+                ],
+                "summary": "List Mods",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/tool.Mod"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/mods/{type}/{name}/config": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get the content of a config file listed in config_files of the mod",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "Mod"
+                ],
+                "summary": "Get Mod Config",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ue4ss or pak",
+                        "name": "type",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Mod name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Config file",
+                        "name": "file",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Replace a config file of the mod with the request body, the old file is copied to backups/mods first",
+                "consumes": [
+                    "text/plain"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Mod"
+                ],
+                "summary": "Put Mod Config",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ue4ss or pak",
+                        "name": "type",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Mod name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Config file",
+                        "name": "file",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "description": "Content",
+                        "name": "content",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.ModConfigResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/mods/{type}/{name}/disable": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Disable a mod, it takes effect after the server restarts",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Mod"
+                ],
+                "summary": "Disable Mod",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ue4ss or pak",
+                        "name": "type",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Mod name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/tool.Mod"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/mods/{type}/{name}/enable": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Enable a mod, it takes effect after the server restarts",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Mod"
+                ],
+                "summary": "Enable Mod",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ue4ss or pak",
+                        "name": "type",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Mod name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/tool.Mod"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/online_player": {
+            "get": {
+                "description": "List Online Players",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Player"
+                ],
+                "summary": "List Online Players",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/database.OnlinePlayer"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/orphans": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Count the records of players no longer in the save and the stale backup index entries, without removing them",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Maintenance"
+                ],
+                "summary": "List Orphans",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/service.OrphanReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Remove the records of players no longer in the save and the stale backup index entries",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Maintenance"
+                ],
+                "summary": "Prune Orphans",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "Only count what would be removed, like GET",
+                        "name": "dry_run",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/service.OrphanReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/pal/{instance_id}/history": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get the ownership changes (pal.traded), moves between a base and the party or palbox (pal.moved) and the disappearance (pal.released) of a pal instance, newest first, recorded on save syncs",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Pal"
+                ],
+                "summary": "Get Pal History",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Pal Instance ID",
+                        "name": "instance_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "max number of events",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/database.Event"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.EmptyResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/player": {
+            "get": {
+                "description": "List Players",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Player"
+                ],
+                "summary": "List Players",
+                "parameters": [
+                    {
+                        "enum": [
+                            "last_online",
+                            "level"
+                        ],
+                        "type": "string",
+                        "description": "order by field",
+                        "name": "order_by",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "order by desc",
+                        "name": "desc",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only players updated after, unix seconds or RFC3339, pass the X-Server-Time of the last response",
+                        "name": "since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only players of the platform",
+                        "name": "platform",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "comma separated fields to return, like nickname,level,last_online",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only players of the saved segment, needs the login token",
+                        "name": "segment",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "add the location computed from location_x and location_y to players in the world",
+                        "name": "location",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 6,
+                        "type": "integer",
+                        "default": 2,
+                        "description": "decimals of location values",
+                        "name": "precision",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "m",
+                            "cm",
+                            "km"
+                        ],
+                        "type": "string",
+                        "default": "m",
+                        "description": "unit of distance_from_spawn",
+                        "name": "unit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "without order_by an error midway ends the list with an object of the error",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/database.TersePlayer"
+                            }
+                        },
+                        "headers": {
+                            "X-Server-Time": {
+                                "type": "string",
+                                "description": "time of the response for the next since"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Put Players Only For SavSync,PlayerSync",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Player"
+                ],
+                "summary": "Put Players",
+                "parameters": [
+                    {
+                        "description": "Players",
+                        "name": "players",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/database.Player"
+                            }
+                        }
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only return the players and events that would change, as a DryRunResponse",
+                        "name": "dry_run",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Sync batch journaling the players",
+                        "name": "X-Sync-Batch",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/player/alts": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Report players seen from the same ip address with their session timelines, concurrent groups are more likely a shared network than alt accounts",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Player"
+                ],
+                "summary": "List Alt Accounts",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/service.AltAccounts"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/player/bulk": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Kick, ban, tag or whitelist many players at once. Every player is tried, the results tell which failed and why.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Player"
+                ],
+                "summary": "Bulk Player Action",
+                "parameters": [
+                    {
+                        "description": "Players and action",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.BulkPlayerRequest"
+                        }
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only return the changes of each player, nothing is done",
+                        "name": "dry_run",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.BulkPlayerResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/player/export": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Export players as CSV or Excel, with the chosen columns of player_uid, nickname, steam_id, level, exp, hp, max_hp, ip, location_x, location_y and last_online",
+                "produces": [
+                    "text/csv",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+                ],
+                "tags": [
+                    "Player"
+                ],
+                "summary": "Export Players",
+                "parameters": [
+                    {
+                        "enum": [
+                            "csv",
+                            "xlsx"
+                        ],
+                        "type": "string",
+                        "default": "csv",
+                        "description": "Format",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated columns, all by default",
+                        "name": "columns",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Minimum level",
+                        "name": "min_level",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum level",
+                        "name": "max_level",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Only players online within the days",
+                        "name": "online_within_days",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/player/merge": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "List the player merges, newest first",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Player"
+                ],
+                "summary": "List Player Merges",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/database.PlayerMerge"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Merge two records of the same player, like crossplay duplicates. Playtime and points are summed, ip sessions, point transactions, whitelist entries and group memberships move to the kept uid. Can be undone for manage.merge_undo_hours",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Player"
+                ],
+                "summary": "Merge Players",
+                "parameters": [
+                    {
+                        "description": "Players to merge",
+                        "name": "merge",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.MergePlayersRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/database.PlayerMerge"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.EmptyResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/player/merge/{id}/undo": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Restore the records a merge changed, changes made to them since are lost",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Player"
+                ],
+                "summary": "Undo Player Merge",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Merge ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/database.PlayerMerge"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.EmptyResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/player/near": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "List players whose last known location is within radius of a position, nearest first, all in world coordinates",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Player"
+                ],
+                "summary": "List Nearby Players",
+                "parameters": [
+                    {
+                        "type": "number",
+                        "description": "World X",
+                        "name": "x",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "number",
+                        "description": "World Y",
+                        "name": "y",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "number",
+                        "description": "Radius in world units, centimeters",
+                        "name": "radius",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/task.NearbyPlayer"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/player/technology": {
+            "get": {
+                "description": "Get the average technology points and unlocks of the players and how many researched each technology, the progress of one player is in its technology",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Player"
+                ],
+                "summary": "Get Technology Stats",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/service.TechnologyStats"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/player/{player_uid}": {
+            "get": {
+                "description": "Get Player",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Player"
+                ],
+                "summary": "Get Player",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Player UID",
+                        "name": "player_uid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "comma separated fields to return, like nickname,level,pals",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "add the location computed from location_x and location_y when the player is in the world",
+                        "name": "location",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 6,
+                        "type": "integer",
+                        "default": 2,
+                        "description": "decimals of location values",
+                        "name": "precision",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "m",
+                            "cm",
+                            "km"
+                        ],
+                        "type": "string",
+                        "default": "m",
+                        "description": "unit of distance_from_spawn",
+                        "name": "unit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/database.Player"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.EmptyResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/player/{player_uid}/badges": {
+            "get": {
+                "description": "Get the badges a player earned and the days in a row they played",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Player"
+                ],
+                "summary": "Get Player Badges",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Player UID",
+                        "name": "player_uid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/database.PlayerBadges"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/player/{player_uid}/ban": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Ban Player",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Player"
+                ],
+                "summary": "Ban Player",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Player UID",
+                        "name": "player_uid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only return the command that would be sent, as a DryRunResponse",
+                        "name": "dry_run",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/player/{player_uid}/give_exp": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Give a player experience with the command of admin.commands.give_exp, {amount} is replaced. Limited by admin.give_limits of the player's group, recorded as an admin.command event",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Player"
+                ],
+                "summary": "Give Experience",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Player UID",
+                        "name": "player_uid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Amount and confirmation",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.GiveExpRequest"
+                        }
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only return the command that would be sent, as a DryRunResponse",
+                        "name": "dry_run",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.AdminActionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "501": {
+                        "description": "Not Implemented",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/player/{player_uid}/give_item": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Give a player an item of the catalog with the command of admin.commands.give_item, {item_id} and {count} are replaced. Limited by admin.give_limits of the player's group, recorded as an admin.command event",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Player"
+                ],
+                "summary": "Give Item",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Player UID",
+                        "name": "player_uid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Item, count and confirmation",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.GiveItemRequest"
+                        }
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only return the command that would be sent, as a DryRunResponse",
+                        "name": "dry_run",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.AdminActionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "501": {
+                        "description": "Not Implemented",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/player/{player_uid}/ips": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "List the ip addresses a player was seen from with the sessions of each",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Player"
+                ],
+                "summary": "List Player IPs",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Player UID",
+                        "name": "player_uid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/database.PlayerIp"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/player/{player_uid}/kick": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Kick Player",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Player"
+                ],
+                "summary": "Kick Player",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Player UID",
+                        "name": "player_uid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only return the command that would be sent, as a DryRunResponse",
+                        "name": "dry_run",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/player/{player_uid}/ping": {
+            "get": {
+                "description": "Get the ping percentiles of a player from the sampled history, with a series for a sparkline",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Player"
+                ],
+                "summary": "Get Player Ping",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Player UID",
+                        "name": "player_uid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "max points of the series, default 60",
+                        "name": "points",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/service.PingStats"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.EmptyResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/player/{player_uid}/unban": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Unban Player, the ban is kept in the history of removed entries to be restored",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Player"
+                ],
+                "summary": "Unban Player",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Player UID",
+                        "name": "player_uid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Why the ban is lifted",
+                        "name": "reason",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only return the command that would be sent and the removed entry kept, as a DryRunResponse",
+                        "name": "dry_run",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/player/{player_uid}/unlock_tech": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Unlock a technology for a player with the command of admin.commands.unlock_tech, {tech_id} is replaced. Limited by admin.give_limits of the player's group, recorded as an admin.command event",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Player"
+                ],
+                "summary": "Unlock Technology",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Player UID",
+                        "name": "player_uid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Technology and confirmation",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.UnlockTechRequest"
+                        }
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only return the command that would be sent, as a DryRunResponse",
+                        "name": "dry_run",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.AdminActionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "501": {
+                        "description": "Not Implemented",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/player/{player_uid}/{action}": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Teleport the admin character to the player, or bring the player to it, with the command of admin.commands.teleport or admin.commands.bring. The game only teleports from in-game chat, so the commands need a server mod. Sent actions are recorded as admin.command events",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Player"
+                ],
+                "summary": "Teleport To Player or Bring Player",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Player UID",
+                        "name": "player_uid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "teleport or bring",
+                        "name": "action",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Admin character and confirmation",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.TeleportRequest"
+                        }
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only return the command that would be sent, as a DryRunResponse",
+                        "name": "dry_run",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.AdminActionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "501": {
+                        "description": "Not Implemented",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/player_group": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "List player groups like VIP or admin with their members",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Player Group"
+                ],
+                "summary": "List Player Groups",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/database.PlayerGroup"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/player_group/{name}": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Create or replace a player group. Admin members bypass the whitelist and keep their slot, members of reserved slot groups can take a reserved slot, join_message is broadcast when a member joins",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Player Group"
+                ],
+                "summary": "Put Player Group",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Group Name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Player Group",
+                        "name": "group",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/database.PlayerGroup"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Remove Player Group",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Player Group"
+                ],
+                "summary": "Remove Player Group",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Group Name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/player_group/{name}/members": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Add a player to the group by player_uid or steam_id",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Player Group"
+                ],
+                "summary": "Add Group Member",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Group Name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Member",
+                        "name": "member",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/database.GroupMember"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Remove a player from the group by player_uid or steam_id",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Player Group"
+                ],
+                "summary": "Remove Group Member",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Group Name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Member",
+                        "name": "member",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/database.GroupMember"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/player_segment": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "List the saved player filters, query one with /api/player?segment=name",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Player Segment"
+                ],
+                "summary": "List Player Segments",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/database.PlayerSegment"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/player_segment/{name}": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Create or replace a saved player filter like level\u003e45 AND last_online\u003c7d AND NOT whitelisted. Fields are the player fields and whitelisted, tags, playtime (seconds), guild and groups, joined with AND, OR, NOT and parentheses. Operators are = != \u003e \u003e= \u003c \u003c= and ~ for contains, a time field compared to a duration like 7d compares its age",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Player Segment"
+                ],
+                "summary": "Put Player Segment",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Segment Name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Player Segment",
+                        "name": "segment",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/database.PlayerSegment"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Remove Player Segment",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Player Segment"
+                ],
+                "summary": "Remove Player Segment",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Segment Name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/playtime": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "List playtime of players with the rank tags they reached, longest first",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Player"
+                ],
+                "summary": "List Playtimes",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/database.Playtime"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/playtime/{player_uid}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get playtime of a player with the rank tags reached",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Player"
+                ],
+                "summary": "Get Playtime",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Player UID",
+                        "name": "player_uid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/database.Playtime"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.EmptyResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/points": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "List the points balances, highest first",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Points"
+                ],
+                "summary": "List Points",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/database.PointsAccount"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/points/{player_uid}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get the points balance of a player",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Points"
+                ],
+                "summary": "Get Points",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Player UID",
+                        "name": "player_uid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/database.PointsAccount"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.EmptyResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/points/{player_uid}/grant": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Add points to a player known from a save sync",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Points"
+                ],
+                "summary": "Grant Points",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Player UID",
+                        "name": "player_uid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Points",
+                        "name": "points",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.PointsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/database.PointsAccount"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.EmptyResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/points/{player_uid}/spend": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Take points off a player's balance, which can't go below zero",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Points"
+                ],
+                "summary": "Spend Points",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Player UID",
+                        "name": "player_uid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Points",
+                        "name": "points",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.PointsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/database.PointsAccount"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.EmptyResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/points/{player_uid}/transactions": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "List the points transactions of a player, newest first. Online earnings are one transaction per day",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Points"
+                ],
+                "summary": "List Points Transactions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Player UID",
+                        "name": "player_uid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "max number of transactions",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/database.PointsTransaction"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/rank": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "List the playtime ranks of rank.ranks by hours",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Player"
+                ],
+                "summary": "List Ranks",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/task.Rank"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/rcon": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "List Rcon Commands",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Rcon"
+                ],
+                "summary": "List Rcon Commands",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/database.RconCommandList"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Add Rcon Command",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Rcon"
+                ],
+                "summary": "Add Rcon Command",
+                "parameters": [
+                    {
+                        "description": "Rcon Command",
+                        "name": "command",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/database.RconCommand"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/rcon/import": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Import Rcon Commands from a TXT file",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Rcon"
+                ],
+                "summary": "Import Rcon Commands",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "Upload txt file",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/rcon/send": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Send Rcon Command",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Rcon"
+                ],
+                "summary": "Send Rcon Command",
+                "parameters": [
+                    {
+                        "description": "Rcon Command",
+                        "name": "command",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.SendRconCommandRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.MessageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/rcon/{uuid}": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Put Rcon Command",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Rcon"
+                ],
+                "summary": "Put Rcon Command",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Rcon Command",
+                        "name": "command",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/database.RconCommand"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Remove Rcon Command",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Rcon"
+                ],
+                "summary": "Remove Rcon Command",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/removed": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "List removed whitelist entries, ip bans and lifted player bans with who removed them, when and why, newest first",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Player"
+                ],
+                "summary": "List Removed Entries",
+                "parameters": [
+                    {
+                        "enum": [
+                            "whitelist",
+                            "ipban",
+                            "ban"
+                        ],
+                        "type": "string",
+                        "description": "Kind",
+                        "name": "kind",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "max number of entries, default 100",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/database.RemovedEntry"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/removed/{id}/restore": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Put a removed whitelist entry or ip ban back, or ban an unbanned player again",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Player"
+                ],
+                "summary": "Restore Removed Entry",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Entry ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/database.RemovedEntry"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.EmptyResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/replica/snapshot": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Download a consistent copy of the database for a read-only replica. With since set to the txid of the copy the replica has, 304 is answered when nothing changed",
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "replica"
+                ],
+                "summary": "Get Replica Snapshot",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "txid of the snapshot the replica has",
+                        "name": "since",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Snapshot",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "304": {
+                        "description": "Not Modified",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/reports/daily/{date}": {
+            "get": {
+                "description": "Get what changed on a day compared with the previous snapshot: new players, level gains, pal gains and guild changes",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Report"
+                ],
+                "summary": "Get Daily Report",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Date, YYYY-MM-DD",
+                        "name": "date",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/database.DailyReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.EmptyResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/reserved_slot": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "When the server is full, kick the most recently joined player outside reserved slot and admin groups so the given member can join",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Player Group"
+                ],
+                "summary": "Free Reserved Slot",
+                "parameters": [
+                    {
+                        "description": "VIP Member",
+                        "name": "member",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/database.GroupMember"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.ReservedSlotResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/scripts": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "List the scripts of scripts.dir as loaded, with the error of those that failed to load",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Script"
+                ],
+                "summary": "List Scripts",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/task.Script"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/seasons": {
+            "get": {
+                "description": "List the ended seasons, the first first",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Season"
+                ],
+                "summary": "List Seasons",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/database.Season"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "End the season for a wipe: the save is backed up, the database is archived under the season name with the backups not in a season yet, and the players, guilds and their stats are emptied for the next season. Archived backups aren't cleaned up",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Season"
+                ],
+                "summary": "End Season",
+                "parameters": [
+                    {
+                        "description": "Season name and confirmation",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.EndSeasonRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/database.Season"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/seasons/{id}/guilds": {
+            "get": {
+                "description": "List the guilds as they were when the season ended, read from its archive",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Season"
+                ],
+                "summary": "List Season Guilds",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Season ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/database.Guild"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/seasons/{id}/players": {
+            "get": {
+                "description": "List the players as they were when the season ended, read from its archive",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Season"
+                ],
+                "summary": "List Season Players",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Season ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/database.TersePlayer"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/server": {
+            "get": {
+                "description": "Get Server Info",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Server"
+                ],
+                "summary": "Get Server Info",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.ServerInfo"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/server/broadcast": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Publish Broadcast. With tags or segment it only targets the matching online players, the game has no messages to single players so the broadcast starts with their names",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Server"
+                ],
+                "summary": "Publish Broadcast",
+                "parameters": [
+                    {
+                        "description": "Broadcast",
+                        "name": "broadcast",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.BroadcastRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.BroadcastResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/server/endpoints": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "List the RCON and REST API addresses of the game server with their health, the address with active true took the last request. Fallbacks are used while the primary address can't be reached",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Server"
+                ],
+                "summary": "List Server Endpoints",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/tool.EndpointStatus"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/server/jobs": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "List lifecycle jobs, latest first",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Server"
+                ],
+                "summary": "List Server Jobs",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Max number of jobs, default 20",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/database.ServerJob"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/server/jobs/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get a lifecycle job with the output of its steps",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Server"
+                ],
+                "summary": "Get Server Job",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Job ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/database.ServerJob"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/server/metrics": {
+            "get": {
+                "description": "Get Server Metrics",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Server"
+                ],
+                "summary": "Get Server Metrics",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.ServerMetrics"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/server/metrics/history": {
+            "get": {
+                "description": "List the sampled server FPS and frame time with the player and base camp counts, oldest first",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Server"
+                ],
+                "summary": "List Server Metrics History",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "hours back, default 24",
+                        "name": "hours",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "max samples, averaged down, default 288",
+                        "name": "points",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/database.MetricSample"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/server/requests": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "List the policy of each class of requests to the game server, query, action, save and shutdown, with the requests, retries and failures since pst started",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Server"
+                ],
+                "summary": "List Server Request Statistics",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/tool.RequestStats"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/server/settings": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get OptionSettings of PalWorldSettings.ini",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Settings"
+                ],
+                "summary": "Get Server Settings",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/tool.SettingOption"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/server/settings/preset": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "List Settings Presets",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Settings"
+                ],
+                "summary": "List Settings Presets",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/database.SettingsPreset"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/server/settings/preset/{name}": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Create or replace a named settings preset",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Settings"
+                ],
+                "summary": "Put Settings Preset",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Preset Name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Settings Preset",
+                        "name": "preset",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/database.SettingsPreset"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Backup PalWorldSettings.ini, then write the preset values into it and optionally restart the server. The response has the id of a snapshot for /api/snapshot/{id}/revert",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Settings"
+                ],
+                "summary": "Apply Settings Preset",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Preset Name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Apply Options",
+                        "name": "apply",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/api.ApplyPresetRequest"
+                        }
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only return the settings that would change and the shutdown, as a DryRunResponse",
+                        "name": "dry_run",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.ApplyPresetResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Remove Settings Preset",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Settings"
+                ],
+                "summary": "Remove Settings Preset",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Preset Name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/server/shutdown": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Shutdown Server",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Server"
+                ],
+                "summary": "Shutdown Server",
+                "parameters": [
+                    {
+                        "description": "Shutdown",
+                        "name": "shutdown",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.ShutdownRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/server/state": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get the lifecycle state of the game server and the running job",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Server"
+                ],
+                "summary": "Get Server State",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/task.ServerState"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/server/tool": {
+            "get": {
+                "description": "Get PalWorld Server Tool",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Server"
+                ],
+                "summary": "Get PalWorld Server Tool",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.ServerToolResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/server/update_status": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get the result of the last check for a new server build on Steam, run it again by POST /api/tasks/update_check/run",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Server"
+                ],
+                "summary": "Get Update Status",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/task.UpdateStatus"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/server/{action}": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Start a job that stops the server after a countdown broadcast, starts it with server.start_command, restarts it or updates it with server.update_command. {action} and {seconds} in message are replaced",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Server"
+                ],
+                "summary": "Stop, Start, Restart or Update Server",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "stop, start, restart or update",
+                        "name": "action",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Options",
+                        "name": "job",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/api.ServerJobRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/database.ServerJob"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/setup": {
+            "get": {
+                "description": "Whether pst started without a config and waits for the first-run setup",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Setup"
+                ],
+                "summary": "Get Setup Status",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.SetupStatus"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Check the settings against the game server and the save, then write config.yaml and start the tasks. Only available once, while pst runs without a config, with the setup token pst logs at startup. A failed check is returned as a 400 with the fields at fault",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Setup"
+                ],
+                "summary": "Run Setup",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Setup token from the log",
+                        "name": "X-Setup-Token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Settings",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.SetupRequest"
+                        }
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only check the settings, as a DryRunResponse",
+                        "name": "dry_run",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.SetupStatus"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/snapshot": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "List the snapshots taken before replacing the whitelist, applying settings presets and reverting snapshots, newest first",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Snapshot"
+                ],
+                "summary": "List Snapshots",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "max number of snapshots, default 100",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/database.Snapshot"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/snapshot/{id}/revert": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Undo the operation of a snapshot, putting back the whitelist or PalWorldSettings.ini as they were before it. What the revert replaces is kept in a snapshot.revert snapshot, and an admin.restore event is recorded. Changes made since are lost, a settings change takes effect on the next server restart",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Snapshot"
+                ],
+                "summary": "Revert Snapshot",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Snapshot ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/database.Snapshot"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.EmptyResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/sync": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Sync Data",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Sync"
+                ],
+                "summary": "Sync Data",
+                "parameters": [
+                    {
+                        "enum": [
+                            "rest",
+                            "sav"
+                        ],
+                        "type": "string",
+                        "description": "from",
+                        "name": "from",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.SuccessResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/sync/journal": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "List the journaled save syncs newest first, with the hash and time of the save and what each step changed. save.journal_keep are kept",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Sync"
+                ],
+                "summary": "List Sync Journal",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "at most this many batches",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/database.SyncBatch"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/sync/journal/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get a journaled save sync",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Sync"
+                ],
+                "summary": "Get Sync Batch",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Batch ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/database.SyncBatch"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.EmptyResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/sync/journal/{id}/replay": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Apply again what the save sent in a journaled sync, as a new batch, to finish a partial sync or redo one rolled back",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Sync"
+                ],
+                "summary": "Replay Sync Batch",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Batch ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/database.SyncBatch"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.EmptyResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/sync/journal/{id}/rollback": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Put the players, guilds and map objects back as they were before the save sync. Records changed since, like a player who came online, are left as they are and counted in rollback_skipped. Only the latest batch not rolled back yet can be, roll back newer ones first. Events and stats derived during the sync stay",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Sync"
+                ],
+                "summary": "Rollback Sync Batch",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Batch ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/database.SyncBatch"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.EmptyResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/tasks": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "List scheduled tasks with their schedule, state and last result",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Task"
+                ],
+                "summary": "List Tasks",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/task.TaskInfo"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/tasks/{name}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get a task with its last result",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Task"
+                ],
+                "summary": "Get Task",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Task Name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/task.TaskInfo"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/tasks/{name}/pause": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Skip the scheduled runs of a task until it is resumed",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Task"
+                ],
+                "summary": "Pause Task",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Task Name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.SuccessResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/tasks/{name}/resume": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Resume a paused task",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Task"
+                ],
+                "summary": "Resume Task",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Task Name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.SuccessResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/tasks/{name}/run": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Run a task now in the background, its result shows up as the last result",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Task"
+                ],
+                "summary": "Run Task",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Task Name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/templates": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "List the outbound message keys with their default text and the template overriding it in every language. Templates are Go templates with the vars as data, like {{.username}}, and the functions duration, time, upper, lower, join and player. Older {name} placeholders keep working",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Template"
+                ],
+                "summary": "List Templates",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/api.MessageTemplate"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/templates/{key}": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Override an outbound message in every language, email.body replaces the html of emails",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Template"
+                ],
+                "summary": "Put Template",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Message key, like player.join",
+                        "name": "key",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Template",
+                        "name": "template",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.TemplateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Remove the override of a message, the localized default is used again",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Template"
+                ],
+                "summary": "Remove Template",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Message key",
+                        "name": "key",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.EmptyResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/templates/{key}/preview": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Render a message with example vars, the template in the body or else the current message of the key",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Template"
+                ],
+                "summary": "Preview Template",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Message key",
+                        "name": "key",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Template and vars",
+                        "name": "preview",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.PreviewTemplateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/whitelist": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "List White List, all entries unless a page is asked for. X-Total-Count is how many entries match q",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Player"
+                ],
+                "summary": "List White List",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "part of the name, steam id or player uid",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "name",
+                            "steam_id",
+                            "player_uid"
+                        ],
+                        "type": "string",
+                        "description": "order by field, the stored order without",
+                        "name": "order_by",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "order by desc",
+                        "name": "desc",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 1000,
+                        "type": "integer",
+                        "description": "Page Size, 100 when only page is given",
+                        "name": "page_size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/database.PlayerW"
+                            }
+                        },
+                        "headers": {
+                            "X-Total-Count": {
+                                "type": "int",
+                                "description": "entries matching q"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Put players in the White List. Mode replace drops the entries left out, keeping them in the history of removed entries, merge adds or updates each player like POST and append only adds the players not listed yet. A snapshot is taken first, the response has its id for /api/snapshot/{id}/revert",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Player"
+                ],
+                "summary": "Put White List",
+                "parameters": [
+                    {
+                        "description": "Players",
+                        "name": "players",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/database.PlayerW"
+                            }
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "replace, the default, merge or append",
+                        "name": "mode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Why entries are removed",
+                        "name": "reason",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only return the entries that would be added, overwritten or removed, as a DryRunResponse, no snapshot is taken",
+                        "name": "dry_run",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.SnapshotResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Add a player to the White List, or update the entry matching its uid, name or steam id. Sent with the revision of the entry, the update is refused with 409 when the entry changed since",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Player"
+                ],
+                "summary": "Add White List",
+                "parameters": [
+                    {
+                        "description": "Player",
+                        "name": "player",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/database.PlayerW"
+                        }
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only return the entries that would change, as a DryRunResponse",
+                        "name": "dry_run",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Remove White List, the entry is kept in the history of removed entries to be restored",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Player"
+                ],
+                "summary": "Remove White List",
+                "parameters": [
+                    {
+                        "description": "Player",
+                        "name": "player",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/database.PlayerW"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Why the player is removed",
+                        "name": "reason",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only return the entries that would change, as a DryRunResponse",
+                        "name": "dry_run",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/whitelist/application": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "List whitelist applications, newest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Whitelist Application"
+                ],
+                "summary": "List Whitelist Applications",
+                "parameters": [
+                    {
+                        "enum": [
+                            "pending",
+                            "approved",
+                            "denied"
+                        ],
+                        "type": "string",
+                        "description": "Status",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "max number of applications, default 100",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/database.WhitelistApplication"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Apply to the whitelist with the public form, an ip can send whitelist_application.rate_limit applications per hour, refused ones included",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Whitelist Application"
+                ],
+                "summary": "Apply To Whitelist",
+                "parameters": [
+                    {
+                        "description": "Application",
+                        "name": "application",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.ApplicationRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/database.WhitelistApplication"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/whitelist/application/form": {
+            "get": {
+                "description": "Whether players can apply to the whitelist and the questions they must answer",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Whitelist Application"
+                ],
+                "summary": "Get Whitelist Application Form",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.ApplicationFormResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/whitelist/application/{id}/{action}": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Approve a pending application, adding the applicant to the whitelist, or deny it. The applicant is mailed the decision when they left an email",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Whitelist Application"
+                ],
+                "summary": "Decide Whitelist Application",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Application ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "approve",
+                            "deny"
+                        ],
+                        "type": "string",
+                        "description": "Action",
+                        "name": "action",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Reason, told to the applicant",
+                        "name": "reason",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/database.WhitelistApplication"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.EmptyResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/whitelist/count": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Count the white list entries matching q",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Player"
+                ],
+                "summary": "Count White List",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "part of the name, steam id or player uid",
+                        "name": "q",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.CountResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/whitelist/export": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Export the whitelist as CSV or Excel, with the chosen columns of name, steam_id and player_uid",
+                "produces": [
+                    "text/csv",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+                ],
+                "tags": [
+                    "Player"
+                ],
+                "summary": "Export White List",
+                "parameters": [
+                    {
+                        "enum": [
+                            "csv",
+                            "xlsx"
+                        ],
+                        "type": "string",
+                        "default": "csv",
+                        "description": "Format",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated columns, all by default",
+                        "name": "columns",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/world/{action}": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Set the time of day or weather, or trigger a world event, with the command of admin.commands.set_time, set_weather or trigger_event. The game has no such RCON commands, they need a server mod. Schedule them with the start_actions and end_actions of a community event. Recorded as admin.command events",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Server"
+                ],
+                "summary": "Control World",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "set_time, set_weather or trigger_event",
+                        "name": "action",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Values and confirmation",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.WorldActionRequest"
+                        }
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only return the command that would be sent, as a DryRunResponse",
+                        "name": "dry_run",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.AdminActionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "501": {
+                        "description": "Not Implemented",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "api.AdminActionResponse": {
+            "type": "object",
+            "properties": {
+                "command": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.ApplicationFormResponse": {
+            "type": "object",
+            "properties": {
+                "enable": {
+                    "type": "boolean"
+                },
+                "questions": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "steam_login": {
+                    "description": "SteamLogin is whether players can apply signed in with Steam, POST\n/api/me/application",
+                    "type": "boolean"
+                }
+            }
+        },
+        "api.ApplicationRequest": {
+            "type": "object",
+            "properties": {
+                "answers": {
+                    "description": "Answers are by question, see GET /api/whitelist/application/form",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "email": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "player_uid": {
+                    "type": "string"
+                },
+                "steam_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.ApplyPresetRequest": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string"
+                },
+                "restart": {
+                    "type": "boolean"
+                },
+                "seconds": {
+                    "type": "integer"
+                }
+            }
+        },
+        "api.ApplyPresetResponse": {
+            "type": "object",
+            "properties": {
+                "backup": {
+                    "type": "string"
+                },
+                "snapshot_id": {
+                    "description": "SnapshotId reverts the change with /api/snapshot/{id}/revert",
+                    "type": "integer"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "api.BroadcastRequest": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string"
+                },
+                "segment": {
+                    "description": "Segment only sends to the online players of the saved segment",
+                    "type": "string"
+                },
+                "tags": {
+                    "description": "Tags only send to the online players with one of the tags, a rank\ntag or the name of a player group like vip",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "api.BroadcastResponse": {
+            "type": "object",
+            "properties": {
+                "recipients": {
+                    "description": "Recipients are the nicknames a targeted broadcast went to, nothing is\nsent when none is online. It is null for a broadcast to everyone",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "api.BulkPlayerRequest": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "description": "Action is kick, ban, tag or whitelist",
+                    "type": "string"
+                },
+                "player_uids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "tag": {
+                    "description": "Tag is added to the players by the tag action",
+                    "type": "string"
+                }
+            }
+        },
+        "api.BulkPlayerResponse": {
+            "type": "object",
+            "properties": {
+                "dry_run": {
+                    "type": "boolean"
+                },
+                "failed": {
+                    "type": "integer"
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.BulkPlayerResult"
+                    }
+                },
+                "succeeded": {
+                    "type": "integer"
+                }
+            }
+        },
+        "api.BulkPlayerResult": {
+            "type": "object",
+            "properties": {
+                "changes": {
+                    "description": "Changes are what the action would do, for a dry run",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.Change"
+                    }
+                },
+                "error": {
+                    "type": "string"
+                },
+                "player_uid": {
+                    "type": "string"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "api.CountResponse": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                }
+            }
+        },
+        "api.EmptyResponse": {
+            "type": "object"
+        },
+        "api.EndSeasonRequest": {
+            "type": "object",
+            "properties": {
+                "confirm": {
+                    "description": "Confirm must be true, without it nothing is reset",
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "skip_backup": {
+                    "description": "SkipBackup ends the season without backing up the save first",
+                    "type": "boolean"
+                }
+            }
+        },
+        "api.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "fields": {
+                    "description": "Fields are the refused fields of an invalid request",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/validate.FieldError"
+                    }
+                }
+            }
+        },
+        "api.GiveExpRequest": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "integer"
+                },
+                "confirm": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "api.GiveItemRequest": {
+            "type": "object",
+            "properties": {
+                "confirm": {
+                    "type": "boolean"
+                },
+                "count": {
+                    "type": "integer"
+                },
+                "item_id": {
+                    "description": "ItemId is the key of the item, or its exact name in a language",
+                    "type": "string"
+                }
+            }
+        },
+        "api.GraphqlRequest": {
+            "type": "object",
+            "properties": {
+                "operationName": {
+                    "type": "string"
+                },
+                "query": {
+                    "type": "string"
+                },
+                "variables": {
+                    "type": "object",
+                    "additionalProperties": {}
+                }
+            }
+        },
+        "api.IpBanRequest": {
+            "type": "object",
+            "properties": {
+                "expires_in": {
+                    "description": "ExpiresIn is the ban length in seconds, 0 bans for good",
+                    "type": "integer"
+                },
+                "ip": {
+                    "type": "string"
+                },
+                "reason": {
+                    "description": "Reason is why the ban is added, or removed",
+                    "type": "string"
+                }
+            }
+        },
+        "api.LocaleInfo": {
+            "type": "object",
+            "properties": {
+                "channels": {
+                    "description": "Channels is the language used by broadcast, bot and notify",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "languages": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "api.LocatedMapObject": {
+            "type": "object",
+            "properties": {
+                "base_camp_id": {
+                    "type": "string"
+                },
+                "build_player_uid": {
+                    "type": "string"
+                },
+                "category": {
+                    "type": "string"
+                },
+                "hp": {
+                    "type": "integer"
+                },
+                "instance_id": {
+                    "type": "string"
+                },
+                "location": {
+                    "$ref": "#/definitions/tool.Location"
+                },
+                "location_x": {
+                    "type": "number"
+                },
+                "location_y": {
+                    "type": "number"
+                },
+                "max_hp": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.LoginInfo": {
+            "type": "object",
+            "properties": {
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.MapObjectsResponse": {
+            "type": "object",
+            "properties": {
+                "objects": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.LocatedMapObject"
+                    }
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "page_size": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "api.MeResponse": {
+            "type": "object",
+            "properties": {
+                "players": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/database.TersePlayer"
+                    }
+                },
+                "steam_id": {
+                    "type": "string"
+                },
+                "whitelisted": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "api.MergePlayersRequest": {
+            "type": "object",
+            "properties": {
+                "keep": {
+                    "description": "Keep is the uid to keep, by default the canonical one or else the one\nonline last",
+                    "type": "string"
+                },
+                "other_player_revision": {
+                    "type": "integer"
+                },
+                "other_player_uid": {
+                    "type": "string"
+                },
+                "player_revision": {
+                    "description": "PlayerRevision and OtherPlayerRevision are the revisions of the players\nas read, the merge is refused when one changed since. 0 skips the check",
+                    "type": "integer"
+                },
+                "player_uid": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.MessageResponse": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.MessageTemplate": {
+            "type": "object",
+            "properties": {
+                "default": {
+                    "description": "Default is the message in locale.default",
+                    "type": "string"
+                },
+                "key": {
+                    "type": "string"
+                },
+                "template": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.ModConfigResponse": {
+            "type": "object",
+            "properties": {
+                "backup": {
+                    "type": "string"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "api.OneBotEvent": {
+            "type": "object",
+            "properties": {
+                "group_id": {
+                    "type": "integer"
+                },
+                "message_type": {
+                    "type": "string"
+                },
+                "post_type": {
+                    "type": "string"
+                },
+                "raw_message": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "api.OneBotReply": {
+            "type": "object",
+            "properties": {
+                "auto_escape": {
+                    "type": "boolean"
+                },
+                "reply": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.PointsRequest": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "description": "Amount is positive, spend takes it off the balance",
+                    "type": "integer"
+                },
+                "reason": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.PreviewTemplateRequest": {
+            "type": "object",
+            "properties": {
+                "template": {
+                    "description": "Template is previewed instead of the current message when set",
+                    "type": "string"
+                },
+                "vars": {
+                    "type": "object",
+                    "additionalProperties": {}
+                }
+            }
+        },
+        "api.ReservedSlotResponse": {
+            "type": "object",
+            "properties": {
+                "kicked": {
+                    "$ref": "#/definitions/database.OnlinePlayer"
+                }
+            }
+        },
+        "api.RunMacroRequest": {
+            "type": "object",
+            "properties": {
+                "params": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "api.SendRconCommandRequest": {
+            "type": "object",
+            "properties": {
+                "content": {
+                    "type": "string"
+                },
+                "uuid": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.ServerInfo": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.ServerJobRequest": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string"
+                },
+                "seconds": {
+                    "type": "integer"
+                },
+                "update": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "api.ServerMetrics": {
+            "type": "object",
+            "properties": {
+                "current_player_num": {
+                    "type": "integer"
+                },
+                "days": {
+                    "type": "integer"
+                },
+                "max_player_num": {
+                    "type": "integer"
+                },
+                "server_fps": {
+                    "type": "integer"
+                },
+                "server_frame_time": {
+                    "type": "number"
+                },
+                "uptime": {
+                    "type": "integer"
+                }
+            }
+        },
+        "api.ServerToolResponse": {
+            "type": "object",
+            "properties": {
+                "latest": {
+                    "type": "string"
+                },
+                "time_zone": {
+                    "description": "TimeZone is task.timezone, or the abbreviation of the host's zone,\nUtcOffset its current offset like +08:00",
+                    "type": "string"
+                },
+                "ui_version": {
+                    "description": "UiVersion is the version the web UI was built for",
+                    "type": "string"
+                },
+                "utc_offset": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.SetupRequest": {
+            "type": "object",
+            "properties": {
+                "admin_password": {
+                    "description": "AdminPassword is AdminPassword of PalWorldSettings.ini",
+                    "type": "string"
+                },
+                "rcon_address": {
+                    "description": "RconAddress is like 127.0.0.1:25575",
+                    "type": "string"
+                },
+                "rest_address": {
+                    "description": "RestAddress is like http://127.0.0.1:8212",
+                    "type": "string"
+                },
+                "save_path": {
+                    "type": "string"
+                },
+                "web_password": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.SetupStatus": {
+            "type": "object",
+            "properties": {
+                "config_path": {
+                    "type": "string"
+                },
+                "required": {
+                    "description": "Required is true until the setup wrote the config, the other routes\nanswer 503 until then",
+                    "type": "boolean"
+                }
+            }
+        },
+        "api.ShutdownRequest": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string"
+                },
+                "seconds": {
+                    "type": "integer"
+                }
+            }
+        },
+        "api.SnapshotResponse": {
+            "type": "object",
+            "properties": {
+                "snapshot_id": {
+                    "type": "integer"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "api.SteamLoginResponse": {
+            "type": "object",
+            "properties": {
+                "players": {
+                    "description": "Players are the characters of the Steam account",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/database.TersePlayer"
+                    }
+                },
+                "steam_id": {
+                    "type": "string"
+                },
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.SuccessResponse": {
+            "type": "object",
+            "properties": {
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "api.TeleportRequest": {
+            "type": "object",
+            "properties": {
+                "admin_player_uid": {
+                    "description": "AdminPlayerUid is the admin character teleported or teleported to",
+                    "type": "string"
+                },
+                "confirm": {
+                    "description": "Confirm must be true, without it nothing is sent",
+                    "type": "boolean"
+                }
+            }
+        },
+        "api.TemplateRequest": {
+            "type": "object",
+            "properties": {
+                "template": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.UnlockTechRequest": {
+            "type": "object",
+            "properties": {
+                "confirm": {
+                    "type": "boolean"
+                },
+                "tech_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.WorldActionRequest": {
+            "type": "object",
+            "properties": {
+                "confirm": {
+                    "type": "boolean"
+                },
+                "vars": {
+                    "description": "Vars are hour and minute of set_time, weather of set_weather and\nevent of trigger_event",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "catalog.Item": {
+            "type": "object",
+            "properties": {
+                "key": {
+                    "description": "Key is the id of the item in the game and its commands",
+                    "type": "string"
+                },
+                "names": {
+                    "description": "Names are the display names by language, en, zh and ja",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "crash.Report": {
+            "type": "object",
+            "properties": {
+                "created": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "size": {
+                    "type": "integer"
+                }
+            }
+        },
+        "database.Backup": {
+            "type": "object",
+            "properties": {
+                "backup_id": {
+                    "type": "string"
+                },
+                "checksum": {
+                    "description": "Checksum is the hex sha256 of the archive, or of the file contents in\norder for chunked backups",
+                    "type": "string"
+                },
+                "files": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/database.BackupFile"
+                    }
+                },
+                "format": {
+                    "description": "Format is zip or chunks, older records without it are zip",
+                    "type": "string"
+                },
+                "key_id": {
+                    "description": "KeyId is the id of the key the backup is encrypted with, empty when\nit isn't encrypted",
+                    "type": "string"
+                },
+                "path": {
+                    "type": "string"
+                },
+                "player_count": {
+                    "type": "integer"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "save_time": {
+                    "type": "string"
+                },
+                "season": {
+                    "description": "Season is the name of the season the backup was archived with, those\naren't cleaned up",
+                    "type": "string"
+                },
+                "size": {
+                    "type": "integer"
+                },
+                "stored_size": {
+                    "description": "StoredSize is how many bytes the backup added to the chunk store",
+                    "type": "integer"
+                },
+                "world_version": {
+                    "type": "string"
+                }
+            }
+        },
+        "database.BackupFile": {
+            "type": "object",
+            "properties": {
+                "chunks": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "mod_time": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "size": {
+                    "type": "integer"
+                }
+            }
+        },
+        "database.BaseCamp": {
+            "type": "object",
+            "properties": {
+                "area": {
+                    "type": "number"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "location_x": {
+                    "type": "number"
+                },
+                "location_y": {
+                    "type": "number"
+                },
+                "storage": {
+                    "description": "Storage is the item count in the chests of the camp by item id, nil\nwhen the save was parsed without it",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "structures": {
+                    "$ref": "#/definitions/database.BaseCampStructures"
+                }
+            }
+        },
+        "database.BaseCampStructures": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "hp": {
+                    "type": "integer"
+                },
+                "max_hp": {
+                    "type": "integer"
+                },
+                "pal_box": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "database.BucketSize": {
+            "type": "object",
+            "properties": {
+                "bytes": {
+                    "type": "integer"
+                },
+                "keys": {
+                    "type": "integer"
+                }
+            }
+        },
+        "database.CommunityEvent": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "announce_before": {
+                    "type": "integer"
+                },
+                "announced": {
+                    "type": "boolean"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "duration": {
+                    "type": "integer"
+                },
+                "enabled": {
+                    "type": "boolean"
+                },
+                "end_actions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/database.WorldAction"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "overrides": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "previous": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "restart": {
+                    "description": "Restart restarts the server when the overrides are applied and\nreverted, with server.start_command or else by shutting it down for\na supervisor to start",
+                    "type": "boolean"
+                },
+                "rewards": {
+                    "type": "string"
+                },
+                "start_actions": {
+                    "description": "StartActions and EndActions are world actions run when the event\nstarts and ends, like setting the time to night for a boss hunt",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/database.WorldAction"
+                    }
+                },
+                "start_time": {
+                    "type": "string"
+                },
+                "weekdays": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "database.DailyReport": {
+            "type": "object",
+            "properties": {
+                "date": {
+                    "type": "string"
+                },
+                "disbanded_guilds": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "guild_joins": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/database.ReportGuildMember"
+                    }
+                },
+                "guild_leaves": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/database.ReportGuildMember"
+                    }
+                },
+                "level_gains": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/database.ReportPlayer"
+                    }
+                },
+                "new_guilds": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "new_players": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/database.ReportPlayer"
+                    }
+                },
+                "pal_gains": {
+                    "description": "PalGains is the net gain of pals, captures less releases",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/database.ReportPlayer"
+                    }
+                },
+                "since": {
+                    "type": "string"
+                }
+            }
+        },
+        "database.EarnedBadge": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "time": {
+                    "type": "string"
+                },
+                "value": {
+                    "description": "Value is what the player had of the metric of the badge",
+                    "type": "number"
+                }
+            }
+        },
+        "database.Event": {
+            "type": "object",
+            "properties": {
+                "admin_player_uid": {
+                    "type": "string"
+                },
+                "data": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "player_uid": {
+                    "type": "string"
+                },
+                "time": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "database.GroupMember": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "player_uid": {
+                    "type": "string"
+                },
+                "steam_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "database.Guild": {
+            "type": "object",
+            "properties": {
+                "admin_player_uid": {
+                    "type": "string"
+                },
+                "base_camp": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/database.BaseCamp"
+                    }
+                },
+                "base_camp_level": {
+                    "type": "integer"
+                },
+                "id": {
+                    "description": "Id is the group id of the guild in the save, it stays when the admin\nchanges. Empty from older sav_cli.",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "players": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/database.GuildPlayer"
+                    }
+                }
+            }
+        },
+        "database.GuildPlayer": {
+            "type": "object",
+            "properties": {
+                "last_online": {
+                    "type": "string"
+                },
+                "nickname": {
+                    "type": "string"
+                },
+                "player_uid": {
+                    "type": "string"
+                }
+            }
+        },
+        "database.GuildStats": {
+            "type": "object",
+            "properties": {
+                "admin_player_uid": {
+                    "type": "string"
+                },
+                "average_level": {
+                    "type": "number"
+                },
+                "base_camp_level": {
+                    "type": "integer"
+                },
+                "base_camps": {
+                    "type": "integer"
+                },
+                "date": {
+                    "type": "string"
+                },
+                "last_active": {
+                    "type": "string"
+                },
+                "max_level": {
+                    "type": "integer"
+                },
+                "members": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "pals": {
+                    "type": "integer"
+                }
+            }
+        },
+        "database.Heatmap": {
+            "type": "object",
+            "properties": {
+                "cells": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "date": {
+                    "type": "string"
+                },
+                "samples": {
+                    "type": "integer"
+                },
+                "size": {
+                    "type": "integer"
+                }
+            }
+        },
+        "database.IpBan": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "description": "ExpiresAt zero means the ban never expires",
+                    "type": "string"
+                },
+                "ip": {
+                    "description": "Ip is a single address or a CIDR range",
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                }
+            }
+        },
+        "database.IpSession": {
+            "type": "object",
+            "properties": {
+                "end": {
+                    "type": "string"
+                },
+                "start": {
+                    "type": "string"
+                }
+            }
+        },
+        "database.Item": {
+            "type": "object",
+            "properties": {
+                "ItemId": {
+                    "type": "string"
+                },
+                "SlotIndex": {
+                    "type": "integer"
+                },
+                "StackCount": {
+                    "type": "integer"
+                }
+            }
+        },
+        "database.Items": {
+            "type": "object",
+            "properties": {
+                "CommonContainerId": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/database.Item"
+                    }
+                },
+                "DropSlotContainerId": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/database.Item"
+                    }
+                },
+                "EssentialContainerId": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/database.Item"
+                    }
+                },
+                "FoodEquipContainerId": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/database.Item"
+                    }
+                },
+                "PlayerEquipArmorContainerId": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/database.Item"
+                    }
+                },
+                "WeaponLoadOutContainerId": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/database.Item"
+                    }
+                }
+            }
+        },
+        "database.Macro": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "steps": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/database.MacroStep"
+                    }
+                }
+            }
+        },
+        "database.MacroStep": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "content": {
+                    "type": "string"
+                },
+                "seconds": {
+                    "type": "integer"
+                }
+            }
+        },
+        "database.MapCalibration": {
+            "type": "object",
+            "properties": {
+                "max_x": {
+                    "type": "number"
+                },
+                "max_y": {
+                    "type": "number"
+                },
+                "min_x": {
+                    "type": "number"
+                },
+                "min_y": {
+                    "type": "number"
+                },
+                "version": {
+                    "type": "string"
+                }
+            }
+        },
+        "database.MapObject": {
+            "type": "object",
+            "properties": {
+                "base_camp_id": {
+                    "type": "string"
+                },
+                "build_player_uid": {
+                    "type": "string"
+                },
+                "category": {
+                    "type": "string"
+                },
+                "hp": {
+                    "type": "integer"
+                },
+                "instance_id": {
+                    "type": "string"
+                },
+                "location_x": {
+                    "type": "number"
+                },
+                "location_y": {
+                    "type": "number"
+                },
+                "max_hp": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "database.MetricSample": {
+            "type": "object",
+            "properties": {
+                "base_camps": {
+                    "type": "number"
+                },
+                "players": {
+                    "type": "number"
+                },
+                "server_fps": {
+                    "type": "number"
+                },
+                "server_frame_time": {
+                    "type": "number"
+                },
+                "time": {
+                    "type": "string"
+                }
+            }
+        },
+        "database.OnlinePlayer": {
+            "type": "object",
+            "properties": {
+                "ip": {
+                    "type": "string"
+                },
+                "last_online": {
+                    "type": "string"
+                },
+                "level": {
+                    "type": "integer"
+                },
+                "location_x": {
+                    "type": "number"
+                },
+                "location_y": {
+                    "type": "number"
+                },
+                "nickname": {
+                    "type": "string"
+                },
+                "ping": {
+                    "type": "number"
+                },
+                "platform": {
+                    "description": "Platform is steam, xbox or playstation, empty when unknown",
+                    "type": "string"
+                },
+                "player_uid": {
+                    "type": "string"
+                },
+                "steam_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "database.Pal": {
+            "type": "object",
+            "properties": {
+                "defense": {
+                    "type": "integer"
+                },
+                "exp": {
+                    "type": "integer"
+                },
+                "gender": {
+                    "type": "string"
+                },
+                "hp": {
+                    "type": "integer"
+                },
+                "instance_id": {
+                    "description": "InstanceId identifies the pal across saves, empty from older sav_cli",
+                    "type": "string"
+                },
+                "is_boss": {
+                    "type": "boolean"
+                },
+                "is_lucky": {
+                    "type": "boolean"
+                },
+                "is_tower": {
+                    "type": "boolean"
+                },
+                "level": {
+                    "type": "integer"
+                },
+                "location": {
+                    "description": "Location is party, palbox or base, empty when unknown",
+                    "type": "string"
+                },
+                "max_hp": {
+                    "type": "integer"
+                },
+                "melee": {
+                    "type": "integer"
+                },
+                "nickname": {
+                    "type": "string"
+                },
+                "ranged": {
+                    "type": "integer"
+                },
+                "rank": {
+                    "type": "integer"
+                },
+                "rank_attack": {
+                    "type": "integer"
+                },
+                "rank_craftspeed": {
+                    "type": "integer"
+                },
+                "rank_defence": {
+                    "type": "integer"
+                },
+                "skills": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "type": {
+                    "type": "string"
+                },
+                "workspeed": {
+                    "type": "integer"
+                }
+            }
+        },
+        "database.Player": {
+            "type": "object",
+            "properties": {
+                "exp": {
+                    "type": "integer"
+                },
+                "full_stomach": {
+                    "type": "number"
+                },
+                "hp": {
+                    "type": "integer"
+                },
+                "ip": {
+                    "type": "string"
+                },
+                "items": {
+                    "$ref": "#/definitions/database.Items"
+                },
+                "last_online": {
+                    "type": "string"
+                },
+                "last_online_source": {
+                    "description": "LastOnlineSource is save or live, whether last_online comes from the\nsave or the online players polled",
+                    "type": "string"
+                },
+                "level": {
+                    "type": "integer"
+                },
+                "location_x": {
+                    "type": "number"
+                },
+                "location_y": {
+                    "type": "number"
+                },
+                "max_hp": {
+                    "type": "integer"
+                },
+                "max_status_point": {
+                    "type": "integer"
+                },
+                "nickname": {
+                    "type": "string"
+                },
+                "pals": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/database.Pal"
+                    }
+                },
+                "ping": {
+                    "type": "number"
+                },
+                "platform": {
+                    "description": "Platform is steam, xbox or playstation, empty when unknown",
+                    "type": "string"
+                },
+                "player_uid": {
+                    "type": "string"
+                },
+                "revision": {
+                    "description": "Revision counts the changes by save syncs and merges, the online state\npolled in between doesn't count",
+                    "type": "integer"
+                },
+                "save_last_online": {
+                    "type": "string"
+                },
+                "shield_hp": {
+                    "type": "integer"
+                },
+                "shield_max_hp": {
+                    "type": "integer"
+                },
+                "status_point": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "steam_id": {
+                    "type": "string"
+                },
+                "technology": {
+                    "description": "Technology is nil when the player's save couldn't be read",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/database.Technology"
+                        }
+                    ]
+                },
+                "updated_at": {
+                    "description": "UpdatedAt is when the stored record last changed",
+                    "type": "string"
+                }
+            }
+        },
+        "database.PlayerBadges": {
+            "type": "object",
+            "properties": {
+                "badges": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/database.EarnedBadge"
+                    }
+                },
+                "nickname": {
+                    "type": "string"
+                },
+                "player_uid": {
+                    "type": "string"
+                },
+                "streak": {
+                    "description": "Streak is the days in a row the player was online, ending at\nStreakDay",
+                    "type": "integer"
+                },
+                "streak_day": {
+                    "type": "string"
+                }
+            }
+        },
+        "database.PlayerGroup": {
+            "type": "object",
+            "properties": {
+                "admin": {
+                    "description": "Admin members bypass the whitelist and are never kicked for a slot",
+                    "type": "boolean"
+                },
+                "join_message": {
+                    "description": "JoinMessage is broadcast when a member joins, {username} is replaced",
+                    "type": "string"
+                },
+                "members": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/database.GroupMember"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                },
+                "reserved_slot": {
+                    "description": "ReservedSlot members may take one of manage.reserved_slots, the most\nrecently joined non-members are kicked for them",
+                    "type": "boolean"
+                }
+            }
+        },
+        "database.PlayerIp": {
+            "type": "object",
+            "properties": {
+                "ip": {
+                    "type": "string"
+                },
+                "nickname": {
+                    "type": "string"
+                },
+                "player_uid": {
+                    "type": "string"
+                },
+                "sessions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/database.IpSession"
+                    }
+                },
+                "steam_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "database.PlayerMerge": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "merged_player_uid": {
+                    "type": "string"
+                },
+                "player_uid": {
+                    "description": "PlayerUid is the player kept, MergedPlayerUid the one merged into it",
+                    "type": "string"
+                },
+                "previous": {
+                    "description": "Previous holds the records the merge changed by bucket and key, null\nfor records it created",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "object",
+                        "additionalProperties": {
+                            "type": "array",
+                            "items": {
+                                "type": "integer"
+                            }
+                        }
+                    }
+                },
+                "time": {
+                    "type": "string"
+                },
+                "undo_until": {
+                    "type": "string"
+                },
+                "undone": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "database.PlayerSegment": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "filter": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "database.PlayerW": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "player_uid": {
+                    "type": "string"
+                },
+                "revision": {
+                    "description": "Revision counts the writes of the entry. A change sent with it is\nrefused when the entry was written since, 0 writes regardless.",
+                    "type": "integer"
+                },
+                "steam_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "database.Playtime": {
+            "type": "object",
+            "properties": {
+                "last_seen": {
+                    "type": "string"
+                },
+                "nickname": {
+                    "type": "string"
+                },
+                "player_uid": {
+                    "type": "string"
+                },
+                "seconds": {
+                    "description": "Seconds online, summed from player sync polls",
+                    "type": "integer"
+                },
+                "tags": {
+                    "description": "Tags of the ranks reached, appended once and kept",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "database.PointsAccount": {
+            "type": "object",
+            "properties": {
+                "balance": {
+                    "type": "integer"
+                },
+                "last_seen": {
+                    "type": "string"
+                },
+                "nickname": {
+                    "type": "string"
+                },
+                "online_seconds": {
+                    "description": "OnlineSeconds not paid yet, points are earned per whole minute",
+                    "type": "integer"
+                },
+                "online_transaction": {
+                    "description": "OnlineTransaction is the id of today's online earnings, which are\nsummed into one transaction per day",
+                    "type": "integer"
+                },
+                "player_uid": {
+                    "type": "string"
+                }
+            }
+        },
+        "database.PointsTransaction": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "description": "Amount is negative for spending",
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "player_uid": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "time": {
+                    "type": "string"
+                }
+            }
+        },
+        "database.RconCommand": {
+            "type": "object",
+            "properties": {
+                "command": {
+                    "type": "string"
+                },
+                "placeholder": {
+                    "type": "string"
+                },
+                "remark": {
+                    "type": "string"
+                }
+            }
+        },
+        "database.RconCommandList": {
+            "type": "object",
+            "properties": {
+                "command": {
+                    "type": "string"
+                },
+                "placeholder": {
+                    "type": "string"
+                },
+                "remark": {
+                    "type": "string"
+                },
+                "uuid": {
+                    "type": "string"
+                }
+            }
+        },
+        "database.RemovedEntry": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "ip_ban": {
+                    "$ref": "#/definitions/database.IpBan"
+                },
+                "kind": {
+                    "description": "Kind is whitelist, ipban or ban",
+                    "type": "string"
+                },
+                "player": {
+                    "description": "Player is the whitelist entry or the unbanned player",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/database.PlayerW"
+                        }
+                    ]
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "removed_at": {
+                    "type": "string"
+                },
+                "removed_by": {
+                    "description": "RemovedBy is the address of the request, the bot or cli",
+                    "type": "string"
+                },
+                "restored_at": {
+                    "description": "RestoredAt is zero until the entry is restored",
+                    "type": "string"
+                }
+            }
+        },
+        "database.ReportGuildMember": {
+            "type": "object",
+            "properties": {
+                "guild": {
+                    "type": "string"
+                },
+                "nickname": {
+                    "type": "string"
+                },
+                "player_uid": {
+                    "type": "string"
+                }
+            }
+        },
+        "database.ReportPlayer": {
+            "type": "object",
+            "properties": {
+                "from": {
+                    "description": "From and To are levels for level gains and pal counts for pal gains",
+                    "type": "integer"
+                },
+                "nickname": {
+                    "type": "string"
+                },
+                "player_uid": {
+                    "type": "string"
+                },
+                "to": {
+                    "type": "integer"
+                }
+            }
+        },
+        "database.Season": {
+            "type": "object",
+            "properties": {
+                "backups": {
+                    "type": "integer"
+                },
+                "end": {
+                    "type": "string"
+                },
+                "guilds": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "path": {
+                    "description": "Path is the archived database, relative to the seasons directory",
+                    "type": "string"
+                },
+                "players": {
+                    "type": "integer"
+                },
+                "start": {
+                    "description": "Start is the end of the season before, zero for the first one",
+                    "type": "string"
+                }
+            }
+        },
+        "database.ServerJob": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "steps": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/database.ServerJobStep"
+                    }
+                }
+            }
+        },
+        "database.ServerJobStep": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "output": {
+                    "type": "string"
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "database.SettingsPreset": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "values": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "database.Snapshot": {
+            "type": "object",
+            "properties": {
+                "buckets": {
+                    "description": "Buckets holds the records of the buckets the operation changes by\nbucket and key",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "object",
+                        "additionalProperties": {
+                            "type": "array",
+                            "items": {
+                                "type": "integer"
+                            }
+                        }
+                    }
+                },
+                "by": {
+                    "description": "By is the address of the request",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "operation": {
+                    "description": "Operation is whitelist.replace, whitelist.merge, whitelist.append,\nsettings.apply or snapshot.revert",
+                    "type": "string"
+                },
+                "reverted_at": {
+                    "description": "RevertedAt is zero until the snapshot is reverted",
+                    "type": "string"
+                },
+                "settings_backup": {
+                    "description": "SettingsBackup is the name of the PalWorldSettings.ini backup",
+                    "type": "string"
+                },
+                "time": {
+                    "type": "string"
+                }
+            }
+        },
+        "database.StorageChange": {
+            "type": "object",
+            "properties": {
+                "admin_player_uid": {
+                    "type": "string"
+                },
+                "after": {
+                    "type": "integer"
+                },
+                "base_camp_id": {
+                    "type": "string"
+                },
+                "before": {
+                    "type": "integer"
+                },
+                "delta": {
+                    "description": "Delta is the count added, negative for a withdrawal",
+                    "type": "integer"
+                },
+                "guild": {
+                    "type": "string"
+                },
+                "item_id": {
+                    "type": "string"
+                },
+                "online": {
+                    "description": "Online are the guild members online between the two syncs",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/database.GuildPlayer"
+                    }
+                },
+                "since": {
+                    "description": "Since is the sync the change was counted from",
+                    "type": "string"
+                },
+                "time": {
+                    "type": "string"
+                }
+            }
+        },
+        "database.StorageSample": {
+            "type": "object",
+            "properties": {
+                "buckets": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/database.BucketSize"
+                    }
+                },
+                "file_size": {
+                    "type": "integer"
+                },
+                "time": {
+                    "type": "string"
+                }
+            }
+        },
+        "database.SyncBatch": {
+            "type": "object",
+            "properties": {
+                "end": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "replay_of": {
+                    "description": "ReplayOf is the id of the batch this one replayed",
+                    "type": "integer"
+                },
+                "rollback_skipped": {
+                    "description": "RollbackSkipped are the records the rollback left as they were,\nthey changed after the batch",
+                    "type": "integer"
+                },
+                "rolled_back_at": {
+                    "description": "RolledBackAt is zero until the batch is rolled back",
+                    "type": "string"
+                },
+                "save_hash": {
+                    "description": "SaveHash is the hex sha256 of Level.sav, SaveTime its modification\ntime",
+                    "type": "string"
+                },
+                "save_time": {
+                    "type": "string"
+                },
+                "source": {
+                    "description": "Source is the save path, demo, or replay for a replayed batch",
+                    "type": "string"
+                },
+                "start": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "steps": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/database.SyncStep"
+                    }
+                }
+            }
+        },
+        "database.SyncStep": {
+            "type": "object",
+            "properties": {
+                "changed": {
+                    "type": "integer"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "mode": {
+                    "description": "Mode is how the step wrote the records, merge or replace for guilds",
+                    "type": "string"
+                },
+                "name": {
+                    "description": "Name is players, guilds or map_objects",
+                    "type": "string"
+                },
+                "records": {
+                    "description": "Records are the records the save sent, Changed the stored ones\nthe step added, changed or removed",
+                    "type": "integer"
+                },
+                "time": {
+                    "type": "string"
+                }
+            }
+        },
+        "database.Technology": {
+            "type": "object",
+            "properties": {
+                "ancient_points": {
+                    "type": "integer"
+                },
+                "points": {
+                    "description": "Points are the unspent technology points",
+                    "type": "integer"
+                },
+                "unlocked": {
+                    "description": "Unlocked are the researched recipe technologies, sorted",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "database.TersePlayer": {
+            "type": "object",
+            "properties": {
+                "exp": {
+                    "type": "integer"
+                },
+                "full_stomach": {
+                    "type": "number"
+                },
+                "hp": {
+                    "type": "integer"
+                },
+                "ip": {
+                    "type": "string"
+                },
+                "last_online": {
+                    "type": "string"
+                },
+                "last_online_source": {
+                    "description": "LastOnlineSource is save or live, whether last_online comes from the\nsave or the online players polled",
+                    "type": "string"
+                },
+                "level": {
+                    "type": "integer"
+                },
+                "location_x": {
+                    "type": "number"
+                },
+                "location_y": {
+                    "type": "number"
+                },
+                "max_hp": {
+                    "type": "integer"
+                },
+                "max_status_point": {
+                    "type": "integer"
+                },
+                "nickname": {
+                    "type": "string"
+                },
+                "ping": {
+                    "type": "number"
+                },
+                "platform": {
+                    "description": "Platform is steam, xbox or playstation, empty when unknown",
+                    "type": "string"
+                },
+                "player_uid": {
+                    "type": "string"
+                },
+                "revision": {
+                    "description": "Revision counts the changes by save syncs and merges, the online state\npolled in between doesn't count",
+                    "type": "integer"
+                },
+                "save_last_online": {
+                    "type": "string"
+                },
+                "shield_hp": {
+                    "type": "integer"
+                },
+                "shield_max_hp": {
+                    "type": "integer"
+                },
+                "status_point": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "steam_id": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "description": "UpdatedAt is when the stored record last changed",
+                    "type": "string"
+                }
+            }
+        },
+        "database.WhitelistApplication": {
+            "type": "object",
+            "properties": {
+                "answers": {
+                    "description": "Answers are to whitelist_application.questions by question",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "decided_at": {
+                    "type": "string"
+                },
+                "decided_by": {
+                    "description": "DecidedBy is the address of the request or the bot, DecidedAt is zero\nwhile pending",
+                    "type": "string"
+                },
+                "email": {
+                    "description": "Email is where the applicant is told of the decision, optional",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "player_uid": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "source": {
+                    "description": "Source is form, or steam when sent signed in with Steam",
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "steam_id": {
+                    "type": "string"
+                },
+                "time": {
+                    "type": "string"
+                }
+            }
+        },
+        "database.WorldAction": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "vars": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "graphql.Error": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string"
+                },
+                "path": {
+                    "type": "array",
+                    "items": {}
+                }
+            }
+        },
+        "graphql.Response": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/graphql.Result"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/graphql.Error"
+                    }
+                }
+            }
+        },
+        "graphql.Result": {
+            "type": "object"
+        },
+        "service.AbandonedBase": {
+            "type": "object",
+            "properties": {
+                "admin_player_uid": {
+                    "type": "string"
+                },
+                "base_camp_id": {
+                    "type": "string"
+                },
+                "guild": {
+                    "type": "string"
+                },
+                "inactive_days": {
+                    "type": "integer"
+                },
+                "last_online": {
+                    "type": "string"
+                },
+                "location_x": {
+                    "type": "number"
+                },
+                "location_y": {
+                    "type": "number"
+                },
+                "structures": {
+                    "type": "integer"
+                }
+            }
+        },
+        "service.ActionCount": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "count": {
+                    "type": "integer"
+                }
+            }
+        },
+        "service.AdminDay": {
+            "type": "object",
+            "properties": {
+                "actions": {
+                    "type": "integer"
+                },
+                "by": {
+                    "description": "By is the admin, the client address of the request, bot or cli",
+                    "type": "string"
+                },
+                "day": {
+                    "description": "Day is the date in task.timezone, like 2024-01-31",
+                    "type": "string"
+                }
+            }
+        },
+        "service.AltAccounts": {
+            "type": "object",
+            "properties": {
+                "concurrent": {
+                    "description": "Concurrent is true when some of the players were online at the same\ntime, more like a shared network than one person on several accounts",
+                    "type": "boolean"
+                },
+                "ip": {
+                    "type": "string"
+                },
+                "last_seen": {
+                    "type": "string"
+                },
+                "players": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/database.PlayerIp"
+                    }
+                }
             }
         },
-        "api.LoginInfo": {
+        "service.AuditAction": {
             "type": "object",
             "properties": {
-                "password": {
+                "action": {
+                    "type": "string"
+                },
+                "by": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "time": {
                     "type": "string"
                 }
             }
         },
-        "api.MessageResponse": {
+        "service.AuditSummary": {
             "type": "object",
             "properties": {
-                "message": {
+                "actions": {
+                    "description": "Actions are how often each action was used, most used first",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.ActionCount"
+                    }
+                },
+                "days": {
+                    "description": "Days are the actions of each admin per day, newest day first",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.AdminDay"
+                    }
+                },
+                "destructive": {
+                    "description": "Destructive are the latest removals and snapshotted operations,\nnewest first",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.AuditAction"
+                    }
+                },
+                "since": {
                     "type": "string"
                 }
             }
         },
-        "api.SendRconCommandRequest": {
+        "service.BadgeHolder": {
             "type": "object",
             "properties": {
-                "content": {
+                "nickname": {
                     "type": "string"
                 },
-                "uuid": {
+                "player_uid": {
+                    "type": "string"
+                },
+                "time": {
                     "type": "string"
                 }
             }
         },
-        "api.ServerInfo": {
+        "service.BadgeSummary": {
             "type": "object",
             "properties": {
-                "name": {
+                "description": {
                     "type": "string"
                 },
-                "version": {
+                "first": {
+                    "description": "First awards it only to the first player to get there",
+                    "type": "boolean"
+                },
+                "holders": {
+                    "description": "Holders are the players who earned it, the first first",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.BadgeHolder"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "metric": {
+                    "type": "string"
+                },
+                "name": {
                     "type": "string"
+                },
+                "value": {
+                    "type": "number"
                 }
             }
         },
-        "api.ServerMetrics": {
+        "service.BucketStats": {
             "type": "object",
             "properties": {
-                "current_player_num": {
-                    "type": "integer"
+                "average_record": {
+                    "type": "number"
                 },
-                "days": {
+                "bytes": {
+                    "description": "Bytes is what the pages of the bucket take in the file, InUse the\npart of them holding records",
                     "type": "integer"
                 },
-                "max_player_num": {
+                "bytes_growth": {
                     "type": "integer"
                 },
-                "server_fps": {
+                "in_use": {
                     "type": "integer"
                 },
-                "server_frame_time": {
-                    "type": "number"
+                "keys": {
+                    "type": "integer"
                 },
-                "uptime": {
+                "keys_growth": {
+                    "description": "KeysGrowth and BytesGrowth are the change since the first sample of\nthe range",
                     "type": "integer"
+                },
+                "name": {
+                    "type": "string"
                 }
             }
         },
-        "api.ServerToolResponse": {
+        "service.Change": {
             "type": "object",
             "properties": {
-                "latest": {
+                "action": {
                     "type": "string"
                 },
-                "version": {
+                "after": {
+                    "type": "object"
+                },
+                "before": {
+                    "type": "object"
+                },
+                "key": {
+                    "description": "Key is the record key, the setting name or the command",
+                    "type": "string"
+                },
+                "target": {
+                    "description": "Target is the bucket of a record, the settings file or server for commands",
                     "type": "string"
                 }
             }
         },
-        "api.ShutdownRequest": {
+        "service.GuildStatsReport": {
             "type": "object",
             "properties": {
-                "message": {
+                "admin_player_uid": {
                     "type": "string"
                 },
-                "seconds": {
+                "average_level": {
+                    "type": "number"
+                },
+                "base_camp_level": {
+                    "type": "integer"
+                },
+                "base_camps": {
+                    "type": "integer"
+                },
+                "date": {
+                    "type": "string"
+                },
+                "history": {
+                    "description": "History is the daily snapshots, oldest first",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/database.GuildStats"
+                    }
+                },
+                "last_active": {
+                    "type": "string"
+                },
+                "max_level": {
+                    "type": "integer"
+                },
+                "members": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "pals": {
                     "type": "integer"
                 }
             }
         },
-        "api.SuccessResponse": {
+        "service.OrphanReport": {
             "type": "object",
             "properties": {
-                "success": {
+                "bytes": {
+                    "description": "Bytes is the size of the keys and values removed",
+                    "type": "integer"
+                },
+                "dry_run": {
                     "type": "boolean"
+                },
+                "file_size": {
+                    "type": "integer"
+                },
+                "free_bytes": {
+                    "description": "FreeBytes is the space free in the database file afterwards, reused\nbefore the file grows again",
+                    "type": "integer"
+                },
+                "records": {
+                    "description": "Records counts the removed records by bucket",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
                 }
             }
         },
-        "database.Backup": {
+        "service.PingSample": {
             "type": "object",
             "properties": {
-                "backup_id": {
-                    "type": "string"
-                },
-                "path": {
-                    "type": "string"
+                "ping": {
+                    "type": "number"
                 },
-                "save_time": {
+                "time": {
                     "type": "string"
                 }
             }
         },
-        "database.BaseCamp": {
+        "service.PingStats": {
             "type": "object",
             "properties": {
-                "area": {
+                "avg": {
                     "type": "number"
                 },
-                "id": {
-                    "type": "string"
+                "max": {
+                    "type": "number"
                 },
-                "location_x": {
+                "min": {
                     "type": "number"
                 },
-                "location_y": {
+                "p50": {
                     "type": "number"
+                },
+                "p90": {
+                    "type": "number"
+                },
+                "p99": {
+                    "type": "number"
+                },
+                "player_uid": {
+                    "type": "string"
+                },
+                "samples": {
+                    "type": "integer"
+                },
+                "series": {
+                    "description": "Series is the history averaged down to at most the requested points,\nfor a sparkline",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.PingSample"
+                    }
                 }
             }
         },
-        "database.Guild": {
+        "service.StorageStats": {
             "type": "object",
             "properties": {
-                "admin_player_uid": {
-                    "type": "string"
-                },
-                "base_camp": {
+                "buckets": {
+                    "description": "Buckets are the largest first",
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/database.BaseCamp"
+                        "$ref": "#/definitions/service.BucketStats"
                     }
                 },
-                "base_camp_level": {
+                "file_size": {
                     "type": "integer"
                 },
-                "name": {
+                "free_bytes": {
+                    "description": "FreeBytes are in free pages the file keeps for reuse",
+                    "type": "integer"
+                },
+                "history": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/database.StorageSample"
+                    }
+                },
+                "path": {
                     "type": "string"
                 },
+                "since": {
+                    "description": "Since is the time of the sample the growth is measured from, zero\nwithout samples in the range",
+                    "type": "string"
+                }
+            }
+        },
+        "service.TechnologyStats": {
+            "type": "object",
+            "properties": {
+                "average_ancient_points": {
+                    "type": "number"
+                },
+                "average_points": {
+                    "type": "number"
+                },
+                "average_unlocked": {
+                    "type": "number"
+                },
                 "players": {
+                    "description": "Players are the players with technology data",
+                    "type": "integer"
+                },
+                "unlocks": {
+                    "description": "Unlocks are the researched technologies, the most common first",
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/database.GuildPlayer"
+                        "$ref": "#/definitions/service.TechnologyUnlock"
                     }
                 }
             }
         },
-        "database.GuildPlayer": {
+        "service.TechnologyUnlock": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "percent": {
+                    "type": "number"
+                },
+                "players": {
+                    "description": "Players are the players who researched it",
+                    "type": "integer"
+                }
+            }
+        },
+        "task.AfkPlayer": {
             "type": "object",
             "properties": {
+                "location_x": {
+                    "type": "number"
+                },
+                "location_y": {
+                    "type": "number"
+                },
                 "nickname": {
                     "type": "string"
                 },
                 "player_uid": {
                     "type": "string"
+                },
+                "since": {
+                    "type": "string"
+                },
+                "steam_id": {
+                    "type": "string"
+                },
+                "warned": {
+                    "type": "boolean"
                 }
             }
         },
-        "database.Item": {
+        "task.NearbyPlayer": {
             "type": "object",
             "properties": {
-                "ItemId": {
+                "distance": {
+                    "description": "Distance is in world units, centimeters",
+                    "type": "number"
+                },
+                "location_x": {
+                    "type": "number"
+                },
+                "location_y": {
+                    "type": "number"
+                },
+                "nickname": {
                     "type": "string"
                 },
-                "SlotIndex": {
-                    "type": "integer"
+                "online": {
+                    "type": "boolean"
                 },
-                "StackCount": {
-                    "type": "integer"
+                "player_uid": {
+                    "type": "string"
+                },
+                "seen_at": {
+                    "type": "string"
+                },
+                "steam_id": {
+                    "type": "string"
                 }
             }
         },
-        "database.Items": {
+        "task.Rank": {
             "type": "object",
             "properties": {
-                "CommonContainerId": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/database.Item"
-                    }
+                "hours": {
+                    "type": "number"
                 },
-                "DropSlotContainerId": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/database.Item"
-                    }
+                "name": {
+                    "type": "string"
                 },
-                "EssentialContainerId": {
+                "tag": {
+                    "type": "string"
+                }
+            }
+        },
+        "task.Script": {
+            "type": "object",
+            "properties": {
+                "actions": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/database.Item"
+                        "$ref": "#/definitions/task.ScriptAction"
                     }
                 },
-                "FoodEquipContainerId": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/database.Item"
-                    }
+                "error": {
+                    "type": "string"
                 },
-                "PlayerEquipArmorContainerId": {
+                "events": {
+                    "description": "Events selects event types like notify.events, an empty list selects\nthe stored events only",
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/database.Item"
+                        "type": "string"
                     }
                 },
-                "WeaponLoadOutContainerId": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/database.Item"
+                "lua": {
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "when": {
+                    "description": "When requires event fields to equal the values, keys are type,\nplayer_uid, admin_player_uid, message, nickname or data.\u003ckey\u003e",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
                     }
                 }
             }
         },
-        "database.OnlinePlayer": {
+        "task.ScriptAction": {
             "type": "object",
             "properties": {
-                "ip": {
-                    "type": "string"
-                },
-                "last_online": {
-                    "type": "string"
-                },
-                "level": {
-                    "type": "integer"
-                },
-                "location_x": {
-                    "type": "number"
-                },
-                "location_y": {
-                    "type": "number"
-                },
-                "nickname": {
+                "broadcast": {
                     "type": "string"
                 },
-                "ping": {
-                    "type": "number"
+                "kick": {
+                    "description": "Kick kicks the player of the event",
+                    "type": "boolean"
                 },
-                "player_uid": {
+                "tag": {
+                    "description": "Tag adds a tag to the playtime of the player of the event",
                     "type": "string"
+                }
+            }
+        },
+        "task.ServerState": {
+            "type": "object",
+            "properties": {
+                "job": {
+                    "$ref": "#/definitions/database.ServerJob"
                 },
-                "steam_id": {
+                "state": {
                     "type": "string"
                 }
             }
         },
-        "database.Pal": {
+        "task.TaskInfo": {
             "type": "object",
             "properties": {
-                "defense": {
-                    "type": "integer"
+                "last_duration": {
+                    "description": "LastDuration is in seconds",
+                    "type": "number"
                 },
-                "exp": {
-                    "type": "integer"
+                "last_error": {
+                    "type": "string"
                 },
-                "gender": {
+                "last_run": {
                     "type": "string"
                 },
-                "hp": {
-                    "type": "integer"
+                "name": {
+                    "type": "string"
                 },
-                "is_boss": {
-                    "type": "boolean"
+                "next_run": {
+                    "type": "string"
                 },
-                "is_lucky": {
+                "paused": {
                     "type": "boolean"
                 },
-                "is_tower": {
+                "running": {
                     "type": "boolean"
                 },
-                "level": {
-                    "type": "integer"
+                "schedule": {
+                    "type": "string"
+                }
+            }
+        },
+        "task.UpdateStatus": {
+            "type": "object",
+            "properties": {
+                "checked_at": {
+                    "type": "string"
                 },
-                "max_hp": {
-                    "type": "integer"
+                "error": {
+                    "type": "string"
                 },
-                "melee": {
-                    "type": "integer"
+                "installed_build_id": {
+                    "type": "string"
                 },
-                "nickname": {
+                "job_id": {
+                    "description": "JobId is the update job started by the last check, if any",
                     "type": "string"
                 },
-                "ranged": {
-                    "type": "integer"
+                "latest_build_id": {
+                    "type": "string"
                 },
-                "rank": {
-                    "type": "integer"
+                "update_available": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "tool.EndpointStatus": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "description": "Active is the endpoint the last request went to",
+                    "type": "boolean"
                 },
-                "rank_attack": {
-                    "type": "integer"
+                "address": {
+                    "type": "string"
                 },
-                "rank_craftspeed": {
-                    "type": "integer"
+                "down_until": {
+                    "description": "DownUntil is when an unreachable endpoint is tried again first",
+                    "type": "string"
                 },
-                "rank_defence": {
-                    "type": "integer"
+                "healthy": {
+                    "type": "boolean"
                 },
-                "skills": {
-                    "type": "array",
-                    "items": {
-                        "type": "string"
-                    }
+                "kind": {
+                    "description": "Kind is rcon or rest",
+                    "type": "string"
                 },
-                "type": {
+                "last_error": {
                     "type": "string"
                 },
-                "workspeed": {
-                    "type": "integer"
+                "primary": {
+                    "type": "boolean"
                 }
             }
         },
-        "database.Player": {
+        "tool.IpReputation": {
             "type": "object",
             "properties": {
-                "exp": {
-                    "type": "integer"
-                },
-                "full_stomach": {
-                    "type": "number"
+                "checked_at": {
+                    "type": "string"
                 },
-                "hp": {
-                    "type": "integer"
+                "flagged": {
+                    "type": "boolean"
                 },
                 "ip": {
                     "type": "string"
                 },
-                "items": {
-                    "$ref": "#/definitions/database.Items"
-                },
-                "last_online": {
+                "source": {
+                    "description": "Source is the list file or \"provider\" that flagged the ip",
                     "type": "string"
-                },
-                "level": {
-                    "type": "integer"
-                },
-                "location_x": {
+                }
+            }
+        },
+        "tool.Location": {
+            "type": "object",
+            "properties": {
+                "distance_from_spawn": {
+                    "description": "DistanceFromSpawn is the straight line distance to map.spawn in Unit",
                     "type": "number"
                 },
-                "location_y": {
+                "lat": {
+                    "description": "Lat and Lng place the position on the tiles of /api/map",
                     "type": "number"
                 },
-                "max_hp": {
-                    "type": "integer"
-                },
-                "max_status_point": {
-                    "type": "integer"
-                },
-                "nickname": {
-                    "type": "string"
-                },
-                "pals": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/database.Pal"
-                    }
+                "lng": {
+                    "type": "number"
                 },
-                "ping": {
+                "map_x": {
+                    "description": "MapX and MapY are the coordinates the in-game map shows",
                     "type": "number"
                 },
-                "player_uid": {
-                    "type": "string"
+                "map_y": {
+                    "type": "number"
                 },
-                "save_last_online": {
+                "region": {
+                    "description": "Region is the name of the first of map.regions holding the position",
                     "type": "string"
                 },
-                "shield_hp": {
-                    "type": "integer"
-                },
-                "shield_max_hp": {
-                    "type": "integer"
-                },
-                "status_point": {
-                    "type": "object",
-                    "additionalProperties": {
-                        "type": "integer"
-                    }
-                },
-                "steam_id": {
+                "unit": {
                     "type": "string"
                 }
             }
         },
-        "database.PlayerW": {
+        "tool.MapInfo": {
             "type": "object",
             "properties": {
-                "name": {
-                    "type": "string"
+                "max_zoom": {
+                    "type": "integer"
                 },
-                "player_uid": {
+                "tile_url": {
                     "type": "string"
                 },
-                "steam_id": {
+                "version": {
                     "type": "string"
                 }
             }
         },
-        "database.RconCommand": {
+        "tool.MapPosition": {
             "type": "object",
             "properties": {
-                "command": {
-                    "type": "string"
+                "lat": {
+                    "type": "number"
                 },
-                "placeholder": {
-                    "type": "string"
+                "lng": {
+                    "type": "number"
                 },
-                "remark": {
-                    "type": "string"
+                "location": {
+                    "description": "Location is added by /api/map/convert when asked",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/tool.Location"
+                        }
+                    ]
+                },
+                "pixel_x": {
+                    "type": "number"
+                },
+                "pixel_y": {
+                    "type": "number"
+                },
+                "world_x": {
+                    "type": "number"
+                },
+                "world_y": {
+                    "type": "number"
+                },
+                "zoom": {
+                    "type": "integer"
                 }
             }
         },
-        "database.RconCommandList": {
+        "tool.Mod": {
             "type": "object",
             "properties": {
-                "command": {
-                    "type": "string"
+                "config_files": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 },
-                "placeholder": {
+                "enabled": {
+                    "type": "boolean"
+                },
+                "name": {
                     "type": "string"
                 },
-                "remark": {
+                "path": {
+                    "description": "Path is relative to mods.server_dir",
                     "type": "string"
                 },
-                "uuid": {
+                "type": {
                     "type": "string"
                 }
             }
         },
-        "database.TersePlayer": {
+        "tool.RequestPolicy": {
             "type": "object",
             "properties": {
-                "exp": {
-                    "type": "integer"
-                },
-                "full_stomach": {
+                "backoff": {
+                    "description": "Backoff is the seconds before the first retry, doubled for each next",
                     "type": "number"
                 },
-                "hp": {
+                "retries": {
                     "type": "integer"
                 },
-                "ip": {
-                    "type": "string"
-                },
-                "last_online": {
+                "timeout": {
+                    "description": "Timeout is in seconds, 0 keeps the one of the endpoint",
+                    "type": "integer"
+                }
+            }
+        },
+        "tool.RequestStats": {
+            "type": "object",
+            "properties": {
+                "class": {
                     "type": "string"
                 },
-                "level": {
+                "failures": {
                     "type": "integer"
                 },
-                "location_x": {
-                    "type": "number"
-                },
-                "location_y": {
-                    "type": "number"
+                "policy": {
+                    "$ref": "#/definitions/tool.RequestPolicy"
                 },
-                "max_hp": {
+                "requests": {
                     "type": "integer"
                 },
-                "max_status_point": {
+                "retries": {
+                    "description": "Retries are the requests sent again after a failure",
                     "type": "integer"
-                },
-                "nickname": {
+                }
+            }
+        },
+        "tool.SettingOption": {
+            "type": "object",
+            "properties": {
+                "key": {
                     "type": "string"
                 },
-                "ping": {
-                    "type": "number"
-                },
-                "player_uid": {
+                "value": {
                     "type": "string"
-                },
-                "save_last_online": {
+                }
+            }
+        },
+        "validate.FieldError": {
+            "type": "object",
+            "properties": {
+                "field": {
                     "type": "string"
                 },
-                "shield_hp": {
-                    "type": "integer"
-                },
-                "shield_max_hp": {
-                    "type": "integer"
-                },
-                "status_point": {
-                    "type": "object",
-                    "additionalProperties": {
-                        "type": "integer"
-                    }
-                },
-                "steam_id": {
+                "message": {
                     "type": "string"
                 }
             }
@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
@@ -8,57 +9,153 @@ import (
 
 	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/spf13/viper"
+	"github.com/zaigie/palworld-server-tool/internal/cache"
 )
 
-var SecretKey = []byte(viper.GetString("web.password"))
+// ErrNoSecret refuses to sign or accept tokens while web.password, their
+// key, is empty, anyone could sign one otherwise.
+var ErrNoSecret = errors.New("web.password is empty, tokens can't be signed")
+
+// secretKey is the key tokens are signed with. It's read when used, the
+// config is loaded after the package.
+func secretKey() ([]byte, error) {
+	key := viper.GetString("web.password")
+	if key == "" {
+		return nil, ErrNoSecret
+	}
+	return []byte(key), nil
+}
+
+// RolePlayer is the role of the tokens of players signed in with Steam,
+// which only open the routes of the player. Admin tokens have no role.
+const RolePlayer = "player"
 
 func JWTAuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
-		prefixBearer := "Bearer "
-		prefixJWT := "JWT "
-
-		var tokenString string
-		if strings.HasPrefix(authHeader, prefixBearer) {
-			tokenString = strings.TrimPrefix(authHeader, prefixBearer)
-		} else if strings.HasPrefix(authHeader, prefixJWT) {
-			tokenString = strings.TrimPrefix(authHeader, prefixJWT)
-		} else {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized - token missing"})
-			return
+		if Authenticate(c) {
+			c.Next()
 		}
+	}
+}
 
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-			}
-			return SecretKey, nil
-		})
-
-		if err != nil {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized - invalid token"})
+// PlayerAuthMiddleware lets through the requests with the token of a player
+// signed in with Steam.
+func PlayerAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := parseToken(c)
+		if !ok {
 			return
 		}
-
-		if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-			c.Set("claims", claims)
-		} else {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized - invalid claims"})
+		if role, _ := claims["role"].(string); role != RolePlayer {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "forbidden - not a player token"})
 			return
 		}
-
 		c.Next()
 	}
 }
 
+// Authenticate checks the token of the request like JWTAuthMiddleware, for
+// anonymous routes with options only admins may use. It aborts the request
+// and returns false when the token is missing or invalid.
+func Authenticate(c *gin.Context) bool {
+	claims, ok := parseToken(c)
+	if !ok {
+		return false
+	}
+	if role, _ := claims["role"].(string); role != "" {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "forbidden - not an admin token"})
+		return false
+	}
+	return true
+}
+
+// parseToken checks the token of the request and sets its claims. It aborts
+// the request and returns false when the token is missing or invalid.
+func parseToken(c *gin.Context) (jwt.MapClaims, bool) {
+	authHeader := c.GetHeader("Authorization")
+	prefixBearer := "Bearer "
+	prefixJWT := "JWT "
+
+	var tokenString string
+	if strings.HasPrefix(authHeader, prefixBearer) {
+		tokenString = strings.TrimPrefix(authHeader, prefixBearer)
+	} else if strings.HasPrefix(authHeader, prefixJWT) {
+		tokenString = strings.TrimPrefix(authHeader, prefixJWT)
+	} else {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized - token missing"})
+		return nil, false
+	}
+
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return secretKey()
+	})
+
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized - invalid token"})
+		return nil, false
+	}
+
+	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
+		if jti, _ := claims["jti"].(string); jti != "" {
+			if _, err := cache.Default().Get("revoked:" + jti); err == nil {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized - token revoked"})
+				return nil, false
+			}
+		}
+		c.Set("claims", claims)
+		return claims, true
+	}
+	c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized - invalid claims"})
+	return nil, false
+}
+
 func GenerateToken() (string, error) {
+	key, err := secretKey()
+	if err != nil {
+		return "", err
+	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
 		"exp": time.Now().Add(time.Hour * 24).Unix(),
+		"jti": uuid.New().String(),
 	})
-	tokenString, err := token.SignedString(SecretKey)
+	tokenString, err := token.SignedString(key)
 	if err != nil {
 		return "", err
 	}
 	return tokenString, nil
 }
+
+// GeneratePlayerToken signs in the player of steamId for ttl.
+func GeneratePlayerToken(steamId string, ttl time.Duration) (string, error) {
+	key, err := secretKey()
+	if err != nil {
+		return "", err
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"exp":      time.Now().Add(ttl).Unix(),
+		"jti":      uuid.New().String(),
+		"role":     RolePlayer,
+		"steam_id": steamId,
+	})
+	return token.SignedString(key)
+}
+
+// RevokeToken rejects the token of claims until it expires. Revoked ids are
+// kept in the shared cache so every replica rejects it.
+func RevokeToken(claims jwt.MapClaims) error {
+	jti, _ := claims["jti"].(string)
+	if jti == "" {
+		return fmt.Errorf("token has no id")
+	}
+	exp, _ := claims["exp"].(float64)
+	ttl := time.Until(time.Unix(int64(exp), 0))
+	if ttl <= 0 {
+		return nil
+	}
+	return cache.Default().Set("revoked:"+jti, []byte("1"), ttl)
+}
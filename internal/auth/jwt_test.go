@@ -0,0 +1,99 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+)
+
+// request runs a request with token through a router guarded by middleware
+// and returns the status.
+func request(middleware gin.HandlerFunc, token string) int {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/", middleware, func(c *gin.Context) { c.Status(http.StatusOK) })
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w.Code
+}
+
+func signed(key string, claims jwt.MapClaims) string {
+	token, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(key))
+	return token
+}
+
+func TestTokensNeedPassword(t *testing.T) {
+	viper.Set("web.password", "")
+	defer viper.Set("web.password", nil)
+	if _, err := GenerateToken(); err != ErrNoSecret {
+		t.Errorf("GenerateToken with no password: %v, want ErrNoSecret", err)
+	}
+	if _, err := GeneratePlayerToken("76561198000000001", time.Hour); err != ErrNoSecret {
+		t.Errorf("GeneratePlayerToken with no password: %v, want ErrNoSecret", err)
+	}
+	forged := signed("", jwt.MapClaims{"exp": time.Now().Add(time.Hour).Unix()})
+	if code := request(JWTAuthMiddleware(), forged); code != http.StatusUnauthorized {
+		t.Errorf("token signed with an empty key: status %d, want 401", code)
+	}
+}
+
+func TestRoles(t *testing.T) {
+	viper.Set("web.password", "pw")
+	defer viper.Set("web.password", nil)
+	admin, err := GenerateToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	player, err := GeneratePlayerToken("76561198000000001", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := time.Now().Add(time.Hour).Unix()
+	tests := []struct {
+		name   string
+		token  string
+		admin  int
+		player int
+	}{
+		{"admin", admin, http.StatusOK, http.StatusForbidden},
+		{"player", player, http.StatusForbidden, http.StatusOK},
+		{"missing", "", http.StatusUnauthorized, http.StatusUnauthorized},
+		{"other key", signed("other", jwt.MapClaims{"exp": exp}), http.StatusUnauthorized, http.StatusUnauthorized},
+		{"empty key", signed("", jwt.MapClaims{"exp": exp}), http.StatusUnauthorized, http.StatusUnauthorized},
+		{"expired", signed("pw", jwt.MapClaims{"exp": time.Now().Add(-time.Minute).Unix()}), http.StatusUnauthorized, http.StatusUnauthorized},
+		{"unknown role", signed("pw", jwt.MapClaims{"exp": exp, "role": "owner"}), http.StatusForbidden, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		if code := request(JWTAuthMiddleware(), tt.token); code != tt.admin {
+			t.Errorf("%s on admin route: status %d, want %d", tt.name, code, tt.admin)
+		}
+		if code := request(PlayerAuthMiddleware(), tt.token); code != tt.player {
+			t.Errorf("%s on player route: status %d, want %d", tt.name, code, tt.player)
+		}
+	}
+}
+
+func TestRevokeToken(t *testing.T) {
+	viper.Set("web.password", "pw")
+	defer viper.Set("web.password", nil)
+	token, err := GenerateToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, _ := jwt.Parse(token, func(*jwt.Token) (interface{}, error) { return []byte("pw"), nil })
+	if err := RevokeToken(parsed.Claims.(jwt.MapClaims)); err != nil {
+		t.Fatal(err)
+	}
+	if code := request(JWTAuthMiddleware(), token); code != http.StatusUnauthorized {
+		t.Errorf("revoked token: status %d, want 401", code)
+	}
+}
@@ -0,0 +1,121 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/zaigie/palworld-server-tool/internal/cache"
+)
+
+// steamOpenIdUrl is the OpenID 2.0 provider of Steam.
+const steamOpenIdUrl = "https://steamcommunity.com/openid/login"
+
+var steamClient = &http.Client{Timeout: 10 * time.Second}
+
+var steamIdPattern = regexp.MustCompile(`^https://steamcommunity\.com/openid/id/(\d{17})$`)
+
+// steamNonceAge is how long after Steam made it a login response is taken.
+const steamNonceAge = 5 * time.Minute
+
+// SteamLoginUrl is the Steam page a player signs in on, which sends them
+// back to returnTo on the site of realm.
+func SteamLoginUrl(realm, returnTo string) string {
+	v := url.Values{}
+	v.Set("openid.ns", "http://specs.openid.net/auth/2.0")
+	v.Set("openid.mode", "checkid_setup")
+	v.Set("openid.return_to", returnTo)
+	v.Set("openid.realm", realm)
+	v.Set("openid.identity", "http://specs.openid.net/auth/2.0/identifier_select")
+	v.Set("openid.claimed_id", "http://specs.openid.net/auth/2.0/identifier_select")
+	return steamOpenIdUrl + "?" + v.Encode()
+}
+
+// VerifySteamLogin has Steam check the signature of the assertion it sent
+// back to returnTo and returns the SteamId64 of the player signed in. A
+// response is taken once, within steamNonceAge.
+func VerifySteamLogin(query url.Values, returnTo string) (string, error) {
+	switch query.Get("openid.mode") {
+	case "id_res":
+	case "cancel":
+		return "", errors.New("steam login was cancelled")
+	default:
+		return "", errors.New("not a steam login response")
+	}
+	if query.Get("openid.op_endpoint") != steamOpenIdUrl {
+		return "", errors.New("steam login response from another provider")
+	}
+	if query.Get("openid.return_to") != returnTo {
+		return "", errors.New("steam login response for another site")
+	}
+	claimedId := query.Get("openid.claimed_id")
+	m := steamIdPattern.FindStringSubmatch(claimedId)
+	if m == nil || query.Get("openid.identity") != claimedId {
+		return "", errors.New("steam login response without a steam id")
+	}
+	// the fields checked above only count when they are signed
+	signed := make(map[string]bool)
+	for _, field := range strings.Split(query.Get("openid.signed"), ",") {
+		signed[field] = true
+	}
+	for _, field := range []string{"op_endpoint", "claimed_id", "identity", "return_to", "response_nonce"} {
+		if !signed[field] {
+			return "", fmt.Errorf("steam login response with %s unsigned", field)
+		}
+	}
+
+	nonce := query.Get("openid.response_nonce")
+	// the nonce starts with the time Steam made it, in UTC
+	if len(nonce) < 20 {
+		return "", errors.New("steam login response without a nonce")
+	}
+	made, err := time.Parse("2006-01-02T15:04:05Z", nonce[:20])
+	if err != nil {
+		return "", errors.New("steam login response without a nonce")
+	}
+	if age := time.Since(made); age > steamNonceAge || age < -time.Minute {
+		return "", errors.New("steam login response expired")
+	}
+
+	check := url.Values{}
+	for key, values := range query {
+		if strings.HasPrefix(key, "openid.") {
+			check[key] = values
+		}
+	}
+	check.Set("openid.mode", "check_authentication")
+	resp, err := steamClient.PostForm(steamOpenIdUrl, check)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("steam: %d %s", resp.StatusCode, body)
+	}
+	valid := false
+	for _, line := range strings.Split(string(body), "\n") {
+		valid = valid || strings.TrimSpace(line) == "is_valid:true"
+	}
+	if !valid {
+		return "", errors.New("steam refused the login response")
+	}
+	// counted so two requests with the same response can't both pass,
+	// kept past the age a nonce is taken within
+	used, err := cache.Default().Incr("steam_nonce:"+nonce, 2*steamNonceAge)
+	if err != nil {
+		return "", err
+	}
+	if used > 1 {
+		return "", errors.New("steam login response was used already")
+	}
+	return m[1], nil
+}
@@ -0,0 +1,126 @@
+package auth
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+const testReturnTo = "https://pst.example.com/api/auth/steam/callback"
+
+// steamResponse is a login response for steamId as Steam sends it back,
+// with a fresh nonce unique to the test.
+func steamResponse(steamId, nonce string) url.Values {
+	claimed := "https://steamcommunity.com/openid/id/" + steamId
+	return url.Values{
+		"openid.ns":             {"http://specs.openid.net/auth/2.0"},
+		"openid.mode":           {"id_res"},
+		"openid.op_endpoint":    {steamOpenIdUrl},
+		"openid.claimed_id":     {claimed},
+		"openid.identity":       {claimed},
+		"openid.return_to":      {testReturnTo},
+		"openid.response_nonce": {time.Now().UTC().Format("2006-01-02T15:04:05Z") + nonce},
+		"openid.assoc_handle":   {"1234567890"},
+		"openid.signed":         {"signed,op_endpoint,claimed_id,identity,return_to,response_nonce,assoc_handle"},
+		"openid.sig":            {"c2lnbmF0dXJl"},
+	}
+}
+
+// fakeSteam answers check_authentication with valid, counting the checks.
+func fakeSteam(t *testing.T, valid bool) *int {
+	checks := 0
+	old := steamClient
+	steamClient = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		checks++
+		if err := req.ParseForm(); err != nil {
+			t.Fatal(err)
+		}
+		if req.URL.String() != steamOpenIdUrl || req.PostForm.Get("openid.mode") != "check_authentication" {
+			t.Errorf("checked at %s with mode %s", req.URL, req.PostForm.Get("openid.mode"))
+		}
+		body := "ns:http://specs.openid.net/auth/2.0\nis_valid:false\n"
+		if valid {
+			body = "ns:http://specs.openid.net/auth/2.0\nis_valid:true\n"
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body))}, nil
+	})}
+	t.Cleanup(func() { steamClient = old })
+	return &checks
+}
+
+func TestVerifySteamLogin(t *testing.T) {
+	checks := fakeSteam(t, true)
+	steamId, err := VerifySteamLogin(steamResponse("76561198000000001", "a1"), testReturnTo)
+	if err != nil || steamId != "76561198000000001" {
+		t.Fatalf("VerifySteamLogin = %q, %v", steamId, err)
+	}
+	if *checks != 1 {
+		t.Errorf("steam checked %d times, want 1", *checks)
+	}
+
+	tests := []struct {
+		name   string
+		change func(q url.Values)
+	}{
+		{"cancel", func(q url.Values) { q.Set("openid.mode", "cancel") }},
+		{"other provider", func(q url.Values) { q.Set("openid.op_endpoint", "https://evil.example.com/openid/login") }},
+		{"other site", func(q url.Values) { q.Set("openid.return_to", "https://evil.example.com/callback") }},
+		{"identity differs", func(q url.Values) {
+			q.Set("openid.identity", "https://steamcommunity.com/openid/id/76561198000000002")
+		}},
+		{"not a steam id", func(q url.Values) {
+			q.Set("openid.claimed_id", "https://evil.example.com/openid/id/76561198000000001")
+			q.Set("openid.identity", q.Get("openid.claimed_id"))
+		}},
+		{"claimed id unsigned", func(q url.Values) {
+			q.Set("openid.signed", "signed,op_endpoint,identity,return_to,response_nonce")
+		}},
+		{"return to unsigned", func(q url.Values) {
+			q.Set("openid.signed", "signed,op_endpoint,claimed_id,identity,response_nonce")
+		}},
+		{"nonce unsigned", func(q url.Values) {
+			q.Set("openid.signed", "signed,op_endpoint,claimed_id,identity,return_to")
+		}},
+		{"no nonce", func(q url.Values) { q.Del("openid.response_nonce") }},
+		{"bad nonce", func(q url.Values) { q.Set("openid.response_nonce", "not a time at all, no") }},
+		{"old nonce", func(q url.Values) {
+			q.Set("openid.response_nonce", time.Now().UTC().Add(-time.Hour).Format("2006-01-02T15:04:05Z")+"b1")
+		}},
+	}
+	for _, tt := range tests {
+		*checks = 0
+		q := steamResponse("76561198000000001", "c-"+tt.name)
+		tt.change(q)
+		if steamId, err := VerifySteamLogin(q, testReturnTo); err == nil {
+			t.Errorf("%s: accepted as %s", tt.name, steamId)
+		}
+		if *checks != 0 {
+			t.Errorf("%s: checked with steam before refusing", tt.name)
+		}
+	}
+}
+
+func TestVerifySteamLoginRefusedBySteam(t *testing.T) {
+	fakeSteam(t, false)
+	if steamId, err := VerifySteamLogin(steamResponse("76561198000000001", "d1"), testReturnTo); err == nil {
+		t.Errorf("signature steam refused accepted as %s", steamId)
+	}
+}
+
+func TestVerifySteamLoginNonceOnce(t *testing.T) {
+	fakeSteam(t, true)
+	q := steamResponse("76561198000000001", "e1")
+	if _, err := VerifySteamLogin(q, testReturnTo); err != nil {
+		t.Fatal(err)
+	}
+	if steamId, err := VerifySteamLogin(q, testReturnTo); err == nil {
+		t.Errorf("replayed response accepted as %s", steamId)
+	}
+}
@@ -0,0 +1,157 @@
+// Package bot answers chat commands from QQ groups through a OneBot v11
+// implementation (go-cqhttp, NapCat, Lagrange...) posting its events here.
+package bot
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/zaigie/palworld-server-tool/internal/locale"
+	"github.com/zaigie/palworld-server-tool/internal/logger"
+	"go.etcd.io/bbolt"
+)
+
+type Level int
+
+const (
+	LevelNone Level = iota
+	LevelUser
+	LevelAdmin
+)
+
+func parseLevel(s string) Level {
+	switch strings.ToLower(s) {
+	case "admin":
+		return LevelAdmin
+	case "none":
+		return LevelNone
+	default:
+		return LevelUser
+	}
+}
+
+type Group struct {
+	GroupId int64 `mapstructure:"group_id"`
+	// Level is what everyone in the group may run, "none", "user" or "admin"
+	Level  string  `mapstructure:"level"`
+	Admins []int64 `mapstructure:"admins"`
+}
+
+// Message is the part of a OneBot v11 message event the bot needs.
+type Message struct {
+	MessageType string `json:"message_type"`
+	GroupId     int64  `json:"group_id"`
+	UserId      int64  `json:"user_id"`
+	RawMessage  string `json:"raw_message"`
+}
+
+var cqCode = regexp.MustCompile(`\[CQ:[^\]]*\]`)
+
+// Handle runs the command in msg and returns the reply, which is empty when
+// msg isn't a command or its sender isn't allowed to run anything.
+func Handle(db *bbolt.DB, msg Message) string {
+	prefix := viper.GetString("bot.prefix")
+	text := strings.TrimSpace(html.UnescapeString(cqCode.ReplaceAllString(msg.RawMessage, "")))
+	if !strings.HasPrefix(text, prefix) {
+		return ""
+	}
+	fields := strings.Fields(strings.TrimPrefix(text, prefix))
+	if len(fields) == 0 {
+		return ""
+	}
+	level := permission(msg)
+	if level == LevelNone {
+		return ""
+	}
+	cmd, ok := commands[strings.ToLower(fields[0])]
+	if !ok {
+		return ""
+	}
+	if level < cmd.level {
+		return locale.T(locale.Bot, "bot.permission_denied")
+	}
+	if !limiter.allow(msg.UserId, viper.GetInt("bot.rate_limit")) {
+		return locale.T(locale.Bot, "bot.rate_limited")
+	}
+
+	logger.Infof("Bot command from %d in %d: %s\n", msg.UserId, msg.GroupId, text)
+	reply, err := cmd.run(db, fields[1:], level)
+	if err != nil {
+		return locale.T(locale.Bot, "bot.error", "error", err)
+	}
+	return reply
+}
+
+// permission gives global admins admin everywhere, group members the level of
+// their group and everyone else nothing.
+func permission(msg Message) Level {
+	for _, admin := range viper.GetIntSlice("bot.admins") {
+		if int64(admin) == msg.UserId {
+			return LevelAdmin
+		}
+	}
+	if msg.MessageType != "group" {
+		return LevelNone
+	}
+	var groups []Group
+	if err := viper.UnmarshalKey("bot.groups", &groups); err != nil {
+		logger.Errorf("Parse bot.groups fail, %v\n", err)
+		return LevelNone
+	}
+	for _, group := range groups {
+		if group.GroupId != msg.GroupId {
+			continue
+		}
+		for _, admin := range group.Admins {
+			if admin == msg.UserId {
+				return LevelAdmin
+			}
+		}
+		return parseLevel(group.Level)
+	}
+	return LevelNone
+}
+
+// rateLimiter allows each user a number of commands per minute.
+type rateLimiter struct {
+	mu    sync.Mutex
+	calls map[int64][]time.Time
+	// swept is when the users without a call in the last minute were last
+	// dropped
+	swept time.Time
+}
+
+var limiter = &rateLimiter{calls: make(map[int64][]time.Time)}
+
+func (l *rateLimiter) allow(userId int64, perMinute int) bool {
+	if perMinute <= 0 {
+		return true
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	if now.Sub(l.swept) >= time.Minute {
+		for user, calls := range l.calls {
+			if len(calls) == 0 || now.Sub(calls[len(calls)-1]) >= time.Minute {
+				delete(l.calls, user)
+			}
+		}
+		l.swept = now
+	}
+	calls := l.calls[userId][:0]
+	for _, t := range l.calls[userId] {
+		if now.Sub(t) < time.Minute {
+			calls = append(calls, t)
+		}
+	}
+	if len(calls) >= perMinute {
+		l.calls[userId] = calls
+		return false
+	}
+	l.calls[userId] = append(calls, now)
+	return true
+}
@@ -0,0 +1,77 @@
+package bot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+func TestPermission(t *testing.T) {
+	viper.Set("bot.admins", []int{42})
+	viper.Set("bot.groups", []map[string]any{
+		{"group_id": 100, "level": "user", "admins": []int64{7}},
+		{"group_id": 200, "level": "none"},
+	})
+	defer viper.Set("bot.admins", nil)
+	defer viper.Set("bot.groups", nil)
+	tests := []struct {
+		name string
+		msg  Message
+		want Level
+	}{
+		{"global admin private", Message{MessageType: "private", UserId: 42}, LevelAdmin},
+		{"global admin in group", Message{MessageType: "group", GroupId: 300, UserId: 42}, LevelAdmin},
+		{"stranger private", Message{MessageType: "private", UserId: 1}, LevelNone},
+		{"group admin", Message{MessageType: "group", GroupId: 100, UserId: 7}, LevelAdmin},
+		{"group admin elsewhere", Message{MessageType: "private", UserId: 7}, LevelNone},
+		{"group member", Message{MessageType: "group", GroupId: 100, UserId: 1}, LevelUser},
+		{"muted group", Message{MessageType: "group", GroupId: 200, UserId: 1}, LevelNone},
+		{"unknown group", Message{MessageType: "group", GroupId: 300, UserId: 1}, LevelNone},
+	}
+	for _, tt := range tests {
+		if got := permission(tt.msg); got != tt.want {
+			t.Errorf("%s: permission = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestHandleIgnoresNonCommands(t *testing.T) {
+	viper.Set("bot.prefix", "/")
+	defer viper.Set("bot.prefix", nil)
+	for _, text := range []string{"hello", "", "/", "[CQ:at,qq=1] hi"} {
+		if reply := Handle(nil, Message{MessageType: "private", UserId: 1, RawMessage: text}); reply != "" {
+			t.Errorf("Handle(%q) = %q, want no reply", text, reply)
+		}
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	l := &rateLimiter{calls: make(map[int64][]time.Time)}
+	for i := 0; i < 3; i++ {
+		if !l.allow(1, 3) {
+			t.Fatalf("call %d refused within the limit", i+1)
+		}
+	}
+	if l.allow(1, 3) {
+		t.Error("call over the limit allowed")
+	}
+	if !l.allow(2, 3) {
+		t.Error("other user limited")
+	}
+
+	// users idle for a minute are dropped on the next sweep
+	old := time.Now().Add(-2 * time.Minute)
+	l.calls[3] = []time.Time{old}
+	l.calls[4] = nil
+	l.swept = old
+	l.allow(2, 3)
+	for _, user := range []int64{3, 4} {
+		if _, ok := l.calls[user]; ok {
+			t.Errorf("idle user %d kept", user)
+		}
+	}
+	if _, ok := l.calls[1]; !ok {
+		t.Error("active user dropped")
+	}
+}
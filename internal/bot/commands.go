@@ -0,0 +1,349 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/viper"
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/internal/locale"
+	"github.com/zaigie/palworld-server-tool/internal/logger"
+	"github.com/zaigie/palworld-server-tool/internal/task"
+	"github.com/zaigie/palworld-server-tool/internal/tool"
+	"github.com/zaigie/palworld-server-tool/internal/validate"
+	"github.com/zaigie/palworld-server-tool/service"
+	"go.etcd.io/bbolt"
+)
+
+type command struct {
+	level Level
+	usage string
+	run   func(db *bbolt.DB, args []string, level Level) (string, error)
+}
+
+var commands map[string]command
+
+func init() {
+	commands = map[string]command{
+		"help":        {LevelUser, "help", helpCommand},
+		"online":      {LevelUser, "online", onlineCommand},
+		"player":      {LevelUser, "player <name|uid>", playerCommand},
+		"points":      {LevelUser, "points [give|take <amount>] <name|uid>", pointsCommand},
+		"whitelist":   {LevelAdmin, "whitelist list|add|remove <name|uid|steam_id>", whitelistCommand},
+		"application": {LevelAdmin, "application list|approve|deny <id> [reason]", applicationCommand},
+		"backup":      {LevelAdmin, "backup", backupCommand},
+		"kick":        {LevelAdmin, "kick <name|uid>", kickCommand},
+		"ban":         {LevelAdmin, "ban <name|uid>", banCommand},
+		"unban":       {LevelAdmin, "unban <name|uid>", unbanCommand},
+		"broadcast":   {LevelAdmin, "broadcast <message>", broadcastCommand},
+		"macro":       {LevelAdmin, "macro <name> [key=value...]", macroCommand},
+	}
+}
+
+func helpCommand(_ *bbolt.DB, _ []string, level Level) (string, error) {
+	prefix := viper.GetString("bot.prefix")
+	usages := make([]string, 0, len(commands))
+	for _, cmd := range commands {
+		if level >= cmd.level {
+			usages = append(usages, prefix+cmd.usage)
+		}
+	}
+	sort.Strings(usages)
+	return strings.Join(usages, "\n"), nil
+}
+
+func onlineCommand(_ *bbolt.DB, _ []string, _ Level) (string, error) {
+	players, err := tool.ShowPlayers(context.Background())
+	if err != nil {
+		return "", err
+	}
+	if len(players) == 0 {
+		return locale.T(locale.Bot, "bot.no_players"), nil
+	}
+	lines := []string{locale.T(locale.Bot, "bot.players_online", "count", len(players))}
+	for _, p := range players {
+		lines = append(lines, fmt.Sprintf("%s Lv.%d", p.Nickname, p.Level))
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// findPlayer looks the player up by uid, then by nickname ignoring case.
+func findPlayer(db *bbolt.DB, query string) (database.TersePlayer, error) {
+	if player, err := service.GetPlayer(db, query); err == nil {
+		return player.TersePlayer, nil
+	} else if err != service.ErrNoRecord {
+		return database.TersePlayer{}, err
+	}
+	players, err := service.ListPlayers(db)
+	if err != nil {
+		return database.TersePlayer{}, err
+	}
+	var matched []database.TersePlayer
+	for _, p := range players {
+		if strings.EqualFold(p.Nickname, query) {
+			matched = append(matched, p)
+		}
+	}
+	switch len(matched) {
+	case 0:
+		return database.TersePlayer{}, errors.New(locale.T(locale.Bot, "bot.player_not_found"))
+	case 1:
+		return matched[0], nil
+	default:
+		return database.TersePlayer{}, errors.New(locale.T(locale.Bot, "bot.player_ambiguous", "count", len(matched), "name", query))
+	}
+}
+
+func playerCommand(db *bbolt.DB, args []string, _ Level) (string, error) {
+	if len(args) == 0 {
+		return "", errors.New(locale.T(locale.Bot, "bot.name_required"))
+	}
+	p, err := findPlayer(db, strings.Join(args, " "))
+	if err != nil {
+		return "", err
+	}
+	lastOnline := "-"
+	if !p.LastOnline.IsZero() {
+		lastOnline = p.LastOnline.Local().Format("2006-01-02 15:04")
+	}
+	return strings.Join([]string{
+		fmt.Sprintf("%s Lv.%d", p.Nickname, p.Level),
+		fmt.Sprintf("UID: %s", p.PlayerUid),
+		fmt.Sprintf("SteamID: %s", p.SteamId),
+		fmt.Sprintf("HP: %d/%d", p.Hp/1000, p.MaxHp/1000),
+		locale.T(locale.Bot, "bot.last_online", "time", lastOnline),
+	}, "\n"), nil
+}
+
+func pointsCommand(db *bbolt.DB, args []string, level Level) (string, error) {
+	if len(args) == 0 {
+		return "", errors.New(locale.T(locale.Bot, "bot.name_required"))
+	}
+	var amount int64
+	if args[0] == "give" || args[0] == "take" {
+		if level < LevelAdmin {
+			return "", errors.New(locale.T(locale.Bot, "bot.points_admin_only"))
+		}
+		if len(args) < 3 {
+			return "", errors.New(locale.T(locale.Bot, "bot.points_usage"))
+		}
+		n, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil || n <= 0 {
+			return "", errors.New(locale.T(locale.Bot, "bot.points_amount"))
+		}
+		amount = n
+		if args[0] == "take" {
+			amount = -n
+		}
+		args = args[2:]
+	}
+	p, err := findPlayer(db, strings.Join(args, " "))
+	if err != nil {
+		return "", err
+	}
+	if amount != 0 {
+		account, err := service.AdjustPoints(db, p.PlayerUid, amount, "bot")
+		if err != nil {
+			return "", err
+		}
+		return locale.T(locale.Bot, "bot.points_now", "username", p.Nickname, "points", account.Balance), nil
+	}
+	account, err := service.GetPoints(db, p.PlayerUid)
+	if err != nil && err != service.ErrNoRecord {
+		return "", err
+	}
+	return locale.T(locale.Bot, "bot.points", "username", p.Nickname, "points", account.Balance), nil
+}
+
+func whitelistCommand(db *bbolt.DB, args []string, _ Level) (string, error) {
+	if len(args) == 0 {
+		return "", errors.New(locale.T(locale.Bot, "bot.whitelist_usage"))
+	}
+	switch args[0] {
+	case "list":
+		players, err := service.ListWhitelist(db)
+		if err != nil {
+			return "", err
+		}
+		if len(players) == 0 {
+			return locale.T(locale.Bot, "bot.whitelist_empty"), nil
+		}
+		lines := make([]string, 0, len(players))
+		for _, p := range players {
+			lines = append(lines, fmt.Sprintf("%s %s %s", p.Name, p.SteamID, p.PlayerUID))
+		}
+		return strings.Join(lines, "\n"), nil
+	case "add", "remove":
+		if len(args) < 2 {
+			return "", errors.New(locale.T(locale.Bot, "bot.whitelist_query"))
+		}
+		query := strings.Join(args[1:], " ")
+		var entry database.PlayerW
+		if p, err := findPlayer(db, query); err == nil {
+			entry = database.PlayerW{Name: p.Nickname, SteamID: p.SteamId, PlayerUID: p.PlayerUid}
+		} else if validate.IsSteamId(query) {
+			entry = database.PlayerW{SteamID: query}
+		} else {
+			return "", err
+		}
+		if args[0] == "add" {
+			if err := service.AddWhitelist(db, entry); err != nil {
+				return "", err
+			}
+			return locale.T(locale.Bot, "bot.whitelist_added", "name", describe(entry)), nil
+		}
+		if err := service.RemoveWhitelist(db, entry, service.Removal{By: "bot"}); err != nil {
+			return "", err
+		}
+		return locale.T(locale.Bot, "bot.whitelist_removed", "name", describe(entry)), nil
+	default:
+		return "", errors.New(locale.T(locale.Bot, "bot.whitelist_usage"))
+	}
+}
+
+// applicationCommand lists the pending whitelist applications or decides
+// one, the reason is told to the applicant.
+func applicationCommand(db *bbolt.DB, args []string, _ Level) (string, error) {
+	if len(args) == 0 {
+		return "", errors.New(locale.T(locale.Bot, "bot.application_usage"))
+	}
+	switch args[0] {
+	case "list":
+		apps, err := service.ListWhitelistApplications(db, service.ApplicationPending, 0)
+		if err != nil {
+			return "", err
+		}
+		if len(apps) == 0 {
+			return locale.T(locale.Bot, "bot.application_empty"), nil
+		}
+		lines := make([]string, 0, len(apps))
+		for _, app := range apps {
+			lines = append(lines, fmt.Sprintf("#%d %s %s", app.Id, app.Name, app.SteamId))
+			questions := make([]string, 0, len(app.Answers))
+			for question := range app.Answers {
+				questions = append(questions, question)
+			}
+			sort.Strings(questions)
+			for _, question := range questions {
+				lines = append(lines, fmt.Sprintf("  %s: %s", question, app.Answers[question]))
+			}
+		}
+		return strings.Join(lines, "\n"), nil
+	case "approve", "deny":
+		if len(args) < 2 {
+			return "", errors.New(locale.T(locale.Bot, "bot.application_usage"))
+		}
+		id, err := strconv.ParseUint(strings.TrimPrefix(args[1], "#"), 10, 64)
+		if err != nil {
+			return "", errors.New(locale.T(locale.Bot, "bot.application_usage"))
+		}
+		decision := service.ApplicationDecision{
+			Approve: args[0] == "approve",
+			Reason:  strings.Join(args[2:], " "),
+			By:      "bot",
+		}
+		app, err := task.DecideWhitelistApplication(db, id, decision)
+		if err != nil {
+			return "", err
+		}
+		if decision.Approve {
+			return locale.T(locale.Bot, "bot.application_approved", "name", app.Name), nil
+		}
+		return locale.T(locale.Bot, "bot.application_denied", "name", app.Name), nil
+	default:
+		return "", errors.New(locale.T(locale.Bot, "bot.application_usage"))
+	}
+}
+
+func describe(player database.PlayerW) string {
+	if player.Name != "" {
+		return player.Name
+	}
+	return player.SteamID
+}
+
+func backupCommand(db *bbolt.DB, _ []string, _ Level) (string, error) {
+	backup, err := task.RunBackup(db, service.BackupReasonBot)
+	if err != nil {
+		return "", err
+	}
+	return locale.T(locale.Bot, "bot.backup_saved", "time", backup.SaveTime.Local().Format("2006-01-02 15:04:05")), nil
+}
+
+func playerAction(db *bbolt.DB, args []string, action func(ctx context.Context, steamId string) error, doneKey string) (string, error) {
+	if len(args) == 0 {
+		return "", errors.New(locale.T(locale.Bot, "bot.name_required"))
+	}
+	p, err := findPlayer(db, strings.Join(args, " "))
+	if err != nil {
+		return "", err
+	}
+	if p.SteamId == "" {
+		return "", errors.New(locale.T(locale.Bot, "bot.no_steam_id"))
+	}
+	if err := action(context.Background(), fmt.Sprintf("steam_%s", p.SteamId)); err != nil {
+		return "", err
+	}
+	return locale.T(locale.Bot, doneKey, "username", p.Nickname), nil
+}
+
+func kickCommand(db *bbolt.DB, args []string, _ Level) (string, error) {
+	return playerAction(db, args, tool.KickPlayer, "bot.kicked")
+}
+
+func banCommand(db *bbolt.DB, args []string, _ Level) (string, error) {
+	return playerAction(db, args, tool.BanPlayer, "bot.banned")
+}
+
+func unbanCommand(db *bbolt.DB, args []string, _ Level) (string, error) {
+	return playerAction(db, args, func(ctx context.Context, steamId string) error {
+		if err := tool.UnBanPlayer(ctx, steamId); err != nil {
+			return err
+		}
+		p, err := findPlayer(db, strings.Join(args, " "))
+		if err != nil {
+			return err
+		}
+		return service.AddRemovedBan(db, database.PlayerW{Name: p.Nickname, SteamID: p.SteamId, PlayerUID: p.PlayerUid}, service.Removal{By: "bot"})
+	}, "bot.unbanned")
+}
+
+func broadcastCommand(_ *bbolt.DB, args []string, _ Level) (string, error) {
+	message := strings.Join(args, " ")
+	if message == "" {
+		return "", errors.New(locale.T(locale.Bot, "bot.message_required"))
+	}
+	if err := tool.Broadcast(context.Background(), message); err != nil {
+		return "", err
+	}
+	return locale.T(locale.Bot, "bot.broadcast_sent"), nil
+}
+
+func macroCommand(db *bbolt.DB, args []string, _ Level) (string, error) {
+	if len(args) == 0 {
+		return "", errors.New(locale.T(locale.Bot, "bot.macro_required"))
+	}
+	macro, err := service.GetMacro(db, args[0])
+	if err != nil {
+		if err == service.ErrNoRecord {
+			return "", errors.New(locale.T(locale.Bot, "bot.macro_not_found"))
+		}
+		return "", err
+	}
+	params := make(map[string]string)
+	for _, arg := range args[1:] {
+		if k, v, ok := strings.Cut(arg, "="); ok {
+			params[k] = v
+		}
+	}
+	go func() {
+		if err := tool.RunMacro(context.Background(), macro, params); err != nil {
+			logger.Errorf("%v\n", err)
+		}
+	}()
+	return locale.T(locale.Bot, "bot.macro_started", "name", macro.Name), nil
+}
@@ -0,0 +1,148 @@
+// Package bus delivers events to the subscribers interested in them, so
+// producers don't need to know about webhooks, email, streams or hooks.
+package bus
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/zaigie/palworld-server-tool/internal/crash"
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/internal/logger"
+)
+
+// queueSize is how many batches a slow subscriber may fall behind before
+// batches are dropped for it
+const queueSize = 64
+
+// Handler receives the matching events of one Publish, in order.
+type Handler func(events []database.Event)
+
+type subscriber struct {
+	name     string
+	patterns []string
+	queue    chan []database.Event
+}
+
+var (
+	mu          sync.RWMutex
+	subscribers = make(map[*subscriber]bool)
+	derivers    []Deriver
+)
+
+// Deriver returns an event to publish along with event, like alert.fired
+// for an alerting one.
+type Deriver func(event database.Event) (database.Event, bool)
+
+// Derive has every published event passed to derive, the events it returns
+// are published in the same batch, after the ones they derive from. Derived
+// events aren't derived from again.
+func Derive(derive Deriver) {
+	mu.Lock()
+	defer mu.Unlock()
+	derivers = append(derivers, derive)
+}
+
+// Subscribe runs handler for the events matching patterns, see Match, in a
+// goroutine of its own. It returns a function that ends the subscription.
+func Subscribe(name string, patterns []string, handler Handler) (unsubscribe func()) {
+	s := &subscriber{name: name, patterns: patterns, queue: make(chan []database.Event, queueSize)}
+	mu.Lock()
+	subscribers[s] = true
+	mu.Unlock()
+	go func() {
+		for events := range s.queue {
+			deliver(s.name, handler, events)
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			mu.Lock()
+			delete(subscribers, s)
+			close(s.queue)
+			mu.Unlock()
+		})
+	}
+}
+
+// deliver runs the handler with a panic reported rather than taking the
+// process down.
+func deliver(name string, handler Handler, events []database.Event) {
+	defer crash.Recover("subscriber "+name, nil)
+	handler(events)
+}
+
+// Publish hands the events to the subscribers without waiting for them.
+// Events that aren't stored have Id 0.
+func Publish(events ...database.Event) {
+	if len(events) == 0 {
+		return
+	}
+	mu.RLock()
+	defer mu.RUnlock()
+	if len(derivers) > 0 {
+		all := append([]database.Event(nil), events...)
+		for _, event := range events {
+			for _, derive := range derivers {
+				if derived, ok := derive(event); ok {
+					all = append(all, derived)
+				}
+			}
+		}
+		events = all
+	}
+	for s := range subscribers {
+		matched := make([]database.Event, 0, len(events))
+		for _, event := range events {
+			if Match(s.patterns, event.Type) {
+				matched = append(matched, event)
+			}
+		}
+		if len(matched) == 0 {
+			continue
+		}
+		select {
+		case s.queue <- matched:
+		default:
+			logger.Warnf("Event subscriber %s is behind, dropped %d events\n", s.name, len(matched))
+		}
+	}
+}
+
+// SubscriberStats is how far behind a subscriber is.
+type SubscriberStats struct {
+	Name string `json:"name"`
+	// Queued is how many batches wait for the handler
+	Queued int `json:"queued"`
+}
+
+// Stats lists the subscribers by name.
+func Stats() []SubscriberStats {
+	mu.RLock()
+	defer mu.RUnlock()
+	stats := make([]SubscriberStats, 0, len(subscribers))
+	for s := range subscribers {
+		stats = append(stats, SubscriberStats{Name: s.name, Queued: len(s.queue)})
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Name < stats[j].Name })
+	return stats
+}
+
+// Match reports whether eventType is selected by patterns, an empty pattern
+// list selects everything and a trailing "*" matches by prefix.
+func Match(patterns []string, eventType string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		if pattern == eventType || pattern == "*" {
+			return true
+		}
+		if strings.HasSuffix(pattern, "*") && strings.HasPrefix(eventType, strings.TrimSuffix(pattern, "*")) {
+			return true
+		}
+	}
+	return false
+}
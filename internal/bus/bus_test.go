@@ -0,0 +1,34 @@
+package bus
+
+import (
+	"testing"
+	"time"
+
+	"github.com/zaigie/palworld-server-tool/internal/database"
+)
+
+func TestDerive(t *testing.T) {
+	Derive(func(event database.Event) (database.Event, bool) {
+		if event.Type != "backup.fail" {
+			return database.Event{}, false
+		}
+		return database.Event{Type: "alert.fired", Data: map[string]string{"alert": event.Type}}, true
+	})
+	got := make(chan []database.Event, 1)
+	unsubscribe := Subscribe("test", []string{"backup.*", "alert.*"}, func(events []database.Event) { got <- events })
+	defer unsubscribe()
+
+	Publish(database.Event{Type: "backup.success"}, database.Event{Type: "backup.fail"})
+	select {
+	case events := <-got:
+		var types []string
+		for _, event := range events {
+			types = append(types, event.Type)
+		}
+		if len(types) != 3 || types[0] != "backup.success" || types[1] != "backup.fail" || types[2] != "alert.fired" {
+			t.Errorf("got %v, want backup.success, backup.fail and alert.fired", types)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no events delivered")
+	}
+}
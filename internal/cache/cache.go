@@ -0,0 +1,103 @@
+// Package cache keeps short lived shared state, sessions, rate limit
+// counters and cached lookups, in memory or in Redis so several pst replicas
+// behind a load balancer see the same state.
+package cache
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/zaigie/palworld-server-tool/internal/logger"
+)
+
+var ErrMiss = errors.New("cache miss")
+
+type Store interface {
+	// Get returns ErrMiss for a missing or expired key.
+	Get(key string) ([]byte, error)
+	// Set stores value for ttl, a ttl of zero keeps it until deleted.
+	Set(key string, value []byte, ttl time.Duration) error
+	// Incr adds one to the counter and returns it, a new counter expires
+	// after ttl.
+	Incr(key string, ttl time.Duration) (int64, error)
+	Delete(key string) error
+}
+
+var (
+	defaultMu    sync.Mutex
+	defaultStore Store
+)
+
+// Default returns the store of cache.redis_url, or an in memory store when
+// there is none. Keys get the cache.prefix.
+func Default() Store {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	if defaultStore == nil {
+		var store Store = NewMemory()
+		if redisUrl := viper.GetString("cache.redis_url"); redisUrl != "" {
+			redis, err := NewRedis(redisUrl)
+			if err != nil {
+				logger.Errorf("Invalid cache.redis_url, using memory: %v\n", err)
+			} else {
+				store = redis
+			}
+		}
+		defaultStore = &prefixed{prefix: viper.GetString("cache.prefix"), store: store}
+	}
+	return defaultStore
+}
+
+type Stats struct {
+	// Backend is memory or redis
+	Backend string `json:"backend"`
+	Hits    int64  `json:"hits"`
+	Misses  int64  `json:"misses"`
+	// Keys is how many keys the memory store holds, -1 for Redis
+	Keys int `json:"keys"`
+}
+
+// DefaultStats counts the lookups of the Default store so far.
+func DefaultStats() Stats {
+	p := Default().(*prefixed)
+	stats := Stats{Backend: "redis", Hits: p.hits.Load(), Misses: p.misses.Load(), Keys: -1}
+	if m, ok := p.store.(*Memory); ok {
+		stats.Backend = "memory"
+		m.mu.Lock()
+		stats.Keys = len(m.entries)
+		m.mu.Unlock()
+	}
+	return stats
+}
+
+type prefixed struct {
+	prefix string
+	store  Store
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+func (p *prefixed) Get(key string) ([]byte, error) {
+	value, err := p.store.Get(p.prefix + key)
+	if err == nil {
+		p.hits.Add(1)
+	} else if err == ErrMiss {
+		p.misses.Add(1)
+	}
+	return value, err
+}
+
+func (p *prefixed) Set(key string, value []byte, ttl time.Duration) error {
+	return p.store.Set(p.prefix+key, value, ttl)
+}
+
+func (p *prefixed) Incr(key string, ttl time.Duration) (int64, error) {
+	return p.store.Incr(p.prefix+key, ttl)
+}
+
+func (p *prefixed) Delete(key string) error {
+	return p.store.Delete(p.prefix + key)
+}
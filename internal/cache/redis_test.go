@@ -0,0 +1,193 @@
+package cache
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRedis speaks enough RESP for the store, answering each command with
+// the reply of handle.
+type fakeRedis struct {
+	mu       sync.Mutex
+	values   map[string]string
+	ttls     map[string]string
+	commands [][]string
+	// reply overrides the reply of a command when it returns one
+	reply func(args []string) string
+}
+
+func startFakeRedis(t *testing.T) (*fakeRedis, *Redis) {
+	f := &fakeRedis{values: make(map[string]string), ttls: make(map[string]string)}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+	r, err := NewRedis("redis://:pw@" + ln.Addr().String() + "/2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f, r
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	br := bufio.NewReader(conn)
+	for {
+		line, err := br.ReadString('\n')
+		if err != nil {
+			return
+		}
+		n, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+		args := make([]string, n)
+		for i := range args {
+			size, _ := br.ReadString('\n')
+			l, _ := strconv.Atoi(strings.TrimSpace(size[1:]))
+			data := make([]byte, l+2)
+			if _, err := io.ReadFull(br, data); err != nil {
+				return
+			}
+			args[i] = string(data[:l])
+		}
+		io.WriteString(conn, f.handle(args))
+	}
+}
+
+func (f *fakeRedis) handle(args []string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.commands = append(f.commands, args)
+	if f.reply != nil {
+		if reply := f.reply(args); reply != "" {
+			return reply
+		}
+	}
+	switch strings.ToUpper(args[0]) {
+	case "AUTH", "SELECT":
+		return "+OK\r\n"
+	case "GET":
+		v, ok := f.values[args[1]]
+		if !ok {
+			return "$-1\r\n"
+		}
+		return fmt.Sprintf("$%d\r\n%s\r\n", len(v), v)
+	case "SET":
+		f.values[args[1]] = args[2]
+		if len(args) == 5 {
+			f.ttls[args[1]] = args[4]
+		}
+		return "+OK\r\n"
+	case "EVAL":
+		// the increment script, keys after the count
+		key, ttl := args[3], args[4]
+		n, _ := strconv.Atoi(f.values[key])
+		n++
+		f.values[key] = strconv.Itoa(n)
+		if _, ok := f.ttls[key]; !ok && ttl != "0" {
+			f.ttls[key] = ttl
+		}
+		return fmt.Sprintf(":%d\r\n", n)
+	case "DEL":
+		delete(f.values, args[1])
+		return ":1\r\n"
+	}
+	return "-ERR unknown command\r\n"
+}
+
+func TestRedisStore(t *testing.T) {
+	f, r := startFakeRedis(t)
+	if _, err := r.Get("missing"); err != ErrMiss {
+		t.Errorf("Get missing: %v, want ErrMiss", err)
+	}
+	if err := r.Set("k", []byte("v\r\nwith newline"), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := r.Get("k"); err != nil || string(v) != "v\r\nwith newline" {
+		t.Errorf("Get = %q, %v", v, err)
+	}
+	for want := int64(1); want <= 3; want++ {
+		if n, err := r.Incr("count", 2*time.Second); err != nil || n != want {
+			t.Errorf("Incr = %d, %v, want %d", n, err, want)
+		}
+	}
+	if f.ttls["count"] != "2000" {
+		t.Errorf("counter ttl %q, want 2000", f.ttls["count"])
+	}
+	if err := r.Delete("k"); err != nil {
+		t.Fatal(err)
+	}
+	if f.commands[0][0] != "AUTH" || f.commands[1][0] != "SELECT" || f.commands[1][1] != "2" {
+		t.Errorf("connection set up with %v", f.commands[:2])
+	}
+	// the ttl goes with the increment, never in a command of its own
+	for _, args := range f.commands {
+		if strings.ToUpper(args[0]) == "INCR" || strings.ToUpper(args[0]) == "PEXPIRE" {
+			t.Errorf("counter changed with %v outside the script", args)
+		}
+	}
+}
+
+func TestRedisUnexpectedReplies(t *testing.T) {
+	f, r := startFakeRedis(t)
+	tests := []struct {
+		name  string
+		reply string
+		run   func() error
+	}{
+		{"error on incr", "-ERR value is not an integer\r\n", func() error { _, err := r.Incr("k", time.Minute); return err }},
+		{"string on incr", "+OK\r\n", func() error { _, err := r.Incr("k", time.Minute); return err }},
+		{"bulk on incr", "$1\r\n1\r\n", func() error { _, err := r.Incr("k", time.Minute); return err }},
+		{"integer on get", ":1\r\n", func() error { _, err := r.Get("k"); return err }},
+		{"array on get", "*1\r\n:1\r\n", func() error { _, err := r.Get("k"); return err }},
+		{"garbage", "?\r\n", func() error { _, err := r.Get("k"); return err }},
+	}
+	for _, tt := range tests {
+		f.mu.Lock()
+		f.reply = func(args []string) string {
+			if args[0] == "AUTH" || args[0] == "SELECT" {
+				return ""
+			}
+			return tt.reply
+		}
+		f.mu.Unlock()
+		if err := tt.run(); err == nil {
+			t.Errorf("%s: no error", tt.name)
+		}
+	}
+	// a store still works after the replies it couldn't read
+	f.mu.Lock()
+	f.reply = nil
+	f.mu.Unlock()
+	if n, err := r.Incr("after", time.Minute); err != nil || n != 1 {
+		t.Errorf("Incr after errors = %d, %v", n, err)
+	}
+}
+
+func TestMemoryIncr(t *testing.T) {
+	m := NewMemory()
+	for want := int64(1); want <= 2; want++ {
+		if n, err := m.Incr("k", 20*time.Millisecond); err != nil || n != want {
+			t.Errorf("Incr = %d, %v, want %d", n, err, want)
+		}
+	}
+	time.Sleep(30 * time.Millisecond)
+	if n, err := m.Incr("k", time.Minute); err != nil || n != 1 {
+		t.Errorf("Incr after expiry = %d, %v, want 1", n, err)
+	}
+}
@@ -0,0 +1,85 @@
+// Package catalog is the list of game items, generated from the one of the
+// web UI so admin commands can check item ids before sending them.
+package catalog
+
+//go:generate go run gen.go
+
+import (
+	_ "embed"
+	"encoding/json"
+	"sort"
+	"strings"
+	"sync"
+)
+
+type Item struct {
+	// Key is the id of the item in the game and its commands
+	Key string `json:"key"`
+	// Names are the display names by language, en, zh and ja
+	Names map[string]string `json:"names"`
+}
+
+//go:embed items.json
+var itemsJson []byte
+
+var (
+	itemsOnce sync.Once
+	items     []Item
+	byKey     map[string]Item
+)
+
+func load() {
+	itemsOnce.Do(func() {
+		if err := json.Unmarshal(itemsJson, &items); err != nil {
+			panic(err)
+		}
+		byKey = make(map[string]Item, len(items))
+		for _, item := range items {
+			byKey[strings.ToLower(item.Key)] = item
+		}
+	})
+}
+
+// FindItem returns the item of a key in any case, or the one with exactly
+// that name in a language.
+func FindItem(id string) (Item, bool) {
+	load()
+	if item, ok := byKey[strings.ToLower(id)]; ok {
+		return item, true
+	}
+	for _, item := range items {
+		for _, name := range item.Names {
+			if strings.EqualFold(name, id) {
+				return item, true
+			}
+		}
+	}
+	return Item{}, false
+}
+
+// SearchItems returns at most limit items whose key or a name contains q,
+// those whose key starts with it first.
+func SearchItems(q string, limit int) []Item {
+	load()
+	q = strings.ToLower(q)
+	found := make([]Item, 0)
+	for _, item := range items {
+		if strings.Contains(strings.ToLower(item.Key), q) {
+			found = append(found, item)
+			continue
+		}
+		for _, name := range item.Names {
+			if strings.Contains(strings.ToLower(name), q) {
+				found = append(found, item)
+				break
+			}
+		}
+	}
+	sort.SliceStable(found, func(i, j int) bool {
+		return strings.HasPrefix(strings.ToLower(found[i].Key), q) && !strings.HasPrefix(strings.ToLower(found[j].Key), q)
+	})
+	if len(found) > limit {
+		found = found[:limit]
+	}
+	return found
+}
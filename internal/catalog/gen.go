@@ -0,0 +1,62 @@
+//go:build ignore
+
+// gen writes items.json from the item list of the web UI, keeping the key
+// the game uses and the names in each language.
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+)
+
+type webItem struct {
+	Key  string `json:"key"`
+	Name string `json:"name"`
+}
+
+type item struct {
+	Key   string            `json:"key"`
+	Names map[string]string `json:"names"`
+}
+
+func main() {
+	data, err := os.ReadFile("../../web/src/assets/items.json")
+	if err != nil {
+		log.Fatal(err)
+	}
+	var langs map[string][]webItem
+	if err := json.Unmarshal(data, &langs); err != nil {
+		log.Fatal(err)
+	}
+	var items []item
+	index := make(map[string]int)
+	for _, lang := range []string{"en", "zh", "ja"} {
+		for _, wi := range langs[lang] {
+			i, ok := index[wi.Key]
+			if !ok {
+				i = len(items)
+				index[wi.Key] = i
+				items = append(items, item{Key: wi.Key, Names: make(map[string]string)})
+			}
+			items[i].Names[lang] = wi.Name
+		}
+	}
+	// an item a line keeps regenerated diffs readable
+	out := []byte("[\n")
+	for i, it := range items {
+		line, err := json.Marshal(it)
+		if err != nil {
+			log.Fatal(err)
+		}
+		out = append(out, line...)
+		if i < len(items)-1 {
+			out = append(out, ',')
+		}
+		out = append(out, '\n')
+	}
+	out = append(out, "]\n"...)
+	if err := os.WriteFile("items.json", out, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,179 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/zaigie/palworld-server-tool/client"
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/internal/task"
+	"github.com/zaigie/palworld-server-tool/internal/tool"
+	"github.com/zaigie/palworld-server-tool/service"
+)
+
+// backend is what the subcommands operate on, either a running instance
+// over its API or the local database when the instance is stopped.
+type backend interface {
+	ListPlayers() ([]database.TersePlayer, error)
+	ListOnlinePlayers() ([]database.OnlinePlayer, error)
+	KickPlayer(playerUid string) error
+	BanPlayer(playerUid string) error
+	UnbanPlayer(playerUid string) error
+	Broadcast(message string) error
+	Backup() (database.Backup, error)
+	ListBackups() ([]database.Backup, error)
+	ListWhitelist() ([]database.PlayerW, error)
+	AddWhitelist(player database.PlayerW) error
+	RemoveWhitelist(player database.PlayerW) error
+	Metrics() (client.Metrics, error)
+}
+
+// apiBackend calls a running instance through the client package.
+type apiBackend struct {
+	client *client.Client
+}
+
+func newApiBackend(server, password string) *apiBackend {
+	return &apiBackend{client: client.New(server, password)}
+}
+
+func (b *apiBackend) ListPlayers() ([]database.TersePlayer, error) {
+	return b.client.ListPlayers(context.Background())
+}
+
+func (b *apiBackend) ListOnlinePlayers() ([]database.OnlinePlayer, error) {
+	return b.client.ListOnlinePlayers(context.Background())
+}
+
+func (b *apiBackend) KickPlayer(playerUid string) error {
+	return b.client.Kick(context.Background(), playerUid)
+}
+
+func (b *apiBackend) BanPlayer(playerUid string) error {
+	return b.client.Ban(context.Background(), playerUid)
+}
+
+func (b *apiBackend) UnbanPlayer(playerUid string) error {
+	return b.client.Unban(context.Background(), playerUid)
+}
+
+func (b *apiBackend) Broadcast(message string) error {
+	return b.client.Broadcast(context.Background(), message)
+}
+
+func (b *apiBackend) Backup() (database.Backup, error) {
+	return b.client.Backup(context.Background())
+}
+
+func (b *apiBackend) ListBackups() ([]database.Backup, error) {
+	return b.client.ListBackups(context.Background())
+}
+
+func (b *apiBackend) ListWhitelist() ([]database.PlayerW, error) {
+	return b.client.ListWhitelist(context.Background())
+}
+
+func (b *apiBackend) AddWhitelist(player database.PlayerW) error {
+	return b.client.AddWhitelist(context.Background(), player)
+}
+
+func (b *apiBackend) RemoveWhitelist(player database.PlayerW) error {
+	return b.client.RemoveWhitelist(context.Background(), player)
+}
+
+func (b *apiBackend) Metrics() (client.Metrics, error) {
+	return b.client.Metrics(context.Background())
+}
+
+// dbBackend works on pst.db directly, bbolt locks the file so the instance
+// has to be stopped. Game server actions still go through REST API and RCON.
+type dbBackend struct{}
+
+func (dbBackend) ListPlayers() ([]database.TersePlayer, error) {
+	return service.ListPlayers(database.GetDB())
+}
+
+func (dbBackend) ListOnlinePlayers() ([]database.OnlinePlayer, error) {
+	return tool.ShowPlayers(context.Background())
+}
+
+func (dbBackend) steamId(playerUid string) (string, error) {
+	player, err := service.GetPlayer(database.GetDB(), playerUid)
+	if err != nil {
+		if err == service.ErrNoRecord {
+			return "", errors.New("player not found")
+		}
+		return "", err
+	}
+	return fmt.Sprintf("steam_%s", player.SteamId), nil
+}
+
+func (b dbBackend) KickPlayer(playerUid string) error {
+	steamId, err := b.steamId(playerUid)
+	if err != nil {
+		return err
+	}
+	return tool.KickPlayer(context.Background(), steamId)
+}
+
+func (b dbBackend) BanPlayer(playerUid string) error {
+	steamId, err := b.steamId(playerUid)
+	if err != nil {
+		return err
+	}
+	return tool.BanPlayer(context.Background(), steamId)
+}
+
+func (dbBackend) UnbanPlayer(playerUid string) error {
+	player, err := service.GetPlayer(database.GetDB(), playerUid)
+	if err != nil {
+		if err == service.ErrNoRecord {
+			return errors.New("player not found")
+		}
+		return err
+	}
+	if err := tool.UnBanPlayer(context.Background(), fmt.Sprintf("steam_%s", player.SteamId)); err != nil {
+		return err
+	}
+	return service.AddRemovedBan(database.GetDB(), database.PlayerW{Name: player.Nickname, SteamID: player.SteamId, PlayerUID: player.PlayerUid}, service.Removal{By: "cli"})
+}
+
+func (dbBackend) Broadcast(message string) error {
+	return tool.Broadcast(context.Background(), message)
+}
+
+func (dbBackend) Backup() (database.Backup, error) {
+	return task.RunBackup(database.GetDB(), service.BackupReasonManual)
+}
+
+func (dbBackend) ListBackups() ([]database.Backup, error) {
+	return service.ListBackups(database.GetDB(), service.BackupFilter{})
+}
+
+func (dbBackend) ListWhitelist() ([]database.PlayerW, error) {
+	return service.ListWhitelist(database.GetDB())
+}
+
+func (dbBackend) AddWhitelist(player database.PlayerW) error {
+	return service.AddWhitelist(database.GetDB(), player)
+}
+
+func (dbBackend) RemoveWhitelist(player database.PlayerW) error {
+	return service.RemoveWhitelist(database.GetDB(), player, service.Removal{By: "cli"})
+}
+
+func (dbBackend) Metrics() (client.Metrics, error) {
+	metrics, err := tool.Metrics(context.Background())
+	if err != nil {
+		return client.Metrics{}, err
+	}
+	return client.Metrics{
+		ServerFps:        metrics["server_fps"].(int),
+		CurrentPlayerNum: metrics["current_player_num"].(int),
+		ServerFrameTime:  metrics["server_frame_time"].(float64),
+		MaxPlayerNum:     metrics["max_player_num"].(int),
+		Uptime:           metrics["uptime"].(int),
+		Days:             metrics["days"].(int),
+	}, nil
+}
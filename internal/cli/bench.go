@@ -0,0 +1,121 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/internal/fixture"
+	"github.com/zaigie/palworld-server-tool/service"
+	"go.etcd.io/bbolt"
+)
+
+type benchOptions struct {
+	fixture.Options
+	rounds int
+}
+
+// benchCommand runs save syncs of a generated world against a scratch
+// database and reports how long each step of the sync took, the pst.db of
+// the instance is not touched.
+func benchCommand(_ backend, opts options, _ []string) error {
+	o := opts.bench
+	if o.Players <= 0 || o.Pals < 0 || o.Guilds < 0 || o.rounds <= 0 {
+		return errors.New("players and rounds must be positive, pals and guilds not negative")
+	}
+	dir, err := os.MkdirTemp("", "pst-bench-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	db, err := database.Open(filepath.Join(dir, "bench.db"))
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	threshold := service.RaidThreshold{
+		Structures: viper.GetInt("manage.base_raid_structures"),
+		HpPercent:  viper.GetFloat64("manage.base_raid_hp_percent"),
+	}
+	steps := []struct {
+		name string
+		run  func(db *bbolt.DB, world fixture.World) error
+	}{
+		{"PutPlayers", func(db *bbolt.DB, world fixture.World) error {
+			_, err := service.PutPlayers(db, world.Players, nil)
+			return err
+		}},
+		{"TrackPals", func(db *bbolt.DB, world fixture.World) error {
+			_, err := service.TrackPals(db, world.Players)
+			return err
+		}},
+		{"PutGuilds", func(db *bbolt.DB, world fixture.World) error {
+			_, err := service.PutGuilds(db, world.Guilds, service.GuildsReplace, threshold, nil)
+			return err
+		}},
+		{"TrackGuildStorage", func(db *bbolt.DB, world fixture.World) error {
+			_, err := service.TrackGuildStorage(db, world.Guilds, 0, 0)
+			return err
+		}},
+		{"RecordGuildStats", func(db *bbolt.DB, world fixture.World) error {
+			return service.RecordGuildStats(db)
+		}},
+		{"RecordDailySnapshot", func(db *bbolt.DB, world fixture.World) error {
+			return service.RecordDailySnapshot(db)
+		}},
+	}
+
+	world := fixture.Generate(o.Options)
+	fmt.Fprintf(out, "Syncing %d players with %d pals each in %d guilds, %d rounds\n", len(world.Players), o.Pals, len(world.Guilds), o.rounds)
+	durations := make([][]time.Duration, len(steps))
+	var total time.Duration
+	for round := 0; round < o.rounds; round++ {
+		if round > 0 {
+			world.Advance(o.Seed + int64(round))
+		}
+		for i, step := range steps {
+			start := time.Now()
+			if err := step.run(db, world); err != nil {
+				return fmt.Errorf("%s: %w", step.name, err)
+			}
+			d := time.Since(start)
+			durations[i] = append(durations[i], d)
+			total += d
+		}
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "STEP\tMIN\tAVG\tMAX")
+	for i, step := range steps {
+		min, avg, max := summarize(durations[i])
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", step.name, min, avg, max)
+	}
+	w.Flush()
+	perSync := total / time.Duration(o.rounds)
+	fmt.Fprintf(out, "%s per sync, %.0f players/s\n", perSync.Round(time.Microsecond), float64(len(world.Players))/perSync.Seconds())
+	if info, err := os.Stat(db.Path()); err == nil {
+		fmt.Fprintf(out, "Database size %d bytes\n", info.Size())
+	}
+	return nil
+}
+
+func summarize(durations []time.Duration) (min, avg, max time.Duration) {
+	var sum time.Duration
+	for i, d := range durations {
+		if i == 0 || d < min {
+			min = d
+		}
+		if d > max {
+			max = d
+		}
+		sum += d
+	}
+	avg = sum / time.Duration(len(durations))
+	return min.Round(time.Microsecond), avg.Round(time.Microsecond), max.Round(time.Microsecond)
+}
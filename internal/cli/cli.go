@@ -0,0 +1,345 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/zaigie/palworld-server-tool/internal/config"
+	"github.com/zaigie/palworld-server-tool/internal/database"
+)
+
+type options struct {
+	online   bool
+	player   database.PlayerW
+	interval int
+	bench    benchOptions
+	service  serviceOptions
+	db       dbOptions
+	// showSecrets prints passwords and keys in config print-effective
+	showSecrets bool
+}
+
+type command struct {
+	usage string
+	run   func(b backend, opts options, args []string) error
+	// local commands need no instance or database, they get a nil backend
+	local bool
+}
+
+var commands = map[string]command{
+	"players": {
+		usage: "players list [-online]",
+		run:   playersCommand,
+	},
+	"kick": {
+		usage: "kick <player_uid>",
+		run: func(b backend, _ options, args []string) error {
+			return playerAction(args, b.KickPlayer, "Kicked")
+		},
+	},
+	"ban": {
+		usage: "ban <player_uid>",
+		run: func(b backend, _ options, args []string) error {
+			return playerAction(args, b.BanPlayer, "Banned")
+		},
+	},
+	"unban": {
+		usage: "unban <player_uid>",
+		run: func(b backend, _ options, args []string) error {
+			return playerAction(args, b.UnbanPlayer, "Unbanned")
+		},
+	},
+	"broadcast": {
+		usage: "broadcast <message>",
+		run:   broadcastCommand,
+	},
+	"backup": {
+		usage: "backup now|list",
+		run:   backupCommand,
+	},
+	"whitelist": {
+		usage: "whitelist list|add|remove [-name name] [-steam_id id] [-player_uid uid]",
+		run:   whitelistCommand,
+	},
+	"events": {
+		usage: "events [type...]",
+		run:   eventsCommand,
+	},
+	"tui": {
+		usage: "tui [-interval seconds]",
+		run:   tuiCommand,
+	},
+	"bench": {
+		usage: "bench [-players n] [-pals n] [-guilds n] [-rounds n] [-seed n]",
+		run:   benchCommand,
+		local: true,
+	},
+	"config": {
+		usage: "config validate|doctor|print-effective [-show_secrets]",
+		run:   configCommand,
+		local: true,
+	},
+	"db": {
+		usage: "db list|restore [-target dir|url] [-time time] [-output file] [-force]",
+		run:   dbCommand,
+		local: true,
+	},
+	"setup": {
+		usage: "setup",
+		run:   setupCommand,
+		local: true,
+	},
+	"service": {
+		usage: "service install|uninstall [-service_name name] [-user user] [-print]",
+		run:   serviceCommand,
+		local: true,
+	},
+}
+
+var out io.Writer = os.Stdout
+
+// Run executes the subcommand in args and returns its exit code, ok is false
+// when args don't start with a subcommand so the server should start instead.
+func Run(args []string) (code int, ok bool) {
+	if len(args) == 0 {
+		return 0, false
+	}
+	if args[0] == "help" {
+		usage()
+		return 0, true
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		return 0, false
+	}
+
+	var cfgFile, server, password string
+	var offline bool
+	var opts options
+	fs := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	fs.StringVar(&cfgFile, "config", "", "config file")
+	fs.StringVar(&server, "server", "", "address of the running instance, default from web.port of the config")
+	fs.StringVar(&password, "password", "", "web password, default web.password of the config")
+	fs.BoolVar(&offline, "offline", false, "operate on pst.db directly, the instance must be stopped")
+	fs.BoolVar(&opts.online, "online", false, "players: list online players from the game server")
+	fs.StringVar(&opts.player.Name, "name", "", "whitelist: player name")
+	fs.StringVar(&opts.player.SteamID, "steam_id", "", "whitelist: steam id")
+	fs.StringVar(&opts.player.PlayerUID, "player_uid", "", "whitelist: player uid")
+	fs.IntVar(&opts.interval, "interval", 5, "tui: refresh interval in seconds")
+	fs.IntVar(&opts.bench.Players, "players", 100, "bench: players in the generated save")
+	fs.IntVar(&opts.bench.Pals, "pals", 20, "bench: pals per player")
+	fs.IntVar(&opts.bench.Guilds, "guilds", 10, "bench: guilds in the generated save")
+	fs.IntVar(&opts.bench.rounds, "rounds", 10, "bench: save syncs to run")
+	fs.Int64Var(&opts.bench.Seed, "seed", 1, "bench: seed of the generated save")
+	fs.StringVar(&opts.service.name, "service_name", "pst", "service: name of the service")
+	fs.StringVar(&opts.service.user, "user", "", "service: user running the systemd unit, default root")
+	fs.BoolVar(&opts.service.print, "print", false, "service: print the systemd unit instead of installing it")
+	fs.StringVar(&opts.db.target, "target", "", "db: shipping target, default db_shipping.target of the config")
+	fs.StringVar(&opts.db.at, "time", "", "db: restore as of this time, RFC3339 or 2006-01-02 15:04:05, default the newest")
+	fs.StringVar(&opts.db.output, "output", "", "db: file to restore to, default pst.db of the data directory")
+	fs.BoolVar(&opts.db.force, "force", false, "db: replace an existing database, it is kept as a .bak file")
+	fs.BoolVar(&opts.showSecrets, "show_secrets", false, "config: print passwords and keys instead of masking them")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: pst %s\n", cmd.usage)
+		fs.PrintDefaults()
+	}
+	cmdArgs, err := parseInterspersed(fs, args[1:])
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0, true
+		}
+		return 2, true
+	}
+
+	var conf config.Config
+	config.Init(cfgFile, &conf)
+
+	var b backend
+	switch {
+	case cmd.local:
+		// nothing to connect to
+	case offline:
+		db, err := database.InitLocal(2 * time.Second)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1, true
+		}
+		defer db.Close()
+		b = dbBackend{}
+	default:
+		if server == "" {
+			scheme := "http"
+			if viper.GetBool("web.tls") {
+				scheme = "https"
+			}
+			server = fmt.Sprintf("%s://127.0.0.1:%d", scheme, viper.GetInt("web.port"))
+		}
+		if password == "" {
+			password = viper.GetString("web.password")
+		}
+		b = newApiBackend(server, password)
+	}
+
+	if err := cmd.run(b, opts, cmdArgs); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1, true
+	}
+	return 0, true
+}
+
+// parseInterspersed allows flags after positional arguments, which the flag
+// package stops at.
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
+
+func usage() {
+	fmt.Fprintln(out, "Usage: pst <command> [-config file] [-server url] [-password pwd] [-offline] [args]")
+	fmt.Fprintln(out, "\nCommands:")
+	for _, name := range []string{"players", "kick", "ban", "unban", "broadcast", "backup", "whitelist", "events", "tui", "bench", "service", "config", "db", "setup"} {
+		fmt.Fprintf(out, "  %s\n", commands[name].usage)
+	}
+	fmt.Fprintln(out, "\nWithout a command pst starts the server.")
+}
+
+func playersCommand(b backend, opts options, args []string) error {
+	if len(args) != 1 || args[0] != "list" {
+		return errors.New("usage: pst players list [-online]")
+	}
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	defer w.Flush()
+	if opts.online {
+		players, err := b.ListOnlinePlayers()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(w, "PLAYER_UID\tSTEAM_ID\tNICKNAME\tLEVEL\tLOCATION")
+		for _, p := range players {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%.0f,%.0f\n", p.PlayerUid, p.SteamId, p.Nickname, p.Level, p.LocationX, p.LocationY)
+		}
+		return nil
+	}
+	players, err := b.ListPlayers()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(w, "PLAYER_UID\tSTEAM_ID\tNICKNAME\tLEVEL\tLAST_ONLINE")
+	for _, p := range players {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\n", p.PlayerUid, p.SteamId, p.Nickname, p.Level, formatTime(p.LastOnline))
+	}
+	return nil
+}
+
+func playerAction(args []string, action func(playerUid string) error, done string) error {
+	if len(args) != 1 {
+		return errors.New("player_uid is required")
+	}
+	if err := action(args[0]); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "%s %s\n", done, args[0])
+	return nil
+}
+
+func broadcastCommand(b backend, _ options, args []string) error {
+	message := strings.Join(args, " ")
+	if message == "" {
+		return errors.New("message is required")
+	}
+	if err := b.Broadcast(message); err != nil {
+		return err
+	}
+	fmt.Fprintln(out, "Broadcast sent")
+	return nil
+}
+
+func backupCommand(b backend, _ options, args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: pst backup now|list")
+	}
+	switch args[0] {
+	case "now":
+		backup, err := b.Backup()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Backup %s saved to %s\n", backup.BackupId, backup.Path)
+	case "list":
+		backups, err := b.ListBackups()
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		defer w.Flush()
+		fmt.Fprintln(w, "BACKUP_ID\tPATH\tSAVE_TIME")
+		for _, backup := range backups {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", backup.BackupId, backup.Path, formatTime(backup.SaveTime))
+		}
+	default:
+		return errors.New("usage: pst backup now|list")
+	}
+	return nil
+}
+
+func whitelistCommand(b backend, opts options, args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: pst whitelist list|add|remove")
+	}
+	player := opts.player
+	switch args[0] {
+	case "list":
+		players, err := b.ListWhitelist()
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		defer w.Flush()
+		fmt.Fprintln(w, "NAME\tSTEAM_ID\tPLAYER_UID")
+		for _, p := range players {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", p.Name, p.SteamID, p.PlayerUID)
+		}
+		return nil
+	case "add", "remove":
+		if player.SteamID == "" && player.PlayerUID == "" {
+			return errors.New("steam_id or player_uid is required")
+		}
+		if args[0] == "add" {
+			if err := b.AddWhitelist(player); err != nil {
+				return err
+			}
+			fmt.Fprintln(out, "Added to whitelist")
+			return nil
+		}
+		if err := b.RemoveWhitelist(player); err != nil {
+			return err
+		}
+		fmt.Fprintln(out, "Removed from whitelist")
+		return nil
+	default:
+		return errors.New("usage: pst whitelist list|add|remove")
+	}
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format("2006-01-02 15:04:05")
+}
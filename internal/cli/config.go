@@ -0,0 +1,72 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/viper"
+	"github.com/zaigie/palworld-server-tool/internal/config"
+	"github.com/zaigie/palworld-server-tool/internal/doctor"
+)
+
+// configCommand validates the config, doctor also tries what it points to:
+// the RCON and REST API of the game server, the save and pst's directories.
+// print-effective prints the config merged from the file, the environment
+// and the defaults.
+func configCommand(_ backend, opts options, args []string) error {
+	if len(args) != 1 || (args[0] != "validate" && args[0] != "doctor" && args[0] != "print-effective") {
+		return errors.New("usage: pst config validate|doctor|print-effective [-show_secrets]")
+	}
+	if args[0] == "print-effective" {
+		b, err := config.Effective(opts.showSecrets)
+		if err != nil {
+			return err
+		}
+		_, err = out.Write(b)
+		return err
+	}
+	if used := viper.ConfigFileUsed(); used != "" {
+		fmt.Fprintf(out, "Config %s\n\n", used)
+	} else {
+		fmt.Fprint(out, "No config file, using the environment\n\n")
+	}
+	problems := config.Check()
+	if args[0] == "doctor" {
+		failed := make(map[string]bool)
+		for _, p := range problems {
+			if !p.Warning {
+				failed[p.Key] = true
+			}
+		}
+		for _, r := range doctor.Run(failed) {
+			if r.Problem != nil {
+				problems = append(problems, *r.Problem)
+			} else {
+				fmt.Fprintf(out, "[ok]   %s: %s\n", r.Key, r.Message)
+			}
+		}
+	}
+	if failed := printProblems(problems); failed > 0 {
+		return fmt.Errorf("%d problems found", failed)
+	}
+	fmt.Fprintf(out, "Config OK with %d warnings\n", len(problems))
+	return nil
+}
+
+// printProblems lists the problems with their fixes and returns how many
+// aren't warnings.
+func printProblems(problems []config.Problem) int {
+	failed := 0
+	for _, p := range problems {
+		status := "[warn]"
+		if !p.Warning {
+			status = "[fail]"
+			failed++
+		}
+		fmt.Fprintf(out, "%s %s: %s\n", status, p.Key, p.Message)
+		if p.Fix != "" {
+			fmt.Fprintf(out, "       fix: %s\n", p.Fix)
+		}
+	}
+	return failed
+}
@@ -0,0 +1,120 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/internal/dbship"
+	"github.com/zaigie/palworld-server-tool/internal/paths"
+	"github.com/zaigie/palworld-server-tool/service"
+)
+
+type dbOptions struct {
+	target string
+	at     string
+	output string
+	force  bool
+}
+
+// dbCommand lists the shipments of db_shipping.target or restores pst.db
+// from them, as of -time or the newest.
+func dbCommand(_ backend, opts options, args []string) error {
+	if len(args) != 1 || (args[0] != "list" && args[0] != "restore") {
+		return errors.New("usage: pst db list|restore [-target dir|url] [-time time] [-output file] [-force]")
+	}
+	target := opts.db.target
+	if target == "" {
+		target = viper.GetString("db_shipping.target")
+	}
+	if target == "" {
+		return errors.New("no target, set db_shipping.target or pass -target")
+	}
+	t, err := dbship.OpenTarget(target)
+	if err != nil {
+		return err
+	}
+
+	if args[0] == "list" {
+		m, err := dbship.LoadManifest(t)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		defer w.Flush()
+		fmt.Fprintln(w, "GENERATION\tNAME\tTIME\tSIZE\tPAGES")
+		for _, gen := range m.Generations {
+			for _, s := range append([]dbship.Shipment{gen.Snapshot}, gen.Diffs...) {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d\n", gen.Id, s.Name, formatTime(s.Time), s.Size, s.Pages)
+			}
+		}
+		return nil
+	}
+
+	var at time.Time
+	if opts.db.at != "" {
+		if at, err = time.Parse(time.RFC3339, opts.db.at); err != nil {
+			if at, err = time.ParseInLocation("2006-01-02 15:04:05", opts.db.at, time.Local); err != nil {
+				return errors.New("-time is RFC3339 or 2006-01-02 15:04:05 in local time")
+			}
+		}
+	}
+	output := opts.db.output
+	if output == "" {
+		output = filepath.Join(paths.Data(), "pst.db")
+	}
+	// the database in the way is only moved aside once the restore worked
+	restored := output
+	_, err = os.Stat(output)
+	exists := err == nil
+	if exists {
+		if !opts.db.force {
+			return fmt.Errorf("%s exists, stop pst and pass -force to replace it", output)
+		}
+		restored = output + ".restored"
+	}
+	shipment, err := dbship.Restore(t, at, restored)
+	if err != nil {
+		return err
+	}
+	backup := fmt.Sprintf("%s.%s.bak", output, time.Now().Format("20060102150405"))
+	if err := auditRestore(restored, shipment, exists, backup); err != nil {
+		return err
+	}
+	if exists {
+		if err := os.Rename(output, backup); err != nil {
+			return err
+		}
+		if err := os.Rename(restored, output); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Moved the replaced database to %s\n", backup)
+	}
+	fmt.Fprintf(out, "Restored %s as of %s from %s\n", output, formatTime(shipment.Time), shipment.Name)
+	return nil
+}
+
+// auditRestore records the restore as admin.restore in the restored
+// database, there is no instance running to publish it to.
+func auditRestore(path string, shipment dbship.Shipment, replaced bool, backup string) error {
+	db, err := database.Open(path)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	data := map[string]string{"action": "db_restore", "by": "cli", "shipment": shipment.Name, "as_of": shipment.Time.Format(time.RFC3339)}
+	if replaced {
+		data["replaced"] = backup
+	}
+	_, err = service.AddEvents(db, []database.Event{{
+		Type:    service.EventAdminRestore,
+		Message: fmt.Sprintf("Database restored as of %s from %s", formatTime(shipment.Time), shipment.Name),
+		Data:    data,
+	}})
+	return err
+}
@@ -0,0 +1,42 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+)
+
+// eventsCommand prints the events of the running instance as they happen,
+// until interrupted.
+func eventsCommand(b backend, _ options, args []string) error {
+	api, ok := b.(*apiBackend)
+	if !ok {
+		return errors.New("events need a running instance")
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	sub, err := api.client.SubscribeEvents(ctx, args...)
+	if err != nil {
+		return err
+	}
+	for event := range sub.Events {
+		data := make([]string, 0, len(event.Data))
+		for key, value := range event.Data {
+			data = append(data, key+"="+value)
+		}
+		sort.Strings(data)
+		fields := []string{formatTime(event.Time), event.Type}
+		if event.Message != "" {
+			fields = append(fields, event.Message)
+		}
+		fmt.Fprintln(out, strings.Join(append(fields, data...), " "))
+	}
+	if err := sub.Err(); err != nil && ctx.Err() == nil {
+		return err
+	}
+	return nil
+}
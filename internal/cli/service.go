@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/spf13/viper"
+	"github.com/zaigie/palworld-server-tool/internal/daemon"
+)
+
+type serviceOptions struct {
+	name  string
+	user  string
+	print bool
+}
+
+// serviceCommand installs pst running in the current directory with the
+// config in use as a service, or removes it.
+func serviceCommand(_ backend, opts options, args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: pst service install|uninstall")
+	}
+	switch args[0] {
+	case "install":
+		d, err := daemon.DefaultOptions()
+		if err != nil {
+			return err
+		}
+		d.Name = opts.service.name
+		d.User = opts.service.user
+		if used := viper.ConfigFileUsed(); used != "" {
+			if d.Config, err = filepath.Abs(used); err != nil {
+				return err
+			}
+		}
+		if opts.service.print {
+			unit, err := daemon.Unit(d)
+			if err != nil {
+				return err
+			}
+			fmt.Fprint(out, unit)
+			return nil
+		}
+		if err := daemon.Install(d); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Installed and started service %s running %s in %s\n", d.Name, d.Executable, d.Dir)
+	case "uninstall":
+		if err := daemon.Uninstall(opts.service.name); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Removed service %s\n", opts.service.name)
+	default:
+		return errors.New("usage: pst service install|uninstall")
+	}
+	return nil
+}
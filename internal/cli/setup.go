@@ -0,0 +1,98 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/viper"
+	"github.com/zaigie/palworld-server-tool/internal/config"
+	"github.com/zaigie/palworld-server-tool/internal/doctor"
+	"golang.org/x/term"
+)
+
+// setupCommand asks for the settings of the setup, checks them against the
+// game server and writes config.yaml, like the web setup of a first start.
+func setupCommand(_ backend, _ options, _ []string) error {
+	if used := viper.ConfigFileUsed(); used != "" {
+		return fmt.Errorf("config %s exists, edit it or check it with pst config doctor", used)
+	}
+	path := config.SetupPath()
+	fmt.Fprintf(out, "Setting up %s, the passwords are AdminPassword of PalWorldSettings.ini\n\n", path)
+	r := bufio.NewReader(os.Stdin)
+	var s config.Setup
+	var err error
+	for {
+		fields := []struct {
+			label, fallback string
+			secret          bool
+			value           *string
+		}{
+			{"Game server RCON address", "127.0.0.1:25575", false, &s.RconAddress},
+			{"Game server REST API address", "http://127.0.0.1:8212", false, &s.RestAddress},
+			{"Game server admin password", "", true, &s.AdminPassword},
+			{"Save path, the Pal/Saved directory", "", false, &s.SavePath},
+			{"Password of the pst web UI", "", true, &s.WebPassword},
+		}
+		for _, f := range fields {
+			if *f.value != "" {
+				f.fallback = *f.value
+			}
+			if *f.value, err = prompt(r, f.label, f.fallback, f.secret); err != nil {
+				return err
+			}
+		}
+		fmt.Fprintln(out, "\nChecking...")
+		problems := doctor.Setup(s)
+		if printProblems(problems) == 0 {
+			break
+		}
+		again, err := prompt(r, "\nChange the settings (n writes them anyway)? [Y/n]", "", false)
+		if err != nil {
+			return err
+		}
+		if strings.EqualFold(again, "n") {
+			break
+		}
+		fmt.Fprintln(out)
+	}
+	if err := s.Write(path); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Wrote %s, start pst to use it\n", path)
+	return nil
+}
+
+// prompt reads a line, or a password without echo from a terminal, with
+// fallback for an empty answer.
+func prompt(r *bufio.Reader, label, fallback string, secret bool) (string, error) {
+	switch {
+	case secret && fallback != "":
+		fmt.Fprintf(out, "%s [unchanged]: ", label)
+	case secret || fallback == "":
+		fmt.Fprintf(out, "%s: ", label)
+	default:
+		fmt.Fprintf(out, "%s [%s]: ", label, fallback)
+	}
+	var line string
+	if fd := int(os.Stdin.Fd()); secret && term.IsTerminal(fd) {
+		b, err := term.ReadPassword(fd)
+		fmt.Fprintln(out)
+		if err != nil {
+			return "", err
+		}
+		line = string(b)
+	} else {
+		var err error
+		if line, err = r.ReadString('\n'); err != nil && (!errors.Is(err, io.EOF) || line == "") {
+			return "", errors.New("setup cancelled")
+		}
+	}
+	if line = strings.TrimSpace(line); line == "" {
+		return fallback, nil
+	}
+	return line, nil
+}
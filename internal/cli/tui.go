@@ -0,0 +1,394 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/zaigie/palworld-server-tool/client"
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"golang.org/x/term"
+)
+
+type tuiMode int
+
+const (
+	modeBrowse tuiMode = iota
+	modeConfirm
+	modeInput
+)
+
+type snapshot struct {
+	players []database.OnlinePlayer
+	metrics client.Metrics
+	err     error
+	time    time.Time
+}
+
+// tui draws online players and server metrics, refreshed every interval, with
+// keys for the usual actions and a ":" command palette.
+type tui struct {
+	b        backend
+	interval time.Duration
+
+	data     snapshot
+	selected int
+
+	mode   tuiMode
+	prompt string
+	input  []rune
+	submit func(input string) (string, error)
+	status string
+
+	snapshots chan snapshot
+	results   chan string
+}
+
+func tuiCommand(b backend, opts options, args []string) error {
+	if len(args) != 0 || opts.interval <= 0 {
+		return errors.New("usage: pst tui [-interval seconds]")
+	}
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return errors.New("tui needs a terminal")
+	}
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return err
+	}
+	// alternate screen and hidden cursor, restored on the way out
+	fmt.Print("\x1b[?1049h\x1b[?25l")
+	defer func() {
+		fmt.Print("\x1b[?25h\x1b[?1049l")
+		term.Restore(fd, state)
+	}()
+
+	t := &tui{
+		b:         b,
+		interval:  time.Duration(opts.interval) * time.Second,
+		snapshots: make(chan snapshot, 1),
+		results:   make(chan string, 1),
+		status:    "Loading...",
+	}
+	return t.loop()
+}
+
+func (t *tui) loop() error {
+	keys := make(chan []byte)
+	go func() {
+		buf := make([]byte, 16)
+		for {
+			n, err := os.Stdin.Read(buf)
+			if err != nil {
+				close(keys)
+				return
+			}
+			for _, key := range splitKeys(buf[:n]) {
+				keys <- key
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(t.interval)
+	defer ticker.Stop()
+	go t.refresh()
+	for {
+		t.render()
+		select {
+		case key, ok := <-keys:
+			if !ok {
+				return nil
+			}
+			if quit := t.handleKey(key); quit {
+				return nil
+			}
+		case s := <-t.snapshots:
+			t.data = s
+			if t.selected >= len(s.players) {
+				t.selected = len(s.players) - 1
+			}
+			if t.selected < 0 {
+				t.selected = 0
+			}
+			if t.status == "Loading..." {
+				t.status = ""
+			}
+		case status := <-t.results:
+			t.status = status
+			go t.refresh()
+		case <-ticker.C:
+			go t.refresh()
+		}
+	}
+}
+
+// splitKeys separates the keys of one read, which holds several when typed
+// fast or pasted. Escape sequences stay whole.
+func splitKeys(b []byte) [][]byte {
+	var keys [][]byte
+	for len(b) > 0 {
+		size := 1
+		switch {
+		case b[0] == 27 && len(b) >= 3 && b[1] == '[':
+			size = 3
+		case b[0] >= utf8.RuneSelf:
+			_, size = utf8.DecodeRune(b)
+		}
+		key := make([]byte, size)
+		copy(key, b[:size])
+		keys = append(keys, key)
+		b = b[size:]
+	}
+	return keys
+}
+
+func (t *tui) refresh() {
+	var s snapshot
+	s.time = time.Now()
+	s.players, s.err = t.b.ListOnlinePlayers()
+	if s.err == nil {
+		s.metrics, s.err = t.b.Metrics()
+	}
+	t.snapshots <- s
+}
+
+// run executes an action in the background and reports it on the status line.
+func (t *tui) run(action func() (string, error)) {
+	t.status = "Working..."
+	go func() {
+		msg, err := action()
+		if err != nil {
+			msg = fmt.Sprintf("Error: %v", err)
+		}
+		t.results <- msg
+	}()
+}
+
+func (t *tui) handleKey(key []byte) (quit bool) {
+	switch t.mode {
+	case modeConfirm:
+		if key[0] == 'y' || key[0] == 'Y' {
+			submit := t.submit
+			t.run(func() (string, error) { return submit("") })
+		} else {
+			t.status = "Cancelled"
+		}
+		t.mode = modeBrowse
+		return false
+	case modeInput:
+		switch {
+		case key[0] == 27:
+			t.mode = modeBrowse
+			t.status = "Cancelled"
+		case key[0] == '\r' || key[0] == '\n':
+			submit, input := t.submit, string(t.input)
+			t.mode = modeBrowse
+			t.run(func() (string, error) { return submit(input) })
+		case key[0] == 127 || key[0] == 8:
+			if len(t.input) > 0 {
+				t.input = t.input[:len(t.input)-1]
+			}
+		case key[0] >= 32:
+			t.input = append(t.input, []rune(string(key))...)
+		}
+		return false
+	}
+
+	switch {
+	case key[0] == 'q' || key[0] == 3:
+		return true
+	case len(key) >= 3 && key[0] == 27 && key[1] == '[':
+		switch key[2] {
+		case 'A':
+			if t.selected > 0 {
+				t.selected--
+			}
+		case 'B':
+			if t.selected < len(t.data.players)-1 {
+				t.selected++
+			}
+		}
+	case key[0] == 'r':
+		t.status = "Refreshing..."
+		go t.refresh()
+	case key[0] == 'k' || key[0] == 'b':
+		player, ok := t.selectedPlayer()
+		if !ok {
+			t.status = "No player selected"
+			return false
+		}
+		verb, action := "Kick", t.b.KickPlayer
+		if key[0] == 'b' {
+			verb, action = "Ban", t.b.BanPlayer
+		}
+		t.mode = modeConfirm
+		t.prompt = fmt.Sprintf("%s %s? (y/n)", verb, player.Nickname)
+		t.submit = func(string) (string, error) {
+			if err := action(player.PlayerUid); err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("%s %s done", verb, player.Nickname), nil
+		}
+	case key[0] == 'm':
+		t.startInput("Broadcast: ", func(input string) (string, error) {
+			return t.broadcast(input)
+		})
+	case key[0] == ':':
+		t.startInput(":", t.palette)
+	}
+	return false
+}
+
+func (t *tui) startInput(prompt string, submit func(string) (string, error)) {
+	t.mode = modeInput
+	t.prompt = prompt
+	t.input = t.input[:0]
+	t.submit = submit
+}
+
+func (t *tui) selectedPlayer() (database.OnlinePlayer, bool) {
+	if t.selected < 0 || t.selected >= len(t.data.players) {
+		return database.OnlinePlayer{}, false
+	}
+	return t.data.players[t.selected], true
+}
+
+func (t *tui) broadcast(message string) (string, error) {
+	if strings.TrimSpace(message) == "" {
+		return "", errors.New("message is required")
+	}
+	if err := t.b.Broadcast(message); err != nil {
+		return "", err
+	}
+	return "Broadcast sent", nil
+}
+
+// palette runs the commands typed after ":".
+func (t *tui) palette(input string) (string, error) {
+	fields := strings.Fields(input)
+	if len(fields) == 0 {
+		return "", nil
+	}
+	arg := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(input), fields[0]))
+	playerAction := func(action func(string) error, done string) (string, error) {
+		if arg == "" {
+			return "", errors.New("player_uid is required")
+		}
+		if err := action(arg); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s %s", done, arg), nil
+	}
+	switch fields[0] {
+	case "kick":
+		return playerAction(t.b.KickPlayer, "Kicked")
+	case "ban":
+		return playerAction(t.b.BanPlayer, "Banned")
+	case "unban":
+		return playerAction(t.b.UnbanPlayer, "Unbanned")
+	case "broadcast":
+		return t.broadcast(arg)
+	case "backup":
+		backup, err := t.b.Backup()
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Backup saved to %s", backup.Path), nil
+	default:
+		return "", fmt.Errorf("unknown command %s, try kick, ban, unban, broadcast or backup", fields[0])
+	}
+}
+
+func (t *tui) render() {
+	width, height, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil || width <= 0 || height <= 0 {
+		width, height = 80, 24
+	}
+	var lines []string
+	m := t.data.metrics
+	lines = append(lines, fmt.Sprintf("\x1b[1mPalworld Server Tool\x1b[0m  FPS %d  Frame %.1fms  Players %d/%d  Uptime %s  Day %d",
+		m.ServerFps, m.ServerFrameTime, m.CurrentPlayerNum, m.MaxPlayerNum, time.Duration(m.Uptime)*time.Second, m.Days))
+	if t.data.err != nil {
+		lines = append(lines, fmt.Sprintf("\x1b[31m%v\x1b[0m", t.data.err))
+	} else if !t.data.time.IsZero() {
+		lines = append(lines, fmt.Sprintf("Updated %s", t.data.time.Format("15:04:05")))
+	} else {
+		lines = append(lines, "")
+	}
+	lines = append(lines, "", fmt.Sprintf("\x1b[7m  %-24s %-36s %-6s %-8s %s\x1b[0m", "NICKNAME", "PLAYER_UID", "LEVEL", "PING", "LOCATION"))
+
+	// keep the selected row visible, the rest of the screen is header and footer
+	rows := height - len(lines) - 3
+	if rows < 1 {
+		rows = 1
+	}
+	start := 0
+	if t.selected >= rows {
+		start = t.selected - rows + 1
+	}
+	for i := start; i < len(t.data.players) && i < start+rows; i++ {
+		p := t.data.players[i]
+		cursor := "  "
+		if i == t.selected {
+			cursor = "> "
+		}
+		row := fmt.Sprintf("%s%-24s %-36s %-6d %-8.0f %.0f,%.0f", cursor, p.Nickname, p.PlayerUid, p.Level, p.Ping, p.LocationX, p.LocationY)
+		if i == t.selected {
+			row = "\x1b[1m" + row + "\x1b[0m"
+		}
+		lines = append(lines, row)
+	}
+	for len(lines) < height-2 {
+		lines = append(lines, "")
+	}
+
+	switch t.mode {
+	case modeConfirm:
+		lines = append(lines, t.prompt)
+	case modeInput:
+		lines = append(lines, t.prompt+string(t.input)+"_")
+	default:
+		lines = append(lines, t.status)
+	}
+	lines = append(lines, "\x1b[2m↑/↓ select  k kick  b ban  m broadcast  : command  r refresh  q quit\x1b[0m")
+
+	var sb strings.Builder
+	sb.WriteString("\x1b[H\x1b[2J")
+	for i, line := range lines {
+		if i > 0 {
+			sb.WriteString("\r\n")
+		}
+		sb.WriteString(truncate(line, width))
+	}
+	fmt.Print(sb.String())
+}
+
+// truncate cuts the line to width runes, escape sequences don't count.
+func truncate(line string, width int) string {
+	var sb strings.Builder
+	visible := 0
+	for i := 0; i < len(line); {
+		if line[i] == 27 {
+			end := strings.IndexByte(line[i:], 'm')
+			if end < 0 {
+				break
+			}
+			sb.WriteString(line[i : i+end+1])
+			i += end + 1
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(line[i:])
+		if visible < width {
+			sb.WriteRune(r)
+		}
+		visible++
+		i += size
+	}
+	if visible > width {
+		sb.WriteString("\x1b[0m")
+	}
+	return sb.String()
+}
@@ -0,0 +1,372 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// Problem is something wrong in the config, with what to do about it.
+type Problem struct {
+	Key     string
+	Message string
+	Fix     string
+	// Warning problems don't stop pst from working, like an ignored unknown
+	// key
+	Warning bool
+}
+
+func (p Problem) String() string {
+	if p.Fix == "" {
+		return fmt.Sprintf("%s: %s", p.Key, p.Message)
+	}
+	return fmt.Sprintf("%s: %s, %s", p.Key, p.Message, p.Fix)
+}
+
+// Check validates the loaded config against the fields of Config, finding
+// keys pst doesn't know, like misspelled ones, and values it can't use. It
+// doesn't connect to anything.
+func Check() []Problem {
+	var problems []Problem
+	known := make(map[string]bool)
+	collectKeys(reflect.TypeOf(Config{}), "", known)
+	checkKeys(viper.AllSettings(), reflect.TypeOf(Config{}), "", known, &problems)
+	sort.Slice(problems, func(i, j int) bool { return problems[i].Key < problems[j].Key })
+	return append(problems, checkValues(viper.GetViper())...)
+}
+
+// fieldName is the config key of a struct field, which mapstructure matches
+// case-insensitively to the field name when it has no tag.
+func fieldName(f reflect.StructField) string {
+	if tag, _, _ := strings.Cut(f.Tag.Get("mapstructure"), ","); tag != "" {
+		return tag
+	}
+	return strings.ToLower(f.Name)
+}
+
+func collectKeys(t reflect.Type, prefix string, known map[string]bool) {
+	switch t.Kind() {
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			key := prefix + fieldName(t.Field(i))
+			known[key] = true
+			collectKeys(t.Field(i).Type, key+".", known)
+		}
+	case reflect.Slice:
+		collectKeys(t.Elem(), prefix, known)
+	}
+}
+
+// checkKeys reports the keys of settings without a field in t, maps take
+// any key and each item of a list of sections is checked on its own.
+func checkKeys(settings any, t reflect.Type, key string, known map[string]bool, problems *[]Problem) {
+	switch t.Kind() {
+	case reflect.Struct:
+		values := toStringMap(settings)
+		if values == nil {
+			return
+		}
+		fields := make(map[string]reflect.Type, t.NumField())
+		for i := 0; i < t.NumField(); i++ {
+			fields[fieldName(t.Field(i))] = t.Field(i).Type
+		}
+		for name, value := range values {
+			path := joinKey(key, name)
+			ft, ok := fields[strings.ToLower(name)]
+			if !ok {
+				*problems = append(*problems, unknownKey(path, known))
+				continue
+			}
+			checkKeys(value, ft, path, known, problems)
+		}
+	case reflect.Map:
+		for name, value := range toStringMap(settings) {
+			checkKeys(value, t.Elem(), joinKey(key, name), known, problems)
+		}
+	case reflect.Slice:
+		if t.Elem().Kind() != reflect.Struct {
+			return
+		}
+		if items, ok := settings.([]any); ok {
+			for i, item := range items {
+				checkKeys(item, t.Elem(), fmt.Sprintf("%s[%d]", key, i), known, problems)
+			}
+		}
+	}
+}
+
+func toStringMap(v any) map[string]any {
+	switch m := v.(type) {
+	case map[string]any:
+		return m
+	case map[any]any:
+		values := make(map[string]any, len(m))
+		for k, v := range m {
+			values[fmt.Sprint(k)] = v
+		}
+		return values
+	}
+	return nil
+}
+
+func joinKey(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
+
+// unknownKey suggests the known key closest to a misspelled one, in the
+// same section or with the same name in another.
+func unknownKey(key string, known map[string]bool) Problem {
+	problem := Problem{Key: key, Message: "unknown key, it is ignored", Warning: true}
+	// items of lists are checked with the key of the list
+	plain := key
+	for {
+		open := strings.IndexByte(plain, '[')
+		if open < 0 {
+			break
+		}
+		plain = plain[:open] + plain[open+strings.IndexByte(plain[open:], ']')+1:]
+	}
+	section, name := "", plain
+	if i := strings.LastIndexByte(plain, '.'); i >= 0 {
+		section, name = plain[:i+1], plain[i+1:]
+	}
+	best, bestDistance := "", 3
+	for candidate := range known {
+		candidateSection, candidateName := "", candidate
+		if i := strings.LastIndexByte(candidate, '.'); i >= 0 {
+			candidateSection, candidateName = candidate[:i+1], candidate[i+1:]
+		}
+		distance := 0
+		if candidateSection != section {
+			if candidateName != name {
+				continue
+			}
+			distance = 2
+		} else {
+			distance = editDistance(name, candidateName)
+		}
+		if distance < bestDistance || distance == bestDistance && candidate < best {
+			best, bestDistance = candidate, distance
+		}
+	}
+	if strings.HasPrefix(best, section) && section != "" {
+		// keep the list indexes of the key
+		best = key[:strings.LastIndexByte(key, '.')+1] + best[len(section):]
+	}
+	if best != "" {
+		problem.Fix = fmt.Sprintf("did you mean %s?", best)
+	} else {
+		problem.Fix = "remove it or check example/config.yaml for the right name"
+	}
+	return problem
+}
+
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+// checkValues finds values pst can't work with, the types were already
+// checked decoding the config.
+func checkValues(cfg *viper.Viper) []Problem {
+	var problems []Problem
+	add := func(key, message, fix string, warning bool) {
+		problems = append(problems, Problem{Key: key, Message: message, Fix: fix, Warning: warning})
+	}
+
+	if cfg.GetString("web.password") == "" {
+		add("web.password", "is empty, pst doesn't start without it since it is the key login tokens are signed with", "set a password", false)
+	}
+	if port := cfg.GetInt("web.port"); port < 1 || port > 65535 {
+		add("web.port", fmt.Sprintf("%d is not a port", port), "use a port between 1 and 65535, default 8080", false)
+	}
+	if cfg.GetBool("web.tls") {
+		for _, key := range []string{"web.cert_path", "web.key_path"} {
+			if cfg.GetString(key) == "" {
+				add(key, "is empty with web.tls on", "set the PEM file or turn web.tls off", false)
+			}
+		}
+	}
+
+	if cfg.GetBool("web.steam_login") && !isHttpUrl(cfg.GetString("web.public_url")) {
+		add("web.public_url", "is not an http url with web.steam_login on, Steam can't send players back", "set it to the url players open pst at, like https://pst.example.com", false)
+	}
+	for i, proxy := range cfg.GetStringSlice("web.trusted_proxies") {
+		if net.ParseIP(proxy) == nil {
+			if _, _, err := net.ParseCIDR(proxy); err != nil {
+				add(fmt.Sprintf("web.trusted_proxies[%d]", i), fmt.Sprintf("%q is not an ip or CIDR", proxy), "use the address of the reverse proxy like 127.0.0.1 or 10.0.0.0/8", false)
+			}
+		}
+	}
+	if cfg.GetBool("bot.enable") && cfg.GetString("bot.secret") == "" {
+		add("bot.secret", "is empty with bot.enable on, every bot event is refused since anyone could send one as an admin", "set it to the secret of the OneBot implementation", false)
+	}
+	// answers are kept by question, a repeated one is asked once
+	asked := make(map[string]bool)
+	for i, question := range cfg.GetStringSlice("whitelist_application.questions") {
+		key := fmt.Sprintf("whitelist_application.questions[%d]", i)
+		if strings.TrimSpace(question) == "" {
+			add(key, "is empty", "remove it or write the question", false)
+		} else if asked[question] {
+			add(key, fmt.Sprintf("%q is asked before", question), "remove the repeated question", true)
+		}
+		asked[question] = true
+	}
+
+	if address := cfg.GetString("rcon.address"); address == "" {
+		add("rcon.address", "is empty", "set it to the RCONPort of the server like 127.0.0.1:25575", false)
+	} else if !isHostPort(address) {
+		add("rcon.address", fmt.Sprintf("%q is not host:port", address), "use the form 127.0.0.1:25575", false)
+	}
+	for i, address := range fallbackAddresses(cfg, "rcon") {
+		if !isHostPort(address) {
+			add(fmt.Sprintf("rcon.fallbacks[%d].address", i), fmt.Sprintf("%q is not host:port", address), "use the form 203.0.113.10:25575", false)
+		}
+	}
+	if cfg.GetString("rcon.password") == "" {
+		add("rcon.password", "is empty, RCON refuses it", "set it to AdminPassword of PalWorldSettings.ini", false)
+	}
+
+	if address := cfg.GetString("rest.address"); address == "" {
+		add("rest.address", "is empty", "set it to the RESTAPIPort of the server like http://127.0.0.1:8212", false)
+	} else if !isHttpUrl(address) {
+		add("rest.address", fmt.Sprintf("%q is not an http url", address), "use the form http://127.0.0.1:8212", false)
+	}
+	for i, address := range fallbackAddresses(cfg, "rest") {
+		if !isHttpUrl(address) {
+			add(fmt.Sprintf("rest.fallbacks[%d].address", i), fmt.Sprintf("%q is not an http url", address), "use the form http://203.0.113.10:8212", false)
+		}
+	}
+	if cfg.GetString("rest.password") == "" {
+		add("rest.password", "is empty, the REST API refuses it", "set it to AdminPassword of PalWorldSettings.ini", false)
+	}
+
+	if path := cfg.GetString("save.path"); path == "" || path == "/path/to/your/Pal/Saved" {
+		add("save.path", "is not set, players and guilds won't be synced", "set it to the Pal/Saved directory of the server", false)
+	}
+	if format := cfg.GetString("save.backup_format"); format != "zip" && format != "chunks" {
+		add("save.backup_format", fmt.Sprintf("%q is not a backup format", format), "use zip or chunks", false)
+	}
+
+	if name := cfg.GetString("task.timezone"); name != "" {
+		if _, err := time.LoadLocation(name); err != nil {
+			add("task.timezone", fmt.Sprintf("%q is not a time zone", name), "use an IANA name like Asia/Shanghai or UTC, empty for the host's", false)
+		}
+	}
+
+	for _, key := range []string{"web.login_window", "web.player_token_hours", "task.sync_interval", "rcon.timeout", "rest.timeout", "save.sync_interval", "save.backup_interval", "save.backup_keep_days", "save.backup_gc_interval", "save.journal_keep", "save.clock_skew_tolerance", "metrics.interval", "manage.storage_withdrawal", "manage.storage_keep_days", "db_shipping.interval", "db_shipping.snapshot_interval", "db_shipping.keep_days", "debug.storage_sample_interval", "debug.storage_sample_keep_days", "whitelist_application.rate_limit", "scripts.max_instructions", "scripts.max_string_bytes"} {
+		if cfg.GetInt(key) < 0 {
+			add(key, fmt.Sprintf("%d is negative", cfg.GetInt(key)), "use 0 or more", false)
+		}
+	}
+	for class := range cfg.GetStringMap("request_policy") {
+		switch class {
+		case "query", "action", "save", "shutdown":
+			for _, name := range []string{"timeout", "retries", "backoff"} {
+				if key := "request_policy." + class + "." + name; cfg.GetFloat64(key) < 0 {
+					add(key, "is negative", "use 0 or more", false)
+				}
+			}
+		default:
+			add("request_policy."+class, "is not a request class", "use query, action, save or shutdown", true)
+		}
+	}
+	if target := cfg.GetString("db_shipping.target"); strings.Contains(target, "://") && !strings.HasPrefix(target, "file://") && !isHttpUrl(target) {
+		add("db_shipping.target", fmt.Sprintf("%q is not a directory or WebDAV url", target), "use a path or an http(s) url", false)
+	}
+	var webhooks []struct {
+		DigestInterval int    `mapstructure:"digest_interval"`
+		Verbosity      string `mapstructure:"verbosity"`
+	}
+	cfg.UnmarshalKey("notify.webhooks", &webhooks)
+	for i, webhook := range webhooks {
+		if webhook.DigestInterval < 0 {
+			add(fmt.Sprintf("notify.webhooks[%d].digest_interval", i), fmt.Sprintf("%d is negative", webhook.DigestInterval), "use 0 or more", false)
+		}
+		switch webhook.Verbosity {
+		case "", "full", "names", "count":
+		default:
+			add(fmt.Sprintf("notify.webhooks[%d].verbosity", i), fmt.Sprintf("%q is not a verbosity", webhook.Verbosity), "use full, names or count", false)
+		}
+	}
+	var badges []struct {
+		Id     string `mapstructure:"id"`
+		Metric string `mapstructure:"metric"`
+	}
+	cfg.UnmarshalKey("badges.rules", &badges)
+	badgeIds := make(map[string]bool)
+	for i, badge := range badges {
+		key := fmt.Sprintf("badges.rules[%d]", i)
+		switch {
+		case badge.Id == "":
+			add(key+".id", "is empty", "give the badge an id, earned badges are stored by it", false)
+		case badgeIds[badge.Id]:
+			add(key+".id", fmt.Sprintf("%q is used twice", badge.Id), "give every badge its own id", false)
+		}
+		badgeIds[badge.Id] = true
+		switch badge.Metric {
+		case "level", "pals", "technology", "playtime_hours", "streak_days":
+		default:
+			add(key+".metric", fmt.Sprintf("%q is not a metric", badge.Metric), "use level, pals, technology, playtime_hours or streak_days", false)
+		}
+	}
+	for _, key := range []string{"rcon.timeout", "rest.timeout"} {
+		if cfg.GetInt(key) == 0 {
+			add(key, "is 0, requests won't time out", "use a few seconds, default 5", true)
+		}
+	}
+	return problems
+}
+
+func isHostPort(address string) bool {
+	_, port, err := net.SplitHostPort(address)
+	return err == nil && isPort(port)
+}
+
+func isHttpUrl(address string) bool {
+	u, err := url.Parse(address)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// fallbackAddresses are the addresses of rcon.fallbacks or rest.fallbacks.
+func fallbackAddresses(cfg *viper.Viper, section string) []string {
+	var fallbacks []struct {
+		Address string `mapstructure:"address"`
+	}
+	cfg.UnmarshalKey(section+".fallbacks", &fallbacks)
+	addresses := make([]string, len(fallbacks))
+	for i, f := range fallbacks {
+		addresses[i] = f.Address
+	}
+	return addresses
+}
+
+func isPort(s string) bool {
+	port, err := strconv.Atoi(s)
+	return err == nil && port > 0 && port <= 65535
+}
@@ -1,7 +1,7 @@
 package config
 
 import (
-	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 	"github.com/zaigie/palworld-server-tool/internal/logger"
@@ -15,34 +15,357 @@ type Config struct {
 		CertPath  string `mapstructure:"cert_path"`
 		KeyPath   string `mapstructure:"key_path"`
 		PublicUrl string `mapstructure:"public_url"`
+		Graphql   bool   `mapstructure:"graphql"`
+		// LoginAttempts is how many failed logins an ip gets per LoginWindow
+		// seconds, 0 is unlimited
+		LoginAttempts int `mapstructure:"login_attempts"`
+		LoginWindow   int `mapstructure:"login_window"`
+		// UiDir serves the web UI from a directory, falling back to the
+		// embedded one for files it lacks
+		UiDir string `mapstructure:"ui_dir"`
+		// SteamLogin lets players sign in with Steam at PublicUrl, for
+		// PlayerTokenHours
+		SteamLogin       bool `mapstructure:"steam_login"`
+		PlayerTokenHours int  `mapstructure:"player_token_hours"`
+		// TrustedProxies are the ips or CIDRs of the reverse proxies whose
+		// X-Forwarded-For is believed, without any the client ip is the
+		// address of the connection
+		TrustedProxies []string `mapstructure:"trusted_proxies"`
 	} `mapstructure:"web"`
 	Task struct {
-		SyncInterval        int    `mapstructure:"sync_interval"`
-		PlayerLogging       bool   `mapstructure:"player_logging"`
-		PlayerLoginMessage  string `mapstructure:"player_login_message"`
-		PlayerLogoutMessage string `mapstructure:"player_logout_message"`
+		SyncInterval         int               `mapstructure:"sync_interval"`
+		PlayerLogging        bool              `mapstructure:"player_logging"`
+		PlayerLoginMessage   string            `mapstructure:"player_login_message"`
+		PlayerLogoutMessage  string            `mapstructure:"player_logout_message"`
+		EventAnnounceMessage string            `mapstructure:"event_announce_message"`
+		EventStartMessage    string            `mapstructure:"event_start_message"`
+		EventEndMessage      string            `mapstructure:"event_end_message"`
+		Cron                 map[string]string `mapstructure:"cron"`
+		// Timezone is the IANA time zone the cron tasks, reports, templates
+		// and API times use, the host's when empty
+		Timezone string `mapstructure:"timezone"`
 	} `mapstructure:"task"`
 	Rcon struct {
 		Address   string `mapstructure:"address"`
 		Password  string `mapstructure:"password"`
 		UseBase64 bool   `mapstructure:"use_base64"`
 		Timeout   int    `mapstructure:"timeout"`
+		// Fallbacks are tried in order when address can't be reached, for
+		// FailoverCooldown seconds before it is tried first again
+		Fallbacks []struct {
+			Address string `mapstructure:"address"`
+			Timeout int    `mapstructure:"timeout"`
+		} `mapstructure:"fallbacks"`
+		FailoverCooldown int `mapstructure:"failover_cooldown"`
 	} `mapstructure:"rcon"`
 	Rest struct {
 		Address  string `mapstructure:"address"`
 		Username string `mapstructure:"username"`
 		Password string `mapstructure:"password"`
 		Timeout  int    `mapstructure:"timeout"`
+		// Fallbacks and FailoverCooldown work like those of rcon
+		Fallbacks []struct {
+			Address string `mapstructure:"address"`
+			Timeout int    `mapstructure:"timeout"`
+		} `mapstructure:"fallbacks"`
+		FailoverCooldown int `mapstructure:"failover_cooldown"`
 	} `mapstructure:"rest"`
+	// RequestPolicy is the timeout in seconds, retries and backoff of the
+	// requests to the game server by class: query, action, save and shutdown
+	RequestPolicy map[string]struct {
+		Timeout int     `mapstructure:"timeout"`
+		Retries int     `mapstructure:"retries"`
+		Backoff float64 `mapstructure:"backoff"`
+	} `mapstructure:"request_policy"`
 	Save struct {
 		Path           string `mapstructure:"path"`
 		DecodePath     string `mapstructure:"decode_path"`
 		SyncInterval   int    `mapstructure:"sync_interval"`
 		BackupInterval int    `mapstructure:"backup_interval"`
 		BackupKeepDays int    `mapstructure:"backup_keep_days"`
+		BackupFormat   string `mapstructure:"backup_format"`
+		// BackupKey is a base64 or hex AES-256 key encrypting backups
+		BackupKey     string `mapstructure:"backup_key"`
+		BackupKeyFile string `mapstructure:"backup_key_file"`
+		// BackupGcInterval is how often chunks no backup uses are removed
+		BackupGcInterval int `mapstructure:"backup_gc_interval"`
+		// JournalKeep is how many sync batches are kept to roll back or replay
+		JournalKeep int `mapstructure:"journal_keep"`
+		// ClockSkewTolerance is how far in seconds the save may be ahead of
+		// pst's clock before its times are taken as pst's
+		ClockSkewTolerance int `mapstructure:"clock_skew_tolerance"`
 	} `mapstructure:"save"`
+	Server struct {
+		SettingsPath     string `mapstructure:"settings_path"`
+		StartCommand     string `mapstructure:"start_command"`
+		StopCommand      string `mapstructure:"stop_command"`
+		UpdateCommand    string `mapstructure:"update_command"`
+		CommandTimeout   int    `mapstructure:"command_timeout"`
+		StartTimeout     int    `mapstructure:"start_timeout"`
+		CountdownMessage string `mapstructure:"countdown_message"`
+	} `mapstructure:"server"`
+	Update struct {
+		Check             bool   `mapstructure:"check"`
+		CheckInterval     int    `mapstructure:"check_interval"`
+		AppId             int    `mapstructure:"app_id"`
+		Branch            string `mapstructure:"branch"`
+		InfoUrl           string `mapstructure:"info_url"`
+		ManifestPath      string `mapstructure:"manifest_path"`
+		AutoUpdate        bool   `mapstructure:"auto_update"`
+		MaintenanceWindow string `mapstructure:"maintenance_window"`
+		Countdown         int    `mapstructure:"countdown"`
+	} `mapstructure:"update"`
+	Mods struct {
+		ServerDir string `mapstructure:"server_dir"`
+	} `mapstructure:"mods"`
+	Map struct {
+		Version         string `mapstructure:"version"`
+		TilesDir        string `mapstructure:"tiles_dir"`
+		TilesUrl        string `mapstructure:"tiles_url"`
+		MaxZoom         int    `mapstructure:"max_zoom"`
+		HeatmapGrid     int    `mapstructure:"heatmap_grid"`
+		HeatmapKeepDays int    `mapstructure:"heatmap_keep_days"`
+		// SpawnX and SpawnY are the world position distances are measured from
+		SpawnX  float64 `mapstructure:"spawn_x"`
+		SpawnY  float64 `mapstructure:"spawn_y"`
+		Regions []struct {
+			Name string  `mapstructure:"name"`
+			MinX float64 `mapstructure:"min_x"`
+			MinY float64 `mapstructure:"min_y"`
+			MaxX float64 `mapstructure:"max_x"`
+			MaxY float64 `mapstructure:"max_y"`
+		} `mapstructure:"regions"`
+	} `mapstructure:"map"`
+	Notify struct {
+		Webhooks []struct {
+			Url    string   `mapstructure:"url"`
+			Events []string `mapstructure:"events"`
+			Type   string   `mapstructure:"type"`
+			Secret string   `mapstructure:"secret"`
+			// DigestInterval batches joins and leaves, in seconds
+			DigestInterval int    `mapstructure:"digest_interval"`
+			Verbosity      string `mapstructure:"verbosity"`
+		} `mapstructure:"webhooks"`
+		Email struct {
+			Host     string   `mapstructure:"host"`
+			Port     int      `mapstructure:"port"`
+			Username string   `mapstructure:"username"`
+			Password string   `mapstructure:"password"`
+			From     string   `mapstructure:"from"`
+			To       []string `mapstructure:"to"`
+			Security string   `mapstructure:"security"`
+			Events   []string `mapstructure:"events"`
+			Digest   bool     `mapstructure:"digest"`
+			Template string   `mapstructure:"template"`
+		} `mapstructure:"email"`
+	} `mapstructure:"notify"`
+	Bot struct {
+		Enable    bool    `mapstructure:"enable"`
+		Secret    string  `mapstructure:"secret"`
+		Prefix    string  `mapstructure:"prefix"`
+		RateLimit int     `mapstructure:"rate_limit"`
+		Admins    []int64 `mapstructure:"admins"`
+		Groups    []struct {
+			GroupId int64   `mapstructure:"group_id"`
+			Level   string  `mapstructure:"level"`
+			Admins  []int64 `mapstructure:"admins"`
+		} `mapstructure:"groups"`
+	} `mapstructure:"bot"`
+	Rank struct {
+		Announce bool   `mapstructure:"announce"`
+		Message  string `mapstructure:"message"`
+		Ranks    []struct {
+			Name  string  `mapstructure:"name"`
+			Hours float64 `mapstructure:"hours"`
+			Tag   string  `mapstructure:"tag"`
+		} `mapstructure:"ranks"`
+	} `mapstructure:"rank"`
+	Badges struct {
+		Announce bool   `mapstructure:"announce"`
+		Message  string `mapstructure:"message"`
+		Rules    []struct {
+			Id          string  `mapstructure:"id"`
+			Name        string  `mapstructure:"name"`
+			Description string  `mapstructure:"description"`
+			Metric      string  `mapstructure:"metric"`
+			Value       float64 `mapstructure:"value"`
+			First       bool    `mapstructure:"first"`
+		} `mapstructure:"rules"`
+	} `mapstructure:"badges"`
+	IpReputation struct {
+		Enable         bool     `mapstructure:"enable"`
+		Lists          []string `mapstructure:"lists"`
+		ProviderUrl    string   `mapstructure:"provider_url"`
+		ProviderFields []string `mapstructure:"provider_fields"`
+		CacheHours     int      `mapstructure:"cache_hours"`
+		Action         string   `mapstructure:"action"`
+		Exempt         []string `mapstructure:"exempt"`
+	} `mapstructure:"ip_reputation"`
+	Hooks []struct {
+		Name    string   `mapstructure:"name"`
+		Events  []string `mapstructure:"events"`
+		Command string   `mapstructure:"command"`
+		Url     string   `mapstructure:"url"`
+		Timeout int      `mapstructure:"timeout"`
+	} `mapstructure:"hooks"`
+	Metrics struct {
+		Interval   int     `mapstructure:"interval"`
+		KeepDays   int     `mapstructure:"keep_days"`
+		LowFps     float64 `mapstructure:"low_fps"`
+		LowSamples int     `mapstructure:"low_samples"`
+	} `mapstructure:"metrics"`
+	Ping struct {
+		History     int     `mapstructure:"history"`
+		HighMs      float64 `mapstructure:"high_ms"`
+		HighSamples int     `mapstructure:"high_samples"`
+	} `mapstructure:"ping"`
+	Points struct {
+		PerMinute int64 `mapstructure:"per_minute"`
+	} `mapstructure:"points"`
+	Rewards []struct {
+		Name     string   `mapstructure:"name"`
+		Cost     int64    `mapstructure:"cost"`
+		Cooldown int      `mapstructure:"cooldown"`
+		Commands []string `mapstructure:"commands"`
+	} `mapstructure:"rewards"`
+	Admin struct {
+		// Commands are the RCON commands of admin actions by action name
+		Commands   map[string]string `mapstructure:"commands"`
+		GiveLimits []struct {
+			Group    string   `mapstructure:"group"`
+			Actions  []string `mapstructure:"actions"`
+			Items    []string `mapstructure:"items"`
+			MaxCount int      `mapstructure:"max_count"`
+			MaxExp   int64    `mapstructure:"max_exp"`
+		} `mapstructure:"give_limits"`
+	} `mapstructure:"admin"`
+	Chat struct {
+		LogPath string `mapstructure:"log_path"`
+		Pattern string `mapstructure:"pattern"`
+		Prefix  string `mapstructure:"prefix"`
+	} `mapstructure:"chat"`
+	Vote struct {
+		Enable           bool    `mapstructure:"enable"`
+		MinVotes         int     `mapstructure:"min_votes"`
+		QuorumPercent    float64 `mapstructure:"quorum_percent"`
+		Duration         int     `mapstructure:"duration"`
+		Cooldown         int     `mapstructure:"cooldown"`
+		RestartAction    string  `mapstructure:"restart_action"`
+		RestartCountdown int     `mapstructure:"restart_countdown"`
+		KickAction       string  `mapstructure:"kick_action"`
+	} `mapstructure:"vote"`
+	WhitelistApplication struct {
+		Enable bool `mapstructure:"enable"`
+		// Questions must each be answered to apply
+		Questions []string `mapstructure:"questions"`
+		// RateLimit is the applications an ip can send with the public form
+		// per hour, 0 for no limit
+		RateLimit int `mapstructure:"rate_limit"`
+	} `mapstructure:"whitelist_application"`
+	Scripts struct {
+		Dir             string `mapstructure:"dir"`
+		MaxInstructions int    `mapstructure:"max_instructions"`
+		MaxStringBytes  int    `mapstructure:"max_string_bytes"`
+	} `mapstructure:"scripts"`
+	Locale struct {
+		Default   string `mapstructure:"default"`
+		Broadcast string `mapstructure:"broadcast"`
+		Bot       string `mapstructure:"bot"`
+		Notify    string `mapstructure:"notify"`
+	} `mapstructure:"locale"`
+	Export struct {
+		// Interval is in seconds, 0 exports only when run by hand
+		Interval int `mapstructure:"interval"`
+		Targets  []struct {
+			Type     string `mapstructure:"type"`
+			Url      string `mapstructure:"url"`
+			Database string `mapstructure:"database"`
+			Org      string `mapstructure:"org"`
+			Token    string `mapstructure:"token"`
+			Username string `mapstructure:"username"`
+			Password string `mapstructure:"password"`
+			Command  string `mapstructure:"command"`
+		} `mapstructure:"targets"`
+	} `mapstructure:"export"`
+	Mqtt struct {
+		// Broker is like tcp://host:1883 or ssl://host:8883, empty disables
+		Broker    string `mapstructure:"broker"`
+		ClientId  string `mapstructure:"client_id"`
+		Username  string `mapstructure:"username"`
+		Password  string `mapstructure:"password"`
+		KeepAlive int    `mapstructure:"keep_alive"`
+		Topics    []struct {
+			Events []string `mapstructure:"events"`
+			Topic  string   `mapstructure:"topic"`
+			Qos    int      `mapstructure:"qos"`
+			Retain bool     `mapstructure:"retain"`
+		} `mapstructure:"topics"`
+	} `mapstructure:"mqtt"`
+	Cache struct {
+		// RedisUrl is like redis://:password@host:6379/0, empty keeps the
+		// cache in memory
+		RedisUrl string `mapstructure:"redis_url"`
+		Prefix   string `mapstructure:"prefix"`
+	} `mapstructure:"cache"`
+	DbShipping struct {
+		// Target is a directory or a WebDAV url, empty turns shipping off
+		Target string `mapstructure:"target"`
+		// Interval and SnapshotInterval are in seconds
+		Interval         int `mapstructure:"interval"`
+		SnapshotInterval int `mapstructure:"snapshot_interval"`
+		KeepDays         int `mapstructure:"keep_days"`
+	} `mapstructure:"db_shipping"`
+	Replica struct {
+		// PrimaryUrl or SnapshotPath makes this instance a read-only replica
+		PrimaryUrl   string `mapstructure:"primary_url"`
+		SnapshotPath string `mapstructure:"snapshot_path"`
+		Interval     int    `mapstructure:"interval"`
+	} `mapstructure:"replica"`
+	Trace struct {
+		// Endpoint is the OTLP/HTTP base url like http://localhost:4318,
+		// empty disables tracing
+		Endpoint    string            `mapstructure:"endpoint"`
+		Headers     map[string]string `mapstructure:"headers"`
+		ServiceName string            `mapstructure:"service_name"`
+		SampleRatio float64           `mapstructure:"sample_ratio"`
+	} `mapstructure:"trace"`
+	Debug struct {
+		// CrashReports is how many crash reports are kept
+		CrashReports int `mapstructure:"crash_reports"`
+		// StorageSampleInterval is in seconds, the bucket sizes are sampled
+		// for their growth in /api/debug/storage
+		StorageSampleInterval int `mapstructure:"storage_sample_interval"`
+		StorageSampleKeepDays int `mapstructure:"storage_sample_keep_days"`
+	} `mapstructure:"debug"`
+	Paths struct {
+		// DataDir holds pst.db, default the working directory when pst.db is
+		// there already, otherwise $XDG_DATA_HOME/pst
+		DataDir   string `mapstructure:"data_dir"`
+		BackupDir string `mapstructure:"backup_dir"`
+		CacheDir  string `mapstructure:"cache_dir"`
+		// LogDir gets a pst.log besides stdout when set
+		LogDir string `mapstructure:"log_dir"`
+	} `mapstructure:"paths"`
 	Manage struct {
-		KickNonWhitelist bool `mapstructure:"kick_non_whitelist"`
+		KickNonWhitelist    bool     `mapstructure:"kick_non_whitelist"`
+		BaseRaidStructures  int      `mapstructure:"base_raid_structures"`
+		BaseRaidHpPercent   float64  `mapstructure:"base_raid_hp_percent"`
+		AbandonedBaseDays   int      `mapstructure:"abandoned_base_days"`
+		ReservedSlots       int      `mapstructure:"reserved_slots"`
+		ReservedSlotMessage string   `mapstructure:"reserved_slot_message"`
+		AfkMinutes          int      `mapstructure:"afk_minutes"`
+		AfkKick             bool     `mapstructure:"afk_kick"`
+		AfkWarnMessage      string   `mapstructure:"afk_warn_message"`
+		AfkExempt           []string `mapstructure:"afk_exempt"`
+		MergeUndoHours      int      `mapstructure:"merge_undo_hours"`
+		// OrphanPruneInterval is how often in seconds records of players no
+		// longer in the save are removed, 0 disables
+		OrphanPruneInterval int `mapstructure:"orphan_prune_interval"`
+		// StorageWithdrawal is the items of a kind taken from the chests of a
+		// base camp between two syncs that make a base.storage_withdrawn
+		// event, 0 disables
+		StorageWithdrawal int `mapstructure:"storage_withdrawal"`
+		StorageKeepDays   int `mapstructure:"storage_keep_days"`
 	}
 }
 
@@ -66,25 +389,148 @@ func Init(cfgFile string, conf *Config) {
 	}
 
 	viper.SetDefault("web.port", 8080)
+	viper.SetDefault("web.login_attempts", 0)
+	viper.SetDefault("web.login_window", 600)
+	viper.SetDefault("web.steam_login", false)
+	viper.SetDefault("web.player_token_hours", 168)
+	viper.SetDefault("web.trusted_proxies", []string{})
 
 	viper.SetDefault("task.sync_interval", 60)
+	viper.SetDefault("task.timezone", "")
+	viper.SetDefault("task.cron.email_digest", "0 8 * * *")
+	viper.SetDefault("task.cron.daily_report", "5 0 * * *")
 
 	viper.SetDefault("rcon.timeout", 5)
 	viper.SetDefault("rcon.use_base64", false)
+	viper.SetDefault("rcon.failover_cooldown", 30)
 
 	viper.SetDefault("rest.username", "admin")
 	viper.SetDefault("rest.timeout", 5)
+	viper.SetDefault("rest.failover_cooldown", 30)
+
+	viper.SetDefault("request_policy.query.retries", 1)
+	viper.SetDefault("request_policy.query.backoff", 1)
+	viper.SetDefault("request_policy.action.backoff", 1)
+	viper.SetDefault("request_policy.save.timeout", 60)
+	viper.SetDefault("request_policy.save.retries", 1)
+	viper.SetDefault("request_policy.save.backoff", 5)
+	viper.SetDefault("request_policy.shutdown.timeout", 30)
+	viper.SetDefault("request_policy.shutdown.backoff", 1)
 
 	viper.SetDefault("save.sync_interval", 600)
 	viper.SetDefault("save.backup_interval", 14400)
 	viper.SetDefault("save.backup_keep_days", 7)
+	viper.SetDefault("save.backup_format", "zip")
+	viper.SetDefault("save.backup_gc_interval", 86400)
+	viper.SetDefault("save.backup_key", "")
+	viper.SetDefault("save.backup_key_file", "")
+	viper.SetDefault("save.journal_keep", 10)
+	viper.SetDefault("save.clock_skew_tolerance", 300)
+
+	viper.SetDefault("server.command_timeout", 600)
+	viper.SetDefault("server.start_timeout", 300)
+
+	viper.SetDefault("update.check_interval", 1800)
+	viper.SetDefault("update.app_id", 2394010)
+	viper.SetDefault("update.branch", "public")
+	viper.SetDefault("update.info_url", "https://api.steamcmd.net/v1/info/{app_id}")
+	viper.SetDefault("update.countdown", 300)
+
+	viper.SetDefault("map.version", "embedded")
+	viper.SetDefault("map.max_zoom", 6)
+	viper.SetDefault("map.heatmap_grid", 64)
+	viper.SetDefault("map.heatmap_keep_days", 30)
+
+	viper.SetDefault("notify.email.security", "starttls")
+
+	viper.SetDefault("bot.prefix", "/")
+	viper.SetDefault("bot.rate_limit", 10)
+
+	viper.SetDefault("metrics.interval", 60)
+	viper.SetDefault("metrics.keep_days", 7)
+	viper.SetDefault("metrics.low_samples", 5)
+
+	viper.SetDefault("ping.history", 1440)
+	viper.SetDefault("ping.high_samples", 5)
+
+	viper.SetDefault("chat.pattern", `\[Chat::\w+\]\['(?P<name>.+?)' \(UserId=steam_(?P<steam_id>\d+)[^)]*\)\]: (?P<message>.*)`)
+	viper.SetDefault("chat.prefix", "!")
+
+	viper.SetDefault("vote.min_votes", 3)
+	viper.SetDefault("vote.quorum_percent", 50)
+	viper.SetDefault("vote.duration", 120)
+	viper.SetDefault("vote.cooldown", 300)
+	viper.SetDefault("vote.restart_action", "notify")
+	viper.SetDefault("vote.restart_countdown", 60)
+	viper.SetDefault("vote.kick_action", "notify")
+
+	viper.SetDefault("whitelist_application.enable", false)
+	viper.SetDefault("whitelist_application.questions", []string{})
+	viper.SetDefault("whitelist_application.rate_limit", 3)
+
+	viper.SetDefault("scripts.max_instructions", 1000000)
+	viper.SetDefault("scripts.max_string_bytes", 1<<20)
+
+	viper.SetDefault("ip_reputation.cache_hours", 24)
+	viper.SetDefault("ip_reputation.action", "flag")
+
+	viper.SetDefault("locale.default", "en")
+	viper.SetDefault("export.interval", 0)
+	viper.SetDefault("mqtt.broker", "")
+	viper.SetDefault("mqtt.client_id", "pst")
+	viper.SetDefault("mqtt.keep_alive", 60)
+	viper.SetDefault("cache.redis_url", "")
+	viper.SetDefault("cache.prefix", "pst:")
+	viper.SetDefault("db_shipping.target", "")
+	viper.SetDefault("db_shipping.interval", 60)
+	viper.SetDefault("db_shipping.snapshot_interval", 86400)
+	viper.SetDefault("db_shipping.keep_days", 7)
+	viper.SetDefault("replica.primary_url", "")
+	viper.SetDefault("replica.interval", 30)
+	viper.SetDefault("trace.endpoint", "")
+	viper.SetDefault("trace.service_name", "palworld-server-tool")
+	viper.SetDefault("trace.sample_ratio", 1.0)
+	viper.SetDefault("debug.crash_reports", 20)
+	viper.SetDefault("debug.storage_sample_interval", 3600)
+	viper.SetDefault("debug.storage_sample_keep_days", 30)
+	viper.SetDefault("paths.data_dir", "")
+	viper.SetDefault("paths.backup_dir", "")
+	viper.SetDefault("paths.cache_dir", "")
+	viper.SetDefault("paths.log_dir", "")
+
+	viper.SetDefault("manage.base_raid_structures", 10)
+	viper.SetDefault("manage.base_raid_hp_percent", 20)
+	viper.SetDefault("manage.abandoned_base_days", 30)
+	viper.SetDefault("manage.merge_undo_hours", 24)
+	viper.SetDefault("manage.orphan_prune_interval", 86400)
+	viper.SetDefault("manage.storage_withdrawal", 0)
+	viper.SetDefault("manage.storage_keep_days", 30)
 
 	viper.SetEnvPrefix("")
-	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "__"))
+	viper.SetEnvKeyReplacer(envKeyReplacer)
 	viper.AutomaticEnv()
+	if err := bindEnv(); err != nil {
+		logger.Panicf("Unable to read config from env, %s\n", err)
+	}
 
 	err = viper.Unmarshal(conf)
 	if err != nil {
 		logger.Panicf("Unable to decode config into struct, %s", err)
 	}
+	applyTimezone()
+}
+
+// applyTimezone makes task.timezone the local time of pst, so everything
+// dated or scheduled in local time follows it instead of the host.
+func applyTimezone() {
+	name := viper.GetString("task.timezone")
+	if name == "" {
+		return
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		logger.Errorf("Unable to load task.timezone %q, keeping the host's: %v\n", name, err)
+		return
+	}
+	time.Local = loc
 }
@@ -0,0 +1,153 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// envKeyReplacer turns a key into its environment variable, web.port into
+// WEB__PORT.
+var envKeyReplacer = strings.NewReplacer(".", "__")
+
+// EnvName is the environment variable of a config key, which takes
+// precedence over the config file and the defaults.
+func EnvName(key string) string {
+	return strings.ToUpper(envKeyReplacer.Replace(key))
+}
+
+// bindEnv makes every option of Config settable from the environment, also
+// the ones without a default that viper would otherwise only find on Get.
+// Lists of sections and maps are read as YAML or JSON from the variable of
+// the key, like HOOKS='[{"name": "backup", "command": "..."}]', and map
+// entries also from one variable each, like ADMIN__COMMANDS__SET_TIME.
+func bindEnv() error {
+	var walk func(t reflect.Type, prefix string) error
+	walk = func(t reflect.Type, prefix string) error {
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			key := prefix + fieldName(f)
+			switch {
+			case f.Type.Kind() == reflect.Struct:
+				if err := walk(f.Type, key+"."); err != nil {
+					return err
+				}
+			case f.Type.Kind() == reflect.Map, f.Type.Kind() == reflect.Slice && f.Type.Elem().Kind() == reflect.Struct:
+				if err := setStructured(key, f.Type.Kind() == reflect.Map); err != nil {
+					return err
+				}
+			default:
+				if err := viper.BindEnv(key, EnvName(key)); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	return walk(reflect.TypeOf(Config{}), "")
+}
+
+func setStructured(key string, isMap bool) error {
+	name := EnvName(key)
+	if value, ok := os.LookupEnv(name); ok && value != "" {
+		var v any
+		if err := yaml.Unmarshal([]byte(value), &v); err != nil {
+			return fmt.Errorf("%s is not YAML or JSON: %w", name, err)
+		}
+		viper.Set(key, v)
+	}
+	if !isMap {
+		return nil
+	}
+	for _, env := range os.Environ() {
+		k, value, _ := strings.Cut(env, "=")
+		if sub, ok := strings.CutPrefix(k, name+"__"); ok && sub != "" && value != "" {
+			// entries of maps of sections are deeper, REQUEST_POLICY__SAVE__TIMEOUT
+			viper.Set(key+"."+strings.ToLower(strings.ReplaceAll(sub, "__", ".")), value)
+		}
+	}
+	return nil
+}
+
+// Source tells where the value of a key comes from: env with the variable,
+// file or default.
+func Source(key string) string {
+	// a whole list or map can come from the variable of a parent key
+	for k := key; k != ""; {
+		if name := EnvName(k); os.Getenv(name) != "" {
+			return "env " + name
+		}
+		i := strings.LastIndexByte(k, '.')
+		if i < 0 {
+			break
+		}
+		k = k[:i]
+	}
+	if viper.InConfig(key) {
+		return "file"
+	}
+	return "default"
+}
+
+// secretNames are the options printed masked unless asked otherwise.
+var secretNames = []string{"password", "secret", "token", "backup_key", "redis_url", "headers"}
+
+func isSecret(key string) bool {
+	for _, part := range strings.Split(key, ".") {
+		for _, name := range secretNames {
+			if strings.Contains(part, name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Effective returns the merged config as YAML, each option commented with
+// its source and the secrets masked unless showSecrets.
+func Effective(showSecrets bool) ([]byte, error) {
+	root := effectiveNode(viper.AllSettings(), "", showSecrets, true)
+	return yaml.Marshal(root)
+}
+
+// effectiveNode builds the node of a value, with the source commented on
+// options outside of lists, whose items all come from the same place.
+func effectiveNode(value any, key string, showSecrets, comment bool) *yaml.Node {
+	if m := toStringMap(value); m != nil {
+		node := &yaml.Node{Kind: yaml.MappingNode}
+		names := make([]string, 0, len(m))
+		for name := range m {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			child := effectiveNode(m[name], joinKey(key, name), showSecrets, comment)
+			keyNode := &yaml.Node{Kind: yaml.ScalarNode, Value: name}
+			if comment && child.Kind != yaml.MappingNode {
+				keyNode.LineComment = Source(joinKey(key, name))
+			}
+			node.Content = append(node.Content, keyNode, child)
+		}
+		return node
+	}
+	if items, ok := value.([]any); ok {
+		node := &yaml.Node{Kind: yaml.SequenceNode}
+		for _, item := range items {
+			node.Content = append(node.Content, effectiveNode(item, key, showSecrets, false))
+		}
+		return node
+	}
+	if !showSecrets && isSecret(key) && value != "" && value != nil {
+		return &yaml.Node{Kind: yaml.ScalarNode, Value: "******"}
+	}
+	node := &yaml.Node{}
+	if err := node.Encode(value); err != nil {
+		return &yaml.Node{Kind: yaml.ScalarNode, Value: fmt.Sprint(value)}
+	}
+	return node
+}
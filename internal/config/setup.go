@@ -0,0 +1,112 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// Setup are the settings the first-run setup asks for, the rest keep their
+// defaults.
+type Setup struct {
+	WebPassword string
+	RconAddress string
+	RestAddress string
+	// AdminPassword is AdminPassword of PalWorldSettings.ini, which both
+	// RCON and the REST API use
+	AdminPassword string
+	SavePath      string
+}
+
+// SetupKeys are the config keys of the setup, for reporting their problems
+// on the field they came from.
+var SetupKeys = map[string]string{
+	"web.password":  "web_password",
+	"rcon.address":  "rcon_address",
+	"rest.address":  "rest_address",
+	"rcon.password": "admin_password",
+	"rest.password": "admin_password",
+	"save.path":     "save_path",
+}
+
+// SetupNeeded is true when pst started without a config file or a web
+// password from the environment, so there is nothing to log in with yet.
+func SetupNeeded() bool {
+	return viper.ConfigFileUsed() == "" && viper.GetString("web.password") == ""
+}
+
+// SetupPath is where the setup writes the config, the config.yaml Init
+// looks for first.
+func SetupPath() string {
+	if path, err := filepath.Abs("config.yaml"); err == nil {
+		return path
+	}
+	return "config.yaml"
+}
+
+// Apply sets the values in the running config, so they can be checked and
+// used without a restart.
+func (s Setup) Apply() {
+	for key, value := range s.values() {
+		viper.Set(key, value)
+	}
+}
+
+// Check finds the problems of the running config with the setup on top,
+// checked on a copy so a refused setup changes nothing.
+func (s Setup) Check() []Problem {
+	cfg := viper.New()
+	cfg.MergeConfigMap(viper.AllSettings())
+	for key, value := range s.values() {
+		cfg.Set(key, value)
+	}
+	return checkValues(cfg)
+}
+
+func (s Setup) values() map[string]string {
+	return map[string]string{
+		"web.password":  s.WebPassword,
+		"rcon.address":  s.RconAddress,
+		"rcon.password": s.AdminPassword,
+		"rest.address":  s.RestAddress,
+		"rest.password": s.AdminPassword,
+		"save.path":     s.SavePath,
+	}
+}
+
+// Write saves the setup as a new config file readable only by its owner,
+// as it holds the passwords. It fails when the file exists.
+func (s Setup) Write(path string) error {
+	sections := make(map[string]map[string]string)
+	for key, value := range s.values() {
+		section, name, _ := strings.Cut(key, ".")
+		if sections[section] == nil {
+			sections[section] = make(map[string]string)
+		}
+		sections[section][name] = value
+	}
+	b, err := yaml.Marshal(sections)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		if os.IsExist(err) {
+			return fmt.Errorf("%s exists, edit it instead", path)
+		}
+		return err
+	}
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	viper.SetConfigFile(path)
+	return nil
+}
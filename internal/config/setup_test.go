@@ -0,0 +1,29 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestSetupCheckKeepsConfig(t *testing.T) {
+	viper.Set("rcon.address", "127.0.0.1:25575")
+	defer viper.Set("rcon.address", "")
+	s := Setup{WebPassword: "pw", RconAddress: "not an address", RestAddress: "http://127.0.0.1:8212", AdminPassword: "admin", SavePath: "/srv/Pal/Saved"}
+	found := false
+	for _, p := range s.Check() {
+		found = found || p.Key == "rcon.address"
+		if p.Key == "web.password" || p.Key == "rcon.password" {
+			t.Errorf("problem %s, the setup sets it", p)
+		}
+	}
+	if !found {
+		t.Error("no problem with rcon.address of the setup")
+	}
+	if got := viper.GetString("rcon.address"); got != "127.0.0.1:25575" {
+		t.Errorf("rcon.address is %q after Check, want it unchanged", got)
+	}
+	if got := viper.GetString("web.password"); got != "" {
+		t.Errorf("web.password is %q after Check, want it unchanged", got)
+	}
+}
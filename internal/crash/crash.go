@@ -0,0 +1,270 @@
+// Package crash writes crash report bundles when something panics: the stack,
+// the recent logs, the sanitized config and database stats in one zip that
+// can be attached to a bug report.
+package crash
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/internal/logger"
+	"github.com/zaigie/palworld-server-tool/internal/paths"
+	"github.com/zaigie/palworld-server-tool/internal/system"
+	"go.etcd.io/bbolt"
+)
+
+var ErrReportNotFound = errors.New("crash report not found")
+
+// Version is written into reports, main sets it.
+var Version = "Develop"
+
+var started = time.Now()
+
+type Report struct {
+	Name    string    `json:"name"`
+	Size    int64     `json:"size"`
+	Created time.Time `json:"created"`
+}
+
+// secretKey matches the config keys whose values stay out of reports.
+var secretKey = regexp.MustCompile(`(?i)password|secret|token|key|webhook|dsn|credential|headers|authorization`)
+
+// userinfo matches the credentials of a url
+var userinfo = regexp.MustCompile(`://[^/@\s]+@`)
+
+func reportDir() (string, error) {
+	dir := filepath.Join(paths.Data(), "crash_reports")
+	if err := system.CheckAndCreateDir(dir); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// Recover reports a panic of the goroutine it is deferred in and lets it go
+// on, the panic is turned into the returned error of where.
+func Recover(where string, err *error) {
+	if r := recover(); r != nil {
+		name, reportErr := Write(where, r, debug.Stack())
+		if reportErr != nil {
+			logger.Errorf("Write crash report fail: %v\n", reportErr)
+		}
+		if err != nil {
+			*err = fmt.Errorf("panic in %s: %v, see crash report %s", where, r, name)
+		}
+	}
+}
+
+// Write writes a crash report of a panic with the value and stack, keeping
+// only the last debug.crash_reports reports. It returns the report name.
+func Write(where string, value any, stack []byte) (string, error) {
+	logger.Errorf("Panic in %s: %v\n%s\n", where, value, stack)
+	dir, err := reportDir()
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
+	name := fmt.Sprintf("crash-%s.zip", now.Format("20060102-150405.000"))
+	f, err := os.Create(filepath.Join(dir, name))
+	if err != nil {
+		return "", err
+	}
+	zw := zip.NewWriter(f)
+	files := []struct {
+		name  string
+		write func(io.Writer) error
+	}{
+		{"panic.txt", func(w io.Writer) error {
+			_, err := fmt.Fprintf(w, "time: %s\nversion: %s\ngo: %s %s/%s\nuptime: %s\nwhere: %s\npanic: %v\n\n%s",
+				now.Format(time.RFC3339), Version, runtime.Version(), runtime.GOOS, runtime.GOARCH,
+				now.Sub(started).Round(time.Second), where, value, stack)
+			return err
+		}},
+		{"goroutines.txt", func(w io.Writer) error {
+			_, err := w.Write(allStacks())
+			return err
+		}},
+		{"logs.txt", func(w io.Writer) error {
+			_, err := io.WriteString(w, strings.Join(logger.Recent(), "\n"))
+			return err
+		}},
+		{"config.json", func(w io.Writer) error {
+			return writeJSON(w, sanitize(viper.AllSettings()))
+		}},
+		{"db.json", func(w io.Writer) error {
+			return writeJSON(w, dbStats())
+		}},
+		{"runtime.json", func(w io.Writer) error {
+			var mem runtime.MemStats
+			runtime.ReadMemStats(&mem)
+			return writeJSON(w, map[string]any{
+				"goroutines":  runtime.NumGoroutine(),
+				"heap_alloc":  mem.HeapAlloc,
+				"heap_sys":    mem.HeapSys,
+				"num_gc":      mem.NumGC,
+				"cpus":        runtime.NumCPU(),
+				"working_dir": dir,
+			})
+		}},
+	}
+	for _, file := range files {
+		w, err := zw.Create(file.name)
+		if err == nil {
+			err = file.write(w)
+		}
+		if err != nil {
+			zw.Close()
+			f.Close()
+			return "", fmt.Errorf("%s: %w", file.name, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		f.Close()
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+	prune(dir, viper.GetInt("debug.crash_reports"))
+	logger.Errorf("Crash report written to %s\n", filepath.Join(dir, name))
+	return name, nil
+}
+
+func allStacks() []byte {
+	buf := make([]byte, 1<<20)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) || len(buf) >= 64<<20 {
+			return buf[:n]
+		}
+		buf = make([]byte, len(buf)*2)
+	}
+}
+
+func writeJSON(w io.Writer, v any) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// sanitize masks the values of secret looking keys and the credentials in
+// urls.
+func sanitize(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, value := range v {
+			if value != nil && value != "" && secretKey.MatchString(k) {
+				out[k] = "***"
+				continue
+			}
+			out[k] = sanitize(value)
+		}
+		return out
+	case map[any]any:
+		out := make(map[string]any, len(v))
+		for k, value := range v {
+			out[fmt.Sprint(k)] = value
+		}
+		return sanitize(out)
+	case []any:
+		out := make([]any, len(v))
+		for i, value := range v {
+			out[i] = sanitize(value)
+		}
+		return out
+	case string:
+		return userinfo.ReplaceAllString(v, "://***@")
+	}
+	return v
+}
+
+func dbStats() map[string]any {
+	db := database.GetDB()
+	stats := map[string]any{"path": db.Path()}
+	if info, err := os.Stat(db.Path()); err == nil {
+		stats["size"] = info.Size()
+	}
+	dbStats := db.Stats()
+	stats["free_pages"] = dbStats.FreePageN
+	stats["open_read_tx"] = dbStats.OpenTxN
+	buckets := make(map[string]int)
+	err := db.View(func(tx *bbolt.Tx) error {
+		stats["tx_id"] = tx.ID()
+		return tx.ForEach(func(name []byte, b *bbolt.Bucket) error {
+			buckets[string(name)] = b.Stats().KeyN
+			return nil
+		})
+	})
+	if err != nil {
+		stats["error"] = err.Error()
+	}
+	stats["bucket_keys"] = buckets
+	return stats
+}
+
+func prune(dir string, keep int) {
+	if keep <= 0 {
+		return
+	}
+	reports, err := List()
+	if err != nil || len(reports) <= keep {
+		return
+	}
+	for _, report := range reports[keep:] {
+		os.Remove(filepath.Join(dir, report.Name))
+	}
+}
+
+// List returns the crash reports, newest first.
+func List() ([]Report, error) {
+	dir, err := reportDir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	reports := make([]Report, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".zip" {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		reports = append(reports, Report{Name: entry.Name(), Size: info.Size(), Created: info.ModTime()})
+	}
+	sort.Slice(reports, func(i, j int) bool { return reports[i].Name > reports[j].Name })
+	return reports, nil
+}
+
+// Path returns the file of a report, the name is checked to be only a name.
+func Path(name string) (string, error) {
+	if name != filepath.Base(name) || filepath.Ext(name) != ".zip" {
+		return "", ErrReportNotFound
+	}
+	dir, err := reportDir()
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, name)
+	if _, err := os.Stat(path); err != nil {
+		return "", ErrReportNotFound
+	}
+	return path, nil
+}
@@ -0,0 +1,58 @@
+// Package daemon installs pst as a system service, a Windows service or a
+// systemd unit, so it keeps running after the admin logs off and comes back
+// after a crash or a reboot.
+package daemon
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+const DisplayName = "Palworld Server Tool"
+
+const description = "Manages a Palworld dedicated server over RCON and REST"
+
+var ErrExists = errors.New("service already installed")
+var ErrNotInstalled = errors.New("service not installed")
+
+// Options is how the installed service runs pst.
+type Options struct {
+	// Name of the service, pst by default
+	Name string
+	// Executable is the absolute path of pst
+	Executable string
+	// Dir is the working directory, where pst.db and the backups stay by
+	// default
+	Dir string
+	// Config is the absolute path of the config file, empty for the one
+	// found in Dir
+	Config string
+	// User runs the systemd unit, empty for root
+	User string
+}
+
+// DefaultOptions runs the current executable in the current directory.
+func DefaultOptions() (Options, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return Options{}, err
+	}
+	if exe, err = filepath.EvalSymlinks(exe); err != nil {
+		return Options{}, err
+	}
+	dir, err := os.Getwd()
+	if err != nil {
+		return Options{}, err
+	}
+	return Options{Name: "pst", Executable: exe, Dir: dir}, nil
+}
+
+// args are the command line arguments of the service.
+func (o Options) args() []string {
+	args := []string{"-dir", o.Dir}
+	if o.Config != "" {
+		args = append(args, "-config", o.Config)
+	}
+	return args
+}
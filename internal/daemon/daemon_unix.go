@@ -0,0 +1,109 @@
+//go:build !windows
+
+package daemon
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+const unitDir = "/etc/systemd/system"
+
+var unitTemplate = template.Must(template.New("unit").Funcs(template.FuncMap{"quote": quote}).Parse(`[Unit]
+Description={{.DisplayName}}
+Documentation=https://github.com/zaigie/palworld-server-tool
+After=network-online.target
+Wants=network-online.target
+
+[Service]
+Type=simple
+WorkingDirectory={{quote .Dir}}
+ExecStart={{quote .Executable}}{{range .Args}} {{quote .}}{{end}}
+Restart=on-failure
+RestartSec=5
+KillSignal=SIGTERM
+TimeoutStopSec=30
+{{- if .User}}
+User={{.User}}
+{{- end}}
+
+[Install]
+WantedBy=multi-user.target
+`))
+
+// quote quotes an argument of a unit file when it has spaces or quotes.
+func quote(s string) string {
+	if !strings.ContainsAny(s, " \t\"'\\") {
+		return s
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// Unit returns the systemd unit running pst with opts.
+func Unit(opts Options) (string, error) {
+	var buf bytes.Buffer
+	err := unitTemplate.Execute(&buf, struct {
+		Options
+		DisplayName string
+		Args        []string
+	}{opts, DisplayName, opts.args()})
+	return buf.String(), err
+}
+
+func unitPath(name string) string {
+	return filepath.Join(unitDir, name+".service")
+}
+
+// Install writes the systemd unit of pst, enables and starts it.
+func Install(opts Options) error {
+	if _, err := os.Stat("/run/systemd/system"); err != nil {
+		return errors.New("systemd is not running here, use -print and set the service up by hand")
+	}
+	path := unitPath(opts.Name)
+	if _, err := os.Stat(path); err == nil {
+		return ErrExists
+	}
+	unit, err := Unit(opts)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, []byte(unit), 0644); err != nil {
+		return err
+	}
+	if err := systemctl("daemon-reload"); err != nil {
+		return err
+	}
+	return systemctl("enable", "--now", opts.Name+".service")
+}
+
+// Uninstall stops and disables the systemd unit and removes it.
+func Uninstall(name string) error {
+	path := unitPath(name)
+	if _, err := os.Stat(path); err != nil {
+		return ErrNotInstalled
+	}
+	if err := systemctl("disable", "--now", name+".service"); err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil {
+		return err
+	}
+	return systemctl("daemon-reload")
+}
+
+func systemctl(args ...string) error {
+	out, err := exec.Command("systemctl", args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("systemctl %s: %v: %s", strings.Join(args, " "), err, bytes.TrimSpace(out))
+	}
+	return nil
+}
+
+// Notify does nothing, systemd stops pst with SIGTERM.
+func Notify(chan<- os.Signal) {}
@@ -0,0 +1,113 @@
+//go:build windows
+
+package daemon
+
+import (
+	"errors"
+	"os"
+	"syscall"
+	"time"
+
+	"github.com/zaigie/palworld-server-tool/internal/logger"
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+// Unit is for systemd, which Windows doesn't have.
+func Unit(Options) (string, error) {
+	return "", errors.New("systemd units are not supported on Windows")
+}
+
+// Install registers pst as a Windows service starting with the system and
+// restarting when it exits unexpectedly, and starts it.
+func Install(opts Options) error {
+	m, err := mgr.Connect()
+	if err != nil {
+		return err
+	}
+	defer m.Disconnect()
+	if s, err := m.OpenService(opts.Name); err == nil {
+		s.Close()
+		return ErrExists
+	}
+	s, err := m.CreateService(opts.Name, opts.Executable, mgr.Config{
+		DisplayName: DisplayName,
+		Description: description,
+		StartType:   mgr.StartAutomatic,
+	}, opts.args()...)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+	err = s.SetRecoveryActions([]mgr.RecoveryAction{
+		{Type: mgr.ServiceRestart, Delay: 5 * time.Second},
+		{Type: mgr.ServiceRestart, Delay: 30 * time.Second},
+		{Type: mgr.ServiceRestart, Delay: time.Minute},
+	}, uint32((24 * time.Hour).Seconds()))
+	if err != nil {
+		s.Delete()
+		return err
+	}
+	return s.Start()
+}
+
+// Uninstall stops the Windows service and removes it.
+func Uninstall(name string) error {
+	m, err := mgr.Connect()
+	if err != nil {
+		return err
+	}
+	defer m.Disconnect()
+	s, err := m.OpenService(name)
+	if err != nil {
+		return ErrNotInstalled
+	}
+	defer s.Close()
+	if status, err := s.Control(svc.Stop); err == nil {
+		for deadline := time.Now().Add(30 * time.Second); status.State != svc.Stopped && time.Now().Before(deadline); {
+			time.Sleep(300 * time.Millisecond)
+			if status, err = s.Query(); err != nil {
+				break
+			}
+		}
+	} else if !errors.Is(err, windows.ERROR_SERVICE_NOT_ACTIVE) {
+		return err
+	}
+	return s.Delete()
+}
+
+type handler struct {
+	stop chan<- os.Signal
+}
+
+func (h handler) Execute(_ []string, requests <-chan svc.ChangeRequest, status chan<- svc.Status) (bool, uint32) {
+	status <- svc.Status{State: svc.Running, Accepts: svc.AcceptStop | svc.AcceptShutdown}
+	for req := range requests {
+		switch req.Cmd {
+		case svc.Interrogate:
+			status <- req.CurrentStatus
+		case svc.Stop, svc.Shutdown:
+			status <- svc.Status{State: svc.StopPending}
+			h.stop <- syscall.SIGTERM
+			return false, 0
+		}
+	}
+	return false, 0
+}
+
+// Notify has stop requests of the service control manager sent to c as
+// SIGTERM when pst runs as a Windows service.
+func Notify(c chan<- os.Signal) {
+	if ok, err := svc.IsWindowsService(); err != nil || !ok {
+		return
+	}
+	go func() {
+		if err := svc.Run("pst", handler{stop: c}); err != nil {
+			logger.Errorf("Windows service: %v\n", err)
+		}
+	}()
+}
+
+// HandleSignals does nothing, Windows has no SIGHUP or SIGUSR1.
+func HandleSignals() {}
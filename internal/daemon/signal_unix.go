@@ -0,0 +1,35 @@
+//go:build !windows
+
+package daemon
+
+import (
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/zaigie/palworld-server-tool/internal/logger"
+)
+
+// HandleSignals reopens the log file on SIGHUP, for logrotate, and writes
+// the state of pst to the log on SIGUSR1.
+func HandleSignals() {
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, syscall.SIGHUP, syscall.SIGUSR1)
+	go func() {
+		for sig := range c {
+			switch sig {
+			case syscall.SIGHUP:
+				if err := logger.Reopen(); err != nil {
+					logger.Errorf("Reopen log file: %v\n", err)
+					continue
+				}
+				logger.Info("Log file reopened\n")
+			case syscall.SIGUSR1:
+				var b strings.Builder
+				DumpState(&b)
+				logger.Infof("State dump:\n%s", b.String())
+			}
+		}
+	}()
+}
@@ -0,0 +1,60 @@
+package daemon
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"runtime/pprof"
+	"text/tabwriter"
+	"time"
+
+	"github.com/zaigie/palworld-server-tool/internal/bus"
+	"github.com/zaigie/palworld-server-tool/internal/cache"
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/internal/task"
+)
+
+var started = time.Now()
+
+// DumpState writes what pst is doing right now, the tasks, event
+// subscribers, cache, database, memory and goroutines, for looking into a
+// running instance without restarting it.
+func DumpState(w io.Writer) {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	fmt.Fprintf(w, "uptime %s, %d goroutines, heap %d MiB, sys %d MiB, %d gc\n",
+		time.Since(started).Round(time.Second), runtime.NumGoroutine(), mem.HeapAlloc>>20, mem.Sys>>20, mem.NumGC)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "\nTASK\tSCHEDULE\tSTATE\tLAST_RUN\tLAST_DURATION\tNEXT_RUN\tLAST_ERROR")
+	for _, t := range task.ListTasks() {
+		state := "idle"
+		if t.Running {
+			state = "running"
+		} else if t.Paused {
+			state = "paused"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%.1fs\t%s\t%s\n", t.Name, t.Schedule, state,
+			formatTime(t.LastRun), t.LastDuration, formatTime(t.NextRun), t.LastError)
+	}
+	fmt.Fprintln(tw, "\nSUBSCRIBER\tQUEUED")
+	for _, s := range bus.Stats() {
+		fmt.Fprintf(tw, "%s\t%d\n", s.Name, s.Queued)
+	}
+	tw.Flush()
+
+	c := cache.DefaultStats()
+	fmt.Fprintf(w, "\ncache %s: %d hits, %d misses, %d keys\n", c.Backend, c.Hits, c.Misses, c.Keys)
+	db := database.GetDB().Stats()
+	fmt.Fprintf(w, "database: %d read transactions open, %d free pages, %d writes\n", db.OpenTxN, db.FreePageN, db.TxStats.GetWrite())
+
+	fmt.Fprintln(w)
+	pprof.Lookup("goroutine").WriteTo(w, 1)
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format("2006-01-02 15:04:05")
+}
@@ -1,59 +1,151 @@
 package database
 
 import (
+	"errors"
+	"path/filepath"
 	"sync"
 	"time"
 
 	"github.com/zaigie/palworld-server-tool/internal/logger"
+	"github.com/zaigie/palworld-server-tool/internal/paths"
 	"go.etcd.io/bbolt"
 )
 
 var db *bbolt.DB
 var once sync.Once
+var mu sync.RWMutex
+
+var buckets = []string{
+	"players",
+	"guilds",
+	"rcons",
+	"backups",
+	"macros",
+	"community_events",
+	"settings_presets",
+	"events",
+	"map_calibrations",
+	"heatmaps",
+	"map_objects",
+	"server_jobs",
+	"player_groups",
+	"playtimes",
+	"player_ips",
+	"ipbans",
+	"guild_stats",
+	"daily_snapshots",
+	"daily_reports",
+	"points",
+	"point_transactions",
+	"ping_history",
+	"metrics",
+	"player_merges",
+	"locales",
+	"templates",
+	"backup_times",
+	"removed_entries",
+	"player_segments",
+	"pal_owners",
+	"snapshots",
+	"guild_storage",
+	"storage_changes",
+	"badges",
+	"seasons",
+	"storage_samples",
+	"sync_batches",
+	"sync_journal",
+	"whitelist_applications",
+	"task_times",
+}
 
 func InitDB() *bbolt.DB {
-	db_, err := bbolt.Open("pst.db", 0600, &bbolt.Options{Timeout: 1 * time.Minute})
+	db_, err := Open(filepath.Join(paths.Data(), "pst.db"))
 	if err != nil {
 		logger.Panic(err)
 	}
-	// players
-	err = db_.Update(func(tx *bbolt.Tx) error {
-		_, err := tx.CreateBucketIfNotExists([]byte("players"))
-		return err
-	})
+	return db_
+}
+
+// ErrInUse is returned when another process, like a running pst, holds the
+// lock of the database file.
+var ErrInUse = errors.New("database in use, stop the pst using it first")
+
+// InitLocal opens pst.db as the database of GetDB, waiting at most timeout
+// for the lock of the file instead of the minute of the server, for the
+// cli working on the database of a stopped instance.
+func InitLocal(timeout time.Duration) (*bbolt.DB, error) {
+	db_, err := open(filepath.Join(paths.Data(), "pst.db"), timeout)
 	if err != nil {
-		logger.Panic(err)
+		return nil, err
 	}
-	// guilds
-	err = db_.Update(func(tx *bbolt.Tx) error {
-		_, err := tx.CreateBucketIfNotExists([]byte("guilds"))
-		return err
+	opened := false
+	once.Do(func() {
+		db = db_
+		opened = true
 	})
-	if err != nil {
-		logger.Panic(err)
+	if !opened {
+		db_.Close()
+	}
+	return GetDB(), nil
+}
+
+// Open opens the database at path with all buckets created, for tools
+// working on a database other than the one of the server.
+func Open(path string) (*bbolt.DB, error) {
+	return open(path, 1*time.Minute)
+}
+
+func open(path string, timeout time.Duration) (*bbolt.DB, error) {
+	db_, err := bbolt.Open(path, 0600, &bbolt.Options{Timeout: timeout})
+	if errors.Is(err, bbolt.ErrTimeout) {
+		return nil, ErrInUse
 	}
-	// rcons
-	err = db_.Update(func(tx *bbolt.Tx) error {
-		_, err := tx.CreateBucketIfNotExists([]byte("rcons"))
-		return err
-	})
 	if err != nil {
-		logger.Panic(err)
+		return nil, err
 	}
-	// backups
 	err = db_.Update(func(tx *bbolt.Tx) error {
-		_, err := tx.CreateBucketIfNotExists([]byte("backups"))
-		return err
+		for _, name := range buckets {
+			if _, err := tx.CreateBucketIfNotExists([]byte(name)); err != nil {
+				return err
+			}
+		}
+		return nil
 	})
 	if err != nil {
-		logger.Panic(err)
+		db_.Close()
+		return nil, err
 	}
-	return db_
+	return db_, nil
 }
 
 func GetDB() *bbolt.DB {
 	once.Do(func() {
 		db = InitDB()
 	})
+	mu.RLock()
+	defer mu.RUnlock()
 	return db
 }
+
+// Replace serves the database snapshot at path read-only from now on, for a
+// replica. The database replaced is closed a little later so the requests
+// still reading it can finish.
+func Replace(path string) error {
+	next, err := bbolt.Open(path, 0600, &bbolt.Options{Timeout: 5 * time.Second, ReadOnly: true})
+	if err != nil {
+		return err
+	}
+	once.Do(func() {})
+	mu.Lock()
+	prev := db
+	db = next
+	mu.Unlock()
+	if prev != nil {
+		time.AfterFunc(10*time.Second, func() {
+			if err := prev.Close(); err != nil {
+				logger.Warnf("Failed to close replaced database: %v\n", err)
+			}
+		})
+	}
+	return nil
+}
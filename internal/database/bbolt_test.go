@@ -0,0 +1,28 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestOpenInUse(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pst.db")
+	first, err := open(path, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	if _, err := open(path, 100*time.Millisecond); err != ErrInUse {
+		t.Fatalf("got %v, want ErrInUse", err)
+	}
+	if waited := time.Since(start); waited > 5*time.Second {
+		t.Errorf("waited %s for the lock", waited)
+	}
+	first.Close()
+	second, err := open(path, 100*time.Millisecond)
+	if err != nil {
+		t.Fatalf("open after close: %v", err)
+	}
+	second.Close()
+}
@@ -3,6 +3,10 @@ package database
 import "time"
 
 type Pal struct {
+	// InstanceId identifies the pal across saves, empty from older sav_cli
+	InstanceId string `json:"instance_id"`
+	// Location is party, palbox or base, empty when unknown
+	Location       string   `json:"location"`
 	Level          int32    `json:"level"`
 	Exp            int64    `json:"exp"`
 	Hp             int64    `json:"hp"`
@@ -34,11 +38,14 @@ type OnlinePlayer struct {
 	LocationY  float64   `json:"location_y"`
 	Level      int32     `json:"level"`
 	LastOnline time.Time `json:"last_online"`
+	// Platform is steam, xbox or playstation, empty when unknown
+	Platform string `json:"platform"`
 }
 
 type GuildPlayer struct {
-	PlayerUid string `json:"player_uid"`
-	Nickname  string `json:"nickname"`
+	PlayerUid  string `json:"player_uid"`
+	Nickname   string `json:"nickname"`
+	LastOnline string `json:"last_online"`
 }
 
 type TersePlayer struct {
@@ -54,6 +61,14 @@ type TersePlayer struct {
 	StatusPoint    map[string]int32 `json:"status_point"`
 	FullStomach    float64          `json:"full_stomach"`
 	SaveLastOnline string           `json:"save_last_online"`
+	// LastOnlineSource is save or live, whether last_online comes from the
+	// save or the online players polled
+	LastOnlineSource string `json:"last_online_source"`
+	// UpdatedAt is when the stored record last changed
+	UpdatedAt time.Time `json:"updated_at"`
+	// Revision counts the changes by save syncs and merges, the online state
+	// polled in between doesn't count
+	Revision uint64 `json:"revision"`
 	OnlinePlayer
 }
 
@@ -61,16 +76,41 @@ type Player struct {
 	TersePlayer
 	Pals  []*Pal `json:"pals"`
 	Items *Items `json:"items"`
+	// Technology is nil when the player's save couldn't be read
+	Technology *Technology `json:"technology"`
+}
+
+// Technology is the research progress of a player.
+type Technology struct {
+	// Points are the unspent technology points
+	Points        int32 `json:"points"`
+	AncientPoints int32 `json:"ancient_points"`
+	// Unlocked are the researched recipe technologies, sorted
+	Unlocked []string `json:"unlocked"`
+}
+
+type BaseCampStructures struct {
+	Count  int   `json:"count"`
+	Hp     int64 `json:"hp"`
+	MaxHp  int64 `json:"max_hp"`
+	PalBox bool  `json:"pal_box"`
 }
 
 type BaseCamp struct {
-	Id        string  `json:"id"`
-	Area      float64 `json:"area"`
-	LocationX float64 `json:"location_x"`
-	LocationY float64 `json:"location_y"`
+	Id         string              `json:"id"`
+	Area       float64             `json:"area"`
+	LocationX  float64             `json:"location_x"`
+	LocationY  float64             `json:"location_y"`
+	Structures *BaseCampStructures `json:"structures,omitempty"`
+	// Storage is the item count in the chests of the camp by item id, nil
+	// when the save was parsed without it
+	Storage map[string]int64 `json:"storage,omitempty"`
 }
 
 type Guild struct {
+	// Id is the group id of the guild in the save, it stays when the admin
+	// changes. Empty from older sav_cli.
+	Id             string         `json:"id"`
 	Name           string         `json:"name"`
 	BaseCampLevel  int32          `json:"base_camp_level"`
 	AdminPlayerUid string         `json:"admin_player_uid"`
@@ -82,6 +122,9 @@ type PlayerW struct {
 	Name      string `json:"name"`
 	SteamID   string `json:"steam_id"`
 	PlayerUID string `json:"player_uid"`
+	// Revision counts the writes of the entry. A change sent with it is
+	// refused when the entry was written since, 0 writes regardless.
+	Revision uint64 `json:"revision"`
 }
 
 type RconCommand struct {
@@ -111,7 +154,484 @@ type Item struct {
 }
 
 type Backup struct {
-	BackupId string    `json:"backup_id"`
-	SaveTime time.Time `json:"save_time"`
-	Path     string    `json:"path"`
+	BackupId     string    `json:"backup_id"`
+	SaveTime     time.Time `json:"save_time"`
+	Path         string    `json:"path"`
+	Size         int64     `json:"size"`
+	WorldVersion string    `json:"world_version"`
+	PlayerCount  int       `json:"player_count"`
+	Reason       string    `json:"reason"`
+	// Checksum is the hex sha256 of the archive, or of the file contents in
+	// order for chunked backups
+	Checksum string `json:"checksum"`
+	// Format is zip or chunks, older records without it are zip
+	Format string `json:"format"`
+	// StoredSize is how many bytes the backup added to the chunk store
+	StoredSize int64 `json:"stored_size"`
+	// KeyId is the id of the key the backup is encrypted with, empty when
+	// it isn't encrypted
+	KeyId string       `json:"key_id"`
+	Files []BackupFile `json:"files,omitempty"`
+	// Season is the name of the season the backup was archived with, those
+	// aren't cleaned up
+	Season string `json:"season,omitempty"`
+}
+
+type BackupFile struct {
+	Name    string    `json:"name"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+	Chunks  []string  `json:"chunks"`
+}
+
+type MacroStep struct {
+	Action  string `json:"action"`
+	Content string `json:"content"`
+	Seconds int    `json:"seconds"`
+}
+
+type Macro struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Steps       []MacroStep `json:"steps"`
+}
+
+type CommunityEvent struct {
+	Id             string            `json:"id"`
+	Name           string            `json:"name"`
+	Description    string            `json:"description"`
+	Rewards        string            `json:"rewards"`
+	Weekdays       []int             `json:"weekdays"`
+	StartTime      string            `json:"start_time"`
+	Duration       int               `json:"duration"`
+	AnnounceBefore int               `json:"announce_before"`
+	Overrides      map[string]string `json:"overrides"`
+	// Restart restarts the server when the overrides are applied and
+	// reverted, with server.start_command or else by shutting it down for
+	// a supervisor to start
+	Restart bool `json:"restart"`
+	// StartActions and EndActions are world actions run when the event
+	// starts and ends, like setting the time to night for a boss hunt
+	StartActions []WorldAction     `json:"start_actions"`
+	EndActions   []WorldAction     `json:"end_actions"`
+	Enabled      bool              `json:"enabled"`
+	Active       bool              `json:"active"`
+	Announced    bool              `json:"announced"`
+	Previous     map[string]string `json:"previous"`
+}
+
+// WorldAction is a set_time, set_weather or trigger_event command of
+// admin.commands with the values of its placeholders.
+type WorldAction struct {
+	Action string            `json:"action"`
+	Vars   map[string]string `json:"vars"`
+}
+
+type SettingsPreset struct {
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	Values      map[string]string `json:"values"`
+}
+
+type Event struct {
+	Id             uint64            `json:"id"`
+	Type           string            `json:"type"`
+	Time           time.Time         `json:"time"`
+	PlayerUid      string            `json:"player_uid"`
+	AdminPlayerUid string            `json:"admin_player_uid"`
+	Message        string            `json:"message"`
+	Data           map[string]string `json:"data"`
+}
+
+type MapCalibration struct {
+	Version string  `json:"version"`
+	MinX    float64 `json:"min_x"`
+	MaxX    float64 `json:"max_x"`
+	MinY    float64 `json:"min_y"`
+	MaxY    float64 `json:"max_y"`
+}
+
+type Heatmap struct {
+	Date    string `json:"date"`
+	Size    int    `json:"size"`
+	Samples int    `json:"samples"`
+	Cells   []int  `json:"cells"`
+}
+
+type MapObject struct {
+	InstanceId     string  `json:"instance_id"`
+	Name           string  `json:"name"`
+	Category       string  `json:"category"`
+	LocationX      float64 `json:"location_x"`
+	LocationY      float64 `json:"location_y"`
+	BaseCampId     string  `json:"base_camp_id"`
+	BuildPlayerUid string  `json:"build_player_uid"`
+	Hp             int64   `json:"hp"`
+	MaxHp          int64   `json:"max_hp"`
+}
+
+type ServerJobStep struct {
+	Name       string    `json:"name"`
+	Status     string    `json:"status"`
+	Output     string    `json:"output"`
+	Error      string    `json:"error"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+}
+
+type ServerJob struct {
+	Id         string           `json:"id"`
+	Action     string           `json:"action"`
+	Status     string           `json:"status"`
+	Error      string           `json:"error"`
+	Steps      []*ServerJobStep `json:"steps"`
+	CreatedAt  time.Time        `json:"created_at"`
+	FinishedAt time.Time        `json:"finished_at"`
+}
+
+type GroupMember struct {
+	Name      string `json:"name"`
+	SteamId   string `json:"steam_id"`
+	PlayerUid string `json:"player_uid"`
+}
+
+type PlayerGroup struct {
+	Name string `json:"name"`
+	// Admin members bypass the whitelist and are never kicked for a slot
+	Admin bool `json:"admin"`
+	// ReservedSlot members may take one of manage.reserved_slots, the most
+	// recently joined non-members are kicked for them
+	ReservedSlot bool `json:"reserved_slot"`
+	// JoinMessage is broadcast when a member joins, {username} is replaced
+	JoinMessage string        `json:"join_message"`
+	Members     []GroupMember `json:"members"`
+}
+
+type Playtime struct {
+	PlayerUid string `json:"player_uid"`
+	Nickname  string `json:"nickname"`
+	// Seconds online, summed from player sync polls
+	Seconds  int64     `json:"seconds"`
+	LastSeen time.Time `json:"last_seen"`
+	// Tags of the ranks reached, appended once and kept
+	Tags []string `json:"tags"`
+}
+
+// PlayerBadges are the badges a player earned and the days in a row they
+// played.
+type PlayerBadges struct {
+	PlayerUid string        `json:"player_uid"`
+	Nickname  string        `json:"nickname"`
+	Badges    []EarnedBadge `json:"badges"`
+	// Streak is the days in a row the player was online, ending at
+	// StreakDay
+	Streak    int    `json:"streak"`
+	StreakDay string `json:"streak_day"`
+}
+
+type EarnedBadge struct {
+	Id   string    `json:"id"`
+	Name string    `json:"name"`
+	Time time.Time `json:"time"`
+	// Value is what the player had of the metric of the badge
+	Value float64 `json:"value"`
+}
+
+type IpSession struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// PlayerIp is the sessions of a player from one ip address.
+type PlayerIp struct {
+	PlayerUid string      `json:"player_uid"`
+	SteamId   string      `json:"steam_id"`
+	Nickname  string      `json:"nickname"`
+	Ip        string      `json:"ip"`
+	Sessions  []IpSession `json:"sessions"`
+}
+
+type IpBan struct {
+	// Ip is a single address or a CIDR range
+	Ip        string    `json:"ip"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+	// ExpiresAt zero means the ban never expires
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// GuildStats is a daily snapshot of a guild taken at save sync.
+type GuildStats struct {
+	AdminPlayerUid string    `json:"admin_player_uid"`
+	Name           string    `json:"name"`
+	Date           string    `json:"date"`
+	Members        int       `json:"members"`
+	AverageLevel   float64   `json:"average_level"`
+	MaxLevel       int32     `json:"max_level"`
+	Pals           int       `json:"pals"`
+	BaseCamps      int       `json:"base_camps"`
+	BaseCampLevel  int32     `json:"base_camp_level"`
+	LastActive     time.Time `json:"last_active"`
+}
+
+// StorageChange is the change of an item in the chests of a base camp
+// between two save syncs.
+type StorageChange struct {
+	Time           time.Time `json:"time"`
+	AdminPlayerUid string    `json:"admin_player_uid"`
+	Guild          string    `json:"guild"`
+	BaseCampId     string    `json:"base_camp_id"`
+	ItemId         string    `json:"item_id"`
+	Before         int64     `json:"before"`
+	After          int64     `json:"after"`
+	// Delta is the count added, negative for a withdrawal
+	Delta int64 `json:"delta"`
+	// Since is the sync the change was counted from
+	Since time.Time `json:"since"`
+	// Online are the guild members online between the two syncs
+	Online []*GuildPlayer `json:"online"`
+}
+
+type SnapshotPlayer struct {
+	Nickname string `json:"nickname"`
+	Level    int32  `json:"level"`
+	Pals     int    `json:"pals"`
+}
+
+type SnapshotGuild struct {
+	Name    string   `json:"name"`
+	Members []string `json:"members"`
+}
+
+// DailySnapshot is the state of players and guilds at the last save sync of
+// a day, keyed by player uid and guild admin player uid.
+type DailySnapshot struct {
+	Date    string                    `json:"date"`
+	Players map[string]SnapshotPlayer `json:"players"`
+	Guilds  map[string]SnapshotGuild  `json:"guilds"`
+}
+
+type ReportPlayer struct {
+	PlayerUid string `json:"player_uid"`
+	Nickname  string `json:"nickname"`
+	// From and To are levels for level gains and pal counts for pal gains
+	From int32 `json:"from"`
+	To   int32 `json:"to"`
+}
+
+type ReportGuildMember struct {
+	Guild     string `json:"guild"`
+	PlayerUid string `json:"player_uid"`
+	Nickname  string `json:"nickname"`
+}
+
+// DailyReport is what changed between the snapshot of Since and of Date.
+type DailyReport struct {
+	Date       string         `json:"date"`
+	Since      string         `json:"since"`
+	NewPlayers []ReportPlayer `json:"new_players"`
+	LevelGains []ReportPlayer `json:"level_gains"`
+	// PalGains is the net gain of pals, captures less releases
+	PalGains        []ReportPlayer      `json:"pal_gains"`
+	NewGuilds       []string            `json:"new_guilds"`
+	DisbandedGuilds []string            `json:"disbanded_guilds"`
+	GuildJoins      []ReportGuildMember `json:"guild_joins"`
+	GuildLeaves     []ReportGuildMember `json:"guild_leaves"`
+}
+
+type PointsAccount struct {
+	PlayerUid string `json:"player_uid"`
+	Nickname  string `json:"nickname"`
+	Balance   int64  `json:"balance"`
+	// OnlineSeconds not paid yet, points are earned per whole minute
+	OnlineSeconds int64     `json:"online_seconds"`
+	LastSeen      time.Time `json:"last_seen"`
+	// OnlineTransaction is the id of today's online earnings, which are
+	// summed into one transaction per day
+	OnlineTransaction uint64 `json:"online_transaction"`
+}
+
+type PointsTransaction struct {
+	Id        uint64 `json:"id"`
+	PlayerUid string `json:"player_uid"`
+	// Amount is negative for spending
+	Amount int64     `json:"amount"`
+	Reason string    `json:"reason"`
+	Time   time.Time `json:"time"`
+}
+
+// PingHistory is a ring buffer of ping samples in parallel slices, once
+// full the next sample replaces the one at Next.
+type PingHistory struct {
+	PlayerUid string `json:"player_uid"`
+	// Times are unix seconds
+	Times []int64   `json:"times"`
+	Pings []float32 `json:"pings"`
+	Next  int       `json:"next"`
+	// High is set while the ping stays above ping.high_ms, so it's reported
+	// once
+	High bool `json:"high"`
+}
+
+type MetricSample struct {
+	Time            time.Time `json:"time"`
+	ServerFps       float64   `json:"server_fps"`
+	ServerFrameTime float64   `json:"server_frame_time"`
+	Players         float64   `json:"players"`
+	BaseCamps       float64   `json:"base_camps"`
+}
+
+// StorageSample is the size of the database and its buckets at Time.
+type StorageSample struct {
+	Time     time.Time             `json:"time"`
+	FileSize int64                 `json:"file_size"`
+	Buckets  map[string]BucketSize `json:"buckets"`
+}
+
+type BucketSize struct {
+	Keys  int   `json:"keys"`
+	Bytes int64 `json:"bytes"`
+}
+
+type PlayerMerge struct {
+	Id uint64 `json:"id"`
+	// PlayerUid is the player kept, MergedPlayerUid the one merged into it
+	PlayerUid       string    `json:"player_uid"`
+	MergedPlayerUid string    `json:"merged_player_uid"`
+	Time            time.Time `json:"time"`
+	UndoUntil       time.Time `json:"undo_until"`
+	Undone          bool      `json:"undone"`
+	// Previous holds the records the merge changed by bucket and key, null
+	// for records it created
+	Previous map[string]map[string][]byte `json:"previous,omitempty"`
+}
+
+// RemovedEntry keeps a whitelist entry, ip ban or player ban after its
+// removal, so an accidental removal can be restored.
+type RemovedEntry struct {
+	Id uint64 `json:"id"`
+	// Kind is whitelist, ipban or ban
+	Kind string `json:"kind"`
+	// Player is the whitelist entry or the unbanned player
+	Player *PlayerW `json:"player,omitempty"`
+	IpBan  *IpBan   `json:"ip_ban,omitempty"`
+	Reason string   `json:"reason"`
+	// RemovedBy is the address of the request, the bot or cli
+	RemovedBy string    `json:"removed_by"`
+	RemovedAt time.Time `json:"removed_at"`
+	// RestoredAt is zero until the entry is restored
+	RestoredAt time.Time `json:"restored_at"`
+}
+
+// WhitelistApplication is a player asking to be whitelisted, approving it
+// adds the whitelist entry.
+type WhitelistApplication struct {
+	Id        uint64 `json:"id"`
+	Name      string `json:"name"`
+	SteamId   string `json:"steam_id"`
+	PlayerUid string `json:"player_uid"`
+	// Email is where the applicant is told of the decision, optional
+	Email string `json:"email"`
+	// Answers are to whitelist_application.questions by question
+	Answers map[string]string `json:"answers"`
+	// Source is form, or steam when sent signed in with Steam
+	Source string    `json:"source"`
+	Status string    `json:"status"`
+	Time   time.Time `json:"time"`
+	// DecidedBy is the address of the request or the bot, DecidedAt is zero
+	// while pending
+	DecidedBy string    `json:"decided_by"`
+	DecidedAt time.Time `json:"decided_at"`
+	Reason    string    `json:"reason"`
+}
+
+// PlayerSegment is a named filter of players, like
+// level>45 AND last_online<7d AND NOT whitelisted
+type PlayerSegment struct {
+	Name        string `json:"name"`
+	Filter      string `json:"filter"`
+	Description string `json:"description"`
+}
+
+// PalOwner is the owner and location of a pal instance at the last save
+// sync, compared with the next sync for ownership changes.
+type PalOwner struct {
+	InstanceId string    `json:"instance_id"`
+	PlayerUid  string    `json:"player_uid"`
+	Type       string    `json:"type"`
+	Nickname   string    `json:"nickname"`
+	Location   string    `json:"location"`
+	FirstSeen  time.Time `json:"first_seen"`
+	LastSeen   time.Time `json:"last_seen"`
+}
+
+// Snapshot is what a destructive operation replaced, taken before it runs
+// so the operation can be reverted.
+type Snapshot struct {
+	Id uint64 `json:"id"`
+	// Operation is whitelist.replace, whitelist.merge, whitelist.append,
+	// settings.apply or snapshot.revert
+	Operation string `json:"operation"`
+	// By is the address of the request
+	By   string    `json:"by"`
+	Time time.Time `json:"time"`
+	// Buckets holds the records of the buckets the operation changes by
+	// bucket and key
+	Buckets map[string]map[string][]byte `json:"buckets,omitempty"`
+	// SettingsBackup is the name of the PalWorldSettings.ini backup
+	SettingsBackup string `json:"settings_backup,omitempty"`
+	// RevertedAt is zero until the snapshot is reverted
+	RevertedAt time.Time `json:"reverted_at"`
+}
+
+// Season is an ended season, its database archived before the live stats
+// were reset.
+type Season struct {
+	Id   uint64 `json:"id"`
+	Name string `json:"name"`
+	// Start is the end of the season before, zero for the first one
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	// Path is the archived database, relative to the seasons directory
+	Path    string `json:"path"`
+	Players int    `json:"players"`
+	Guilds  int    `json:"guilds"`
+	Backups int    `json:"backups"`
+}
+
+// SyncBatch is a journaled save sync, with what each step changed so it can
+// be rolled back or replayed.
+type SyncBatch struct {
+	Id uint64 `json:"id"`
+	// Source is the save path, demo, or replay for a replayed batch
+	Source string `json:"source"`
+	// SaveHash is the hex sha256 of Level.sav, SaveTime its modification
+	// time
+	SaveHash string     `json:"save_hash"`
+	SaveTime time.Time  `json:"save_time"`
+	Start    time.Time  `json:"start"`
+	End      time.Time  `json:"end"`
+	Status   string     `json:"status"`
+	Error    string     `json:"error,omitempty"`
+	Steps    []SyncStep `json:"steps"`
+	// ReplayOf is the id of the batch this one replayed
+	ReplayOf uint64 `json:"replay_of,omitempty"`
+	// RolledBackAt is zero until the batch is rolled back
+	RolledBackAt time.Time `json:"rolled_back_at"`
+	// RollbackSkipped are the records the rollback left as they were,
+	// they changed after the batch
+	RollbackSkipped int `json:"rollback_skipped,omitempty"`
+}
+
+type SyncStep struct {
+	// Name is players, guilds or map_objects
+	Name string `json:"name"`
+	// Mode is how the step wrote the records, merge or replace for guilds
+	Mode string    `json:"mode,omitempty"`
+	Time time.Time `json:"time"`
+	// Records are the records the save sent, Changed the stored ones
+	// the step added, changed or removed
+	Records int    `json:"records"`
+	Changed int    `json:"changed"`
+	Error   string `json:"error,omitempty"`
 }
@@ -0,0 +1,322 @@
+// Package dbship ships copies of pst.db to a remote target: a full snapshot
+// now and then and the pages changed since at each run in between, so the
+// database can be restored as it was at any shipment.
+package dbship
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/zaigie/palworld-server-tool/internal/logger"
+	"go.etcd.io/bbolt"
+)
+
+const manifestName = "manifest.json"
+
+// Manifest lists what is on the target, it's written after the files it
+// lists.
+type Manifest struct {
+	Generations []Generation `json:"generations"`
+}
+
+// Generation is a snapshot and the diffs taken against it.
+type Generation struct {
+	Id       string     `json:"id"`
+	Snapshot Shipment   `json:"snapshot"`
+	Diffs    []Shipment `json:"diffs"`
+}
+
+// Last is the newest state of the database the generation restores to.
+func (g Generation) Last() Shipment {
+	if len(g.Diffs) > 0 {
+		return g.Diffs[len(g.Diffs)-1]
+	}
+	return g.Snapshot
+}
+
+// Shipment is a shipped file, the database as it was at Time.
+type Shipment struct {
+	Name string    `json:"name"`
+	Time time.Time `json:"time"`
+	Txid uint64    `json:"txid"`
+	// Size is the gzipped size on the target
+	Size int64 `json:"size"`
+	// Pages is how many pages a diff changes
+	Pages int `json:"pages,omitempty"`
+}
+
+func LoadManifest(t Target) (Manifest, error) {
+	var m Manifest
+	r, err := t.Get(manifestName)
+	if err == ErrNotFound {
+		return m, nil
+	}
+	if err != nil {
+		return m, err
+	}
+	defer r.Close()
+	err = json.NewDecoder(r).Decode(&m)
+	return m, err
+}
+
+// Shipper ships the database to Target, a new generation every
+// SnapshotInterval and dropping the generations not needed to restore to
+// any time within Keep.
+type Shipper struct {
+	Target Target
+	// Dir keeps the copy of the last shipment the next diff is taken
+	// against, without it the next run starts a generation
+	Dir              string
+	SnapshotInterval time.Duration
+	Keep             time.Duration
+
+	manifest *Manifest
+}
+
+// Ship ships what changed since the last run, the zero Shipment when
+// nothing did.
+func (s *Shipper) Ship(db *bbolt.DB) (Shipment, error) {
+	if s.manifest == nil {
+		m, err := LoadManifest(s.Target)
+		if err != nil {
+			return Shipment{}, fmt.Errorf("load manifest: %w", err)
+		}
+		s.manifest = &m
+	}
+	if err := os.MkdirAll(s.Dir, 0755); err != nil {
+		return Shipment{}, err
+	}
+	next := filepath.Join(s.Dir, "next.db")
+	last := filepath.Join(s.Dir, "last.db")
+	defer os.Remove(next)
+
+	now := time.Now()
+	var txid uint64
+	var pageSize int
+	if err := db.View(func(tx *bbolt.Tx) error {
+		txid = uint64(tx.ID())
+		pageSize = tx.DB().Info().PageSize
+		return tx.CopyFile(next, 0600)
+	}); err != nil {
+		return Shipment{}, err
+	}
+
+	gens := s.manifest.Generations
+	var gen *Generation
+	if len(gens) > 0 {
+		gen = &gens[len(gens)-1]
+		if gen.Last().Txid == txid {
+			return Shipment{}, nil
+		}
+	}
+	shipment := Shipment{Time: now, Txid: txid}
+	var err error
+	if gen == nil || now.Sub(gen.Snapshot.Time) >= s.SnapshotInterval || fileTxid(last) != gen.Last().Txid {
+		id := now.UTC().Format("20060102T150405")
+		shipment.Name = id + "-snapshot.db.gz"
+		shipment.Size, err = putGzip(s.Target, shipment.Name, func(w io.Writer) error {
+			f, err := os.Open(next)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			_, err = io.Copy(w, f)
+			return err
+		})
+		gens = append(gens, Generation{Id: id, Snapshot: shipment, Diffs: make([]Shipment, 0)})
+	} else {
+		shipment.Name = fmt.Sprintf("%s-diff-%06d.gz", gen.Id, len(gen.Diffs)+1)
+		var pages int
+		shipment.Size, err = putGzip(s.Target, shipment.Name, func(w io.Writer) error {
+			var err error
+			pages, err = writeDiff(w, last, next, pageSize)
+			return err
+		})
+		shipment.Pages = pages
+		gen.Diffs = append(gen.Diffs, shipment)
+	}
+	if err != nil {
+		s.manifest = nil
+		return Shipment{}, err
+	}
+
+	var expired []Generation
+	cutoff := now.Add(-s.Keep)
+	for s.Keep > 0 && len(gens) > 1 && gens[1].Snapshot.Time.Before(cutoff) {
+		expired = append(expired, gens[0])
+		gens = gens[1:]
+	}
+	m := Manifest{Generations: gens}
+	if err := putManifest(s.Target, m); err != nil {
+		s.manifest = nil
+		return Shipment{}, fmt.Errorf("put manifest: %w", err)
+	}
+	s.manifest = &m
+	if err := os.Rename(next, last); err != nil {
+		return Shipment{}, err
+	}
+	for _, g := range expired {
+		for _, shipment := range append([]Shipment{g.Snapshot}, g.Diffs...) {
+			if err := s.Target.Delete(shipment.Name); err != nil {
+				logger.Warnf("Failed to delete expired shipment %s: %v\n", shipment.Name, err)
+			}
+		}
+	}
+	return shipment, nil
+}
+
+// fileTxid is the txid of the database file at path, 0 when it can't be
+// read.
+func fileTxid(path string) uint64 {
+	if _, err := os.Stat(path); err != nil {
+		return 0
+	}
+	db, err := bbolt.Open(path, 0600, &bbolt.Options{ReadOnly: true, Timeout: time.Second})
+	if err != nil {
+		return 0
+	}
+	defer db.Close()
+	var txid uint64
+	db.View(func(tx *bbolt.Tx) error {
+		txid = uint64(tx.ID())
+		return nil
+	})
+	return txid
+}
+
+func putManifest(t Target, m Manifest) error {
+	v, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return t.Put(manifestName, bytes.NewReader(v))
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// putGzip puts what write writes gzipped as name, returning the gzipped
+// size.
+func putGzip(t Target, name string, write func(w io.Writer) error) (int64, error) {
+	pr, pw := io.Pipe()
+	go func() {
+		zw := gzip.NewWriter(pw)
+		err := write(zw)
+		if err == nil {
+			err = zw.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+	r := &countingReader{r: pr}
+	err := t.Put(name, r)
+	// unblocks the writer when the target stopped reading early
+	pr.Close()
+	return r.n, err
+}
+
+var diffMagic = []byte("PSTDIFF1")
+
+// writeDiff writes the pages of next that differ from last: a header with
+// the page size and the size of next, then the index and content of each
+// page.
+func writeDiff(w io.Writer, last, next string, pageSize int) (int, error) {
+	lf, err := os.Open(last)
+	if err != nil {
+		return 0, err
+	}
+	defer lf.Close()
+	nf, err := os.Open(next)
+	if err != nil {
+		return 0, err
+	}
+	defer nf.Close()
+	info, err := nf.Stat()
+	if err != nil {
+		return 0, err
+	}
+
+	header := make([]byte, 0, 20)
+	header = append(header, diffMagic...)
+	header = binary.BigEndian.AppendUint32(header, uint32(pageSize))
+	header = binary.BigEndian.AppendUint64(header, uint64(info.Size()))
+	if _, err := w.Write(header); err != nil {
+		return 0, err
+	}
+
+	lr, nr := bufio.NewReader(lf), bufio.NewReader(nf)
+	lp, np := make([]byte, pageSize), make([]byte, pageSize)
+	pages := 0
+	for index := uint64(0); ; index++ {
+		n, err := io.ReadFull(nr, np)
+		if err == io.EOF {
+			return pages, nil
+		}
+		if err != nil && err != io.ErrUnexpectedEOF {
+			return pages, err
+		}
+		// past the end of last reads as a changed page
+		m, _ := io.ReadFull(lr, lp)
+		if m == n && bytes.Equal(lp[:m], np[:n]) {
+			continue
+		}
+		record := binary.BigEndian.AppendUint64(nil, index)
+		record = binary.BigEndian.AppendUint32(record, uint32(n))
+		if _, err := w.Write(append(record, np[:n]...)); err != nil {
+			return pages, err
+		}
+		pages++
+	}
+}
+
+// applyDiff writes the pages of the diff read from r into f and sizes f as
+// the database the diff was taken of.
+func applyDiff(f *os.File, r io.Reader) error {
+	br := bufio.NewReader(r)
+	header := make([]byte, len(diffMagic)+12)
+	if _, err := io.ReadFull(br, header); err != nil {
+		return err
+	}
+	if !bytes.Equal(header[:len(diffMagic)], diffMagic) {
+		return errors.New("not a diff")
+	}
+	pageSize := int64(binary.BigEndian.Uint32(header[len(diffMagic):]))
+	size := int64(binary.BigEndian.Uint64(header[len(diffMagic)+4:]))
+	record := make([]byte, 12)
+	page := make([]byte, pageSize)
+	for {
+		if _, err := io.ReadFull(br, record); err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+		index := int64(binary.BigEndian.Uint64(record))
+		n := binary.BigEndian.Uint32(record[8:])
+		if int64(n) > pageSize {
+			return errors.New("corrupt diff")
+		}
+		if _, err := io.ReadFull(br, page[:n]); err != nil {
+			return err
+		}
+		if _, err := f.WriteAt(page[:n], index*pageSize); err != nil {
+			return err
+		}
+	}
+	return f.Truncate(size)
+}
@@ -0,0 +1,108 @@
+package dbship
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+// find returns the generation holding the last shipment at or before at,
+// the newest when at is zero, and how many of its diffs lead there.
+func (m Manifest) find(at time.Time) (Generation, int, bool) {
+	for i := len(m.Generations) - 1; i >= 0; i-- {
+		gen := m.Generations[i]
+		if !at.IsZero() && gen.Snapshot.Time.After(at) {
+			continue
+		}
+		n := len(gen.Diffs)
+		for n > 0 && !at.IsZero() && gen.Diffs[n-1].Time.After(at) {
+			n--
+		}
+		return gen, n, true
+	}
+	return Generation{}, 0, false
+}
+
+// Restore writes the database as it was at the last shipment at or before
+// at to output, the newest when at is zero, and returns that shipment. The
+// restored database is checked before it replaces output.
+func Restore(t Target, at time.Time, output string) (Shipment, error) {
+	m, err := LoadManifest(t)
+	if err != nil {
+		return Shipment{}, fmt.Errorf("load manifest: %w", err)
+	}
+	gen, n, ok := m.find(at)
+	if !ok {
+		return Shipment{}, errors.New("no shipment to restore from at that time")
+	}
+	shipment := gen.Snapshot
+	if n > 0 {
+		shipment = gen.Diffs[n-1]
+	}
+
+	tmp := output + ".restore"
+	f, err := os.OpenFile(tmp, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return Shipment{}, err
+	}
+	defer os.Remove(tmp)
+	err = getGzip(t, gen.Snapshot.Name, func(r io.Reader) error {
+		_, err := io.Copy(f, r)
+		return err
+	})
+	for _, diff := range gen.Diffs[:n] {
+		if err != nil {
+			break
+		}
+		err = getGzip(t, diff.Name, func(r io.Reader) error { return applyDiff(f, r) })
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return Shipment{}, err
+	}
+	if err := check(tmp); err != nil {
+		return Shipment{}, fmt.Errorf("restored database is corrupt: %w", err)
+	}
+	return shipment, os.Rename(tmp, output)
+}
+
+func getGzip(t Target, name string, read func(r io.Reader) error) error {
+	rc, err := t.Get(name)
+	if err != nil {
+		return fmt.Errorf("get %s: %w", name, err)
+	}
+	defer rc.Close()
+	zr, err := gzip.NewReader(rc)
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	if err := read(zr); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
+
+func check(path string) error {
+	db, err := bbolt.Open(path, 0600, &bbolt.Options{ReadOnly: true, Timeout: time.Second})
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	return db.View(func(tx *bbolt.Tx) error {
+		// the check runs until the channel is drained
+		var first error
+		for err := range tx.Check() {
+			if first == nil {
+				first = err
+			}
+		}
+		return first
+	})
+}
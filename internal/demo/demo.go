@@ -0,0 +1,408 @@
+// Package demo simulates a game server for pst --demo. A generated world is
+// served on loopback by a REST API and RCON like Palworld's, its players
+// walk around, join and leave, so the dashboard can be developed and shown
+// without a Palworld server.
+package demo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorcon/rcon"
+	"github.com/gorcon/rcon/rcontest"
+	"github.com/spf13/viper"
+	"github.com/zaigie/palworld-server-tool/internal/bus"
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/internal/fixture"
+	"github.com/zaigie/palworld-server-tool/internal/logger"
+	"github.com/zaigie/palworld-server-tool/service"
+	"go.etcd.io/bbolt"
+)
+
+const (
+	players      = 32
+	pals         = 8
+	guilds       = 6
+	maxPlayerNum = 32
+	// each tick an online player leaves with 1 in leaveChance and an
+	// offline one joins with 1 in joinChance, about a third are online
+	leaveChance = 40
+	joinChance  = 100
+	tick        = 5 * time.Second
+	// Password is the web.password of the demo unless one is configured
+	Password = "demo"
+)
+
+type onlinePlayer struct {
+	index int
+	x, y  float64
+	ping  float64
+	ip    string
+}
+
+type server struct {
+	sync.Mutex
+	r       *rand.Rand
+	world   fixture.World
+	online  map[string]*onlinePlayer
+	banned  map[string]bool
+	started time.Time
+	rounds  int64
+
+	rest    *http.Server
+	rcon    *rcontest.Server
+	dataDir string
+	stop    chan struct{}
+}
+
+var demo *server
+
+// Enabled is true when pst runs against the demo server.
+func Enabled() bool {
+	return demo != nil
+}
+
+// Start generates the world, serves it and points the config to it. pst.db
+// and the backups go to a scratch directory Stop removes, a configured
+// instance is not touched.
+func Start() error {
+	dataDir, err := os.MkdirTemp("", "pst-demo-")
+	if err != nil {
+		return err
+	}
+	s := &server{
+		r:       rand.New(rand.NewSource(time.Now().UnixNano())),
+		world:   fixture.Generate(fixture.Options{Players: players, Pals: pals, Guilds: guilds, Seed: 1}),
+		online:  make(map[string]*onlinePlayer),
+		banned:  make(map[string]bool),
+		started: time.Now(),
+		dataDir: dataDir,
+		stop:    make(chan struct{}),
+	}
+	for i := range s.world.Players {
+		if s.r.Intn(3) == 0 {
+			s.join(i)
+		}
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		os.RemoveAll(dataDir)
+		return err
+	}
+	s.rest = &http.Server{Handler: s.restHandler()}
+	go s.rest.Serve(listener)
+	s.rcon = rcontest.NewServer(
+		rcontest.SetSettings(rcontest.Settings{Password: Password}),
+		rcontest.SetCommandHandler(func(c *rcontest.Context) {
+			rcon.NewPacket(rcon.SERVERDATA_RESPONSE_VALUE, c.Request().ID, s.command(c.Request().Body())).WriteTo(c.Conn())
+		}),
+	)
+	go s.run()
+
+	settings := map[string]any{
+		"rest.address":          "http://" + listener.Addr().String(),
+		"rest.username":         "admin",
+		"rest.password":         Password,
+		"rest.fallbacks":        nil,
+		"rcon.address":          s.rcon.Addr(),
+		"rcon.password":         Password,
+		"rcon.fallbacks":        nil,
+		"save.path":             "demo://world",
+		"save.sync_interval":    60,
+		"save.backup_interval":  0,
+		"task.sync_interval":    10,
+		"paths.data_dir":        dataDir,
+		"paths.backup_dir":      "",
+		"replica.primary_url":   "",
+		"replica.snapshot_path": "",
+	}
+	for key, value := range settings {
+		viper.Set(key, value)
+	}
+	if viper.GetString("web.password") == "" {
+		viper.Set("web.password", Password)
+	}
+	demo = s
+	return nil
+}
+
+// Stop shuts the demo server down and removes its data.
+func Stop() {
+	if demo == nil {
+		return
+	}
+	close(demo.stop)
+	demo.rest.Close()
+	demo.rcon.Close()
+	os.RemoveAll(demo.dataDir)
+}
+
+func (s *server) run() {
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			s.step()
+		}
+	}
+}
+
+// step moves the online players a little and lets some leave and join.
+func (s *server) step() {
+	s.Lock()
+	defer s.Unlock()
+	for i, player := range s.world.Players {
+		p := s.online[player.PlayerUid]
+		switch {
+		case p == nil:
+			if !s.banned[player.SteamId] && len(s.online) < maxPlayerNum && s.r.Intn(joinChance) == 0 {
+				s.join(i)
+			}
+		case s.r.Intn(leaveChance) == 0:
+			delete(s.online, player.PlayerUid)
+		default:
+			p.x += s.r.NormFloat64() * 1500
+			p.y += s.r.NormFloat64() * 1500
+			p.ping = max(5, p.ping+s.r.NormFloat64()*5)
+		}
+	}
+}
+
+// join puts the player online near the base camp of their guild.
+func (s *server) join(i int) {
+	player := s.world.Players[i]
+	var x, y float64
+	if len(s.world.Guilds) > 0 {
+		base := s.world.Guilds[i%len(s.world.Guilds)].BaseCamp[0]
+		x, y = base.LocationX, base.LocationY
+	}
+	s.online[player.PlayerUid] = &onlinePlayer{
+		index: i,
+		x:     x + s.r.NormFloat64()*5000,
+		y:     y + s.r.NormFloat64()*5000,
+		ping:  float64(20 + s.r.Intn(100)),
+		ip:    fmt.Sprintf("203.0.113.%d", 1+i%254),
+	}
+}
+
+// userIndex finds the player of a user id of the REST API or RCON, with or
+// without the steam_ prefix.
+func (s *server) userIndex(userId string) (int, bool) {
+	steamId := strings.TrimPrefix(userId, "steam_")
+	for i, player := range s.world.Players {
+		if player.SteamId == steamId || player.PlayerUid == userId {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+func (s *server) restHandler() http.Handler {
+	mux := http.NewServeMux()
+	reply := func(w http.ResponseWriter, v any) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(v)
+	}
+	mux.HandleFunc("/v1/api/info", func(w http.ResponseWriter, r *http.Request) {
+		reply(w, map[string]string{"version": "v0.3.0 demo", "servername": "PST Demo", "description": "Generated players for pst --demo"})
+	})
+	mux.HandleFunc("/v1/api/metrics", func(w http.ResponseWriter, r *http.Request) {
+		s.Lock()
+		defer s.Unlock()
+		uptime := int(time.Since(s.started).Seconds())
+		reply(w, map[string]any{
+			"serverfps":        55 + s.r.Intn(6),
+			"currentplayernum": len(s.online),
+			"serverframetime":  16 + s.r.Float64()*2,
+			"maxplayernum":     maxPlayerNum,
+			"uptime":           uptime,
+			"days":             1 + uptime/1440,
+		})
+	})
+	mux.HandleFunc("/v1/api/players", func(w http.ResponseWriter, r *http.Request) {
+		s.Lock()
+		defer s.Unlock()
+		list := make([]map[string]any, 0, len(s.online))
+		for _, p := range s.online {
+			player := s.world.Players[p.index]
+			list = append(list, map[string]any{
+				"name":       player.Nickname,
+				"playerId":   player.PlayerUid,
+				"userId":     "steam_" + player.SteamId,
+				"ip":         p.ip,
+				"ping":       p.ping,
+				"location_x": p.x,
+				"location_y": p.y,
+				"level":      player.Level,
+			})
+		}
+		reply(w, map[string]any{"players": list})
+	})
+	for _, action := range []string{"kick", "ban", "unban"} {
+		action := action
+		mux.HandleFunc("/v1/api/"+action, func(w http.ResponseWriter, r *http.Request) {
+			var req struct {
+				UserId string `json:"userid"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if err := s.moderate(action, req.UserId); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			reply(w, map[string]any{})
+		})
+	}
+	mux.HandleFunc("/v1/api/announce", func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Message string `json:"message"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		logger.Infof("Demo broadcast: %s\n", req.Message)
+		reply(w, map[string]any{})
+	})
+	for _, api := range []string{"save", "shutdown", "stop"} {
+		mux.HandleFunc("/v1/api/"+api, func(w http.ResponseWriter, r *http.Request) {
+			reply(w, map[string]any{})
+		})
+	}
+	return mux
+}
+
+// moderate kicks, bans or unbans a player, banned players don't join again.
+func (s *server) moderate(action, userId string) error {
+	s.Lock()
+	defer s.Unlock()
+	i, ok := s.userIndex(userId)
+	if !ok {
+		return errors.New("player not found")
+	}
+	player := s.world.Players[i]
+	switch action {
+	case "kick":
+		delete(s.online, player.PlayerUid)
+	case "ban":
+		delete(s.online, player.PlayerUid)
+		s.banned[player.SteamId] = true
+	case "unban":
+		delete(s.banned, player.SteamId)
+	}
+	return nil
+}
+
+var moderated = map[string]string{"kick": "Kicked", "ban": "Banned", "unban": "Unbanned"}
+
+// command answers an RCON command the way the game server does.
+func (s *server) command(command string) string {
+	name, args, _ := strings.Cut(command, " ")
+	switch strings.ToLower(name) {
+	case "info":
+		return "Welcome to Pal Server[v0.3.0 demo] PST Demo"
+	case "showplayers":
+		s.Lock()
+		defer s.Unlock()
+		var b strings.Builder
+		b.WriteString("name,playeruid,steamid\n")
+		for _, p := range s.online {
+			player := s.world.Players[p.index]
+			fmt.Fprintf(&b, "%s,%s,%s\n", player.Nickname, player.PlayerUid, player.SteamId)
+		}
+		return b.String()
+	case "broadcast":
+		logger.Infof("Demo broadcast: %s\n", args)
+		return "Broadcasted: " + args
+	case "kickplayer", "banplayer", "unbanplayer":
+		action := strings.TrimSuffix(strings.ToLower(name), "player")
+		if err := s.moderate(action, args); err != nil {
+			return fmt.Sprintf("Failed to %s: %s", action, args)
+		}
+		return fmt.Sprintf("%s: %s", moderated[action], args)
+	case "save":
+		return "Complete Save"
+	}
+	return "Demo: " + command
+}
+
+// SavSync writes the world like a sync of the save does, journaled as a
+// batch, the online players have played a little since the last one.
+func SavSync(db *bbolt.DB) (err error) {
+	if demo == nil {
+		return errors.New("demo is not running")
+	}
+	s := demo
+	s.Lock()
+	defer s.Unlock()
+	s.rounds++
+	s.world.Advance(s.rounds)
+	now := time.Now().Format(time.RFC3339)
+	for _, p := range s.online {
+		s.world.Players[p.index].SaveLastOnline = now
+	}
+	for _, guild := range s.world.Guilds {
+		for _, member := range guild.Players {
+			if _, ok := s.online[member.PlayerUid]; ok {
+				member.LastOnline = now
+			}
+		}
+	}
+
+	batch, err := service.BeginSyncBatch(db, database.SyncBatch{Source: "demo", SaveTime: time.Now()})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if _, ferr := service.FinishSyncBatch(db, batch.Id, err, viper.GetInt("save.journal_keep")); ferr != nil && err == nil {
+			err = ferr
+		}
+	}()
+	err = service.JournalStep(db, batch.Id, service.SyncStepPlayers, s.world.Players, len(s.world.Players), func(j *service.StepJournal) error {
+		events, err := service.PutPlayers(db, s.world.Players, j)
+		if err != nil {
+			return err
+		}
+		bus.Publish(events...)
+		if events, err = service.TrackPals(db, s.world.Players); err != nil {
+			return err
+		}
+		bus.Publish(events...)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	err = service.JournalStep(db, batch.Id, service.SyncStepGuilds, s.world.Guilds, len(s.world.Guilds), func(j *service.StepJournal) error {
+		events, err := service.PutGuilds(db, s.world.Guilds, service.GuildsReplace, service.RaidThreshold{
+			Structures: viper.GetInt("manage.base_raid_structures"),
+			HpPercent:  viper.GetFloat64("manage.base_raid_hp_percent"),
+		}, j)
+		if err != nil {
+			return err
+		}
+		bus.Publish(events...)
+		if events, err = service.TrackGuildStorage(db, s.world.Guilds,
+			viper.GetInt64("manage.storage_withdrawal"),
+			time.Duration(viper.GetInt("manage.storage_keep_days"))*24*time.Hour); err != nil {
+			return err
+		}
+		bus.Publish(events...)
+		return service.RecordGuildStats(db)
+	})
+	if err != nil {
+		return err
+	}
+	return service.RecordDailySnapshot(db)
+}
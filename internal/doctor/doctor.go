@@ -0,0 +1,303 @@
+// Package doctor tries what the config points to: the RCON and REST API of
+// the game server, the save, sav_cli and pst's own directories, for pst
+// config doctor and the setup.
+package doctor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gorcon/rcon"
+	"github.com/spf13/viper"
+	"github.com/zaigie/palworld-server-tool/internal/config"
+	"github.com/zaigie/palworld-server-tool/internal/executor"
+	"github.com/zaigie/palworld-server-tool/internal/paths"
+	"github.com/zaigie/palworld-server-tool/internal/system"
+	"github.com/zaigie/palworld-server-tool/internal/tool"
+)
+
+// Result is the outcome of one check, which passed when Problem is nil.
+type Result struct {
+	Key     string
+	Message string
+	Problem *config.Problem
+}
+
+func passed(key, message string) Result {
+	return Result{Key: key, Message: message}
+}
+
+func failed(key string, err error, fix string) Result {
+	return Result{Key: key, Problem: &config.Problem{Key: key, Message: err.Error(), Fix: fix}}
+}
+
+// Run runs every check, skipping those of keys that already failed the
+// config check.
+func Run(skip map[string]bool) []Result {
+	var results []Result
+	if !skip["rcon.address"] && !skip["rcon.password"] {
+		results = append(results, Rcon())
+	}
+	if !skip["rest.address"] && !skip["rest.password"] {
+		results = append(results, Rest())
+	}
+	results = append(results, Fallbacks(skip)...)
+	if !skip["save.path"] {
+		results = append(results, Save())
+	}
+	results = append(results, SavCli())
+	results = append(results, Dirs()...)
+	return append(results, Files()...)
+}
+
+// Rcon connects to rcon.address and runs Info.
+func Rcon() Result {
+	return rconAt("rcon.address", viper.GetString("rcon.address"), viper.GetString("rcon.password"), viper.GetInt("rcon.timeout"))
+}
+
+func rconAt(key, address, password string, timeout int) Result {
+	exec, err := executor.NewExecutor(address, password, timeout, true)
+	if err == nil {
+		_, err = exec.Execute("Info")
+		exec.Close()
+	}
+	switch {
+	case err == nil:
+		return passed(key, "connected to "+address)
+	case errors.Is(err, rcon.ErrAuthFailed), errors.Is(err, executor.ErrPasswordEmpty):
+		return failed("rcon.password", err, "use AdminPassword of PalWorldSettings.ini")
+	default:
+		return failed(key, err, "start the server with RCONEnabled=True and check RCONPort and the firewall")
+	}
+}
+
+// Rest asks the REST API at rest.address for the server info.
+func Rest() Result {
+	return restAt("rest.address", viper.GetString("rest.address"), viper.GetString("rest.password"), viper.GetInt("rest.timeout"))
+}
+
+// restAt asks the REST API at address as rest.username with password.
+func restAt(key, address, password string, timeout int) Result {
+	var info struct {
+		Version    string `json:"version"`
+		ServerName string `json:"servername"`
+	}
+	err := func() error {
+		u, err := url.JoinPath(address, "/v1/api/info")
+		if err != nil {
+			return err
+		}
+		req, err := http.NewRequest(http.MethodGet, u, nil)
+		if err != nil {
+			return err
+		}
+		req.SetBasicAuth(viper.GetString("rest.username"), password)
+		client := &http.Client{Timeout: time.Duration(timeout) * time.Second}
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode == http.StatusUnauthorized {
+			return errUnauthorized
+		}
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("rest: %s", resp.Status)
+		}
+		return json.NewDecoder(resp.Body).Decode(&info)
+	}()
+	switch {
+	case err == nil:
+		return passed(key, fmt.Sprintf("%s %s", info.ServerName, info.Version))
+	case errors.Is(err, errUnauthorized):
+		return failed("rest.password", err, "use AdminPassword of PalWorldSettings.ini and username admin")
+	default:
+		return failed(key, err, "start the server with RESTAPIEnabled=True and check RESTAPIPort and the firewall")
+	}
+}
+
+var errUnauthorized = errors.New("rest: 401 unauthorized")
+
+// Fallbacks tries the fallback endpoints of RCON and the REST API, but not
+// those in skip.
+func Fallbacks(skip map[string]bool) []Result {
+	var results []Result
+	for _, section := range []struct {
+		name  string
+		check func(key, address, password string, timeout int) Result
+	}{{"rcon", rconAt}, {"rest", restAt}} {
+		var fallbacks []struct {
+			Address string `mapstructure:"address"`
+			Timeout int    `mapstructure:"timeout"`
+		}
+		viper.UnmarshalKey(section.name+".fallbacks", &fallbacks)
+		for i, f := range fallbacks {
+			key := fmt.Sprintf("%s.fallbacks[%d].address", section.name, i)
+			if skip[key] {
+				continue
+			}
+			if f.Timeout == 0 {
+				f.Timeout = viper.GetInt(section.name + ".timeout")
+			}
+			results = append(results, section.check(key, f.Address, viper.GetString(section.name+".password"), f.Timeout))
+		}
+	}
+	return results
+}
+
+// Save looks for the Level.sav of a local save.path the way a sync does,
+// remote ones are only copied during a sync.
+func Save() Result {
+	return saveAt(viper.GetString("save.path"))
+}
+
+func saveAt(path string) Result {
+	if scheme, _, remote := strings.Cut(path, "://"); remote {
+		return passed("save.path", "not checked, it is copied from "+scheme)
+	}
+	level, err := levelSav(path)
+	if err != nil {
+		return failed("save.path", err, "point it to the Pal/Saved directory or a Level.sav pst can read")
+	}
+	return passed("save.path", "found "+level)
+}
+
+func levelSav(path string) (string, error) {
+	isDir, err := system.CheckIsDir(path)
+	if err != nil {
+		return "", err
+	}
+	if !isDir {
+		if filepath.Base(path) != "Level.sav" {
+			return "", fmt.Errorf("%s is not Level.sav or a directory", path)
+		}
+	} else if path, err = system.GetLevelSavFilePath(path); err != nil {
+		return "", err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	f.Close()
+	return path, nil
+}
+
+// SavCli checks sav_cli exists and can be run.
+func SavCli() Result {
+	savCli, err := tool.SavCli()
+	if err != nil {
+		return failed("save.decode_path", err, "put sav_cli next to pst or set save.decode_path to it")
+	}
+	if info, err := os.Stat(savCli); err == nil && info.Mode()&0o111 == 0 && !strings.HasSuffix(savCli, ".exe") {
+		return failed("save.decode_path", fmt.Errorf("%s is not executable", savCli), "chmod +x "+savCli)
+	}
+	return passed("save.decode_path", savCli)
+}
+
+// Dirs checks pst can write to its directories.
+func Dirs() []Result {
+	dirs := []struct{ key, dir string }{
+		{"paths.data_dir", paths.Data()},
+		{"paths.backup_dir", paths.Backups()},
+		{"paths.cache_dir", paths.Cache()},
+		{"paths.log_dir", paths.Logs()},
+	}
+	var results []Result
+	for _, d := range dirs {
+		if d.dir == "" {
+			continue
+		}
+		if err := checkWritable(d.dir); err != nil {
+			results = append(results, failed(d.key, err, fmt.Sprintf("give the user running pst write access to %s or set %s", d.dir, d.key)))
+		} else {
+			results = append(results, passed(d.key, d.dir+" writable"))
+		}
+	}
+	return results
+}
+
+// checkWritable creates a file in dir, or in the nearest parent that exists
+// when pst would still have to create dir.
+func checkWritable(dir string) error {
+	for {
+		if _, err := os.Stat(dir); err == nil {
+			break
+		} else if !os.IsNotExist(err) {
+			return err
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	f, err := os.CreateTemp(dir, ".pst-doctor-*")
+	if err != nil {
+		return err
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
+
+// Files checks the files pst reads can be opened.
+func Files() []Result {
+	keys := []string{"server.settings_path", "chat.log_path"}
+	if viper.GetBool("web.tls") {
+		keys = append(keys, "web.cert_path", "web.key_path")
+	}
+	var results []Result
+	for _, key := range keys {
+		path := viper.GetString(key)
+		if path == "" {
+			continue
+		}
+		if f, err := os.Open(path); err != nil {
+			results = append(results, failed(key, err, "fix the path or give the user running pst read access"))
+		} else {
+			f.Close()
+			results = append(results, passed(key, path+" readable"))
+		}
+	}
+	return results
+}
+
+// Setup checks the settings of the setup without applying them, first
+// their values and then the game server and the save they point to.
+func Setup(s config.Setup) []config.Problem {
+	var problems []config.Problem
+	skip := make(map[string]bool)
+	for _, p := range s.Check() {
+		if _, ok := config.SetupKeys[p.Key]; ok && !p.Warning {
+			problems = append(problems, p)
+			skip[p.Key] = true
+		}
+	}
+	for _, check := range []struct {
+		keys []string
+		run  func() Result
+	}{
+		{[]string{"rcon.address", "rcon.password"}, func() Result {
+			return rconAt("rcon.address", s.RconAddress, s.AdminPassword, viper.GetInt("rcon.timeout"))
+		}},
+		{[]string{"rest.address", "rest.password"}, func() Result {
+			return restAt("rest.address", s.RestAddress, s.AdminPassword, viper.GetInt("rest.timeout"))
+		}},
+		{[]string{"save.path"}, func() Result { return saveAt(s.SavePath) }},
+	} {
+		if slices.ContainsFunc(check.keys, func(key string) bool { return skip[key] }) {
+			continue
+		}
+		if r := check.run(); r.Problem != nil {
+			problems = append(problems, *r.Problem)
+		}
+	}
+	return problems
+}
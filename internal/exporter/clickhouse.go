@@ -0,0 +1,92 @@
+package exporter
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// clickHouse inserts JSONEachRow over the http interface.
+type clickHouse struct{}
+
+var clickHouseTables = []string{
+	`CREATE TABLE IF NOT EXISTS %s.pst_online (time DateTime, players UInt32) ENGINE = MergeTree ORDER BY time`,
+	`CREATE TABLE IF NOT EXISTS %s.pst_players (time DateTime, player_uid String, nickname String, level Int32, exp Int64, online UInt8, platform String) ENGINE = MergeTree ORDER BY (player_uid, time)`,
+	`CREATE TABLE IF NOT EXISTS %s.pst_guilds (time DateTime, admin_player_uid String, name String, level Int32, members UInt32, base_camps UInt32) ENGINE = MergeTree ORDER BY (admin_player_uid, time)`,
+}
+
+func (c clickHouse) Write(target Target, snapshot Snapshot) error {
+	database := target.Database
+	if database == "" {
+		database = "default"
+	}
+	err := prepare(target, func() error {
+		for _, table := range clickHouseTables {
+			if err := c.exec(target, strings.Replace(table, "%s", database, 1), nil); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	ts := snapshot.Time.Unix()
+	if err := c.insert(target, database+".pst_online", []any{map[string]any{"time": ts, "players": snapshot.Online}}); err != nil {
+		return err
+	}
+	players := make([]any, 0, len(snapshot.Players))
+	for _, p := range snapshot.Players {
+		online := 0
+		if p.Online {
+			online = 1
+		}
+		players = append(players, map[string]any{
+			"time": ts, "player_uid": p.PlayerUid, "nickname": p.Nickname, "level": p.Level,
+			"exp": p.Exp, "online": online, "platform": p.Platform,
+		})
+	}
+	if err := c.insert(target, database+".pst_players", players); err != nil {
+		return err
+	}
+	guilds := make([]any, 0, len(snapshot.Guilds))
+	for _, g := range snapshot.Guilds {
+		guilds = append(guilds, map[string]any{
+			"time": ts, "admin_player_uid": g.AdminPlayerUid, "name": g.Name, "level": g.Level,
+			"members": g.Members, "base_camps": g.BaseCamps,
+		})
+	}
+	return c.insert(target, database+".pst_guilds", guilds)
+}
+
+func (c clickHouse) insert(target Target, table string, rows []any) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	var body bytes.Buffer
+	enc := json.NewEncoder(&body)
+	for _, row := range rows {
+		if err := enc.Encode(row); err != nil {
+			return err
+		}
+	}
+	return c.exec(target, "INSERT INTO "+table+" FORMAT JSONEachRow", &body)
+}
+
+func (clickHouse) exec(target Target, query string, body *bytes.Buffer) error {
+	if body == nil {
+		body = &bytes.Buffer{}
+	}
+	req, err := http.NewRequest(http.MethodPost, strings.TrimSuffix(target.Url, "/")+"/?"+url.Values{"query": {query}}.Encode(), body)
+	if err != nil {
+		return err
+	}
+	if target.Username != "" {
+		req.Header.Set("X-ClickHouse-User", target.Username)
+		req.Header.Set("X-ClickHouse-Key", target.Password)
+	}
+	return post(req)
+}
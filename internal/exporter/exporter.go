@@ -0,0 +1,153 @@
+// Package exporter writes periodic snapshots of players, guilds and the
+// online count to external time series databases for long term analytics.
+package exporter
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// Target is an entry of export.targets.
+type Target struct {
+	// Type is the driver, influxdb, timescaledb or clickhouse
+	Type string `mapstructure:"type"`
+	// Url is the http address, or the postgres connection string for
+	// timescaledb
+	Url string `mapstructure:"url"`
+	// Database is the bucket for influxdb
+	Database string `mapstructure:"database"`
+	Org      string `mapstructure:"org"`
+	Token    string `mapstructure:"token"`
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+	// Command runs the SQL for timescaledb, psql by default
+	Command string `mapstructure:"command"`
+}
+
+type Snapshot struct {
+	Time    time.Time
+	Online  int
+	Players []PlayerStat
+	Guilds  []GuildStat
+}
+
+type PlayerStat struct {
+	PlayerUid string
+	Nickname  string
+	Level     int32
+	Exp       int64
+	Online    bool
+	Platform  string
+}
+
+type GuildStat struct {
+	AdminPlayerUid string
+	Name           string
+	Level          int32
+	Members        int
+	BaseCamps      int
+}
+
+// Driver writes snapshots to a kind of database, creating what it needs
+// there on the first write.
+type Driver interface {
+	Write(target Target, snapshot Snapshot) error
+}
+
+var (
+	driversMu sync.RWMutex
+	drivers   = map[string]Driver{
+		"influxdb":    influxDB{},
+		"clickhouse":  clickHouse{},
+		"timescaledb": timescaleDB{},
+	}
+
+	// prepared holds the targets whose tables were created this run
+	preparedMu sync.Mutex
+	prepared   = make(map[Target]bool)
+)
+
+var client = &http.Client{Timeout: 30 * time.Second}
+
+// Register adds a driver for targets of the type.
+func Register(name string, driver Driver) {
+	driversMu.Lock()
+	defer driversMu.Unlock()
+	drivers[name] = driver
+}
+
+// Targets returns the configured export.targets.
+func Targets() ([]Target, error) {
+	var targets []Target
+	if err := viper.UnmarshalKey("export.targets", &targets); err != nil {
+		return nil, fmt.Errorf("parse export.targets fail, %v", err)
+	}
+	return targets, nil
+}
+
+// Export writes the snapshot to every target, a failing target doesn't keep
+// the others from getting it.
+func Export(snapshot Snapshot) error {
+	targets, err := Targets()
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, target := range targets {
+		driversMu.RLock()
+		driver, ok := drivers[target.Type]
+		driversMu.RUnlock()
+		if !ok {
+			errs = append(errs, fmt.Errorf("unknown export type %s", target.Type))
+			continue
+		}
+		if err := driver.Write(target, snapshot); err != nil {
+			errs = append(errs, fmt.Errorf("export to %s %s: %w", target.Type, redact(target.Url), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// prepare runs setup for a target once per run.
+func prepare(target Target, setup func() error) error {
+	preparedMu.Lock()
+	defer preparedMu.Unlock()
+	if prepared[target] {
+		return nil
+	}
+	if err := setup(); err != nil {
+		return err
+	}
+	prepared[target] = true
+	return nil
+}
+
+// redact hides the password of a connection url in errors.
+func redact(rawUrl string) string {
+	u, err := url.Parse(rawUrl)
+	if err != nil {
+		return "target"
+	}
+	return u.Redacted()
+}
+
+func post(req *http.Request) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package exporter
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// influxDB writes line protocol to the v2 write api, which InfluxDB 1.8 and
+// later also serve.
+type influxDB struct{}
+
+var (
+	tagEscaper    = strings.NewReplacer(",", `\,`, " ", `\ `, "=", `\=`)
+	stringEscaper = strings.NewReplacer(`"`, `\"`, `\`, `\\`)
+)
+
+func (influxDB) Write(target Target, snapshot Snapshot) error {
+	ts := snapshot.Time.Unix()
+	var b strings.Builder
+	fmt.Fprintf(&b, "pst_online players=%di %d\n", snapshot.Online, ts)
+	for _, p := range snapshot.Players {
+		fmt.Fprintf(&b, "pst_players,player_uid=%s,platform=%s nickname=\"%s\",level=%di,exp=%di,online=%t %d\n",
+			tagValue(p.PlayerUid), tagValue(p.Platform), stringEscaper.Replace(p.Nickname), p.Level, p.Exp, p.Online, ts)
+	}
+	for _, g := range snapshot.Guilds {
+		fmt.Fprintf(&b, "pst_guilds,admin_player_uid=%s name=\"%s\",level=%di,members=%di,base_camps=%di %d\n",
+			tagValue(g.AdminPlayerUid), stringEscaper.Replace(g.Name), g.Level, g.Members, g.BaseCamps, ts)
+	}
+	query := url.Values{"bucket": {target.Database}, "org": {target.Org}, "precision": {"s"}}
+	req, err := http.NewRequest(http.MethodPost, strings.TrimSuffix(target.Url, "/")+"/api/v2/write?"+query.Encode(), strings.NewReader(b.String()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if target.Token != "" {
+		req.Header.Set("Authorization", "Token "+target.Token)
+	} else if target.Username != "" {
+		req.SetBasicAuth(target.Username, target.Password)
+	}
+	return post(req)
+}
+
+// tagValue escapes a tag value, an empty tag isn't allowed in line protocol.
+func tagValue(s string) string {
+	if s == "" {
+		return "none"
+	}
+	return tagEscaper.Replace(s)
+}
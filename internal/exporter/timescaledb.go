@@ -0,0 +1,77 @@
+package exporter
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// timescaleDB runs the SQL through psql, Url being its connection string.
+type timescaleDB struct{}
+
+const timescaleSchema = `
+CREATE TABLE IF NOT EXISTS pst_online (time TIMESTAMPTZ NOT NULL, players INTEGER);
+CREATE TABLE IF NOT EXISTS pst_players (time TIMESTAMPTZ NOT NULL, player_uid TEXT, nickname TEXT, level INTEGER, exp BIGINT, online BOOLEAN, platform TEXT);
+CREATE TABLE IF NOT EXISTS pst_guilds (time TIMESTAMPTZ NOT NULL, admin_player_uid TEXT, name TEXT, level INTEGER, members INTEGER, base_camps INTEGER);
+SELECT create_hypertable('pst_online', 'time', if_not_exists => TRUE);
+SELECT create_hypertable('pst_players', 'time', if_not_exists => TRUE);
+SELECT create_hypertable('pst_guilds', 'time', if_not_exists => TRUE);
+`
+
+func (t timescaleDB) Write(target Target, snapshot Snapshot) error {
+	if err := prepare(target, func() error { return t.exec(target, timescaleSchema) }); err != nil {
+		return err
+	}
+	ts := fmt.Sprintf("to_timestamp(%d)", snapshot.Time.Unix())
+	var b strings.Builder
+	b.WriteString("BEGIN;\n")
+	fmt.Fprintf(&b, "INSERT INTO pst_online VALUES (%s, %d);\n", ts, snapshot.Online)
+	if len(snapshot.Players) > 0 {
+		b.WriteString("INSERT INTO pst_players VALUES\n")
+		for i, p := range snapshot.Players {
+			if i > 0 {
+				b.WriteString(",\n")
+			}
+			fmt.Fprintf(&b, "(%s, %s, %s, %d, %d, %t, %s)", ts, sqlString(p.PlayerUid), sqlString(p.Nickname), p.Level, p.Exp, p.Online, sqlString(p.Platform))
+		}
+		b.WriteString(";\n")
+	}
+	if len(snapshot.Guilds) > 0 {
+		b.WriteString("INSERT INTO pst_guilds VALUES\n")
+		for i, g := range snapshot.Guilds {
+			if i > 0 {
+				b.WriteString(",\n")
+			}
+			fmt.Fprintf(&b, "(%s, %s, %s, %d, %d, %d)", ts, sqlString(g.AdminPlayerUid), sqlString(g.Name), g.Level, g.Members, g.BaseCamps)
+		}
+		b.WriteString(";\n")
+	}
+	b.WriteString("COMMIT;\n")
+	return t.exec(target, b.String())
+}
+
+func (timescaleDB) exec(target Target, sql string) error {
+	command := target.Command
+	if command == "" {
+		command = "psql"
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, command, "-X", "-q", "-v", "ON_ERROR_STOP=1", "-d", target.Url, "-f", "-")
+	cmd.Stdin = strings.NewReader(sql)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// sqlString quotes a string literal, standard_conforming_strings being on.
+func sqlString(s string) string {
+	s = strings.ReplaceAll(s, "\x00", "")
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
@@ -0,0 +1,153 @@
+package filter
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Record returns the value of a field of the filtered record, nil when the
+// record has no such field.
+type Record func(field string) any
+
+type Expr interface {
+	Match(r Record) bool
+}
+
+type and struct{ left, right Expr }
+
+func (e and) Match(r Record) bool { return e.left.Match(r) && e.right.Match(r) }
+
+type or struct{ left, right Expr }
+
+func (e or) Match(r Record) bool { return e.left.Match(r) || e.right.Match(r) }
+
+type not struct{ expr Expr }
+
+func (e not) Match(r Record) bool { return !e.expr.Match(r) }
+
+type truthy struct{ field string }
+
+func (e truthy) Match(r Record) bool {
+	v := r(e.field)
+	if v == nil {
+		return false
+	}
+	if t, ok := v.(time.Time); ok {
+		return !t.IsZero()
+	}
+	return !reflect.ValueOf(v).IsZero()
+}
+
+// value is a literal with every reading of it that parses.
+type value struct {
+	raw    string
+	isNum  bool
+	num    float64
+	isDur  bool
+	dur    time.Duration
+	isTime bool
+	time   time.Time
+}
+
+type compare struct {
+	field string
+	op    string
+	value value
+}
+
+func (e compare) Match(r Record) bool {
+	switch v := r(e.field).(type) {
+	case nil:
+		return false
+	case time.Time:
+		if e.value.isDur {
+			// the age, never seen is older than any duration
+			age := time.Duration(1<<63 - 1)
+			if !v.IsZero() {
+				age = time.Since(v)
+			}
+			return order(e.op, float64(age), float64(e.value.dur))
+		}
+		if e.value.isTime && !v.IsZero() {
+			return order(e.op, float64(v.UnixNano()), float64(e.value.time.UnixNano()))
+		}
+		return false
+	case string:
+		return compareString(e.op, v, e.value.raw)
+	case bool:
+		want := strings.EqualFold(e.value.raw, "true")
+		if !want && !strings.EqualFold(e.value.raw, "false") {
+			return false
+		}
+		switch e.op {
+		case "=":
+			return v == want
+		case "!=":
+			return v != want
+		}
+		return false
+	case []string:
+		found := false
+		for _, s := range v {
+			if e.op == "~" && compareString("~", s, e.value.raw) || e.op != "~" && strings.EqualFold(s, e.value.raw) {
+				found = true
+				break
+			}
+		}
+		if e.op == "!=" {
+			return !found
+		}
+		return (e.op == "=" || e.op == "~") && found
+	default:
+		n, ok := number(v)
+		if !ok || !e.value.isNum {
+			return false
+		}
+		return order(e.op, n, e.value.num)
+	}
+}
+
+func number(v any) (float64, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	}
+	return 0, false
+}
+
+func order(op string, a, b float64) bool {
+	switch op {
+	case "=":
+		return a == b
+	case "!=":
+		return a != b
+	case ">":
+		return a > b
+	case ">=":
+		return a >= b
+	case "<":
+		return a < b
+	case "<=":
+		return a <= b
+	}
+	return false
+}
+
+func compareString(op, a, b string) bool {
+	switch op {
+	case "=":
+		return strings.EqualFold(a, b)
+	case "!=":
+		return !strings.EqualFold(a, b)
+	case "~":
+		return strings.Contains(strings.ToLower(a), strings.ToLower(b))
+	}
+	c := strings.Compare(a, b)
+	return order(op, float64(c), 0)
+}
@@ -0,0 +1,238 @@
+// Package filter parses filter expressions like
+//
+//	level>45 AND last_online<7d AND NOT whitelisted
+//
+// made of field comparisons joined by AND, OR and NOT with parentheses.
+// Comparing a time field to a duration compares its age, so last_online<7d
+// is a player seen in the last 7 days, comparing it to a date or RFC3339
+// time compares the times. = and != on a list field test membership, ~
+// tests for a substring, a field on its own tests it isn't empty.
+package filter
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+type parser struct {
+	src   []rune
+	pos   int
+	known func(field string) bool
+}
+
+// Parse reads an expression, fields known returns false for are errors. A
+// nil known allows every field.
+func Parse(src string, known func(field string) bool) (Expr, error) {
+	p := &parser{src: []rune(src), known: known}
+	p.skip()
+	if p.pos == len(p.src) {
+		return nil, p.errorf("empty expression")
+	}
+	expr, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.src) {
+		return nil, p.errorf("unexpected %q", p.src[p.pos])
+	}
+	return expr, nil
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return fmt.Errorf("syntax error at %d: %s", p.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) skip() {
+	for p.pos < len(p.src) && unicode.IsSpace(p.src[p.pos]) {
+		p.pos++
+	}
+}
+
+// keyword consumes one of words, case insensitive and not followed by
+// another name character.
+func (p *parser) keyword(words ...string) bool {
+	for _, word := range words {
+		end := p.pos + len(word)
+		if end > len(p.src) || !strings.EqualFold(string(p.src[p.pos:end]), word) {
+			continue
+		}
+		if isName(word[0]) && end < len(p.src) && isNameRune(p.src[end]) {
+			continue
+		}
+		p.pos = end
+		p.skip()
+		return true
+	}
+	return false
+}
+
+func isName(b byte) bool {
+	return isNameRune(rune(b))
+}
+
+func isNameRune(r rune) bool {
+	return r == '_' || r == '.' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func (p *parser) or() (Expr, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("OR", "||") {
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		left = or{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) and() (Expr, error) {
+	left, err := p.not()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("AND", "&&") {
+		right, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		left = and{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) not() (Expr, error) {
+	if p.keyword("NOT", "!") {
+		expr, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		return not{expr}, nil
+	}
+	if p.keyword("(") {
+		expr, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if !p.keyword(")") {
+			return nil, p.errorf("expected )")
+		}
+		return expr, nil
+	}
+	return p.comparison()
+}
+
+func (p *parser) peekOp(op string) bool {
+	end := p.pos + len(op)
+	return end <= len(p.src) && string(p.src[p.pos:end]) == op
+}
+
+var ops = []string{"!=", ">=", "<=", "=", ">", "<", "~"}
+
+func (p *parser) comparison() (Expr, error) {
+	start := p.pos
+	for p.pos < len(p.src) && isNameRune(p.src[p.pos]) {
+		p.pos++
+	}
+	field := string(p.src[start:p.pos])
+	if field == "" {
+		if p.pos == len(p.src) {
+			return nil, p.errorf("expected field")
+		}
+		return nil, p.errorf("unexpected %q", p.src[p.pos])
+	}
+	if p.known != nil && !p.known(field) {
+		p.pos = start
+		return nil, p.errorf("unknown field %s", field)
+	}
+	p.skip()
+	for _, op := range ops {
+		if p.peekOp(op) {
+			p.pos += len(op)
+			p.skip()
+			v, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			return compare{field: field, op: op, value: v}, nil
+		}
+	}
+	return truthy{field}, nil
+}
+
+var durationPattern = regexp.MustCompile(`^(\d+(?:\.\d+)?)([smhdw])$`)
+
+var durationUnits = map[string]time.Duration{
+	"s": time.Second,
+	"m": time.Minute,
+	"h": time.Hour,
+	"d": 24 * time.Hour,
+	"w": 7 * 24 * time.Hour,
+}
+
+func (p *parser) value() (value, error) {
+	if p.pos == len(p.src) {
+		return value{}, p.errorf("expected value")
+	}
+	var v value
+	if r := p.src[p.pos]; r == '"' || r == '\'' {
+		s, err := p.quoted(r)
+		if err != nil {
+			return value{}, err
+		}
+		v.raw = s
+	} else {
+		start := p.pos
+		for p.pos < len(p.src) && !unicode.IsSpace(p.src[p.pos]) && p.src[p.pos] != ')' {
+			p.pos++
+		}
+		v.raw = string(p.src[start:p.pos])
+		if v.raw == "" {
+			return value{}, p.errorf("expected value")
+		}
+		if n, err := strconv.ParseFloat(v.raw, 64); err == nil {
+			v.isNum, v.num = true, n
+		}
+		if m := durationPattern.FindStringSubmatch(v.raw); m != nil {
+			n, _ := strconv.ParseFloat(m[1], 64)
+			v.isDur, v.dur = true, time.Duration(n*float64(durationUnits[m[2]]))
+		}
+	}
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, v.raw, time.Local); err == nil {
+			v.isTime, v.time = true, t
+			break
+		}
+	}
+	p.skip()
+	return v, nil
+}
+
+func (p *parser) quoted(quote rune) (string, error) {
+	p.pos++
+	var sb strings.Builder
+	for p.pos < len(p.src) {
+		r := p.src[p.pos]
+		p.pos++
+		switch r {
+		case quote:
+			return sb.String(), nil
+		case '\\':
+			if p.pos == len(p.src) {
+				return "", p.errorf("unterminated string")
+			}
+			sb.WriteRune(p.src[p.pos])
+			p.pos++
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	return "", p.errorf("unterminated string")
+}
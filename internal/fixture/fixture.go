@@ -0,0 +1,203 @@
+// Package fixture synthesizes the players and guilds sav_cli would parse
+// from a Level.sav, for load testing and repeatable runs of the service
+// layer without a game server.
+package fixture
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/zaigie/palworld-server-tool/internal/database"
+)
+
+type Options struct {
+	Players int
+	// Pals is how many pals each player owns
+	Pals   int
+	Guilds int
+	// Seed makes the same options generate the same world
+	Seed int64
+}
+
+type World struct {
+	Players []database.Player
+	Guilds  []database.Guild
+}
+
+var palTypes = []string{
+	"Lamball", "Cattiva", "Chikipi", "Foxparks", "Pengullet", "Depresso",
+	"Anubis", "Jetragon", "Frostallion", "Lyleen", "Relaxaurus", "Grizzbolt",
+}
+
+var palLocations = []string{"party", "palbox", "base"}
+
+// technologies are unlocked in this order, a level unlocks about one
+var technologies = []string{
+	"Workbench", "PalSphere", "Campfire", "StoneAxe", "WoodenChest", "Bed",
+	"PalBox", "MiningSite", "Furnace", "MegaSphere", "ProductionLine", "GigaSphere",
+}
+
+var storageItems = []string{"wood", "stone", "ingot", "paldium", "pal_sphere", "money"}
+
+var palSkills = []string{
+	"Swift", "Serious", "Artisan", "Lucky", "Legend", "Vampiric",
+	"Ferocious", "Hard Skin", "Workaholic", "Nimble",
+}
+
+// Generate returns a world of opts.Players players spread over opts.Guilds
+// guilds, each guild with a base camp. Player uids are canonical and stable
+// for a seed.
+func Generate(opts Options) World {
+	r := rand.New(rand.NewSource(opts.Seed))
+	now := time.Now()
+	world := World{Players: make([]database.Player, 0, opts.Players)}
+	uids := make(map[string]bool, opts.Players)
+	for i := 0; i < opts.Players; i++ {
+		var uid string
+		for uid == "" || uids[uid] {
+			uid = strconv.FormatUint(uint64(r.Uint32()|1), 10)
+		}
+		uids[uid] = true
+		level := int32(1 + r.Intn(55))
+		player := database.Player{
+			TersePlayer: database.TersePlayer{
+				PlayerUid:      uid,
+				Nickname:       fmt.Sprintf("Player%d", i+1),
+				Level:          level,
+				Exp:            int64(level) * int64(1000+r.Intn(1000)),
+				Hp:             int64(500 + r.Intn(500)),
+				MaxHp:          1000,
+				MaxStatusPoint: level - 1,
+				StatusPoint:    map[string]int32{"最大HP": level / 4, "最大SP": level / 4, "攻撃力": level / 4, "所持重量": level / 4},
+				FullStomach:    float64(r.Intn(150)),
+				SaveLastOnline: now.Add(-time.Duration(r.Intn(30*24)) * time.Hour).Format(time.RFC3339),
+			},
+			Pals: make([]*database.Pal, 0, opts.Pals),
+		}
+		player.Technology = &database.Technology{
+			Points:        int32(r.Intn(10)),
+			AncientPoints: int32(r.Intn(3)),
+			Unlocked:      slices.Clone(technologies[:min(len(technologies), int(level)/4)]),
+		}
+		sort.Strings(player.Technology.Unlocked)
+		// a SteamID64 is the account id over the base of individual accounts
+		player.SteamId = strconv.FormatUint(76561197960265728+1+uint64(r.Uint32()>>1), 10)
+		for j := 0; j < opts.Pals; j++ {
+			player.Pals = append(player.Pals, generatePal(r))
+		}
+		world.Players = append(world.Players, player)
+	}
+	for i := 0; i < opts.Guilds && i < len(world.Players); i++ {
+		world.Guilds = append(world.Guilds, database.Guild{
+			Id:             strconv.Itoa(i + 1),
+			Name:           fmt.Sprintf("Guild%d", i+1),
+			BaseCampLevel:  int32(1 + r.Intn(20)),
+			AdminPlayerUid: world.Players[i].PlayerUid,
+			BaseCamp: []database.BaseCamp{{
+				Id:        fmt.Sprintf("%08x-0000-0000-0000-%012x", r.Uint32(), i),
+				Area:      3500,
+				LocationX: float64(r.Intn(700000) - 350000),
+				LocationY: float64(r.Intn(700000) - 350000),
+				Structures: &database.BaseCampStructures{
+					Count:  r.Intn(300),
+					Hp:     int64(r.Intn(100000)),
+					MaxHp:  100000,
+					PalBox: true,
+				},
+				Storage: generateStorage(r),
+			}},
+		})
+	}
+	// every player joins a guild, the admins their own
+	for i := range world.Players {
+		if len(world.Guilds) == 0 {
+			break
+		}
+		guild := &world.Guilds[i%len(world.Guilds)]
+		guild.Players = append(guild.Players, &database.GuildPlayer{
+			PlayerUid:  world.Players[i].PlayerUid,
+			Nickname:   world.Players[i].Nickname,
+			LastOnline: world.Players[i].SaveLastOnline,
+		})
+	}
+	return world
+}
+
+// Advance changes the world like some play time does, players level up and
+// catch pals, for the next sync of a load test to have something to write.
+func (w *World) Advance(seed int64) {
+	r := rand.New(rand.NewSource(seed))
+	now := time.Now().Format(time.RFC3339)
+	for i := range w.Players {
+		if r.Intn(4) != 0 {
+			continue
+		}
+		player := &w.Players[i]
+		if player.Level < 55 {
+			player.Level++
+			player.MaxStatusPoint++
+		}
+		player.Exp += int64(r.Intn(5000))
+		if t := player.Technology; t != nil {
+			t.Points += 2
+			if n := len(t.Unlocked); n < len(technologies) && t.Points >= 3 {
+				t.Points -= 3
+				t.Unlocked = append(t.Unlocked, technologies[n])
+				sort.Strings(t.Unlocked)
+			}
+		}
+		player.SaveLastOnline = now
+		player.Pals = append(player.Pals, generatePal(r))
+	}
+	// guild members put things into the chests and take them out
+	for i := range w.Guilds {
+		for _, camp := range w.Guilds[i].BaseCamp {
+			if camp.Storage == nil || r.Intn(3) != 0 {
+				continue
+			}
+			item := storageItems[r.Intn(len(storageItems))]
+			camp.Storage[item] = max(0, camp.Storage[item]+int64(r.Intn(400)-250))
+		}
+	}
+}
+
+func generateStorage(r *rand.Rand) map[string]int64 {
+	storage := make(map[string]int64, len(storageItems))
+	for _, item := range storageItems {
+		storage[item] = int64(r.Intn(1000))
+	}
+	return storage
+}
+
+func generatePal(r *rand.Rand) *database.Pal {
+	level := int32(1 + r.Intn(50))
+	skills := make([]string, 0, 4)
+	for _, i := range r.Perm(len(palSkills))[:r.Intn(5)] {
+		skills = append(skills, palSkills[i])
+	}
+	gender := "Male"
+	if r.Intn(2) == 0 {
+		gender = "Female"
+	}
+	return &database.Pal{
+		InstanceId: fmt.Sprintf("%08x-%04x-%04x-%04x-%012x", r.Uint32(), r.Intn(1<<16), r.Intn(1<<16), r.Intn(1<<16), r.Int63n(1<<48)),
+		Location:   palLocations[r.Intn(len(palLocations))],
+		Level:      level,
+		Exp:        int64(level) * int64(500+r.Intn(500)),
+		Hp:         int64(level) * 100,
+		MaxHp:      int64(level) * 100,
+		Type:       palTypes[r.Intn(len(palTypes))],
+		Gender:     gender,
+		IsLucky:    r.Intn(100) == 0,
+		Workspeed:  70,
+		Melee:      int32(r.Intn(101)),
+		Ranged:     int32(r.Intn(101)),
+		Defense:    int32(r.Intn(101)),
+		Rank:       int32(1 + r.Intn(5)),
+		Skills:     skills,
+	}
+}
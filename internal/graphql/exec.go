@@ -0,0 +1,327 @@
+// Package graphql serves a small query-only subset of GraphQL over plain Go
+// values: field selection, aliases, arguments, variables and nested fields of
+// related types. There is no schema, objects are the JSON form of the values
+// tagged with their __typename.
+package graphql
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// Resolver resolves a field, parent is nil for root query fields.
+type Resolver func(parent map[string]any, args map[string]any) (any, error)
+
+type Schema struct {
+	Query map[string]Resolver
+	// Types holds computed fields by __typename, other fields are read from
+	// the object itself
+	Types map[string]map[string]Resolver
+	// MaxDepth refuses queries nesting selections deeper, 0 is unlimited
+	MaxDepth int
+	// MaxFields stops a query once it resolved that many fields, counting
+	// each one for every object of a list, 0 is unlimited. Related types
+	// like Player.guild and Guild.members select each other, so a few
+	// levels of them already resolve a great many fields.
+	MaxFields int
+}
+
+type Error struct {
+	Message string `json:"message"`
+	Path    []any  `json:"path,omitempty"`
+}
+
+type Response struct {
+	Data   *Result `json:"data"`
+	Errors []Error `json:"errors,omitempty"`
+}
+
+// Result is an object of the response, its fields in the order selected.
+type Result struct {
+	keys   []string
+	values []any
+}
+
+func (r *Result) set(key string, value any) {
+	for i, k := range r.keys {
+		if k == key {
+			r.values[i] = value
+			return
+		}
+	}
+	r.keys = append(r.keys, key)
+	r.values = append(r.values, value)
+}
+
+// Get returns the value of the field, nil when it isn't there.
+func (r *Result) Get(key string) any {
+	for i, k := range r.keys {
+		if k == key {
+			return r.values[i]
+		}
+	}
+	return nil
+}
+
+func (r *Result) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, k := range r.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(k)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(r.values[i])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// Object converts v to its JSON form, with __typename set on the object or
+// on every object of a slice.
+func Object(typename string, v any) (any, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	// numbers stay json.Number so int64 fields like exp keep their digits
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var out any
+	if err := dec.Decode(&out); err != nil {
+		return nil, err
+	}
+	return Tag(typename, out), nil
+}
+
+// Tag sets __typename on the object, or on every object of the list, of the
+// JSON form v.
+func Tag(typename string, v any) any {
+	switch o := v.(type) {
+	case map[string]any:
+		o["__typename"] = typename
+	case []any:
+		for _, item := range o {
+			if m, ok := item.(map[string]any); ok {
+				m["__typename"] = typename
+			}
+		}
+	}
+	return v
+}
+
+// Execute runs the query. A failed field is null in data and reported in
+// errors, the rest of the query still resolves.
+func (s *Schema) Execute(query string, variables map[string]any) Response {
+	fields, err := Parse(query, variables)
+	if err != nil {
+		return Response{Errors: []Error{{Message: err.Error()}}}
+	}
+	if d := depth(fields); s.MaxDepth > 0 && d > s.MaxDepth {
+		return Response{Errors: []Error{{Message: fmt.Sprintf("query is %d levels deep, at most %d are allowed", d, s.MaxDepth)}}}
+	}
+	e := &execution{Schema: s, fields: s.MaxFields}
+	resp := Response{Data: &Result{}}
+	for _, field := range fields {
+		path := []any{field.Alias}
+		if err := e.spend(path); err != nil {
+			resp.Data.set(field.Alias, nil)
+			resp.Errors = append(resp.Errors, fieldError(err, path))
+			continue
+		}
+		if field.Name == "__typename" {
+			resp.Data.set(field.Alias, "Query")
+			continue
+		}
+		resolve, ok := s.Query[field.Name]
+		if !ok {
+			resp.Data.set(field.Alias, nil)
+			resp.Errors = append(resp.Errors, Error{Message: fmt.Sprintf("unknown field %s on Query", field.Name), Path: path})
+			continue
+		}
+		value, err := resolve(nil, field.Args)
+		if err == nil {
+			value, err = e.complete(value, field, path)
+		}
+		if err != nil {
+			resp.Data.set(field.Alias, nil)
+			resp.Errors = append(resp.Errors, fieldError(err, path))
+			continue
+		}
+		resp.Data.set(field.Alias, value)
+	}
+	return resp
+}
+
+// depth is how many levels of selections fields nest.
+func depth(fields []Field) int {
+	if len(fields) == 0 {
+		return 0
+	}
+	deepest := 0
+	for _, field := range fields {
+		deepest = max(deepest, depth(field.Selections))
+	}
+	return deepest + 1
+}
+
+// execution is the state of a single Execute.
+type execution struct {
+	*Schema
+	// fields left to resolve when MaxFields is set
+	fields int
+}
+
+// spend takes a field from the budget, failing once it is used up.
+func (e *execution) spend(path []any) error {
+	if e.MaxFields <= 0 {
+		return nil
+	}
+	if e.fields <= 0 {
+		return &pathError{fmt.Errorf("query resolves more than %d fields", e.MaxFields), path}
+	}
+	e.fields--
+	return nil
+}
+
+type pathError struct {
+	err  error
+	path []any
+}
+
+func (e *pathError) Error() string { return e.err.Error() }
+
+func fieldError(err error, path []any) Error {
+	if pe, ok := err.(*pathError); ok {
+		return Error{Message: pe.err.Error(), Path: pe.path}
+	}
+	return Error{Message: err.Error(), Path: path}
+}
+
+// complete applies the selection set of field to value.
+func (e *execution) complete(value any, field Field, path []any) (any, error) {
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case []any:
+		list := make([]any, len(v))
+		for i, item := range v {
+			c, err := e.complete(item, field, append(append([]any{}, path...), i))
+			if err != nil {
+				return nil, err
+			}
+			list[i] = c
+		}
+		return list, nil
+	case map[string]any:
+		if len(field.Selections) == 0 {
+			return nil, &pathError{fmt.Errorf("field %s of type %s needs a selection", field.Name, typename(v)), path}
+		}
+		out := &Result{}
+		for _, sel := range field.Selections {
+			selPath := append(append([]any{}, path...), sel.Alias)
+			if err := e.spend(selPath); err != nil {
+				return nil, err
+			}
+			var child any
+			var err error
+			if sel.Name == "__typename" {
+				child = typename(v)
+			} else if resolve, ok := e.Types[typename(v)][sel.Name]; ok {
+				child, err = resolve(v, sel.Args)
+			} else if c, ok := v[sel.Name]; ok {
+				child = c
+			} else {
+				err = fmt.Errorf("unknown field %s on %s", sel.Name, typename(v))
+			}
+			if err == nil {
+				child, err = e.complete(child, sel, selPath)
+			}
+			if err != nil {
+				return nil, &pathError{unwrap(err), pathOf(err, selPath)}
+			}
+			out.set(sel.Alias, child)
+		}
+		return out, nil
+	default:
+		if len(field.Selections) > 0 {
+			return nil, &pathError{fmt.Errorf("field %s is a scalar and has no fields", field.Name), path}
+		}
+		return v, nil
+	}
+}
+
+func unwrap(err error) error {
+	if pe, ok := err.(*pathError); ok {
+		return pe.err
+	}
+	return err
+}
+
+func pathOf(err error, path []any) []any {
+	if pe, ok := err.(*pathError); ok {
+		return pe.path
+	}
+	return path
+}
+
+func typename(v map[string]any) string {
+	if name, ok := v["__typename"].(string); ok {
+		return name
+	}
+	return "Object"
+}
+
+// BoolArg reads a bool argument, def when it is missing.
+func BoolArg(args map[string]any, name string, def bool) (bool, error) {
+	v, ok := args[name]
+	if !ok || v == nil {
+		return def, nil
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("argument %s must be a bool", name)
+	}
+	return b, nil
+}
+
+// StringArg reads a string argument, def when it is missing.
+func StringArg(args map[string]any, name, def string) (string, error) {
+	v, ok := args[name]
+	if !ok || v == nil {
+		return def, nil
+	}
+	s, ok := v.(string)
+	if !ok {
+		return "", fmt.Errorf("argument %s must be a string", name)
+	}
+	return s, nil
+}
+
+// IntArg reads an int argument, def when it is missing. Variables decoded
+// from JSON come as float64.
+func IntArg(args map[string]any, name string, def int) (int, error) {
+	v, ok := args[name]
+	if !ok || v == nil {
+		return def, nil
+	}
+	switch n := v.(type) {
+	case int64:
+		return int(n), nil
+	case float64:
+		if n == float64(int(n)) {
+			return int(n), nil
+		}
+	}
+	return 0, fmt.Errorf("argument %s must be an int", name)
+}
@@ -0,0 +1,67 @@
+package graphql
+
+import (
+	"strings"
+	"testing"
+)
+
+// cyclic has players in guilds of the same players, like Player.guild and
+// Guild.members.
+func cyclic() *Schema {
+	player := map[string]any{"__typename": "Player", "name": "alice"}
+	guild := map[string]any{"__typename": "Guild", "name": "wolves"}
+	return &Schema{
+		Query: map[string]Resolver{
+			"players": func(_, _ map[string]any) (any, error) { return []any{player, player}, nil },
+		},
+		Types: map[string]map[string]Resolver{
+			"Player": {"guild": func(_, _ map[string]any) (any, error) { return guild, nil }},
+			"Guild":  {"members": func(_, _ map[string]any) (any, error) { return []any{player, player}, nil }},
+		},
+	}
+}
+
+func TestExecute(t *testing.T) {
+	resp := cyclic().Execute(`{ players { name guild { name } } }`, nil)
+	if len(resp.Errors) > 0 {
+		t.Fatal(resp.Errors)
+	}
+	players := resp.Data.Get("players").([]any)
+	if len(players) != 2 {
+		t.Fatalf("players %v, want 2", players)
+	}
+	if name := players[0].(*Result).Get("guild").(*Result).Get("name"); name != "wolves" {
+		t.Errorf("guild name %v, want wolves", name)
+	}
+}
+
+func TestExecuteMaxDepth(t *testing.T) {
+	s := cyclic()
+	s.MaxDepth = 4
+	if resp := s.Execute(`{ players { guild { members { name } } } }`, nil); len(resp.Errors) > 0 {
+		t.Errorf("4 levels: %v", resp.Errors)
+	}
+	resp := s.Execute(`{ players { guild { members { guild { name } } } } }`, nil)
+	if resp.Data != nil || len(resp.Errors) != 1 || !strings.Contains(resp.Errors[0].Message, "5 levels deep") {
+		t.Errorf("5 levels: %+v, want refused", resp)
+	}
+}
+
+func TestExecuteMaxFields(t *testing.T) {
+	s := cyclic()
+	// players, then guild and members for each of the 2 and the names of
+	// their 2 members
+	query := `{ players { guild { members { name } } } }`
+	s.MaxFields = 9
+	if resp := s.Execute(query, nil); len(resp.Errors) > 0 {
+		t.Errorf("9 fields: %v", resp.Errors)
+	}
+	s.MaxFields = 8
+	resp := s.Execute(query, nil)
+	if len(resp.Errors) != 1 || !strings.Contains(resp.Errors[0].Message, "more than 8 fields") {
+		t.Fatalf("8 fields: %+v, want the budget error", resp.Errors)
+	}
+	if resp.Data.Get("players") != nil {
+		t.Errorf("players %v, want null", resp.Data.Get("players"))
+	}
+}
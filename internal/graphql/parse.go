@@ -0,0 +1,372 @@
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Field is a field of a selection set, Args values are already resolved
+// against the variables.
+type Field struct {
+	Alias      string
+	Name       string
+	Args       map[string]any
+	Selections []Field
+}
+
+type variable string
+
+type parser struct {
+	src []rune
+	pos int
+}
+
+// Parse reads a query document, only a single query operation without
+// fragments or directives.
+func Parse(query string, variables map[string]any) ([]Field, error) {
+	p := &parser{src: []rune(query)}
+	p.skip()
+	if p.peekName() {
+		name := p.name()
+		if name != "query" {
+			return nil, fmt.Errorf("only query operations are supported, got %s", name)
+		}
+		p.skip()
+		if p.peekName() {
+			p.name()
+			p.skip()
+		}
+		if p.peek('(') {
+			if err := p.variableDefinitions(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	fields, err := p.selectionSet()
+	if err != nil {
+		return nil, err
+	}
+	p.skip()
+	if p.pos < len(p.src) {
+		return nil, p.errorf("unexpected %q", p.src[p.pos])
+	}
+	if err := resolveVariables(fields, variables); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return fmt.Errorf("syntax error at %d: %s", p.pos, fmt.Sprintf(format, args...))
+}
+
+// skip passes whitespace, commas and comments.
+func (p *parser) skip() {
+	for p.pos < len(p.src) {
+		r := p.src[p.pos]
+		switch {
+		case unicode.IsSpace(r) || r == ',':
+			p.pos++
+		case r == '#':
+			for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+				p.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (p *parser) peek(r rune) bool {
+	return p.pos < len(p.src) && p.src[p.pos] == r
+}
+
+func (p *parser) expect(r rune) error {
+	p.skip()
+	if !p.peek(r) {
+		return p.errorf("expected %q", r)
+	}
+	p.pos++
+	p.skip()
+	return nil
+}
+
+func isNameStart(r rune) bool {
+	return r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+}
+
+func (p *parser) peekName() bool {
+	return p.pos < len(p.src) && isNameStart(p.src[p.pos])
+}
+
+func (p *parser) name() string {
+	start := p.pos
+	for p.pos < len(p.src) && (isNameStart(p.src[p.pos]) || (p.src[p.pos] >= '0' && p.src[p.pos] <= '9')) {
+		p.pos++
+	}
+	return string(p.src[start:p.pos])
+}
+
+// variableDefinitions skips ($name: Type = default, ...), the types aren't
+// checked.
+func (p *parser) variableDefinitions() error {
+	depth := 0
+	for p.pos < len(p.src) {
+		switch p.src[p.pos] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				p.pos++
+				p.skip()
+				return nil
+			}
+		}
+		p.pos++
+	}
+	return p.errorf("unterminated variable definitions")
+}
+
+func (p *parser) selectionSet() ([]Field, error) {
+	if err := p.expect('{'); err != nil {
+		return nil, err
+	}
+	var fields []Field
+	for !p.peek('}') {
+		if p.pos >= len(p.src) {
+			return nil, p.errorf("unterminated selection set")
+		}
+		field, err := p.field()
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, field)
+		p.skip()
+	}
+	p.pos++
+	p.skip()
+	return fields, nil
+}
+
+func (p *parser) field() (Field, error) {
+	if p.pos+3 <= len(p.src) && string(p.src[p.pos:p.pos+3]) == "..." {
+		return Field{}, p.errorf("fragments are not supported")
+	}
+	if !p.peekName() {
+		return Field{}, p.errorf("expected field name")
+	}
+	field := Field{Name: p.name()}
+	p.skip()
+	if p.peek(':') {
+		p.pos++
+		p.skip()
+		if !p.peekName() {
+			return Field{}, p.errorf("expected field name after alias")
+		}
+		field.Alias = field.Name
+		field.Name = p.name()
+		p.skip()
+	}
+	if field.Alias == "" {
+		field.Alias = field.Name
+	}
+	if p.peek('(') {
+		p.pos++
+		p.skip()
+		field.Args = make(map[string]any)
+		for !p.peek(')') {
+			if !p.peekName() {
+				return Field{}, p.errorf("expected argument name")
+			}
+			name := p.name()
+			if err := p.expect(':'); err != nil {
+				return Field{}, err
+			}
+			value, err := p.value()
+			if err != nil {
+				return Field{}, err
+			}
+			field.Args[name] = value
+			p.skip()
+		}
+		p.pos++
+		p.skip()
+	}
+	if p.peek('@') {
+		return Field{}, p.errorf("directives are not supported")
+	}
+	if p.peek('{') {
+		selections, err := p.selectionSet()
+		if err != nil {
+			return Field{}, err
+		}
+		field.Selections = selections
+	}
+	return field, nil
+}
+
+func (p *parser) value() (any, error) {
+	if p.pos >= len(p.src) {
+		return nil, p.errorf("expected value")
+	}
+	r := p.src[p.pos]
+	switch {
+	case r == '$':
+		p.pos++
+		return variable(p.name()), nil
+	case r == '"':
+		return p.string()
+	case r == '-' || (r >= '0' && r <= '9'):
+		start := p.pos
+		p.pos++
+		for p.pos < len(p.src) && strings.ContainsRune("0123456789.eE+-", p.src[p.pos]) {
+			p.pos++
+		}
+		s := string(p.src[start:p.pos])
+		if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return i, nil
+		}
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, p.errorf("invalid number %s", s)
+		}
+		return f, nil
+	case r == '[':
+		p.pos++
+		p.skip()
+		list := make([]any, 0)
+		for !p.peek(']') {
+			v, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+			p.skip()
+		}
+		p.pos++
+		return list, nil
+	case r == '{':
+		p.pos++
+		p.skip()
+		object := make(map[string]any)
+		for !p.peek('}') {
+			if !p.peekName() {
+				return nil, p.errorf("expected field name")
+			}
+			name := p.name()
+			if err := p.expect(':'); err != nil {
+				return nil, err
+			}
+			v, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			object[name] = v
+			p.skip()
+		}
+		p.pos++
+		return object, nil
+	case isNameStart(r):
+		switch name := p.name(); name {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		default:
+			// enum values are passed on as strings
+			return name, nil
+		}
+	}
+	return nil, p.errorf("unexpected %q", r)
+}
+
+func (p *parser) string() (string, error) {
+	p.pos++
+	var sb strings.Builder
+	for p.pos < len(p.src) {
+		r := p.src[p.pos]
+		p.pos++
+		switch r {
+		case '"':
+			return sb.String(), nil
+		case '\\':
+			if p.pos >= len(p.src) {
+				return "", p.errorf("unterminated string")
+			}
+			e := p.src[p.pos]
+			p.pos++
+			switch e {
+			case 'n':
+				sb.WriteRune('\n')
+			case 't':
+				sb.WriteRune('\t')
+			case 'r':
+				sb.WriteRune('\r')
+			case 'u':
+				if p.pos+4 > len(p.src) {
+					return "", p.errorf("invalid unicode escape")
+				}
+				code, err := strconv.ParseUint(string(p.src[p.pos:p.pos+4]), 16, 32)
+				if err != nil {
+					return "", p.errorf("invalid unicode escape")
+				}
+				sb.WriteRune(rune(code))
+				p.pos += 4
+			default:
+				sb.WriteRune(e)
+			}
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	return "", p.errorf("unterminated string")
+}
+
+func resolveVariables(fields []Field, variables map[string]any) error {
+	for i := range fields {
+		for name, arg := range fields[i].Args {
+			v, err := resolveValue(arg, variables)
+			if err != nil {
+				return err
+			}
+			fields[i].Args[name] = v
+		}
+		if err := resolveVariables(fields[i].Selections, variables); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func resolveValue(v any, variables map[string]any) (any, error) {
+	switch v := v.(type) {
+	case variable:
+		value, ok := variables[string(v)]
+		if !ok {
+			return nil, fmt.Errorf("variable $%s is not provided", v)
+		}
+		return value, nil
+	case []any:
+		for i := range v {
+			r, err := resolveValue(v[i], variables)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = r
+		}
+	case map[string]any:
+		for k := range v {
+			r, err := resolveValue(v[k], variables)
+			if err != nil {
+				return nil, err
+			}
+			v[k] = r
+		}
+	}
+	return v, nil
+}
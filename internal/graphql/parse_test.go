@@ -0,0 +1,95 @@
+package graphql
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	fields, err := Parse(`query Top($n: Int = 5) {
+		# the best players first
+		top: players(order_by: "level", desc: true, limit: $n) {
+			nickname, level
+			guild { name }
+		}
+		events(type: "player.join", order: DESC, filter: {tags: ["a", "b"], min: -1.5}) { type }
+	}`, map[string]any{"n": float64(3)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Field{
+		{Alias: "top", Name: "players", Args: map[string]any{"order_by": "level", "desc": true, "limit": float64(3)}, Selections: []Field{
+			{Alias: "nickname", Name: "nickname"},
+			{Alias: "level", Name: "level"},
+			{Alias: "guild", Name: "guild", Selections: []Field{{Alias: "name", Name: "name"}}},
+		}},
+		{Alias: "events", Name: "events", Args: map[string]any{"type": "player.join", "order": "DESC", "filter": map[string]any{"tags": []any{"a", "b"}, "min": -1.5}}, Selections: []Field{
+			{Alias: "type", Name: "type"},
+		}},
+	}
+	if !reflect.DeepEqual(fields, want) {
+		t.Errorf("Parse = %+v, want %+v", fields, want)
+	}
+}
+
+func TestParseValues(t *testing.T) {
+	for _, tt := range []struct {
+		value string
+		want  any
+	}{
+		{`42`, int64(42)},
+		{`-7`, int64(-7)},
+		{`1e3`, float64(1000)},
+		{`"a\"b\né"`, "a\"b\né"},
+		{`null`, nil},
+		{`false`, false},
+		{`LEVEL`, "LEVEL"},
+		{`[]`, []any{}},
+	} {
+		fields, err := Parse(`{ f(v: `+tt.value+`) }`, nil)
+		if err != nil {
+			t.Errorf("Parse %s: %v", tt.value, err)
+			continue
+		}
+		if got := fields[0].Args["v"]; !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Parse %s = %#v, want %#v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, tt := range []struct {
+		query string
+		want  string
+	}{
+		{`mutation { a }`, "only query operations"},
+		{`{ a { ...Fields } }`, "fragments are not supported"},
+		{`{ a @include(if: true) }`, "directives are not supported"},
+		{`{ a { b }`, "unterminated selection set"},
+		{`{ a(v: "x) }`, "unterminated string"},
+		{`{ a(v: 1.2.3) }`, "invalid number"},
+		{`{ a(v: $missing) }`, "variable $missing is not provided"},
+		{`{ a } b`, "unexpected"},
+		{`query ($n: Int { a }`, "unterminated variable definitions"},
+		{`{ a: }`, "expected field name after alias"},
+		{`{ ..`, "expected field name"},
+	} {
+		_, err := Parse(tt.query, nil)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Parse %s: %v, want %q", tt.query, err, tt.want)
+		}
+	}
+}
+
+func TestParseLongQuery(t *testing.T) {
+	// the fragment check looks at the next three runes, not the whole rest
+	query := "{" + strings.Repeat(" a", 200000) + " }"
+	fields, err := Parse(query, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fields) != 200000 {
+		t.Errorf("Parse got %d fields, want 200000", len(fields))
+	}
+}
@@ -0,0 +1,109 @@
+// Package hook runs user commands and HTTP endpoints on bus events, for
+// automations that don't belong in pst itself.
+package hook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/zaigie/palworld-server-tool/internal/bus"
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/internal/logger"
+	"github.com/zaigie/palworld-server-tool/internal/notify"
+	"github.com/zaigie/palworld-server-tool/internal/tool"
+)
+
+type Hook struct {
+	Name   string   `mapstructure:"name"`
+	Events []string `mapstructure:"events"`
+	// Command is run by the shell with the event JSON on stdin
+	Command string `mapstructure:"command"`
+	// Url is posted the event JSON
+	Url string `mapstructure:"url"`
+	// Timeout in seconds, 10 when zero
+	Timeout int `mapstructure:"timeout"`
+}
+
+// Subscribe subscribes each configured hook to the bus on its own, so a
+// slow hook doesn't hold back the others.
+func Subscribe() {
+	var hooks []Hook
+	if err := viper.UnmarshalKey("hooks", &hooks); err != nil {
+		logger.Errorf("invalid hooks config: %v\n", err)
+		return
+	}
+	for _, hook := range hooks {
+		if hook.Command == "" && hook.Url == "" {
+			logger.Warnf("Hook %s has neither command nor url, skipped\n", hook.Name)
+			continue
+		}
+		hook := hook
+		bus.Subscribe("hook "+hook.Name, hook.Events, func(events []database.Event) {
+			for _, event := range events {
+				if !notify.MatchEvent(hook.Events, event) {
+					continue
+				}
+				if err := Run(hook, event); err != nil {
+					logger.Warnf("Hook %s failed on %s: %v\n", hook.Name, event.Type, err)
+				}
+			}
+		})
+	}
+}
+
+// Run runs the hook for one event.
+func Run(hook Hook, event database.Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	timeout := time.Duration(hook.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	if hook.Command != "" {
+		output, err := tool.RunCommandInput(hook.Command, payload, timeout)
+		if output != "" {
+			logger.Debugf("Hook %s: %s\n", hook.Name, output)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if hook.Url != "" {
+		if err := post(hook.Url, payload, timeout); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func post(url string, payload []byte, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return errors.New("request timed out")
+		}
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%d %s", resp.StatusCode, body)
+	}
+	return nil
+}
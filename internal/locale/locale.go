@@ -0,0 +1,151 @@
+package locale
+
+import (
+	"embed"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// Channels pick their language with locale.<channel>, falling back to
+// locale.default.
+const (
+	// Broadcast is in-game broadcasts and replies to chat commands
+	Broadcast = "broadcast"
+	Bot       = "bot"
+	Notify    = "notify"
+)
+
+// Fallback is used for keys missing in a language.
+const Fallback = "en"
+
+//go:embed locales/*.yaml
+var bundled embed.FS
+
+var (
+	mu sync.RWMutex
+	// builtin are the bundled messages by language
+	builtin map[string]map[string]string
+	// custom are the messages registered at runtime, they override builtin
+	custom = make(map[string]map[string]string)
+	// overrides are the templates set for a key, whatever the language
+	overrides = make(map[string]string)
+)
+
+func init() {
+	builtin = make(map[string]map[string]string)
+	files, err := bundled.ReadDir("locales")
+	if err != nil {
+		panic(err)
+	}
+	for _, f := range files {
+		data, err := bundled.ReadFile(path.Join("locales", f.Name()))
+		if err != nil {
+			panic(err)
+		}
+		messages := make(map[string]string)
+		if err := yaml.Unmarshal(data, &messages); err != nil {
+			panic(fmt.Errorf("locale %s: %w", f.Name(), err))
+		}
+		builtin[strings.TrimSuffix(f.Name(), ".yaml")] = messages
+	}
+}
+
+// Register adds or replaces the custom messages of a language, keys it
+// doesn't have come from the bundled language.
+func Register(lang string, messages map[string]string) {
+	mu.Lock()
+	defer mu.Unlock()
+	custom[lang] = messages
+}
+
+func Unregister(lang string) {
+	mu.Lock()
+	defer mu.Unlock()
+	delete(custom, lang)
+}
+
+// Languages lists the bundled and registered languages.
+func Languages() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	seen := make(map[string]bool)
+	var langs []string
+	for _, m := range []map[string]map[string]string{builtin, custom} {
+		for lang := range m {
+			if !seen[lang] {
+				seen[lang] = true
+				langs = append(langs, lang)
+			}
+		}
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// Messages returns all messages of a language, with the fallback filling
+// in missing keys.
+func Messages(lang string) map[string]string {
+	mu.RLock()
+	defer mu.RUnlock()
+	messages := make(map[string]string)
+	for _, m := range []map[string]string{builtin[Fallback], custom[Fallback], builtin[lang], custom[lang]} {
+		for k, v := range m {
+			messages[k] = v
+		}
+	}
+	return messages
+}
+
+// Lang returns the language of the channel.
+func Lang(channel string) string {
+	if lang := viper.GetString("locale." + channel); lang != "" {
+		return lang
+	}
+	if lang := viper.GetString("locale.default"); lang != "" {
+		return lang
+	}
+	return Fallback
+}
+
+func lookup(lang, key string) string {
+	mu.RLock()
+	defer mu.RUnlock()
+	if v, ok := overrides[key]; ok {
+		return v
+	}
+	for _, l := range []string{lang, Fallback} {
+		if v, ok := custom[l][key]; ok {
+			return v
+		}
+		if v, ok := builtin[l][key]; ok {
+			return v
+		}
+	}
+	return key
+}
+
+// Source returns the unrendered message of the channel's language. When
+// configKey is set in the config, that message wins, so existing configs keep
+// their text and an empty one still turns the message off.
+func Source(channel, key, configKey string) string {
+	if configKey != "" && viper.IsSet(configKey) {
+		return viper.GetString(configKey)
+	}
+	return lookup(Lang(channel), key)
+}
+
+// Text renders the message of Source with vars, see Render.
+func Text(channel, key, configKey string, vars ...any) string {
+	return Render(Source(channel, key, configKey), vars...)
+}
+
+// T renders the message in the channel's language with vars, see Render.
+func T(channel, key string, vars ...any) string {
+	return Text(channel, key, "", vars...)
+}
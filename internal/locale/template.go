@@ -0,0 +1,140 @@
+package locale
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/zaigie/palworld-server-tool/internal/logger"
+)
+
+var (
+	funcs = template.FuncMap{
+		"duration": formatDuration,
+		"time":     formatTime,
+		"upper":    strings.ToUpper,
+		"lower":    strings.ToLower,
+		"join":     strings.Join,
+	}
+	parsedMu sync.Mutex
+	// parsed caches the templates by source, it's reset when funcs change
+	parsed = make(map[string]*template.Template)
+)
+
+// RegisterFunc adds a function for templates, like player to look a player
+// up by uid.
+func RegisterFunc(name string, fn any) {
+	mu.Lock()
+	defer mu.Unlock()
+	funcs[name] = fn
+	parsedMu.Lock()
+	parsed = make(map[string]*template.Template)
+	parsedMu.Unlock()
+}
+
+// Funcs returns a copy of the template functions, for templates rendered
+// elsewhere like the email body.
+func Funcs() template.FuncMap {
+	mu.RLock()
+	defer mu.RUnlock()
+	copied := make(template.FuncMap, len(funcs))
+	for name, fn := range funcs {
+		copied[name] = fn
+	}
+	return copied
+}
+
+// SetTemplate overrides the message of key in every language.
+func SetTemplate(key, source string) {
+	mu.Lock()
+	defer mu.Unlock()
+	overrides[key] = source
+}
+
+func RemoveTemplate(key string) {
+	mu.Lock()
+	defer mu.Unlock()
+	delete(overrides, key)
+}
+
+// Override returns the template set for key, if any.
+func Override(key string) (string, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	source, ok := overrides[key]
+	return source, ok
+}
+
+// Parse checks a message source, it's only a Go template when it has {{.
+func Parse(source string) (*template.Template, error) {
+	parsedMu.Lock()
+	tmpl, ok := parsed[source]
+	parsedMu.Unlock()
+	if ok {
+		return tmpl, nil
+	}
+	tmpl, err := template.New("message").Funcs(Funcs()).Parse(source)
+	if err != nil {
+		return nil, err
+	}
+	parsedMu.Lock()
+	parsed[source] = tmpl
+	parsedMu.Unlock()
+	return tmpl, nil
+}
+
+// Render executes source as a Go template with vars, name and value pairs,
+// as the data, like {{.username}} or {{duration .seconds}}. The older {name}
+// placeholders are replaced as well. A broken template is logged and only
+// gets the placeholders replaced.
+func Render(source string, vars ...any) string {
+	data := make(map[string]any, len(vars)/2)
+	pairs := make([]string, 0, len(vars))
+	for i := 0; i+1 < len(vars); i += 2 {
+		name := fmt.Sprint(vars[i])
+		data[name] = vars[i+1]
+		pairs = append(pairs, "{"+name+"}", fmt.Sprint(vars[i+1]))
+	}
+	text := source
+	if strings.Contains(source, "{{") {
+		var buf bytes.Buffer
+		tmpl, err := Parse(source)
+		if err == nil {
+			err = tmpl.Execute(&buf, data)
+		}
+		if err != nil {
+			logger.Warnf("Render message fail, %v\n", err)
+		} else {
+			text = buf.String()
+		}
+	}
+	return strings.NewReplacer(pairs...).Replace(text)
+}
+
+// formatDuration takes a time.Duration or seconds.
+func formatDuration(v any) string {
+	var d time.Duration
+	switch v := v.(type) {
+	case time.Duration:
+		d = v
+	case int:
+		d = time.Duration(v) * time.Second
+	case int64:
+		d = time.Duration(v) * time.Second
+	case float64:
+		d = time.Duration(v * float64(time.Second))
+	default:
+		return fmt.Sprint(v)
+	}
+	return d.Round(time.Second).String()
+}
+
+func formatTime(t time.Time, layout ...string) string {
+	if len(layout) > 0 {
+		return t.Local().Format(layout[0])
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
@@ -0,0 +1,63 @@
+package logger
+
+import (
+	"os"
+	"sync"
+)
+
+// logFile is the file the log is written to besides stdout, nothing until
+// SetFile is called.
+type logFile struct {
+	mu   sync.Mutex
+	f    *os.File
+	path string
+}
+
+var file = &logFile{}
+
+func (l *logFile) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.f == nil {
+		return len(p), nil
+	}
+	return l.f.Write(p)
+}
+
+func (l *logFile) Sync() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.f == nil {
+		return nil
+	}
+	return l.f.Sync()
+}
+
+// SetFile writes the log to the file at path too, appending to it.
+func SetFile(path string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	file.mu.Lock()
+	prev := file.f
+	file.f = f
+	file.path = path
+	file.mu.Unlock()
+	if prev != nil {
+		return prev.Close()
+	}
+	return nil
+}
+
+// Reopen opens the log file again, after logrotate moved it away. It does
+// nothing without a log file.
+func Reopen() error {
+	file.mu.Lock()
+	path := file.path
+	file.mu.Unlock()
+	if path == "" {
+		return nil
+	}
+	return SetFile(path)
+}
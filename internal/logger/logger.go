@@ -1,6 +1,7 @@
 package logger
 
 import (
+	"io"
 	"os"
 
 	"go.uber.org/zap"
@@ -43,13 +44,19 @@ func init() {
 
 	core := zapcore.NewCore(
 		newCustomEncoder(encoderConfig),
-		zapcore.Lock(os.Stdout),
+		zapcore.NewMultiWriteSyncer(zapcore.Lock(os.Stdout), recent, file),
 		zap.DebugLevel,
 	)
 
 	logger = zap.New(core, zap.AddCaller(), zap.AddCallerSkip(1)).Sugar()
 }
 
+// Writer is where other logs, like the request log, go to be written with
+// the log to stdout, the log file and Recent.
+func Writer() io.Writer {
+	return io.MultiWriter(os.Stdout, recent, file)
+}
+
 // Info logs a message at InfoLevel. The message includes any fields passed.
 func Info(args ...interface{}) {
 	logger.Info(args...)
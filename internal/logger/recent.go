@@ -0,0 +1,49 @@
+package logger
+
+import (
+	"strings"
+	"sync"
+)
+
+const recentLines = 1000
+
+// recentLog keeps the last lines written, for crash reports.
+type recentLog struct {
+	mu    sync.Mutex
+	lines []string
+	next  int
+	// partial holds a line not ended yet
+	partial string
+}
+
+var recent = &recentLog{lines: make([]string, 0, recentLines)}
+
+func (r *recentLog) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	text := r.partial + string(p)
+	parts := strings.Split(text, "\n")
+	r.partial = parts[len(parts)-1]
+	for _, line := range parts[:len(parts)-1] {
+		if len(r.lines) < recentLines {
+			r.lines = append(r.lines, line)
+			continue
+		}
+		r.lines[r.next] = line
+		r.next = (r.next + 1) % recentLines
+	}
+	return len(p), nil
+}
+
+func (r *recentLog) Sync() error {
+	return nil
+}
+
+// Recent returns the last lines logged, oldest first.
+func Recent() []string {
+	recent.mu.Lock()
+	defer recent.mu.Unlock()
+	lines := make([]string, 0, len(recent.lines))
+	lines = append(lines, recent.lines[recent.next:]...)
+	return append(lines, recent.lines[:recent.next]...)
+}
@@ -0,0 +1,274 @@
+package mqtt
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// MQTT 3.1.1 control packet types, shifted into the fixed header.
+const (
+	packetConnect    = 0x10
+	packetConnack    = 0x20
+	packetPublish    = 0x30
+	packetPuback     = 0x40
+	packetPubrec     = 0x50
+	packetPubrel     = 0x62
+	packetPubcomp    = 0x70
+	packetPingreq    = 0xc0
+	packetPingresp   = 0xd0
+	packetDisconnect = 0xe0
+)
+
+var connackErrors = map[byte]string{
+	1: "unacceptable protocol version",
+	2: "client id rejected",
+	3: "server unavailable",
+	4: "bad username or password",
+	5: "not authorized",
+}
+
+type options struct {
+	Broker    string
+	ClientId  string
+	Username  string
+	Password  string
+	KeepAlive time.Duration
+}
+
+// client publishes to a broker, one packet exchange at a time. It only
+// publishes, so the broker never sends anything it didn't ask for.
+type client struct {
+	mu        sync.Mutex
+	conn      net.Conn
+	r         *bufio.Reader
+	packetId  uint16
+	keepAlive time.Duration
+	lastSent  time.Time
+	done      chan struct{}
+}
+
+func dial(opts options) (*client, error) {
+	u, err := url.Parse(opts.Broker)
+	if err != nil {
+		return nil, err
+	}
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	var conn net.Conn
+	switch u.Scheme {
+	case "tcp", "mqtt", "":
+		conn, err = dialer.Dial("tcp", hostPort(u, "1883"))
+	case "ssl", "tls", "mqtts":
+		conn, err = tls.DialWithDialer(dialer, "tcp", hostPort(u, "8883"), &tls.Config{ServerName: u.Hostname()})
+	default:
+		return nil, fmt.Errorf("unknown broker scheme %s", u.Scheme)
+	}
+	if err != nil {
+		return nil, err
+	}
+	c := &client{conn: conn, r: bufio.NewReader(conn), keepAlive: opts.KeepAlive, done: make(chan struct{})}
+	if err := c.connect(opts); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if c.keepAlive > 0 {
+		go c.ping()
+	}
+	return c, nil
+}
+
+func hostPort(u *url.URL, port string) string {
+	if u.Port() != "" {
+		return u.Host
+	}
+	return net.JoinHostPort(u.Hostname(), port)
+}
+
+func (c *client) connect(opts options) error {
+	var body []byte
+	body = appendString(body, "MQTT")
+	flags := byte(0x02) // clean session
+	if opts.Username != "" {
+		flags |= 0x80
+		if opts.Password != "" {
+			flags |= 0x40
+		}
+	}
+	body = append(body, 4, flags)
+	body = binary.BigEndian.AppendUint16(body, uint16(opts.KeepAlive/time.Second))
+	body = appendString(body, opts.ClientId)
+	if opts.Username != "" {
+		body = appendString(body, opts.Username)
+		if opts.Password != "" {
+			body = appendString(body, opts.Password)
+		}
+	}
+	if err := c.write(packetConnect, body); err != nil {
+		return err
+	}
+	header, ack, err := c.read()
+	if err != nil {
+		return err
+	}
+	if header&0xf0 != packetConnack || len(ack) != 2 {
+		return errors.New("broker didn't acknowledge the connection")
+	}
+	if ack[1] != 0 {
+		if reason, ok := connackErrors[ack[1]]; ok {
+			return errors.New(reason)
+		}
+		return fmt.Errorf("connection refused with code %d", ack[1])
+	}
+	return nil
+}
+
+// Publish sends payload and waits for the broker to take it over for QoS 1
+// and 2.
+func (c *client) Publish(topic string, payload []byte, qos byte, retain bool) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	header := byte(packetPublish) | qos<<1
+	if retain {
+		header |= 1
+	}
+	body := appendString(nil, topic)
+	var id uint16
+	if qos > 0 {
+		c.packetId++
+		if c.packetId == 0 {
+			c.packetId = 1
+		}
+		id = c.packetId
+		body = binary.BigEndian.AppendUint16(body, id)
+	}
+	body = append(body, payload...)
+	if err := c.write(header, body); err != nil {
+		return err
+	}
+	switch qos {
+	case 1:
+		return c.await(packetPuback, id)
+	case 2:
+		if err := c.await(packetPubrec, id); err != nil {
+			return err
+		}
+		if err := c.write(packetPubrel, binary.BigEndian.AppendUint16(nil, id)); err != nil {
+			return err
+		}
+		return c.await(packetPubcomp, id)
+	}
+	return nil
+}
+
+// await reads until the acknowledgement of packet id.
+func (c *client) await(packetType byte, id uint16) error {
+	c.conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+	defer c.conn.SetReadDeadline(time.Time{})
+	for {
+		header, body, err := c.read()
+		if err != nil {
+			return err
+		}
+		if header&0xf0 != packetType&0xf0 {
+			continue
+		}
+		if packetType == packetPingresp || len(body) >= 2 && binary.BigEndian.Uint16(body) == id {
+			return nil
+		}
+	}
+}
+
+func (c *client) ping() {
+	ticker := time.NewTicker(c.keepAlive / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+		}
+		c.mu.Lock()
+		if time.Since(c.lastSent) >= c.keepAlive/2 {
+			if err := c.write(packetPingreq, nil); err == nil {
+				c.await(packetPingresp, 0)
+			}
+		}
+		c.mu.Unlock()
+	}
+}
+
+func (c *client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	select {
+	case <-c.done:
+		return nil
+	default:
+		close(c.done)
+	}
+	c.write(packetDisconnect, nil)
+	return c.conn.Close()
+}
+
+func (c *client) write(header byte, body []byte) error {
+	packet := []byte{header}
+	packet = appendLength(packet, len(body))
+	packet = append(packet, body...)
+	c.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	_, err := c.conn.Write(packet)
+	c.lastSent = time.Now()
+	return err
+}
+
+func (c *client) read() (byte, []byte, error) {
+	header, err := c.r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	length, multiplier := 0, 1
+	for i := 0; ; i++ {
+		b, err := c.r.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		length += int(b&0x7f) * multiplier
+		if b&0x80 == 0 {
+			break
+		}
+		if i == 3 {
+			return 0, nil, errors.New("malformed packet length")
+		}
+		multiplier *= 128
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(c.r, body); err != nil {
+		return 0, nil, err
+	}
+	return header, body, nil
+}
+
+func appendString(b []byte, s string) []byte {
+	b = binary.BigEndian.AppendUint16(b, uint16(len(s)))
+	return append(b, s...)
+}
+
+func appendLength(b []byte, n int) []byte {
+	for {
+		digit := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			digit |= 0x80
+		}
+		b = append(b, digit)
+		if n == 0 {
+			return b
+		}
+	}
+}
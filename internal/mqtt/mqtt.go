@@ -0,0 +1,98 @@
+// Package mqtt publishes bus events to an MQTT broker, for home automation
+// style integrations and dashboards already built around one.
+package mqtt
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/zaigie/palworld-server-tool/internal/bus"
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/internal/logger"
+	"github.com/zaigie/palworld-server-tool/internal/notify"
+)
+
+// Topic routes the matching events to a topic.
+type Topic struct {
+	Events []string `mapstructure:"events"`
+	// Topic may hold {type}, the event type with dots as levels, so
+	// pst/player/# selects every player event
+	Topic  string `mapstructure:"topic"`
+	Qos    byte   `mapstructure:"qos"`
+	Retain bool   `mapstructure:"retain"`
+}
+
+var defaultTopic = Topic{Topic: "pst/{type}"}
+
+var (
+	mu   sync.Mutex
+	conn *client
+)
+
+// Subscribe publishes the events of the bus to mqtt.broker by mqtt.topics,
+// or every stored event to pst/{type} when there are none.
+func Subscribe() {
+	if viper.GetString("mqtt.broker") == "" {
+		return
+	}
+	var topics []Topic
+	if err := viper.UnmarshalKey("mqtt.topics", &topics); err != nil {
+		logger.Errorf("invalid mqtt.topics config: %v\n", err)
+		return
+	}
+	if len(topics) == 0 {
+		topics = []Topic{defaultTopic}
+	}
+	for _, topic := range topics {
+		if topic.Qos > 2 {
+			logger.Errorf("mqtt topic %s has qos %d, want 0, 1 or 2\n", topic.Topic, topic.Qos)
+			return
+		}
+	}
+	bus.Subscribe("mqtt", nil, func(events []database.Event) {
+		for _, event := range events {
+			for _, topic := range topics {
+				if !notify.MatchEvent(topic.Events, event) {
+					continue
+				}
+				if err := Publish(topic, event); err != nil {
+					logger.Warnf("MQTT publish of %s failed: %v\n", event.Type, err)
+				}
+			}
+		}
+	})
+}
+
+// Publish sends the event JSON to the topic, connecting first if needed and
+// retrying once on a fresh connection when the old one went away.
+func Publish(topic Topic, event database.Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	name := strings.ReplaceAll(topic.Topic, "{type}", strings.ReplaceAll(event.Type, ".", "/"))
+	mu.Lock()
+	defer mu.Unlock()
+	for attempt := 0; ; attempt++ {
+		if conn == nil {
+			if conn, err = dial(options{
+				Broker:    viper.GetString("mqtt.broker"),
+				ClientId:  viper.GetString("mqtt.client_id"),
+				Username:  viper.GetString("mqtt.username"),
+				Password:  viper.GetString("mqtt.password"),
+				KeepAlive: time.Duration(viper.GetInt("mqtt.keep_alive")) * time.Second,
+			}); err != nil {
+				return err
+			}
+		}
+		err = conn.Publish(name, payload, topic.Qos, topic.Retain)
+		if err == nil || attempt > 0 {
+			return err
+		}
+		conn.Close()
+		conn = nil
+	}
+}
@@ -0,0 +1,103 @@
+package notify
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/internal/locale"
+)
+
+// The group bots of Feishu, DingTalk and WeCom take a text message instead of
+// the event json, and answer errors with a code in a 200 response.
+
+type botResponse struct {
+	// Feishu
+	Code int    `json:"code"`
+	Msg  string `json:"msg"`
+	// DingTalk and WeCom
+	ErrCode int    `json:"errcode"`
+	ErrMsg  string `json:"errmsg"`
+}
+
+func botText(event database.Event) string {
+	return locale.T(locale.Notify, "notify.event",
+		"type", event.Type,
+		"message", event.Message,
+		"time", event.Time.Format("2006-01-02 15:04:05"),
+		"event", event,
+	)
+}
+
+func postBot(url string, payload interface{}) error {
+	body, err := postJSON(url, payload)
+	if err != nil {
+		return err
+	}
+	var resp botResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return fmt.Errorf("unexpected response %s", body)
+	}
+	if resp.Code != 0 {
+		return fmt.Errorf("%d %s", resp.Code, resp.Msg)
+	}
+	if resp.ErrCode != 0 {
+		return fmt.Errorf("%d %s", resp.ErrCode, resp.ErrMsg)
+	}
+	return nil
+}
+
+func hmacSign(key, message string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(message))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func sendFeishu(webhook Webhook, event database.Event) error {
+	payload := map[string]interface{}{
+		"msg_type": "text",
+		"content":  map[string]string{"text": botText(event)},
+	}
+	if webhook.Secret != "" {
+		// Feishu signs with timestamp+"\n"+secret as the key and an empty message
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		payload["timestamp"] = timestamp
+		payload["sign"] = hmacSign(timestamp+"\n"+webhook.Secret, "")
+	}
+	return postBot(webhook.Url, payload)
+}
+
+func sendDingTalk(webhook Webhook, event database.Event) error {
+	target := webhook.Url
+	if webhook.Secret != "" {
+		timestamp := strconv.FormatInt(time.Now().UnixMilli(), 10)
+		u, err := url.Parse(webhook.Url)
+		if err != nil {
+			return err
+		}
+		q := u.Query()
+		q.Set("timestamp", timestamp)
+		q.Set("sign", hmacSign(webhook.Secret, timestamp+"\n"+webhook.Secret))
+		u.RawQuery = q.Encode()
+		target = u.String()
+	}
+	return postBot(target, map[string]interface{}{
+		"msgtype": "text",
+		"text":    map[string]string{"content": botText(event)},
+	})
+}
+
+// sendWeCom posts to a WeCom group bot, which is authorized by the key in its
+// url and has no signature.
+func sendWeCom(webhook Webhook, event database.Event) error {
+	return postBot(webhook.Url, map[string]interface{}{
+		"msgtype": "text",
+		"text":    map[string]string{"content": botText(event)},
+	})
+}
@@ -0,0 +1,143 @@
+package notify
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/internal/locale"
+	"github.com/zaigie/palworld-server-tool/internal/logger"
+	"github.com/zaigie/palworld-server-tool/service"
+)
+
+// EventPresenceDigest is the event a webhook with a digest_interval gets
+// instead of each player.join and player.leave.
+const EventPresenceDigest = "player.digest"
+
+// The verbosities of a presence digest, names is the default.
+const (
+	VerbosityFull  = "full"
+	VerbosityNames = "names"
+	VerbosityCount = "count"
+)
+
+func isPresence(event database.Event) bool {
+	return event.Type == service.EventPlayerJoin || event.Type == service.EventPlayerLeave
+}
+
+type presenceDigest struct {
+	webhook Webhook
+	events  []database.Event
+}
+
+// digests are the joins and leaves waiting for the digest of each webhook,
+// by url. The first event of a digest starts its interval.
+var digests = struct {
+	sync.Mutex
+	byUrl map[string]*presenceDigest
+}{byUrl: make(map[string]*presenceDigest)}
+
+func addDigest(webhook Webhook, event database.Event) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	digests.Lock()
+	defer digests.Unlock()
+	d := digests.byUrl[webhook.Url]
+	if d == nil {
+		d = &presenceDigest{}
+		digests.byUrl[webhook.Url] = d
+		time.AfterFunc(time.Duration(webhook.DigestInterval)*time.Second, func() {
+			flushDigest(webhook.Url)
+		})
+	}
+	d.webhook = webhook
+	d.events = append(d.events, event)
+}
+
+func flushDigest(url string) {
+	digests.Lock()
+	d := digests.byUrl[url]
+	delete(digests.byUrl, url)
+	digests.Unlock()
+	if d == nil || len(d.events) == 0 {
+		return
+	}
+	if err := sendWebhook(d.webhook, digestEvent(d.webhook, d.events)); err != nil {
+		logger.Warnf("Webhook %s fail, %v\n", url, err)
+	}
+}
+
+// digestEvent sums the joins and leaves up in one event, its message as
+// detailed as the verbosity of the webhook.
+func digestEvent(webhook Webhook, events []database.Event) database.Event {
+	var joined, left []string
+	var lines []string
+	for _, event := range events {
+		nickname := event.Data["nickname"]
+		sign := "+"
+		if event.Type == service.EventPlayerJoin {
+			joined = appendName(joined, nickname)
+		} else {
+			left = appendName(left, nickname)
+			sign = "-"
+		}
+		lines = append(lines, fmt.Sprintf("%s %s %s", event.Time.Format("15:04:05"), sign, nickname))
+	}
+	joins, leaves := countType(events, service.EventPlayerJoin), countType(events, service.EventPlayerLeave)
+	onlineNum := events[len(events)-1].Data["online_num"]
+
+	var parts []string
+	switch webhook.Verbosity {
+	case VerbosityCount:
+		parts = append(parts, locale.T(locale.Notify, "notify.digest_count", "joins", joins, "leaves", leaves))
+	case VerbosityFull:
+		parts = append(parts, lines...)
+	default:
+		if len(joined) > 0 {
+			parts = append(parts, locale.T(locale.Notify, "notify.digest_joined", "names", strings.Join(joined, ", ")))
+		}
+		if len(left) > 0 {
+			parts = append(parts, locale.T(locale.Notify, "notify.digest_left", "names", strings.Join(left, ", ")))
+		}
+	}
+	parts = append(parts, locale.T(locale.Notify, "notify.digest_online", "online_num", onlineNum))
+
+	return database.Event{
+		Type:    EventPresenceDigest,
+		Time:    time.Now(),
+		Message: strings.Join(parts, "\n"),
+		Data: map[string]string{
+			"joins":      strconv.Itoa(joins),
+			"leaves":     strconv.Itoa(leaves),
+			"joined":     strings.Join(joined, ","),
+			"left":       strings.Join(left, ","),
+			"online_num": onlineNum,
+			"since":      events[0].Time.Format(time.RFC3339),
+		},
+	}
+}
+
+// appendName appends the name unless it's in names already, a player
+// joining twice is listed once.
+func appendName(names []string, name string) []string {
+	for _, n := range names {
+		if n == name {
+			return names
+		}
+	}
+	return append(names, name)
+}
+
+func countType(events []database.Event, eventType string) int {
+	n := 0
+	for _, event := range events {
+		if event.Type == eventType {
+			n++
+		}
+	}
+	return n
+}
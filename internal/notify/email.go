@@ -0,0 +1,247 @@
+package notify
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"html/template"
+	"mime"
+	"net"
+	"net/smtp"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/internal/locale"
+	"github.com/zaigie/palworld-server-tool/internal/logger"
+)
+
+type Email struct {
+	Host     string   `mapstructure:"host"`
+	Port     int      `mapstructure:"port"`
+	Username string   `mapstructure:"username"`
+	Password string   `mapstructure:"password"`
+	From     string   `mapstructure:"from"`
+	To       []string `mapstructure:"to"`
+	// Security is "tls" for implicit TLS, "starttls" or "none"
+	Security string   `mapstructure:"security"`
+	Events   []string `mapstructure:"events"`
+	Digest   bool     `mapstructure:"digest"`
+	Template string   `mapstructure:"template"`
+}
+
+type emailData struct {
+	Subject string
+	Digest  bool
+	Time    time.Time
+	Events  []database.Event
+}
+
+const defaultEmailTemplate = `<!DOCTYPE html>
+<html>
+<body style="font-family: sans-serif;">
+<h2>{{.Subject}}</h2>
+<table cellpadding="6" style="border-collapse: collapse;">
+<tr style="background: #eee;"><th align="left">Time</th><th align="left">Type</th><th align="left">Message</th></tr>
+{{range .Events}}<tr><td>{{.Time.Format "2006-01-02 15:04:05"}}</td><td>{{.Type}}</td><td>{{.Message}}</td></tr>
+{{else}}<tr><td colspan="3">Nothing happened.</td></tr>
+{{end}}</table>
+</body>
+</html>
+`
+
+func getEmail() (Email, bool) {
+	var email Email
+	if err := viper.UnmarshalKey("notify.email", &email); err != nil {
+		logger.Errorf("Parse notify.email fail, %v\n", err)
+		return email, false
+	}
+	return email, email.Host != "" && len(email.To) > 0
+}
+
+// publishEmail mails the matching events right away, unless email is
+// configured as a daily digest.
+func publishEmail(events []database.Event) {
+	email, ok := getEmail()
+	if !ok || email.Digest {
+		return
+	}
+	matched := filterEvents(email.Events, events)
+	if len(matched) == 0 {
+		return
+	}
+	subject := matched[0].Message
+	if len(matched) > 1 {
+		subject = locale.T(locale.Notify, "email.events", "count", len(matched))
+	}
+	go func() {
+		if err := SendEmail(subject, matched, false); err != nil {
+			logger.Warnf("Email fail, %v\n", err)
+		}
+	}()
+}
+
+// EmailDigestEnabled reports whether notify.email is configured as a digest
+// with recipients.
+func EmailDigestEnabled() bool {
+	email, ok := getEmail()
+	return ok && email.Digest
+}
+
+// SendEmailDigest mails the matching events as one digest, it does nothing
+// when email isn't configured as a digest.
+func SendEmailDigest(events []database.Event) error {
+	email, ok := getEmail()
+	if !ok || !email.Digest {
+		return nil
+	}
+	matched := filterEvents(email.Events, events)
+	return SendEmail(locale.T(locale.Notify, "email.digest", "count", len(matched)), matched, true)
+}
+
+func filterEvents(patterns []string, events []database.Event) []database.Event {
+	matched := make([]database.Event, 0, len(events))
+	for _, event := range events {
+		if MatchEvent(patterns, event) {
+			matched = append(matched, event)
+		}
+	}
+	return matched
+}
+
+// SendEmail renders the events with the configured template and mails them.
+func SendEmail(subject string, events []database.Event, digest bool) error {
+	email, ok := getEmail()
+	if !ok {
+		return errors.New("notify.email is not configured")
+	}
+	return sendEmailTo(email, email.To, subject, events, digest)
+}
+
+// SendEmailTo mails the events to the addresses given instead of
+// notify.email.to, like an applicant told of the decision. It only needs the
+// smtp server of notify.email.
+func SendEmailTo(to []string, subject string, events []database.Event) error {
+	email, _ := getEmail()
+	if email.Host == "" {
+		return errors.New("notify.email is not configured")
+	}
+	return sendEmailTo(email, to, subject, events, false)
+}
+
+func sendEmailTo(email Email, to []string, subject string, events []database.Event, digest bool) error {
+	body, err := renderEmail(email, emailData{
+		Subject: subject,
+		Digest:  digest,
+		Time:    time.Now(),
+		Events:  events,
+	})
+	if err != nil {
+		return err
+	}
+
+	from := email.From
+	if from == "" {
+		from = email.Username
+	}
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", from)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", "[PST] "+subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/html; charset=UTF-8\r\n\r\n")
+	msg.Write(body)
+
+	return sendMail(email, from, to, msg.Bytes())
+}
+
+func renderEmail(email Email, data emailData) ([]byte, error) {
+	text := defaultEmailTemplate
+	if source, ok := locale.Override("email.body"); ok {
+		text = source
+	} else if email.Template != "" {
+		b, err := os.ReadFile(email.Template)
+		if err != nil {
+			return nil, err
+		}
+		text = string(b)
+	}
+	tmpl, err := template.New("email").Funcs(template.FuncMap(locale.Funcs())).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func sendMail(email Email, from string, to []string, msg []byte) error {
+	port := email.Port
+	if port == 0 {
+		switch email.Security {
+		case "tls":
+			port = 465
+		case "starttls":
+			port = 587
+		default:
+			port = 25
+		}
+	}
+	addr := net.JoinHostPort(email.Host, strconv.Itoa(port))
+	tlsConfig := &tls.Config{ServerName: email.Host}
+
+	var conn net.Conn
+	var err error
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	if email.Security == "tls" {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return err
+	}
+	c, err := smtp.NewClient(conn, email.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if email.Security == "starttls" {
+		if err := c.StartTLS(tlsConfig); err != nil {
+			return err
+		}
+	}
+	if email.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", email.Username, email.Password, email.Host)); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(from); err != nil {
+		return err
+	}
+	for _, rcpt := range to {
+		if err := c.Rcpt(rcpt); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
@@ -0,0 +1,108 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/zaigie/palworld-server-tool/internal/bus"
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/internal/logger"
+)
+
+type Webhook struct {
+	Url    string   `mapstructure:"url"`
+	Events []string `mapstructure:"events"`
+	// Type is empty for the plain event json, or one of the group bots
+	// feishu, dingtalk and wecom
+	Type   string `mapstructure:"type"`
+	Secret string `mapstructure:"secret"`
+	// DigestInterval is the seconds player.join and player.leave are
+	// collected for one player.digest, 0 sends each on its own
+	DigestInterval int `mapstructure:"digest_interval"`
+	// Verbosity of the digest, full, names or count
+	Verbosity string `mapstructure:"verbosity"`
+}
+
+var client = &http.Client{Timeout: 10 * time.Second}
+
+// Subscribe pushes the events of the bus to the configured webhooks and
+// email.
+func Subscribe() {
+	bus.Subscribe("notify", nil, publish)
+}
+
+func publish(events []database.Event) {
+	publishEmail(events)
+	var webhooks []Webhook
+	if err := viper.UnmarshalKey("notify.webhooks", &webhooks); err != nil {
+		logger.Errorf("Parse notify.webhooks fail, %v\n", err)
+		return
+	}
+	for _, webhook := range webhooks {
+		if webhook.Url == "" {
+			continue
+		}
+		for _, event := range events {
+			if !MatchEvent(webhook.Events, event) {
+				continue
+			}
+			if webhook.DigestInterval > 0 && isPresence(event) {
+				addDigest(webhook, event)
+				continue
+			}
+			go func(webhook Webhook, event database.Event) {
+				if err := sendWebhook(webhook, event); err != nil {
+					logger.Warnf("Webhook %s fail, %v\n", webhook.Url, err)
+				}
+			}(webhook, event)
+		}
+	}
+}
+
+// MatchEvent reports whether the event is selected by patterns, see bus.Match.
+// An empty list selects only stored events, the frequent transient ones like
+// player.join and sync.done have to be listed.
+func MatchEvent(patterns []string, event database.Event) bool {
+	if len(patterns) == 0 && event.Id == 0 {
+		return false
+	}
+	return bus.Match(patterns, event.Type)
+}
+
+func sendWebhook(webhook Webhook, event database.Event) error {
+	switch webhook.Type {
+	case "":
+		_, err := postJSON(webhook.Url, event)
+		return err
+	case "feishu":
+		return sendFeishu(webhook, event)
+	case "dingtalk":
+		return sendDingTalk(webhook, event)
+	case "wecom":
+		return sendWeCom(webhook, event)
+	default:
+		return fmt.Errorf("unknown webhook type %s", webhook.Type)
+	}
+}
+
+func postJSON(url string, payload interface{}) ([]byte, error) {
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("%d %s", resp.StatusCode, body)
+	}
+	return body, nil
+}
@@ -0,0 +1,222 @@
+// Package paths resolves where pst keeps its files. Each directory can be
+// set in the paths config section, or the PATHS__*_DIR environment
+// variables, and defaults to the XDG base directories. Installs that already
+// have pst.db or backups in the working directory keep using them.
+package paths
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+
+	"github.com/spf13/viper"
+	"github.com/zaigie/palworld-server-tool/internal/logger"
+)
+
+const appName = "pst"
+
+var (
+	once sync.Once
+	dirs struct {
+		data    string
+		backups string
+		cache   string
+		logs    string
+	}
+)
+
+// Data is the directory of pst.db, replica snapshots and crash reports.
+func Data() string {
+	resolve()
+	return dirs.data
+}
+
+// Seasons is the directory of the databases archived when a season ends.
+func Seasons() string {
+	return filepath.Join(Data(), "seasons")
+}
+
+// Backups is the directory of the backup archives and the chunk store.
+func Backups() string {
+	resolve()
+	return dirs.backups
+}
+
+// Cache is the directory the save is copied to for parsing and backups.
+func Cache() string {
+	resolve()
+	return dirs.cache
+}
+
+// Logs is the directory of pst.log, empty when logging to stdout only.
+func Logs() string {
+	resolve()
+	return dirs.logs
+}
+
+func resolve() {
+	once.Do(func() {
+		wd, err := os.Getwd()
+		if err != nil {
+			wd = "."
+		}
+		dirs.data = viper.GetString("paths.data_dir")
+		if dirs.data == "" {
+			if exists(filepath.Join(wd, "pst.db")) {
+				dirs.data = wd
+			} else if dirs.data = userDir("XDG_DATA_HOME", ".local/share"); dirs.data == "" {
+				dirs.data = wd
+			}
+		}
+		dirs.backups = viper.GetString("paths.backup_dir")
+		if dirs.backups == "" {
+			if exists(filepath.Join(wd, "backups")) {
+				dirs.backups = filepath.Join(wd, "backups")
+			} else {
+				dirs.backups = filepath.Join(dirs.data, "backups")
+			}
+		}
+		dirs.cache = viper.GetString("paths.cache_dir")
+		if dirs.cache == "" {
+			dirs.cache = os.TempDir()
+		}
+		dirs.logs = viper.GetString("paths.log_dir")
+		for _, dir := range []*string{&dirs.data, &dirs.backups, &dirs.cache, &dirs.logs} {
+			if *dir != "" {
+				if abs, err := filepath.Abs(*dir); err == nil {
+					*dir = abs
+				}
+			}
+		}
+	})
+}
+
+// userDir is the pst directory in the XDG base directory of env, or in
+// the place for application data of Windows and macOS.
+func userDir(env, fallback string) string {
+	if dir := os.Getenv(env); filepath.IsAbs(dir) {
+		return filepath.Join(dir, appName)
+	}
+	switch runtime.GOOS {
+	case "windows":
+		if dir := os.Getenv("LocalAppData"); dir != "" {
+			return filepath.Join(dir, appName)
+		}
+	case "darwin":
+		if dir, err := os.UserConfigDir(); err == nil {
+			return filepath.Join(dir, appName)
+		}
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, fallback, appName)
+}
+
+// Init moves pst.db, the backups and the crash reports from the working
+// directory into the configured directories on the first start with them,
+// and checks every directory can be written.
+func Init() error {
+	resolve()
+	wd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	moves := []struct{ from, to string }{
+		{filepath.Join(wd, "pst.db"), filepath.Join(dirs.data, "pst.db")},
+		{filepath.Join(wd, "crash_reports"), filepath.Join(dirs.data, "crash_reports")},
+		{filepath.Join(wd, "backups"), dirs.backups},
+	}
+	for _, dir := range []string{dirs.data, dirs.backups, dirs.cache, dirs.logs} {
+		if dir == "" {
+			continue
+		}
+		if err := checkWritable(dir); err != nil {
+			return err
+		}
+	}
+	for _, m := range moves {
+		if m.from == m.to || !exists(m.from) || exists(m.to) && !isEmptyDir(m.to) {
+			continue
+		}
+		if err := move(m.from, m.to); err != nil {
+			return fmt.Errorf("move %s to %s: %w, move it by hand or unset the paths config", m.from, m.to, err)
+		}
+		logger.Infof("Moved %s to %s\n", m.from, m.to)
+	}
+	return nil
+}
+
+func checkWritable(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("create %s: %w", dir, err)
+	}
+	f, err := os.CreateTemp(dir, ".pst-write-check-*")
+	if err != nil {
+		return fmt.Errorf("%s is not writable: %w", dir, err)
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
+
+// move renames from to to, copying when they are on different file systems.
+func move(from, to string) error {
+	if exists(to) {
+		// an empty directory created by checkWritable
+		if err := os.Remove(to); err != nil {
+			return err
+		}
+	}
+	if err := os.Rename(from, to); err == nil {
+		return nil
+	}
+	if err := copyTree(from, to); err != nil {
+		os.RemoveAll(to)
+		return err
+	}
+	return os.RemoveAll(from)
+}
+
+func copyTree(from, to string) error {
+	return filepath.Walk(from, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(from, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(to, rel)
+		if info.IsDir() {
+			return os.MkdirAll(target, info.Mode().Perm())
+		}
+		src, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer src.Close()
+		dst, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode().Perm())
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(dst, src); err != nil {
+			dst.Close()
+			return err
+		}
+		return dst.Close()
+	})
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func isEmptyDir(path string) bool {
+	entries, err := os.ReadDir(path)
+	return err == nil && len(entries) == 0
+}
@@ -0,0 +1,211 @@
+// Package replica keeps a read-only copy of the primary's database for pst
+// instances that only serve the API and UI, so dashboards of big communities
+// don't all land on the instance doing the writes and RCON.
+package replica
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/zaigie/palworld-server-tool/internal/auth"
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/internal/logger"
+	"github.com/zaigie/palworld-server-tool/internal/paths"
+)
+
+const snapshotPattern = "pst-replica-*.db"
+
+var client = &http.Client{Timeout: 10 * time.Minute}
+
+var (
+	// current is the snapshot file being served
+	current string
+	// txid is the primary's transaction the current snapshot was taken at
+	txid    uint64
+	modTime time.Time
+)
+
+// Enabled reports whether this instance is a replica, pulling snapshots from
+// replica.primary_url or reading the file at replica.snapshot_path that some
+// other tool ships.
+func Enabled() bool {
+	return PrimaryUrl() != "" || viper.GetString("replica.snapshot_path") != ""
+}
+
+func PrimaryUrl() string {
+	return strings.TrimSuffix(viper.GetString("replica.primary_url"), "/")
+}
+
+func interval() time.Duration {
+	seconds := viper.GetInt("replica.interval")
+	if seconds <= 0 {
+		seconds = 30
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// Start loads a snapshot, falling back to the last one kept locally and
+// waiting for the primary when there is none, then keeps loading new ones
+// every replica.interval seconds.
+func Start() {
+	for {
+		err := Sync()
+		if err == nil {
+			break
+		}
+		if last := lastSnapshot(); last != "" {
+			if replaceErr := database.Replace(last); replaceErr == nil {
+				logger.Warnf("Replica sync fail, serving the local snapshot %s: %v\n", last, err)
+				current = last
+				break
+			}
+		}
+		logger.Errorf("Replica sync fail, retrying: %v\n", err)
+		time.Sleep(interval())
+	}
+	go func() {
+		for range time.Tick(interval()) {
+			if err := Sync(); err != nil {
+				logger.Errorf("Replica sync fail: %v\n", err)
+			}
+		}
+	}()
+}
+
+// Sync loads a newer snapshot when there is one.
+func Sync() error {
+	var path string
+	var err error
+	if PrimaryUrl() != "" {
+		path, err = pullSnapshot()
+	} else {
+		path, err = copySnapshot(viper.GetString("replica.snapshot_path"))
+	}
+	if err != nil || path == "" {
+		return err
+	}
+	if err := database.Replace(path); err != nil {
+		os.Remove(path)
+		return err
+	}
+	previous := current
+	current = path
+	logger.Infof("Replica loaded snapshot %s\n", filepath.Base(path))
+	// the previous one may still be read for a moment, it goes next time
+	removeSnapshots(current, previous)
+	return nil
+}
+
+func pullSnapshot() (string, error) {
+	token, err := auth.GenerateToken()
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/api/replica/snapshot?since=%d", PrimaryUrl(), txid), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotModified && current != "" {
+		return "", nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return "", fmt.Errorf("primary answered %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	next, err := strconv.ParseUint(resp.Header.Get("X-Snapshot-Txid"), 10, 64)
+	if err != nil {
+		return "", errors.New("primary sent no snapshot txid")
+	}
+	path := snapshotPath(strconv.FormatUint(next, 10))
+	if err := writeSnapshot(path, resp.Body, resp.ContentLength); err != nil {
+		return "", err
+	}
+	txid = next
+	return path, nil
+}
+
+func copySnapshot(src string) (string, error) {
+	info, err := os.Stat(src)
+	if err != nil {
+		return "", err
+	}
+	if info.ModTime().Equal(modTime) && current != "" {
+		return "", nil
+	}
+	f, err := os.Open(src)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	path := snapshotPath(strconv.FormatInt(info.ModTime().UnixNano(), 10))
+	if err := writeSnapshot(path, f, info.Size()); err != nil {
+		return "", err
+	}
+	modTime = info.ModTime()
+	return path, nil
+}
+
+func snapshotPath(id string) string {
+	return filepath.Join(paths.Data(), strings.Replace(snapshotPattern, "*", id, 1))
+}
+
+// writeSnapshot writes path through a temporary file, checking it got all
+// size bytes when the size is known.
+func writeSnapshot(path string, r io.Reader, size int64) error {
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	n, err := io.Copy(f, r)
+	if err == nil && size >= 0 && n != size {
+		err = fmt.Errorf("snapshot cut off at %d of %d bytes", n, size)
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func lastSnapshot() string {
+	matches, _ := filepath.Glob(filepath.Join(paths.Data(), snapshotPattern))
+	var last string
+	var lastTime time.Time
+	for _, path := range matches {
+		if info, err := os.Stat(path); err == nil && info.ModTime().After(lastTime) {
+			last, lastTime = path, info.ModTime()
+		}
+	}
+	return last
+}
+
+func removeSnapshots(keep ...string) {
+	matches, _ := filepath.Glob(filepath.Join(paths.Data(), snapshotPattern))
+	for _, path := range matches {
+		kept := false
+		for _, k := range keep {
+			kept = kept || path == k
+		}
+		if !kept {
+			os.Remove(path)
+		}
+	}
+}
@@ -12,6 +12,7 @@ import (
 
 	"github.com/docker/docker/pkg/stdcopy"
 	"github.com/google/uuid"
+	"github.com/zaigie/palworld-server-tool/internal/paths"
 	"github.com/zaigie/palworld-server-tool/internal/system"
 
 	"github.com/docker/docker/api/types"
@@ -57,7 +58,7 @@ func CopyFromContainer(containerID, remotePath, way string) (string, error) {
 
 	// 创建临时目录
 	id := uuid.New().String()
-	tempDir := filepath.Join(os.TempDir(), "palworldsav-docker-"+way+"-"+id)
+	tempDir := filepath.Join(paths.Cache(), "palworldsav-docker-"+way+"-"+id)
 	err = os.MkdirAll(tempDir, os.ModePerm)
 	if err != nil {
 		return "", err
@@ -8,6 +8,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/zaigie/palworld-server-tool/internal/logger"
+	"github.com/zaigie/palworld-server-tool/internal/paths"
 	"github.com/zaigie/palworld-server-tool/internal/system"
 )
 
@@ -20,7 +21,7 @@ func DownloadFromHttp(url, way string) (string, error) {
 	defer resp.Body.Close()
 
 	uuid := uuid.New().String()
-	tempPath := filepath.Join(os.TempDir(), "palworldsav-http-"+way+"-"+uuid)
+	tempPath := filepath.Join(paths.Cache(), "palworldsav-http-"+way+"-"+uuid)
 	absPath, err := filepath.Abs(tempPath)
 	if err != nil {
 		return "", err
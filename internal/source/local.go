@@ -8,6 +8,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/zaigie/palworld-server-tool/internal/logger"
+	"github.com/zaigie/palworld-server-tool/internal/paths"
 	"github.com/zaigie/palworld-server-tool/internal/system"
 )
 
@@ -36,7 +37,7 @@ func CopyFromLocal(src, way string) (string, error) {
 
 	// 创建临时目录
 	randId := uuid.New().String()
-	tempDir := filepath.Join(os.TempDir(), "palworldsav-"+way+"-"+randId)
+	tempDir := filepath.Join(paths.Cache(), "palworldsav-"+way+"-"+randId)
 	if err = os.MkdirAll(tempDir, fs.ModePerm); err != nil {
 		return "", err
 	}
@@ -13,6 +13,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/zaigie/palworld-server-tool/internal/logger"
+	"github.com/zaigie/palworld-server-tool/internal/paths"
 	"github.com/zaigie/palworld-server-tool/internal/system"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/client-go/kubernetes"
@@ -68,7 +69,7 @@ func CopyFromPod(namespace, podName, container, remotePath, way string) (string,
 	}
 
 	id := uuid.New().String()
-	tempDir := filepath.Join(os.TempDir(), "palworldsav-pod-"+way+"-"+id)
+	tempDir := filepath.Join(paths.Cache(), "palworldsav-pod-"+way+"-"+id)
 	err = os.MkdirAll(tempDir, os.ModePerm)
 	if err != nil {
 		return "", err
@@ -0,0 +1,229 @@
+package system
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Content defined chunking cuts where a rolling hash of the last bytes hits
+// the mask, so an edit in one part of a file only changes the chunks around
+// it and the rest are stored once across backups.
+const (
+	minChunkSize = 512 << 10
+	maxChunkSize = 8 << 20
+	// about 2 MiB between cuts past the minimum
+	chunkMask = 1<<21 - 1
+)
+
+var gearTable = func() [256]uint64 {
+	var table [256]uint64
+	// splitmix64 with a fixed seed, the cuts must not change between runs
+	seed := uint64(0x9e3779b97f4a7c15)
+	for i := range table {
+		seed += 0x9e3779b97f4a7c15
+		z := seed
+		z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+		z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+		table[i] = z ^ (z >> 31)
+	}
+	return table
+}()
+
+// ChunkStore keeps chunks by their hex sha256 under Dir, encrypted when it
+// has a Keyring. The name is the hash of the plain chunk so encrypting
+// doesn't get in the way of deduplication.
+type ChunkStore struct {
+	Dir     string
+	Keyring *Keyring
+}
+
+func (s ChunkStore) path(chunk string) string {
+	return filepath.Join(s.Dir, chunk[:2], chunk)
+}
+
+// Put splits r into chunks and stores the ones not stored yet. It returns
+// the chunks in order and how many bytes were newly written.
+func (s ChunkStore) Put(r io.Reader) ([]string, int64, error) {
+	br := bufio.NewReaderSize(r, 1<<20)
+	buf := make([]byte, 0, maxChunkSize)
+	var chunks []string
+	var added int64
+	var hash uint64
+	flush := func() error {
+		if len(buf) == 0 {
+			return nil
+		}
+		chunk, n, err := s.write(buf)
+		if err != nil {
+			return err
+		}
+		chunks = append(chunks, chunk)
+		added += n
+		buf = buf[:0]
+		hash = 0
+		return nil
+	}
+	for {
+		b, err := br.ReadByte()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		buf = append(buf, b)
+		hash = hash<<1 + gearTable[b]
+		if len(buf) >= maxChunkSize || (len(buf) >= minChunkSize && hash&chunkMask == 0) {
+			if err := flush(); err != nil {
+				return nil, 0, err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return nil, 0, err
+	}
+	return chunks, added, nil
+}
+
+func (s ChunkStore) write(data []byte) (string, int64, error) {
+	sum := sha256.Sum256(data)
+	chunk := hex.EncodeToString(sum[:])
+	path := s.path(chunk)
+	if _, err := os.Stat(path); err == nil {
+		// touched so a collection running meanwhile sees it as new
+		now := time.Now()
+		return chunk, 0, os.Chtimes(path, now, now)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return "", 0, err
+	}
+	n, err := s.store(path, data)
+	if err != nil {
+		return "", 0, err
+	}
+	return chunk, n, nil
+}
+
+// store writes the chunk to path through a temporary file, sealed when the
+// store has a keyring, and returns how many bytes were written.
+func (s ChunkStore) store(path string, data []byte) (int64, error) {
+	if s.Keyring != nil {
+		var sealed bytes.Buffer
+		if err := s.Keyring.Seal(&sealed, bytes.NewReader(data)); err != nil {
+			return 0, err
+		}
+		data = sealed.Bytes()
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return 0, err
+	}
+	return int64(len(data)), os.Rename(tmp, path)
+}
+
+// read returns the plain chunk stored at path.
+func (s ChunkStore) read(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil || !IsSealed(data) {
+		return data, err
+	}
+	if s.Keyring == nil {
+		return nil, errors.New("chunk is encrypted but no backup key is configured")
+	}
+	var plain bytes.Buffer
+	if err := s.Keyring.Open(&plain, bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+	return plain.Bytes(), nil
+}
+
+// WriteTo writes the chunks in order to w.
+func (s ChunkStore) WriteTo(w io.Writer, chunks []string) error {
+	for _, chunk := range chunks {
+		if len(chunk) < 2 {
+			return errors.New("invalid chunk " + chunk)
+		}
+		data, err := s.read(s.path(chunk))
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Rekey seals the chunks that are plain or sealed with another key with the
+// active key of the keyring. It returns how many chunks were rewritten.
+func (s ChunkStore) Rekey() (int, error) {
+	if s.Keyring == nil {
+		return 0, errors.New("no backup key is configured")
+	}
+	rekeyed := 0
+	err := filepath.Walk(s.Dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.IsDir() || filepath.Ext(path) == ".tmp" {
+			return nil
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		keyId, _ := SealedKeyId(f)
+		f.Close()
+		if keyId == s.Keyring.ActiveKeyId() {
+			return nil
+		}
+		data, err := s.read(path)
+		if err != nil {
+			return fmt.Errorf("chunk %s: %w", info.Name(), err)
+		}
+		if _, err := s.store(path, data); err != nil {
+			return err
+		}
+		rekeyed++
+		return nil
+	})
+	return rekeyed, err
+}
+
+// Collect removes the chunks not in keep that are older than grace, so
+// chunks of a backup still being recorded survive. It returns how many
+// chunks and bytes were removed.
+func (s ChunkStore) Collect(keep map[string]bool, grace time.Duration) (int, int64, error) {
+	removed := 0
+	var freed int64
+	deadline := time.Now().Add(-grace)
+	err := filepath.Walk(s.Dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.IsDir() || keep[info.Name()] || info.ModTime().After(deadline) {
+			return nil
+		}
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+		removed++
+		freed += info.Size()
+		return nil
+	})
+	return removed, freed, err
+}
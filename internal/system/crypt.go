@@ -0,0 +1,185 @@
+package system
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Sealed data is a header naming the key, then segments of AES-GCM, each
+// with a nonce of a random prefix, its index and whether it is the last so
+// reordered or truncated data fails to open.
+const (
+	sealMagic   = "PSTENC1\n"
+	keyIdLength = 8
+	segmentSize = 1 << 20
+)
+
+var (
+	ErrUnknownKey = errors.New("backup is encrypted with a key not in the keyring")
+	errCorrupted  = errors.New("backup is corrupted or was tampered with")
+)
+
+// Keyring holds the keys backups may be encrypted with, new data is sealed
+// with the active one.
+type Keyring struct {
+	keys   map[string]cipher.AEAD
+	active string
+}
+
+// KeyId is the short id a key is recorded by, so the key itself is never
+// stored next to the data.
+func KeyId(key []byte) string {
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:])[:keyIdLength]
+}
+
+// ParseKey decodes an AES-256 key given in base64 or hex.
+func ParseKey(s string) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	var key []byte
+	var err error
+	if len(s) == 64 {
+		key, err = hex.DecodeString(s)
+	} else {
+		key, err = base64.StdEncoding.DecodeString(s)
+	}
+	if err != nil {
+		return nil, errors.New("key is neither hex nor base64")
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("key is %d bytes, want 32", len(key))
+	}
+	return key, nil
+}
+
+// NewKeyring returns a keyring of keys, the last one being active.
+func NewKeyring(keys ...[]byte) (*Keyring, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("no keys")
+	}
+	k := &Keyring{keys: make(map[string]cipher.AEAD, len(keys))}
+	for _, key := range keys {
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+		k.active = KeyId(key)
+		k.keys[k.active] = aead
+	}
+	return k, nil
+}
+
+func (k *Keyring) ActiveKeyId() string {
+	return k.active
+}
+
+// IsSealed reports whether data starts like sealed data.
+func IsSealed(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(sealMagic))
+}
+
+// SealedKeyId returns the id of the key r was sealed with.
+func SealedKeyId(r io.Reader) (string, error) {
+	header := make([]byte, len(sealMagic)+keyIdLength)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return "", err
+	}
+	if !IsSealed(header) {
+		return "", errors.New("not encrypted")
+	}
+	return string(header[len(sealMagic):]), nil
+}
+
+func segmentNonce(prefix []byte, index uint32, last bool) []byte {
+	nonce := make([]byte, 12)
+	copy(nonce, prefix)
+	binary.BigEndian.PutUint32(nonce[7:], index)
+	if last {
+		nonce[11] = 1
+	}
+	return nonce
+}
+
+// Seal encrypts src to dst with the active key.
+func (k *Keyring) Seal(dst io.Writer, src io.Reader) error {
+	aead := k.keys[k.active]
+	prefix := make([]byte, 7)
+	if _, err := rand.Read(prefix); err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(dst)
+	bw.WriteString(sealMagic)
+	bw.WriteString(k.active)
+	bw.Write(prefix)
+	buf := make([]byte, segmentSize)
+	out := make([]byte, 0, segmentSize+aead.Overhead())
+	for index := uint32(0); ; index++ {
+		n, err := io.ReadFull(src, buf)
+		last := err == io.EOF || err == io.ErrUnexpectedEOF
+		if err != nil && !last {
+			return err
+		}
+		out = aead.Seal(out[:0], segmentNonce(prefix, index, last), buf[:n], nil)
+		if _, err := bw.Write(out); err != nil {
+			return err
+		}
+		if last {
+			return bw.Flush()
+		}
+	}
+}
+
+// Open decrypts src to dst with the key it was sealed with.
+func (k *Keyring) Open(dst io.Writer, src io.Reader) error {
+	br := bufio.NewReader(src)
+	keyId, err := SealedKeyId(br)
+	if err != nil {
+		return err
+	}
+	aead, ok := k.keys[keyId]
+	if !ok {
+		return ErrUnknownKey
+	}
+	prefix := make([]byte, 7)
+	if _, err := io.ReadFull(br, prefix); err != nil {
+		return err
+	}
+	buf := make([]byte, segmentSize+aead.Overhead())
+	out := make([]byte, 0, segmentSize)
+	for index := uint32(0); ; index++ {
+		n, err := io.ReadFull(br, buf)
+		// a full segment is never the last one, the data got cut off
+		if err == io.EOF {
+			return io.ErrUnexpectedEOF
+		}
+		last := err == io.ErrUnexpectedEOF
+		if err != nil && !last {
+			return err
+		}
+		out, err = aead.Open(out[:0], segmentNonce(prefix, index, last), buf[:n], nil)
+		if err != nil {
+			return errCorrupted
+		}
+		if _, err := dst.Write(out); err != nil {
+			return err
+		}
+		if last {
+			return nil
+		}
+	}
+}
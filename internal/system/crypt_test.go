@@ -0,0 +1,93 @@
+package system
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestKeyringOpen(t *testing.T) {
+	oldKey, key := make([]byte, 32), make([]byte, 32)
+	rand.Read(oldKey)
+	rand.Read(key)
+	k, err := NewKeyring(oldKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := NewKeyring(oldKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plain := make([]byte, 2*segmentSize+segmentSize/2)
+	rand.Read(plain)
+	var sealed bytes.Buffer
+	if err := k.Seal(&sealed, bytes.NewReader(plain)); err != nil {
+		t.Fatal(err)
+	}
+	data := sealed.Bytes()
+	header := len(sealMagic) + keyIdLength + 7
+	segment := segmentSize + 16
+	seg := func(i int) []byte {
+		end := header + (i+1)*segment
+		if end > len(data) {
+			end = len(data)
+		}
+		return data[header+i*segment : end]
+	}
+	join := func(parts ...[]byte) []byte {
+		return bytes.Join(parts, nil)
+	}
+	flipped := join(data)
+	flipped[header+10] ^= 1
+
+	tests := []struct {
+		name    string
+		keyring *Keyring
+		data    []byte
+		want    error
+	}{
+		{"intact", k, data, nil},
+		{"segments swapped", k, join(data[:header], seg(1), seg(0), seg(2)), errCorrupted},
+		{"cut at a segment", k, data[:header+2*segment], io.ErrUnexpectedEOF},
+		{"cut inside a segment", k, data[:header+segment+100], errCorrupted},
+		{"last segment dropped", k, join(data[:header], seg(0), seg(2)), errCorrupted},
+		{"bit flipped", k, flipped, errCorrupted},
+		{"header only", k, data[:header], io.ErrUnexpectedEOF},
+		{"unknown key", other, data, ErrUnknownKey},
+		{"not sealed", k, plain[:100], errors.New("not encrypted")},
+	}
+	for _, tt := range tests {
+		var out bytes.Buffer
+		err := tt.keyring.Open(&out, bytes.NewReader(tt.data))
+		switch {
+		case tt.want == nil && err != nil:
+			t.Errorf("%s: %v", tt.name, err)
+		case tt.want == nil && !bytes.Equal(out.Bytes(), plain):
+			t.Errorf("%s: opened %d bytes that differ from the %d sealed", tt.name, out.Len(), len(plain))
+		case tt.want != nil && (err == nil || err.Error() != tt.want.Error()):
+			t.Errorf("%s: got %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestKeyringSealSizes(t *testing.T) {
+	k, err := NewKeyring(make([]byte, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, size := range []int{0, 1, segmentSize - 1, segmentSize, segmentSize + 1, 2 * segmentSize} {
+		plain := bytes.Repeat([]byte{'p'}, size)
+		var sealed, out bytes.Buffer
+		if err := k.Seal(&sealed, bytes.NewReader(plain)); err != nil {
+			t.Fatal(err)
+		}
+		if err := k.Open(&out, &sealed); err != nil {
+			t.Errorf("%d bytes: %v", size, err)
+		} else if !bytes.Equal(out.Bytes(), plain) {
+			t.Errorf("%d bytes: opened %d bytes", size, out.Len())
+		}
+	}
+}
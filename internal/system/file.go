@@ -48,21 +48,31 @@ func CopyDir(srcDir, dstDir string) error {
 	})
 }
 
+// CopyFile copies srcFile to destFile keeping its modification time, the
+// time of a save is its Level.sav's.
 func CopyFile(srcFile, destFile string) error {
 	input, err := os.Open(srcFile)
 	if err != nil {
 		return err
 	}
 	defer input.Close()
+	info, err := input.Stat()
+	if err != nil {
+		return err
+	}
 
 	output, err := os.Create(destFile)
 	if err != nil {
 		return err
 	}
-	defer output.Close()
-
 	_, err = io.Copy(output, input)
-	return err
+	if cerr := output.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Chtimes(destFile, info.ModTime(), info.ModTime())
 }
 
 func ZipDir(srcDir, zipFilePath string) error {
@@ -252,19 +262,19 @@ type dirInfo struct {
 	createTime time.Time
 }
 
-// LimitCacheDir keeps only the latest `n` directories in the cache directory
-func LimitCacheDir(cacheDirPrefix string, n int) error {
-	tempDir := os.TempDir()
-	entries, err := os.ReadDir(tempDir)
+// LimitCacheDir keeps only the latest `n` directories starting with
+// cacheDirPrefix in the cache directory
+func LimitCacheDir(cacheDir, cacheDirPrefix string, n int) error {
+	entries, err := os.ReadDir(cacheDir)
 	if err != nil {
-		logger.Errorf("LimitCacheDir: error reading temp directory: %v\n", err)
+		logger.Errorf("LimitCacheDir: error reading cache directory: %v\n", err)
 		return err
 	}
 
 	var dirs []dirInfo
 	for _, entry := range entries {
-		if entry.IsDir() && strings.HasPrefix(filepath.Base(entry.Name()), cacheDirPrefix) {
-			dirPath := filepath.Join(tempDir, entry.Name())
+		if entry.IsDir() && strings.HasPrefix(entry.Name(), cacheDirPrefix) {
+			dirPath := filepath.Join(cacheDir, entry.Name())
 			createTime := GetEntryCreateTime(entry)
 			dirs = append(dirs, dirInfo{path: dirPath, createTime: createTime})
 		}
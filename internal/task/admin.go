@@ -0,0 +1,177 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/spf13/viper"
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/internal/logger"
+	"github.com/zaigie/palworld-server-tool/internal/tool"
+	"github.com/zaigie/palworld-server-tool/service"
+	"go.etcd.io/bbolt"
+)
+
+// Admin actions are RCON commands from admin.commands, the game's own
+// /TeleportToPlayer and /TeleportToMe only work typed in-game by an admin
+// and it has no command giving items, so over RCON they need a server mod.
+const (
+	AdminTeleport   = "teleport"
+	AdminBring      = "bring"
+	AdminGiveItem   = "give_item"
+	AdminGiveExp    = "give_exp"
+	AdminUnlockTech = "unlock_tech"
+)
+
+var (
+	ErrAdminCommandUnset = errors.New("admin command not set")
+	ErrGiveRestricted    = errors.New("not allowed for the player's group by admin.give_limits")
+)
+
+// GiveLimit restricts what members of a player group may be given, the
+// limit without a group is for players in none of the listed groups.
+type GiveLimit struct {
+	Group string `json:"group" mapstructure:"group"`
+	// Actions are the allowed give_item, give_exp and unlock_tech, empty
+	// allows them all
+	Actions []string `json:"actions" mapstructure:"actions"`
+	// Items are the allowed item keys, empty allows every item
+	Items    []string `json:"items" mapstructure:"items"`
+	MaxCount int      `json:"max_count" mapstructure:"max_count"`
+	MaxExp   int64    `json:"max_exp" mapstructure:"max_exp"`
+}
+
+// giveLimit returns the limit of the first listed group the player is in,
+// nil when no limit applies.
+func giveLimit(db *bbolt.DB, player database.TersePlayer) (*GiveLimit, error) {
+	var limits []GiveLimit
+	if err := viper.UnmarshalKey("admin.give_limits", &limits); err != nil {
+		return nil, err
+	}
+	if len(limits) == 0 {
+		return nil, nil
+	}
+	groups, err := service.ListPlayerGroups(db)
+	if err != nil {
+		return nil, err
+	}
+	var fallback *GiveLimit
+	for i, limit := range limits {
+		if limit.Group == "" {
+			if fallback == nil {
+				fallback = &limits[i]
+			}
+			continue
+		}
+		for _, group := range groups {
+			if group.Name != limit.Group {
+				continue
+			}
+			for _, member := range group.Members {
+				if service.IsGroupMember(member, player.PlayerUid, player.SteamId) {
+					return &limits[i], nil
+				}
+			}
+		}
+	}
+	return fallback, nil
+}
+
+// CheckGiveLimit returns ErrGiveRestricted when the player's group may not
+// be given amount of the item, or amount exp for give_exp.
+func CheckGiveLimit(db *bbolt.DB, player database.TersePlayer, action, item string, amount int64) error {
+	limit, err := giveLimit(db, player)
+	if err != nil || limit == nil {
+		return err
+	}
+	if len(limit.Actions) > 0 && !slices.Contains(limit.Actions, action) {
+		return fmt.Errorf("%w: %s", ErrGiveRestricted, action)
+	}
+	switch action {
+	case AdminGiveItem:
+		if len(limit.Items) > 0 && !slices.ContainsFunc(limit.Items, func(key string) bool { return strings.EqualFold(key, item) }) {
+			return fmt.Errorf("%w: item %s", ErrGiveRestricted, item)
+		}
+		if limit.MaxCount > 0 && amount > int64(limit.MaxCount) {
+			return fmt.Errorf("%w: at most %d at once", ErrGiveRestricted, limit.MaxCount)
+		}
+	case AdminGiveExp:
+		if limit.MaxExp > 0 && amount > limit.MaxExp {
+			return fmt.Errorf("%w: at most %d exp at once", ErrGiveRestricted, limit.MaxExp)
+		}
+	}
+	return nil
+}
+
+// AdminAction is an admin command about a target player or the world, sent
+// for a request or task described by By.
+type AdminAction struct {
+	Action string
+	Target database.TersePlayer
+	// Admin is the character the action moves from or to, if any
+	Admin *database.TersePlayer
+	// Vars are more placeholders of the command, like {item_id}
+	Vars map[string]string
+	By   string
+}
+
+// Command returns the command of the action with {steam_id}, {player_uid}
+// and {nickname} of the target, the same prefixed with admin_ for the admin
+// and Vars replaced.
+func (a AdminAction) Command() (string, error) {
+	template := viper.GetString("admin.commands." + a.Action)
+	if template == "" {
+		return "", fmt.Errorf("%w: admin.commands.%s, the game needs a server mod for it", ErrAdminCommandUnset, a.Action)
+	}
+	pairs := []string{
+		"{steam_id}", a.Target.SteamId,
+		"{player_uid}", a.Target.PlayerUid,
+		"{nickname}", a.Target.Nickname,
+	}
+	if a.Admin != nil {
+		pairs = append(pairs,
+			"{admin_steam_id}", a.Admin.SteamId,
+			"{admin_player_uid}", a.Admin.PlayerUid,
+			"{admin_nickname}", a.Admin.Nickname,
+		)
+	}
+	for k, v := range a.Vars {
+		pairs = append(pairs, "{"+k+"}", v)
+	}
+	return strings.NewReplacer(pairs...).Replace(template), nil
+}
+
+// RunAdminAction sends the command of the action and records it as an
+// admin.command event for the audit trail.
+func RunAdminAction(ctx context.Context, db *bbolt.DB, action AdminAction) (string, error) {
+	command, err := action.Command()
+	if err != nil {
+		return "", err
+	}
+	response, err := tool.CustomCommand(ctx, command)
+	if err != nil {
+		return "", err
+	}
+	message := fmt.Sprintf("Admin %s sent by %s", action.Action, action.By)
+	if action.Target.PlayerUid != "" {
+		message = fmt.Sprintf("Admin %s of %s sent by %s", action.Action, action.Target.Nickname, action.By)
+	}
+	logger.Infof("%s\n", message)
+	data := map[string]string{"action": action.Action, "command": command, "by": action.By}
+	if action.Admin != nil {
+		data["admin_player_uid"] = action.Admin.PlayerUid
+	}
+	for k, v := range action.Vars {
+		data[k] = v
+	}
+	recordEvent(db, database.Event{
+		Type:      service.EventAdminCommand,
+		PlayerUid: action.Target.PlayerUid,
+		Message:   message,
+		Data:      data,
+	})
+	return response, nil
+}
@@ -0,0 +1,141 @@
+package task
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/internal/locale"
+	"github.com/zaigie/palworld-server-tool/internal/logger"
+	"github.com/zaigie/palworld-server-tool/internal/tool"
+	"github.com/zaigie/palworld-server-tool/service"
+	"go.etcd.io/bbolt"
+)
+
+type AfkPlayer struct {
+	PlayerUid string    `json:"player_uid"`
+	SteamId   string    `json:"steam_id"`
+	Nickname  string    `json:"nickname"`
+	LocationX float64   `json:"location_x"`
+	LocationY float64   `json:"location_y"`
+	Since     time.Time `json:"since"`
+	Warned    bool      `json:"warned"`
+}
+
+var (
+	afkMu sync.Mutex
+	// afkPlayers holds where each online player was last seen moving
+	afkPlayers = make(map[string]*AfkPlayer)
+)
+
+// ListAfkPlayers returns the online players who haven't moved for
+// manage.afk_minutes, longest first.
+func ListAfkPlayers() []AfkPlayer {
+	threshold := time.Duration(viper.GetInt("manage.afk_minutes")) * time.Minute
+	afk := make([]AfkPlayer, 0)
+	if threshold <= 0 {
+		return afk
+	}
+	afkMu.Lock()
+	defer afkMu.Unlock()
+	for _, p := range afkPlayers {
+		if time.Since(p.Since) >= threshold {
+			afk = append(afk, *p)
+		}
+	}
+	sort.Slice(afk, func(i, j int) bool { return afk[i].Since.Before(afk[j].Since) })
+	return afk
+}
+
+// CheckAfkPlayers tracks the coordinates of online players, and when the server
+// is full warns the AFK ones, then kicks them at the next poll if
+// manage.afk_kick is on.
+func CheckAfkPlayers(db *bbolt.DB, players []database.OnlinePlayer) {
+	minutes := viper.GetInt("manage.afk_minutes")
+	if minutes <= 0 {
+		return
+	}
+	threshold := time.Duration(minutes) * time.Minute
+	now := time.Now()
+
+	afkMu.Lock()
+	online := make(map[string]*AfkPlayer, len(players))
+	var afk []*AfkPlayer
+	for _, player := range players {
+		if player.PlayerUid == "" {
+			continue
+		}
+		p, ok := afkPlayers[player.PlayerUid]
+		if !ok || p.LocationX != player.LocationX || p.LocationY != player.LocationY {
+			p = &AfkPlayer{Since: now}
+		}
+		p.PlayerUid, p.SteamId, p.Nickname = player.PlayerUid, player.SteamId, player.Nickname
+		p.LocationX, p.LocationY = player.LocationX, player.LocationY
+		online[player.PlayerUid] = p
+		if now.Sub(p.Since) >= threshold {
+			afk = append(afk, p)
+		}
+	}
+	afkPlayers = online
+	afkMu.Unlock()
+
+	if len(afk) == 0 || !viper.GetBool("manage.afk_kick") {
+		return
+	}
+	metrics, err := tool.Metrics(context.Background())
+	if err != nil {
+		logger.Errorf("%v\n", err)
+		return
+	}
+	if len(players) < metrics["max_player_num"].(int) {
+		return
+	}
+	groups, err := service.ListPlayerGroups(db)
+	if err != nil {
+		logger.Errorf("%v\n", err)
+		return
+	}
+	exempt := viper.GetStringSlice("manage.afk_exempt")
+
+	warnMessage := locale.Source(locale.Broadcast, "afk.warn", "manage.afk_warn_message")
+	for _, p := range afk {
+		player := database.OnlinePlayer{PlayerUid: p.PlayerUid, SteamId: p.SteamId, Nickname: p.Nickname}
+		if p.SteamId == "" || isGroupAdmin(player, groups) || isExempt(player, exempt) {
+			continue
+		}
+		afkMu.Lock()
+		warned := p.Warned
+		p.Warned = true
+		afkMu.Unlock()
+		if !warned {
+			if warnMessage != "" {
+				BroadcastVariableMessage(warnMessage, p.Nickname, len(players))
+			}
+			continue
+		}
+		if err := tool.KickPlayer(context.Background(), fmt.Sprintf("steam_%s", p.SteamId)); err != nil {
+			logger.Warnf("Kick %s fail, %s \n", p.Nickname, err)
+			continue
+		}
+		logger.Warnf("Kicked AFK player %s\n", p.Nickname)
+		recordEvent(db, database.Event{
+			Type:      service.EventAfkKick,
+			PlayerUid: p.PlayerUid,
+			Message:   fmt.Sprintf("%s was kicked after %d minutes AFK", p.Nickname, int(now.Sub(p.Since).Minutes())),
+		})
+	}
+}
+
+// isExempt reports whether the player uid or steam id is in the list.
+func isExempt(player database.OnlinePlayer, exempt []string) bool {
+	for _, id := range exempt {
+		if id != "" && (id == player.PlayerUid || id == player.SteamId) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package task
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/internal/locale"
+	"github.com/zaigie/palworld-server-tool/internal/logger"
+	"github.com/zaigie/palworld-server-tool/service"
+	"go.etcd.io/bbolt"
+)
+
+// BadgeRules returns badges.rules.
+func BadgeRules() ([]service.BadgeRule, error) {
+	var rules []service.BadgeRule
+	if err := viper.UnmarshalKey("badges.rules", &rules); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// RecordStreaks counts today for the online players.
+func RecordStreaks(db *bbolt.DB, players []database.OnlinePlayer) {
+	if err := service.RecordStreaks(db, players, time.Now()); err != nil {
+		logger.Errorf("%v\n", err)
+	}
+}
+
+// BadgeSync awards the badges players reached since the last save sync and
+// announces them.
+func BadgeSync(db *bbolt.DB) {
+	rules, err := BadgeRules()
+	if err != nil {
+		logger.Errorf("%v\n", err)
+		return
+	}
+	if len(rules) == 0 {
+		return
+	}
+	awards, err := service.AwardBadges(db, rules, time.Now())
+	if err != nil {
+		logger.Errorf("%v\n", err)
+		return
+	}
+	for _, award := range awards {
+		logger.Infof("%s earned badge %s\n", award.Nickname, award.Rule.Name)
+		if viper.GetBool("badges.announce") {
+			broadcastLines(locale.Text(locale.Broadcast, "badge.earned", "badges.message",
+				"username", award.Nickname,
+				"badge", award.Rule.Name,
+				"description", award.Rule.Description,
+			))
+		}
+		recordEvent(db, database.Event{
+			Type:      service.EventBadgeEarned,
+			PlayerUid: award.PlayerUid,
+			Message:   fmt.Sprintf("%s earned badge %s", award.Nickname, award.Rule.Name),
+			Data: map[string]string{
+				"nickname": award.Nickname,
+				"badge":    award.Rule.Id,
+				"name":     award.Rule.Name,
+				"value":    fmt.Sprint(award.Badge.Value),
+			},
+		})
+	}
+}
@@ -0,0 +1,210 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/zaigie/palworld-server-tool/internal/bus"
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/internal/locale"
+	"github.com/zaigie/palworld-server-tool/internal/logger"
+	"github.com/zaigie/palworld-server-tool/internal/tool"
+	"github.com/zaigie/palworld-server-tool/service"
+	"go.etcd.io/bbolt"
+)
+
+type chatCommand func(db *bbolt.DB, player database.TersePlayer, args []string) (string, error)
+
+var chatCommands = map[string]chatCommand{
+	"points":  pointsChatCommand,
+	"rewards": rewardsChatCommand,
+	"redeem":  redeemChatCommand,
+
+	"restartvote": restartVoteChatCommand,
+	"votekick":    voteKickChatCommand,
+}
+
+// identifiedChatCommands spend points or vote, they only run for a sender
+// the pattern identifies by steam_id or player_uid, or who is online under
+// the nickname, anyone can write with the nickname of an offline player.
+var identifiedChatCommands = map[string]bool{
+	"redeem":      true,
+	"restartvote": true,
+	"votekick":    true,
+}
+
+// ChatTail publishes the lines of chat.log_path matching chat.pattern as
+// chat.message events and answers the chat commands among them. The
+// dedicated server doesn't log chat itself, a server mod has to.
+func ChatTail(db *bbolt.DB) {
+	pattern, err := regexp.Compile(viper.GetString("chat.pattern"))
+	if err != nil {
+		logger.Errorf("invalid chat.pattern: %v\n", err)
+		return
+	}
+	bus.Subscribe("chat_commands", []string{service.EventChatMessage}, func(events []database.Event) {
+		RunChatCommands(db, events)
+	})
+
+	tail := &tool.LogTail{Path: viper.GetString("chat.log_path")}
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	var lastErr string
+	for range ticker.C {
+		lines, err := tail.ReadLines()
+		if err != nil {
+			// the file is missing while the server is down, say so once
+			if err.Error() != lastErr {
+				logger.Warnf("Read chat log fail, %v\n", err)
+				lastErr = err.Error()
+			}
+			continue
+		}
+		lastErr = ""
+		var events []database.Event
+		for _, line := range lines {
+			if event, ok := parseChatLine(pattern, line); ok {
+				events = append(events, event)
+			}
+		}
+		publishEvents(events...)
+	}
+}
+
+// parseChatLine reads the named groups name, message and optionally
+// steam_id and player_uid of pattern.
+func parseChatLine(pattern *regexp.Regexp, line string) (database.Event, bool) {
+	match := pattern.FindStringSubmatch(line)
+	if match == nil {
+		return database.Event{}, false
+	}
+	data := make(map[string]string)
+	for i, name := range pattern.SubexpNames() {
+		switch name {
+		case "name":
+			data["nickname"] = match[i]
+		case "message", "steam_id", "player_uid":
+			data[name] = match[i]
+		}
+	}
+	if data["message"] == "" {
+		return database.Event{}, false
+	}
+	return database.Event{
+		Type:      service.EventChatMessage,
+		PlayerUid: service.CanonicalPlayerUid(data["player_uid"]),
+		Message:   fmt.Sprintf("%s: %s", data["nickname"], data["message"]),
+		Data:      data,
+	}, true
+}
+
+// RunChatCommands runs the messages starting with chat.prefix and
+// broadcasts the reply, the only way to answer in game.
+func RunChatCommands(db *bbolt.DB, events []database.Event) {
+	prefix := viper.GetString("chat.prefix")
+	for _, event := range events {
+		message := strings.TrimSpace(event.Data["message"])
+		if prefix == "" || !strings.HasPrefix(message, prefix) {
+			continue
+		}
+		args := strings.Fields(strings.TrimPrefix(message, prefix))
+		if len(args) == 0 {
+			continue
+		}
+		name := strings.ToLower(args[0])
+		cmd, ok := chatCommands[name]
+		if !ok {
+			continue
+		}
+		player, byId, err := findChatPlayer(db, event.Data)
+		if err == nil && !byId && identifiedChatCommands[name] {
+			err = checkChatPlayerOnline(player)
+		}
+		var reply string
+		if err == nil {
+			reply, err = cmd(db, player, args[1:])
+		}
+		if err != nil {
+			reply = chatError(err)
+		}
+		if reply != "" {
+			broadcastLines(fmt.Sprintf("@%s %s", event.Data["nickname"], reply))
+		}
+	}
+}
+
+// chatError is the reply for err, known errors are translated.
+func chatError(err error) string {
+	switch err {
+	case ErrUnknownReward:
+		return locale.T(locale.Broadcast, "reward.unknown")
+	case service.ErrInsufficientPoints:
+		return locale.T(locale.Broadcast, "points.insufficient")
+	}
+	return err.Error()
+}
+
+// findChatPlayer matches the sender by steam id or uid when the pattern
+// captures them, by nickname otherwise. byId tells the first.
+func findChatPlayer(db *bbolt.DB, data map[string]string) (player database.TersePlayer, byId bool, err error) {
+	if uid := data["player_uid"]; uid != "" {
+		if player, err := service.GetPlayer(db, uid); err == nil {
+			return player.TersePlayer, true, nil
+		}
+	}
+	players, err := service.ListPlayers(db)
+	if err != nil {
+		return database.TersePlayer{}, false, err
+	}
+	var matched []database.TersePlayer
+	for _, p := range players {
+		if steamId := data["steam_id"]; steamId != "" {
+			if p.SteamId == steamId {
+				return p, true, nil
+			}
+			continue
+		}
+		if p.Nickname == data["nickname"] {
+			matched = append(matched, p)
+		}
+	}
+	if len(matched) == 1 {
+		return matched[0], false, nil
+	}
+	if len(matched) > 1 {
+		return database.TersePlayer{}, false, errors.New(locale.T(locale.Broadcast, "chat.name_taken"))
+	}
+	return database.TersePlayer{}, false, errors.New(locale.T(locale.Broadcast, "chat.unknown_player"))
+}
+
+// checkChatPlayerOnline fails unless the player matched by nickname is the
+// only one online under it.
+func checkChatPlayerOnline(player database.TersePlayer) error {
+	online, err := tool.ShowPlayers(context.Background())
+	if err != nil {
+		return err
+	}
+	var matched []database.OnlinePlayer
+	for _, p := range online {
+		if p.Nickname == player.Nickname {
+			matched = append(matched, p)
+		}
+	}
+	if len(matched) != 1 || matched[0].PlayerUid != player.PlayerUid {
+		return errors.New(locale.T(locale.Broadcast, "chat.not_online"))
+	}
+	return nil
+}
+
+func pointsChatCommand(db *bbolt.DB, player database.TersePlayer, _ []string) (string, error) {
+	account, err := service.GetPoints(db, player.PlayerUid)
+	if err != nil && err != service.ErrNoRecord {
+		return "", err
+	}
+	return locale.T(locale.Broadcast, "chat.points", "points", account.Balance), nil
+}
@@ -0,0 +1,52 @@
+package task
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/internal/logger"
+	"github.com/zaigie/palworld-server-tool/service"
+	"go.etcd.io/bbolt"
+)
+
+var (
+	clockSkewMu sync.Mutex
+	// clockSkewed is whether the last save synced was ahead of pst's clock
+	clockSkewed bool
+)
+
+// checkClockSkew compares the time of the last save synced with pst's clock
+// and reports once when it's ahead by more than save.clock_skew_tolerance,
+// until a save is within it again.
+func checkClockSkew(db *bbolt.DB) {
+	batches, err := service.ListSyncBatches(db, 1)
+	if err != nil {
+		logger.Errorf("%v\n", err)
+		return
+	}
+	if len(batches) == 0 || batches[0].SaveTime.IsZero() {
+		return
+	}
+	skew := batches[0].SaveTime.Sub(batches[0].Start)
+	tolerance := time.Duration(viper.GetInt("save.clock_skew_tolerance")) * time.Second
+	clockSkewMu.Lock()
+	report := skew > tolerance && !clockSkewed
+	clockSkewed = skew > tolerance
+	clockSkewMu.Unlock()
+	if !report {
+		return
+	}
+	skew = skew.Round(time.Second)
+	logger.Warnf("Save is dated %s ahead of pst's clock, check the time of the game server\n", skew)
+	recordEvent(db, database.Event{
+		Type:    service.EventServerClockSkew,
+		Message: fmt.Sprintf("The save is dated %s ahead of pst's clock, last online times are taken from pst's until the game server's clock is fixed", skew),
+		Data: map[string]string{
+			"skew_seconds": fmt.Sprintf("%.0f", skew.Seconds()),
+			"save_time":    batches[0].SaveTime.Format(time.RFC3339),
+		},
+	})
+}
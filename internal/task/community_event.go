@@ -0,0 +1,182 @@
+package task
+
+import (
+	"context"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/internal/locale"
+	"github.com/zaigie/palworld-server-tool/internal/logger"
+	"github.com/zaigie/palworld-server-tool/internal/tool"
+	"github.com/zaigie/palworld-server-tool/service"
+	"go.etcd.io/bbolt"
+)
+
+// CommunityEventTask announces, starts and ends recurring community events,
+// applying their setting overrides only for the duration of the event.
+func CommunityEventTask(db *bbolt.DB) error {
+	events, err := service.ListCommunityEvents(db)
+	if err != nil {
+		logger.Errorf("%v\n", err)
+		return err
+	}
+	now := time.Now()
+	for _, event := range events {
+		start, end, ok := CommunityEventWindow(event, now)
+		inWindow := ok && !now.Before(start) && now.Before(end)
+
+		changed := true
+		switch {
+		case event.Active && (!event.Enabled || !inWindow):
+			changed = stopCommunityEvent(db, &event)
+		case !event.Active && event.Enabled && inWindow:
+			changed = startCommunityEvent(db, &event, end)
+		case !event.Active && event.Enabled && ok && !event.Announced &&
+			event.AnnounceBefore > 0 && !now.Before(start.Add(-time.Duration(event.AnnounceBefore)*time.Minute)):
+			minutes := int(start.Sub(now).Minutes()) + 1
+			broadcastLines(formatEventMessage(locale.Source(locale.Broadcast, "event.announce", "task.event_announce_message"), event, minutes))
+			event.Announced = true
+		default:
+			changed = false
+		}
+
+		if changed {
+			if err := service.PutCommunityEvent(db, event); err != nil {
+				logger.Errorf("%v\n", err)
+			}
+		}
+	}
+	return nil
+}
+
+// startCommunityEvent applies the overrides of the event and starts it,
+// it stays inactive to be tried again next run when they can't be applied.
+func startCommunityEvent(db *bbolt.DB, event *database.CommunityEvent, end time.Time) bool {
+	if len(event.Overrides) > 0 {
+		previous, err := tool.UpdateSettings(event.Overrides)
+		if err != nil {
+			logger.Errorf("Apply settings of event %s fail, %v\n", event.Name, err)
+			return false
+		}
+		// settings a failed revert left are the ones to go back to, not
+		// the overrides they were replaced with
+		if event.Previous == nil {
+			event.Previous = previous
+		} else {
+			for key, value := range previous {
+				if _, ok := event.Previous[key]; !ok {
+					event.Previous[key] = value
+				}
+			}
+		}
+	}
+	logger.Infof("Community event %s started\n", event.Name)
+	message := formatEventMessage(locale.Source(locale.Broadcast, "event.start", "task.event_start_message"), *event, int(time.Until(end).Minutes()))
+	broadcastLines(message)
+	event.Active = true
+	runWorldActions(db, *event, event.StartActions)
+	if len(event.Overrides) > 0 && event.Restart {
+		restartForSettings(db, message)
+	}
+	return true
+}
+
+// stopCommunityEvent puts back the settings the event replaced and ends
+// it, it stays active to be tried again next run when they can't be.
+func stopCommunityEvent(db *bbolt.DB, event *database.CommunityEvent) bool {
+	if len(event.Previous) > 0 {
+		if _, err := tool.UpdateSettings(event.Previous); err != nil {
+			logger.Errorf("Revert settings of event %s fail, %v\n", event.Name, err)
+			return false
+		}
+	}
+	logger.Infof("Community event %s ended\n", event.Name)
+	message := formatEventMessage(locale.Source(locale.Broadcast, "event.end", "task.event_end_message"), *event, 0)
+	broadcastLines(message)
+	event.Active = false
+	event.Announced = false
+	runWorldActions(db, *event, event.EndActions)
+	if len(event.Previous) > 0 {
+		event.Previous = nil
+		if event.Restart {
+			restartForSettings(db, message)
+		}
+	}
+	return true
+}
+
+// restartForSettings restarts the server with a server job so it loads the
+// changed settings. Without server.start_command pst can only shut it down,
+// a supervisor like systemd or docker's restart policy has to start it.
+func restartForSettings(db *bbolt.DB, message string) {
+	if viper.GetString("server.start_command") != "" {
+		if _, err := StartServerJob(db, ServerActionRestart, ServerJobOptions{Seconds: 60, Message: message}); err != nil {
+			logger.Errorf("Restart for settings fail, %v\n", err)
+		}
+		return
+	}
+	logger.Warnf("server.start_command is not set, shutting the server down for its settings, something else has to start it\n")
+	if err := tool.Shutdown(context.Background(), 60, message); err != nil {
+		logger.Errorf("Restart for settings fail, %v\n", err)
+	}
+}
+
+// CommunityEventWindow returns the occurrence of the event that contains now,
+// or the next upcoming one within a week.
+func CommunityEventWindow(event database.CommunityEvent, now time.Time) (time.Time, time.Time, bool) {
+	hour, minute, ok := parseClock(event.StartTime)
+	if !ok || event.Duration <= 0 {
+		return time.Time{}, time.Time{}, false
+	}
+	duration := time.Duration(event.Duration) * time.Minute
+	occurrence := func(offset int) (time.Time, bool) {
+		day := now.AddDate(0, 0, offset)
+		if len(event.Weekdays) > 0 {
+			matched := false
+			for _, weekday := range event.Weekdays {
+				if time.Weekday(weekday) == day.Weekday() {
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return time.Time{}, false
+			}
+		}
+		return time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, now.Location()), true
+	}
+	// events may last several days, look back far enough to find a running one
+	lookBack := int(duration.Hours()/24) + 1
+	for offset := -lookBack; offset <= 0; offset++ {
+		start, ok := occurrence(offset)
+		if ok && !now.Before(start) && now.Before(start.Add(duration)) {
+			return start, start.Add(duration), true
+		}
+	}
+	for offset := 0; offset <= 7; offset++ {
+		start, ok := occurrence(offset)
+		if ok && start.After(now) {
+			return start, start.Add(duration), true
+		}
+	}
+	return time.Time{}, time.Time{}, false
+}
+
+func parseClock(clock string) (int, int, bool) {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return 0, 0, false
+	}
+	return t.Hour(), t.Minute(), true
+}
+
+func formatEventMessage(message string, event database.CommunityEvent, minutes int) string {
+	return locale.Render(message,
+		"name", event.Name,
+		"description", event.Description,
+		"rewards", event.Rewards,
+		"minutes", minutes,
+		"event", event,
+	)
+}
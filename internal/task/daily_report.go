@@ -0,0 +1,38 @@
+package task
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/internal/logger"
+	"github.com/zaigie/palworld-server-tool/service"
+	"go.etcd.io/bbolt"
+)
+
+// DailyReportTask stores what changed yesterday and publishes a summary to the
+// notify channels.
+func DailyReportTask(db *bbolt.DB) error {
+	date := time.Now().AddDate(0, 0, -1).Format("2006-01-02")
+	report, err := service.BuildDailyReport(db, date)
+	if err != nil {
+		if err == service.ErrNoRecord {
+			logger.Warnf("No snapshot of %s for the daily report\n", date)
+			return nil
+		}
+		logger.Errorf("%v\n", err)
+		return err
+	}
+	if err := service.PutDailyReport(db, report); err != nil {
+		logger.Errorf("%v\n", err)
+		return err
+	}
+	recordEvent(db, database.Event{
+		Type: service.EventDailyReport,
+		Message: fmt.Sprintf("%s: %d new players, %d level gains, %d players caught pals, %d new guilds, %d disbanded, %d joins, %d leaves",
+			date, len(report.NewPlayers), len(report.LevelGains), len(report.PalGains),
+			len(report.NewGuilds), len(report.DisbandedGuilds), len(report.GuildJoins), len(report.GuildLeaves)),
+		Data: map[string]string{"date": date},
+	})
+	return nil
+}
@@ -0,0 +1,47 @@
+package task
+
+import (
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/zaigie/palworld-server-tool/internal/dbship"
+	"github.com/zaigie/palworld-server-tool/internal/logger"
+	"github.com/zaigie/palworld-server-tool/internal/paths"
+	"go.etcd.io/bbolt"
+)
+
+var (
+	shipperMu sync.Mutex
+	shipper   *dbship.Shipper
+)
+
+// DbShipTask ships the changes of the database since the last run to
+// db_shipping.target.
+func DbShipTask(db *bbolt.DB) error {
+	shipperMu.Lock()
+	defer shipperMu.Unlock()
+	if shipper == nil {
+		target, err := dbship.OpenTarget(viper.GetString("db_shipping.target"))
+		if err != nil {
+			logger.Errorf("Database shipping target: %v\n", err)
+			return err
+		}
+		shipper = &dbship.Shipper{
+			Target:           target,
+			Dir:              filepath.Join(paths.Cache(), "dbship"),
+			SnapshotInterval: time.Duration(viper.GetInt("db_shipping.snapshot_interval")) * time.Second,
+			Keep:             time.Duration(viper.GetInt("db_shipping.keep_days")) * 24 * time.Hour,
+		}
+	}
+	shipment, err := shipper.Ship(db)
+	if err != nil {
+		logger.Errorf("Database shipping failed: %v\n", err)
+		return err
+	}
+	if shipment.Name != "" {
+		logger.Debugf("Shipped database as %s, %d bytes\n", shipment.Name, shipment.Size)
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package task
+
+import (
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/internal/logger"
+	"github.com/zaigie/palworld-server-tool/internal/tool"
+	"github.com/zaigie/palworld-server-tool/service"
+	"go.etcd.io/bbolt"
+)
+
+const heatmapDateLayout = "2006-01-02"
+
+// HeatmapSample counts the locations of online players into the heatmap of
+// today and drops heatmaps older than map.heatmap_keep_days.
+func HeatmapSample(db *bbolt.DB, players []database.OnlinePlayer) {
+	calibration, err := tool.GetMapCalibration(db)
+	if err != nil {
+		logger.Errorf("%v\n", err)
+		return
+	}
+	positions := make([][2]float64, 0, len(players))
+	for _, player := range players {
+		if player.LocationX == 0 && player.LocationY == 0 {
+			continue
+		}
+		pos, err := tool.ConvertMapPosition(calibration, "world", player.LocationX, player.LocationY, 0)
+		if err != nil {
+			logger.Errorf("%v\n", err)
+			return
+		}
+		positions = append(positions, [2]float64{pos.Lat, pos.Lng})
+	}
+	now := time.Now()
+	if len(positions) > 0 {
+		err = service.AddHeatmapSamples(db, now.Format(heatmapDateLayout), viper.GetInt("map.heatmap_grid"), tool.MapSize, positions)
+		if err != nil {
+			logger.Errorf("%v\n", err)
+		}
+	}
+	if keepDays := viper.GetInt("map.heatmap_keep_days"); keepDays > 0 {
+		before := now.AddDate(0, 0, -keepDays).Format(heatmapDateLayout)
+		if err := service.CleanHeatmaps(db, before); err != nil {
+			logger.Errorf("%v\n", err)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package task
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/internal/logger"
+	"github.com/zaigie/palworld-server-tool/internal/tool"
+	"github.com/zaigie/palworld-server-tool/service"
+	"go.etcd.io/bbolt"
+)
+
+// EnforceIpBans kicks the online players whose ip falls in an ip ban.
+func EnforceIpBans(db *bbolt.DB, players []database.OnlinePlayer) {
+	bans, err := service.ListIpBans(db)
+	if err != nil {
+		logger.Errorf("%v\n", err)
+		return
+	}
+	if len(bans) == 0 {
+		return
+	}
+	for _, player := range players {
+		ban, ok := matchIpBan(player.Ip, bans)
+		if !ok || player.SteamId == "" {
+			continue
+		}
+		if err := tool.KickPlayer(context.Background(), fmt.Sprintf("steam_%s", player.SteamId)); err != nil {
+			logger.Warnf("Kick %s fail, %s \n", player.Nickname, err)
+			continue
+		}
+		logger.Warnf("Kicked %s, ip %s is banned by %s\n", player.Nickname, player.Ip, ban.Ip)
+		recordEvent(db, database.Event{
+			Type:      service.EventIpBanKick,
+			PlayerUid: player.PlayerUid,
+			Message:   fmt.Sprintf("%s was kicked, ip %s is banned", player.Nickname, player.Ip),
+			Data:      map[string]string{"ip": player.Ip, "ban": ban.Ip, "reason": ban.Reason},
+		})
+	}
+}
+
+func matchIpBan(ip string, bans []database.IpBan) (database.IpBan, bool) {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return database.IpBan{}, false
+	}
+	addr = addr.Unmap()
+	for _, ban := range bans {
+		prefix, err := tool.ParseIpPrefix(ban.Ip)
+		if err != nil {
+			continue
+		}
+		if prefix.Contains(addr) {
+			return ban, true
+		}
+	}
+	return database.IpBan{}, false
+}
@@ -0,0 +1,104 @@
+package task
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/spf13/viper"
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/internal/logger"
+	"github.com/zaigie/palworld-server-tool/internal/tool"
+	"github.com/zaigie/palworld-server-tool/service"
+	"go.etcd.io/bbolt"
+)
+
+const (
+	IpReputationFlag = "flag"
+	IpReputationKick = "kick"
+	IpReputationBan  = "ban"
+)
+
+var (
+	reputationMu sync.Mutex
+	// reputationSeen holds the player and ip pairs already handled, so a
+	// flagged player is reported once per session
+	reputationSeen = make(map[string]bool)
+)
+
+// CheckIpReputation flags online players connecting from VPN or datacenter
+// addresses and applies ip_reputation.action to them. Group admins and
+// ip_reputation.exempt players are left alone.
+func CheckIpReputation(db *bbolt.DB, players []database.OnlinePlayer) {
+	if !viper.GetBool("ip_reputation.enable") {
+		return
+	}
+	reputationMu.Lock()
+	online := make(map[string]bool, len(players))
+	var unchecked []database.OnlinePlayer
+	for _, player := range players {
+		if player.PlayerUid == "" || player.Ip == "" {
+			continue
+		}
+		key := player.PlayerUid + "|" + player.Ip
+		online[key] = true
+		if !reputationSeen[key] {
+			unchecked = append(unchecked, player)
+		}
+	}
+	reputationMu.Unlock()
+	defer func() {
+		reputationMu.Lock()
+		reputationSeen = online
+		reputationMu.Unlock()
+	}()
+	if len(unchecked) == 0 {
+		return
+	}
+
+	groups, err := service.ListPlayerGroups(db)
+	if err != nil {
+		logger.Errorf("%v\n", err)
+		return
+	}
+	exempt := viper.GetStringSlice("ip_reputation.exempt")
+	action := viper.GetString("ip_reputation.action")
+	for _, player := range unchecked {
+		reputation, err := tool.CheckIpReputation(player.Ip)
+		if err != nil {
+			// checked again at the next poll
+			logger.Warnf("Check ip of %s fail, %s \n", player.Nickname, err)
+			delete(online, player.PlayerUid+"|"+player.Ip)
+			continue
+		}
+		if !reputation.Flagged || isGroupAdmin(player, groups) || isExempt(player, exempt) {
+			continue
+		}
+		handleFlaggedIp(db, player, reputation, action)
+	}
+}
+
+func handleFlaggedIp(db *bbolt.DB, player database.OnlinePlayer, reputation tool.IpReputation, action string) {
+	var err error
+	switch {
+	case player.SteamId == "":
+		action = IpReputationFlag
+	case action == IpReputationKick:
+		err = tool.KickPlayer(context.Background(), fmt.Sprintf("steam_%s", player.SteamId))
+	case action == IpReputationBan:
+		err = tool.BanPlayer(context.Background(), fmt.Sprintf("steam_%s", player.SteamId))
+	default:
+		action = IpReputationFlag
+	}
+	if err != nil {
+		logger.Warnf("%s %s fail, %s \n", action, player.Nickname, err)
+		action = IpReputationFlag
+	}
+	logger.Warnf("%s connects from flagged ip %s (%s), %s\n", player.Nickname, player.Ip, reputation.Source, action)
+	recordEvent(db, database.Event{
+		Type:      service.EventVpnDetected,
+		PlayerUid: player.PlayerUid,
+		Message:   fmt.Sprintf("%s connects from a VPN or datacenter ip %s", player.Nickname, player.Ip),
+		Data:      map[string]string{"ip": player.Ip, "source": reputation.Source, "action": action},
+	})
+}
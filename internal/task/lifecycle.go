@@ -0,0 +1,331 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/internal/locale"
+	"github.com/zaigie/palworld-server-tool/internal/logger"
+	"github.com/zaigie/palworld-server-tool/internal/tool"
+	"github.com/zaigie/palworld-server-tool/service"
+	"go.etcd.io/bbolt"
+)
+
+const (
+	ServerActionStop    = "stop"
+	ServerActionStart   = "start"
+	ServerActionRestart = "restart"
+	ServerActionUpdate  = "update"
+
+	ServerStateRunning  = "running"
+	ServerStateStopping = "stopping"
+	ServerStateStopped  = "stopped"
+	ServerStateUpdating = "updating"
+	ServerStateStarting = "starting"
+
+	JobStatusPending = "pending"
+	JobStatusRunning = "running"
+	JobStatusSuccess = "success"
+	JobStatusFailed  = "failed"
+)
+
+var ErrServerBusy = errors.New("another server job is running")
+
+type ServerJobOptions struct {
+	// Seconds of countdown before stopping
+	Seconds int
+	// Message is broadcast during the countdown, {action} and {seconds} are
+	// replaced
+	Message string
+	// Update runs server.update_command before starting
+	Update bool
+}
+
+type ServerState struct {
+	State string              `json:"state"`
+	Job   *database.ServerJob `json:"job"`
+}
+
+type jobStep struct {
+	name  string
+	state string
+	run   func() (string, error)
+}
+
+var (
+	lifecycleMu  sync.Mutex
+	currentJob   *database.ServerJob
+	currentState string
+)
+
+func serverRunning() bool {
+	_, err := tool.Info(context.Background())
+	return err == nil
+}
+
+// GetServerState reports the phase of the running job, or probes the REST API
+// when there is none.
+func GetServerState() ServerState {
+	lifecycleMu.Lock()
+	if currentJob != nil {
+		job := copyJob(currentJob)
+		state := currentState
+		lifecycleMu.Unlock()
+		return ServerState{State: state, Job: &job}
+	}
+	lifecycleMu.Unlock()
+	if serverRunning() {
+		return ServerState{State: ServerStateRunning}
+	}
+	return ServerState{State: ServerStateStopped}
+}
+
+// StartServerJob checks the action can run and starts it in the background,
+// only one job runs at a time.
+func StartServerJob(db *bbolt.DB, action string, opts ServerJobOptions) (database.ServerJob, error) {
+	steps, err := serverJobSteps(action, opts)
+	if err != nil {
+		return database.ServerJob{}, err
+	}
+
+	lifecycleMu.Lock()
+	defer lifecycleMu.Unlock()
+	if currentJob != nil {
+		return database.ServerJob{}, ErrServerBusy
+	}
+	running := serverRunning()
+	switch {
+	case action == ServerActionStop && !running:
+		return database.ServerJob{}, errors.New("server is not running")
+	case action == ServerActionStart && running:
+		return database.ServerJob{}, errors.New("server is already running")
+	case action == ServerActionUpdate && running:
+		return database.ServerJob{}, errors.New("server is running, stop it before updating")
+	}
+
+	job := &database.ServerJob{
+		Id:        uuid.New().String(),
+		Action:    action,
+		Status:    JobStatusPending,
+		CreatedAt: time.Now(),
+	}
+	for _, step := range steps {
+		job.Steps = append(job.Steps, &database.ServerJobStep{Name: step.name, Status: JobStatusPending})
+	}
+	if err := service.PutServerJob(db, *job); err != nil {
+		return database.ServerJob{}, err
+	}
+	currentJob = job
+	go runServerJob(db, job, steps)
+	return copyJob(job), nil
+}
+
+func serverJobSteps(action string, opts ServerJobOptions) ([]jobStep, error) {
+	startCommand := viper.GetString("server.start_command")
+	stopCommand := viper.GetString("server.stop_command")
+	updateCommand := viper.GetString("server.update_command")
+	commandTimeout := time.Duration(viper.GetInt("server.command_timeout")) * time.Second
+
+	stopSteps := []jobStep{
+		{"countdown", ServerStateStopping, func() (string, error) {
+			return countdown(action, opts.Seconds, opts.Message), nil
+		}},
+		{"save", ServerStateStopping, func() (string, error) { return "", tool.Save(context.Background()) }},
+		{"shutdown", ServerStateStopping, func() (string, error) {
+			return "", tool.Shutdown(context.Background(), 1, locale.Render(countdownMessage(opts.Message), "action", action, "seconds", 0))
+		}},
+		{"wait_stopped", ServerStateStopping, func() (string, error) {
+			if waitServer(false, 2*time.Minute) {
+				return "", nil
+			}
+			if stopCommand != "" {
+				return "server still responds, leaving it to stop_command", nil
+			}
+			return "", errors.New("server still responds after shutdown")
+		}},
+	}
+	if stopCommand != "" {
+		stopSteps = append(stopSteps, jobStep{"stop_command", ServerStateStopping, func() (string, error) {
+			return tool.RunCommand(stopCommand, commandTimeout)
+		}})
+	}
+	updateSteps := []jobStep{
+		{"update", ServerStateUpdating, func() (string, error) {
+			return tool.RunCommand(updateCommand, commandTimeout)
+		}},
+	}
+	startSteps := []jobStep{
+		{"start_command", ServerStateStarting, func() (string, error) {
+			return tool.RunCommand(startCommand, commandTimeout)
+		}},
+		{"wait_running", ServerStateStarting, func() (string, error) {
+			if waitServer(true, time.Duration(viper.GetInt("server.start_timeout"))*time.Second) {
+				return "", nil
+			}
+			return "", errors.New("server did not come up in time")
+		}},
+	}
+
+	if (action == ServerActionUpdate || opts.Update) && updateCommand == "" {
+		return nil, errors.New("server.update_command is not configured")
+	}
+	if (action == ServerActionStart || action == ServerActionRestart) && startCommand == "" {
+		return nil, errors.New("server.start_command is not configured")
+	}
+	var steps []jobStep
+	switch action {
+	case ServerActionStop:
+		steps = stopSteps
+	case ServerActionStart:
+		if opts.Update {
+			steps = append(steps, updateSteps...)
+		}
+		steps = append(steps, startSteps...)
+	case ServerActionRestart:
+		steps = append(steps, stopSteps...)
+		if opts.Update {
+			steps = append(steps, updateSteps...)
+		}
+		steps = append(steps, startSteps...)
+	case ServerActionUpdate:
+		steps = updateSteps
+	default:
+		return nil, fmt.Errorf("unknown action %s", action)
+	}
+	return steps, nil
+}
+
+func runServerJob(db *bbolt.DB, job *database.ServerJob, steps []jobStep) {
+	update := func(fn func()) {
+		lifecycleMu.Lock()
+		fn()
+		snapshot := copyJob(job)
+		lifecycleMu.Unlock()
+		if err := service.PutServerJob(db, snapshot); err != nil {
+			logger.Errorf("%v\n", err)
+		}
+	}
+
+	logger.Infof("Server job %s %s started\n", job.Id, job.Action)
+	update(func() { job.Status = JobStatusRunning })
+	var jobErr error
+	for i, step := range steps {
+		record := job.Steps[i]
+		update(func() {
+			currentState = step.state
+			record.Status = JobStatusRunning
+			record.StartedAt = time.Now()
+		})
+		output, err := step.run()
+		update(func() {
+			record.Output = output
+			record.FinishedAt = time.Now()
+			record.Status = JobStatusSuccess
+			if err != nil {
+				record.Status = JobStatusFailed
+				record.Error = err.Error()
+			}
+		})
+		if err != nil {
+			jobErr = fmt.Errorf("%s: %w", step.name, err)
+			break
+		}
+	}
+
+	update(func() {
+		job.FinishedAt = time.Now()
+		job.Status = JobStatusSuccess
+		if jobErr != nil {
+			job.Status = JobStatusFailed
+			job.Error = jobErr.Error()
+		}
+	})
+	lifecycleMu.Lock()
+	currentJob = nil
+	currentState = ""
+	lifecycleMu.Unlock()
+
+	event := database.Event{
+		Type:    service.EventServerJobSuccess,
+		Message: fmt.Sprintf("Server %s succeeded", job.Action),
+		Data:    map[string]string{"job_id": job.Id, "action": job.Action},
+	}
+	if jobErr != nil {
+		event.Type = service.EventServerJobFail
+		event.Message = fmt.Sprintf("Server %s failed, %v", job.Action, jobErr)
+		logger.Errorf("Server job %s %s failed, %v\n", job.Id, job.Action, jobErr)
+	} else {
+		logger.Infof("Server job %s %s done\n", job.Id, job.Action)
+	}
+	recordEvent(db, event)
+}
+
+// countdown broadcasts the message at the start and at the usual marks, then
+// waits out the rest. Broadcast failures don't stop the job, the server may
+// already be unreachable.
+func countdown(action string, seconds int, message string) string {
+	message = countdownMessage(message)
+	var sent []string
+	broadcast := func(remaining int) {
+		msg := locale.Render(message, "action", action, "seconds", remaining)
+		if err := tool.Broadcast(context.Background(), msg); err != nil {
+			logger.Warnf("Broadcast fail, %s \n", err)
+			return
+		}
+		sent = append(sent, msg)
+	}
+
+	remaining := seconds
+	if remaining > 0 {
+		broadcast(remaining)
+	}
+	for _, mark := range []int{600, 300, 120, 60, 30, 10, 5} {
+		if mark >= remaining {
+			continue
+		}
+		time.Sleep(time.Duration(remaining-mark) * time.Second)
+		remaining = mark
+		broadcast(remaining)
+	}
+	time.Sleep(time.Duration(remaining) * time.Second)
+	return strings.Join(sent, "\n")
+}
+
+func countdownMessage(message string) string {
+	if message == "" {
+		return locale.Source(locale.Broadcast, "server.countdown", "server.countdown_message")
+	}
+	return message
+}
+
+// waitServer polls the REST API until the server is up, or down, and reports
+// whether that happened within timeout.
+func waitServer(up bool, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for {
+		if serverRunning() == up {
+			return true
+		}
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(2 * time.Second)
+	}
+}
+
+func copyJob(job *database.ServerJob) database.ServerJob {
+	c := *job
+	c.Steps = make([]*database.ServerJobStep, len(job.Steps))
+	for i, step := range job.Steps {
+		s := *step
+		c.Steps[i] = &s
+	}
+	return c
+}
@@ -0,0 +1,89 @@
+package task
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/internal/logger"
+	"github.com/zaigie/palworld-server-tool/internal/tool"
+	"github.com/zaigie/palworld-server-tool/service"
+	"go.etcd.io/bbolt"
+)
+
+var (
+	lowFpsMu sync.Mutex
+	// lowFpsStreak counts the samples in a row below metrics.low_fps
+	lowFpsStreak int
+)
+
+// MetricsTask samples the server FPS and frame time with the player and
+// base camp counts, and reports FPS staying below metrics.low_fps for
+// metrics.low_samples samples.
+func MetricsTask(db *bbolt.DB) error {
+	metrics, err := tool.Metrics(context.Background())
+	if err != nil {
+		return err
+	}
+	baseCamps, err := service.CountBaseCamps(db)
+	if err != nil {
+		logger.Errorf("%v\n", err)
+	}
+	sample := database.MetricSample{
+		Time:            time.Now(),
+		ServerFps:       float64(metrics["server_fps"].(int)),
+		ServerFrameTime: metrics["server_frame_time"].(float64),
+		Players:         float64(metrics["current_player_num"].(int)),
+		BaseCamps:       float64(baseCamps),
+	}
+	keep := time.Duration(viper.GetInt("metrics.keep_days")) * 24 * time.Hour
+	if err := service.AddMetricSample(db, sample, keep); err != nil {
+		return err
+	}
+	publishEvents(database.Event{
+		Type:    service.EventMetricsSample,
+		Time:    sample.Time,
+		Message: fmt.Sprintf("%.0f fps, %.0f players", sample.ServerFps, sample.Players),
+		Data: map[string]string{
+			"server_fps":        fmt.Sprint(sample.ServerFps),
+			"server_frame_time": fmt.Sprint(sample.ServerFrameTime),
+			"players":           fmt.Sprint(sample.Players),
+			"base_camps":        fmt.Sprint(sample.BaseCamps),
+		},
+	})
+	checkLowFps(db, sample)
+	return nil
+}
+
+func checkLowFps(db *bbolt.DB, sample database.MetricSample) {
+	lowFps := viper.GetFloat64("metrics.low_fps")
+	if lowFps <= 0 {
+		return
+	}
+	lowSamples := viper.GetInt("metrics.low_samples")
+	lowFpsMu.Lock()
+	if sample.ServerFps >= lowFps {
+		lowFpsStreak = 0
+		lowFpsMu.Unlock()
+		return
+	}
+	lowFpsStreak++
+	// report once, when the streak gets long enough
+	report := lowFpsStreak == lowSamples || (lowSamples <= 0 && lowFpsStreak == 1)
+	lowFpsMu.Unlock()
+	if !report {
+		return
+	}
+	recordEvent(db, database.Event{
+		Type:    service.EventServerLowFps,
+		Message: fmt.Sprintf("Server FPS has stayed below %.0f, now %.0f with %.0f players and %.0f base camps", lowFps, sample.ServerFps, sample.Players, sample.BaseCamps),
+		Data: map[string]string{
+			"server_fps": fmt.Sprintf("%.0f", sample.ServerFps),
+			"players":    fmt.Sprintf("%.0f", sample.Players),
+			"base_camps": fmt.Sprintf("%.0f", sample.BaseCamps),
+		},
+	})
+}
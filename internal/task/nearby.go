@@ -0,0 +1,155 @@
+package task
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/service"
+	"go.etcd.io/bbolt"
+)
+
+// nearbyCellSize is the side of a cell of the location grid in world units,
+// 200 m, so a search around a base only looks at a few cells.
+const nearbyCellSize = 20000
+
+type NearbyPlayer struct {
+	PlayerUid string    `json:"player_uid"`
+	SteamId   string    `json:"steam_id"`
+	Nickname  string    `json:"nickname"`
+	LocationX float64   `json:"location_x"`
+	LocationY float64   `json:"location_y"`
+	Online    bool      `json:"online"`
+	SeenAt    time.Time `json:"seen_at"`
+	// Distance is in world units, centimeters
+	Distance float64 `json:"distance"`
+}
+
+type gridCell struct{ x, y int64 }
+
+var (
+	nearbyMu sync.Mutex
+	// nearbyLoaded is set once the last known locations were read from the
+	// database, until then only polled players would be found
+	nearbyLoaded bool
+	// nearbyPlayers are the last known locations by player uid, nearbyGrid
+	// the same players by the cell they are in
+	nearbyPlayers = make(map[string]*NearbyPlayer)
+	nearbyGrid    = make(map[gridCell]map[string]*NearbyPlayer)
+)
+
+func cellOf(x, y float64) gridCell {
+	return gridCell{int64(math.Floor(x / nearbyCellSize)), int64(math.Floor(y / nearbyCellSize))}
+}
+
+// indexLocation moves the player to its cell, callers hold nearbyMu.
+func indexLocation(p NearbyPlayer) {
+	if old, ok := nearbyPlayers[p.PlayerUid]; ok {
+		cell := cellOf(old.LocationX, old.LocationY)
+		delete(nearbyGrid[cell], p.PlayerUid)
+		if len(nearbyGrid[cell]) == 0 {
+			delete(nearbyGrid, cell)
+		}
+	}
+	cell := cellOf(p.LocationX, p.LocationY)
+	if nearbyGrid[cell] == nil {
+		nearbyGrid[cell] = make(map[string]*NearbyPlayer)
+	}
+	nearbyPlayers[p.PlayerUid] = &p
+	nearbyGrid[cell][p.PlayerUid] = &p
+}
+
+// TrackLocations indexes where the polled players are, players who left
+// keep their last location.
+func TrackLocations(players []database.OnlinePlayer) {
+	now := time.Now()
+	nearbyMu.Lock()
+	defer nearbyMu.Unlock()
+	online := make(map[string]bool, len(players))
+	for _, player := range players {
+		// 0,0 is what the REST API reports while loading in
+		if player.PlayerUid == "" || (player.LocationX == 0 && player.LocationY == 0) {
+			continue
+		}
+		online[player.PlayerUid] = true
+		indexLocation(NearbyPlayer{
+			PlayerUid: player.PlayerUid,
+			SteamId:   player.SteamId,
+			Nickname:  player.Nickname,
+			LocationX: player.LocationX,
+			LocationY: player.LocationY,
+			Online:    true,
+			SeenAt:    now,
+		})
+	}
+	for uid, p := range nearbyPlayers {
+		if !online[uid] {
+			p.Online = false
+		}
+	}
+}
+
+// loadLocations indexes the locations stored with the players once,
+// callers hold nearbyMu.
+func loadLocations(db *bbolt.DB) error {
+	if nearbyLoaded {
+		return nil
+	}
+	players, err := service.ListPlayers(db)
+	if err != nil {
+		return err
+	}
+	for _, player := range players {
+		if _, ok := nearbyPlayers[player.PlayerUid]; ok || (player.LocationX == 0 && player.LocationY == 0) {
+			continue
+		}
+		indexLocation(NearbyPlayer{
+			PlayerUid: player.PlayerUid,
+			SteamId:   player.SteamId,
+			Nickname:  player.Nickname,
+			LocationX: player.LocationX,
+			LocationY: player.LocationY,
+			SeenAt:    player.LastOnline,
+		})
+	}
+	nearbyLoaded = true
+	return nil
+}
+
+// NearbyPlayers returns the players last seen within radius of x, y in world
+// coordinates, nearest first.
+func NearbyPlayers(db *bbolt.DB, x, y, radius float64) ([]NearbyPlayer, error) {
+	nearbyMu.Lock()
+	defer nearbyMu.Unlock()
+	if err := loadLocations(db); err != nil {
+		return nil, err
+	}
+	near := make([]NearbyPlayer, 0)
+	add := func(p *NearbyPlayer) {
+		if d := math.Hypot(p.LocationX-x, p.LocationY-y); d <= radius {
+			found := *p
+			found.Distance = d
+			near = append(near, found)
+		}
+	}
+	lo, hi := cellOf(x-radius, y-radius), cellOf(x+radius, y+radius)
+	// a radius covering more cells than there are players is faster to
+	// answer by looking at each player
+	if cells := float64(hi.x-lo.x+1) * float64(hi.y-lo.y+1); cells > float64(len(nearbyPlayers)) {
+		for _, p := range nearbyPlayers {
+			add(p)
+		}
+	} else {
+		for cx := lo.x; cx <= hi.x; cx++ {
+			for cy := lo.y; cy <= hi.y; cy++ {
+				for _, p := range nearbyGrid[gridCell{cx, cy}] {
+					add(p)
+				}
+			}
+		}
+	}
+	sort.Slice(near, func(i, j int) bool { return near[i].Distance < near[j].Distance })
+	return near, nil
+}
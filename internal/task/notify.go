@@ -0,0 +1,73 @@
+package task
+
+import (
+	"time"
+
+	"github.com/zaigie/palworld-server-tool/internal/bus"
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/internal/logger"
+	"github.com/zaigie/palworld-server-tool/internal/notify"
+	"github.com/zaigie/palworld-server-tool/service"
+	"go.etcd.io/bbolt"
+)
+
+// recordEvent stores the event and publishes it on the bus.
+func recordEvent(db *bbolt.DB, event database.Event) {
+	events, err := service.AddEvents(db, []database.Event{event})
+	if err != nil {
+		logger.Errorf("%v\n", err)
+		return
+	}
+	bus.Publish(events...)
+}
+
+// RecordEvent stores the event and publishes it on the bus, for the events
+// of the api and startup.
+func RecordEvent(db *bbolt.DB, event database.Event) {
+	recordEvent(db, event)
+}
+
+// publishEvents publishes events on the bus without storing them, for the
+// frequent ones not worth keeping.
+func publishEvents(events ...database.Event) {
+	now := time.Now()
+	for i := range events {
+		if events[i].Time.IsZero() {
+			events[i].Time = now
+		}
+	}
+	bus.Publish(events...)
+}
+
+// EmailDigestTask mails the events since the last digest, or of the last day
+// on the first run. The time of the last digest is stored, so a restart
+// neither repeats nor skips events.
+func EmailDigestTask(db *bbolt.DB) error {
+	now := time.Now()
+	since, err := service.GetTaskTime(db, TaskEmailDigest)
+	if err != nil {
+		logger.Errorf("%v\n", err)
+		return err
+	}
+	if since.IsZero() {
+		since = now.Add(-24 * time.Hour)
+	}
+	events, err := service.ListEvents(db, service.EventFilter{StartTime: since, EndTime: now})
+	if err != nil {
+		logger.Errorf("%v\n", err)
+		return err
+	}
+	// oldest first reads better in a digest
+	for i, j := 0, len(events)-1; i < j; i, j = i+1, j-1 {
+		events[i], events[j] = events[j], events[i]
+	}
+	if err := notify.SendEmailDigest(events); err != nil {
+		logger.Errorf("Email digest fail, %v\n", err)
+		return err
+	}
+	if err := service.PutTaskTime(db, TaskEmailDigest, now); err != nil {
+		logger.Errorf("%v\n", err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,22 @@
+package task
+
+import (
+	"github.com/zaigie/palworld-server-tool/internal/logger"
+	"github.com/zaigie/palworld-server-tool/service"
+	"go.etcd.io/bbolt"
+)
+
+// OrphanPruneTask removes the sessions, points, events and other records of
+// players deleted from the save.
+func OrphanPruneTask(db *bbolt.DB) error {
+	report, err := service.PruneOrphans(db, false)
+	if err != nil {
+		logger.Errorf("Orphan prune failed: %v\n", err)
+		return err
+	}
+	if total := report.Total(); total > 0 {
+		logger.Infof("Pruned %d orphan records %v, reclaimed %d bytes, %d of %d bytes of the database now free\n",
+			total, report.Records, report.Bytes, report.FreeBytes, report.FileSize)
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package task
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/internal/logger"
+	"github.com/zaigie/palworld-server-tool/service"
+	"go.etcd.io/bbolt"
+)
+
+// RecordPings keeps the ping history of the online players and reports
+// those whose ping stays above ping.high_ms for ping.high_samples polls.
+func RecordPings(db *bbolt.DB, players []database.OnlinePlayer) {
+	highMs := viper.GetFloat64("ping.high_ms")
+	highSamples := viper.GetInt("ping.high_samples")
+	high, err := service.RecordPings(db, players, time.Now(), viper.GetInt("ping.history"), highMs, highSamples)
+	if err != nil {
+		logger.Errorf("%v\n", err)
+		return
+	}
+	nicknames := make(map[string]string, len(players))
+	for _, p := range players {
+		nicknames[p.PlayerUid] = p.Nickname
+	}
+	for _, h := range high {
+		ping := h.Pings[(h.Next+len(h.Pings)-1)%len(h.Pings)]
+		recordEvent(db, database.Event{
+			Type:      service.EventHighPing,
+			PlayerUid: h.PlayerUid,
+			Message:   fmt.Sprintf("%s has had a ping above %.0fms for %d polls, now %.0fms", nicknames[h.PlayerUid], highMs, highSamples, ping),
+			Data:      map[string]string{"nickname": nicknames[h.PlayerUid], "ping": fmt.Sprintf("%.0f", ping)},
+		})
+	}
+}
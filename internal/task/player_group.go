@@ -0,0 +1,80 @@
+package task
+
+import (
+	"sync"
+	"time"
+
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/internal/logger"
+	"github.com/zaigie/palworld-server-tool/service"
+	"go.etcd.io/bbolt"
+)
+
+var (
+	joinMu sync.Mutex
+	// joinedAt is when online players were first seen, zero for those online
+	// at the first poll
+	joinedAt       map[string]time.Time
+	groupFirstPoll = true
+)
+
+// ApplyPlayerGroups announces the members of player groups who joined since
+// the last poll with the join message of their groups.
+func ApplyPlayerGroups(db *bbolt.DB, players []database.OnlinePlayer) {
+	now := time.Now()
+	joinMu.Lock()
+	online := make(map[string]time.Time, len(players))
+	var joined []database.OnlinePlayer
+	for _, player := range players {
+		if player.PlayerUid == "" {
+			continue
+		}
+		t, ok := joinedAt[player.PlayerUid]
+		if !ok && !groupFirstPoll {
+			t = now
+			joined = append(joined, player)
+		}
+		online[player.PlayerUid] = t
+	}
+	groupFirstPoll = false
+	joinedAt = online
+	joinMu.Unlock()
+
+	for _, player := range joined {
+		groups, err := service.ListGroupsOf(db, player.PlayerUid, player.SteamId)
+		if err != nil {
+			logger.Errorf("%v\n", err)
+			return
+		}
+		for _, group := range groups {
+			logger.Infof("%s of group %s joined\n", player.Nickname, group.Name)
+			if group.JoinMessage != "" {
+				BroadcastVariableMessage(group.JoinMessage, player.Nickname, len(players))
+			}
+		}
+	}
+}
+
+// isGroupAdmin reports whether the player is a member of an admin group.
+func isGroupAdmin(player database.OnlinePlayer, groups []database.PlayerGroup) bool {
+	return inGroup(player, groups, func(group database.PlayerGroup) bool { return group.Admin })
+}
+
+// isVip reports whether the player may take a reserved slot, admins included.
+func isVip(player database.OnlinePlayer, groups []database.PlayerGroup) bool {
+	return inGroup(player, groups, func(group database.PlayerGroup) bool { return group.Admin || group.ReservedSlot })
+}
+
+func inGroup(player database.OnlinePlayer, groups []database.PlayerGroup, match func(group database.PlayerGroup) bool) bool {
+	for _, group := range groups {
+		if !match(group) {
+			continue
+		}
+		for _, member := range group.Members {
+			if service.IsGroupMember(member, player.PlayerUid, player.SteamId) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,18 @@
+package task
+
+import (
+	"time"
+
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/internal/logger"
+	"github.com/zaigie/palworld-server-tool/service"
+	"go.etcd.io/bbolt"
+)
+
+// RecordPlayerIps adds the online players to the sessions of their ip
+// addresses for the alt account report.
+func RecordPlayerIps(db *bbolt.DB, players []database.OnlinePlayer) {
+	if err := service.RecordPlayerIps(db, players, time.Now(), maxPollGap()); err != nil {
+		logger.Errorf("%v\n", err)
+	}
+}
@@ -0,0 +1,22 @@
+package task
+
+import (
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/internal/logger"
+	"github.com/zaigie/palworld-server-tool/service"
+	"go.etcd.io/bbolt"
+)
+
+// EarnPoints pays points.per_minute to the online players.
+func EarnPoints(db *bbolt.DB, players []database.OnlinePlayer) {
+	perMinute := viper.GetInt64("points.per_minute")
+	if perMinute <= 0 {
+		return
+	}
+	if err := service.EarnPoints(db, players, time.Now(), maxPollGap(), perMinute); err != nil {
+		logger.Errorf("%v\n", err)
+	}
+}
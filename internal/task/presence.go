@@ -0,0 +1,72 @@
+package task
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/service"
+)
+
+var (
+	presenceMu  sync.Mutex
+	playerCache map[string]string
+	firstPoll   = true
+)
+
+// PublishPresence publishes a join or leave event for each player that came
+// or went since the last poll. The first poll only fills the cache, players
+// already online when the tool starts didn't just join.
+func PublishPresence(players []database.OnlinePlayer) {
+	presenceMu.Lock()
+	defer presenceMu.Unlock()
+
+	tmp := make(map[string]string, len(players))
+	for _, player := range players {
+		if player.PlayerUid != "" {
+			tmp[player.PlayerUid] = player.Nickname
+		}
+	}
+	if !firstPoll {
+		onlineNum := strconv.Itoa(len(players))
+		var events []database.Event
+		for id, name := range tmp {
+			if _, ok := playerCache[id]; !ok {
+				events = append(events, presenceEvent(service.EventPlayerJoin, id, name, onlineNum, "%s joined the server"))
+			}
+		}
+		for id, name := range playerCache {
+			if _, ok := tmp[id]; !ok {
+				events = append(events, presenceEvent(service.EventPlayerLeave, id, name, onlineNum, "%s left the server"))
+			}
+		}
+		publishEvents(events...)
+	}
+	firstPoll = false
+	playerCache = tmp
+}
+
+// onlineCount returns how many players were online at the last poll.
+func onlineCount() int {
+	presenceMu.Lock()
+	defer presenceMu.Unlock()
+	return len(playerCache)
+}
+
+// isOnline reports whether the player was online at the last poll.
+func isOnline(playerUid string) bool {
+	presenceMu.Lock()
+	defer presenceMu.Unlock()
+	_, ok := playerCache[playerUid]
+	return ok
+}
+
+func presenceEvent(eventType, playerUid, nickname, onlineNum, format string) database.Event {
+	return database.Event{
+		Type:      eventType,
+		PlayerUid: playerUid,
+		Message:   fmt.Sprintf(format, nickname),
+		Data:      map[string]string{"nickname": nickname, "online_num": onlineNum},
+	}
+}
@@ -0,0 +1,98 @@
+package task
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/internal/locale"
+	"github.com/zaigie/palworld-server-tool/internal/logger"
+	"github.com/zaigie/palworld-server-tool/service"
+	"go.etcd.io/bbolt"
+)
+
+type Rank struct {
+	Name  string  `json:"name" mapstructure:"name"`
+	Hours float64 `json:"hours" mapstructure:"hours"`
+	Tag   string  `json:"tag" mapstructure:"tag"`
+}
+
+// Ranks returns rank.ranks ordered by hours.
+func Ranks() ([]Rank, error) {
+	var ranks []Rank
+	if err := viper.UnmarshalKey("rank.ranks", &ranks); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(ranks, func(i, j int) bool { return ranks[i].Hours < ranks[j].Hours })
+	return ranks, nil
+}
+
+// PlaytimeSync adds the time since the last poll to the online players and
+// promotes those who passed a rank threshold.
+func PlaytimeSync(db *bbolt.DB, players []database.OnlinePlayer) {
+	playtimes, err := service.AddPlaytime(db, players, time.Now(), maxPollGap())
+	if err != nil {
+		logger.Errorf("%v\n", err)
+		return
+	}
+	ranks, err := Ranks()
+	if err != nil {
+		logger.Errorf("%v\n", err)
+		return
+	}
+	if len(ranks) == 0 {
+		return
+	}
+	for _, playtime := range playtimes {
+		if err := applyRanks(db, playtime, ranks); err != nil {
+			logger.Errorf("%v\n", err)
+		}
+	}
+}
+
+// applyRanks appends the tags of the ranks the player reached and announces
+// the highest new one.
+func applyRanks(db *bbolt.DB, playtime database.Playtime, ranks []Rank) error {
+	hours := float64(playtime.Seconds) / 3600
+	var promoted *Rank
+	for i, rank := range ranks {
+		if hours < rank.Hours || rank.Tag == "" || hasTag(playtime.Tags, rank.Tag) {
+			continue
+		}
+		playtime.Tags = append(playtime.Tags, rank.Tag)
+		promoted = &ranks[i]
+	}
+	if promoted == nil {
+		return nil
+	}
+	if err := service.PutPlaytime(db, playtime); err != nil {
+		return err
+	}
+	logger.Infof("%s reached rank %s\n", playtime.Nickname, promoted.Name)
+
+	if viper.GetBool("rank.announce") {
+		broadcastLines(locale.Text(locale.Broadcast, "rank.up", "rank.message",
+			"username", playtime.Nickname,
+			"rank", promoted.Name,
+			"hours", fmt.Sprintf("%.0f", promoted.Hours),
+		))
+	}
+	recordEvent(db, database.Event{
+		Type:      service.EventPlayerRankUp,
+		PlayerUid: playtime.PlayerUid,
+		Message:   fmt.Sprintf("%s reached rank %s after %.0f hours", playtime.Nickname, promoted.Name, promoted.Hours),
+		Data:      map[string]string{"rank": promoted.Name, "tag": promoted.Tag},
+	})
+	return nil
+}
+
+func hasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,204 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-co-op/gocron/v2"
+	"github.com/spf13/viper"
+	"github.com/zaigie/palworld-server-tool/internal/crash"
+	"github.com/zaigie/palworld-server-tool/internal/tracing"
+)
+
+const (
+	TaskPlayerSync     = "player_sync"
+	TaskSavSync        = "sav_sync"
+	TaskBackup         = "backup"
+	TaskBackupGc       = "backup_gc"
+	TaskCommunityEvent = "community_event"
+	TaskCleanCache     = "clean_cache"
+	TaskEmailDigest    = "email_digest"
+	TaskUpdateCheck    = "update_check"
+	TaskDailyReport    = "daily_report"
+	TaskMetrics        = "metrics"
+	TaskStatsExport    = "stats_export"
+	TaskOrphanPrune    = "orphan_prune"
+	TaskDbShip         = "db_ship"
+	TaskStorageSample  = "storage_sample"
+)
+
+var ErrTaskNotFound = errors.New("task not found")
+
+type TaskInfo struct {
+	Name     string    `json:"name"`
+	Schedule string    `json:"schedule"`
+	Paused   bool      `json:"paused"`
+	Running  bool      `json:"running"`
+	NextRun  time.Time `json:"next_run"`
+	LastRun  time.Time `json:"last_run"`
+	// LastDuration is in seconds
+	LastDuration float64 `json:"last_duration"`
+	LastError    string  `json:"last_error"`
+}
+
+type registeredTask struct {
+	mu   sync.Mutex
+	info TaskInfo
+	fn   func() error
+	job  gocron.Job
+}
+
+var (
+	tasksMu sync.RWMutex
+	tasks   = make(map[string]*registeredTask)
+	// keeps tasks listed in registration order
+	taskNames []string
+)
+
+// registerTask schedules fn by the cron expression of task.cron.<name>, or
+// every interval when there is none. A task without either is registered
+// unscheduled so it can still be run by hand, scheduled reports which.
+func registerTask(s gocron.Scheduler, name string, interval time.Duration, fn func() error) (scheduled bool, err error) {
+	t := &registeredTask{info: TaskInfo{Name: name}, fn: fn}
+
+	var definition gocron.JobDefinition
+	if crontab := strings.TrimSpace(viper.GetString("task.cron." + name)); crontab != "" {
+		// a sixth field means the expression starts with seconds
+		definition = gocron.CronJob(crontab, len(strings.Fields(crontab)) == 6)
+		t.info.Schedule = crontab
+	} else if interval > 0 {
+		definition = gocron.DurationJob(interval)
+		t.info.Schedule = fmt.Sprintf("@every %s", interval)
+	}
+	if definition != nil {
+		job, err := s.NewJob(definition, gocron.NewTask(t.run), gocron.WithName(name))
+		if err != nil {
+			return false, fmt.Errorf("schedule task %s: %w", name, err)
+		}
+		t.job = job
+	}
+
+	tasksMu.Lock()
+	if _, exists := tasks[name]; !exists {
+		taskNames = append(taskNames, name)
+	}
+	tasks[name] = t
+	tasksMu.Unlock()
+	return t.job != nil, nil
+}
+
+func (t *registeredTask) run() {
+	t.mu.Lock()
+	if t.info.Paused || t.info.Running {
+		t.mu.Unlock()
+		return
+	}
+	t.info.Running = true
+	t.mu.Unlock()
+
+	start := time.Now()
+	_, span := tracing.Start(context.Background(), "task "+t.info.Name)
+	err := t.call()
+	tracing.End(span, err)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.info.Running = false
+	t.info.LastRun = start
+	t.info.LastDuration = time.Since(start).Seconds()
+	t.info.LastError = ""
+	if err != nil {
+		t.info.LastError = err.Error()
+	}
+}
+
+// call runs the task with a panic reported and returned as its error.
+func (t *registeredTask) call() (err error) {
+	defer crash.Recover("task "+t.info.Name, &err)
+	return t.fn()
+}
+
+func (t *registeredTask) snapshot() TaskInfo {
+	t.mu.Lock()
+	info := t.info
+	t.mu.Unlock()
+	if t.job != nil && !info.Paused {
+		if next, err := t.job.NextRun(); err == nil {
+			info.NextRun = next
+		}
+	}
+	return info
+}
+
+func getTask(name string) (*registeredTask, error) {
+	tasksMu.RLock()
+	defer tasksMu.RUnlock()
+	t, ok := tasks[name]
+	if !ok {
+		return nil, ErrTaskNotFound
+	}
+	return t, nil
+}
+
+func ListTasks() []TaskInfo {
+	tasksMu.RLock()
+	defer tasksMu.RUnlock()
+	infos := make([]TaskInfo, 0, len(taskNames))
+	for _, name := range taskNames {
+		infos = append(infos, tasks[name].snapshot())
+	}
+	return infos
+}
+
+func GetTask(name string) (TaskInfo, error) {
+	t, err := getTask(name)
+	if err != nil {
+		return TaskInfo{}, err
+	}
+	return t.snapshot(), nil
+}
+
+// PauseTask skips the scheduled runs of a task until it is resumed, a run in
+// progress is not interrupted.
+func PauseTask(name string) error {
+	return setPaused(name, true)
+}
+
+func ResumeTask(name string) error {
+	return setPaused(name, false)
+}
+
+func setPaused(name string, paused bool) error {
+	t, err := getTask(name)
+	if err != nil {
+		return err
+	}
+	t.mu.Lock()
+	t.info.Paused = paused
+	t.mu.Unlock()
+	return nil
+}
+
+// RunTask runs a task right away in the caller's goroutine, unless it is
+// paused or already running.
+func RunTask(name string) error {
+	t, err := getTask(name)
+	if err != nil {
+		return err
+	}
+	t.mu.Lock()
+	paused, running := t.info.Paused, t.info.Running
+	t.mu.Unlock()
+	if paused {
+		return errors.New("task is paused")
+	}
+	if running {
+		return errors.New("task is already running")
+	}
+	t.run()
+	return nil
+}
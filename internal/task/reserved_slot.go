@@ -0,0 +1,122 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/spf13/viper"
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/internal/locale"
+	"github.com/zaigie/palworld-server-tool/internal/logger"
+	"github.com/zaigie/palworld-server-tool/internal/tool"
+	"github.com/zaigie/palworld-server-tool/service"
+	"go.etcd.io/bbolt"
+)
+
+var (
+	ErrNotVip        = errors.New("player is not in a reserved slot or admin group")
+	ErrServerNotFull = errors.New("server is not full")
+	ErrNoSlotToFree  = errors.New("no player to kick for a slot")
+)
+
+// EnforceReservedSlots keeps manage.reserved_slots slots free for VIPs by
+// kicking the most recently joined non-VIPs beyond that.
+func EnforceReservedSlots(db *bbolt.DB, players []database.OnlinePlayer) {
+	reserved := viper.GetInt("manage.reserved_slots")
+	if reserved <= 0 {
+		return
+	}
+	metrics, err := tool.Metrics(context.Background())
+	if err != nil {
+		logger.Errorf("%v\n", err)
+		return
+	}
+	groups, err := service.ListPlayerGroups(db)
+	if err != nil {
+		logger.Errorf("%v\n", err)
+		return
+	}
+	nonVips := 0
+	for _, player := range players {
+		if !isVip(player, groups) {
+			nonVips++
+		}
+	}
+	over := nonVips - (metrics["max_player_num"].(int) - reserved)
+	if over <= 0 {
+		return
+	}
+	if _, err := kickForSlots(db, players, groups, over); err != nil {
+		logger.Errorf("%v\n", err)
+	}
+}
+
+// FreeReservedSlot kicks the most recently joined non-VIP when the server is
+// full, so the VIP member can get in.
+func FreeReservedSlot(db *bbolt.DB, member database.GroupMember) (database.OnlinePlayer, error) {
+	groups, err := service.ListPlayerGroups(db)
+	if err != nil {
+		return database.OnlinePlayer{}, err
+	}
+	if !isVip(database.OnlinePlayer{PlayerUid: member.PlayerUid, SteamId: member.SteamId}, groups) {
+		return database.OnlinePlayer{}, ErrNotVip
+	}
+	players, err := tool.ShowPlayers(context.Background())
+	if err != nil {
+		return database.OnlinePlayer{}, err
+	}
+	metrics, err := tool.Metrics(context.Background())
+	if err != nil {
+		return database.OnlinePlayer{}, err
+	}
+	if len(players) < metrics["max_player_num"].(int) {
+		return database.OnlinePlayer{}, ErrServerNotFull
+	}
+	kicked, err := kickForSlots(db, players, groups, 1)
+	if err != nil {
+		return database.OnlinePlayer{}, err
+	}
+	return kicked[0], nil
+}
+
+// kickForSlots announces and kicks count non-VIPs, latest joined first.
+func kickForSlots(db *bbolt.DB, players []database.OnlinePlayer, groups []database.PlayerGroup, count int) ([]database.OnlinePlayer, error) {
+	var candidates []database.OnlinePlayer
+	for _, player := range players {
+		if player.SteamId != "" && !isVip(player, groups) {
+			candidates = append(candidates, player)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, ErrNoSlotToFree
+	}
+	joinMu.Lock()
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return joinedAt[candidates[i].PlayerUid].After(joinedAt[candidates[j].PlayerUid])
+	})
+	joinMu.Unlock()
+	if count > len(candidates) {
+		count = len(candidates)
+	}
+
+	message := locale.Source(locale.Broadcast, "reserved_slot.kick", "manage.reserved_slot_message")
+	var kicked []database.OnlinePlayer
+	for _, player := range candidates[:count] {
+		if message != "" {
+			BroadcastVariableMessage(message, player.Nickname, len(players))
+		}
+		if err := tool.KickPlayer(context.Background(), fmt.Sprintf("steam_%s", player.SteamId)); err != nil {
+			return kicked, err
+		}
+		logger.Warnf("Kicked %s for a reserved slot\n", player.Nickname)
+		recordEvent(db, database.Event{
+			Type:      service.EventReservedSlotKick,
+			PlayerUid: player.PlayerUid,
+			Message:   fmt.Sprintf("%s was kicked for a reserved slot", player.Nickname),
+		})
+		kicked = append(kicked, player)
+	}
+	return kicked, nil
+}
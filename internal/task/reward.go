@@ -0,0 +1,135 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/internal/locale"
+	"github.com/zaigie/palworld-server-tool/internal/logger"
+	"github.com/zaigie/palworld-server-tool/internal/tool"
+	"github.com/zaigie/palworld-server-tool/service"
+	"go.etcd.io/bbolt"
+)
+
+type Reward struct {
+	Name string `json:"name" mapstructure:"name"`
+	Cost int64  `json:"cost" mapstructure:"cost"`
+	// Cooldown is the seconds before a player can redeem it again
+	Cooldown int `json:"cooldown" mapstructure:"cooldown"`
+	// Commands are sent over RCON with {steam_id}, {player_uid} and
+	// {nickname} replaced, giving items needs a server mod
+	Commands []string `json:"commands" mapstructure:"commands"`
+}
+
+var ErrUnknownReward = errors.New("unknown reward")
+
+func Rewards() ([]Reward, error) {
+	var rewards []Reward
+	if err := viper.UnmarshalKey("rewards", &rewards); err != nil {
+		return nil, err
+	}
+	return rewards, nil
+}
+
+func findReward(name string) (Reward, error) {
+	rewards, err := Rewards()
+	if err != nil {
+		return Reward{}, err
+	}
+	for _, reward := range rewards {
+		if strings.EqualFold(reward.Name, name) {
+			return reward, nil
+		}
+	}
+	return Reward{}, ErrUnknownReward
+}
+
+// RedeemReward takes the cost off the player's points and sends the reward
+// commands, points are refunded when a command fails.
+func RedeemReward(db *bbolt.DB, player database.TersePlayer, name string) (database.PointsAccount, error) {
+	reward, err := findReward(name)
+	if err != nil {
+		return database.PointsAccount{}, err
+	}
+	reason := "reward:" + reward.Name
+	if reward.Cooldown > 0 {
+		if wait, err := rewardCooldown(db, player.PlayerUid, reason, time.Duration(reward.Cooldown)*time.Second); err != nil {
+			return database.PointsAccount{}, err
+		} else if wait > 0 {
+			return database.PointsAccount{}, errors.New(locale.T(locale.Broadcast, "reward.cooldown", "reward", reward.Name, "wait", wait.Round(time.Second)))
+		}
+	}
+	account, err := service.AdjustPoints(db, player.PlayerUid, -reward.Cost, reason)
+	if err != nil {
+		return database.PointsAccount{}, err
+	}
+	replacer := strings.NewReplacer(
+		"{steam_id}", player.SteamId,
+		"{player_uid}", player.PlayerUid,
+		"{nickname}", player.Nickname,
+	)
+	for _, command := range reward.Commands {
+		if _, err := tool.CustomCommand(context.Background(), replacer.Replace(command)); err != nil {
+			logger.Errorf("Reward %s for %s failed: %v\n", reward.Name, player.Nickname, err)
+			if _, refundErr := service.AdjustPoints(db, player.PlayerUid, reward.Cost, "refund:"+reward.Name); refundErr != nil {
+				logger.Errorf("%v\n", refundErr)
+			}
+			return database.PointsAccount{}, errors.New(locale.T(locale.Broadcast, "reward.failed", "reward", reward.Name))
+		}
+	}
+	logger.Infof("%s redeemed %s for %d points\n", player.Nickname, reward.Name, reward.Cost)
+	recordEvent(db, database.Event{
+		Type:      service.EventRewardRedeemed,
+		PlayerUid: player.PlayerUid,
+		Message:   fmt.Sprintf("%s redeemed %s for %d points", player.Nickname, reward.Name, reward.Cost),
+		Data:      map[string]string{"reward": reward.Name, "cost": fmt.Sprint(reward.Cost)},
+	})
+	return account, nil
+}
+
+// rewardCooldown is how long until the player's last redemption of reason
+// is cooldown old.
+func rewardCooldown(db *bbolt.DB, playerUid, reason string, cooldown time.Duration) (time.Duration, error) {
+	transactions, err := service.ListPointsTransactions(db, playerUid, 0)
+	if err != nil {
+		return 0, err
+	}
+	for _, t := range transactions {
+		if t.Reason == reason {
+			return time.Until(t.Time.Add(cooldown)), nil
+		}
+	}
+	return 0, nil
+}
+
+func rewardsChatCommand(_ *bbolt.DB, _ database.TersePlayer, _ []string) (string, error) {
+	rewards, err := Rewards()
+	if err != nil {
+		return "", err
+	}
+	if len(rewards) == 0 {
+		return locale.T(locale.Broadcast, "reward.none"), nil
+	}
+	items := make([]string, 0, len(rewards))
+	for _, reward := range rewards {
+		items = append(items, fmt.Sprintf("%s (%d)", reward.Name, reward.Cost))
+	}
+	return locale.T(locale.Broadcast, "reward.list", "rewards", strings.Join(items, ", ")), nil
+}
+
+func redeemChatCommand(db *bbolt.DB, player database.TersePlayer, args []string) (string, error) {
+	if len(args) == 0 {
+		return "", errors.New(locale.T(locale.Broadcast, "reward.usage"))
+	}
+	reward := strings.Join(args, " ")
+	account, err := RedeemReward(db, player, reward)
+	if err != nil {
+		return "", err
+	}
+	return locale.T(locale.Broadcast, "reward.redeemed", "reward", reward, "points", account.Balance), nil
+}
@@ -0,0 +1,279 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+	lua "github.com/yuin/gopher-lua"
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/internal/logger"
+	"github.com/zaigie/palworld-server-tool/internal/notify"
+	"github.com/zaigie/palworld-server-tool/internal/tool"
+	"github.com/zaigie/palworld-server-tool/service"
+	"go.etcd.io/bbolt"
+	"gopkg.in/yaml.v3"
+)
+
+// Script is a file of scripts.dir, its actions run in order for each event
+// it matches. A lua script has no When or Actions, its on_event function
+// is called with the event instead.
+type Script struct {
+	Name string `json:"name" yaml:"-"`
+	Lua  bool   `json:"lua,omitempty" yaml:"-"`
+	// Events selects event types like notify.events, an empty list selects
+	// the stored events only
+	Events []string `json:"events" yaml:"events"`
+	// When requires event fields to equal the values, keys are type,
+	// player_uid, admin_player_uid, message, nickname or data.<key>
+	When    map[string]string `json:"when" yaml:"when"`
+	Actions []ScriptAction    `json:"actions" yaml:"actions"`
+	Error   string            `json:"error,omitempty" yaml:"-"`
+}
+
+// ScriptAction is one of broadcast, kick or tag, the text of broadcast and
+// tag has the event fields of Script.When in braces replaced.
+type ScriptAction struct {
+	Broadcast string `json:"broadcast,omitempty" yaml:"broadcast"`
+	// Kick kicks the player of the event
+	Kick bool `json:"kick,omitempty" yaml:"kick"`
+	// Tag adds a tag to the playtime of the player of the event
+	Tag string `json:"tag,omitempty" yaml:"tag"`
+}
+
+type scriptFile struct {
+	modTime time.Time
+	script  Script
+	// proto is the compiled lua script
+	proto *lua.FunctionProto
+}
+
+var (
+	scriptMu    sync.Mutex
+	scriptFiles = make(map[string]scriptFile)
+)
+
+// ListScripts loads the yaml, json and lua files of scripts.dir, files are
+// parsed again only when they changed so edits apply on the next event.
+func ListScripts() ([]Script, error) {
+	files, err := loadScripts()
+	if err != nil {
+		return nil, err
+	}
+	scripts := make([]Script, len(files))
+	for i, f := range files {
+		scripts[i] = f.script
+	}
+	return scripts, nil
+}
+
+func loadScripts() ([]scriptFile, error) {
+	dir := viper.GetString("scripts.dir")
+	if dir == "" {
+		return []scriptFile{}, nil
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	scriptMu.Lock()
+	defer scriptMu.Unlock()
+	scripts := make([]scriptFile, 0, len(entries))
+	seen := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		ext := strings.ToLower(filepath.Ext(entry.Name()))
+		if entry.IsDir() || (ext != ".yaml" && ext != ".yml" && ext != ".json" && ext != ".lua") {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		seen[path] = true
+		cached, ok := scriptFiles[path]
+		if !ok || !cached.modTime.Equal(info.ModTime()) {
+			cached = loadScript(path)
+			cached.modTime = info.ModTime()
+			scriptFiles[path] = cached
+		}
+		scripts = append(scripts, cached)
+	}
+	for path := range scriptFiles {
+		if !seen[path] {
+			delete(scriptFiles, path)
+		}
+	}
+	return scripts, nil
+}
+
+func loadScript(path string) scriptFile {
+	script := Script{Name: strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))}
+	if strings.EqualFold(filepath.Ext(path), ".lua") {
+		script.Lua = true
+		proto := compileLuaScript(path, &script)
+		return scriptFile{script: script, proto: proto}
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		script.Error = err.Error()
+		return scriptFile{script: script}
+	}
+	// yaml rather than viper, viper would split the dotted keys of When
+	if err := yaml.Unmarshal(b, &script); err != nil {
+		script.Error = err.Error()
+		return scriptFile{script: script}
+	}
+	for i, action := range script.Actions {
+		if action.Broadcast == "" && !action.Kick && action.Tag == "" {
+			script.Error = fmt.Sprintf("action %d has none of broadcast, kick or tag", i+1)
+		}
+	}
+	return scriptFile{script: script}
+}
+
+// RunScripts runs the scripts matching each event, a failed action stops
+// the rest of its script.
+func RunScripts(db *bbolt.DB, events []database.Event) {
+	files, err := loadScripts()
+	if err != nil {
+		logger.Errorf("%v\n", err)
+		return
+	}
+	for _, event := range events {
+		fields := scriptFields(db, event)
+		for _, f := range files {
+			script := f.script
+			if script.Error != "" || !notify.MatchEvent(script.Events, event) || !scriptWhen(script.When, fields) {
+				continue
+			}
+			if script.Lua {
+				err = runLuaScript(db, script, f.proto, event, fields)
+			} else {
+				err = runScript(db, script, event, fields)
+			}
+			if err != nil {
+				logger.Warnf("Script %s failed on %s: %v\n", script.Name, event.Type, err)
+			}
+		}
+	}
+}
+
+func runScript(db *bbolt.DB, script Script, event database.Event, fields map[string]string) error {
+	replacer := scriptReplacer(fields)
+	for _, action := range script.Actions {
+		if action.Broadcast != "" {
+			broadcastLines(replacer.Replace(action.Broadcast))
+		}
+		if action.Kick {
+			if err := kickEventPlayer(db, event); err != nil {
+				return err
+			}
+			logger.Infof("Script %s kicked %s\n", script.Name, fields["nickname"])
+		}
+		if action.Tag != "" {
+			if err := tagEventPlayer(db, event, fields["nickname"], replacer.Replace(action.Tag)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func scriptFields(db *bbolt.DB, event database.Event) map[string]string {
+	fields := map[string]string{
+		"type":             event.Type,
+		"player_uid":       event.PlayerUid,
+		"admin_player_uid": event.AdminPlayerUid,
+		"message":          event.Message,
+		"nickname":         event.Data["nickname"],
+	}
+	for k, v := range event.Data {
+		fields["data."+k] = v
+	}
+	if fields["nickname"] == "" && event.PlayerUid != "" {
+		if player, err := service.GetPlayer(db, event.PlayerUid); err == nil {
+			fields["nickname"] = player.Nickname
+		}
+	}
+	return fields
+}
+
+func scriptWhen(when map[string]string, fields map[string]string) bool {
+	for k, v := range when {
+		if fields[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+func scriptReplacer(fields map[string]string) *strings.Replacer {
+	pairs := make([]string, 0, 2*len(fields))
+	for k, v := range fields {
+		pairs = append(pairs, "{"+k+"}", v)
+	}
+	return strings.NewReplacer(pairs...)
+}
+
+func kickEventPlayer(db *bbolt.DB, event database.Event) error {
+	if event.PlayerUid == "" {
+		return errors.New("event has no player to kick")
+	}
+	player, err := service.GetPlayer(db, event.PlayerUid)
+	if err != nil {
+		return err
+	}
+	if player.SteamId == "" {
+		return errors.New("player has no steam id")
+	}
+	return tool.KickPlayer(context.Background(), fmt.Sprintf("steam_%s", player.SteamId))
+}
+
+func tagEventPlayer(db *bbolt.DB, event database.Event, nickname, tag string) error {
+	if event.PlayerUid == "" {
+		return errors.New("event has no player to tag")
+	}
+	return TagPlayer(db, event.PlayerUid, nickname, tag)
+}
+
+// TagPlayer adds tag to the playtime of the player once.
+func TagPlayer(db *bbolt.DB, playerUid, nickname, tag string) error {
+	playtime, ok, err := taggedPlaytime(db, playerUid, nickname, tag)
+	if err != nil || !ok {
+		return err
+	}
+	return service.PutPlaytime(db, playtime)
+}
+
+// DryRunTagPlayer returns what TagPlayer would change.
+func DryRunTagPlayer(db *bbolt.DB, playerUid, nickname, tag string) ([]service.Change, error) {
+	playtime, ok, err := taggedPlaytime(db, playerUid, nickname, tag)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return service.DryRunPutPlaytime(db, playtime)
+}
+
+// taggedPlaytime returns the playtime of the player with tag added, and
+// false when it already has the tag.
+func taggedPlaytime(db *bbolt.DB, playerUid, nickname, tag string) (database.Playtime, bool, error) {
+	playtime, err := service.GetPlaytime(db, playerUid)
+	if err != nil && err != service.ErrNoRecord {
+		return playtime, false, err
+	}
+	if hasTag(playtime.Tags, tag) {
+		return playtime, false, nil
+	}
+	if err == service.ErrNoRecord {
+		playtime = database.Playtime{PlayerUid: playerUid, Nickname: nickname}
+	}
+	playtime.Tags = append(playtime.Tags, tag)
+	return playtime, true, nil
+}
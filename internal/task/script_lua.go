@@ -0,0 +1,347 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/viper"
+	lua "github.com/yuin/gopher-lua"
+	"github.com/yuin/gopher-lua/parse"
+	"github.com/yuin/gopher-lua/pm"
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/internal/logger"
+	"go.etcd.io/bbolt"
+)
+
+// Lua scripts run in gopher-lua with the base, string, table and math
+// libraries less what reaches files or loads code, and a pst table of
+// broadcast, kick, tag and log. Each event gets a new state, nothing is kept
+// between events.
+var (
+	errInstructionLimit = errors.New("script ran more than scripts.max_instructions instructions")
+	errStringLimit      = errors.New("script built a string over scripts.max_string_bytes")
+)
+
+// luaRemoved are the globals of the opened libraries scripts don't get.
+var luaRemoved = []string{"collectgarbage", "dofile", "getfenv", "load", "loadfile", "loadstring", "module", "newproxy", "print", "require", "setfenv", "_printregs"}
+
+// scriptBudget ends a script after a number of instructions, or once a
+// register of the running function holds a string over the max size,
+// gopher-lua asks Done of its context before every instruction. A string
+// built by .. is caught at the next instruction, the library functions
+// building strings check their result size first, see limitStrings. Go
+// can't tell what a single goroutine allocated, so a script keeping many
+// strings is only bounded by the instructions times the string size.
+type scriptBudget struct {
+	context.Context
+	L               *lua.LState
+	ran             int64
+	maxInstructions int64
+	maxString       int
+	err             error
+	stopped         chan struct{}
+}
+
+func newScriptBudget(ctx context.Context, L *lua.LState) *scriptBudget {
+	return &scriptBudget{
+		Context:         ctx,
+		L:               L,
+		maxInstructions: viper.GetInt64("scripts.max_instructions"),
+		maxString:       viper.GetInt("scripts.max_string_bytes"),
+		stopped:         make(chan struct{}),
+	}
+}
+
+func (b *scriptBudget) Done() <-chan struct{} {
+	if b.err != nil {
+		return b.stopped
+	}
+	b.ran++
+	if b.maxInstructions > 0 && b.ran > b.maxInstructions {
+		b.stop(errInstructionLimit)
+		return b.stopped
+	}
+	if b.maxString > 0 {
+		for i := b.L.GetTop(); i > 0; i-- {
+			if s, ok := b.L.Get(i).(lua.LString); ok && len(s) > b.maxString {
+				b.stop(errStringLimit)
+				return b.stopped
+			}
+		}
+	}
+	return b.Context.Done()
+}
+
+func (b *scriptBudget) stop(err error) {
+	b.err = err
+	close(b.stopped)
+}
+
+func (b *scriptBudget) Err() error {
+	if b.err != nil {
+		return b.err
+	}
+	return b.Context.Err()
+}
+
+// limitStrings wraps the library functions that build a string within a
+// single call, table.concat, string.format and string.gsub, so they raise
+// errStringLimit instead of building one over max bytes.
+func limitStrings(L *lua.LState, max int) {
+	tab, _ := L.GetGlobal("table").(*lua.LTable)
+	str, _ := L.GetGlobal("string").(*lua.LTable)
+	if max <= 0 || tab == nil || str == nil {
+		return
+	}
+	check := func(L *lua.LState, n int) {
+		if n > max {
+			L.RaiseError(errStringLimit.Error())
+		}
+	}
+	wrap := func(lib *lua.LTable, name string, size func(L *lua.LState) int) {
+		f, ok := lib.RawGetString(name).(*lua.LFunction)
+		if !ok || !f.IsG {
+			return
+		}
+		lib.RawSetString(name, L.NewFunction(func(L *lua.LState) int {
+			check(L, size(L))
+			return f.GFunction(L)
+		}))
+	}
+	wrap(tab, "concat", func(L *lua.LState) int {
+		tbl := L.CheckTable(1)
+		sep := len(L.OptString(2, ""))
+		n := 0
+		for i, j := L.OptInt(3, 1), min(L.OptInt(4, tbl.Len()), tbl.Len()); i <= j && n <= max; i++ {
+			v := tbl.RawGetInt(i)
+			if !lua.LVCanConvToString(v) {
+				// table.concat raises the error
+				break
+			}
+			n += len(lua.LVAsString(v)) + sep
+		}
+		return n
+	})
+	wrap(str, "format", func(L *lua.LState) int {
+		format := L.CheckString(1)
+		n := len(format)
+		for i := 0; i < len(format); i++ {
+			if format[i] != '%' {
+				continue
+			}
+			// widths and precisions of up to two digits like Lua 5.1, so an
+			// item is at most its value and 99 bytes of padding
+			i++
+			for i < len(format) && strings.IndexByte("-+ #0", format[i]) >= 0 {
+				i++
+			}
+			for _, part := range []string{"width", "precision"} {
+				digits := 0
+				for i < len(format) && format[i] >= '0' && format[i] <= '9' {
+					digits++
+					i++
+				}
+				if digits > 2 {
+					L.RaiseError("invalid format (%s too long)", part)
+				}
+				if part == "width" && i < len(format) && format[i] == '.' {
+					i++
+				}
+			}
+			n += 99
+		}
+		for i := 2; i <= L.GetTop(); i++ {
+			n += len(lua.LVAsString(L.Get(i)))
+		}
+		return n
+	})
+	wrap(str, "gsub", func(L *lua.LState) int {
+		s := L.CheckString(1)
+		pattern := L.CheckString(2)
+		limit := L.OptInt(4, -1)
+		switch repl := L.Get(3).(type) {
+		case lua.LString:
+			matches, err := pm.Find(pattern, []byte(s), 0, limit)
+			if err != nil {
+				L.RaiseError(err.Error())
+			}
+			// each %0 to %9 adds at most the match, or a position
+			captures := strings.Count(string(repl), "%") - 2*strings.Count(string(repl), "%%")
+			n := len(s)
+			for _, m := range matches {
+				n += len(repl) + captures*(m.Capture(1)-m.Capture(0)+20)
+			}
+			return n
+		case *lua.LTable, *lua.LFunction:
+			// the replacements are counted as they are made
+			n := len(s)
+			L.Replace(3, L.NewFunction(func(L *lua.LState) int {
+				if tbl, ok := repl.(*lua.LTable); ok {
+					L.Push(L.GetTable(tbl, L.Get(1)))
+				} else {
+					top := L.GetTop()
+					L.Push(repl)
+					for i := 1; i <= top; i++ {
+						L.Push(L.Get(i))
+					}
+					L.Call(top, 1)
+				}
+				if v := L.Get(-1); lua.LVCanConvToString(v) {
+					n += len(lua.LVAsString(v))
+					check(L, n)
+				}
+				return 1
+			}))
+		}
+		return 0
+	})
+}
+
+// compileLuaScript compiles the file and runs it once to read its events
+// list and check it defines on_event.
+func compileLuaScript(path string, script *Script) *lua.FunctionProto {
+	f, err := os.Open(path)
+	if err != nil {
+		script.Error = err.Error()
+		return nil
+	}
+	defer f.Close()
+	chunk, err := parse.Parse(f, script.Name)
+	if err != nil {
+		script.Error = err.Error()
+		return nil
+	}
+	proto, err := lua.Compile(chunk, script.Name)
+	if err != nil {
+		script.Error = err.Error()
+		return nil
+	}
+	L, err := newLuaState(nil, proto, script)
+	if err != nil {
+		script.Error = luaError(err)
+		return nil
+	}
+	defer L.Close()
+	if L.GetGlobal("on_event").Type() != lua.LTFunction {
+		script.Error = "on_event is not a function"
+		return nil
+	}
+	switch events := L.GetGlobal("events").(type) {
+	case *lua.LNilType:
+	case *lua.LTable:
+		events.ForEach(func(_, v lua.LValue) {
+			script.Events = append(script.Events, v.String())
+		})
+	default:
+		script.Error = "events is not a list of event types"
+		return nil
+	}
+	return proto
+}
+
+// newLuaState opens a sandboxed state with the pst table of db and runs the
+// compiled script in it. Without db the pst functions fail, for the run
+// at load.
+func newLuaState(db *bbolt.DB, proto *lua.FunctionProto, script *Script) (*lua.LState, error) {
+	L := lua.NewState(lua.Options{
+		SkipOpenLibs:    true,
+		CallStackSize:   200,
+		RegistrySize:    1024,
+		RegistryMaxSize: 64 * 1024,
+	})
+	L.SetContext(newScriptBudget(context.Background(), L))
+	for _, lib := range []struct {
+		name string
+		open lua.LGFunction
+	}{{lua.BaseLibName, lua.OpenBase}, {lua.TabLibName, lua.OpenTable}, {lua.StringLibName, lua.OpenString}, {lua.MathLibName, lua.OpenMath}} {
+		L.Push(L.NewFunction(lib.open))
+		L.Push(lua.LString(lib.name))
+		L.Call(1, 0)
+	}
+	for _, name := range luaRemoved {
+		L.SetGlobal(name, lua.LNil)
+	}
+	if str, ok := L.GetGlobal("string").(*lua.LTable); ok {
+		str.RawSetString("rep", lua.LNil)
+	}
+	limitStrings(L, viper.GetInt("scripts.max_string_bytes"))
+	L.SetGlobal("pst", luaAPI(L, db, script))
+	L.Push(L.NewFunctionFromProto(proto))
+	if err := L.PCall(0, 0, nil); err != nil {
+		L.Close()
+		return nil, err
+	}
+	return L, nil
+}
+
+func luaAPI(L *lua.LState, db *bbolt.DB, script *Script) *lua.LTable {
+	api := L.NewTable()
+	fn := func(name string, f func(L *lua.LState) error) {
+		api.RawSetString(name, L.NewFunction(func(L *lua.LState) int {
+			if db == nil {
+				L.RaiseError("pst.%s can't be called when the script loads", name)
+			}
+			if err := f(L); err != nil {
+				L.RaiseError("pst.%s: %s", name, err.Error())
+			}
+			return 0
+		}))
+	}
+	fn("broadcast", func(L *lua.LState) error {
+		broadcastLines(L.CheckString(1))
+		return nil
+	})
+	fn("kick", func(L *lua.LState) error {
+		uid := L.CheckString(1)
+		if err := kickEventPlayer(db, database.Event{PlayerUid: uid}); err != nil {
+			return err
+		}
+		logger.Infof("Script %s kicked %s\n", script.Name, uid)
+		return nil
+	})
+	fn("tag", func(L *lua.LState) error {
+		return tagEventPlayer(db, database.Event{PlayerUid: L.CheckString(1)}, L.OptString(3, ""), L.CheckString(2))
+	})
+	fn("log", func(L *lua.LState) error {
+		logger.Infof("Script %s: %s\n", script.Name, L.CheckString(1))
+		return nil
+	})
+	return api
+}
+
+// runLuaScript calls on_event of the script with the event, in a state of
+// its own.
+func runLuaScript(db *bbolt.DB, script Script, proto *lua.FunctionProto, event database.Event, fields map[string]string) error {
+	L, err := newLuaState(db, proto, &script)
+	if err != nil {
+		return errors.New(luaError(err))
+	}
+	defer L.Close()
+	ev := L.NewTable()
+	data := L.NewTable()
+	for k, v := range fields {
+		if name, ok := strings.CutPrefix(k, "data."); ok {
+			data.RawSetString(name, lua.LString(v))
+		} else {
+			ev.RawSetString(k, lua.LString(v))
+		}
+	}
+	ev.RawSetString("data", data)
+	ev.RawSetString("time", lua.LNumber(event.Time.Unix()))
+	if err := L.CallByParam(lua.P{Fn: L.GetGlobal("on_event"), Protect: true}, ev); err != nil {
+		return errors.New(luaError(err))
+	}
+	return nil
+}
+
+// luaError is the message of a script error without the stack trace.
+func luaError(err error) string {
+	var apiErr *lua.ApiError
+	if errors.As(err, &apiErr) {
+		return fmt.Sprint(apiErr.Object)
+	}
+	return err.Error()
+}
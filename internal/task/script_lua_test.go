@@ -0,0 +1,124 @@
+package task
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/zaigie/palworld-server-tool/internal/database"
+)
+
+func writeScript(t *testing.T, dir, name, source string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(source), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestCompileLuaScript(t *testing.T) {
+	viper.Set("scripts.max_instructions", 10000)
+	defer viper.Set("scripts.max_instructions", nil)
+	dir := t.TempDir()
+	tests := []struct {
+		name   string
+		source string
+		events []string
+		err    string
+	}{
+		{"ok", `events = {"player.join", "player.leave"}
+function on_event(event) pst.log(event.nickname) end`, []string{"player.join", "player.leave"}, ""},
+		{"all events", `function on_event(event) end`, nil, ""},
+		{"syntax", `function on_event(event)`, nil, "EOF"},
+		{"no handler", `events = {"player.join"}`, nil, "on_event is not a function"},
+		{"bad events", `events = "player.join"
+function on_event(event) end`, nil, "events is not a list"},
+		{"loop at load", `while true do end`, nil, "max_instructions"},
+		{"api at load", `pst.broadcast("hi")`, nil, "can't be called when the script loads"},
+	}
+	for _, tt := range tests {
+		var script Script
+		script.Name = tt.name
+		proto := compileLuaScript(writeScript(t, dir, tt.name+".lua", tt.source), &script)
+		if tt.err == "" {
+			if script.Error != "" || proto == nil {
+				t.Errorf("%s: error %q", tt.name, script.Error)
+			}
+			if strings.Join(script.Events, ",") != strings.Join(tt.events, ",") {
+				t.Errorf("%s: events %v, want %v", tt.name, script.Events, tt.events)
+			}
+			continue
+		}
+		if proto != nil || !strings.Contains(script.Error, tt.err) {
+			t.Errorf("%s: error %q, want it to contain %q", tt.name, script.Error, tt.err)
+		}
+	}
+}
+
+func TestRunLuaScriptSandbox(t *testing.T) {
+	viper.Set("scripts.max_instructions", 10000)
+	defer viper.Set("scripts.max_instructions", nil)
+	viper.Set("scripts.max_string_bytes", 1<<20)
+	defer viper.Set("scripts.max_string_bytes", nil)
+	dir := t.TempDir()
+	event := database.Event{Type: "player.join", PlayerUid: "1001", Data: map[string]string{"nickname": "alice"}}
+	fields := map[string]string{"type": event.Type, "player_uid": event.PlayerUid, "nickname": "alice", "data.nickname": "alice"}
+	tests := []struct {
+		name   string
+		source string
+		err    string
+	}{
+		{"fields", `function on_event(event)
+  assert(event.type == "player.join" and event.nickname == "alice" and event.data.nickname == "alice")
+end`, ""},
+		{"no os", `function on_event(event) os.exit(1) end`, "os"},
+		{"no io", `function on_event(event) io.open("/etc/passwd") end`, "io"},
+		{"no load", `function on_event(event) load("return 1")() end`, "load"},
+		{"no require", `function on_event(event) require("os") end`, "require"},
+		{"no rep", `function on_event(event) string.rep("x", 1e9) end`, "rep"},
+		{"loop", `function on_event(event) while true do end end`, "max_instructions"},
+		{"loop in pcall", `function on_event(event) pcall(function() while true do end end) while true do end end`, "max_instructions"},
+		{"strings", `function on_event(event)
+  local s = string.format("%-10s|%5.2f", event.nickname, 1.5) .. table.concat({"a", "b"}, ",")
+  s = s:gsub("alice", "%0 %0"):gsub("b", {b = "c"}):gsub("a", function(a) return a:upper() end)
+  assert(s == "Alice Alice     | 1.50A,c", s)
+end`, ""},
+		{"doubling", `function on_event(event) local s = "x" for i = 1, 40 do s = s .. s end end`, "max_string_bytes"},
+		{"doubling in table", `function on_event(event) local t = {"x"} for i = 1, 40 do t[1] = t[1] .. t[1] end end`, "max_string_bytes"},
+		{"concat", `function on_event(event)
+  local t = {} for i = 1, 2048 do t[i] = "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef" end
+  for i = 1, 40 do table.concat(t) t = {table.concat(t), table.concat(t)} end
+end`, "max_string_bytes"},
+		{"format width", `function on_event(event) string.format("%0999999999d", 1) end`, "too long"},
+		{"gsub string", `function on_event(event)
+  local s = "0123456789abcdef" for i = 1, 15 do s = s .. s end
+  s:gsub(".", "%0%0%0%0")
+end`, "max_string_bytes"},
+		{"gsub function", `function on_event(event)
+  local s = "0123456789abcdef" for i = 1, 15 do s = s .. s end
+  s:gsub(".", function() return s end)
+end`, "max_string_bytes"},
+		{"recursion", `local function f() return 1 + f() end
+function on_event(event) f() end`, "overflow"},
+	}
+	for _, tt := range tests {
+		script := Script{Name: tt.name, Lua: true}
+		proto := compileLuaScript(writeScript(t, dir, tt.name+".lua", tt.source), &script)
+		if proto == nil {
+			t.Fatalf("%s: %s", tt.name, script.Error)
+		}
+		err := runLuaScript(nil, script, proto, event, fields)
+		if tt.err == "" {
+			if err != nil {
+				t.Errorf("%s: %v", tt.name, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: error %v, want it to contain %q", tt.name, err, tt.err)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package task
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/internal/logger"
+	"github.com/zaigie/palworld-server-tool/internal/paths"
+	"github.com/zaigie/palworld-server-tool/service"
+	"go.etcd.io/bbolt"
+)
+
+// EndSeason archives the season named name and starts the next one with
+// empty stats. With backup the save is backed up first so the season ends
+// with its last state, a failed backup keeps the season going.
+func EndSeason(db *bbolt.DB, name string, backup bool) (database.Season, error) {
+	if backup {
+		if _, err := RunBackup(db, service.BackupReasonSeason); err != nil {
+			return database.Season{}, fmt.Errorf("backup before the season ends failed: %v", err)
+		}
+	}
+	season, err := service.EndSeason(db, name, paths.Seasons())
+	if err != nil {
+		return database.Season{}, err
+	}
+	logger.Infof("Season %s ended, archived to %s\n", season.Name, season.Path)
+	recordEvent(db, database.Event{
+		Type:    service.EventSeasonEnded,
+		Message: fmt.Sprintf("Season %s ended with %d players and %d guilds", season.Name, season.Players, season.Guilds),
+		Data: map[string]string{
+			"season_id": strconv.FormatUint(season.Id, 10),
+			"name":      season.Name,
+			"players":   strconv.Itoa(season.Players),
+			"guilds":    strconv.Itoa(season.Guilds),
+			"backups":   strconv.Itoa(season.Backups),
+		},
+	})
+	return season, nil
+}
@@ -0,0 +1,43 @@
+package task
+
+import (
+	"time"
+
+	"github.com/zaigie/palworld-server-tool/internal/exporter"
+	"github.com/zaigie/palworld-server-tool/service"
+	"go.etcd.io/bbolt"
+)
+
+// StatsExportTask writes a snapshot of the players, guilds and online count
+// to export.targets.
+func StatsExportTask(db *bbolt.DB) error {
+	players, err := service.ListPlayers(db)
+	if err != nil {
+		return err
+	}
+	guilds, err := service.ListGuilds(db)
+	if err != nil {
+		return err
+	}
+	snapshot := exporter.Snapshot{Time: time.Now(), Online: onlineCount()}
+	for _, p := range players {
+		snapshot.Players = append(snapshot.Players, exporter.PlayerStat{
+			PlayerUid: p.PlayerUid,
+			Nickname:  p.Nickname,
+			Level:     p.Level,
+			Exp:       p.Exp,
+			Online:    isOnline(p.PlayerUid),
+			Platform:  p.Platform,
+		})
+	}
+	for _, g := range guilds {
+		snapshot.Guilds = append(snapshot.Guilds, exporter.GuildStat{
+			AdminPlayerUid: g.AdminPlayerUid,
+			Name:           g.Name,
+			Level:          g.BaseCampLevel,
+			Members:        len(g.Players),
+			BaseCamps:      len(g.BaseCamp),
+		})
+	}
+	return exporter.Export(snapshot)
+}
@@ -0,0 +1,20 @@
+package task
+
+import (
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/zaigie/palworld-server-tool/internal/logger"
+	"github.com/zaigie/palworld-server-tool/service"
+	"go.etcd.io/bbolt"
+)
+
+// StorageSampleTask samples the bucket sizes for their growth over time.
+func StorageSampleTask(db *bbolt.DB) error {
+	keep := time.Duration(viper.GetInt("debug.storage_sample_keep_days")) * 24 * time.Hour
+	if err := service.SampleStorage(db, keep); err != nil {
+		logger.Errorf("Storage sample failed: %v\n", err)
+		return err
+	}
+	return nil
+}
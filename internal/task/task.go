@@ -1,20 +1,24 @@
 package task
 
 import (
+	"context"
 	"fmt"
-	"os"
-	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/zaigie/palworld-server-tool/internal/bus"
 	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/internal/demo"
+	"github.com/zaigie/palworld-server-tool/internal/paths"
 	"github.com/zaigie/palworld-server-tool/internal/system"
 
 	"github.com/go-co-op/gocron/v2"
 	"github.com/spf13/viper"
+	"github.com/zaigie/palworld-server-tool/internal/locale"
 	"github.com/zaigie/palworld-server-tool/internal/logger"
+	"github.com/zaigie/palworld-server-tool/internal/notify"
 	"github.com/zaigie/palworld-server-tool/internal/tool"
 	"github.com/zaigie/palworld-server-tool/service"
 	"go.etcd.io/bbolt"
@@ -22,23 +26,74 @@ import (
 
 var s gocron.Scheduler
 
-func BackupTask(db *bbolt.DB) {
-	logger.Info("Scheduling backup...\n")
-	path, err := tool.Backup()
+// RunBackup backs up the save as a zip archive or into the chunk store by
+// save.backup_format, and records it in the catalog with why it was taken,
+// the players online at the last poll and the server version. Either way
+// backup.done is published.
+func RunBackup(db *bbolt.DB, reason string) (database.Backup, error) {
+	backup, err := runBackup(db, reason)
+	event := database.Event{
+		Type:    service.EventBackupDone,
+		Message: fmt.Sprintf("Backup saved to %s", backup.Path),
+		Data:    map[string]string{"status": "success", "reason": reason, "backup_id": backup.BackupId, "path": backup.Path},
+	}
 	if err != nil {
-		logger.Errorf("%v\n", err)
-		return
+		event.Message = fmt.Sprintf("Backup failed: %v", err)
+		event.Data = map[string]string{"status": "fail", "reason": reason, "error": err.Error()}
 	}
-	err = service.AddBackup(db, database.Backup{
-		BackupId: uuid.New().String(),
-		Path:     path,
-		SaveTime: time.Now(),
-	})
+	publishEvents(event)
+	return backup, err
+}
+
+func runBackup(db *bbolt.DB, reason string) (database.Backup, error) {
+	var backup database.Backup
+	if viper.GetString("save.backup_format") == service.BackupFormatChunks {
+		var err error
+		if backup, err = tool.BackupChunks(); err != nil {
+			return database.Backup{}, err
+		}
+	} else {
+		path, keyId, err := tool.Backup()
+		if err != nil {
+			return database.Backup{}, err
+		}
+		backup.Path = path
+		backup.KeyId = keyId
+		backup.Format = service.BackupFormatZip
+		if backup.Size, backup.Checksum, err = tool.BackupFileInfo(path); err != nil {
+			logger.Warnf("Failed to checksum backup %s: %v\n", path, err)
+		}
+	}
+	backup.BackupId = uuid.New().String()
+	backup.SaveTime = time.Now()
+	backup.Reason = reason
+	backup.PlayerCount = onlineCount()
+	if info, err := tool.Info(context.Background()); err == nil {
+		backup.WorldVersion = info["version"]
+	}
+	if err := service.AddBackup(db, backup); err != nil {
+		return database.Backup{}, err
+	}
+	return backup, nil
+}
+
+func BackupTask(db *bbolt.DB) error {
+	logger.Info("Scheduling backup...\n")
+	backup, err := RunBackup(db, service.BackupReasonSchedule)
 	if err != nil {
 		logger.Errorf("%v\n", err)
-		return
+		recordEvent(db, database.Event{
+			Type:    service.EventBackupFail,
+			Message: fmt.Sprintf("Auto backup failed: %v", err),
+		})
+		return err
 	}
-	logger.Infof("Auto backup to %s\n", path)
+	logger.Infof("Auto backup to %s\n", backup.Path)
+	recordEvent(db, database.Event{
+		Type:    service.EventBackupSuccess,
+		Message: fmt.Sprintf("Auto backup saved to %s", backup.Path),
+		Data:    map[string]string{"backup_id": backup.BackupId, "path": backup.Path},
+	})
 
 	keepDays := viper.GetInt("save.backup_keep_days")
 	if keepDays == 0 {
@@ -47,30 +102,78 @@ func BackupTask(db *bbolt.DB) {
 	err = tool.CleanOldBackups(db, keepDays)
 	if err != nil {
 		logger.Errorf("Failed to clean old backups: %v\n", err)
+		return err
 	}
+	return nil
 }
 
-func PlayerSync(db *bbolt.DB) {
-	logger.Info("Scheduling Player sync...\n")
-	onlinePlayers, err := tool.ShowPlayers()
+// BackupGcTask removes the chunks of the chunk store no backup uses since
+// their backups were deleted.
+func BackupGcTask(db *bbolt.DB) error {
+	removed, freed, err := tool.CollectBackupChunks(db)
 	if err != nil {
-		logger.Errorf("%v\n", err)
+		logger.Errorf("Backup chunk collection failed: %v\n", err)
+		return err
+	}
+	if removed > 0 {
+		logger.Infof("Removed %d unused backup chunks, freed %d bytes\n", removed, freed)
+	}
+	return nil
+}
+
+func PlayerSync(db *bbolt.DB) error {
+	logger.Info("Scheduling Player sync...\n")
+	onlinePlayers, showErr := tool.ShowPlayers(context.Background())
+	if showErr != nil {
+		logger.Errorf("%v\n", showErr)
 	}
-	err = service.PutPlayersOnline(db, onlinePlayers)
+	err := service.PutPlayersOnline(db, onlinePlayers)
 	if err != nil {
 		logger.Errorf("%v\n", err)
 	}
 	logger.Info("Player sync done\n")
 
-	playerLogging := viper.GetBool("task.player_logging")
-	if playerLogging {
-		go PlayerLogging(onlinePlayers)
+	if viper.GetInt("map.heatmap_grid") > 0 {
+		go HeatmapSample(db, onlinePlayers)
+	}
+
+	// a failed poll would look like everyone left and rejoined
+	if showErr == nil {
+		PublishPresence(onlinePlayers)
+		TrackLocations(onlinePlayers)
+		go func() {
+			ApplyPlayerGroups(db, onlinePlayers)
+			EnforceReservedSlots(db, onlinePlayers)
+		}()
+		go PlaytimeSync(db, onlinePlayers)
+		go RecordStreaks(db, onlinePlayers)
+		go EarnPoints(db, onlinePlayers)
+		go CheckAfkPlayers(db, onlinePlayers)
+		go RecordPlayerIps(db, onlinePlayers)
+		go RecordPings(db, onlinePlayers)
+		go CheckIpReputation(db, onlinePlayers)
+		go EnforceIpBans(db, onlinePlayers)
 	}
 
 	kickInterval := viper.GetBool("manage.kick_non_whitelist")
 	if kickInterval {
 		go CheckAndKickPlayers(db, onlinePlayers)
 	}
+
+	if showErr != nil {
+		return showErr
+	}
+	publishEvents(database.Event{
+		Type: service.EventSyncDone,
+		Data: map[string]string{"sync": "player", "online_num": strconv.Itoa(len(onlinePlayers))},
+	})
+	return err
+}
+
+// maxPollGap is how long between polls still counts as staying online, two
+// missed polls means the tool or the server was down in between.
+func maxPollGap() time.Duration {
+	return 2 * time.Duration(viper.GetInt("task.sync_interval")) * time.Second
 }
 
 func isPlayerWhitelisted(player database.OnlinePlayer, whitelist []database.PlayerW) bool {
@@ -83,41 +186,28 @@ func isPlayerWhitelisted(player database.OnlinePlayer, whitelist []database.Play
 	return false
 }
 
-var playerCache map[string]string
-var firstPoll = true
-
-func PlayerLogging(players []database.OnlinePlayer) {
-	loginMsg := viper.GetString("task.player_login_message")
-	logoutMsg := viper.GetString("task.player_logout_message")
-
-	tmp := make(map[string]string, len(players))
-	for _, player := range players {
-		if player.PlayerUid != "" {
-			tmp[player.PlayerUid] = player.Nickname
-		}
-	}
-	if !firstPoll {
-		for id, name := range tmp {
-			if _, ok := playerCache[id]; !ok {
-				BroadcastVariableMessage(loginMsg, name, len(players))
-			}
-		}
-		for id, name := range playerCache {
-			if _, ok := tmp[id]; !ok {
-				BroadcastVariableMessage(logoutMsg, name, len(players))
-			}
+// PlayerLogging broadcasts the login and logout messages on the join and
+// leave events of the bus.
+func PlayerLogging(events []database.Event) {
+	for _, event := range events {
+		onlineNum, _ := strconv.Atoi(event.Data["online_num"])
+		switch event.Type {
+		case service.EventPlayerJoin:
+			BroadcastVariableMessage(locale.Source(locale.Broadcast, "player.join", "task.player_login_message"), event.Data["nickname"], onlineNum)
+		case service.EventPlayerLeave:
+			BroadcastVariableMessage(locale.Source(locale.Broadcast, "player.leave", "task.player_logout_message"), event.Data["nickname"], onlineNum)
 		}
 	}
-	firstPoll = false
-	playerCache = tmp
 }
 
 func BroadcastVariableMessage(message string, username string, onlineNum int) {
-	message = strings.ReplaceAll(message, "{username}", username)
-	message = strings.ReplaceAll(message, "{online_num}", strconv.Itoa(onlineNum))
+	broadcastLines(locale.Render(message, "username", username, "online_num", onlineNum))
+}
+
+func broadcastLines(message string) {
 	arr := strings.Split(message, "\n")
 	for _, msg := range arr {
-		err := tool.Broadcast(msg)
+		err := tool.Broadcast(context.Background(), msg)
 		if err != nil {
 			logger.Warnf("Broadcast fail, %s \n", err)
 		}
@@ -131,14 +221,18 @@ func CheckAndKickPlayers(db *bbolt.DB, players []database.OnlinePlayer) {
 	if err != nil {
 		logger.Errorf("%v\n", err)
 	}
+	groups, err := service.ListPlayerGroups(db)
+	if err != nil {
+		logger.Errorf("%v\n", err)
+	}
 	for _, player := range players {
-		if !isPlayerWhitelisted(player, whitelist) {
+		if !isPlayerWhitelisted(player, whitelist) && !isGroupAdmin(player, groups) {
 			identifier := player.SteamId
 			if identifier == "" {
 				logger.Warnf("Kicked %s fail, SteamId is empty \n", player.Nickname)
 				continue
 			}
-			err := tool.KickPlayer(fmt.Sprintf("steam_%s", identifier))
+			err := tool.KickPlayer(context.Background(), fmt.Sprintf("steam_%s", identifier))
 			if err != nil {
 				logger.Warnf("Kicked %s fail, %s \n", player.Nickname, err)
 				continue
@@ -149,63 +243,98 @@ func CheckAndKickPlayers(db *bbolt.DB, players []database.OnlinePlayer) {
 	logger.Info("Check whitelist done\n")
 }
 
-func SavSync() {
+func SavSync() error {
 	logger.Info("Scheduling Sav sync...\n")
-	err := tool.Decode(viper.GetString("save.path"))
+	var err error
+	if demo.Enabled() {
+		// the demo world is written as is, there is no save to parse
+		err = demo.SavSync(database.GetDB())
+	} else {
+		err = tool.Decode(context.Background(), database.GetDB(), viper.GetString("save.path"))
+		checkClockSkew(database.GetDB())
+	}
 	if err != nil {
 		logger.Errorf("%v\n", err)
+		return err
 	}
 	logger.Info("Sav sync done\n")
+	go BadgeSync(database.GetDB())
+	publishEvents(database.Event{
+		Type: service.EventSyncDone,
+		Data: map[string]string{"sync": "save"},
+	})
+	return nil
 }
 
 func Schedule(db *bbolt.DB) {
 	s := getScheduler()
 
+	if viper.GetBool("task.player_logging") {
+		bus.Subscribe("player_logging", []string{service.EventPlayerJoin, service.EventPlayerLeave}, PlayerLogging)
+	}
+	if viper.GetString("chat.log_path") != "" {
+		go ChatTail(db)
+	}
+	if viper.GetString("scripts.dir") != "" {
+		bus.Subscribe("scripts", nil, func(events []database.Event) { RunScripts(db, events) })
+	}
+
 	playerSyncInterval := time.Duration(viper.GetInt("task.sync_interval"))
 	savSyncInterval := time.Duration(viper.GetInt("save.sync_interval"))
 	backupInterval := time.Duration(viper.GetInt("save.backup_interval"))
-
-	if playerSyncInterval > 0 {
-		go PlayerSync(db)
-		_, err := s.NewJob(
-			gocron.DurationJob(playerSyncInterval*time.Second),
-			gocron.NewTask(PlayerSync, db),
-		)
-		if err != nil {
-			logger.Errorf("%v\n", err)
-		}
+	backupGcInterval := time.Duration(viper.GetInt("save.backup_gc_interval"))
+	metricsInterval := time.Duration(viper.GetInt("metrics.interval"))
+	exportInterval := time.Duration(viper.GetInt("export.interval"))
+	orphanPruneInterval := time.Duration(viper.GetInt("manage.orphan_prune_interval"))
+	storageSampleInterval := time.Duration(viper.GetInt("debug.storage_sample_interval"))
+	var dbShipInterval time.Duration
+	if viper.GetString("db_shipping.target") != "" {
+		dbShipInterval = time.Duration(viper.GetInt("db_shipping.interval"))
+	}
+	var updateCheckInterval time.Duration
+	if viper.GetBool("update.check") {
+		updateCheckInterval = time.Duration(viper.GetInt("update.check_interval"))
 	}
 
-	if savSyncInterval > 0 {
-		go SavSync()
-		_, err := s.NewJob(
-			gocron.DurationJob(savSyncInterval*time.Second),
-			gocron.NewTask(SavSync),
-		)
+	tasks := []struct {
+		name       string
+		interval   time.Duration
+		runOnStart bool
+		fn         func() error
+	}{
+		{TaskPlayerSync, playerSyncInterval * time.Second, true, func() error { return PlayerSync(db) }},
+		{TaskSavSync, savSyncInterval * time.Second, true, SavSync},
+		{TaskBackup, backupInterval * time.Second, true, func() error { return BackupTask(db) }},
+		{TaskBackupGc, backupGcInterval * time.Second, false, func() error { return BackupGcTask(db) }},
+		{TaskCommunityEvent, 60 * time.Second, false, func() error { return CommunityEventTask(db) }},
+		{TaskCleanCache, 300 * time.Second, false, func() error {
+			return system.LimitCacheDir(paths.Cache(), "palworldsav-", 5)
+		}},
+		{TaskEmailDigest, 0, false, func() error { return EmailDigestTask(db) }},
+		{TaskUpdateCheck, updateCheckInterval * time.Second, false, func() error { return UpdateCheckTask(db) }},
+		{TaskDailyReport, 0, false, func() error { return DailyReportTask(db) }},
+		{TaskMetrics, metricsInterval * time.Second, false, func() error { return MetricsTask(db) }},
+		{TaskStatsExport, exportInterval * time.Second, false, func() error { return StatsExportTask(db) }},
+		{TaskOrphanPrune, orphanPruneInterval * time.Second, false, func() error { return OrphanPruneTask(db) }},
+		{TaskDbShip, dbShipInterval * time.Second, true, func() error { return DbShipTask(db) }},
+		{TaskStorageSample, storageSampleInterval * time.Second, true, func() error { return StorageSampleTask(db) }},
+	}
+	for _, t := range tasks {
+		// the digest runs on the default crontab, it's only there with
+		// someone to mail it to
+		if t.name == TaskEmailDigest && !notify.EmailDigestEnabled() {
+			continue
+		}
+		scheduled, err := registerTask(s, t.name, t.interval, t.fn)
 		if err != nil {
 			logger.Errorf("%v\n", err)
+			continue
 		}
-	}
-
-	if backupInterval > 0 {
-		go BackupTask(db)
-		_, err := s.NewJob(
-			gocron.DurationJob(backupInterval*time.Second),
-			gocron.NewTask(BackupTask, db),
-		)
-		if err != nil {
-			logger.Error(err)
+		if scheduled && t.runOnStart && viper.GetString("task.cron."+t.name) == "" {
+			go RunTask(t.name)
 		}
 	}
 
-	_, err := s.NewJob(
-		gocron.DurationJob(300*time.Second),
-		gocron.NewTask(system.LimitCacheDir, filepath.Join(os.TempDir(), "palworldsav-"), 5),
-	)
-	if err != nil {
-		logger.Errorf("%v\n", err)
-	}
-
 	s.Start()
 }
 
@@ -218,7 +347,8 @@ func Shutdown() {
 }
 
 func initScheduler() gocron.Scheduler {
-	s, err := gocron.NewScheduler()
+	// the cron tasks run in task.timezone, which config made the local one
+	s, err := gocron.NewScheduler(gocron.WithLocation(time.Local))
 	if err != nil {
 		logger.Errorf("%v\n", err)
 	}
@@ -227,7 +357,7 @@ func initScheduler() gocron.Scheduler {
 
 func getScheduler() gocron.Scheduler {
 	if s == nil {
-		return initScheduler()
+		s = initScheduler()
 	}
 	return s
 }
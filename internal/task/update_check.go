@@ -0,0 +1,137 @@
+package task
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/internal/logger"
+	"github.com/zaigie/palworld-server-tool/internal/tool"
+	"github.com/zaigie/palworld-server-tool/service"
+	"go.etcd.io/bbolt"
+)
+
+type UpdateStatus struct {
+	InstalledBuildId string    `json:"installed_build_id"`
+	LatestBuildId    string    `json:"latest_build_id"`
+	UpdateAvailable  bool      `json:"update_available"`
+	CheckedAt        time.Time `json:"checked_at"`
+	Error            string    `json:"error"`
+	// JobId is the update job started by the last check, if any
+	JobId string `json:"job_id"`
+}
+
+var (
+	updateMu      sync.Mutex
+	updateStatus  UpdateStatus
+	notifiedBuild string
+)
+
+func GetUpdateStatus() UpdateStatus {
+	updateMu.Lock()
+	defer updateMu.Unlock()
+	return updateStatus
+}
+
+// UpdateCheckTask compares the installed build with the latest on Steam,
+// notifies once per new build and, with update.auto_update, updates and
+// restarts the server within the maintenance window.
+func UpdateCheckTask(db *bbolt.DB) error {
+	status := UpdateStatus{CheckedAt: time.Now()}
+	err := checkUpdate(db, &status)
+	if err != nil {
+		status.Error = err.Error()
+		logger.Errorf("Update check fail, %v\n", err)
+	}
+	updateMu.Lock()
+	updateStatus = status
+	updateMu.Unlock()
+	return err
+}
+
+func checkUpdate(db *bbolt.DB, status *UpdateStatus) error {
+	appId := viper.GetInt("update.app_id")
+	installed, err := tool.InstalledBuildId(viper.GetString("update.manifest_path"))
+	if err != nil {
+		return err
+	}
+	latest, err := tool.LatestBuildId(viper.GetString("update.info_url"), appId, viper.GetString("update.branch"))
+	if err != nil {
+		return err
+	}
+	status.InstalledBuildId = installed
+	status.LatestBuildId = latest
+	status.UpdateAvailable = installed != latest
+	if !status.UpdateAvailable {
+		return nil
+	}
+
+	updateMu.Lock()
+	notify := notifiedBuild != latest
+	notifiedBuild = latest
+	updateMu.Unlock()
+	if notify {
+		logger.Warnf("Server update available, build %s -> %s\n", installed, latest)
+		recordEvent(db, database.Event{
+			Type:    service.EventServerUpdateAvailable,
+			Message: fmt.Sprintf("Server update available, build %s -> %s", installed, latest),
+			Data:    map[string]string{"installed_build_id": installed, "latest_build_id": latest},
+		})
+	}
+
+	if !viper.GetBool("update.auto_update") {
+		return nil
+	}
+	inWindow, err := inMaintenanceWindow(viper.GetString("update.maintenance_window"), time.Now())
+	if err != nil || !inWindow {
+		return err
+	}
+	action, opts := ServerActionRestart, ServerJobOptions{
+		Seconds: viper.GetInt("update.countdown"),
+		Update:  true,
+	}
+	if !serverRunning() {
+		action, opts = ServerActionUpdate, ServerJobOptions{}
+	}
+	job, err := StartServerJob(db, action, opts)
+	if err != nil {
+		if err == ErrServerBusy {
+			return nil
+		}
+		return err
+	}
+	status.JobId = job.Id
+	logger.Infof("Server update job %s started\n", job.Id)
+	return nil
+}
+
+// inMaintenanceWindow reports whether t is within a "HH:MM-HH:MM" window of
+// local time, which may cross midnight. An empty window is always open.
+func inMaintenanceWindow(window string, t time.Time) (bool, error) {
+	if window == "" {
+		return true, nil
+	}
+	from, to, ok := strings.Cut(window, "-")
+	if !ok {
+		return false, errors.New("maintenance window must be HH:MM-HH:MM")
+	}
+	start, err := time.Parse("15:04", strings.TrimSpace(from))
+	if err != nil {
+		return false, fmt.Errorf("invalid maintenance window: %w", err)
+	}
+	end, err := time.Parse("15:04", strings.TrimSpace(to))
+	if err != nil {
+		return false, fmt.Errorf("invalid maintenance window: %w", err)
+	}
+	minute := t.Hour()*60 + t.Minute()
+	startMinute := start.Hour()*60 + start.Minute()
+	endMinute := end.Hour()*60 + end.Minute()
+	if startMinute <= endMinute {
+		return minute >= startMinute && minute < endMinute, nil
+	}
+	return minute >= startMinute || minute < endMinute, nil
+}
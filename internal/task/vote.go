@@ -0,0 +1,179 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/internal/locale"
+	"github.com/zaigie/palworld-server-tool/internal/logger"
+	"github.com/zaigie/palworld-server-tool/internal/tool"
+	"github.com/zaigie/palworld-server-tool/service"
+	"go.etcd.io/bbolt"
+)
+
+const (
+	VoteRestart = "restart"
+	VoteKick    = "kick"
+
+	// VoteActionNotify only records vote.passed for the admins to act on
+	VoteActionNotify = "notify"
+)
+
+type vote struct {
+	kind      string
+	target    database.OnlinePlayer
+	voters    map[string]bool
+	startedAt time.Time
+}
+
+var (
+	voteMu sync.Mutex
+	// votes are keyed by kind, and the target uid for kicks
+	votes = make(map[string]*vote)
+	// voteStarted is when each player last started a vote
+	voteStarted = make(map[string]time.Time)
+)
+
+func restartVoteChatCommand(db *bbolt.DB, player database.TersePlayer, _ []string) (string, error) {
+	return castVote(db, player, VoteRestart, "")
+}
+
+func voteKickChatCommand(db *bbolt.DB, player database.TersePlayer, args []string) (string, error) {
+	if len(args) == 0 {
+		return "", errors.New(locale.T(locale.Broadcast, "vote.kick_usage"))
+	}
+	return castVote(db, player, VoteKick, strings.Join(args, " "))
+}
+
+// castVote adds the player's vote, starting the vote if there is none, and
+// runs the action once vote.min_votes and vote.quorum_percent of the other
+// online players agree. Starting a vote is limited by vote.cooldown.
+func castVote(db *bbolt.DB, player database.TersePlayer, kind, targetName string) (string, error) {
+	if !viper.GetBool("vote.enable") {
+		return "", errors.New(locale.T(locale.Broadcast, "vote.disabled"))
+	}
+	online, err := tool.ShowPlayers(context.Background())
+	if err != nil {
+		return "", err
+	}
+	var target database.OnlinePlayer
+	key := kind
+	if kind == VoteKick {
+		if target, err = findVoteTarget(db, online, targetName); err != nil {
+			return "", err
+		}
+		if target.PlayerUid == player.PlayerUid {
+			return "", errors.New(locale.T(locale.Broadcast, "vote.kick_self"))
+		}
+		key = kind + "|" + target.PlayerUid
+	}
+
+	voteMu.Lock()
+	defer voteMu.Unlock()
+	now := time.Now()
+	duration := time.Duration(viper.GetInt("vote.duration")) * time.Second
+	for k, v := range votes {
+		if now.Sub(v.startedAt) > duration {
+			delete(votes, k)
+		}
+	}
+	v, ok := votes[key]
+	if !ok {
+		cooldown := time.Duration(viper.GetInt("vote.cooldown")) * time.Second
+		if wait := voteStarted[player.PlayerUid].Add(cooldown).Sub(now); wait > 0 {
+			return "", errors.New(locale.T(locale.Broadcast, "vote.cooldown", "wait", wait.Round(time.Second)))
+		}
+		v = &vote{kind: kind, target: target, voters: make(map[string]bool), startedAt: now}
+		votes[key] = v
+		voteStarted[player.PlayerUid] = now
+	}
+	if v.voters[player.PlayerUid] {
+		return "", errors.New(locale.T(locale.Broadcast, "vote.already_voted"))
+	}
+	v.voters[player.PlayerUid] = true
+
+	// the target of a kick has no say
+	voters := len(online)
+	if kind == VoteKick {
+		voters--
+	}
+	needed := int(math.Ceil(float64(voters) * viper.GetFloat64("vote.quorum_percent") / 100))
+	if minVotes := viper.GetInt("vote.min_votes"); needed < minVotes {
+		needed = minVotes
+	}
+	prefix := viper.GetString("chat.prefix")
+	if len(v.voters) < needed {
+		if kind == VoteKick {
+			return locale.T(locale.Broadcast, "vote.kick_progress", "username", target.Nickname, "votes", len(v.voters), "needed", needed, "prefix", prefix), nil
+		}
+		return locale.T(locale.Broadcast, "vote.restart_progress", "votes", len(v.voters), "needed", needed, "prefix", prefix), nil
+	}
+	delete(votes, key)
+	return passVote(db, v)
+}
+
+func findVoteTarget(db *bbolt.DB, online []database.OnlinePlayer, name string) (database.OnlinePlayer, error) {
+	for _, p := range online {
+		if !strings.EqualFold(p.Nickname, name) {
+			continue
+		}
+		groups, err := service.ListPlayerGroups(db)
+		if err != nil {
+			return database.OnlinePlayer{}, err
+		}
+		if isGroupAdmin(p, groups) {
+			return database.OnlinePlayer{}, errors.New(locale.T(locale.Broadcast, "vote.kick_admin"))
+		}
+		return p, nil
+	}
+	return database.OnlinePlayer{}, errors.New(locale.T(locale.Broadcast, "vote.not_online", "username", name))
+}
+
+// passVote records vote.passed and runs vote.restart_action or
+// vote.kick_action.
+func passVote(db *bbolt.DB, v *vote) (string, error) {
+	event := database.Event{
+		Type: service.EventVotePassed,
+		Data: map[string]string{"vote": v.kind, "votes": fmt.Sprint(len(v.voters))},
+	}
+	var reply string
+	var err error
+	switch v.kind {
+	case VoteRestart:
+		event.Message = fmt.Sprintf("Players voted to restart the server with %d votes", len(v.voters))
+		if viper.GetString("vote.restart_action") == VoteActionNotify {
+			reply = locale.T(locale.Broadcast, "vote.restart_notified")
+			break
+		}
+		_, err = StartServerJob(db, ServerActionRestart, ServerJobOptions{Seconds: viper.GetInt("vote.restart_countdown")})
+		reply = locale.T(locale.Broadcast, "vote.restart_passed")
+	case VoteKick:
+		event.PlayerUid = v.target.PlayerUid
+		event.Data["nickname"] = v.target.Nickname
+		event.Message = fmt.Sprintf("Players voted to kick %s with %d votes", v.target.Nickname, len(v.voters))
+		if viper.GetString("vote.kick_action") == VoteActionNotify {
+			reply = locale.T(locale.Broadcast, "vote.kick_notified", "username", v.target.Nickname)
+			break
+		}
+		if v.target.SteamId == "" {
+			err = errors.New("player has no steam id")
+		} else {
+			err = tool.KickPlayer(context.Background(), fmt.Sprintf("steam_%s", v.target.SteamId))
+		}
+		reply = locale.T(locale.Broadcast, "vote.kicked", "username", v.target.Nickname)
+	}
+	if err != nil {
+		event.Data["error"] = err.Error()
+		logger.Errorf("Vote %s passed but failed: %v\n", v.kind, err)
+		reply = locale.T(locale.Broadcast, "vote.failed", "vote", v.kind)
+	}
+	recordEvent(db, event)
+	return reply, nil
+}
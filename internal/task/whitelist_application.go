@@ -0,0 +1,97 @@
+package task
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/spf13/viper"
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/internal/locale"
+	"github.com/zaigie/palworld-server-tool/internal/logger"
+	"github.com/zaigie/palworld-server-tool/internal/notify"
+	"github.com/zaigie/palworld-server-tool/service"
+	"go.etcd.io/bbolt"
+)
+
+var ErrApplicationsDisabled = errors.New("whitelist applications are disabled")
+
+// ApplicationQuestions are the questions every applicant must answer.
+func ApplicationQuestions() []string {
+	return viper.GetStringSlice("whitelist_application.questions")
+}
+
+// SubmitWhitelistApplication queues app for the moderators and records
+// whitelist.application, so they are notified of it.
+func SubmitWhitelistApplication(db *bbolt.DB, app database.WhitelistApplication) (database.WhitelistApplication, error) {
+	if !viper.GetBool("whitelist_application.enable") {
+		return app, ErrApplicationsDisabled
+	}
+	app, err := service.AddWhitelistApplication(db, app, ApplicationQuestions())
+	if err != nil {
+		return app, err
+	}
+	recordEvent(db, database.Event{
+		Type:      service.EventWhitelistApplication,
+		PlayerUid: app.PlayerUid,
+		Message:   fmt.Sprintf("%s applied to the whitelist, application %d", app.Name, app.Id),
+		Data:      applicationData(app),
+	})
+	return app, nil
+}
+
+// DecideWhitelistApplication approves or denies the application, records
+// whitelist.approved or whitelist.denied and mails the applicant with the
+// smtp server of notify.email when they left an email.
+func DecideWhitelistApplication(db *bbolt.DB, id uint64, decision service.ApplicationDecision) (database.WhitelistApplication, error) {
+	app, err := service.DecideWhitelistApplication(db, id, decision)
+	if err != nil {
+		return app, err
+	}
+	event := database.Event{
+		Type:      service.EventWhitelistApproved,
+		PlayerUid: app.PlayerUid,
+		Message:   fmt.Sprintf("Approved the whitelist application %d of %s", app.Id, app.Name),
+		Data:      applicationData(app),
+	}
+	if !decision.Approve {
+		event.Type = service.EventWhitelistDenied
+		event.Message = fmt.Sprintf("Denied the whitelist application %d of %s", app.Id, app.Name)
+	}
+	if app.Reason != "" {
+		event.Message += ": " + app.Reason
+	}
+	recordEvent(db, event)
+	if app.Email != "" {
+		go mailApplicant(app)
+	}
+	return app, nil
+}
+
+func applicationData(app database.WhitelistApplication) map[string]string {
+	return map[string]string{
+		"id":       strconv.FormatUint(app.Id, 10),
+		"name":     app.Name,
+		"steam_id": app.SteamId,
+		"source":   app.Source,
+		"status":   app.Status,
+		"reason":   app.Reason,
+		"by":       app.DecidedBy,
+	}
+}
+
+func mailApplicant(app database.WhitelistApplication) {
+	eventType, key := service.EventWhitelistApproved, "email.application_approved"
+	if app.Status == service.ApplicationDenied {
+		eventType, key = service.EventWhitelistDenied, "email.application_denied"
+	}
+	subject := locale.T(locale.Notify, key, "name", app.Name)
+	message := subject
+	if app.Reason != "" {
+		message += "\n" + locale.T(locale.Notify, "email.application_reason", "reason", app.Reason)
+	}
+	event := database.Event{Type: eventType, Time: app.DecidedAt, Message: message}
+	if err := notify.SendEmailTo([]string{app.Email}, subject, []database.Event{event}); err != nil {
+		logger.Warnf("Email to applicant %d fail, %v\n", app.Id, err)
+	}
+}
@@ -0,0 +1,73 @@
+package task
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/internal/logger"
+	"go.etcd.io/bbolt"
+)
+
+// World actions are admin actions without a target player, the game has no
+// RCON command for them either.
+const (
+	WorldSetTime      = "set_time"
+	WorldSetWeather   = "set_weather"
+	WorldTriggerEvent = "trigger_event"
+)
+
+var worldIdPattern = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+// WorldAdminAction checks the placeholders of a world action and returns it
+// as an admin action, set_time takes {hour} and {minute}, set_weather
+// {weather} and trigger_event {event}.
+func WorldAdminAction(action database.WorldAction, by string) (AdminAction, error) {
+	vars := make(map[string]string)
+	switch action.Action {
+	case WorldSetTime:
+		hour, err := strconv.Atoi(action.Vars["hour"])
+		if err != nil || hour < 0 || hour > 23 {
+			return AdminAction{}, fmt.Errorf("hour of %s must be between 0 and 23", action.Action)
+		}
+		minute := 0
+		if m := action.Vars["minute"]; m != "" {
+			if minute, err = strconv.Atoi(m); err != nil || minute < 0 || minute > 59 {
+				return AdminAction{}, fmt.Errorf("minute of %s must be between 0 and 59", action.Action)
+			}
+		}
+		vars["hour"], vars["minute"] = strconv.Itoa(hour), strconv.Itoa(minute)
+	case WorldSetWeather, WorldTriggerEvent:
+		name := "weather"
+		if action.Action == WorldTriggerEvent {
+			name = "event"
+		}
+		if !worldIdPattern.MatchString(action.Vars[name]) {
+			return AdminAction{}, fmt.Errorf("%s of %s must be a word", name, action.Action)
+		}
+		vars[name] = action.Vars[name]
+	default:
+		return AdminAction{}, fmt.Errorf("unknown world action %s", action.Action)
+	}
+	admin := AdminAction{Action: action.Action, Vars: vars, By: by}
+	if _, err := admin.Command(); err != nil {
+		return AdminAction{}, err
+	}
+	return admin, nil
+}
+
+// runWorldActions runs the actions of a community event in order, a failed
+// one is logged and the others still run.
+func runWorldActions(db *bbolt.DB, event database.CommunityEvent, actions []database.WorldAction) {
+	for _, action := range actions {
+		admin, err := WorldAdminAction(action, "community event "+event.Name)
+		if err == nil {
+			_, err = RunAdminAction(context.Background(), db, admin)
+		}
+		if err != nil {
+			logger.Errorf("World action %s of event %s fail, %v\n", action.Action, event.Name, err)
+		}
+	}
+}
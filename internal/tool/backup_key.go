@@ -0,0 +1,240 @@
+package tool
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/viper"
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/internal/logger"
+	"github.com/zaigie/palworld-server-tool/internal/system"
+	"github.com/zaigie/palworld-server-tool/service"
+	"go.etcd.io/bbolt"
+)
+
+const encryptedSuffix = ".enc"
+
+// BackupKeyring returns the keys of save.backup_key and save.backup_key_file,
+// or nil when backups aren't encrypted. The key file has a key per line and
+// the last one encrypts new backups, so rotating is appending a key and
+// keeping the old ones until RekeyBackups has run. The file is read on every
+// call, a rotated key is picked up without a restart.
+func BackupKeyring() (*system.Keyring, error) {
+	var keys [][]byte
+	if s := viper.GetString("save.backup_key"); s != "" {
+		key, err := system.ParseKey(s)
+		if err != nil {
+			return nil, fmt.Errorf("save.backup_key: %s", err)
+		}
+		keys = append(keys, key)
+	}
+	if path := viper.GetString("save.backup_key_file"); path != "" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		scanner := bufio.NewScanner(f)
+		for line := 1; scanner.Scan(); line++ {
+			s := strings.TrimSpace(scanner.Text())
+			if s == "" || strings.HasPrefix(s, "#") {
+				continue
+			}
+			key, err := system.ParseKey(s)
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: %s", path, line, err)
+			}
+			keys = append(keys, key)
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	}
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	return system.NewKeyring(keys...)
+}
+
+// sealBackupFile encrypts a plain archive of the backup directory with the
+// active key, replacing it with name.enc.
+func sealBackupFile(keyring *system.Keyring, name string) (string, error) {
+	backupDir, err := GetBackupDir()
+	if err != nil {
+		return "", err
+	}
+	src, err := os.Open(filepath.Join(backupDir, name))
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+	sealed := strings.TrimSuffix(name, encryptedSuffix) + encryptedSuffix
+	if err := writeBackupFile(filepath.Join(backupDir, sealed), func(w io.Writer) error {
+		return keyring.Seal(w, src)
+	}); err != nil {
+		return "", err
+	}
+	src.Close()
+	if sealed != name {
+		if err := os.Remove(filepath.Join(backupDir, name)); err != nil {
+			return "", err
+		}
+	}
+	return sealed, nil
+}
+
+// writeBackupFile writes path through a temporary file so a failure never
+// leaves half an archive behind.
+func writeBackupFile(path string, write func(io.Writer) error) error {
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// WriteBackup writes the zip archive of a backup to w, decrypted unless
+// encrypted is set. An encrypted copy of an unencrypted backup isn't
+// possible without a key.
+func WriteBackup(w io.Writer, backup database.Backup, encrypted bool) error {
+	keyring, err := BackupKeyring()
+	if err != nil {
+		return err
+	}
+	// a zip backup already encrypted is sent as it is
+	needsKey := encrypted && (backup.Format == service.BackupFormatChunks || backup.KeyId == "")
+	if needsKey && keyring == nil {
+		return errors.New("no backup key is configured")
+	}
+	if backup.Format == service.BackupFormatChunks {
+		if !encrypted {
+			return WriteBackupZip(w, backup)
+		}
+		pr, pw := io.Pipe()
+		go func() { pw.CloseWithError(WriteBackupZip(pw, backup)) }()
+		err := keyring.Seal(w, pr)
+		pr.CloseWithError(err)
+		return err
+	}
+	backupDir, err := GetBackupDir()
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(filepath.Join(backupDir, backup.Path))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	switch {
+	case backup.KeyId == "" && encrypted:
+		return keyring.Seal(w, f)
+	case backup.KeyId != "" && !encrypted:
+		if keyring == nil {
+			return system.ErrUnknownKey
+		}
+		return keyring.Open(w, f)
+	}
+	_, err = io.Copy(w, f)
+	return err
+}
+
+// BackupFileName is the name a backup downloads as.
+func BackupFileName(backup database.Backup, encrypted bool) string {
+	name := strings.TrimSuffix(backup.Path, encryptedSuffix)
+	if encrypted {
+		name += encryptedSuffix
+	}
+	return name
+}
+
+// RekeyBackups encrypts the backups that are unencrypted or encrypted with a
+// key other than the active one with the active key. It returns how many
+// backups and chunks were rewritten.
+func RekeyBackups(db *bbolt.DB) (int, int, error) {
+	keyring, err := BackupKeyring()
+	if err != nil {
+		return 0, 0, err
+	}
+	if keyring == nil {
+		return 0, 0, errors.New("no backup key is configured")
+	}
+	store, err := backupChunkStore()
+	if err != nil {
+		return 0, 0, err
+	}
+	store.Keyring = keyring
+	chunks, err := store.Rekey()
+	if err != nil {
+		return 0, chunks, err
+	}
+	backups, err := service.ListBackups(db, service.BackupFilter{Files: true})
+	if err != nil {
+		return 0, chunks, err
+	}
+	backupDir, err := GetBackupDir()
+	if err != nil {
+		return 0, chunks, err
+	}
+	rekeyed := 0
+	for _, backup := range backups {
+		if backup.KeyId == keyring.ActiveKeyId() {
+			continue
+		}
+		if backup.Format != service.BackupFormatChunks {
+			path := filepath.Join(backupDir, backup.Path)
+			if _, err := os.Stat(path); os.IsNotExist(err) {
+				logger.Warnf("Backup %s to rekey is missing\n", backup.Path)
+				continue
+			}
+			sealed := backup.Path
+			if backup.KeyId == "" {
+				sealed, err = sealBackupFile(keyring, backup.Path)
+			} else {
+				err = rekeyBackupFile(keyring, path)
+			}
+			if err != nil {
+				return rekeyed, chunks, fmt.Errorf("backup %s: %w", backup.Path, err)
+			}
+			backup.Path = sealed
+			if backup.Size, backup.Checksum, err = BackupFileInfo(backup.Path); err != nil {
+				return rekeyed, chunks, err
+			}
+		}
+		backup.KeyId = keyring.ActiveKeyId()
+		if err := service.AddBackup(db, backup); err != nil {
+			return rekeyed, chunks, err
+		}
+		rekeyed++
+	}
+	return rekeyed, chunks, nil
+}
+
+func rekeyBackupFile(keyring *system.Keyring, path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	return writeBackupFile(path, func(w io.Writer) error {
+		pr, pw := io.Pipe()
+		go func() { pw.CloseWithError(keyring.Open(pw, src)) }()
+		err := keyring.Seal(w, pr)
+		pr.CloseWithError(err)
+		return err
+	})
+}
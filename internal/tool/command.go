@@ -0,0 +1,46 @@
+package tool
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// maxCommandOutput keeps the tail of long outputs like steamcmd progress.
+const maxCommandOutput = 4096
+
+// RunCommand runs a configured shell command and returns its combined output.
+func RunCommand(command string, timeout time.Duration) (string, error) {
+	return RunCommandInput(command, nil, timeout)
+}
+
+// RunCommandInput is RunCommand with input written to the command's stdin.
+func RunCommandInput(command string, input []byte, timeout time.Duration) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	// children of the shell keep the output pipe open after it's killed
+	cmd.WaitDelay = time.Second
+	if input != nil {
+		cmd.Stdin = bytes.NewReader(input)
+	}
+	out, err := cmd.CombinedOutput()
+	output := strings.TrimSpace(string(out))
+	if len(output) > maxCommandOutput {
+		output = "..." + output[len(output)-maxCommandOutput:]
+	}
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return output, errors.New("command timed out")
+	}
+	return output, err
+}
@@ -0,0 +1,167 @@
+package tool
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/zaigie/palworld-server-tool/internal/logger"
+)
+
+// Endpoint is an address of the RCON or the REST API of the game server,
+// like localhost and the public ip of the same server. Timeout is in
+// seconds, 0 is the timeout of the section.
+type Endpoint struct {
+	Address string `json:"address" mapstructure:"address"`
+	Timeout int    `json:"timeout" mapstructure:"timeout"`
+}
+
+type EndpointStatus struct {
+	// Kind is rcon or rest
+	Kind    string `json:"kind"`
+	Address string `json:"address"`
+	Primary bool   `json:"primary"`
+	// Active is the endpoint the last request went to
+	Active  bool `json:"active"`
+	Healthy bool `json:"healthy"`
+	// DownUntil is when an unreachable endpoint is tried again first
+	DownUntil *time.Time `json:"down_until,omitempty"`
+	LastError string     `json:"last_error,omitempty"`
+}
+
+type endpointHealth struct {
+	downUntil time.Time
+	lastError string
+}
+
+var endpoints = struct {
+	sync.Mutex
+	// health is by kind and address
+	health map[string]*endpointHealth
+	// active is the address that answered last by kind
+	active map[string]string
+}{health: make(map[string]*endpointHealth), active: make(map[string]string)}
+
+// listEndpoints returns the address of the section first, then its
+// fallbacks.
+func listEndpoints(kind string) []Endpoint {
+	timeout := viper.GetInt(kind + ".timeout")
+	list := []Endpoint{{Address: viper.GetString(kind + ".address"), Timeout: timeout}}
+	var fallbacks []Endpoint
+	if err := viper.UnmarshalKey(kind+".fallbacks", &fallbacks); err != nil {
+		logger.Warnf("Invalid %s.fallbacks: %v\n", kind, err)
+	}
+	for _, e := range fallbacks {
+		if e.Address == "" {
+			continue
+		}
+		if e.Timeout == 0 {
+			e.Timeout = timeout
+		}
+		list = append(list, e)
+	}
+	return list
+}
+
+// isUnreachable is true for errors of connecting, when nothing was sent and
+// another endpoint can be tried.
+func isUnreachable(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// withEndpoint calls fn with the endpoints of kind until one is reachable,
+// the healthy ones first in order and those which were unreachable within
+// the failover cooldown last. fn returns reached false when it couldn't
+// connect, so the request wasn't sent.
+func withEndpoint(kind string, fn func(e Endpoint) (reached bool, err error)) error {
+	list := listEndpoints(kind)
+	now := time.Now()
+	endpoints.Lock()
+	ordered := make([]Endpoint, 0, len(list))
+	var down []Endpoint
+	for _, e := range list {
+		if h := endpoints.health[kind+" "+e.Address]; h != nil && now.Before(h.downUntil) {
+			down = append(down, e)
+		} else {
+			ordered = append(ordered, e)
+		}
+	}
+	endpoints.Unlock()
+	ordered = append(ordered, down...)
+
+	var err error
+	for _, e := range ordered {
+		var reached bool
+		reached, err = fn(e)
+		if reached {
+			markReached(kind, e, list[0].Address)
+			return err
+		}
+		markUnreachable(kind, e, err)
+	}
+	return err
+}
+
+func markReached(kind string, e Endpoint, primary string) {
+	endpoints.Lock()
+	defer endpoints.Unlock()
+	delete(endpoints.health, kind+" "+e.Address)
+	previous := endpoints.active[kind]
+	if previous == e.Address {
+		return
+	}
+	endpoints.active[kind] = e.Address
+	switch {
+	case e.Address != primary:
+		logger.Warnf("%s failed over to %s\n", kind, e.Address)
+	case previous != "":
+		logger.Infof("%s back on %s\n", kind, e.Address)
+	}
+}
+
+func markUnreachable(kind string, e Endpoint, err error) {
+	cooldown := time.Duration(viper.GetInt(kind+".failover_cooldown")) * time.Second
+	endpoints.Lock()
+	defer endpoints.Unlock()
+	h := endpoints.health[kind+" "+e.Address]
+	if h == nil {
+		h = &endpointHealth{}
+		endpoints.health[kind+" "+e.Address] = h
+	}
+	h.downUntil = time.Now().Add(cooldown)
+	if err != nil {
+		h.lastError = err.Error()
+	}
+}
+
+// EndpointStatuses returns the health of the RCON and REST API endpoints.
+func EndpointStatuses() []EndpointStatus {
+	now := time.Now()
+	var statuses []EndpointStatus
+	endpoints.Lock()
+	defer endpoints.Unlock()
+	for _, kind := range []string{"rcon", "rest"} {
+		for i, e := range listEndpoints(kind) {
+			status := EndpointStatus{
+				Kind:    kind,
+				Address: e.Address,
+				Primary: i == 0,
+				Active:  endpoints.active[kind] == e.Address,
+				Healthy: true,
+			}
+			if h := endpoints.health[kind+" "+e.Address]; h != nil {
+				status.LastError = h.lastError
+				if now.Before(h.downUntil) {
+					status.Healthy = false
+					downUntil := h.downUntil
+					status.DownUntil = &downUntil
+				}
+			}
+			statuses = append(statuses, status)
+		}
+	}
+	return statuses
+}
@@ -0,0 +1,167 @@
+package tool
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// exportValue formats a cell for CSV.
+func exportValue(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case time.Time:
+		if v.IsZero() {
+			return ""
+		}
+		return v.Format(time.RFC3339)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// csvCell formats a cell for CSV. Text starting like a formula gets a
+// leading ', so spreadsheets show names like =HYPERLINK(...) instead of
+// evaluating them. Numbers are left alone, negative ones stay numbers.
+func csvCell(v any) string {
+	s := exportValue(v)
+	if _, ok := v.(string); ok && s != "" && strings.ContainsRune("=+-@", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+// RowWriter writes the rows of an export one at a time, Close finishes the
+// file.
+type RowWriter interface {
+	WriteRow(row []any) error
+	Close() error
+}
+
+type csvWriter struct {
+	cw     *csv.Writer
+	record []string
+}
+
+// NewCSVWriter writes the header and returns a writer of CSV rows.
+func NewCSVWriter(w io.Writer, header []string) (RowWriter, error) {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(header); err != nil {
+		return nil, err
+	}
+	return &csvWriter{cw: cw, record: make([]string, len(header))}, nil
+}
+
+func (w *csvWriter) WriteRow(row []any) error {
+	for i, v := range row {
+		w.record[i] = csvCell(v)
+	}
+	return w.cw.Write(w.record)
+}
+
+func (w *csvWriter) Close() error {
+	w.cw.Flush()
+	return w.cw.Error()
+}
+
+var xlsxStatic = map[string]string{
+	"[Content_Types].xml": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/></Types>`,
+	"_rels/.rels": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`,
+	"xl/_rels/workbook.xml.rels": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/></Relationships>`,
+}
+
+type xlsxWriter struct {
+	zw    *zip.Writer
+	sheet io.Writer
+}
+
+// NewXLSXWriter writes the header and returns a writer of the rows of a
+// single sheet workbook. Numbers become numeric cells, everything else
+// inline strings, so long ids like steam ids keep all their digits and text
+// is never taken for a formula.
+func NewXLSXWriter(w io.Writer, sheet string, header []string) (RowWriter, error) {
+	zw := zip.NewWriter(w)
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/_rels/workbook.xml.rels"} {
+		f, err := zw.Create(name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(f, xlsxStatic[name]); err != nil {
+			return nil, err
+		}
+	}
+	f, err := zw.Create("xl/workbook.xml")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := fmt.Fprintf(f, `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="%s" sheetId="1" r:id="rId1"/></sheets></workbook>`, xmlEscape(sheet)); err != nil {
+		return nil, err
+	}
+
+	f, err = zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.WriteString(f, `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`); err != nil {
+		return nil, err
+	}
+	headerRow := make([]any, len(header))
+	for i, h := range header {
+		headerRow[i] = h
+	}
+	if err := writeXLSXRow(f, headerRow); err != nil {
+		return nil, err
+	}
+	return &xlsxWriter{zw: zw, sheet: f}, nil
+}
+
+func (w *xlsxWriter) WriteRow(row []any) error {
+	return writeXLSXRow(w.sheet, row)
+}
+
+func (w *xlsxWriter) Close() error {
+	if _, err := io.WriteString(w.sheet, `</sheetData></worksheet>`); err != nil {
+		return err
+	}
+	return w.zw.Close()
+}
+
+func writeXLSXRow(w io.Writer, row []any) error {
+	var sb strings.Builder
+	sb.WriteString("<row>")
+	for _, v := range row {
+		switch v := v.(type) {
+		case int, int32, int64, float32, float64:
+			fmt.Fprintf(&sb, `<c><v>%v</v></c>`, v)
+		case bool:
+			b := 0
+			if v {
+				b = 1
+			}
+			fmt.Fprintf(&sb, `<c t="b"><v>%d</v></c>`, b)
+		default:
+			fmt.Fprintf(&sb, `<c t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, xmlEscape(exportValue(v)))
+		}
+	}
+	sb.WriteString("</row>")
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func xmlEscape(s string) string {
+	var sb strings.Builder
+	xml.EscapeText(&sb, []byte(s))
+	return sb.String()
+}
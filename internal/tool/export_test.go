@@ -0,0 +1,38 @@
+package tool
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCSVWriterFormulas(t *testing.T) {
+	var sb strings.Builder
+	w, err := NewCSVWriter(&sb, []string{"nickname", "location_x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, row := range [][]any{
+		{"=HYPERLINK(\"http://x\")", -1250.5},
+		{"+1", 0},
+		{"-x", 1},
+		{"@SUM(A1)", 2},
+		{"alice", 3},
+	} {
+		if err := w.WriteRow(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	want := `nickname,location_x
+"'=HYPERLINK(""http://x"")",-1250.5
+'+1,0
+'-x,1
+'@SUM(A1),2
+alice,3
+`
+	if sb.String() != want {
+		t.Errorf("CSV\n%s\nwant\n%s", sb.String(), want)
+	}
+}
@@ -0,0 +1,84 @@
+package tool
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+
+	"github.com/zaigie/palworld-server-tool/internal/database"
+)
+
+// MergeHeatmaps sums the heatmaps into one grid of the given size, grids of
+// another size are resampled by the cell centers.
+func MergeHeatmaps(heatmaps []database.Heatmap, size int) database.Heatmap {
+	merged := database.Heatmap{Size: size, Cells: make([]int, size*size)}
+	for _, h := range heatmaps {
+		if h.Size <= 0 || len(h.Cells) != h.Size*h.Size {
+			continue
+		}
+		merged.Samples += h.Samples
+		for i, count := range h.Cells {
+			if count == 0 {
+				continue
+			}
+			row := (i/h.Size*2 + 1) * size / (h.Size * 2)
+			col := (i%h.Size*2 + 1) * size / (h.Size * 2)
+			merged.Cells[row*size+col] += count
+		}
+	}
+	return merged
+}
+
+// RenderHeatmap draws the grid as a transparent png of width x width pixels,
+// to be laid over the whole map. Counts are log scaled so a few crowded cells
+// don't hide the rest.
+func RenderHeatmap(heatmap database.Heatmap, width int) ([]byte, error) {
+	img := image.NewNRGBA(image.Rect(0, 0, width, width))
+	max := 0
+	for _, count := range heatmap.Cells {
+		if count > max {
+			max = count
+		}
+	}
+	if max > 0 && heatmap.Size > 0 {
+		for py := 0; py < width; py++ {
+			row := py * heatmap.Size / width
+			for px := 0; px < width; px++ {
+				count := heatmap.Cells[row*heatmap.Size+px*heatmap.Size/width]
+				if count == 0 {
+					continue
+				}
+				img.SetNRGBA(px, py, heatColor(math.Log1p(float64(count))/math.Log1p(float64(max))))
+			}
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// heatColor goes from blue over green and yellow to red as v goes from 0 to 1.
+func heatColor(v float64) color.NRGBA {
+	var r, g, b float64
+	switch {
+	case v < 0.33:
+		t := v / 0.33
+		r, g, b = 0, t, 1-t
+	case v < 0.66:
+		t := (v - 0.33) / 0.33
+		r, g, b = t, 1, 0
+	default:
+		t := (v - 0.66) / 0.34
+		r, g, b = 1, 1-t, 0
+	}
+	return color.NRGBA{
+		R: uint8(r * 255),
+		G: uint8(g * 255),
+		B: uint8(b * 255),
+		A: uint8(96 + v*128),
+	}
+}
@@ -0,0 +1,185 @@
+package tool
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/netip"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/zaigie/palworld-server-tool/internal/cache"
+	"github.com/zaigie/palworld-server-tool/internal/logger"
+)
+
+type IpReputation struct {
+	Ip      string `json:"ip"`
+	Flagged bool   `json:"flagged"`
+	// Source is the list file or "provider" that flagged the ip
+	Source    string    `json:"source"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+var reputationClient = &http.Client{Timeout: 10 * time.Second}
+
+type ipList struct {
+	modTime  time.Time
+	prefixes []netip.Prefix
+}
+
+var (
+	reputationMu sync.Mutex
+	ipLists      = make(map[string]*ipList)
+)
+
+// CheckIpReputation looks the ip up in the ip_reputation.lists files, then asks
+// the provider. Provider answers are cached for ip_reputation.cache_hours in
+// the shared cache.
+func CheckIpReputation(ip string) (IpReputation, error) {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return IpReputation{}, err
+	}
+	addr = addr.Unmap()
+	for _, path := range viper.GetStringSlice("ip_reputation.lists") {
+		prefixes, err := loadIpList(path)
+		if err != nil {
+			return IpReputation{}, err
+		}
+		for _, prefix := range prefixes {
+			if prefix.Contains(addr) {
+				return IpReputation{Ip: ip, Flagged: true, Source: path, CheckedAt: time.Now()}, nil
+			}
+		}
+	}
+
+	providerUrl := viper.GetString("ip_reputation.provider_url")
+	if providerUrl == "" {
+		return IpReputation{Ip: ip, CheckedAt: time.Now()}, nil
+	}
+	cacheFor := time.Duration(viper.GetInt("ip_reputation.cache_hours")) * time.Hour
+	if data, err := cache.Default().Get("reputation:" + ip); err == nil {
+		var cached IpReputation
+		if json.Unmarshal(data, &cached) == nil {
+			return cached, nil
+		}
+	}
+	flagged, err := queryReputationProvider(providerUrl, ip, viper.GetStringSlice("ip_reputation.provider_fields"))
+	if err != nil {
+		return IpReputation{}, err
+	}
+	reputation := IpReputation{Ip: ip, Flagged: flagged, CheckedAt: time.Now()}
+	if flagged {
+		reputation.Source = "provider"
+	}
+	if cacheFor > 0 {
+		data, _ := json.Marshal(reputation)
+		if err := cache.Default().Set("reputation:"+ip, data, cacheFor); err != nil {
+			logger.Warnf("Failed to cache reputation of %s: %v\n", ip, err)
+		}
+	}
+	return reputation, nil
+}
+
+// loadIpList reads a file of ip addresses and CIDR ranges, one per line with
+// # comments, again only when it changed.
+func loadIpList(path string) ([]netip.Prefix, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	reputationMu.Lock()
+	defer reputationMu.Unlock()
+	if list, ok := ipLists[path]; ok && list.modTime.Equal(info.ModTime()) {
+		return list.prefixes, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var prefixes []netip.Prefix
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		prefix, err := ParseIpPrefix(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		prefixes = append(prefixes, prefix)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	ipLists[path] = &ipList{modTime: info.ModTime(), prefixes: prefixes}
+	return prefixes, nil
+}
+
+// ParseIpPrefix parses a CIDR range, or a single ip address as a range of one.
+func ParseIpPrefix(s string) (netip.Prefix, error) {
+	if strings.Contains(s, "/") {
+		prefix, err := netip.ParsePrefix(s)
+		if err != nil {
+			return netip.Prefix{}, err
+		}
+		return prefix.Masked(), nil
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// queryReputationProvider gets providerUrl with {ip} replaced and reports
+// whether any of fields, dot separated paths into the JSON answer, is true or
+// "yes".
+func queryReputationProvider(providerUrl, ip string, fields []string) (bool, error) {
+	resp, err := reputationClient.Get(strings.ReplaceAll(providerUrl, "{ip}", ip))
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return false, fmt.Errorf("%d %s", resp.StatusCode, body)
+	}
+	var answer map[string]any
+	if err := json.NewDecoder(resp.Body).Decode(&answer); err != nil {
+		return false, err
+	}
+	for _, field := range fields {
+		var value any = answer
+		// {ip} is replaced after splitting, the ip has dots of its own
+		for _, key := range strings.Split(field, ".") {
+			m, ok := value.(map[string]any)
+			if !ok {
+				value = nil
+				break
+			}
+			value = m[strings.ReplaceAll(key, "{ip}", ip)]
+		}
+		switch v := value.(type) {
+		case bool:
+			if v {
+				return true, nil
+			}
+		case string:
+			if strings.EqualFold(v, "yes") || strings.EqualFold(v, "true") {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,127 @@
+package tool
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/spf13/viper"
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"go.etcd.io/bbolt"
+)
+
+const (
+	UnitMeter      = "m"
+	UnitCentimeter = "cm"
+	UnitKilometer  = "km"
+)
+
+// DefaultLocationPrecision is the decimals of locations when none is asked,
+// MaxLocationPrecision is the most that still means something for positions
+// the game saves in whole centimeters.
+const (
+	DefaultLocationPrecision = 2
+	MaxLocationPrecision     = 6
+)
+
+// the in-game map shows world coordinates, which are centimeters, turned a
+// quarter and shifted so the origin is near the middle of the islands
+const (
+	gameMapScale   = 462.962962963
+	gameMapOffsetX = 157664.55791065
+	gameMapOffsetY = -123467.1611767
+)
+
+// Location is what the raw world coordinates of a player, base or object
+// mean on the map, computed by the server so every client agrees.
+type Location struct {
+	// MapX and MapY are the coordinates the in-game map shows
+	MapX float64 `json:"map_x"`
+	MapY float64 `json:"map_y"`
+	// Lat and Lng place the position on the tiles of /api/map
+	Lat float64 `json:"lat"`
+	Lng float64 `json:"lng"`
+	// DistanceFromSpawn is the straight line distance to map.spawn in Unit
+	DistanceFromSpawn float64 `json:"distance_from_spawn"`
+	Unit              string  `json:"unit"`
+	// Region is the name of the first of map.regions holding the position
+	Region string `json:"region,omitempty"`
+}
+
+// Region is a named area of the map in world coordinates.
+type Region struct {
+	Name string  `mapstructure:"name"`
+	MinX float64 `mapstructure:"min_x"`
+	MinY float64 `mapstructure:"min_y"`
+	MaxX float64 `mapstructure:"max_x"`
+	MaxY float64 `mapstructure:"max_y"`
+}
+
+// Locator computes locations with the calibration of the served map and the
+// configured spawn and regions.
+type Locator struct {
+	precision   int
+	unit        string
+	scale       float64
+	calibration database.MapCalibration
+	spawnX      float64
+	spawnY      float64
+	regions     []Region
+}
+
+// NewLocator returns a Locator rounding every value to precision decimals
+// and giving distances in unit, m, cm or km.
+func NewLocator(db *bbolt.DB, precision int, unit string) (*Locator, error) {
+	if precision < 0 || precision > MaxLocationPrecision {
+		return nil, fmt.Errorf("precision must be between 0 and %d", MaxLocationPrecision)
+	}
+	l := &Locator{precision: precision, unit: unit}
+	switch unit {
+	case UnitMeter, "":
+		l.unit, l.scale = UnitMeter, 100
+	case UnitCentimeter:
+		l.scale = 1
+	case UnitKilometer:
+		l.scale = 100000
+	default:
+		return nil, fmt.Errorf("unknown unit %s", unit)
+	}
+	var err error
+	if l.calibration, err = GetMapCalibration(db); err != nil {
+		return nil, err
+	}
+	if err := ValidateMapCalibration(l.calibration); err != nil {
+		return nil, err
+	}
+	l.spawnX = viper.GetFloat64("map.spawn_x")
+	l.spawnY = viper.GetFloat64("map.spawn_y")
+	if err := viper.UnmarshalKey("map.regions", &l.regions); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Locate returns the location of a position in world coordinates.
+func (l *Locator) Locate(x, y float64) *Location {
+	// the calibration was validated, world positions can't fail
+	pos, _ := ConvertMapPosition(l.calibration, "world", x, y, 0)
+	loc := &Location{
+		MapX:              l.round((y - gameMapOffsetX) / gameMapScale),
+		MapY:              l.round((x - gameMapOffsetY) / gameMapScale),
+		Lat:               l.round(pos.Lat),
+		Lng:               l.round(pos.Lng),
+		DistanceFromSpawn: l.round(math.Hypot(x-l.spawnX, y-l.spawnY) / l.scale),
+		Unit:              l.unit,
+	}
+	for _, region := range l.regions {
+		if x >= region.MinX && x <= region.MaxX && y >= region.MinY && y <= region.MaxY {
+			loc.Region = region.Name
+			break
+		}
+	}
+	return loc
+}
+
+func (l *Locator) round(v float64) float64 {
+	p := math.Pow10(l.precision)
+	return math.Round(v*p) / p
+}
@@ -0,0 +1,68 @@
+package tool
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strings"
+)
+
+// maxTailRead caps one read so a huge backlog can't stall the caller.
+const maxTailRead = 1 << 20
+
+// LogTail reads the lines appended to a log file since the last read. It
+// starts at the end of the file, and starts over when the file shrinks, as
+// when it's rotated or truncated.
+type LogTail struct {
+	Path    string
+	offset  int64
+	started bool
+	partial string
+}
+
+// ReadLines returns the complete lines written since the last call, a line
+// still being written is kept for the next call.
+func (t *LogTail) ReadLines() ([]string, error) {
+	f, err := os.Open(t.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if !t.started {
+		t.started = true
+		t.offset = info.Size()
+		return nil, nil
+	}
+	if info.Size() < t.offset {
+		t.offset = 0
+		t.partial = ""
+	}
+	if info.Size() == t.offset {
+		return nil, nil
+	}
+	if _, err := f.Seek(t.offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	b, err := io.ReadAll(io.LimitReader(f, maxTailRead))
+	if err != nil {
+		return nil, err
+	}
+	t.offset += int64(len(b))
+
+	var lines []string
+	scanner := bufio.NewScanner(strings.NewReader(t.partial + string(b)))
+	scanner.Buffer(make([]byte, 0, 64*1024), maxTailRead)
+	for scanner.Scan() {
+		lines = append(lines, strings.TrimRight(scanner.Text(), "\r"))
+	}
+	t.partial = ""
+	if len(b) > 0 && b[len(b)-1] != '\n' && len(lines) > 0 {
+		t.partial = lines[len(lines)-1]
+		lines = lines[:len(lines)-1]
+	}
+	return lines, nil
+}
@@ -0,0 +1,91 @@
+package tool
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/internal/logger"
+)
+
+const (
+	MacroActionSave      = "save"
+	MacroActionBroadcast = "broadcast"
+	MacroActionRcon      = "rcon"
+	MacroActionWait      = "wait"
+	MacroActionShutdown  = "shutdown"
+	MacroActionKick      = "kick"
+	MacroActionBan       = "ban"
+	MacroActionUnban     = "unban"
+)
+
+// ValidateMacroStep checks that a step can be executed by RunMacro.
+func ValidateMacroStep(step database.MacroStep) error {
+	switch step.Action {
+	case MacroActionSave:
+		return nil
+	case MacroActionBroadcast, MacroActionRcon, MacroActionKick, MacroActionBan, MacroActionUnban:
+		if step.Content == "" {
+			return fmt.Errorf("action %s requires content", step.Action)
+		}
+	case MacroActionWait, MacroActionShutdown:
+		if step.Seconds <= 0 {
+			return fmt.Errorf("action %s requires positive seconds", step.Action)
+		}
+	default:
+		return fmt.Errorf("unknown action: %s", step.Action)
+	}
+	return nil
+}
+
+// macroReplacer replaces the {name} placeholders of params in one pass, so
+// a value containing a placeholder isn't replaced again.
+func macroReplacer(params map[string]string) *strings.Replacer {
+	pairs := make([]string, 0, 2*len(params))
+	for k, v := range params {
+		pairs = append(pairs, "{"+k+"}", v)
+	}
+	return strings.NewReplacer(pairs...)
+}
+
+// RunMacro executes the macro steps in order, replacing {name} placeholders
+// in step content with params. It stops at the first failing step.
+func RunMacro(ctx context.Context, macro database.Macro, params map[string]string) error {
+	replacer := macroReplacer(params)
+	for i, step := range macro.Steps {
+		content := replacer.Replace(step.Content)
+		logger.Infof("Macro %s step %d: %s %s\n", macro.Name, i+1, step.Action, content)
+
+		var err error
+		switch step.Action {
+		case MacroActionSave:
+			err = Save(ctx)
+		case MacroActionBroadcast:
+			err = Broadcast(ctx, content)
+		case MacroActionRcon:
+			_, err = CustomCommand(ctx, content)
+		case MacroActionWait:
+			select {
+			case <-time.After(time.Duration(step.Seconds) * time.Second):
+			case <-ctx.Done():
+				err = ctx.Err()
+			}
+		case MacroActionShutdown:
+			err = Shutdown(ctx, step.Seconds, content)
+		case MacroActionKick:
+			err = KickPlayer(ctx, fmt.Sprintf("steam_%s", content))
+		case MacroActionBan:
+			err = BanPlayer(ctx, fmt.Sprintf("steam_%s", content))
+		case MacroActionUnban:
+			err = UnBanPlayer(ctx, fmt.Sprintf("steam_%s", content))
+		default:
+			err = fmt.Errorf("unknown action: %s", step.Action)
+		}
+		if err != nil {
+			return fmt.Errorf("macro %s step %d (%s) failed: %v", macro.Name, i+1, step.Action, err)
+		}
+	}
+	return nil
+}
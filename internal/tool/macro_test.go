@@ -0,0 +1,39 @@
+package tool
+
+import (
+	"testing"
+
+	"github.com/zaigie/palworld-server-tool/internal/database"
+)
+
+func TestMacroReplacer(t *testing.T) {
+	r := macroReplacer(map[string]string{
+		"name":   "{reason}",
+		"reason": "griefing",
+		"empty":  "",
+	})
+	got := r.Replace("kick {name} for {reason}{empty}, {unknown} stays")
+	if want := "kick {reason} for griefing, {unknown} stays"; got != want {
+		t.Errorf("Replace = %q, want %q", got, want)
+	}
+}
+
+func TestValidateMacroStep(t *testing.T) {
+	tests := []struct {
+		step database.MacroStep
+		ok   bool
+	}{
+		{database.MacroStep{Action: MacroActionSave}, true},
+		{database.MacroStep{Action: MacroActionBroadcast}, false},
+		{database.MacroStep{Action: MacroActionWait, Seconds: 5}, true},
+		{database.MacroStep{Action: MacroActionWait}, false},
+		{database.MacroStep{Action: MacroActionShutdown, Seconds: 30}, true},
+		{database.MacroStep{Action: MacroActionShutdown}, false},
+		{database.MacroStep{Action: "reboot"}, false},
+	}
+	for _, tt := range tests {
+		if err := ValidateMacroStep(tt.step); (err == nil) != tt.ok {
+			t.Errorf("ValidateMacroStep(%+v) = %v, want ok %v", tt.step, err, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,235 @@
+package tool
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/internal/system"
+	"github.com/zaigie/palworld-server-tool/service"
+	"go.etcd.io/bbolt"
+)
+
+const EmbeddedMapVersion = "embedded"
+
+// MapSize is the width of the whole map in lat/lng units, the size of the
+// single tile at zoom 0.
+const MapSize = 256
+
+var ErrTileNotFound = errors.New("tile not found")
+
+type MapInfo struct {
+	Version string `json:"version"`
+	MaxZoom int    `json:"max_zoom"`
+	TileUrl string `json:"tile_url"`
+}
+
+var embeddedTiles fs.FS
+
+var tileClient = &http.Client{Timeout: 30 * time.Second}
+
+// SetEmbeddedTiles sets the tiles built into the binary, laid out as {z}/{x}/{y}.png.
+func SetEmbeddedTiles(fsys fs.FS) {
+	embeddedTiles = fsys
+}
+
+func GetMapInfo() MapInfo {
+	return MapInfo{
+		Version: mapVersion(),
+		MaxZoom: viper.GetInt("map.max_zoom"),
+		TileUrl: "/map/tiles/{z}/{x}/{y}.png",
+	}
+}
+
+type MapPosition struct {
+	WorldX float64 `json:"world_x"`
+	WorldY float64 `json:"world_y"`
+	Lat    float64 `json:"lat"`
+	Lng    float64 `json:"lng"`
+	Zoom   int     `json:"zoom"`
+	PixelX float64 `json:"pixel_x"`
+	PixelY float64 `json:"pixel_y"`
+	// Location is added by /api/map/convert when asked
+	Location *Location `json:"location,omitempty"`
+}
+
+// DefaultMapCalibration is the world area covered by the original map tiles.
+func DefaultMapCalibration(version string) database.MapCalibration {
+	return database.MapCalibration{
+		Version: version,
+		MinX:    -999940,
+		MaxX:    447900,
+		MinY:    -738920,
+		MaxY:    708920,
+	}
+}
+
+// GetMapCalibration returns the stored calibration of the served map version,
+// or the default one.
+func GetMapCalibration(db *bbolt.DB) (database.MapCalibration, error) {
+	version := mapVersion()
+	calibration, err := service.GetMapCalibration(db, version)
+	if err == service.ErrNoRecord {
+		return DefaultMapCalibration(version), nil
+	}
+	return calibration, err
+}
+
+func ValidateMapCalibration(calibration database.MapCalibration) error {
+	if calibration.MaxX <= calibration.MinX || calibration.MaxY <= calibration.MinY {
+		return errors.New("max_x and max_y must be greater than min_x and min_y")
+	}
+	return nil
+}
+
+// ConvertMapPosition converts a "world", "latlng" or "pixel" position into all
+// three, latlng follows the frontend map where lat is in [-MapSize, 0] and lng
+// in [0, MapSize], pixels are counted from the top left at the given zoom.
+func ConvertMapPosition(calibration database.MapCalibration, from string, x, y float64, zoom int) (MapPosition, error) {
+	if err := ValidateMapCalibration(calibration); err != nil {
+		return MapPosition{}, err
+	}
+	if zoom < 0 || zoom > 30 {
+		return MapPosition{}, errors.New("invalid zoom")
+	}
+	scale := float64(int(1) << zoom)
+	var pos MapPosition
+	switch from {
+	case "world", "":
+		pos.WorldX, pos.WorldY = x, y
+		pos.Lat = -MapSize + MapSize*(x-calibration.MinX)/(calibration.MaxX-calibration.MinX)
+		pos.Lng = MapSize * (y - calibration.MinY) / (calibration.MaxY - calibration.MinY)
+	case "latlng":
+		pos.Lat, pos.Lng = x, y
+	case "pixel":
+		pos.Lat, pos.Lng = -y/scale, x/scale
+	default:
+		return MapPosition{}, fmt.Errorf("unknown coordinate type %s", from)
+	}
+	if from != "world" && from != "" {
+		pos.WorldX = (pos.Lat+MapSize)*(calibration.MaxX-calibration.MinX)/MapSize + calibration.MinX
+		pos.WorldY = pos.Lng*(calibration.MaxY-calibration.MinY)/MapSize + calibration.MinY
+	}
+	pos.Zoom = zoom
+	pos.PixelX = pos.Lng * scale
+	pos.PixelY = -pos.Lat * scale
+	return pos, nil
+}
+
+func mapVersion() string {
+	version := viper.GetString("map.version")
+	if version == "" {
+		return EmbeddedMapVersion
+	}
+	return version
+}
+
+func getTilesDir() (string, error) {
+	tilesDir := viper.GetString("map.tiles_dir")
+	if tilesDir == "" {
+		ed, err := system.GetExecDir()
+		if err != nil {
+			return "", err
+		}
+		tilesDir = filepath.Join(ed, "map")
+	}
+	return filepath.Join(tilesDir, mapVersion()), nil
+}
+
+// ReadTile returns the png of a tile for the configured map version. Tiles of
+// other versions are read from map.tiles_dir/<version> and, when map.tiles_url
+// is set, missing ones are downloaded once and cached there.
+func ReadTile(z, x, y int) ([]byte, error) {
+	if z < 0 || z > viper.GetInt("map.max_zoom") || x < 0 || y < 0 || x >= 1<<z || y >= 1<<z {
+		return nil, ErrTileNotFound
+	}
+	name := fmt.Sprintf("%d/%d/%d.png", z, x, y)
+
+	if mapVersion() == EmbeddedMapVersion {
+		if embeddedTiles == nil {
+			return nil, ErrTileNotFound
+		}
+		data, err := fs.ReadFile(embeddedTiles, name)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, ErrTileNotFound
+		}
+		return data, err
+	}
+
+	tilesDir, err := getTilesDir()
+	if err != nil {
+		return nil, err
+	}
+	tilePath := filepath.Join(tilesDir, filepath.FromSlash(name))
+	data, err := os.ReadFile(tilePath)
+	if err == nil {
+		return data, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
+	tilesUrl := viper.GetString("map.tiles_url")
+	if tilesUrl == "" {
+		return nil, ErrTileNotFound
+	}
+	data, err = downloadTile(tilesUrl, z, x, y)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeTile(tilePath, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func downloadTile(tilesUrl string, z, x, y int) ([]byte, error) {
+	url := strings.NewReplacer(
+		"{version}", mapVersion(),
+		"{z}", strconv.Itoa(z),
+		"{x}", strconv.Itoa(x),
+		"{y}", strconv.Itoa(y),
+	).Replace(tilesUrl)
+	resp, err := tileClient.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusForbidden {
+		return nil, ErrTileNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("download tile: %d %s", resp.StatusCode, url)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// writeTile goes through a temp file so concurrent requests never read a
+// partially written tile.
+func writeTile(tilePath string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(tilePath), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(tilePath), ".tile-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), tilePath)
+}
@@ -0,0 +1,358 @@
+package tool
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/zaigie/palworld-server-tool/internal/system"
+)
+
+const (
+	ModTypeUE4SS = "ue4ss"
+	ModTypePak   = "pak"
+)
+
+var (
+	ErrModNotFound       = errors.New("mod not found")
+	ErrModConfigNotFound = errors.New("mod config not found")
+)
+
+type Mod struct {
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	Enabled bool   `json:"enabled"`
+	// Path is relative to mods.server_dir
+	Path        string   `json:"path"`
+	ConfigFiles []string `json:"config_files"`
+}
+
+// UE4SS has moved its Mods directory between releases, pak mods are either
+// plain paks or blueprint LogicMods.
+var (
+	ue4ssModDirs = []string{
+		filepath.Join("Pal", "Binaries", "Win64", "ue4ss", "Mods"),
+		filepath.Join("Pal", "Binaries", "Win64", "Mods"),
+	}
+	pakModDirs = []string{
+		filepath.Join("Pal", "Content", "Paks", "~mods"),
+		filepath.Join("Pal", "Content", "Paks", "LogicMods"),
+	}
+)
+
+const disabledPakSuffix = ".disabled"
+
+var modConfigExts = map[string]bool{
+	".ini": true, ".json": true, ".cfg": true, ".toml": true,
+	".yaml": true, ".yml": true, ".txt": true,
+}
+
+func modServerDir() (string, error) {
+	dir := viper.GetString("mods.server_dir")
+	if dir == "" {
+		return "", errors.New("mods.server_dir is not configured")
+	}
+	return dir, nil
+}
+
+func ue4ssModsDir(serverDir string) (string, bool) {
+	for _, dir := range ue4ssModDirs {
+		path := filepath.Join(serverDir, dir)
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+// ListMods lists the UE4SS mods and pak mods installed in the server.
+func ListMods() ([]Mod, error) {
+	serverDir, err := modServerDir()
+	if err != nil {
+		return nil, err
+	}
+	mods := make([]Mod, 0)
+
+	if modsDir, ok := ue4ssModsDir(serverDir); ok {
+		modsTxt, err := readModsTxt(modsDir)
+		if err != nil {
+			return nil, err
+		}
+		entries, err := os.ReadDir(modsDir)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			// shared holds the lua libraries of UE4SS itself
+			if !entry.IsDir() || entry.Name() == "shared" {
+				continue
+			}
+			mod, err := ue4ssMod(serverDir, modsDir, entry.Name(), modsTxt)
+			if err != nil {
+				return nil, err
+			}
+			mods = append(mods, mod)
+		}
+	}
+
+	for _, dir := range pakModDirs {
+		entries, err := os.ReadDir(filepath.Join(serverDir, dir))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		for _, entry := range entries {
+			name := entry.Name()
+			if entry.IsDir() {
+				continue
+			}
+			enabled := strings.HasSuffix(name, ".pak")
+			if !enabled && !strings.HasSuffix(name, ".pak"+disabledPakSuffix) {
+				continue
+			}
+			mods = append(mods, Mod{
+				Name:        strings.TrimSuffix(name, disabledPakSuffix),
+				Type:        ModTypePak,
+				Enabled:     enabled,
+				Path:        filepath.ToSlash(filepath.Join(dir, name)),
+				ConfigFiles: []string{},
+			})
+		}
+	}
+	return mods, nil
+}
+
+func ue4ssMod(serverDir, modsDir, name string, modsTxt map[string]bool) (Mod, error) {
+	modDir := filepath.Join(modsDir, name)
+	rel, err := filepath.Rel(serverDir, modDir)
+	if err != nil {
+		return Mod{}, err
+	}
+	configFiles, err := modConfigFiles(modDir)
+	if err != nil {
+		return Mod{}, err
+	}
+	// enabled.txt in the mod directory overrides mods.txt
+	enabled := modsTxt[name]
+	if _, err := os.Stat(filepath.Join(modDir, "enabled.txt")); err == nil {
+		enabled = true
+	}
+	return Mod{
+		Name:        name,
+		Type:        ModTypeUE4SS,
+		Enabled:     enabled,
+		Path:        filepath.ToSlash(rel),
+		ConfigFiles: configFiles,
+	}, nil
+}
+
+// modConfigFiles finds the editable files of a mod, lua files only when they
+// look like config so scripts aren't edited by accident.
+func modConfigFiles(modDir string) ([]string, error) {
+	files := make([]string, 0)
+	err := filepath.WalkDir(modDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		name := strings.ToLower(d.Name())
+		ext := filepath.Ext(name)
+		if name == "enabled.txt" {
+			return nil
+		}
+		if modConfigExts[ext] || (ext == ".lua" && strings.Contains(name, "config")) {
+			rel, err := filepath.Rel(modDir, path)
+			if err != nil {
+				return err
+			}
+			files = append(files, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	sort.Strings(files)
+	return files, err
+}
+
+// readModsTxt parses the "Name : 1" lines of UE4SS mods.txt.
+func readModsTxt(modsDir string) (map[string]bool, error) {
+	mods := make(map[string]bool)
+	f, err := os.Open(filepath.Join(modsDir, "mods.txt"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return mods, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, ";") {
+			continue
+		}
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		mods[strings.TrimSpace(name)] = strings.TrimSpace(value) == "1"
+	}
+	return mods, scanner.Err()
+}
+
+// writeModsTxt sets a mod in mods.txt, keeping the other lines as they are.
+func writeModsTxt(modsDir, name string, enabled bool) error {
+	path := filepath.Join(modsDir, "mods.txt")
+	value := "0"
+	if enabled {
+		value = "1"
+	}
+	var lines []string
+	b, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	found := false
+	if len(b) > 0 {
+		if _, err := backupModFile("ue4ss", "mods.txt", b); err != nil {
+			return err
+		}
+		for _, line := range strings.Split(strings.TrimRight(string(b), "\r\n"), "\n") {
+			if n, _, ok := strings.Cut(line, ":"); ok && strings.TrimSpace(n) == name {
+				line = fmt.Sprintf("%s : %s", name, value)
+				found = true
+			}
+			lines = append(lines, strings.TrimRight(line, "\r"))
+		}
+	}
+	if !found {
+		lines = append(lines, fmt.Sprintf("%s : %s", name, value))
+	}
+	return os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644)
+}
+
+func findMod(modType, name string) (Mod, error) {
+	mods, err := ListMods()
+	if err != nil {
+		return Mod{}, err
+	}
+	for _, mod := range mods {
+		if mod.Type == modType && mod.Name == name {
+			return mod, nil
+		}
+	}
+	return Mod{}, ErrModNotFound
+}
+
+// SetModEnabled enables or disables a mod, UE4SS mods through mods.txt and
+// enabled.txt, pak mods by renaming them to and from .pak.disabled. The server
+// has to restart to pick it up.
+func SetModEnabled(modType, name string, enabled bool) (Mod, error) {
+	mod, err := findMod(modType, name)
+	if err != nil {
+		return Mod{}, err
+	}
+	serverDir, err := modServerDir()
+	if err != nil {
+		return Mod{}, err
+	}
+	path := filepath.Join(serverDir, filepath.FromSlash(mod.Path))
+
+	switch modType {
+	case ModTypeUE4SS:
+		if err := writeModsTxt(filepath.Dir(path), name, enabled); err != nil {
+			return Mod{}, err
+		}
+		if !enabled {
+			if err := os.Remove(filepath.Join(path, "enabled.txt")); err != nil && !os.IsNotExist(err) {
+				return Mod{}, err
+			}
+		}
+	case ModTypePak:
+		if mod.Enabled != enabled {
+			target := strings.TrimSuffix(path, disabledPakSuffix)
+			if !enabled {
+				target = path + disabledPakSuffix
+			}
+			if err := os.Rename(path, target); err != nil {
+				return Mod{}, err
+			}
+		}
+	}
+	return findMod(modType, name)
+}
+
+func modConfigPath(modType, name, file string) (string, error) {
+	mod, err := findMod(modType, name)
+	if err != nil {
+		return "", err
+	}
+	for _, f := range mod.ConfigFiles {
+		if f == file {
+			serverDir, err := modServerDir()
+			if err != nil {
+				return "", err
+			}
+			return filepath.Join(serverDir, filepath.FromSlash(mod.Path), filepath.FromSlash(f)), nil
+		}
+	}
+	return "", ErrModConfigNotFound
+}
+
+func ReadModConfig(modType, name, file string) ([]byte, error) {
+	path, err := modConfigPath(modType, name, file)
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadFile(path)
+}
+
+// WriteModConfig replaces a config file of a mod after copying the old one to
+// backups/mods/<name>/, it returns where the copy went.
+func WriteModConfig(modType, name, file string, content []byte) (string, error) {
+	path, err := modConfigPath(modType, name, file)
+	if err != nil {
+		return "", err
+	}
+	old, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	backupPath, err := backupModFile(name, file, old)
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		return "", err
+	}
+	return backupPath, nil
+}
+
+// backupModFile copies content to backups/mods/<dir>/ under a timestamped
+// name and returns the path.
+func backupModFile(dir, file string, content []byte) (string, error) {
+	backupDir, err := GetBackupDir()
+	if err != nil {
+		return "", err
+	}
+	dir = filepath.Join(backupDir, "mods", dir)
+	if err := system.CheckAndCreateDir(dir); err != nil {
+		return "", err
+	}
+	backupName := fmt.Sprintf("%s-%s", time.Now().Format("2006-01-02-15-04-05"), strings.ReplaceAll(file, "/", "_"))
+	backupPath := filepath.Join(dir, backupName)
+	if err := os.WriteFile(backupPath, content, 0644); err != nil {
+		return "", err
+	}
+	return backupPath, nil
+}
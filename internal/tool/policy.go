@@ -0,0 +1,151 @@
+package tool
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/zaigie/palworld-server-tool/internal/logger"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Requests to the game server are classed by how long they may take and
+// whether sending them twice is harmless, each class has its own timeout,
+// retries and backoff in request_policy.
+const (
+	ClassQuery    = "query"
+	ClassAction   = "action"
+	ClassSave     = "save"
+	ClassShutdown = "shutdown"
+)
+
+var requestClasses = []string{ClassQuery, ClassAction, ClassSave, ClassShutdown}
+
+type RequestPolicy struct {
+	// Timeout is in seconds, 0 keeps the one of the endpoint
+	Timeout int `json:"timeout" mapstructure:"timeout"`
+	Retries int `json:"retries" mapstructure:"retries"`
+	// Backoff is the seconds before the first retry, doubled for each next
+	Backoff float64 `json:"backoff" mapstructure:"backoff"`
+}
+
+func policyOf(class string) RequestPolicy {
+	var policy RequestPolicy
+	if err := viper.UnmarshalKey("request_policy."+class, &policy); err != nil {
+		logger.Warnf("Invalid request_policy.%s: %v\n", class, err)
+	}
+	return policy
+}
+
+// restClass is the class of a REST API call, GETs are queries.
+func restClass(method, api string) string {
+	switch {
+	case api == "/v1/api/save":
+		return ClassSave
+	case api == "/v1/api/shutdown" || api == "/v1/api/stop":
+		return ClassShutdown
+	case method == http.MethodGet:
+		return ClassQuery
+	}
+	return ClassAction
+}
+
+// rconClass is the class of an RCON command by its name.
+func rconClass(command string) string {
+	name, _, _ := strings.Cut(command, " ")
+	switch strings.ToLower(name) {
+	case "save":
+		return ClassSave
+	case "shutdown", "doexit":
+		return ClassShutdown
+	case "info", "showplayers":
+		return ClassQuery
+	}
+	return ClassAction
+}
+
+// isRetryable is true for errors of the connection, a refused or timed out
+// request, but not the game server answering with an error.
+func isRetryable(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, context.DeadlineExceeded)
+}
+
+type RequestStats struct {
+	Class    string        `json:"class"`
+	Policy   RequestPolicy `json:"policy"`
+	Requests int64         `json:"requests"`
+	// Retries are the requests sent again after a failure
+	Retries  int64 `json:"retries"`
+	Failures int64 `json:"failures"`
+}
+
+var requestStats = struct {
+	sync.Mutex
+	byClass map[string]*RequestStats
+}{byClass: make(map[string]*RequestStats)}
+
+func countRequest(class string, retries int, failed bool) {
+	requestStats.Lock()
+	defer requestStats.Unlock()
+	stats := requestStats.byClass[class]
+	if stats == nil {
+		stats = &RequestStats{Class: class}
+		requestStats.byClass[class] = stats
+	}
+	stats.Requests++
+	stats.Retries += int64(retries)
+	if failed {
+		stats.Failures++
+	}
+}
+
+// RequestStatistics returns the policy and the requests, retries and
+// failures since pst started by class.
+func RequestStatistics() []RequestStats {
+	requestStats.Lock()
+	defer requestStats.Unlock()
+	stats := make([]RequestStats, 0, len(requestClasses))
+	for _, class := range requestClasses {
+		s := RequestStats{Class: class}
+		if counted := requestStats.byClass[class]; counted != nil {
+			s = *counted
+		}
+		s.Policy = policyOf(class)
+		stats = append(stats, s)
+	}
+	return stats
+}
+
+// withRetry calls fn with the timeout of the class, again after the backoff
+// while it fails with a retryable error and retries are left. The retries
+// are logged, counted and set on the span.
+func withRetry(ctx context.Context, span trace.Span, class, name string, fn func(timeout int) error) error {
+	policy := policyOf(class)
+	backoff := time.Duration(policy.Backoff * float64(time.Second))
+	var err error
+	retries := 0
+	for {
+		if err = fn(policy.Timeout); err == nil || retries >= policy.Retries || !isRetryable(err) {
+			break
+		}
+		retries++
+		logger.Warnf("%s failed, retry %d of %d in %s: %v\n", name, retries, policy.Retries, backoff, err)
+		select {
+		case <-ctx.Done():
+			countRequest(class, retries, true)
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+	span.SetAttributes(attribute.String("request.class", class), attribute.Int("request.retries", retries))
+	countRequest(class, retries, err != nil)
+	return err
+}
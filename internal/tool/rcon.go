@@ -1,20 +1,31 @@
 package tool
 
 import (
+	"context"
 	"encoding/base64"
+	"strings"
 
 	"github.com/spf13/viper"
 	"github.com/zaigie/palworld-server-tool/internal/executor"
 	"github.com/zaigie/palworld-server-tool/internal/logger"
+	"github.com/zaigie/palworld-server-tool/internal/tracing"
 )
 
-func executeCommand(command string) (*executor.Executor, string, error) {
+// executeCommand sends the command with the timeout, 0 is the one of the
+// endpoint. The connection is returned to be closed by the caller.
+func executeCommand(command string, timeout int) (*executor.Executor, string, error) {
 	useBase64 := viper.GetBool("rcon.use_base64")
 
-	exec, err := executor.NewExecutor(
-		viper.GetString("rcon.address"),
-		viper.GetString("rcon.password"),
-		viper.GetInt("rcon.timeout"), true)
+	var exec *executor.Executor
+	err := withEndpoint("rcon", func(e Endpoint) (bool, error) {
+		t := e.Timeout
+		if timeout > 0 {
+			t = timeout
+		}
+		var err error
+		exec, err = executor.NewExecutor(e.Address, viper.GetString("rcon.password"), t, true)
+		return !isUnreachable(err), err
+	})
 	if err != nil {
 		return nil, "", err
 	}
@@ -25,6 +36,7 @@ func executeCommand(command string) (*executor.Executor, string, error) {
 
 	response, err := exec.Execute(command)
 	if err != nil {
+		exec.Close()
 		return nil, "", err
 	}
 
@@ -40,12 +52,20 @@ func executeCommand(command string) (*executor.Executor, string, error) {
 	return exec, response, nil
 }
 
-func CustomCommand(command string) (string, error) {
-	exec, response, err := executeCommand(command)
-	if err != nil {
-		return "", err
-	}
-	defer exec.Close()
+func CustomCommand(ctx context.Context, command string) (response string, err error) {
+	// the arguments may hold secrets, only the command name is recorded
+	name, _, _ := strings.Cut(command, " ")
+	ctx, span := tracing.StartClient(ctx, "rcon "+name)
+	defer func() { tracing.End(span, err) }()
 
-	return response, nil
+	err = withRetry(ctx, span, rconClass(command), "rcon "+name, func(timeout int) error {
+		exec, r, err := executeCommand(command, timeout)
+		if err != nil {
+			return err
+		}
+		exec.Close()
+		response = r
+		return nil
+	})
+	return response, err
 }
@@ -2,12 +2,12 @@ package tool
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
-	"strconv"
 	"strings"
 	"time"
 
@@ -15,33 +15,55 @@ import (
 
 	"github.com/spf13/viper"
 	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/internal/tracing"
+	"github.com/zaigie/palworld-server-tool/service"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
 )
 
 var client = &http.Client{}
 
-func callApi(method string, api string, param []byte) ([]byte, error) {
+func callApi(ctx context.Context, method string, api string, param []byte) (b []byte, err error) {
+	ctx, span := tracing.StartClient(ctx, "rest "+api, attribute.String("http.request.method", method))
+	defer func() { tracing.End(span, err) }()
 
-	addr := viper.GetString("rest.address")
 	user := viper.GetString("rest.username")
 	pass := viper.GetString("rest.password")
-	timeout := viper.GetInt("rest.timeout")
 
-	api, err := url.JoinPath(addr, api)
-	if err != nil {
-		return nil, err
-	}
-
-	req, _ := http.NewRequest(method, api, bytes.NewReader(param))
-	req.SetBasicAuth(user, pass)
-
-	client.Timeout = time.Duration(timeout) * time.Second
-	resp, err := client.Do(req)
+	var resp *http.Response
+	cancel := func() {}
+	defer func() { cancel() }()
+	err = withRetry(ctx, span, restClass(method, api), "rest "+api, func(classTimeout int) error {
+		return withEndpoint("rest", func(e Endpoint) (bool, error) {
+			u, err := url.JoinPath(e.Address, api)
+			if err != nil {
+				return true, err
+			}
+			timeout := e.Timeout
+			if classTimeout > 0 {
+				timeout = classTimeout
+			}
+			reqCtx := ctx
+			if timeout > 0 {
+				// the body is read after this returns, so the timeout is only
+				// cancelled when callApi returns or the next endpoint is tried
+				cancel()
+				reqCtx, cancel = context.WithTimeout(ctx, time.Duration(timeout)*time.Second)
+			}
+			req, _ := http.NewRequestWithContext(reqCtx, method, u, bytes.NewReader(param))
+			req.SetBasicAuth(user, pass)
+			tracing.Inject(ctx, propagation.HeaderCarrier(req.Header))
+			resp, err = client.Do(req)
+			return !isUnreachable(err), err
+		})
+	})
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
-	b, err := io.ReadAll(resp.Body)
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+	b, err = io.ReadAll(resp.Body)
 	if err != nil {
 		return nil, err
 	}
@@ -57,8 +79,8 @@ type ResponseInfo struct {
 	Description string `json:"description"`
 }
 
-func Info() (map[string]string, error) {
-	resp, err := callApi("GET", "/v1/api/info", nil)
+func Info(ctx context.Context) (map[string]string, error) {
+	resp, err := callApi(ctx, "GET", "/v1/api/info", nil)
 	if err != nil {
 		return nil, err
 	}
@@ -83,8 +105,8 @@ type ResponseMetrics struct {
 	Days             int     `json:"days"`
 }
 
-func Metrics() (map[string]interface{}, error) {
-	resp, err := callApi("GET", "/v1/api/metrics", nil)
+func Metrics(ctx context.Context) (map[string]interface{}, error) {
+	resp, err := callApi(ctx, "GET", "/v1/api/metrics", nil)
 	if err != nil {
 		return nil, err
 	}
@@ -119,8 +141,8 @@ type ResponsePlayers struct {
 	Players []ResponsePlayer `json:"players"`
 }
 
-func ShowPlayers() ([]database.OnlinePlayer, error) {
-	resp, err := callApi("GET", "/v1/api/players", nil)
+func ShowPlayers(ctx context.Context) ([]database.OnlinePlayer, error) {
+	resp, err := callApi(ctx, "GET", "/v1/api/players", nil)
 	if err != nil {
 		return nil, err
 	}
@@ -134,13 +156,14 @@ func ShowPlayers() ([]database.OnlinePlayer, error) {
 		onlinePlayer := database.OnlinePlayer{
 			PlayerUid:  getPlayerUid(player.PlayerId),
 			SteamId:    getSteamId(player.UserId),
+			Platform:   service.PlatformOf(player.UserId),
 			Nickname:   player.Name,
 			Ip:         player.Ip,
 			Ping:       player.Ping,
 			LocationX:  player.LocationX,
 			LocationY:  player.LocationY,
 			Level:      int32(player.Level),
-			LastOnline: time.Now(),
+			LastOnline: time.Now().UTC(),
 		}
 		onlinePlayers = append(onlinePlayers, onlinePlayer)
 	}
@@ -155,59 +178,54 @@ func getSteamId(userId string) string {
 }
 
 func getPlayerUid(playerId string) string {
-	if len(playerId) < 8 {
+	uid := service.CanonicalPlayerUid(playerId)
+	if uid == "" {
 		logger.Errorf("Parse PlayerId fail: %s\n", playerId)
 		return ""
 	}
-	hexPart := playerId[:8]
-	decimalValue, err := strconv.ParseUint(hexPart, 16, 32)
-	if err != nil {
-		logger.Errorf("Parse PlayerId fail: %s\n", err)
-		return ""
-	}
-	return strconv.FormatUint(decimalValue, 10)
+	return uid
 }
 
 type RequestUserId struct {
 	UserId string `json:"userid"`
 }
 
-func KickPlayer(steamId string) error {
+func KickPlayer(ctx context.Context, steamId string) error {
 	b, err := json.Marshal(RequestUserId{
 		UserId: steamId,
 	})
 	if err != nil {
 		return err
 	}
-	_, err = callApi("POST", "/v1/api/kick", b)
+	_, err = callApi(ctx, "POST", "/v1/api/kick", b)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func BanPlayer(steamId string) error {
+func BanPlayer(ctx context.Context, steamId string) error {
 	b, err := json.Marshal(RequestUserId{
 		UserId: steamId,
 	})
 	if err != nil {
 		return err
 	}
-	_, err = callApi("POST", "/v1/api/ban", b)
+	_, err = callApi(ctx, "POST", "/v1/api/ban", b)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func UnBanPlayer(steamId string) error {
+func UnBanPlayer(ctx context.Context, steamId string) error {
 	b, err := json.Marshal(RequestUserId{
 		UserId: steamId,
 	})
 	if err != nil {
 		return err
 	}
-	_, err = callApi("POST", "/v1/api/unban", b)
+	_, err = callApi(ctx, "POST", "/v1/api/unban", b)
 	if err != nil {
 		return err
 	}
@@ -218,14 +236,14 @@ type RequestBroadcast struct {
 	Message string `json:"message"`
 }
 
-func Broadcast(message string) error {
+func Broadcast(ctx context.Context, message string) error {
 	b, err := json.Marshal(RequestBroadcast{
 		Message: message,
 	})
 	if err != nil {
 		return err
 	}
-	_, err = callApi("POST", "/v1/api/announce", b)
+	_, err = callApi(ctx, "POST", "/v1/api/announce", b)
 	if err != nil {
 		return err
 	}
@@ -237,7 +255,7 @@ type RequestShutdown struct {
 	Message  string `json:"message"`
 }
 
-func Shutdown(seconds int, message string) error {
+func Shutdown(ctx context.Context, seconds int, message string) error {
 	b, err := json.Marshal(RequestShutdown{
 		Waittime: seconds,
 		Message:  message,
@@ -245,15 +263,23 @@ func Shutdown(seconds int, message string) error {
 	if err != nil {
 		return err
 	}
-	_, err = callApi("POST", "/v1/api/shutdown", b)
+	_, err = callApi(ctx, "POST", "/v1/api/shutdown", b)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func Save(ctx context.Context) error {
+	_, err := callApi(ctx, "POST", "/v1/api/save", nil)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func DoExit() error {
-	_, err := callApi("POST", "/v1/api/stop", nil)
+func DoExit(ctx context.Context) error {
+	_, err := callApi(ctx, "POST", "/v1/api/stop", nil)
 	if err != nil {
 		return err
 	}
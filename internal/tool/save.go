@@ -1,23 +1,33 @@
 package tool
 
 import (
+	"archive/zip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/spf13/viper"
 	"github.com/zaigie/palworld-server-tool/internal/auth"
 	"github.com/zaigie/palworld-server-tool/internal/database"
 	"github.com/zaigie/palworld-server-tool/internal/logger"
+	"github.com/zaigie/palworld-server-tool/internal/paths"
 	"github.com/zaigie/palworld-server-tool/internal/source"
 	"github.com/zaigie/palworld-server-tool/internal/system"
+	"github.com/zaigie/palworld-server-tool/internal/tracing"
 	"github.com/zaigie/palworld-server-tool/service"
 	"go.etcd.io/bbolt"
+	"go.opentelemetry.io/otel/propagation"
 )
 
 type Sturcture struct {
@@ -25,7 +35,9 @@ type Sturcture struct {
 	Guilds  []database.Guild  `json:"guilds"`
 }
 
-func getSavCli() (string, error) {
+// SavCli returns the path of sav_cli, save.decode_path or the one next to
+// the pst executable.
+func SavCli() (string, error) {
 	savCliPath := viper.GetString("save.decode_path")
 	if savCliPath == "" || savCliPath == "/path/to/your/sav_cli" {
 		ed, err := system.GetExecDir()
@@ -44,18 +56,49 @@ func getSavCli() (string, error) {
 	return savCliPath, nil
 }
 
-func Decode(file string) error {
-	savCli, err := getSavCli()
+// Decode fetches the save and has sav_cli parse it and put the players and
+// guilds through the API, journaled as one sync batch.
+func Decode(ctx context.Context, db *bbolt.DB, file string) (err error) {
+	ctx, span := tracing.Start(ctx, "save.decode")
+	defer func() { tracing.End(span, err) }()
+
+	savCli, err := SavCli()
 	if err != nil {
 		return errors.New("error getting executable path: " + err.Error())
 	}
 
+	_, fetchSpan := tracing.Start(ctx, "save.fetch")
 	levelFilePath, err := getFromSource(file, "decode")
+	tracing.End(fetchSpan, err)
 	if err != nil {
 		return err
 	}
 	defer os.RemoveAll(filepath.Dir(levelFilePath))
 
+	batch := database.SyncBatch{Source: file}
+	if info, err := os.Stat(levelFilePath); err == nil {
+		batch.SaveTime = info.ModTime().UTC()
+	}
+	// sav_cli dates the save's times from Level.sav's, which a server clock
+	// ahead of pst's puts in the future
+	tolerance := time.Duration(viper.GetInt("save.clock_skew_tolerance")) * time.Second
+	if now := time.Now(); batch.SaveTime.Sub(now) > tolerance {
+		if err := os.Chtimes(levelFilePath, now, now); err != nil {
+			return err
+		}
+	}
+	if batch.SaveHash, err = fileHash(levelFilePath); err != nil {
+		return err
+	}
+	if batch, err = service.BeginSyncBatch(db, batch); err != nil {
+		return errors.New("error journaling sync: " + err.Error())
+	}
+	defer func() {
+		if _, ferr := service.FinishSyncBatch(db, batch.Id, err, viper.GetInt("save.journal_keep")); ferr != nil {
+			logger.Errorf("error journaling sync: %v\n", ferr)
+		}
+	}()
+
 	baseUrl := fmt.Sprintf("http://127.0.0.1:%d", viper.GetInt("web.port"))
 	if viper.GetBool("web.tls") && !strings.HasSuffix(baseUrl, "/") {
 		baseUrl = viper.GetString("web.public_url")
@@ -66,10 +109,19 @@ func Decode(file string) error {
 	if err != nil {
 		return errors.New("error generating token: " + err.Error())
 	}
-	execArgs := []string{"-f", levelFilePath, "--request", requestUrl, "--token", tokenString}
+	execArgs := []string{"-f", levelFilePath, "--request", requestUrl, "--token", tokenString, "--batch", strconv.FormatUint(batch.Id, 10)}
+	parseCtx, parseSpan := tracing.Start(ctx, "save.parse")
+	defer func() { tracing.End(parseSpan, err) }()
 	cmd := exec.Command(savCli, execArgs...)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
+	// TRACEPARENT is how a child process continues the trace
+	carrier := propagation.MapCarrier{}
+	tracing.Inject(parseCtx, carrier)
+	cmd.Env = os.Environ()
+	if traceparent := carrier.Get("traceparent"); traceparent != "" {
+		cmd.Env = append(cmd.Env, "TRACEPARENT="+traceparent)
+	}
 	err = cmd.Start()
 	if err != nil {
 		return errors.New("error starting command: " + err.Error())
@@ -82,73 +134,311 @@ func Decode(file string) error {
 	return nil
 }
 
-func Backup() (string, error) {
+// Backup zips the save into the backup directory, encrypted when a backup key
+// is configured. It returns the archive name and the id of the key.
+func Backup() (string, string, error) {
 	sourcePath := viper.GetString("save.path")
 
+	keyring, err := BackupKeyring()
+	if err != nil {
+		return "", "", err
+	}
+
 	levelFilePath, err := getFromSource(sourcePath, "backup")
 	if err != nil {
-		return "", err
+		return "", "", err
 	}
 	defer os.RemoveAll(filepath.Dir(levelFilePath))
 
 	backupDir, err := GetBackupDir()
 	if err != nil {
-		return "", fmt.Errorf("failed to get backup directory: %s", err)
+		return "", "", fmt.Errorf("failed to get backup directory: %s", err)
 	}
 
 	currentTime := time.Now().Format("2006-01-02-15-04-05")
 	backupZipFile := filepath.Join(backupDir, fmt.Sprintf("%s.zip", currentTime))
 	err = system.ZipDir(filepath.Dir(levelFilePath), backupZipFile)
 	if err != nil {
-		return "", fmt.Errorf("failed to create backup zip: %s", err)
+		return "", "", fmt.Errorf("failed to create backup zip: %s", err)
+	}
+	if keyring == nil {
+		return filepath.Base(backupZipFile), "", nil
+	}
+	name, err := sealBackupFile(keyring, filepath.Base(backupZipFile))
+	if err != nil {
+		os.Remove(backupZipFile)
+		return "", "", fmt.Errorf("failed to encrypt backup: %s", err)
 	}
-	return filepath.Base(backupZipFile), nil
+	return name, keyring.ActiveKeyId(), nil
 }
 
-func GetBackupDir() (string, error) {
-	wd, err := os.Getwd()
+// BackupChunks backs up the save into the chunk store, where the parts of
+// the files unchanged since an earlier backup aren't stored again. The
+// returned record has no id yet.
+func BackupChunks() (database.Backup, error) {
+	keyring, err := BackupKeyring()
 	if err != nil {
-		return "", err
+		return database.Backup{}, err
 	}
-	backDir := filepath.Join(wd, "backups")
-	if err = system.CheckAndCreateDir(backDir); err != nil {
-		return "", err
+	levelFilePath, err := getFromSource(viper.GetString("save.path"), "backup")
+	if err != nil {
+		return database.Backup{}, err
 	}
-	return backDir, nil
+	srcDir := filepath.Dir(levelFilePath)
+	defer os.RemoveAll(srcDir)
+
+	store, err := backupChunkStore()
+	if err != nil {
+		return database.Backup{}, err
+	}
+	backup := database.Backup{
+		Path:   time.Now().Format("2006-01-02-15-04-05") + ".zip",
+		Format: service.BackupFormatChunks,
+	}
+	if keyring != nil {
+		store.Keyring = keyring
+		backup.KeyId = keyring.ActiveKeyId()
+	}
+	h := sha256.New()
+	err = filepath.Walk(srcDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		relPath, err := filepath.Rel(srcDir, path)
+		if err != nil {
+			return err
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		chunks, added, err := store.Put(io.TeeReader(f, h))
+		if err != nil {
+			return err
+		}
+		backup.Files = append(backup.Files, database.BackupFile{
+			Name:    filepath.ToSlash(relPath),
+			Size:    info.Size(),
+			ModTime: info.ModTime(),
+			Chunks:  chunks,
+		})
+		backup.Size += info.Size()
+		backup.StoredSize += added
+		return nil
+	})
+	if err != nil {
+		return database.Backup{}, fmt.Errorf("failed to store backup chunks: %s", err)
+	}
+	backup.Checksum = hex.EncodeToString(h.Sum(nil))
+	return backup, nil
 }
 
-func CleanOldBackups(db *bbolt.DB, keepDays int) error {
+// WriteBackupZip writes a chunked backup to w as a zip archive.
+func WriteBackupZip(w io.Writer, backup database.Backup) error {
+	store, err := backupChunkStore()
+	if err != nil {
+		return err
+	}
+	if store.Keyring, err = BackupKeyring(); err != nil {
+		return err
+	}
+	archive := zip.NewWriter(w)
+	for _, file := range backup.Files {
+		writer, err := archive.CreateHeader(&zip.FileHeader{
+			Name:     file.Name,
+			Method:   zip.Deflate,
+			Modified: file.ModTime,
+		})
+		if err != nil {
+			return err
+		}
+		if err := store.WriteTo(writer, file.Chunks); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+// RemoveBackupFile removes the archive of a zip backup, the chunks of a
+// chunked one are left to CollectBackupChunks.
+func RemoveBackupFile(backup database.Backup) error {
+	if backup.Format == service.BackupFormatChunks {
+		return nil
+	}
 	backupDir, err := GetBackupDir()
 	if err != nil {
-		return fmt.Errorf("failed to get backup directory: %s", err)
+		return err
 	}
+	return os.Remove(filepath.Join(backupDir, backup.Path))
+}
 
+// CollectBackupChunks removes the chunks no backup uses any more. Chunks
+// written in the last hour are kept, they may belong to a backup not
+// recorded yet.
+func CollectBackupChunks(db *bbolt.DB) (int, int64, error) {
+	store, err := backupChunkStore()
+	if err != nil {
+		return 0, 0, err
+	}
+	backups, err := service.ListBackups(db, service.BackupFilter{Files: true})
+	if err != nil {
+		return 0, 0, err
+	}
+	keep := make(map[string]bool)
+	for _, backup := range backups {
+		for _, file := range backup.Files {
+			for _, chunk := range file.Chunks {
+				keep[chunk] = true
+			}
+		}
+	}
+	return store.Collect(keep, time.Hour)
+}
+
+func backupChunkStore() (system.ChunkStore, error) {
+	backupDir, err := GetBackupDir()
+	if err != nil {
+		return system.ChunkStore{}, err
+	}
+	return system.ChunkStore{Dir: filepath.Join(backupDir, "chunks")}, nil
+}
+
+func GetBackupDir() (string, error) {
+	backDir := paths.Backups()
+	if err := system.CheckAndCreateDir(backDir); err != nil {
+		return "", err
+	}
+	return backDir, nil
+}
+
+func CleanOldBackups(db *bbolt.DB, keepDays int) error {
 	deadline := time.Now().AddDate(0, 0, -keepDays)
 
-	backups, err := service.ListBackups(db, time.Time{}, time.Now())
+	backups, err := service.ListBackups(db, service.BackupFilter{EndTime: deadline, Season: "none"})
 	if err != nil {
 		return fmt.Errorf("failed to list backups: %s", err)
 	}
 
 	for _, backup := range backups {
-		if backup.SaveTime.Before(deadline) {
-			err = os.Remove(filepath.Join(backupDir, backup.Path))
-			if err != nil {
-				if !os.IsNotExist(err) {
-					logger.Errorf("failed to delete old backup file %s: %s", backup.Path, err)
-				}
+		err = RemoveBackupFile(backup)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				logger.Errorf("failed to delete old backup file %s: %s", backup.Path, err)
 			}
+		}
 
-			err = service.DeleteBackup(db, backup.BackupId)
-			if err != nil {
-				logger.Errorf("failed to delete backup record from database: %s", err)
-			}
+		err = service.DeleteBackup(db, backup.BackupId)
+		if err != nil {
+			logger.Errorf("failed to delete backup record from database: %s", err)
 		}
 	}
 
 	return nil
 }
 
+// BackupFileInfo returns the size and hex sha256 of a backup archive.
+func BackupFileInfo(name string) (int64, string, error) {
+	backupDir, err := GetBackupDir()
+	if err != nil {
+		return 0, "", err
+	}
+	return fileInfo(filepath.Join(backupDir, name))
+}
+
+// fileHash returns the hex sha256 of a file.
+func fileHash(name string) (string, error) {
+	_, sum, err := fileInfo(name)
+	return sum, err
+}
+
+func fileInfo(name string) (int64, string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return 0, "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	size, err := io.Copy(h, f)
+	if err != nil {
+		return 0, "", err
+	}
+	return size, hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// CatalogBackups fills in the size and checksum of records from before the
+// catalog kept them, and records archives in the backup directory that have
+// no record. It returns how many records were added or updated.
+func CatalogBackups(db *bbolt.DB) (int, error) {
+	if err := service.IndexBackups(db); err != nil {
+		return 0, err
+	}
+	backupDir, err := GetBackupDir()
+	if err != nil {
+		return 0, err
+	}
+	backups, err := service.ListBackups(db, service.BackupFilter{})
+	if err != nil {
+		return 0, err
+	}
+	known := make(map[string]bool, len(backups))
+	changed := 0
+	for _, backup := range backups {
+		known[backup.Path] = true
+		if backup.Checksum != "" {
+			continue
+		}
+		if backup.Size, backup.Checksum, err = BackupFileInfo(backup.Path); err != nil {
+			continue
+		}
+		if err := service.AddBackup(db, backup); err != nil {
+			return changed, err
+		}
+		changed++
+	}
+	files, err := filepath.Glob(filepath.Join(backupDir, "*.zip"))
+	if err != nil {
+		return changed, err
+	}
+	sealed, err := filepath.Glob(filepath.Join(backupDir, "*.zip"+encryptedSuffix))
+	if err != nil {
+		return changed, err
+	}
+	files = append(files, sealed...)
+	for _, file := range files {
+		name := filepath.Base(file)
+		if known[name] {
+			continue
+		}
+		backup := database.Backup{
+			BackupId: uuid.New().String(),
+			Path:     name,
+			Reason:   service.BackupReasonImport,
+		}
+		if strings.HasSuffix(name, encryptedSuffix) {
+			if f, err := os.Open(file); err == nil {
+				backup.KeyId, _ = system.SealedKeyId(f)
+				f.Close()
+			}
+		}
+		if backup.Size, backup.Checksum, err = BackupFileInfo(name); err != nil {
+			logger.Warnf("failed to read backup %s: %v\n", name, err)
+			continue
+		}
+		backup.SaveTime, err = time.ParseInLocation("2006-01-02-15-04-05", strings.TrimSuffix(strings.TrimSuffix(name, encryptedSuffix), ".zip"), time.Local)
+		if err != nil {
+			if info, err := os.Stat(file); err == nil {
+				backup.SaveTime = info.ModTime()
+			}
+		}
+		if err := service.AddBackup(db, backup); err != nil {
+			return changed, err
+		}
+		changed++
+	}
+	return changed, nil
+}
+
 func getFromSource(file, way string) (string, error) {
 	var levelFilePath string
 	var err error
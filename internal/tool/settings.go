@@ -0,0 +1,354 @@
+package tool
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/zaigie/palworld-server-tool/internal/system"
+)
+
+const optionSettingsPrefix = "OptionSettings=("
+
+type settingKind int
+
+const (
+	settingFloat settingKind = iota
+	settingInt
+	settingBool
+	settingString
+	settingEnum
+)
+
+type settingSpec struct {
+	kind    settingKind
+	options []string
+}
+
+// settingSpecs describes the value types of well-known PalWorldSettings keys,
+// keys missing here are accepted as long as they don't break the ini syntax.
+var settingSpecs = map[string]settingSpec{
+	"Difficulty":                           {kind: settingEnum, options: []string{"None", "Casual", "Normal", "Hard"}},
+	"DeathPenalty":                         {kind: settingEnum, options: []string{"None", "Item", "ItemAndEquipment", "All"}},
+	"DayTimeSpeedRate":                     {kind: settingFloat},
+	"NightTimeSpeedRate":                   {kind: settingFloat},
+	"ExpRate":                              {kind: settingFloat},
+	"PalCaptureRate":                       {kind: settingFloat},
+	"PalSpawnNumRate":                      {kind: settingFloat},
+	"PalDamageRateAttack":                  {kind: settingFloat},
+	"PalDamageRateDefense":                 {kind: settingFloat},
+	"PlayerDamageRateAttack":               {kind: settingFloat},
+	"PlayerDamageRateDefense":              {kind: settingFloat},
+	"PlayerStomachDecreaceRate":            {kind: settingFloat},
+	"PlayerStaminaDecreaceRate":            {kind: settingFloat},
+	"PlayerAutoHPRegeneRate":               {kind: settingFloat},
+	"PlayerAutoHpRegeneRateInSleep":        {kind: settingFloat},
+	"PalStomachDecreaceRate":               {kind: settingFloat},
+	"PalStaminaDecreaceRate":               {kind: settingFloat},
+	"PalAutoHPRegeneRate":                  {kind: settingFloat},
+	"PalAutoHpRegeneRateInSleep":           {kind: settingFloat},
+	"BuildObjectDamageRate":                {kind: settingFloat},
+	"BuildObjectDeteriorationDamageRate":   {kind: settingFloat},
+	"CollectionDropRate":                   {kind: settingFloat},
+	"CollectionObjectHpRate":               {kind: settingFloat},
+	"CollectionObjectRespawnSpeedRate":     {kind: settingFloat},
+	"EnemyDropItemRate":                    {kind: settingFloat},
+	"DropItemAliveMaxHours":                {kind: settingFloat},
+	"AutoResetGuildTimeNoOnlinePlayers":    {kind: settingFloat},
+	"PalEggDefaultHatchingTime":            {kind: settingFloat},
+	"WorkSpeedRate":                        {kind: settingFloat},
+	"DropItemMaxNum":                       {kind: settingInt},
+	"DropItemMaxNum_UNKO":                  {kind: settingInt},
+	"BaseCampMaxNum":                       {kind: settingInt},
+	"BaseCampWorkerMaxNum":                 {kind: settingInt},
+	"GuildPlayerMaxNum":                    {kind: settingInt},
+	"CoopPlayerMaxNum":                     {kind: settingInt},
+	"ServerPlayerMaxNum":                   {kind: settingInt},
+	"PublicPort":                           {kind: settingInt},
+	"RCONPort":                             {kind: settingInt},
+	"RESTAPIPort":                          {kind: settingInt},
+	"bEnablePlayerToPlayerDamage":          {kind: settingBool},
+	"bEnableFriendlyFire":                  {kind: settingBool},
+	"bEnableInvaderEnemy":                  {kind: settingBool},
+	"bActiveUNKO":                          {kind: settingBool},
+	"bEnableAimAssistPad":                  {kind: settingBool},
+	"bEnableAimAssistKeyboard":             {kind: settingBool},
+	"bAutoResetGuildNoOnlinePlayers":       {kind: settingBool},
+	"bIsMultiplay":                         {kind: settingBool},
+	"bIsPvP":                               {kind: settingBool},
+	"bCanPickupOtherGuildDeathPenaltyDrop": {kind: settingBool},
+	"bEnableNonLoginPenalty":               {kind: settingBool},
+	"bEnableFastTravel":                    {kind: settingBool},
+	"bIsStartLocationSelectByMap":          {kind: settingBool},
+	"bExistPlayerAfterLogout":              {kind: settingBool},
+	"bEnableDefenseOtherGuildPlayer":       {kind: settingBool},
+	"RCONEnabled":                          {kind: settingBool},
+	"RESTAPIEnabled":                       {kind: settingBool},
+	"bUseAuth":                             {kind: settingBool},
+	"bShowPlayerList":                      {kind: settingBool},
+	"ServerName":                           {kind: settingString},
+	"ServerDescription":                    {kind: settingString},
+	"AdminPassword":                        {kind: settingString},
+	"ServerPassword":                       {kind: settingString},
+	"PublicIP":                             {kind: settingString},
+	"Region":                               {kind: settingString},
+	"BanListURL":                           {kind: settingString},
+}
+
+type SettingOption struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// GetSettingsPath returns the PalWorldSettings.ini path, either configured by
+// server.settings_path or found under a local save.path.
+func GetSettingsPath() (string, error) {
+	settingsPath := viper.GetString("server.settings_path")
+	if settingsPath != "" {
+		return settingsPath, nil
+	}
+	savePath := viper.GetString("save.path")
+	for _, platform := range []string{"LinuxServer", "WindowsServer"} {
+		p := filepath.Join(savePath, "Config", platform, "PalWorldSettings.ini")
+		if _, err := os.Stat(p); err == nil {
+			return p, nil
+		}
+	}
+	return "", errors.New("PalWorldSettings.ini not found, please set server.settings_path")
+}
+
+// ReadSettings reads the OptionSettings entries of PalWorldSettings.ini in file order.
+func ReadSettings() ([]SettingOption, error) {
+	settingsPath, err := GetSettingsPath()
+	if err != nil {
+		return nil, err
+	}
+	content, err := os.ReadFile(settingsPath)
+	if err != nil {
+		return nil, err
+	}
+	start, end, err := locateOptionSettings(string(content))
+	if err != nil {
+		return nil, err
+	}
+	return parseOptionSettings(string(content)[start:end]), nil
+}
+
+// UpdateSettings writes overrides into PalWorldSettings.ini and returns the
+// previous values of overridden keys, an empty previous value means the key
+// did not exist. An empty override value removes the key.
+func UpdateSettings(overrides map[string]string) (map[string]string, error) {
+	settingsPath, err := GetSettingsPath()
+	if err != nil {
+		return nil, err
+	}
+	raw, err := os.ReadFile(settingsPath)
+	if err != nil {
+		return nil, err
+	}
+	content := string(raw)
+	start, end, err := locateOptionSettings(content)
+	if err != nil {
+		return nil, err
+	}
+	options := parseOptionSettings(content[start:end])
+
+	previous := make(map[string]string, len(overrides))
+	for key := range overrides {
+		previous[key] = ""
+	}
+	updated := make([]SettingOption, 0, len(options)+len(overrides))
+	seen := make(map[string]bool, len(overrides))
+	for _, option := range options {
+		value, ok := overrides[option.Key]
+		if !ok {
+			updated = append(updated, option)
+			continue
+		}
+		previous[option.Key] = option.Value
+		seen[option.Key] = true
+		if value != "" {
+			updated = append(updated, SettingOption{Key: option.Key, Value: value})
+		}
+	}
+	for key, value := range overrides {
+		if !seen[key] && value != "" {
+			updated = append(updated, SettingOption{Key: key, Value: value})
+		}
+	}
+
+	parts := make([]string, 0, len(updated))
+	for _, option := range updated {
+		parts = append(parts, option.Key+"="+option.Value)
+	}
+	content = content[:start] + strings.Join(parts, ",") + content[end:]
+	if err := os.WriteFile(settingsPath, []byte(content), 0644); err != nil {
+		return nil, err
+	}
+	return previous, nil
+}
+
+// ValidateSettings checks values against the known setting types and makes
+// sure none of them would corrupt the OptionSettings line. Empty values are
+// accepted, UpdateSettings removes their keys.
+func ValidateSettings(values map[string]string) error {
+	for key, value := range values {
+		if key == "" || strings.ContainsAny(key, "=,()\" \n") {
+			return fmt.Errorf("invalid setting key: %q", key)
+		}
+		// an empty value removes the key, there is nothing to check
+		if value == "" {
+			continue
+		}
+		if strings.Contains(value, "\n") {
+			return fmt.Errorf("setting %s cannot contain line breaks", key)
+		}
+		spec, ok := settingSpecs[key]
+		if !ok {
+			if options := parseOptionSettings(key + "=" + value); len(options) != 1 {
+				return fmt.Errorf("setting %s has an invalid value: %s", key, value)
+			}
+			continue
+		}
+		switch spec.kind {
+		case settingFloat:
+			if _, err := strconv.ParseFloat(value, 64); err != nil {
+				return fmt.Errorf("setting %s must be a number", key)
+			}
+		case settingInt:
+			if _, err := strconv.Atoi(value); err != nil {
+				return fmt.Errorf("setting %s must be an integer", key)
+			}
+		case settingBool:
+			if value != "True" && value != "False" {
+				return fmt.Errorf("setting %s must be True or False", key)
+			}
+		case settingString:
+			if len(value) < 2 || !strings.HasPrefix(value, "\"") || !strings.HasSuffix(value, "\"") ||
+				strings.Contains(value[1:len(value)-1], "\"") {
+				return fmt.Errorf("setting %s must be a quoted string", key)
+			}
+		case settingEnum:
+			matched := false
+			for _, option := range spec.options {
+				if value == option {
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return fmt.Errorf("setting %s must be one of %s", key, strings.Join(spec.options, ", "))
+			}
+		}
+	}
+	return nil
+}
+
+// BackupSettings copies the current PalWorldSettings.ini into the settings
+// folder of the backup directory and returns the backup file name.
+func BackupSettings() (string, error) {
+	settingsPath, err := GetSettingsPath()
+	if err != nil {
+		return "", err
+	}
+	backupDir, err := GetBackupDir()
+	if err != nil {
+		return "", err
+	}
+	settingsBackupDir := filepath.Join(backupDir, "settings")
+	if err = system.CheckAndCreateDir(settingsBackupDir); err != nil {
+		return "", err
+	}
+	name := fmt.Sprintf("PalWorldSettings-%s.ini", time.Now().Format("2006-01-02-15-04-05"))
+	if err = system.CopyFile(settingsPath, filepath.Join(settingsBackupDir, name)); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// RestoreSettings copies the PalWorldSettings.ini backup name made by
+// BackupSettings over the settings file.
+func RestoreSettings(name string) error {
+	settingsPath, err := GetSettingsPath()
+	if err != nil {
+		return err
+	}
+	backupDir, err := GetBackupDir()
+	if err != nil {
+		return err
+	}
+	if name != filepath.Base(name) {
+		return fmt.Errorf("invalid settings backup %s", name)
+	}
+	return system.CopyFile(filepath.Join(backupDir, "settings", name), settingsPath)
+}
+
+// locateOptionSettings returns the bounds of the text inside OptionSettings=(...).
+func locateOptionSettings(content string) (int, int, error) {
+	idx := strings.Index(content, optionSettingsPrefix)
+	if idx < 0 {
+		return 0, 0, errors.New("OptionSettings not found in PalWorldSettings.ini")
+	}
+	start := idx + len(optionSettingsPrefix)
+	depth := 1
+	inQuote := false
+	for i := start; i < len(content); i++ {
+		switch content[i] {
+		case '"':
+			inQuote = !inQuote
+		case '(':
+			if !inQuote {
+				depth++
+			}
+		case ')':
+			if !inQuote {
+				depth--
+				if depth == 0 {
+					return start, i, nil
+				}
+			}
+		}
+	}
+	return 0, 0, errors.New("OptionSettings is not closed in PalWorldSettings.ini")
+}
+
+func parseOptionSettings(s string) []SettingOption {
+	options := make([]SettingOption, 0)
+	depth := 0
+	inQuote := false
+	last := 0
+	flush := func(item string) {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			return
+		}
+		key, value, _ := strings.Cut(item, "=")
+		options = append(options, SettingOption{Key: strings.TrimSpace(key), Value: strings.TrimSpace(value)})
+	}
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '"':
+			inQuote = !inQuote
+		case '(':
+			if !inQuote {
+				depth++
+			}
+		case ')':
+			if !inQuote {
+				depth--
+			}
+		case ',':
+			if !inQuote && depth == 0 {
+				flush(s[last:i])
+				last = i + 1
+			}
+		}
+	}
+	flush(s[last:])
+	return options
+}
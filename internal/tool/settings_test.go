@@ -0,0 +1,38 @@
+package tool
+
+import "testing"
+
+func TestValidateSettings(t *testing.T) {
+	tests := []struct {
+		name   string
+		values map[string]string
+		ok     bool
+	}{
+		{"float", map[string]string{"ExpRate": "1.5"}, true},
+		{"not a float", map[string]string{"ExpRate": "fast"}, false},
+		{"int", map[string]string{"BaseCampMaxNum": "128"}, true},
+		{"not an int", map[string]string{"BaseCampMaxNum": "1.5"}, false},
+		{"bool", map[string]string{"bEnableFriendlyFire": "True"}, true},
+		{"lower case bool", map[string]string{"bEnableFriendlyFire": "true"}, false},
+		{"quoted string", map[string]string{"ServerName": `"My Server"`}, true},
+		{"unquoted string", map[string]string{"ServerName": "My Server"}, false},
+		{"quote inside string", map[string]string{"ServerName": `"My "Server"`}, false},
+		{"enum", map[string]string{"Difficulty": "Hard"}, true},
+		{"unknown enum value", map[string]string{"Difficulty": "Insane"}, false},
+		{"unknown key", map[string]string{"SomeModSetting": "3"}, true},
+		{"unknown key breaking the line", map[string]string{"SomeModSetting": "3,Other=4"}, false},
+		{"line break", map[string]string{"ExpRate": "1\n"}, false},
+		{"bad key", map[string]string{"Exp Rate": "1"}, false},
+		// an empty value removes the key whatever its kind
+		{"empty float", map[string]string{"ExpRate": ""}, true},
+		{"empty string", map[string]string{"ServerName": ""}, true},
+		{"empty unknown key", map[string]string{"SomeModSetting": ""}, true},
+		{"empty bad key", map[string]string{"Exp=Rate": ""}, false},
+	}
+	for _, tt := range tests {
+		err := ValidateSettings(tt.values)
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: got %v, want ok %v", tt.name, err, tt.ok)
+		}
+	}
+}
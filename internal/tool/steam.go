@@ -0,0 +1,67 @@
+package tool
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var steamClient = &http.Client{Timeout: 30 * time.Second}
+
+var buildIdPattern = regexp.MustCompile(`"buildid"\s+"(\d+)"`)
+
+// LatestBuildId asks infoUrl, a steamcmd.net style app info api where {app_id}
+// is replaced, for the build id of a branch.
+func LatestBuildId(infoUrl string, appId int, branch string) (string, error) {
+	url := strings.ReplaceAll(infoUrl, "{app_id}", strconv.Itoa(appId))
+	resp, err := steamClient.Get(url)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("%d %s", resp.StatusCode, body)
+	}
+	var info struct {
+		Data map[string]struct {
+			Depots struct {
+				Branches map[string]struct {
+					BuildId string `json:"buildid"`
+				} `json:"branches"`
+			} `json:"depots"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return "", err
+	}
+	buildId := info.Data[strconv.Itoa(appId)].Depots.Branches[branch].BuildId
+	if buildId == "" {
+		return "", fmt.Errorf("no build id of branch %s", branch)
+	}
+	return buildId, nil
+}
+
+// InstalledBuildId reads the build id from the appmanifest_<app_id>.acf that
+// steamcmd keeps in the steamapps directory of the server.
+func InstalledBuildId(manifestPath string) (string, error) {
+	if manifestPath == "" {
+		return "", errors.New("manifest path is not configured")
+	}
+	b, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return "", err
+	}
+	m := buildIdPattern.FindSubmatch(b)
+	if m == nil {
+		return "", errors.New("no buildid in manifest")
+	}
+	return string(m[1]), nil
+}
@@ -0,0 +1,125 @@
+// Package tracing records OpenTelemetry spans of requests, RCON and REST
+// calls, save parsing and task runs, exported over OTLP/HTTP so a slow
+// request can be followed across subsystems.
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+	"github.com/zaigie/palworld-server-tool/internal/logger"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentation = "github.com/zaigie/palworld-server-tool"
+
+var provider *sdktrace.TracerProvider
+
+// Init exports spans to trace.endpoint, sampling trace.sample_ratio of the
+// traces not started by a caller. Without an endpoint spans are dropped.
+func Init(version string) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	endpoint := viper.GetString("trace.endpoint")
+	if endpoint == "" {
+		return
+	}
+	exporter := &otlpExporter{
+		url:     endpoint,
+		headers: viper.GetStringMapString("trace.headers"),
+	}
+	provider = sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(viper.GetFloat64("trace.sample_ratio")))),
+		sdktrace.WithResource(resource.NewSchemaless(
+			semconv.ServiceName(viper.GetString("trace.service_name")),
+			semconv.ServiceVersion(version),
+		)),
+	)
+	otel.SetTracerProvider(provider)
+	logger.Infof("Tracing to %s\n", endpoint)
+}
+
+// Shutdown exports the spans still buffered.
+func Shutdown() {
+	if provider == nil {
+		return
+	}
+	if err := provider.Shutdown(context.Background()); err != nil {
+		logger.Errorf("Tracing shutdown fail: %v\n", err)
+	}
+}
+
+// Start starts a span under the span of ctx.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentation).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// StartClient starts a span of a call to another service.
+func StartClient(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentation).Start(ctx, name, trace.WithAttributes(attrs...), trace.WithSpanKind(trace.SpanKindClient))
+}
+
+// End ends the span, marking it failed when err isn't nil.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Do runs fn in a span under the span of ctx, for calls that don't take a
+// context like the service functions.
+func Do(ctx context.Context, name string, fn func() error) error {
+	_, span := Start(ctx, name)
+	err := fn()
+	End(span, err)
+	return err
+}
+
+// Inject sets the headers continuing the trace of ctx in another service.
+func Inject(ctx context.Context, carrier propagation.TextMapCarrier) {
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+}
+
+// Middleware starts a span for every request, continuing the trace of the
+// traceparent header. Handlers find it in the request context.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := otel.GetTextMapPropagator().Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		ctx, span := otel.Tracer(instrumentation).Start(ctx, fmt.Sprintf("%s %s", c.Request.Method, route),
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(c.Request.Method),
+				semconv.HTTPRoute(route),
+				semconv.URLPath(c.Request.URL.Path),
+				semconv.ClientAddress(c.ClientIP()),
+			))
+		defer span.End()
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		if status >= 500 {
+			span.SetStatus(codes.Error, fmt.Sprintf("status %d", status))
+		}
+		if len(c.Errors) > 0 {
+			span.RecordError(c.Errors.Last())
+		}
+	}
+}
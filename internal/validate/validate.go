@@ -0,0 +1,144 @@
+// Package validate checks the values that reach the database from requests
+// and syncs, collecting an error per field instead of stopping at the first.
+package validate
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// MaxNameLength is the most characters of a player, guild or entry name
+	MaxNameLength = 64
+	// MaxCoordinate bounds world coordinates, about twice the map size
+	MaxCoordinate = 2000000
+)
+
+// FieldError is a field refused and why, the field is a path like
+// [3].steam_id for the elements of a list.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Errors are the field errors of a value, returned as one error.
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	parts := make([]string, 0, len(e))
+	for _, err := range e {
+		parts = append(parts, err.Field+": "+err.Message)
+	}
+	return "invalid " + strings.Join(parts, "; ")
+}
+
+// Validator collects field errors, the zero value is ready to use.
+type Validator struct {
+	errs Errors
+}
+
+// Err returns the collected Errors, or nil when every field passed.
+func (v *Validator) Err() error {
+	if len(v.errs) == 0 {
+		return nil
+	}
+	return v.errs
+}
+
+func (v *Validator) Add(field, format string, args ...any) {
+	v.errs = append(v.errs, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *Validator) Required(field, value string) {
+	if strings.TrimSpace(value) == "" {
+		v.Add(field, "is required")
+	}
+}
+
+// PlayerUid checks a player uid in one of the forms the REST API and the
+// save use, decimal, 8 hex digits or a guid. Empty passes, use Required.
+func (v *Validator) PlayerUid(field, uid string) {
+	if uid != "" && !isPlayerUid(uid) {
+		v.Add(field, "is not a player uid")
+	}
+}
+
+// SteamId checks a SteamID64, which must be the id of an individual account
+// in the public universe like every player has. Empty passes.
+func (v *Validator) SteamId(field, steamId string) {
+	if steamId != "" && !IsSteamId(steamId) {
+		v.Add(field, "is not a SteamID64")
+	}
+}
+
+// Name checks a display name is utf-8 without control characters and at
+// most MaxNameLength characters. Empty passes.
+func (v *Validator) Name(field, name string) {
+	switch {
+	case !utf8.ValidString(name):
+		v.Add(field, "is not valid utf-8")
+	case utf8.RuneCountInString(name) > MaxNameLength:
+		v.Add(field, "is longer than %d characters", MaxNameLength)
+	case strings.IndexFunc(name, unicode.IsControl) >= 0:
+		v.Add(field, "has control characters")
+	}
+}
+
+// Coordinate checks a world coordinate is a number within MaxCoordinate.
+func (v *Validator) Coordinate(field string, value float64) {
+	if math.IsNaN(value) || math.Abs(value) > MaxCoordinate {
+		v.Add(field, "is out of the world, at most %d either way", MaxCoordinate)
+	}
+}
+
+// Index is the field of the i-th element of a list, prefix is empty for a
+// list sent as the whole body.
+func Index(prefix string, i int, field string) string {
+	return fmt.Sprintf("%s[%d].%s", prefix, i, field)
+}
+
+func isPlayerUid(uid string) bool {
+	if len(uid) <= 10 {
+		if _, err := strconv.ParseUint(uid, 10, 32); err == nil {
+			return true
+		}
+	}
+	hex := uid
+	if len(uid) == 36 {
+		for _, i := range []int{8, 13, 18, 23} {
+			if uid[i] != '-' {
+				return false
+			}
+		}
+		hex = strings.ReplaceAll(uid, "-", "")
+	}
+	if len(hex) != 8 && len(hex) != 32 {
+		return false
+	}
+	for _, r := range hex {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", r) {
+			return false
+		}
+	}
+	return true
+}
+
+// IsSteamId reports whether steamId is the SteamID64 of an individual
+// account in the public universe.
+func IsSteamId(steamId string) bool {
+	if len(steamId) != 17 {
+		return false
+	}
+	id, err := strconv.ParseUint(steamId, 10, 64)
+	if err != nil {
+		return false
+	}
+	universe := id >> 56
+	accountType := id >> 52 & 0xf
+	instance := id >> 32 & 0xfffff
+	return universe == 1 && accountType == 1 && instance == 1 && uint32(id) != 0
+}
@@ -0,0 +1,63 @@
+package validate
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestValidator(t *testing.T) {
+	tests := []struct {
+		name  string
+		check func(v *Validator)
+		ok    bool
+	}{
+		{"required", func(v *Validator) { v.Required("f", "x") }, true},
+		{"required blank", func(v *Validator) { v.Required("f", "  ") }, false},
+		{"decimal uid", func(v *Validator) { v.PlayerUid("f", "1234567890") }, true},
+		{"decimal uid over 32 bits", func(v *Validator) { v.PlayerUid("f", "4294967296") }, false},
+		{"hex uid", func(v *Validator) { v.PlayerUid("f", "0a1B2c3D") }, true},
+		{"guid uid", func(v *Validator) { v.PlayerUid("f", "0a1b2c3d-0000-0000-0000-000000000000") }, true},
+		{"undashed guid uid", func(v *Validator) { v.PlayerUid("f", "0a1b2c3d000000000000000000000000") }, true},
+		{"guid uid misplaced dash", func(v *Validator) { v.PlayerUid("f", "0a1b2c3-d0000-0000-0000-000000000000") }, false},
+		{"uid not hex", func(v *Validator) { v.PlayerUid("f", "0a1b2c3g") }, false},
+		{"empty uid", func(v *Validator) { v.PlayerUid("f", "") }, true},
+		{"steam id", func(v *Validator) { v.SteamId("f", "76561197960287930") }, true},
+		{"steam id zero account", func(v *Validator) { v.SteamId("f", "76561197960265728") }, false},
+		{"steam id other instance", func(v *Validator) { v.SteamId("f", "76561193665298433") }, false},
+		{"steam id short", func(v *Validator) { v.SteamId("f", "7656119796028793") }, false},
+		{"steam id not a number", func(v *Validator) { v.SteamId("f", "7656119796028793x") }, false},
+		{"empty steam id", func(v *Validator) { v.SteamId("f", "") }, true},
+		{"name", func(v *Validator) { v.Name("f", "Pal 帕鲁") }, true},
+		{"name at the limit", func(v *Validator) { v.Name("f", strings.Repeat("帕", MaxNameLength)) }, true},
+		{"name too long", func(v *Validator) { v.Name("f", strings.Repeat("a", MaxNameLength+1)) }, false},
+		{"name control character", func(v *Validator) { v.Name("f", "a\nb") }, false},
+		{"name not utf-8", func(v *Validator) { v.Name("f", "a\xffb") }, false},
+		{"coordinate", func(v *Validator) { v.Coordinate("f", -MaxCoordinate) }, true},
+		{"coordinate out of the world", func(v *Validator) { v.Coordinate("f", MaxCoordinate+1) }, false},
+		{"coordinate infinite", func(v *Validator) { v.Coordinate("f", math.Inf(1)) }, false},
+		{"coordinate NaN", func(v *Validator) { v.Coordinate("f", math.NaN()) }, false},
+	}
+	for _, tt := range tests {
+		var v Validator
+		tt.check(&v)
+		if err := v.Err(); (err == nil) != tt.ok {
+			t.Errorf("%s: got %v, want ok %v", tt.name, err, tt.ok)
+		}
+	}
+}
+
+func TestValidatorCollects(t *testing.T) {
+	var v Validator
+	v.Required(Index("", 0, "player_uid"), "")
+	v.SteamId(Index("", 1, "steam_id"), "1")
+	v.Name(Index(Index("", 2, "players"), 3, "nickname"), "a\tb")
+	err, ok := v.Err().(Errors)
+	if !ok || len(err) != 3 {
+		t.Fatalf("got %v, want 3 errors", v.Err())
+	}
+	want := "invalid [0].player_uid: is required; [1].steam_id: is not a SteamID64; [2].players[3].nickname: has control characters"
+	if err.Error() != want {
+		t.Errorf("got %q, want %q", err.Error(), want)
+	}
+}
@@ -5,42 +5,57 @@ import (
 	"flag"
 	"fmt"
 	"io/fs"
-	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"strconv"
 	"syscall"
+	// task.timezone works on hosts without a zoneinfo database
+	_ "time/tzdata"
 
 	"github.com/gin-gonic/gin"
 	"github.com/spf13/viper"
 	"github.com/zaigie/palworld-server-tool/api"
 	"github.com/zaigie/palworld-server-tool/docs"
+	"github.com/zaigie/palworld-server-tool/internal/bus"
+	"github.com/zaigie/palworld-server-tool/internal/cli"
 	"github.com/zaigie/palworld-server-tool/internal/config"
+	"github.com/zaigie/palworld-server-tool/internal/crash"
+	"github.com/zaigie/palworld-server-tool/internal/daemon"
 	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/internal/demo"
+	"github.com/zaigie/palworld-server-tool/internal/hook"
+	"github.com/zaigie/palworld-server-tool/internal/locale"
 	"github.com/zaigie/palworld-server-tool/internal/logger"
+	"github.com/zaigie/palworld-server-tool/internal/mqtt"
+	"github.com/zaigie/palworld-server-tool/internal/notify"
+	"github.com/zaigie/palworld-server-tool/internal/paths"
+	"github.com/zaigie/palworld-server-tool/internal/replica"
 	"github.com/zaigie/palworld-server-tool/internal/system"
 	"github.com/zaigie/palworld-server-tool/internal/task"
+	"github.com/zaigie/palworld-server-tool/internal/tool"
+	"github.com/zaigie/palworld-server-tool/internal/tracing"
+	"github.com/zaigie/palworld-server-tool/service"
 )
 
 var (
-	version string = "Develop"
-	cfgFile string
-	conf    config.Config
+	version  string = "Develop"
+	cfgFile  string
+	workDir  string
+	demoMode bool
+	conf     config.Config
 )
 
-//go:embed assets/*
-var assets embed.FS
-
-//go:embed index.html
-var indexHTML embed.FS
-
-//go:embed pal-conf.html
-var palConfHTML embed.FS
+//go:embed assets/* index.html pal-conf.html
+var ui embed.FS
 
 //go:embed map/*
 var mapTiles embed.FS
 
 func setupFlags() {
 	flag.StringVar(&cfgFile, "config", "", "config file")
+	flag.StringVar(&workDir, "dir", "", "working directory, set for services which start elsewhere")
+	flag.BoolVar(&demoMode, "demo", false, "run against a simulated server with generated players, for UI development and demos")
 	flag.Parse()
 }
 
@@ -51,11 +66,98 @@ func setupFlags() {
 // @license.name	Apache 2.0
 // @license.url	http://www.apache.org/licenses/LICENSE-2.0.html
 func main() {
-	db := database.GetDB()
-	defer db.Close()
+	if code, ok := cli.Run(os.Args[1:]); ok {
+		os.Exit(code)
+	}
 
 	setupFlags()
+	if workDir != "" {
+		if err := os.Chdir(workDir); err != nil {
+			logger.Panicf("Working directory: %v\n", err)
+		}
+	}
 	config.Init(cfgFile, &conf)
+	if demoMode {
+		if err := demo.Start(); err != nil {
+			logger.Panicf("Demo: %v\n", err)
+		}
+		defer demo.Stop()
+	}
+	var setupToken string
+	if config.SetupNeeded() {
+		setupToken = api.StartSetup()
+	} else {
+		if viper.GetString("web.password") == "" {
+			logger.Panicf("web.password is empty, it is the key login tokens are signed with, set one\n")
+		}
+		for _, problem := range config.Check() {
+			logger.Warnf("Config %s\n", problem)
+		}
+	}
+	if err := paths.Init(); err != nil {
+		logger.Panicf("Data directories: %v\n", err)
+	}
+	if dir := paths.Logs(); dir != "" {
+		if err := logger.SetFile(filepath.Join(dir, "pst.log")); err != nil {
+			logger.Panicf("Log file: %v\n", err)
+		}
+	}
+	tracing.Init(version)
+	crash.Version = version
+	gin.DefaultWriter = logger.Writer()
+	defer tracing.Shutdown()
+	if replica.Enabled() {
+		logger.Info("Running as a read-only replica\n")
+		replica.Start()
+	}
+	db := database.GetDB()
+	defer func() { database.GetDB().Close() }()
+
+	bus.Derive(service.AlertOf)
+	notify.Subscribe()
+	hook.Subscribe()
+	mqtt.Subscribe()
+
+	if !replica.Enabled() {
+		if n, err := service.NormalizePlayerUids(db); err != nil {
+			logger.Errorf("Normalize player uids fail: %v\n", err)
+		} else if n > 0 {
+			logger.Infof("Normalized %d records to canonical player uids\n", n)
+			task.RecordEvent(db, database.Event{
+				Type:    service.EventAdminMigration,
+				Message: fmt.Sprintf("Normalized %d records to canonical player uids", n),
+				Data:    map[string]string{"action": "normalize_uids", "by": "pst", "records": strconv.Itoa(n)},
+			})
+		}
+		if n, err := service.FailInterruptedSyncBatches(db); err != nil {
+			logger.Errorf("Check sync journal fail: %v\n", err)
+		} else if n > 0 {
+			logger.Warnf("%d save syncs were interrupted, see /api/sync/journal\n", n)
+		}
+		if n, err := tool.CatalogBackups(db); err != nil {
+			logger.Errorf("Catalog backups fail: %v\n", err)
+		} else if n > 0 {
+			logger.Infof("Cataloged %d backups\n", n)
+		}
+	}
+	if locales, err := service.ListLocales(db); err != nil {
+		logger.Errorf("Load locales fail: %v\n", err)
+	} else {
+		for lang, messages := range locales {
+			locale.Register(lang, messages)
+		}
+	}
+	if templates, err := service.ListTemplates(db); err != nil {
+		logger.Errorf("Load templates fail: %v\n", err)
+	} else {
+		for key, source := range templates {
+			locale.SetTemplate(key, source)
+		}
+	}
+	locale.RegisterFunc("player", func(playerUid string) database.TersePlayer {
+		player, _ := service.GetPlayer(database.GetDB(), playerUid)
+		return player.TersePlayer
+	})
 
 	docs.SwaggerInfo.Title = "Palworld Manage API"
 	docs.SwaggerInfo.Version = version
@@ -65,28 +167,22 @@ func main() {
 
 	gin.SetMode(gin.ReleaseMode)
 	router := gin.New()
+	// X-Forwarded-For is only believed from the configured proxies, anyone
+	// could dodge the rate limits with it otherwise
+	if err := router.SetTrustedProxies(viper.GetStringSlice("web.trusted_proxies")); err != nil {
+		logger.Errorf("Invalid web.trusted_proxies, trusting none: %v\n", err)
+		router.SetTrustedProxies(nil)
+	}
 	router.Use(func(c *gin.Context) {
 		c.Set("version", version)
 		c.Next()
 	})
 	api.RegisterRouter(router)
 
-	assetsFS, _ := fs.Sub(assets, "assets")
-	router.StaticFS("/assets", http.FS(assetsFS))
+	api.RegisterUI(router, ui, version)
 
 	mapTilesFS, _ := fs.Sub(mapTiles, "map")
-	router.StaticFS("/map/tiles", http.FS(mapTilesFS))
-
-	router.GET("/", func(c *gin.Context) {
-		c.Writer.WriteHeader(http.StatusOK)
-		file, _ := indexHTML.ReadFile("index.html")
-		c.Writer.Write(file)
-	})
-	router.GET("/pal-conf", func(c *gin.Context) {
-		c.Writer.WriteHeader(http.StatusOK)
-		file, _ := palConfHTML.ReadFile("pal-conf.html")
-		c.Writer.Write(file)
-	})
+	tool.SetEmbeddedTiles(mapTilesFS)
 
 	localIp, err := system.GetLocalIP()
 	if err != nil {
@@ -96,12 +192,25 @@ func main() {
 	logger.Infof("Version: %s\n", version)
 	logger.Infof("Listening on http://127.0.0.1:%d or http://%s:%d\n", viper.GetInt("web.port"), localIp, viper.GetInt("web.port"))
 	logger.Infof("Swagger on http://127.0.0.1:%d/swagger/index.html\n", viper.GetInt("web.port"))
+	if demoMode {
+		logger.Warnf("Demo mode, the players are generated and nothing reaches a game server, log in with password %s\n", viper.GetString("web.password"))
+	}
+	if config.SetupNeeded() {
+		logger.Warnf("No config found, post the settings to http://127.0.0.1:%d/api/setup with the header X-Setup-Token: %s or run pst setup, the tasks start after it\n", viper.GetInt("web.port"), setupToken)
+	}
 
-	go task.Schedule(db)
-	defer task.Shutdown()
+	if !replica.Enabled() {
+		go func() {
+			<-api.SetupDone()
+			task.Schedule(db)
+		}()
+		defer task.Shutdown()
+	}
 
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	daemon.Notify(sigChan)
+	daemon.HandleSignals()
 
 	go func() {
 		if viper.GetBool("web.tls") {
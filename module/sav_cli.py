@@ -7,7 +7,12 @@ import argparse
 from urllib.parse import urljoin
 import requests
 
-from structurer import convert_sav, structure_player, structure_guild
+from structurer import (
+    convert_sav,
+    structure_player,
+    structure_guild,
+    structure_map_objects,
+)
 from logger import log
 
 if __name__ == "__main__":
@@ -22,6 +27,9 @@ if __name__ == "__main__":
     )
     parser.add_argument("--request", "-r", help="Request", type=str, default="")
     parser.add_argument("--token", "-t", help="Request token", type=str, default="")
+    parser.add_argument(
+        "--batch", "-b", help="Sync batch journaling the puts", type=int, default=0
+    )
     args = parser.parse_args()
 
     if args.request == "":
@@ -41,6 +49,7 @@ if __name__ == "__main__":
 
     players = structure_player(dir_path, filetime=filetime)
     guilds = structure_guild(filetime)
+    map_objects = structure_map_objects()
 
     # Add last_online to players
     for player in players:
@@ -58,28 +67,56 @@ if __name__ == "__main__":
             )
         log(f"Players: {len(players)}")
         log(f"Guilds: {len(guilds)}")
+        if map_objects is not None:
+            with open(
+                output.replace(".json", "_map_objects.json"), "w", encoding="utf-8"
+            ) as f:
+                json.dump(map_objects, f, ensure_ascii=False)
+            log(f"Map Objects: {len(map_objects)}")
     else:
+        headers = {"Authorization": f"Bearer {args.token}"}
+        if args.batch:
+            headers["X-Sync-Batch"] = str(args.batch)
+        failed = False
         player_url = urljoin(args.request, "player")
-        guild_url = urljoin(args.request, "guild")
+        # the save has every guild, the ones left out were disbanded
+        guild_url = urljoin(args.request, "guild?mode=replace")
         log(f"Put players to {player_url} with Players: {len(players)}")
         player_res = requests.put(
             player_url,
-            headers={"Authorization": f"Bearer {args.token}"},
+            headers=headers,
             json=players,
             timeout=10,
         )
         if player_res.status_code != 200:
             log(f"Put Players data error: {player_res.text}")
+            failed = True
 
         log(f"Put guilds to {guild_url} with Guilds: {len(guilds)}")
         guild_res = requests.put(
             guild_url,
-            headers={"Authorization": f"Bearer {args.token}"},
+            headers=headers,
             json=guilds,
             timeout=10,
         )
         if guild_res.status_code != 200:
             log(f"Put Guilds data error: {guild_res.text}")
+            failed = True
+
+        if map_objects is not None:
+            map_object_url = urljoin(args.request, "map/objects")
+            log(
+                f"Put map objects to {map_object_url} with Map Objects: {len(map_objects)}"
+            )
+            map_object_res = requests.put(
+                map_object_url,
+                headers=headers,
+                json=map_objects,
+                timeout=60,
+            )
+            if map_object_res.status_code != 200:
+                log(f"Put Map Objects data error: {map_object_res.text}")
+                failed = True
 
     try:
         if args.clear:
@@ -92,3 +129,6 @@ if __name__ == "__main__":
         pass
 
     log(f"Done in {round(time.time() - start, 3)}s")
+    if args.request != "" and failed:
+        # pst journals the sync as partial
+        sys.exit(1)
@@ -14,7 +14,7 @@ import item_container_slots
 import base_camp
 import group
 
-from world_types import Player, Pal, Guild, BaseCamp
+from world_types import Player, Pal, Guild, BaseCamp, hexuid_to_decimal
 from logger import log, redirect_stdout_stderr
 
 PALWORLD_CUSTOM_PROPERTIES[
@@ -37,6 +37,7 @@ PALWORLD_CUSTOM_PROPERTIES[".worldSaveData.GroupSaveDataMap"] = (
 
 wsd = None
 gvas_file = None
+map_objects = None
 
 
 def skip_decode(
@@ -225,6 +226,7 @@ def structure_player(dir_path, data_source=None, filetime: int = -1):
     uid_character = (
         (
             c["key"]["PlayerUId"]["value"],
+            c["key"]["InstanceId"]["value"],
             c["value"]["RawData"]["value"]["object"]["SaveParameter"]["value"],
         )
         for c in wsd["CharacterSaveParameterMap"]["value"]
@@ -232,15 +234,20 @@ def structure_player(dir_path, data_source=None, filetime: int = -1):
 
     players = []
     pals = []
+    # the party and palbox container ids of each player, a pal of the
+    # player in neither works at a base
+    pal_containers = {}
     ticks = wsd["GameTimeSaveData"]["value"]["RealDateTimeTicks"]["value"]
-    for uid, c in uid_character:
+    for uid, instance_id, c in uid_character:
         if c.get("IsPlayer") and c["IsPlayer"]["value"]:
-            c["Items"] = getPlayerItems(uid, dir_path)
-            players.append(Player(uid, c).to_dict())
+            c["Items"], containers, c["Technology"] = getPlayerItems(uid, dir_path)
+            player = Player(uid, c).to_dict()
+            pal_containers[player["player_uid"]] = containers
+            players.append(player)
         else:
             if not c.get("OwnerPlayerUId"):
                 continue
-            pals.append(Pal(c, ticks, filetime).to_dict())
+            pals.append(Pal(c, ticks, filetime, instance_id).to_dict())
 
     unique_players_dict = {}
     for player in players:
@@ -257,6 +264,9 @@ def structure_player(dir_path, data_source=None, filetime: int = -1):
         for player in unique_players:
             if player["player_uid"] == pal["owner"]:
                 pal.pop("owner")
+                pal["location"] = pal_location(
+                    pal.pop("container_id"), pal_containers.get(player["player_uid"])
+                )
                 player["pals"].append(pal)
                 break
 
@@ -353,6 +363,16 @@ def parse_item(properties, skip_path):
     return properties
 
 
+def pal_location(container_id, containers):
+    if not containers or not container_id:
+        return ""
+    if container_id == containers.get("party"):
+        return "party"
+    if container_id == containers.get("palbox"):
+        return "palbox"
+    return "base"
+
+
 def getPlayerItems(player_uid, dir_path):
     load_skiped_decode(wsd, ["ItemContainerSaveData"], False)
     item_containers = {}
@@ -364,7 +384,7 @@ def getPlayerItems(player_uid, dir_path):
     )
     if not os.path.exists(player_sav_file):
         # log("Player Sav file Not exists: %s" % player_sav_file)
-        return
+        return None, {}, None
     else:
         with redirect_stdout_stderr():
             try:
@@ -379,7 +399,16 @@ def getPlayerItems(player_uid, dir_path):
                     f"Player Sav file is corrupted: {os.path.basename(player_sav_file)}: {str(e)}",
                     "ERROR",
                 )
-                return
+                return None, {}, None
+    pal_containers = {}
+    for key, idx_key in (
+        ("party", "OtomoCharacterContainerId"),
+        ("palbox", "PalStorageContainerId"),
+    ):
+        try:
+            pal_containers[key] = str(player_gvas[idx_key]["value"]["ID"]["value"])
+        except (KeyError, TypeError):
+            pass
     containers_data = {
         "CommonContainerId": [],
         "DropSlotContainerId": [],
@@ -403,19 +432,91 @@ def getPlayerItems(player_uid, dir_path):
             )
 
             # 提取每个物品的相关数据并保存到字典中
-            containers_data[idx_key] = [
-                {
-                    "SlotIndex": item["RawData"]["value"]["permission"]["type_a"],
-                    "ItemId": item["RawData"]["value"]["permission"][
-                        "item_static_id"
-                    ].lower(),
-                    "StackCount": item["RawData"]["value"]["permission"]["type_b"],
-                }
-                for item in item_container["value"]["Slots"]["value"]["values"]
-                if item["RawData"]["value"]["permission"]["item_static_id"].lower()
-                != "none"
-            ]
-    return containers_data
+            containers_data[idx_key] = container_items(item_container)
+    return containers_data, pal_containers, player_technology(player_gvas)
+
+
+def player_technology(player_gvas):
+    # unspent points and the recipes researched with them
+    try:
+        unlocked = player_gvas["UnlockedRecipeTechnologyNames"]["value"]["values"]
+    except (KeyError, TypeError):
+        unlocked = []
+    return {
+        "points": (
+            player_gvas["TechnologyPoint"]["value"]
+            if player_gvas.get("TechnologyPoint")
+            else 0
+        ),
+        "ancient_points": (
+            player_gvas["bossTechnologyPoint"]["value"]
+            if player_gvas.get("bossTechnologyPoint")
+            else 0
+        ),
+        "unlocked": sorted(str(name) for name in unlocked),
+    }
+
+
+def container_items(item_container):
+    return [
+        {
+            "SlotIndex": item["RawData"]["value"]["permission"]["type_a"],
+            "ItemId": item["RawData"]["value"]["permission"]["item_static_id"].lower(),
+            "StackCount": item["RawData"]["value"]["permission"]["type_b"],
+        }
+        for item in item_container["value"]["Slots"]["value"]["values"]
+        if item["RawData"]["value"]["permission"]["item_static_id"].lower() != "none"
+    ]
+
+
+def map_object_container_id(obj):
+    # chests keep their items in the container of their ItemContainer module
+    try:
+        modules = obj["ConcreteModel"]["value"]["ModuleMap"]["value"]
+    except (KeyError, TypeError):
+        return ""
+    for module in modules:
+        if module["key"] != "EPalMapObjectConcreteModelModuleType::ItemContainer":
+            continue
+        try:
+            return str(module["value"]["RawData"]["value"]["target_container_id"])
+        except (KeyError, TypeError):
+            return ""
+    return ""
+
+
+def structure_base_camp_storage():
+    """Item counts in the chests of each base camp by item id, None when the
+    map objects or containers can't be parsed."""
+    objects = structure_map_objects()
+    if objects is None:
+        return None
+    try:
+        load_skiped_decode(wsd, ["ItemContainerSaveData"], False)
+        item_containers = {
+            str(c["key"]["ID"]["value"]): c
+            for c in wsd["ItemContainerSaveData"]["value"]
+        }
+    except Exception as e:
+        log(f"Item containers cannot be parsed: {str(e)}", "WARNING")
+        return None
+    storage = {}
+    for obj in objects:
+        if obj["base_camp_id"] == "0" or not obj["container_id"]:
+            continue
+        camp = storage.setdefault(obj["base_camp_id"], {})
+        item_container = item_containers.get(obj["container_id"])
+        if item_container is None:
+            continue
+        try:
+            items = container_items(
+                parse_item(item_container, "ItemContainerSaveData")
+            )
+        except (KeyError, TypeError):
+            continue
+        for item in items:
+            camp[item["ItemId"]] = camp.get(item["ItemId"], 0) + item["StackCount"]
+    return storage
 
 
 def structure_base_camp():
@@ -429,11 +530,72 @@ def structure_base_camp():
     return list(base_camps_generator)
 
 
+def structure_map_objects():
+    global map_objects
+    if map_objects is not None:
+        return map_objects
+    log("Structuring map objects...")
+    try:
+        load_skiped_decode(wsd, ["MapObjectSaveData"], False)
+        values = wsd["MapObjectSaveData"]["value"]["values"]
+    except Exception as e:
+        log(f"Map objects cannot be parsed: {str(e)}", "WARNING")
+        return None
+    objects = []
+    for obj in values:
+        try:
+            model = obj["Model"]["value"]["RawData"]["value"]
+            location = obj.get("WorldLocation", {}).get("value", {})
+            hp = model.get("hp", {})
+            objects.append(
+                {
+                    "instance_id": hexuid_to_decimal(
+                        obj["MapObjectInstanceId"]["value"]
+                    ),
+                    "name": obj["MapObjectId"]["value"],
+                    "location_x": location.get("x", 0),
+                    "location_y": location.get("y", 0),
+                    "base_camp_id": hexuid_to_decimal(model["base_camp_id_belong_to"]),
+                    "build_player_uid": hexuid_to_decimal(
+                        model.get("build_player_uid", "0")
+                    ),
+                    "hp": hp.get("current", 0),
+                    "max_hp": hp.get("max", 0),
+                    "container_id": map_object_container_id(obj),
+                }
+            )
+        except (KeyError, TypeError):
+            continue
+    map_objects = objects
+    return map_objects
+
+
+def structure_base_camp_structures():
+    objects = structure_map_objects()
+    if objects is None:
+        return None
+    structures = {}
+    for obj in objects:
+        if obj["base_camp_id"] == "0":
+            continue
+        camp = structures.setdefault(
+            obj["base_camp_id"],
+            {"structures": 0, "hp": 0, "max_hp": 0, "instance_ids": set()},
+        )
+        camp["structures"] += 1
+        camp["hp"] += obj["hp"]
+        camp["max_hp"] += obj["max_hp"]
+        camp["instance_ids"].add(obj["instance_id"])
+    return structures
+
+
 def structure_guild(filetime: int = -1):
     log("Structuring guilds...")
     if not wsd.get("GroupSaveDataMap"):
         return []
     base_camps = structure_base_camp()
+    structures = structure_base_camp_structures()
+    storage = structure_base_camp_storage()
     groups = (
         g["value"]["RawData"]["value"]
         for g in wsd["GroupSaveDataMap"]["value"]
@@ -447,14 +609,27 @@ def structure_guild(filetime: int = -1):
     for guild in sorted_guilds:
         for camp in base_camps:
             if camp["id"] in guild["base_ids"]:
-                guild["base_camp"].append(
-                    {
-                        "id": camp["id"],
-                        "area": camp["area_range"],
-                        "location_x": camp["transform"]["x"],
-                        "location_y": camp["transform"]["y"],
+                base_camp = {
+                    "id": camp["id"],
+                    "area": camp["area_range"],
+                    "location_x": camp["transform"]["x"],
+                    "location_y": camp["transform"]["y"],
+                }
+                if structures is not None:
+                    camp_structures = structures.get(
+                        camp["id"],
+                        {"structures": 0, "hp": 0, "max_hp": 0, "instance_ids": set()},
+                    )
+                    base_camp["structures"] = {
+                        "count": camp_structures["structures"],
+                        "hp": camp_structures["hp"],
+                        "max_hp": camp_structures["max_hp"],
+                        "pal_box": camp["owner_map_object_instance_id"]
+                        in camp_structures["instance_ids"],
                     }
-                )
+                if storage is not None:
+                    base_camp["storage"] = storage.get(camp["id"], {})
+                guild["base_camp"].append(base_camp)
     return list(sorted_guilds)
 
 
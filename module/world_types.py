@@ -65,6 +65,8 @@ class Player:
             }
         )
 
+        self.technology = data.get("Technology")
+
         self.__order = [
             "player_uid",
             "nickname",
@@ -79,6 +81,7 @@ class Player:
             "full_stomach",
             "pals",
             "items",
+            "technology",
         ]
 
     def to_dict(self):
@@ -90,8 +93,15 @@ class Player:
 
 
 class Pal:
-    def __init__(self, data, real_date_time_ticks, filetime):
+    def __init__(self, data, real_date_time_ticks, filetime, instance_id=""):
+        self.instance_id = str(instance_id)
         self.owner = hexuid_to_decimal(data["OwnerPlayerUId"]["value"])
+        try:
+            self.container_id = str(
+                data["SlotID"]["value"]["ContainerId"]["value"]["ID"]["value"]
+            )
+        except (KeyError, TypeError):
+            self.container_id = ""
         self.nickname = data["NickName"]["value"] if data.get("NickName") else ""
         self.level = int(data["Level"]["value"]["value"]) if data.get("Level") else 1
         self.exp = int(data["Exp"]["value"]) if data.get("Exp") else 0
@@ -161,7 +171,9 @@ class Pal:
         )
 
         self.__order = [
+            "instance_id",
             "owner",
+            "container_id",
             "nickname",
             "level",
             "exp",
@@ -194,6 +206,7 @@ class Pal:
 
 class Guild:
     def __init__(self, data, real_date_time_ticks, filetime):
+        self.id = hexuid_to_decimal(data["group_id"])
         self.name = data["guild_name"]
         self.base_camp_level = data["base_camp_level"]
         self.admin_player_uid = hexuid_to_decimal(data["admin_player_uid"])
@@ -216,6 +229,7 @@ class Guild:
         self.base_ids = [hexuid_to_decimal(x) for x in data["base_ids"]]
         self.base_camp = []
         self.__order = [
+            "id",
             "name",
             "base_camp_level",
             "admin_player_uid",
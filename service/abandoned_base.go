@@ -0,0 +1,90 @@
+package service
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"go.etcd.io/bbolt"
+)
+
+type AbandonedBase struct {
+	Guild          string    `json:"guild"`
+	AdminPlayerUid string    `json:"admin_player_uid"`
+	BaseCampId     string    `json:"base_camp_id"`
+	LocationX      float64   `json:"location_x"`
+	LocationY      float64   `json:"location_y"`
+	Structures     int       `json:"structures"`
+	LastOnline     time.Time `json:"last_online"`
+	InactiveDays   int       `json:"inactive_days"`
+}
+
+// ListAbandonedBases returns the base camps of guilds whose members have all
+// been offline for more than inactiveDays, those with most structures first.
+// A member's last online is the latest of the guild data and the player data.
+func ListAbandonedBases(db *bbolt.DB, inactiveDays int) ([]AbandonedBase, error) {
+	bases := make([]AbandonedBase, 0)
+	err := db.View(func(tx *bbolt.Tx) error {
+		lastOnline := make(map[string]time.Time)
+		err := tx.Bucket([]byte("players")).ForEach(func(k, v []byte) error {
+			var player database.TersePlayer
+			if err := json.Unmarshal(v, &player); err != nil {
+				return err
+			}
+			lastOnline[player.PlayerUid] = player.LastOnline
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		deadline := now.AddDate(0, 0, -inactiveDays)
+		return tx.Bucket([]byte("guilds")).ForEach(func(k, v []byte) error {
+			var guild database.Guild
+			if err := json.Unmarshal(v, &guild); err != nil {
+				return err
+			}
+			var latest time.Time
+			for _, p := range guild.Players {
+				if t := lastOnline[p.PlayerUid]; t.After(latest) {
+					latest = t
+				}
+				if t, err := time.Parse(time.RFC3339, p.LastOnline); err == nil && t.After(latest) {
+					latest = t
+				}
+			}
+			if latest.After(deadline) {
+				return nil
+			}
+			days := 0
+			if !latest.IsZero() {
+				days = int(now.Sub(latest).Hours() / 24)
+			}
+			for _, camp := range guild.BaseCamp {
+				base := AbandonedBase{
+					Guild:          guild.Name,
+					AdminPlayerUid: guild.AdminPlayerUid,
+					BaseCampId:     camp.Id,
+					LocationX:      camp.LocationX,
+					LocationY:      camp.LocationY,
+					LastOnline:     latest,
+					InactiveDays:   days,
+				}
+				if camp.Structures != nil {
+					base.Structures = camp.Structures.Count
+				}
+				bases = append(bases, base)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(bases, func(i, j int) bool {
+		return bases[i].Structures > bases[j].Structures
+	})
+	return bases, nil
+}
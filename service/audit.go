@@ -0,0 +1,155 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"go.etcd.io/bbolt"
+)
+
+// AuditSummary is what the admins did since a time, from the admin events,
+// the removed entries and the snapshots taken before destructive
+// operations.
+type AuditSummary struct {
+	Since time.Time `json:"since"`
+	// Days are the actions of each admin per day, newest day first
+	Days []AdminDay `json:"days"`
+	// Actions are how often each action was used, most used first
+	Actions []ActionCount `json:"actions"`
+	// Destructive are the latest removals and snapshotted operations,
+	// newest first
+	Destructive []AuditAction `json:"destructive"`
+}
+
+type AdminDay struct {
+	// Day is the date in task.timezone, like 2024-01-31
+	Day string `json:"day"`
+	// By is the admin, the client address of the request, bot or cli
+	By      string `json:"by"`
+	Actions int    `json:"actions"`
+}
+
+type ActionCount struct {
+	Action string `json:"action"`
+	Count  int    `json:"count"`
+}
+
+type AuditAction struct {
+	Time    time.Time `json:"time"`
+	By      string    `json:"by"`
+	Action  string    `json:"action"`
+	Message string    `json:"message"`
+}
+
+// SummarizeAudit summarizes the admin actions since, with the latest recent
+// destructive ones.
+func SummarizeAudit(db *bbolt.DB, since time.Time, recent int) (AuditSummary, error) {
+	var actions, destructive []AuditAction
+	err := db.View(func(tx *bbolt.Tx) error {
+		// events are keyed in the order they were recorded, not by time
+		c := tx.Bucket([]byte("events")).Cursor()
+		for k, v := c.Last(); k != nil; k, v = c.Prev() {
+			var event database.Event
+			if err := json.Unmarshal(v, &event); err != nil {
+				return err
+			}
+			if event.Time.Before(since) {
+				continue
+			}
+			switch {
+			case event.Type == EventAdminRestore, event.Type == EventAdminMigration:
+				// the snapshot taken before a revert stands for it
+				if event.Data["snapshot_id"] != "" {
+					continue
+				}
+				destructive = append(destructive, AuditAction{Time: event.Time, By: event.Data["by"], Action: event.Type + "." + event.Data["action"], Message: event.Message})
+			case strings.HasPrefix(event.Type, "admin."):
+				action := event.Type
+				if event.Data["action"] != "" {
+					action = event.Type + "." + event.Data["action"]
+				}
+				actions = append(actions, AuditAction{Time: event.Time, By: event.Data["by"], Action: action, Message: event.Message})
+			case event.Type == EventWhitelistApproved, event.Type == EventWhitelistDenied:
+				actions = append(actions, AuditAction{Time: event.Time, By: event.Data["by"], Action: event.Type, Message: event.Message})
+			}
+		}
+		err := tx.Bucket([]byte("removed_entries")).ForEach(func(k, v []byte) error {
+			var entry database.RemovedEntry
+			if err := json.Unmarshal(v, &entry); err != nil {
+				return err
+			}
+			if entry.RemovedAt.Before(since) {
+				return nil
+			}
+			message := "Removed " + entry.Kind
+			switch {
+			case entry.Player != nil:
+				message += " " + entry.Player.Name
+			case entry.IpBan != nil:
+				message += " " + entry.IpBan.Ip
+			}
+			if entry.Reason != "" {
+				message += ": " + entry.Reason
+			}
+			destructive = append(destructive, AuditAction{Time: entry.RemovedAt, By: entry.RemovedBy, Action: "remove." + entry.Kind, Message: message})
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		return tx.Bucket([]byte("snapshots")).ForEach(func(k, v []byte) error {
+			var snapshot database.Snapshot
+			if err := json.Unmarshal(v, &snapshot); err != nil {
+				return err
+			}
+			if snapshot.Time.Before(since) {
+				return nil
+			}
+			destructive = append(destructive, AuditAction{Time: snapshot.Time, By: snapshot.By, Action: snapshot.Operation, Message: fmt.Sprintf("Snapshot %d taken before %s", snapshot.Id, snapshot.Operation)})
+			return nil
+		})
+	})
+	if err != nil {
+		return AuditSummary{}, err
+	}
+	actions = append(actions, destructive...)
+
+	summary := AuditSummary{Since: since, Days: make([]AdminDay, 0), Actions: make([]ActionCount, 0)}
+	days := make(map[AdminDay]int)
+	counts := make(map[string]int)
+	for _, a := range actions {
+		days[AdminDay{Day: a.Time.In(time.Local).Format("2006-01-02"), By: a.By}]++
+		counts[a.Action]++
+	}
+	for day, n := range days {
+		day.Actions = n
+		summary.Days = append(summary.Days, day)
+	}
+	sort.Slice(summary.Days, func(i, j int) bool {
+		a, b := summary.Days[i], summary.Days[j]
+		if a.Day != b.Day {
+			return a.Day > b.Day
+		}
+		return a.By < b.By
+	})
+	for action, n := range counts {
+		summary.Actions = append(summary.Actions, ActionCount{Action: action, Count: n})
+	}
+	sort.Slice(summary.Actions, func(i, j int) bool {
+		a, b := summary.Actions[i], summary.Actions[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.Action < b.Action
+	})
+	sort.Slice(destructive, func(i, j int) bool { return destructive[i].Time.After(destructive[j].Time) })
+	if recent >= 0 && len(destructive) > recent {
+		destructive = destructive[:recent]
+	}
+	summary.Destructive = append(make([]AuditAction, 0, len(destructive)), destructive...)
+	return summary, nil
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"go.etcd.io/bbolt"
+)
+
+func openTestDB(t *testing.T) *bbolt.DB {
+	t.Helper()
+	db, err := database.Open(filepath.Join(t.TempDir(), "pst.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestSummarizeAudit(t *testing.T) {
+	db := openTestDB(t)
+	now := time.Now().UTC()
+	day := now.In(time.Local).Format("2006-01-02")
+	if _, err := AddEvents(db, []database.Event{
+		{Type: "admin.command", Time: now, Data: map[string]string{"action": "give_item", "by": "10.0.0.1"}},
+		// recorded late, like a sync replaying an older one
+		{Type: "admin.command", Time: now.AddDate(0, 0, -40), Data: map[string]string{"action": "teleport", "by": "10.0.0.9"}},
+		{Type: "admin.command", Time: now, Data: map[string]string{"action": "give_item", "by": "10.0.0.1"}},
+		{Type: "admin.command", Time: now, Data: map[string]string{"action": "teleport", "by": "10.0.0.2"}},
+		{Type: EventPlayerJoin, Time: now},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := AddRemovedBan(db, database.PlayerW{Name: "mallory", SteamID: "76561198000000001"}, Removal{Reason: "appeal", By: "10.0.0.2"}); err != nil {
+		t.Fatal(err)
+	}
+
+	summary, err := SummarizeAudit(db, now.AddDate(0, 0, -30), 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantDays := []AdminDay{{day, "10.0.0.1", 2}, {day, "10.0.0.2", 2}}
+	if len(summary.Days) != len(wantDays) {
+		t.Fatalf("days %+v, want %+v", summary.Days, wantDays)
+	}
+	for i, want := range wantDays {
+		if summary.Days[i] != want {
+			t.Errorf("days[%d] = %+v, want %+v", i, summary.Days[i], want)
+		}
+	}
+	wantActions := []ActionCount{{"admin.command.give_item", 2}, {"admin.command.teleport", 1}, {"remove.ban", 1}}
+	if len(summary.Actions) != len(wantActions) {
+		t.Fatalf("actions %+v, want %+v", summary.Actions, wantActions)
+	}
+	for i, want := range wantActions {
+		if summary.Actions[i] != want {
+			t.Errorf("actions[%d] = %+v, want %+v", i, summary.Actions[i], want)
+		}
+	}
+	if len(summary.Destructive) != 1 || summary.Destructive[0].By != "10.0.0.2" || summary.Destructive[0].Action != "remove.ban" {
+		t.Errorf("destructive %+v, want the lifted ban", summary.Destructive)
+	}
+
+	summary, err = SummarizeAudit(db, now.AddDate(0, 0, -30), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(summary.Destructive) != 0 {
+		t.Errorf("destructive %+v with recent 0, want none", summary.Destructive)
+	}
+}
+
+func TestSummarizeAuditRestores(t *testing.T) {
+	db := openTestDB(t)
+	now := time.Now()
+	snapshot, err := TakeSnapshot(db, SnapshotRevert, "10.0.0.1", []string{"whitelist"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := AddEvents(db, []database.Event{
+		{Type: EventAdminMigration, Time: now, Data: map[string]string{"action": "normalize_uids", "by": "pst"}},
+		{Type: EventAdminRestore, Time: now, Data: map[string]string{"action": "db_restore", "by": "cli"}},
+		// stands for the snapshot taken before it
+		{Type: EventAdminRestore, Time: now, Data: map[string]string{"action": "snapshot_revert", "by": "10.0.0.1", "snapshot_id": "1"}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	summary, err := SummarizeAudit(db, now.AddDate(0, 0, -1), 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]string)
+	for _, action := range summary.Destructive {
+		got[action.Action] = action.By
+	}
+	want := map[string]string{
+		"admin.migration.normalize_uids": "pst",
+		"admin.restore.db_restore":       "cli",
+		snapshot.Operation:               "10.0.0.1",
+	}
+	if len(got) != len(want) || len(summary.Destructive) != len(want) {
+		t.Fatalf("destructive %+v, want %v", summary.Destructive, want)
+	}
+	for action, by := range want {
+		if got[action] != by {
+			t.Errorf("%s by %q, want %q", action, got[action], by)
+		}
+	}
+}
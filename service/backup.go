@@ -1,17 +1,95 @@
 package service
 
 import (
+	"bytes"
+	"encoding/binary"
 	"encoding/json"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/zaigie/palworld-server-tool/internal/database"
 	"go.etcd.io/bbolt"
 )
 
+const (
+	BackupReasonSchedule = "schedule"
+	BackupReasonManual   = "manual"
+	BackupReasonBot      = "bot"
+	// BackupReasonImport marks archives found in the backup directory
+	// without a record
+	BackupReasonImport = "import"
+	// BackupReasonSeason is the last backup of a season, taken as it ends
+	BackupReasonSeason = "season"
+)
+
+const (
+	BackupFormatZip = "zip"
+	// BackupFormatChunks stores the files in the deduplicating chunk store
+	BackupFormatChunks = "chunks"
+)
+
+type BackupFilter struct {
+	StartTime    time.Time
+	EndTime      time.Time
+	Reason       string
+	WorldVersion string
+	// Season is the name of the season the backups were archived with,
+	// "none" selects the backups of the current season
+	Season string
+	// Query matches part of the archive name
+	Query   string
+	MinSize int64
+	MaxSize int64
+	// OrderBy is save_time, size or player_count, save_time by default
+	OrderBy string
+	Desc    bool
+	Limit   int
+	// Files keeps the file manifests of chunked backups
+	Files bool
+}
+
+func (f BackupFilter) Match(backup database.Backup) bool {
+	if f.Reason != "" && backup.Reason != f.Reason {
+		return false
+	}
+	if f.WorldVersion != "" && backup.WorldVersion != f.WorldVersion {
+		return false
+	}
+	if f.Season == "none" && backup.Season != "" || f.Season != "" && f.Season != "none" && backup.Season != f.Season {
+		return false
+	}
+	if f.Query != "" && !strings.Contains(strings.ToLower(backup.Path), strings.ToLower(f.Query)) {
+		return false
+	}
+	if f.MinSize > 0 && backup.Size < f.MinSize {
+		return false
+	}
+	if f.MaxSize > 0 && backup.Size > f.MaxSize {
+		return false
+	}
+	return true
+}
+
+// backupTimeKey orders the backup_times index by save time.
+func backupTimeKey(backup database.Backup) []byte {
+	key := make([]byte, 8, 8+len(backup.BackupId))
+	binary.BigEndian.PutUint64(key, uint64(backup.SaveTime.UnixNano()))
+	return append(key, backup.BackupId...)
+}
+
 func AddBackup(db *bbolt.DB, backup database.Backup) error {
 	return db.Update(func(tx *bbolt.Tx) error {
 		b := tx.Bucket([]byte("backups"))
+		if v := b.Get([]byte(backup.BackupId)); v != nil {
+			var old database.Backup
+			if err := json.Unmarshal(v, &old); err != nil {
+				return err
+			}
+			if err := tx.Bucket([]byte("backup_times")).Delete(backupTimeKey(old)); err != nil {
+				return err
+			}
+		}
 		v, err := json.Marshal(backup)
 		if err != nil {
 			return err
@@ -19,7 +97,7 @@ func AddBackup(db *bbolt.DB, backup database.Backup) error {
 		if err := b.Put([]byte(backup.BackupId), v); err != nil {
 			return err
 		}
-		return nil
+		return tx.Bucket([]byte("backup_times")).Put(backupTimeKey(backup), nil)
 	})
 }
 
@@ -45,32 +123,100 @@ func GetBackup(db *bbolt.DB, backupId string) (database.Backup, error) {
 func DeleteBackup(db *bbolt.DB, backupId string) error {
 	return db.Update(func(tx *bbolt.Tx) error {
 		b := tx.Bucket([]byte("backups"))
+		v := b.Get([]byte(backupId))
+		if v == nil {
+			return nil
+		}
+		var backup database.Backup
+		if err := json.Unmarshal(v, &backup); err != nil {
+			return err
+		}
+		if err := tx.Bucket([]byte("backup_times")).Delete(backupTimeKey(backup)); err != nil {
+			return err
+		}
 		return b.Delete([]byte(backupId))
 	})
 }
 
-func ListBackups(db *bbolt.DB, startTime, endTime time.Time) ([]database.Backup, error) {
+// ListBackups returns the matching backups, walking the backup_times index
+// from StartTime so a narrow range doesn't decode the whole catalog.
+func ListBackups(db *bbolt.DB, filter BackupFilter) ([]database.Backup, error) {
 	backups := make([]database.Backup, 0)
 	err := db.View(func(tx *bbolt.Tx) error {
 		b := tx.Bucket([]byte("backups"))
-		return b.ForEach(func(k, v []byte) error {
+		c := tx.Bucket([]byte("backup_times")).Cursor()
+		var from []byte
+		if !filter.StartTime.IsZero() {
+			from = backupTimeKey(database.Backup{SaveTime: filter.StartTime})
+		}
+		var to []byte
+		if !filter.EndTime.IsZero() {
+			to = backupTimeKey(database.Backup{SaveTime: filter.EndTime})
+		}
+		for k, _ := c.Seek(from); k != nil; k, _ = c.Next() {
+			if to != nil && bytes.Compare(k[:8], to) > 0 {
+				break
+			}
+			v := b.Get(k[8:])
+			if v == nil {
+				continue
+			}
 			var backup database.Backup
 			if err := json.Unmarshal(v, &backup); err != nil {
 				return err
 			}
-			// 根据时间筛选
-			if (startTime.IsZero() || backup.SaveTime.After(startTime)) &&
-				(endTime.IsZero() || backup.SaveTime.Before(endTime)) {
+			if !filter.Files {
+				backup.Files = nil
+			}
+			if filter.Match(backup) {
 				backups = append(backups, backup)
 			}
-			return nil
-		})
+		}
+		return nil
 	})
 	if err != nil {
 		return nil, err
 	}
-	sort.Slice(backups, func(i, j int) bool {
-		return backups[i].SaveTime.Before(backups[j].SaveTime)
-	})
+	sortBackups(backups, filter.OrderBy, filter.Desc)
+	if filter.Limit > 0 && len(backups) > filter.Limit {
+		backups = backups[:filter.Limit]
+	}
 	return backups, nil
 }
+
+func sortBackups(backups []database.Backup, orderBy string, desc bool) {
+	less := func(a, b database.Backup) bool { return a.SaveTime.Before(b.SaveTime) }
+	switch orderBy {
+	case "size":
+		less = func(a, b database.Backup) bool { return a.Size < b.Size }
+	case "player_count":
+		less = func(a, b database.Backup) bool { return a.PlayerCount < b.PlayerCount }
+	}
+	sort.SliceStable(backups, func(i, j int) bool {
+		if desc {
+			return less(backups[j], backups[i])
+		}
+		return less(backups[i], backups[j])
+	})
+}
+
+// IndexBackups rebuilds the backup_times index from the catalog, records
+// written before the index existed aren't in it.
+func IndexBackups(db *bbolt.DB) error {
+	return db.Update(func(tx *bbolt.Tx) error {
+		if err := tx.DeleteBucket([]byte("backup_times")); err != nil {
+			return err
+		}
+		index, err := tx.CreateBucket([]byte("backup_times"))
+		if err != nil {
+			return err
+		}
+		return tx.Bucket([]byte("backups")).ForEach(func(_, v []byte) error {
+			var backup database.Backup
+			if err := json.Unmarshal(v, &backup); err != nil {
+				return err
+			}
+			return index.Put(backupTimeKey(backup), nil)
+		})
+	})
+}
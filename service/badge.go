@@ -0,0 +1,262 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"go.etcd.io/bbolt"
+)
+
+// The metrics a badge rule can be about.
+const (
+	BadgeMetricLevel      = "level"
+	BadgeMetricPals       = "pals"
+	BadgeMetricTechnology = "technology"
+	BadgeMetricPlaytime   = "playtime_hours"
+	BadgeMetricStreak     = "streak_days"
+)
+
+// BadgeRule awards a badge to players with at least Value of the metric.
+type BadgeRule struct {
+	Id          string  `json:"id" mapstructure:"id"`
+	Name        string  `json:"name" mapstructure:"name"`
+	Description string  `json:"description" mapstructure:"description"`
+	Metric      string  `json:"metric" mapstructure:"metric"`
+	Value       float64 `json:"value" mapstructure:"value"`
+	// First awards it only to the first player to get there
+	First bool `json:"first" mapstructure:"first"`
+}
+
+type BadgeAward struct {
+	PlayerUid string               `json:"player_uid"`
+	Nickname  string               `json:"nickname"`
+	Rule      BadgeRule            `json:"rule"`
+	Badge     database.EarnedBadge `json:"badge"`
+}
+
+// RecordStreaks counts the day for the online players, a day missed starts
+// the streak over.
+func RecordStreaks(db *bbolt.DB, players []database.OnlinePlayer, now time.Time) error {
+	today := now.Format("2006-01-02")
+	yesterday := now.AddDate(0, 0, -1).Format("2006-01-02")
+	return db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte("badges"))
+		for _, p := range players {
+			uid := CanonicalPlayerUid(p.PlayerUid)
+			if uid == "" {
+				continue
+			}
+			record, err := getPlayerBadges(b, uid)
+			if err != nil {
+				return err
+			}
+			if record.StreakDay == today {
+				continue
+			}
+			if record.StreakDay == yesterday {
+				record.Streak++
+			} else {
+				record.Streak = 1
+			}
+			record.StreakDay = today
+			record.Nickname = p.Nickname
+			if err := putPlayerBadges(b, record); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// AwardBadges gives the stored players the badges of the rules they reached
+// and don't have yet. Of the players reaching a First badge in the same sync
+// the one with the most of the metric gets it.
+func AwardBadges(db *bbolt.DB, rules []BadgeRule, now time.Time) ([]BadgeAward, error) {
+	var awards []BadgeAward
+	err := db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte("badges"))
+		var players []database.Player
+		if err := tx.Bucket([]byte("players")).ForEach(func(k, v []byte) error {
+			var player database.Player
+			if err := json.Unmarshal(v, &player); err != nil {
+				return err
+			}
+			players = append(players, player)
+			return nil
+		}); err != nil {
+			return err
+		}
+		records := make(map[string]database.PlayerBadges, len(players))
+		taken := make(map[string]bool)
+		if err := b.ForEach(func(k, v []byte) error {
+			var record database.PlayerBadges
+			if err := json.Unmarshal(v, &record); err != nil {
+				return err
+			}
+			records[string(k)] = record
+			for _, badge := range record.Badges {
+				taken[badge.Id] = true
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+		playtimes := tx.Bucket([]byte("playtimes"))
+
+		for _, rule := range rules {
+			if rule.First && taken[rule.Id] {
+				continue
+			}
+			var reached []BadgeAward
+			for _, player := range players {
+				record := records[player.PlayerUid]
+				if hasBadge(record, rule.Id) {
+					continue
+				}
+				value, err := badgeMetric(playtimes, player, record, rule.Metric)
+				if err != nil {
+					return err
+				}
+				if value < rule.Value {
+					continue
+				}
+				reached = append(reached, BadgeAward{
+					PlayerUid: player.PlayerUid,
+					Nickname:  player.Nickname,
+					Rule:      rule,
+					Badge:     database.EarnedBadge{Id: rule.Id, Name: rule.Name, Time: now, Value: value},
+				})
+			}
+			if rule.First && len(reached) > 1 {
+				sort.SliceStable(reached, func(i, j int) bool { return reached[i].Badge.Value > reached[j].Badge.Value })
+				reached = reached[:1]
+			}
+			for _, award := range reached {
+				record := records[award.PlayerUid]
+				record.PlayerUid = award.PlayerUid
+				record.Nickname = award.Nickname
+				record.Badges = append(record.Badges, award.Badge)
+				records[award.PlayerUid] = record
+				if err := putPlayerBadges(b, record); err != nil {
+					return err
+				}
+				awards = append(awards, award)
+			}
+		}
+		return nil
+	})
+	return awards, err
+}
+
+func badgeMetric(playtimes *bbolt.Bucket, player database.Player, record database.PlayerBadges, metric string) (float64, error) {
+	switch metric {
+	case BadgeMetricLevel:
+		return float64(player.Level), nil
+	case BadgeMetricPals:
+		return float64(len(player.Pals)), nil
+	case BadgeMetricTechnology:
+		if player.Technology == nil {
+			return 0, nil
+		}
+		return float64(len(player.Technology.Unlocked)), nil
+	case BadgeMetricPlaytime:
+		v := playtimes.Get([]byte(player.PlayerUid))
+		if v == nil {
+			return 0, nil
+		}
+		var playtime database.Playtime
+		if err := json.Unmarshal(v, &playtime); err != nil {
+			return 0, err
+		}
+		return float64(playtime.Seconds) / 3600, nil
+	case BadgeMetricStreak:
+		return float64(record.Streak), nil
+	}
+	return 0, fmt.Errorf("unknown badge metric %s", metric)
+}
+
+func hasBadge(record database.PlayerBadges, id string) bool {
+	for _, badge := range record.Badges {
+		if badge.Id == id {
+			return true
+		}
+	}
+	return false
+}
+
+func getPlayerBadges(b *bbolt.Bucket, playerUid string) (database.PlayerBadges, error) {
+	record := database.PlayerBadges{PlayerUid: playerUid, Badges: make([]database.EarnedBadge, 0)}
+	v := b.Get([]byte(playerUid))
+	if v == nil {
+		return record, nil
+	}
+	err := json.Unmarshal(v, &record)
+	return record, err
+}
+
+func putPlayerBadges(b *bbolt.Bucket, record database.PlayerBadges) error {
+	v, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return b.Put([]byte(record.PlayerUid), v)
+}
+
+// GetPlayerBadges returns the badges of the player, none when they have no
+// record yet.
+func GetPlayerBadges(db *bbolt.DB, playerUid string) (database.PlayerBadges, error) {
+	var record database.PlayerBadges
+	err := db.View(func(tx *bbolt.Tx) error {
+		var err error
+		record, err = getPlayerBadges(tx.Bucket([]byte("badges")), playerUid)
+		return err
+	})
+	return record, err
+}
+
+type BadgeHolder struct {
+	PlayerUid string    `json:"player_uid"`
+	Nickname  string    `json:"nickname"`
+	Time      time.Time `json:"time"`
+}
+
+type BadgeSummary struct {
+	BadgeRule
+	// Holders are the players who earned it, the first first
+	Holders []BadgeHolder `json:"holders"`
+}
+
+// ListBadges returns the rules with who earned each.
+func ListBadges(db *bbolt.DB, rules []BadgeRule) ([]BadgeSummary, error) {
+	summaries := make([]BadgeSummary, len(rules))
+	index := make(map[string]int, len(rules))
+	for i, rule := range rules {
+		summaries[i] = BadgeSummary{BadgeRule: rule, Holders: make([]BadgeHolder, 0)}
+		index[rule.Id] = i
+	}
+	err := db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket([]byte("badges")).ForEach(func(k, v []byte) error {
+			var record database.PlayerBadges
+			if err := json.Unmarshal(v, &record); err != nil {
+				return err
+			}
+			for _, badge := range record.Badges {
+				if i, ok := index[badge.Id]; ok {
+					summaries[i].Holders = append(summaries[i].Holders, BadgeHolder{
+						PlayerUid: record.PlayerUid,
+						Nickname:  record.Nickname,
+						Time:      badge.Time,
+					})
+				}
+			}
+			return nil
+		})
+	})
+	for _, summary := range summaries {
+		sort.Slice(summary.Holders, func(i, j int) bool { return summary.Holders[i].Time.Before(summary.Holders[j].Time) })
+	}
+	return summaries, err
+}
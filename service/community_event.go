@@ -0,0 +1,58 @@
+package service
+
+import (
+	"encoding/json"
+
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"go.etcd.io/bbolt"
+)
+
+func PutCommunityEvent(db *bbolt.DB, event database.CommunityEvent) error {
+	return db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte("community_events"))
+		v, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte(event.Id), v)
+	})
+}
+
+func GetCommunityEvent(db *bbolt.DB, id string) (database.CommunityEvent, error) {
+	var event database.CommunityEvent
+	err := db.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte("community_events"))
+		v := b.Get([]byte(id))
+		if v == nil {
+			return ErrNoRecord
+		}
+		return json.Unmarshal(v, &event)
+	})
+	return event, err
+}
+
+func ListCommunityEvents(db *bbolt.DB) ([]database.CommunityEvent, error) {
+	events := make([]database.CommunityEvent, 0)
+	err := db.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte("community_events"))
+		return b.ForEach(func(k, v []byte) error {
+			var event database.CommunityEvent
+			if err := json.Unmarshal(v, &event); err != nil {
+				return err
+			}
+			events = append(events, event)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+func RemoveCommunityEvent(db *bbolt.DB, id string) error {
+	return db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte("community_events"))
+		return b.Delete([]byte(id))
+	})
+}
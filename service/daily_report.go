@@ -0,0 +1,194 @@
+package service
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"go.etcd.io/bbolt"
+)
+
+// RecordDailySnapshot stores the players and guilds as today's snapshot,
+// later syncs of the same day replace it.
+func RecordDailySnapshot(db *bbolt.DB) error {
+	return db.Update(func(tx *bbolt.Tx) error {
+		return recordDailySnapshot(tx)
+	})
+}
+
+func recordDailySnapshot(tx *bbolt.Tx) error {
+	snapshot := database.DailySnapshot{
+		Date:    time.Now().Format("2006-01-02"),
+		Players: make(map[string]database.SnapshotPlayer),
+		Guilds:  make(map[string]database.SnapshotGuild),
+	}
+	err := tx.Bucket([]byte("players")).ForEach(func(k, v []byte) error {
+		var player database.Player
+		if err := json.Unmarshal(v, &player); err != nil {
+			return err
+		}
+		snapshot.Players[player.PlayerUid] = database.SnapshotPlayer{
+			Nickname: player.Nickname,
+			Level:    player.Level,
+			Pals:     len(player.Pals),
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	err = tx.Bucket([]byte("guilds")).ForEach(func(k, v []byte) error {
+		var guild database.Guild
+		if err := json.Unmarshal(v, &guild); err != nil {
+			return err
+		}
+		members := make([]string, 0, len(guild.Players))
+		for _, p := range guild.Players {
+			members = append(members, p.PlayerUid)
+		}
+		snapshot.Guilds[guild.AdminPlayerUid] = database.SnapshotGuild{Name: guild.Name, Members: members}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	v, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+	touch(tx, "daily_snapshots", []byte(snapshot.Date))
+	return tx.Bucket([]byte("daily_snapshots")).Put([]byte(snapshot.Date), v)
+}
+
+// BuildDailyReport compares the snapshot of date with the latest one before
+// it. ErrNoRecord is returned when there is no snapshot of date.
+func BuildDailyReport(db *bbolt.DB, date string) (database.DailyReport, error) {
+	var current, previous database.DailySnapshot
+	err := db.View(func(tx *bbolt.Tx) error {
+		c := tx.Bucket([]byte("daily_snapshots")).Cursor()
+		k, v := c.Seek([]byte(date))
+		if k == nil || string(k) != date {
+			return ErrNoRecord
+		}
+		if err := json.Unmarshal(v, &current); err != nil {
+			return err
+		}
+		if k, v = c.Prev(); k != nil {
+			return json.Unmarshal(v, &previous)
+		}
+		return nil
+	})
+	if err != nil {
+		return database.DailyReport{}, err
+	}
+	return diffSnapshots(previous, current), nil
+}
+
+func diffSnapshots(previous, current database.DailySnapshot) database.DailyReport {
+	report := database.DailyReport{
+		Date:            current.Date,
+		Since:           previous.Date,
+		NewPlayers:      make([]database.ReportPlayer, 0),
+		LevelGains:      make([]database.ReportPlayer, 0),
+		PalGains:        make([]database.ReportPlayer, 0),
+		NewGuilds:       make([]string, 0),
+		DisbandedGuilds: make([]string, 0),
+		GuildJoins:      make([]database.ReportGuildMember, 0),
+		GuildLeaves:     make([]database.ReportGuildMember, 0),
+	}
+	// the first snapshot has nothing to compare with, everyone would be new
+	if previous.Date == "" {
+		return report
+	}
+	nickname := func(uid string) string {
+		if p, ok := current.Players[uid]; ok {
+			return p.Nickname
+		}
+		return previous.Players[uid].Nickname
+	}
+
+	for uid, p := range current.Players {
+		old, ok := previous.Players[uid]
+		if !ok {
+			report.NewPlayers = append(report.NewPlayers, database.ReportPlayer{PlayerUid: uid, Nickname: p.Nickname, To: p.Level})
+			continue
+		}
+		if p.Level > old.Level {
+			report.LevelGains = append(report.LevelGains, database.ReportPlayer{PlayerUid: uid, Nickname: p.Nickname, From: old.Level, To: p.Level})
+		}
+		if p.Pals > old.Pals {
+			report.PalGains = append(report.PalGains, database.ReportPlayer{PlayerUid: uid, Nickname: p.Nickname, From: int32(old.Pals), To: int32(p.Pals)})
+		}
+	}
+
+	for admin, g := range current.Guilds {
+		old, ok := previous.Guilds[admin]
+		if !ok {
+			report.NewGuilds = append(report.NewGuilds, g.Name)
+		}
+		for _, uid := range g.Members {
+			if !contains(old.Members, uid) {
+				report.GuildJoins = append(report.GuildJoins, database.ReportGuildMember{Guild: g.Name, PlayerUid: uid, Nickname: nickname(uid)})
+			}
+		}
+	}
+	for admin, g := range previous.Guilds {
+		now, ok := current.Guilds[admin]
+		if !ok {
+			report.DisbandedGuilds = append(report.DisbandedGuilds, g.Name)
+			continue
+		}
+		for _, uid := range g.Members {
+			if !contains(now.Members, uid) {
+				report.GuildLeaves = append(report.GuildLeaves, database.ReportGuildMember{Guild: g.Name, PlayerUid: uid, Nickname: nickname(uid)})
+			}
+		}
+	}
+
+	byGain := func(players []database.ReportPlayer) {
+		sort.Slice(players, func(i, j int) bool { return players[i].To-players[i].From > players[j].To-players[j].From })
+	}
+	byGain(report.LevelGains)
+	byGain(report.PalGains)
+	sort.Slice(report.NewPlayers, func(i, j int) bool { return report.NewPlayers[i].Nickname < report.NewPlayers[j].Nickname })
+	sort.Strings(report.NewGuilds)
+	sort.Strings(report.DisbandedGuilds)
+	return report
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func PutDailyReport(db *bbolt.DB, report database.DailyReport) error {
+	return db.Update(func(tx *bbolt.Tx) error {
+		v, err := json.Marshal(report)
+		if err != nil {
+			return err
+		}
+		return tx.Bucket([]byte("daily_reports")).Put([]byte(report.Date), v)
+	})
+}
+
+// GetDailyReport returns the stored report of date, or builds it from the
+// snapshots when the report task hasn't run for it.
+func GetDailyReport(db *bbolt.DB, date string) (database.DailyReport, error) {
+	var report database.DailyReport
+	err := db.View(func(tx *bbolt.Tx) error {
+		v := tx.Bucket([]byte("daily_reports")).Get([]byte(date))
+		if v == nil {
+			return ErrNoRecord
+		}
+		return json.Unmarshal(v, &report)
+	})
+	if err == ErrNoRecord {
+		return BuildDailyReport(db, date)
+	}
+	return report, err
+}
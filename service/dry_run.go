@@ -0,0 +1,225 @@
+package service
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/internal/validate"
+	"go.etcd.io/bbolt"
+)
+
+const (
+	ChangeAdd     = "add"
+	ChangeUpdate  = "update"
+	ChangeRemove  = "remove"
+	ChangeCommand = "command"
+)
+
+// Change is what a dry run found a request would do, a record added,
+// updated or removed, or a command sent to the game server.
+type Change struct {
+	Action string `json:"action"`
+	// Target is the bucket of a record, the settings file or server for commands
+	Target string `json:"target"`
+	// Key is the record key, the setting name or the command
+	Key    string          `json:"key"`
+	Before json.RawMessage `json:"before,omitempty" swaggertype:"object"`
+	After  json.RawMessage `json:"after,omitempty" swaggertype:"object"`
+}
+
+var errDryRun = errors.New("dry run")
+
+// touchedKey is a record written by a dry run.
+type touchedKey struct {
+	bucket string
+	key    string
+}
+
+// dryRunWrites are the records a dry run wrote, in order, with what they
+// were before.
+type dryRunWrites struct {
+	keys   []touchedKey
+	before map[touchedKey][]byte
+}
+
+// dryRuns are the writes of the transactions of running dry runs, the
+// write functions a dry run can reach call touch before each put or delete.
+var dryRuns = struct {
+	sync.Mutex
+	running atomic.Int32
+	writes  map[*bbolt.Tx]*dryRunWrites
+}{writes: make(map[*bbolt.Tx]*dryRunWrites)}
+
+// touch keeps the record of key in bucket as it is before tx writes it when
+// tx is a dry run's, and does nothing otherwise.
+func touch(tx *bbolt.Tx, bucket string, key []byte) {
+	if dryRuns.running.Load() == 0 {
+		return
+	}
+	dryRuns.Lock()
+	w := dryRuns.writes[tx]
+	dryRuns.Unlock()
+	if w == nil {
+		return
+	}
+	k := touchedKey{bucket: bucket, key: string(key)}
+	if _, ok := w.before[k]; ok {
+		return
+	}
+	var v []byte
+	if b := tx.Bucket([]byte(bucket)); b != nil {
+		v = append([]byte(nil), b.Get(key)...)
+	}
+	w.keys = append(w.keys, k)
+	w.before[k] = v
+}
+
+// dryRun runs update in a write transaction that is always rolled back and
+// returns the records it changed. Only the records update touched are
+// compared, bbolt has no way to write without the write lock, so the
+// transaction still holds it while update runs.
+func dryRun(db *bbolt.DB, update func(tx *bbolt.Tx) error) ([]Change, error) {
+	changes := make([]Change, 0)
+	err := db.Update(func(tx *bbolt.Tx) error {
+		w := &dryRunWrites{before: make(map[touchedKey][]byte)}
+		dryRuns.Lock()
+		dryRuns.writes[tx] = w
+		dryRuns.Unlock()
+		dryRuns.running.Add(1)
+		defer func() {
+			dryRuns.running.Add(-1)
+			dryRuns.Lock()
+			delete(dryRuns.writes, tx)
+			dryRuns.Unlock()
+		}()
+		if err := update(tx); err != nil {
+			return err
+		}
+		for _, k := range w.keys {
+			var after []byte
+			if b := tx.Bucket([]byte(k.bucket)); b != nil {
+				if v := b.Get([]byte(k.key)); v != nil {
+					after = append([]byte(nil), v...)
+				}
+			}
+			if change, ok := diffRecord(k, w.before[k], after); ok {
+				changes = append(changes, change)
+			}
+		}
+		return errDryRun
+	})
+	if err != nil && err != errDryRun {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// sequenceBuckets are keyed by eventKey of a sequence, their keys are shown
+// as the number.
+var sequenceBuckets = map[string]bool{"events": true, "removed_entries": true}
+
+func diffRecord(k touchedKey, before, after []byte) (Change, bool) {
+	key := k.key
+	if sequenceBuckets[k.bucket] && len(key) == 8 {
+		key = strconv.FormatUint(binary.BigEndian.Uint64([]byte(key)), 10)
+	}
+	switch {
+	case before == nil && after == nil, bytes.Equal(before, after):
+		return Change{}, false
+	case before == nil:
+		return Change{Action: ChangeAdd, Target: k.bucket, Key: key, After: after}, true
+	case after == nil:
+		return Change{Action: ChangeRemove, Target: k.bucket, Key: key, Before: before}, true
+	}
+	return Change{Action: ChangeUpdate, Target: k.bucket, Key: key, Before: before, After: after}, true
+}
+
+// DryRunPutPlayers returns what a players sync would change, the players
+// and their events, the pal owners and their events and the daily snapshot.
+func DryRunPutPlayers(db *bbolt.DB, players []database.Player) ([]Change, error) {
+	if err := validatePlayers(players); err != nil {
+		return nil, err
+	}
+	return dryRun(db, func(tx *bbolt.Tx) error {
+		if _, err := putPlayers(tx, players, nil); err != nil {
+			return err
+		}
+		if _, err := trackPals(tx, players); err != nil {
+			return err
+		}
+		return recordDailySnapshot(tx)
+	})
+}
+
+// DryRunPutGuilds returns what a guilds sync would change, the guilds and
+// their events and the daily snapshot.
+func DryRunPutGuilds(db *bbolt.DB, guilds []database.Guild, mode string, threshold RaidThreshold) ([]Change, error) {
+	if err := prepareGuilds(guilds, mode); err != nil {
+		return nil, err
+	}
+	return dryRun(db, func(tx *bbolt.Tx) error {
+		if _, err := putGuilds(tx, guilds, mode, threshold, nil); err != nil {
+			return err
+		}
+		return recordDailySnapshot(tx)
+	})
+}
+
+func DryRunAddWhitelist(db *bbolt.DB, player database.PlayerW) ([]Change, error) {
+	var v validate.Validator
+	validateWhitelistEntry(&v, "", player)
+	if err := v.Err(); err != nil {
+		return nil, err
+	}
+	player.PlayerUID = CanonicalPlayerUid(player.PlayerUID)
+	return dryRun(db, func(tx *bbolt.Tx) error {
+		return putWhitelistEntry(tx, player)
+	})
+}
+
+func DryRunRemoveWhitelist(db *bbolt.DB, player database.PlayerW) ([]Change, error) {
+	player.PlayerUID = CanonicalPlayerUid(player.PlayerUID)
+	return dryRun(db, func(tx *bbolt.Tx) error {
+		return removeWhitelist(tx, player, Removal{})
+	})
+}
+
+func DryRunPutWhitelist(db *bbolt.DB, players []database.PlayerW, mode string) ([]Change, error) {
+	if err := validateWhitelist(players); err != nil {
+		return nil, err
+	}
+	return dryRun(db, func(tx *bbolt.Tx) error {
+		return putWhitelist(tx, players, mode, Removal{})
+	})
+}
+
+func DryRunPutPlaytime(db *bbolt.DB, playtime database.Playtime) ([]Change, error) {
+	return dryRun(db, func(tx *bbolt.Tx) error {
+		return putPlaytime(tx, playtime)
+	})
+}
+
+func DryRunPutIpBan(db *bbolt.DB, ban database.IpBan) ([]Change, error) {
+	return dryRun(db, func(tx *bbolt.Tx) error {
+		return putIpBan(tx, ban)
+	})
+}
+
+func DryRunRemoveIpBan(db *bbolt.DB, ip string) ([]Change, error) {
+	return dryRun(db, func(tx *bbolt.Tx) error {
+		return removeIpBan(tx, ip, Removal{})
+	})
+}
+
+// DryRunAddRemovedBan returns the removed entry AddRemovedBan would keep.
+func DryRunAddRemovedBan(db *bbolt.DB, player database.PlayerW, removal Removal) ([]Change, error) {
+	return dryRun(db, func(tx *bbolt.Tx) error {
+		return addRemoved(tx, database.RemovedEntry{Kind: RemovedBan, Player: &player}, removal)
+	})
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"go.etcd.io/bbolt"
+)
+
+func TestDryRunPutPlayers(t *testing.T) {
+	db := openTestDB(t)
+	players := []database.Player{
+		{TersePlayer: database.TersePlayer{PlayerUid: "1001", Nickname: "alice", Level: 10}, Pals: []*database.Pal{{InstanceId: "p1", Type: "Lamball"}}},
+		{TersePlayer: database.TersePlayer{PlayerUid: "1002", Nickname: "bob", Level: 10}},
+	}
+	if _, err := PutPlayers(db, players, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := TrackPals(db, players); err != nil {
+		t.Fatal(err)
+	}
+
+	changes, err := DryRunPutPlayers(db, []database.Player{
+		{TersePlayer: database.TersePlayer{PlayerUid: "1001", Nickname: "alice", Level: 11}, Pals: []*database.Pal{{InstanceId: "p1", Type: "Lamball"}}},
+		{TersePlayer: database.TersePlayer{PlayerUid: "1002", Nickname: "bob", Level: 10}, Pals: []*database.Pal{{InstanceId: "p2", Type: "Cattiva"}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]string)
+	for _, change := range changes {
+		got[change.Target+"/"+change.Key] = change.Action
+	}
+	for key, action := range map[string]string{
+		"players/1001":  ChangeUpdate,
+		"players/1002":  ChangeUpdate,
+		"pal_owners/p1": ChangeUpdate,
+		"pal_owners/p2": ChangeAdd,
+	} {
+		if got[key] != action {
+			t.Errorf("%s: got %q, want %q in %v", key, got[key], action, got)
+		}
+	}
+	snapshot := false
+	for _, change := range changes {
+		snapshot = snapshot || change.Target == "daily_snapshots"
+	}
+	if !snapshot {
+		t.Errorf("no daily snapshot in %v", got)
+	}
+
+	// nothing was written
+	player, err := GetPlayer(db, "1001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if player.Level != 10 {
+		t.Errorf("level %d stored by the dry run", player.Level)
+	}
+	err = db.View(func(tx *bbolt.Tx) error {
+		if tx.Bucket([]byte("pal_owners")).Get([]byte("p2")) != nil {
+			t.Errorf("pal p2 stored by the dry run")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDryRunAddRemovedBan(t *testing.T) {
+	db := openTestDB(t)
+	changes, err := DryRunAddRemovedBan(db, database.PlayerW{Name: "alice", SteamID: "76561198000000001"}, Removal{By: "admin"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 1 || changes[0].Target != "removed_entries" || changes[0].Key != "1" || changes[0].Action != ChangeAdd {
+		t.Fatalf("got %+v", changes)
+	}
+	entries, err := ListRemoved(db, "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("%d removed entries kept by the dry run", len(entries))
+	}
+}
@@ -0,0 +1,275 @@
+package service
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/internal/filter"
+	"go.etcd.io/bbolt"
+)
+
+const (
+	EventGuildCreate  = "guild.create"
+	EventGuildDisband = "guild.disband"
+	EventGuildJoin    = "guild.join"
+	EventGuildLeave   = "guild.leave"
+
+	EventBaseDamaged   = "base.damaged"
+	EventBaseDestroyed = "base.destroyed"
+	// EventStorageWithdrawn is a withdrawal from the chests of a base camp
+	// over the threshold
+	EventStorageWithdrawn = "base.storage_withdrawn"
+
+	EventBackupSuccess = "backup.success"
+	EventBackupFail    = "backup.fail"
+
+	EventServerJobSuccess = "server.job.success"
+	EventServerJobFail    = "server.job.fail"
+
+	EventServerUpdateAvailable = "server.update_available"
+	EventServerLowFps          = "server.low_fps"
+	EventSeasonEnded           = "server.season_ended"
+	EventServerClockSkew       = "server.clock_skew"
+
+	EventDailyReport = "report.daily"
+
+	EventVotePassed = "vote.passed"
+
+	EventWhitelistApplication = "whitelist.application"
+	EventWhitelistApproved    = "whitelist.approved"
+	EventWhitelistDenied      = "whitelist.denied"
+
+	EventReservedSlotKick = "player.reserved_slot_kick"
+	EventPlayerRankUp     = "player.rank_up"
+	EventBadgeEarned      = "player.badge_earned"
+	EventAfkKick          = "player.afk_kick"
+	EventVpnDetected      = "player.vpn_detected"
+	EventIpBanKick        = "player.ip_ban_kick"
+	EventRewardRedeemed   = "player.reward_redeemed"
+	EventHighPing         = "player.high_ping"
+	EventCharacterLost    = "player.character_lost"
+	EventCharacterReset   = "player.character_reset"
+	EventTechnologyLost   = "player.technology_lost"
+
+	// EventAdminCommand is an admin action sent to the game, like a teleport
+	EventAdminCommand = "admin.command"
+	// EventAdminRestore is a snapshot reverted or the database restored
+	EventAdminRestore = "admin.restore"
+	// EventAdminMigration is a change of the stored records pst makes at
+	// startup, like moving them to canonical player uids
+	EventAdminMigration = "admin.migration"
+
+	EventPalTraded   = "pal.traded"
+	EventPalMoved    = "pal.moved"
+	EventPalReleased = "pal.released"
+
+	// published on the bus only, not stored
+	EventPlayerJoin  = "player.join"
+	EventPlayerLeave = "player.leave"
+	EventSyncDone    = "sync.done"
+	EventChatMessage = "chat.message"
+	// EventBackupDone follows every backup, with the status success or
+	// fail in Data
+	EventBackupDone = "backup.done"
+	// EventAlertFired follows each event of AlertTypes, with its type as
+	// alert in Data
+	EventAlertFired = "alert.fired"
+	// EventMetricsSample carries each metrics sample in Data
+	EventMetricsSample = "metrics.sample"
+)
+
+// AlertTypes are the events an admin should act on, each is followed by
+// an alert.fired on the bus.
+var AlertTypes = []string{
+	EventBackupFail,
+	EventServerJobFail,
+	EventServerLowFps,
+	EventServerClockSkew,
+	EventBaseDamaged,
+	EventBaseDestroyed,
+	EventStorageWithdrawn,
+	EventCharacterLost,
+	EventCharacterReset,
+	EventTechnologyLost,
+	EventVpnDetected,
+	EventHighPing,
+}
+
+// AlertOf returns the alert.fired of an event of AlertTypes, with the data
+// of the event, its type as alert and its id as event_id when it's stored.
+func AlertOf(event database.Event) (database.Event, bool) {
+	if !slices.Contains(AlertTypes, event.Type) {
+		return database.Event{}, false
+	}
+	data := make(map[string]string, len(event.Data)+2)
+	for k, v := range event.Data {
+		data[k] = v
+	}
+	data["alert"] = event.Type
+	if event.Id != 0 {
+		data["event_id"] = strconv.FormatUint(event.Id, 10)
+	}
+	return database.Event{
+		Type:           EventAlertFired,
+		Time:           event.Time,
+		PlayerUid:      event.PlayerUid,
+		AdminPlayerUid: event.AdminPlayerUid,
+		Message:        event.Message,
+		Data:           data,
+	}, true
+}
+
+type EventFilter struct {
+	Type           string
+	PlayerUid      string
+	AdminPlayerUid string
+	// GuildId matches the events of the guild by the guild_id of their
+	// data, events without one, from before guilds had ids, by
+	// AdminPlayerUid
+	GuildId   string
+	StartTime time.Time
+	EndTime   time.Time
+	// Data values the event must have
+	Data map[string]string
+	// Expr is a filter expression on the event fields, see EventRecord
+	Expr  filter.Expr
+	Limit int
+}
+
+// Match reports whether the event passes the filter, a Type ending with "*"
+// matches by prefix.
+func (f EventFilter) Match(event database.Event) bool {
+	if f.Type != "" {
+		if strings.HasSuffix(f.Type, "*") {
+			if !strings.HasPrefix(event.Type, strings.TrimSuffix(f.Type, "*")) {
+				return false
+			}
+		} else if event.Type != f.Type {
+			return false
+		}
+	}
+	if f.PlayerUid != "" && event.PlayerUid != f.PlayerUid {
+		return false
+	}
+	if f.GuildId != "" {
+		if id, ok := event.Data["guild_id"]; ok {
+			if id != f.GuildId {
+				return false
+			}
+		} else if event.AdminPlayerUid != f.AdminPlayerUid {
+			return false
+		}
+	} else if f.AdminPlayerUid != "" && event.AdminPlayerUid != f.AdminPlayerUid {
+		return false
+	}
+	if !f.StartTime.IsZero() && event.Time.Before(f.StartTime) {
+		return false
+	}
+	if !f.EndTime.IsZero() && event.Time.After(f.EndTime) {
+		return false
+	}
+	for key, value := range f.Data {
+		if event.Data[key] != value {
+			return false
+		}
+	}
+	if f.Expr != nil && !f.Expr.Match(EventRecord(event)) {
+		return false
+	}
+	return true
+}
+
+// EventRecord gives filter expressions the json fields of the event, data
+// values are data.key or just key when no field has the name.
+func EventRecord(event database.Event) filter.Record {
+	return func(field string) any {
+		switch field {
+		case "id":
+			return event.Id
+		case "type":
+			return event.Type
+		case "time":
+			return event.Time
+		case "player_uid":
+			return event.PlayerUid
+		case "admin_player_uid":
+			return event.AdminPlayerUid
+		case "message":
+			return event.Message
+		}
+		if v, ok := event.Data[strings.TrimPrefix(field, "data.")]; ok {
+			return v
+		}
+		return nil
+	}
+}
+
+func AddEvents(db *bbolt.DB, events []database.Event) ([]database.Event, error) {
+	err := db.Update(func(tx *bbolt.Tx) error {
+		var err error
+		events, err = addEvents(tx, events)
+		return err
+	})
+	return events, err
+}
+
+// addEvents stores events with an increasing id so the bucket keeps time order.
+func addEvents(tx *bbolt.Tx, events []database.Event) ([]database.Event, error) {
+	b := tx.Bucket([]byte("events"))
+	for i := range events {
+		id, err := b.NextSequence()
+		if err != nil {
+			return nil, err
+		}
+		events[i].Id = id
+		if events[i].Time.IsZero() {
+			events[i].Time = time.Now()
+		}
+		v, err := json.Marshal(events[i])
+		if err != nil {
+			return nil, err
+		}
+		touch(tx, "events", eventKey(id))
+		if err := b.Put(eventKey(id), v); err != nil {
+			return nil, err
+		}
+	}
+	return events, nil
+}
+
+// ListEvents returns matching events, newest first.
+func ListEvents(db *bbolt.DB, filter EventFilter) ([]database.Event, error) {
+	events := make([]database.Event, 0)
+	err := db.View(func(tx *bbolt.Tx) error {
+		c := tx.Bucket([]byte("events")).Cursor()
+		for k, v := c.Last(); k != nil; k, v = c.Prev() {
+			var event database.Event
+			if err := json.Unmarshal(v, &event); err != nil {
+				return err
+			}
+			if !filter.Match(event) {
+				continue
+			}
+			events = append(events, event)
+			if filter.Limit > 0 && len(events) >= filter.Limit {
+				break
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+func eventKey(id uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, id)
+	return key
+}
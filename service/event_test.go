@@ -0,0 +1,41 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/zaigie/palworld-server-tool/internal/database"
+)
+
+func TestAlertOf(t *testing.T) {
+	tests := []struct {
+		event database.Event
+		alert bool
+	}{
+		{database.Event{Type: EventBackupFail, Id: 7, Message: "Auto backup failed"}, true},
+		{database.Event{Type: EventBaseDamaged, AdminPlayerUid: "1001", Data: map[string]string{"guild": "Wolves"}}, true},
+		{database.Event{Type: EventBackupSuccess}, false},
+		{database.Event{Type: EventPlayerJoin}, false},
+		{database.Event{Type: EventAlertFired}, false},
+	}
+	for _, tt := range tests {
+		alert, ok := AlertOf(tt.event)
+		if ok != tt.alert {
+			t.Errorf("%s: alert %v, want %v", tt.event.Type, ok, tt.alert)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if alert.Type != EventAlertFired || alert.Data["alert"] != tt.event.Type || alert.Message != tt.event.Message || alert.AdminPlayerUid != tt.event.AdminPlayerUid {
+			t.Errorf("%s: got %+v", tt.event.Type, alert)
+		}
+		for k, v := range tt.event.Data {
+			if alert.Data[k] != v {
+				t.Errorf("%s: data %s is %q, want %q", tt.event.Type, k, alert.Data[k], v)
+			}
+		}
+		if tt.event.Id != 0 && alert.Data["event_id"] != "7" {
+			t.Errorf("%s: event_id %q", tt.event.Type, alert.Data["event_id"])
+		}
+	}
+}
@@ -2,25 +2,292 @@ package service
 
 import (
 	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
 
 	"github.com/zaigie/palworld-server-tool/internal/database"
 	"go.etcd.io/bbolt"
 )
 
-func PutGuilds(db *bbolt.DB, guilds []database.Guild) error {
-	return db.Update(func(tx *bbolt.Tx) error {
-		b := tx.Bucket([]byte("guilds"))
-		for _, g := range guilds {
-			v, err := json.Marshal(g)
-			if err != nil {
-				return err
-			}
-			if err := b.Put([]byte(g.AdminPlayerUid), v); err != nil {
-				return err
-			}
+// RaidThreshold decides when lost base camp structures between two syncs
+// count as a raid, a zero field disables that check.
+type RaidThreshold struct {
+	Structures int
+	HpPercent  float64
+}
+
+const (
+	// GuildsMerge adds or updates the guilds sent and keeps the others
+	GuildsMerge = "merge"
+	// GuildsReplace also deletes the guilds left out, as disbanded
+	GuildsReplace = "replace"
+)
+
+// PutGuilds writes the synced guilds by mode and records guild membership
+// changes and base destruction as events, which are returned for
+// notification. The guilds it changes are kept in j.
+func PutGuilds(db *bbolt.DB, guilds []database.Guild, mode string, threshold RaidThreshold, j *StepJournal) ([]database.Event, error) {
+	if err := prepareGuilds(guilds, mode); err != nil {
+		return nil, err
+	}
+	j.setMode(mode)
+	var events []database.Event
+	err := db.Update(func(tx *bbolt.Tx) error {
+		var err error
+		events, err = putGuilds(tx, guilds, mode, threshold, j)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// prepareGuilds validates the guilds and the mode and normalizes the
+// guilds.
+func prepareGuilds(guilds []database.Guild, mode string) error {
+	if mode != GuildsMerge && mode != GuildsReplace {
+		return fmt.Errorf("unknown guilds mode %q", mode)
+	}
+	if err := validateGuilds(guilds); err != nil {
+		return err
+	}
+	for i := range guilds {
+		normalizeGuild(&guilds[i], CanonicalPlayerUid(guilds[i].AdminPlayerUid))
+	}
+	return nil
+}
+
+func putGuilds(tx *bbolt.Tx, guilds []database.Guild, mode string, threshold RaidThreshold, j *StepJournal) ([]database.Event, error) {
+	b := tx.Bucket([]byte("guilds"))
+
+	existingGuilds := make(map[string]database.Guild)
+	err := b.ForEach(func(k, v []byte) error {
+		var guild database.Guild
+		if err := json.Unmarshal(v, &guild); err != nil {
+			return err
 		}
+		existingGuilds[string(k)] = guild
 		return nil
 	})
+	if err != nil {
+		return nil, err
+	}
+
+	// the first sync only imports guilds, there is nothing to compare with
+	var events []database.Event
+	var moved map[string]string
+	if len(existingGuilds) > 0 {
+		events, moved = diffGuilds(existingGuilds, guilds, threshold, mode == GuildsReplace)
+	}
+
+	newGuilds := make(map[string]bool, len(guilds))
+	for _, g := range guilds {
+		v, err := json.Marshal(g)
+		if err != nil {
+			return nil, err
+		}
+		j.before(tx, b, []byte(g.AdminPlayerUid))
+		if err := b.Put([]byte(g.AdminPlayerUid), v); err != nil {
+			return nil, err
+		}
+		newGuilds[g.AdminPlayerUid] = true
+	}
+
+	// guilds are keyed by their admin, a guild whose admin changed is
+	// stored anew and the old key goes, in replace mode so do the
+	// disbanded ones
+	for key := range existingGuilds {
+		if newGuilds[key] {
+			continue
+		}
+		if _, ok := moved[key]; ok || mode == GuildsReplace {
+			j.before(tx, b, []byte(key))
+			if err := b.Delete([]byte(key)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	j.after(tx, b)
+
+	return addEvents(tx, events)
+}
+
+// diffGuilds matches guilds by id, or for guilds stored without one by
+// admin player uid falling back to guild name, so an admin transfer isn't
+// reported as disband and create. Its events have the guild_id in their
+// data when the guild has one, the history of a guild is read by it. The
+// guilds left out are reported disbanded with disband. moved maps the key
+// of each matched stored guild to the admin it has now.
+func diffGuilds(existingGuilds map[string]database.Guild, guilds []database.Guild, threshold RaidThreshold, disband bool) (events []database.Event, moved map[string]string) {
+	events = make([]database.Event, 0)
+	now := time.Now()
+	moved = make(map[string]string, len(existingGuilds))
+
+	for _, g := range guilds {
+		var old database.Guild
+		var oldKey string
+		ok := false
+		if g.Id != "" {
+			for key, eg := range existingGuilds {
+				if _, taken := moved[key]; !taken && eg.Id == g.Id {
+					old, oldKey, ok = eg, key, true
+					break
+				}
+			}
+		}
+		// a stored guild with an id is only the same guild by that id
+		if !ok {
+			if eg, found := existingGuilds[g.AdminPlayerUid]; found && eg.Id == "" {
+				if _, taken := moved[g.AdminPlayerUid]; !taken {
+					old, oldKey, ok = eg, g.AdminPlayerUid, true
+				}
+			}
+		}
+		if !ok {
+			for key, eg := range existingGuilds {
+				if _, taken := moved[key]; !taken && eg.Id == "" && eg.Name != "" && eg.Name == g.Name {
+					old, oldKey, ok = eg, key, true
+					break
+				}
+			}
+		}
+		if !ok {
+			events = append(events, database.Event{
+				Type:           EventGuildCreate,
+				Time:           now,
+				PlayerUid:      g.AdminPlayerUid,
+				AdminPlayerUid: g.AdminPlayerUid,
+				Message:        fmt.Sprintf("Guild %s was created", g.Name),
+				Data:           guildData(g, map[string]string{"guild": g.Name}),
+			})
+			continue
+		}
+		moved[oldKey] = g.AdminPlayerUid
+		events = append(events, diffBaseCamps(old, g, threshold, now)...)
+
+		oldMembers := make(map[string]*database.GuildPlayer, len(old.Players))
+		for _, p := range old.Players {
+			oldMembers[p.PlayerUid] = p
+		}
+		newMembers := make(map[string]bool, len(g.Players))
+		for _, p := range g.Players {
+			newMembers[p.PlayerUid] = true
+			if _, exists := oldMembers[p.PlayerUid]; !exists {
+				events = append(events, database.Event{
+					Type:           EventGuildJoin,
+					Time:           now,
+					PlayerUid:      p.PlayerUid,
+					AdminPlayerUid: g.AdminPlayerUid,
+					Message:        fmt.Sprintf("Player %s joined guild %s", p.Nickname, g.Name),
+					Data:           guildData(g, map[string]string{"guild": g.Name, "nickname": p.Nickname}),
+				})
+			}
+		}
+		for _, p := range old.Players {
+			if !newMembers[p.PlayerUid] {
+				events = append(events, database.Event{
+					Type:           EventGuildLeave,
+					Time:           now,
+					PlayerUid:      p.PlayerUid,
+					AdminPlayerUid: g.AdminPlayerUid,
+					Message:        fmt.Sprintf("Player %s left guild %s", p.Nickname, g.Name),
+					Data:           guildData(g, map[string]string{"guild": g.Name, "nickname": p.Nickname}),
+				})
+			}
+		}
+	}
+
+	if !disband {
+		return events, moved
+	}
+	for key, eg := range existingGuilds {
+		if _, ok := moved[key]; !ok {
+			events = append(events, database.Event{
+				Type:           EventGuildDisband,
+				Time:           now,
+				PlayerUid:      eg.AdminPlayerUid,
+				AdminPlayerUid: eg.AdminPlayerUid,
+				Message:        fmt.Sprintf("Guild %s was disbanded", eg.Name),
+				Data:           guildData(eg, map[string]string{"guild": eg.Name}),
+			})
+		}
+	}
+	return events, moved
+}
+
+// guildData adds the id of g to the data of one of its events.
+func guildData(g database.Guild, data map[string]string) map[string]string {
+	if g.Id != "" {
+		data["guild_id"] = g.Id
+	}
+	return data
+}
+
+// diffBaseCamps compares the structures of each base camp, camps synced
+// without structure data are skipped.
+func diffBaseCamps(old, g database.Guild, threshold RaidThreshold, now time.Time) []database.Event {
+	events := make([]database.Event, 0)
+	camps := make(map[string]database.BaseCamp, len(g.BaseCamp))
+	for _, camp := range g.BaseCamp {
+		camps[camp.Id] = camp
+	}
+	for _, oldCamp := range old.BaseCamp {
+		if oldCamp.Structures == nil {
+			continue
+		}
+		data := guildData(g, map[string]string{
+			"guild":      g.Name,
+			"base_id":    oldCamp.Id,
+			"location_x": strconv.FormatFloat(oldCamp.LocationX, 'f', 0, 64),
+			"location_y": strconv.FormatFloat(oldCamp.LocationY, 'f', 0, 64),
+		})
+		camp, ok := camps[oldCamp.Id]
+		if !ok {
+			// a base camp only disappears when its pal box is destroyed or dismantled
+			data["structures_lost"] = strconv.Itoa(oldCamp.Structures.Count)
+			events = append(events, database.Event{
+				Type:           EventBaseDestroyed,
+				Time:           now,
+				AdminPlayerUid: g.AdminPlayerUid,
+				Message:        fmt.Sprintf("Base camp of guild %s at (%s, %s) is gone", g.Name, data["location_x"], data["location_y"]),
+				Data:           data,
+			})
+			continue
+		}
+		if camp.Structures == nil {
+			continue
+		}
+		lost := oldCamp.Structures.Count - camp.Structures.Count
+		var hpPercent float64
+		if oldCamp.Structures.Hp > 0 && camp.Structures.Hp < oldCamp.Structures.Hp {
+			hpPercent = float64(oldCamp.Structures.Hp-camp.Structures.Hp) / float64(oldCamp.Structures.Hp) * 100
+		}
+		data["structures_lost"] = strconv.Itoa(lost)
+		data["hp_lost_percent"] = strconv.FormatFloat(hpPercent, 'f', 1, 64)
+		if oldCamp.Structures.PalBox && !camp.Structures.PalBox {
+			events = append(events, database.Event{
+				Type:           EventBaseDestroyed,
+				Time:           now,
+				AdminPlayerUid: g.AdminPlayerUid,
+				Message:        fmt.Sprintf("Pal box of guild %s at (%s, %s) was destroyed", g.Name, data["location_x"], data["location_y"]),
+				Data:           data,
+			})
+			continue
+		}
+		if (threshold.Structures > 0 && lost >= threshold.Structures) ||
+			(threshold.HpPercent > 0 && hpPercent >= threshold.HpPercent) {
+			events = append(events, database.Event{
+				Type:           EventBaseDamaged,
+				Time:           now,
+				AdminPlayerUid: g.AdminPlayerUid,
+				Message:        fmt.Sprintf("Base camp of guild %s at (%s, %s) lost %d structures and %s%% hp", g.Name, data["location_x"], data["location_y"], lost, data["hp_lost_percent"]),
+				Data:           data,
+			})
+		}
+	}
+	return events
 }
 
 func ListGuilds(db *bbolt.DB) ([]database.Guild, error) {
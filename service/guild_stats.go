@@ -0,0 +1,117 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"go.etcd.io/bbolt"
+)
+
+type GuildStatsReport struct {
+	database.GuildStats
+	// History is the daily snapshots, oldest first
+	History []database.GuildStats `json:"history"`
+}
+
+func guildStatsKey(adminPlayerUid, date string) []byte {
+	return []byte(adminPlayerUid + "|" + date)
+}
+
+// guildStats aggregates the stored players of the guild.
+func guildStats(tx *bbolt.Tx, guild database.Guild, now time.Time) (database.GuildStats, error) {
+	stats := database.GuildStats{
+		AdminPlayerUid: guild.AdminPlayerUid,
+		Name:           guild.Name,
+		Date:           now.Format("2006-01-02"),
+		Members:        len(guild.Players),
+		BaseCamps:      len(guild.BaseCamp),
+		BaseCampLevel:  guild.BaseCampLevel,
+	}
+	b := tx.Bucket([]byte("players"))
+	var totalLevel, leveled int32
+	for _, member := range guild.Players {
+		v := b.Get([]byte(member.PlayerUid))
+		if v == nil {
+			continue
+		}
+		var player database.Player
+		if err := json.Unmarshal(v, &player); err != nil {
+			return stats, err
+		}
+		totalLevel += player.Level
+		leveled++
+		if player.Level > stats.MaxLevel {
+			stats.MaxLevel = player.Level
+		}
+		stats.Pals += len(player.Pals)
+		if player.LastOnline.After(stats.LastActive) {
+			stats.LastActive = player.LastOnline
+		}
+	}
+	if leveled > 0 {
+		stats.AverageLevel = float64(totalLevel) / float64(leveled)
+	}
+	return stats, nil
+}
+
+// RecordGuildStats stores today's snapshot of every guild, later syncs of the
+// same day replace it.
+func RecordGuildStats(db *bbolt.DB) error {
+	now := time.Now()
+	return db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte("guild_stats"))
+		return tx.Bucket([]byte("guilds")).ForEach(func(k, v []byte) error {
+			var guild database.Guild
+			if err := json.Unmarshal(v, &guild); err != nil {
+				return err
+			}
+			stats, err := guildStats(tx, guild, now)
+			if err != nil {
+				return err
+			}
+			sv, err := json.Marshal(stats)
+			if err != nil {
+				return err
+			}
+			return b.Put(guildStatsKey(guild.AdminPlayerUid, stats.Date), sv)
+		})
+	})
+}
+
+// GetGuildStats returns the current stats of the guild with the snapshots of
+// the last days.
+func GetGuildStats(db *bbolt.DB, adminPlayerUid string, days int) (GuildStatsReport, error) {
+	var report GuildStatsReport
+	now := time.Now()
+	err := db.View(func(tx *bbolt.Tx) error {
+		v := tx.Bucket([]byte("guilds")).Get([]byte(adminPlayerUid))
+		if v == nil {
+			return ErrNoRecord
+		}
+		var guild database.Guild
+		if err := json.Unmarshal(v, &guild); err != nil {
+			return err
+		}
+		stats, err := guildStats(tx, guild, now)
+		if err != nil {
+			return err
+		}
+		report.GuildStats = stats
+
+		report.History = make([]database.GuildStats, 0)
+		c := tx.Bucket([]byte("guild_stats")).Cursor()
+		from := guildStatsKey(adminPlayerUid, now.AddDate(0, 0, -days).Format("2006-01-02"))
+		prefix := []byte(adminPlayerUid + "|")
+		for k, v := c.Seek(from); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			var snapshot database.GuildStats
+			if err := json.Unmarshal(v, &snapshot); err != nil {
+				return err
+			}
+			report.History = append(report.History, snapshot)
+		}
+		return nil
+	})
+	return report, err
+}
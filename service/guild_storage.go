@@ -0,0 +1,247 @@
+package service
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"go.etcd.io/bbolt"
+)
+
+// campStorage is the storage of a base camp at the last sync, what the next
+// one is compared with.
+type campStorage struct {
+	AdminPlayerUid string           `json:"admin_player_uid"`
+	Items          map[string]int64 `json:"items"`
+	Time           time.Time        `json:"time"`
+}
+
+// TrackGuildStorage stores the item count changes in the chests of every
+// base camp since the last sync, dropping the changes older than keep. A
+// withdrawal of at least withdrawal items of one kind is also an
+// EventStorageWithdrawn, 0 turns the events off. Camps synced without
+// storage keep the counts of the last sync that had it.
+func TrackGuildStorage(db *bbolt.DB, guilds []database.Guild, withdrawal int64, keep time.Duration) ([]database.Event, error) {
+	now := time.Now()
+	var events []database.Event
+	err := db.Update(func(tx *bbolt.Tx) error {
+		sb := tx.Bucket([]byte("guild_storage"))
+		cb := tx.Bucket([]byte("storage_changes"))
+		camps := make(map[string]bool)
+		for _, guild := range guilds {
+			for _, camp := range guild.BaseCamp {
+				camps[camp.Id] = true
+				if camp.Storage == nil {
+					continue
+				}
+				if v := sb.Get([]byte(camp.Id)); v != nil {
+					var last campStorage
+					if err := json.Unmarshal(v, &last); err != nil {
+						return err
+					}
+					changes := diffStorage(last, guild, camp, now)
+					for _, change := range changes {
+						if err := putStorageChange(cb, change); err != nil {
+							return err
+						}
+						if withdrawal > 0 && -change.Delta >= withdrawal {
+							events = append(events, storageWithdrawnEvent(change, camp))
+						}
+					}
+				}
+				v, err := json.Marshal(campStorage{AdminPlayerUid: guild.AdminPlayerUid, Items: camp.Storage, Time: now})
+				if err != nil {
+					return err
+				}
+				if err := sb.Put([]byte(camp.Id), v); err != nil {
+					return err
+				}
+			}
+		}
+
+		// forget the camps that are gone
+		var gone [][]byte
+		if err := sb.ForEach(func(k, v []byte) error {
+			if !camps[string(k)] {
+				gone = append(gone, k)
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+		for _, k := range gone {
+			if err := sb.Delete(k); err != nil {
+				return err
+			}
+		}
+
+		if keep > 0 {
+			c := cb.Cursor()
+			for k, v := c.First(); k != nil; k, v = c.First() {
+				var change database.StorageChange
+				if err := json.Unmarshal(v, &change); err != nil {
+					return err
+				}
+				if !change.Time.Before(now.Add(-keep)) {
+					break
+				}
+				if err := c.Delete(); err != nil {
+					return err
+				}
+			}
+		}
+
+		var err error
+		events, err = addEvents(tx, events)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// diffStorage returns the changed items of the camp by item id, with the
+// members online since the last sync.
+func diffStorage(last campStorage, guild database.Guild, camp database.BaseCamp, now time.Time) []database.StorageChange {
+	items := make([]string, 0, len(camp.Storage))
+	for item := range last.Items {
+		if last.Items[item] != camp.Storage[item] {
+			items = append(items, item)
+		}
+	}
+	for item := range camp.Storage {
+		if _, ok := last.Items[item]; !ok && camp.Storage[item] != 0 {
+			items = append(items, item)
+		}
+	}
+	if len(items) == 0 {
+		return nil
+	}
+	sort.Strings(items)
+
+	// last_online of the save is in seconds
+	since := last.Time.Truncate(time.Second)
+	online := make([]*database.GuildPlayer, 0)
+	for _, member := range guild.Players {
+		if t, err := time.Parse(time.RFC3339, member.LastOnline); err == nil && !t.Before(since) {
+			online = append(online, member)
+		}
+	}
+	changes := make([]database.StorageChange, 0, len(items))
+	for _, item := range items {
+		changes = append(changes, database.StorageChange{
+			Time:           now,
+			AdminPlayerUid: guild.AdminPlayerUid,
+			Guild:          guild.Name,
+			BaseCampId:     camp.Id,
+			ItemId:         item,
+			Before:         last.Items[item],
+			After:          camp.Storage[item],
+			Delta:          camp.Storage[item] - last.Items[item],
+			Since:          last.Time,
+			Online:         online,
+		})
+	}
+	return changes
+}
+
+func putStorageChange(b *bbolt.Bucket, change database.StorageChange) error {
+	id, err := b.NextSequence()
+	if err != nil {
+		return err
+	}
+	v, err := json.Marshal(change)
+	if err != nil {
+		return err
+	}
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, id)
+	return b.Put(key, v)
+}
+
+func storageWithdrawnEvent(change database.StorageChange, camp database.BaseCamp) database.Event {
+	count := strconv.FormatInt(-change.Delta, 10)
+	data := map[string]string{
+		"guild":      change.Guild,
+		"base_id":    camp.Id,
+		"item_id":    change.ItemId,
+		"count":      count,
+		"location_x": strconv.FormatFloat(camp.LocationX, 'f', 0, 64),
+		"location_y": strconv.FormatFloat(camp.LocationY, 'f', 0, 64),
+	}
+	names := make([]string, 0, len(change.Online))
+	for _, member := range change.Online {
+		names = append(names, member.Nickname)
+	}
+	data["online"] = strings.Join(names, ", ")
+	return database.Event{
+		Type:           EventStorageWithdrawn,
+		Time:           change.Time,
+		AdminPlayerUid: change.AdminPlayerUid,
+		Message:        fmt.Sprintf("%s %s were taken from the base camp of guild %s at (%s, %s)", count, change.ItemId, change.Guild, data["location_x"], data["location_y"]),
+		Data:           data,
+	}
+}
+
+type StorageChangeFilter struct {
+	AdminPlayerUid string
+	BaseCampId     string
+	StartTime      time.Time
+	EndTime        time.Time
+	// Withdrawals selects the changes that took at least this many items,
+	// 0 selects every change
+	Withdrawals int64
+	// OrderBy is time, newest first, or amount, the largest withdrawal first
+	OrderBy string
+	Limit   int
+}
+
+func (f StorageChangeFilter) Match(change database.StorageChange) bool {
+	if f.AdminPlayerUid != "" && change.AdminPlayerUid != f.AdminPlayerUid {
+		return false
+	}
+	if f.BaseCampId != "" && change.BaseCampId != f.BaseCampId {
+		return false
+	}
+	if !f.StartTime.IsZero() && change.Time.Before(f.StartTime) {
+		return false
+	}
+	if !f.EndTime.IsZero() && change.Time.After(f.EndTime) {
+		return false
+	}
+	return f.Withdrawals <= 0 || -change.Delta >= f.Withdrawals
+}
+
+// ListStorageChanges returns the stored changes passing the filter.
+func ListStorageChanges(db *bbolt.DB, f StorageChangeFilter) ([]database.StorageChange, error) {
+	changes := make([]database.StorageChange, 0)
+	err := db.View(func(tx *bbolt.Tx) error {
+		c := tx.Bucket([]byte("storage_changes")).Cursor()
+		for k, v := c.Last(); k != nil; k, v = c.Prev() {
+			var change database.StorageChange
+			if err := json.Unmarshal(v, &change); err != nil {
+				return err
+			}
+			if f.Match(change) {
+				changes = append(changes, change)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if f.OrderBy == "amount" {
+		sort.SliceStable(changes, func(i, j int) bool { return changes[i].Delta < changes[j].Delta })
+	}
+	if f.Limit > 0 && len(changes) > f.Limit {
+		changes = changes[:f.Limit]
+	}
+	return changes, nil
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/zaigie/palworld-server-tool/internal/database"
+)
+
+func TestGuildHistoryById(t *testing.T) {
+	db := openTestDB(t)
+	member := func(uid, nickname string) *database.GuildPlayer {
+		return &database.GuildPlayer{PlayerUid: uid, Nickname: nickname}
+	}
+	if _, err := PutGuilds(db, []database.Guild{
+		{Id: "77", Name: "Wolves", AdminPlayerUid: "1001", Players: []*database.GuildPlayer{member("1001", "alice"), member("1002", "bob")}},
+		{Name: "Old", AdminPlayerUid: "1005", Players: []*database.GuildPlayer{member("1005", "erin")}},
+	}, GuildsMerge, RaidThreshold{}, nil); err != nil {
+		t.Fatal(err)
+	}
+	// alice hands the guild to bob and it's renamed, only the id ties
+	// the two together
+	events, err := PutGuilds(db, []database.Guild{
+		{Id: "77", Name: "Lions", AdminPlayerUid: "1002", Players: []*database.GuildPlayer{member("1002", "bob"), member("1003", "carol")}},
+		{Name: "Old", AdminPlayerUid: "1005", Players: []*database.GuildPlayer{member("1005", "erin"), member("1006", "frank")}},
+	}, GuildsMerge, RaidThreshold{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, event := range events {
+		if event.Type == EventGuildCreate || event.Type == EventGuildDisband {
+			t.Errorf("unexpected %s: %s", event.Type, event.Message)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		filter EventFilter
+		want   []string
+	}{
+		{"by id", EventFilter{Type: "guild.*", GuildId: "77", AdminPlayerUid: "1002"}, []string{"1001", "1003"}},
+		{"without id", EventFilter{Type: "guild.*", AdminPlayerUid: "1005"}, []string{"1006"}},
+		{"other id", EventFilter{Type: "guild.*", GuildId: "78", AdminPlayerUid: "1002"}, nil},
+	}
+	for _, tt := range tests {
+		history, err := ListEvents(db, tt.filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := make(map[string]bool)
+		for _, event := range history {
+			got[event.PlayerUid] = true
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+			continue
+		}
+		for _, uid := range tt.want {
+			if !got[uid] {
+				t.Errorf("%s: no event of %s in %v", tt.name, uid, got)
+			}
+		}
+	}
+}
+
+func TestPutGuildsModes(t *testing.T) {
+	db := openTestDB(t)
+	member := func(uid string) *database.GuildPlayer {
+		return &database.GuildPlayer{PlayerUid: uid, Nickname: uid}
+	}
+	if _, err := PutGuilds(db, []database.Guild{
+		{Id: "77", Name: "Wolves", AdminPlayerUid: "1001", Players: []*database.GuildPlayer{member("1001")}},
+		{Id: "78", Name: "Bears", AdminPlayerUid: "1002", Players: []*database.GuildPlayer{member("1002")}},
+		{Name: "Old", AdminPlayerUid: "1003", Players: []*database.GuildPlayer{member("1003")}},
+	}, GuildsMerge, RaidThreshold{}, nil); err != nil {
+		t.Fatal(err)
+	}
+	types := func(events []database.Event) map[string]int {
+		n := make(map[string]int)
+		for _, event := range events {
+			n[event.Type]++
+		}
+		return n
+	}
+	stored := func() []string {
+		guilds, err := ListGuilds(db)
+		if err != nil {
+			t.Fatal(err)
+		}
+		names := make([]string, 0, len(guilds))
+		for _, g := range guilds {
+			names = append(names, g.Name)
+		}
+		return names
+	}
+
+	// guild 79 named like 78 is another guild, and merge keeps the guilds
+	// left out without reporting them
+	events, err := PutGuilds(db, []database.Guild{
+		{Id: "79", Name: "Bears", AdminPlayerUid: "1004", Players: []*database.GuildPlayer{member("1004")}},
+	}, GuildsMerge, RaidThreshold{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := types(events); n[EventGuildCreate] != 1 || n[EventGuildDisband] != 0 {
+		t.Errorf("merge events %v, want one create and no disband", n)
+	}
+	if names := stored(); len(names) != 4 {
+		t.Errorf("merge stored %v, want all 4", names)
+	}
+
+	events, err = PutGuilds(db, []database.Guild{
+		{Id: "77", Name: "Wolves", AdminPlayerUid: "1001", Players: []*database.GuildPlayer{member("1001")}},
+	}, GuildsReplace, RaidThreshold{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := types(events); n[EventGuildDisband] != 3 {
+		t.Errorf("replace events %v, want three disbands", n)
+	}
+	if names := stored(); len(names) != 1 || names[0] != "Wolves" {
+		t.Errorf("replace stored %v, want Wolves", names)
+	}
+
+	if _, err := PutGuilds(db, nil, "append", RaidThreshold{}, nil); err == nil {
+		t.Error("PutGuilds with mode append, want an error")
+	}
+}
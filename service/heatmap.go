@@ -0,0 +1,76 @@
+package service
+
+import (
+	"encoding/json"
+
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"go.etcd.io/bbolt"
+)
+
+// AddHeatmapSamples counts map positions, given as lat and lng within
+// [-mapSize, 0] and [0, mapSize], into the grid of the day. A day keeps the
+// grid size it was created with.
+func AddHeatmapSamples(db *bbolt.DB, date string, size int, mapSize float64, positions [][2]float64) error {
+	return db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte("heatmaps"))
+		heatmap := database.Heatmap{Date: date, Size: size, Cells: make([]int, size*size)}
+		if v := b.Get([]byte(date)); v != nil {
+			if err := json.Unmarshal(v, &heatmap); err != nil {
+				return err
+			}
+		}
+		for _, pos := range positions {
+			row := int(-pos[0] / mapSize * float64(heatmap.Size))
+			col := int(pos[1] / mapSize * float64(heatmap.Size))
+			if row < 0 || row >= heatmap.Size || col < 0 || col >= heatmap.Size {
+				continue
+			}
+			heatmap.Cells[row*heatmap.Size+col]++
+			heatmap.Samples++
+		}
+		v, err := json.Marshal(heatmap)
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte(date), v)
+	})
+}
+
+// ListHeatmaps returns the heatmaps of dates between start and end inclusive,
+// dates are formatted as 2006-01-02 so they sort by time.
+func ListHeatmaps(db *bbolt.DB, start, end string) ([]database.Heatmap, error) {
+	heatmaps := make([]database.Heatmap, 0)
+	err := db.View(func(tx *bbolt.Tx) error {
+		c := tx.Bucket([]byte("heatmaps")).Cursor()
+		for k, v := c.Seek([]byte(start)); k != nil && string(k) <= end; k, v = c.Next() {
+			var heatmap database.Heatmap
+			if err := json.Unmarshal(v, &heatmap); err != nil {
+				return err
+			}
+			heatmaps = append(heatmaps, heatmap)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return heatmaps, nil
+}
+
+// CleanHeatmaps removes the heatmaps of dates before the given one.
+func CleanHeatmaps(db *bbolt.DB, before string) error {
+	return db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte("heatmaps"))
+		var keys [][]byte
+		c := b.Cursor()
+		for k, _ := c.First(); k != nil && string(k) < before; k, _ = c.Next() {
+			keys = append(keys, k)
+		}
+		for _, k := range keys {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"encoding/json"
+	"strings"
+
+	"go.etcd.io/bbolt"
+)
+
+// OrphanReport tells what PruneOrphans removed, or would remove on a dry run.
+type OrphanReport struct {
+	DryRun bool `json:"dry_run"`
+	// Records counts the removed records by bucket
+	Records map[string]int `json:"records"`
+	// Bytes is the size of the keys and values removed
+	Bytes int64 `json:"bytes"`
+	// FreeBytes is the space free in the database file afterwards, reused
+	// before the file grows again
+	FreeBytes int64 `json:"free_bytes"`
+	FileSize  int64 `json:"file_size"`
+}
+
+// playerKeyedBuckets hold one record per player keyed by the player uid, or
+// by the uid and a | separated suffix.
+var playerKeyedBuckets = []string{"playtimes", "badges", "points", "ping_history", "player_ips"}
+
+// playerOwnedBuckets hold records naming their player in player_uid.
+var playerOwnedBuckets = []string{"events", "point_transactions"}
+
+// PruneOrphans removes the records of players no longer in the players
+// bucket, and the backup index entries of deleted backups. Nothing is pruned
+// while there are no players at all, like before the first sync.
+func PruneOrphans(db *bbolt.DB, dryRun bool) (OrphanReport, error) {
+	report := OrphanReport{DryRun: dryRun, Records: make(map[string]int)}
+	prune := func(tx *bbolt.Tx) error {
+		players := tx.Bucket([]byte("players"))
+		if players.Stats().KeyN == 0 {
+			return nil
+		}
+		exists := func(playerUid string) bool {
+			return players.Get([]byte(playerUid)) != nil
+		}
+		removeKeys := func(bucket string, keep func(k, v []byte) bool) error {
+			b := tx.Bucket([]byte(bucket))
+			var orphans [][]byte
+			err := b.ForEach(func(k, v []byte) error {
+				if !keep(k, v) {
+					orphans = append(orphans, k)
+					report.Bytes += int64(len(k) + len(v))
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			report.Records[bucket] = len(orphans)
+			if dryRun {
+				return nil
+			}
+			for _, k := range orphans {
+				if err := b.Delete(k); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+		for _, bucket := range playerKeyedBuckets {
+			err := removeKeys(bucket, func(k, _ []byte) bool {
+				playerUid, _, _ := strings.Cut(string(k), "|")
+				return exists(playerUid)
+			})
+			if err != nil {
+				return err
+			}
+		}
+		for _, bucket := range playerOwnedBuckets {
+			err := removeKeys(bucket, func(_, v []byte) bool {
+				var record struct {
+					PlayerUid string `json:"player_uid"`
+				}
+				// records not about a player, or unreadable, are kept
+				if json.Unmarshal(v, &record) != nil || record.PlayerUid == "" {
+					return true
+				}
+				return exists(record.PlayerUid)
+			})
+			if err != nil {
+				return err
+			}
+		}
+		backups := tx.Bucket([]byte("backups"))
+		return removeKeys("backup_times", func(k, _ []byte) bool {
+			return len(k) > 8 && backups.Get(k[8:]) != nil
+		})
+	}
+	var err error
+	if dryRun {
+		err = db.View(prune)
+	} else {
+		err = db.Update(prune)
+	}
+	if err != nil {
+		return report, err
+	}
+
+	err = db.View(func(tx *bbolt.Tx) error {
+		report.FileSize = tx.Size()
+		return nil
+	})
+	report.FreeBytes = int64(db.Stats().FreePageN) * int64(db.Info().PageSize)
+	return report, err
+}
+
+// Total is how many records were removed.
+func (r OrphanReport) Total() int {
+	total := 0
+	for _, n := range r.Records {
+		total += n
+	}
+	return total
+}
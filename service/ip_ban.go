@@ -0,0 +1,136 @@
+package service
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"go.etcd.io/bbolt"
+)
+
+func PutIpBan(db *bbolt.DB, ban database.IpBan) error {
+	return db.Update(func(tx *bbolt.Tx) error {
+		return putIpBan(tx, ban)
+	})
+}
+
+func putIpBan(tx *bbolt.Tx, ban database.IpBan) error {
+	v, err := json.Marshal(ban)
+	if err != nil {
+		return err
+	}
+	touch(tx, "ipbans", []byte(ban.Ip))
+	return tx.Bucket([]byte("ipbans")).Put([]byte(ban.Ip), v)
+}
+
+// ListIpBans returns the bans that haven't expired and drops the rest.
+func ListIpBans(db *bbolt.DB) ([]database.IpBan, error) {
+	bans := make([]database.IpBan, 0)
+	now := time.Now()
+	err := db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte("ipbans"))
+		var expired [][]byte
+		err := b.ForEach(func(k, v []byte) error {
+			var ban database.IpBan
+			if err := json.Unmarshal(v, &ban); err != nil {
+				return err
+			}
+			if !ban.ExpiresAt.IsZero() && ban.ExpiresAt.Before(now) {
+				expired = append(expired, k)
+				return nil
+			}
+			bans = append(bans, ban)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, k := range expired {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return bans, nil
+}
+
+type IpBanFilter struct {
+	// Query matches part of the ip or the reason
+	Query string
+	// OrderBy is ip, created_at or expires_at, ip by default
+	OrderBy string
+	Desc    bool
+	Offset  int
+	Limit   int
+}
+
+func (f IpBanFilter) Match(ban database.IpBan) bool {
+	if f.Query == "" {
+		return true
+	}
+	q := strings.ToLower(f.Query)
+	return strings.Contains(ban.Ip, q) || strings.Contains(strings.ToLower(ban.Reason), q)
+}
+
+// SearchIpBans returns a page of the bans matching filter and how many
+// match in all.
+func SearchIpBans(db *bbolt.DB, filter IpBanFilter) ([]database.IpBan, int, error) {
+	bans, err := ListIpBans(db)
+	if err != nil {
+		return nil, 0, err
+	}
+	matched := make([]database.IpBan, 0, len(bans))
+	for _, ban := range bans {
+		if filter.Match(ban) {
+			matched = append(matched, ban)
+		}
+	}
+	less := func(a, b database.IpBan) bool { return a.Ip < b.Ip }
+	switch filter.OrderBy {
+	case "created_at":
+		less = func(a, b database.IpBan) bool { return a.CreatedAt.Before(b.CreatedAt) }
+	case "expires_at":
+		// bans that never expire last
+		less = func(a, b database.IpBan) bool {
+			return !a.ExpiresAt.IsZero() && (b.ExpiresAt.IsZero() || a.ExpiresAt.Before(b.ExpiresAt))
+		}
+	}
+	sort.SliceStable(matched, func(i, j int) bool {
+		if filter.Desc {
+			return less(matched[j], matched[i])
+		}
+		return less(matched[i], matched[j])
+	})
+	return page(matched, filter.Offset, filter.Limit), len(matched), nil
+}
+
+// RemoveIpBan lifts the ban of ip, keeping it in the history of removed
+// entries.
+func RemoveIpBan(db *bbolt.DB, ip string, removal Removal) error {
+	return db.Update(func(tx *bbolt.Tx) error {
+		return removeIpBan(tx, ip, removal)
+	})
+}
+
+func removeIpBan(tx *bbolt.Tx, ip string, removal Removal) error {
+	b := tx.Bucket([]byte("ipbans"))
+	v := b.Get([]byte(ip))
+	if v == nil {
+		return ErrNoRecord
+	}
+	var ban database.IpBan
+	if err := json.Unmarshal(v, &ban); err != nil {
+		return err
+	}
+	if err := addRemoved(tx, database.RemovedEntry{Kind: RemovedIpBan, IpBan: &ban}, removal); err != nil {
+		return err
+	}
+	touch(tx, "ipbans", []byte(ip))
+	return b.Delete([]byte(ip))
+}
@@ -0,0 +1,47 @@
+package service
+
+import (
+	"encoding/json"
+
+	"go.etcd.io/bbolt"
+)
+
+// PutLocale stores the custom messages of a language.
+func PutLocale(db *bbolt.DB, lang string, messages map[string]string) error {
+	return db.Update(func(tx *bbolt.Tx) error {
+		v, err := json.Marshal(messages)
+		if err != nil {
+			return err
+		}
+		return tx.Bucket([]byte("locales")).Put([]byte(lang), v)
+	})
+}
+
+// ListLocales returns the custom messages by language.
+func ListLocales(db *bbolt.DB) (map[string]map[string]string, error) {
+	locales := make(map[string]map[string]string)
+	err := db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket([]byte("locales")).ForEach(func(k, v []byte) error {
+			messages := make(map[string]string)
+			if err := json.Unmarshal(v, &messages); err != nil {
+				return err
+			}
+			locales[string(k)] = messages
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return locales, nil
+}
+
+func RemoveLocale(db *bbolt.DB, lang string) error {
+	return db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte("locales"))
+		if b.Get([]byte(lang)) == nil {
+			return ErrNoRecord
+		}
+		return b.Delete([]byte(lang))
+	})
+}
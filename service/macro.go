@@ -0,0 +1,58 @@
+package service
+
+import (
+	"encoding/json"
+
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"go.etcd.io/bbolt"
+)
+
+func PutMacro(db *bbolt.DB, macro database.Macro) error {
+	return db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte("macros"))
+		v, err := json.Marshal(macro)
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte(macro.Name), v)
+	})
+}
+
+func GetMacro(db *bbolt.DB, name string) (database.Macro, error) {
+	var macro database.Macro
+	err := db.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte("macros"))
+		v := b.Get([]byte(name))
+		if v == nil {
+			return ErrNoRecord
+		}
+		return json.Unmarshal(v, &macro)
+	})
+	return macro, err
+}
+
+func ListMacros(db *bbolt.DB) ([]database.Macro, error) {
+	macros := make([]database.Macro, 0)
+	err := db.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte("macros"))
+		return b.ForEach(func(k, v []byte) error {
+			var macro database.Macro
+			if err := json.Unmarshal(v, &macro); err != nil {
+				return err
+			}
+			macros = append(macros, macro)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return macros, nil
+}
+
+func RemoveMacro(db *bbolt.DB, name string) error {
+	return db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte("macros"))
+		return b.Delete([]byte(name))
+	})
+}
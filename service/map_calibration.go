@@ -0,0 +1,39 @@
+package service
+
+import (
+	"encoding/json"
+
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"go.etcd.io/bbolt"
+)
+
+func PutMapCalibration(db *bbolt.DB, calibration database.MapCalibration) error {
+	return db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte("map_calibrations"))
+		v, err := json.Marshal(calibration)
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte(calibration.Version), v)
+	})
+}
+
+func GetMapCalibration(db *bbolt.DB, version string) (database.MapCalibration, error) {
+	var calibration database.MapCalibration
+	err := db.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte("map_calibrations"))
+		v := b.Get([]byte(version))
+		if v == nil {
+			return ErrNoRecord
+		}
+		return json.Unmarshal(v, &calibration)
+	})
+	return calibration, err
+}
+
+func RemoveMapCalibration(db *bbolt.DB, version string) error {
+	return db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte("map_calibrations"))
+		return b.Delete([]byte(version))
+	})
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"go.etcd.io/bbolt"
+)
+
+const (
+	MapObjectPalBox    = "pal_box"
+	MapObjectChest     = "chest"
+	MapObjectDropItem  = "drop_item"
+	MapObjectEgg       = "egg"
+	MapObjectStructure = "structure"
+)
+
+type MapObjectFilter struct {
+	// bounding box in world coordinates, ignored when all zero
+	MinX, MinY, MaxX, MaxY float64
+	Category               string
+	// "true" only lists objects belonging to a base camp, "false" only the others
+	InBase string
+	Offset int
+	Limit  int
+}
+
+func (f MapObjectFilter) Match(object database.MapObject) bool {
+	if f.MinX != 0 || f.MinY != 0 || f.MaxX != 0 || f.MaxY != 0 {
+		if object.LocationX < f.MinX || object.LocationX > f.MaxX ||
+			object.LocationY < f.MinY || object.LocationY > f.MaxY {
+			return false
+		}
+	}
+	if f.Category != "" && object.Category != f.Category {
+		return false
+	}
+	inBase := object.BaseCampId != "" && object.BaseCampId != "0"
+	if (f.InBase == "true" && !inBase) || (f.InBase == "false" && inBase) {
+		return false
+	}
+	return true
+}
+
+func mapObjectCategory(name string) string {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasPrefix(lower, "palbox"):
+		return MapObjectPalBox
+	case strings.Contains(lower, "dropitem"):
+		return MapObjectDropItem
+	case strings.HasPrefix(lower, "palegg"):
+		return MapObjectEgg
+	case strings.Contains(lower, "chest"):
+		return MapObjectChest
+	default:
+		return MapObjectStructure
+	}
+}
+
+// PutMapObjects replaces all map objects with the ones of the latest save,
+// keeping the ones it changes in j.
+func PutMapObjects(db *bbolt.DB, objects []database.MapObject, j *StepJournal) error {
+	if err := validateMapObjects(objects); err != nil {
+		return err
+	}
+	return db.Update(func(tx *bbolt.Tx) error {
+		// every object is replaced
+		if old := tx.Bucket([]byte("map_objects")); old != nil && j != nil {
+			if err := old.ForEach(func(k, v []byte) error {
+				j.before(tx, old, k)
+				return nil
+			}); err != nil {
+				return err
+			}
+		}
+		if err := tx.DeleteBucket([]byte("map_objects")); err != nil && err != bbolt.ErrBucketNotFound {
+			return err
+		}
+		b, err := tx.CreateBucket([]byte("map_objects"))
+		if err != nil {
+			return err
+		}
+		for _, o := range objects {
+			o.Category = mapObjectCategory(o.Name)
+			v, err := json.Marshal(o)
+			if err != nil {
+				return err
+			}
+			j.before(tx, b, []byte(o.InstanceId))
+			if err := b.Put([]byte(o.InstanceId), v); err != nil {
+				return err
+			}
+		}
+		j.after(tx, b)
+		return nil
+	})
+}
+
+// ListMapObjects returns a page of the matching objects and the total count
+// of matches.
+func ListMapObjects(db *bbolt.DB, filter MapObjectFilter) ([]database.MapObject, int, error) {
+	objects := make([]database.MapObject, 0)
+	total := 0
+	err := db.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte("map_objects"))
+		return b.ForEach(func(k, v []byte) error {
+			var object database.MapObject
+			if err := json.Unmarshal(v, &object); err != nil {
+				return err
+			}
+			if !filter.Match(object) {
+				return nil
+			}
+			total++
+			if total > filter.Offset && (filter.Limit <= 0 || len(objects) < filter.Limit) {
+				objects = append(objects, object)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return objects, total, nil
+}
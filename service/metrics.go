@@ -0,0 +1,104 @@
+package service
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"time"
+
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"go.etcd.io/bbolt"
+)
+
+// AddMetricSample stores the sample keyed by time and drops the samples
+// older than keep.
+func AddMetricSample(db *bbolt.DB, sample database.MetricSample, keep time.Duration) error {
+	return db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte("metrics"))
+		v, err := json.Marshal(sample)
+		if err != nil {
+			return err
+		}
+		if err := b.Put(metricKey(sample.Time), v); err != nil {
+			return err
+		}
+		if keep <= 0 {
+			return nil
+		}
+		c := b.Cursor()
+		cutoff := metricKey(sample.Time.Add(-keep))
+		for k, _ := c.First(); k != nil && string(k) < string(cutoff); k, _ = c.First() {
+			if err := c.Delete(); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ListMetricSamples returns the samples since the given time, oldest first,
+// averaged down to at most points samples when points is above zero.
+func ListMetricSamples(db *bbolt.DB, since time.Time, points int) ([]database.MetricSample, error) {
+	samples := make([]database.MetricSample, 0)
+	err := db.View(func(tx *bbolt.Tx) error {
+		c := tx.Bucket([]byte("metrics")).Cursor()
+		for k, v := c.Seek(metricKey(since)); k != nil; k, v = c.Next() {
+			var sample database.MetricSample
+			if err := json.Unmarshal(v, &sample); err != nil {
+				return err
+			}
+			samples = append(samples, sample)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return downsampleMetrics(samples, points), nil
+}
+
+func downsampleMetrics(samples []database.MetricSample, points int) []database.MetricSample {
+	if points <= 0 || len(samples) <= points {
+		return samples
+	}
+	avg := func(sum float64, n int) float64 { return math.Round(sum/float64(n)*100) / 100 }
+	series := make([]database.MetricSample, 0, points)
+	for i := 0; i < points; i++ {
+		start, end := i*len(samples)/points, (i+1)*len(samples)/points
+		var sum database.MetricSample
+		for _, s := range samples[start:end] {
+			sum.ServerFps += s.ServerFps
+			sum.ServerFrameTime += s.ServerFrameTime
+			sum.Players += s.Players
+			sum.BaseCamps += s.BaseCamps
+		}
+		n := end - start
+		series = append(series, database.MetricSample{
+			Time:            samples[end-1].Time,
+			ServerFps:       avg(sum.ServerFps, n),
+			ServerFrameTime: avg(sum.ServerFrameTime, n),
+			Players:         avg(sum.Players, n),
+			BaseCamps:       avg(sum.BaseCamps, n),
+		})
+	}
+	return series
+}
+
+// CountBaseCamps counts the base camps of the guilds from the last save sync.
+func CountBaseCamps(db *bbolt.DB) (int, error) {
+	guilds, err := ListGuilds(db)
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, guild := range guilds {
+		count += len(guild.BaseCamp)
+	}
+	return count, nil
+}
+
+func metricKey(t time.Time) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, uint64(t.Unix()))
+	return key
+}
@@ -0,0 +1,179 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"go.etcd.io/bbolt"
+)
+
+// TrackPals compares the owner and location of each pal instance with the
+// last save sync and records trades, moves between a base and the party or
+// palbox, and pals gone from their owner as events, which are returned for
+// notification. Pals without an instance id are skipped.
+func TrackPals(db *bbolt.DB, players []database.Player) ([]database.Event, error) {
+	var events []database.Event
+	err := db.Update(func(tx *bbolt.Tx) error {
+		var err error
+		events, err = trackPals(tx, players)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+func trackPals(tx *bbolt.Tx, players []database.Player) ([]database.Event, error) {
+	var events []database.Event
+	b := tx.Bucket([]byte("pal_owners"))
+
+	existing := make(map[string]database.PalOwner)
+	err := b.ForEach(func(k, v []byte) error {
+		var owner database.PalOwner
+		if err := json.Unmarshal(v, &owner); err != nil {
+			return err
+		}
+		existing[string(k)] = owner
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	nicknames := make(map[string]string, len(players))
+	for _, p := range players {
+		nicknames[CanonicalPlayerUid(p.PlayerUid)] = p.Nickname
+	}
+	seen := make(map[string]bool)
+	for _, p := range players {
+		playerUid := CanonicalPlayerUid(p.PlayerUid)
+		for _, pal := range p.Pals {
+			if pal == nil || pal.InstanceId == "" || seen[pal.InstanceId] {
+				continue
+			}
+			seen[pal.InstanceId] = true
+			owner, ok := existing[pal.InstanceId]
+			if ok {
+				events = append(events, diffPal(owner, playerUid, pal, nicknames, now)...)
+			} else {
+				owner = database.PalOwner{InstanceId: pal.InstanceId, FirstSeen: now}
+			}
+			owner.PlayerUid = playerUid
+			owner.Type = pal.Type
+			owner.Nickname = pal.Nickname
+			if pal.Location != "" {
+				owner.Location = pal.Location
+			}
+			owner.LastSeen = now
+			v, err := json.Marshal(owner)
+			if err != nil {
+				return nil, err
+			}
+			touch(tx, "pal_owners", []byte(pal.InstanceId))
+			if err := b.Put([]byte(pal.InstanceId), v); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// a save read by an older sav_cli has no instance ids, that isn't
+	// every pal gone
+	if len(seen) == 0 {
+		return nil, nil
+	}
+	for instanceId, owner := range existing {
+		if seen[instanceId] {
+			continue
+		}
+		// pals of a player gone from the save go with the player
+		if _, ok := nicknames[owner.PlayerUid]; ok {
+			events = append(events, database.Event{
+				Type:      EventPalReleased,
+				Time:      now,
+				PlayerUid: owner.PlayerUid,
+				Message:   fmt.Sprintf("%s of %s is gone, released or sold", palName(owner.Type, owner.Nickname), nicknames[owner.PlayerUid]),
+				Data:      palData(owner.InstanceId, owner.Type, owner.Nickname),
+			})
+		}
+		touch(tx, "pal_owners", []byte(instanceId))
+		if err := b.Delete([]byte(instanceId)); err != nil {
+			return nil, err
+		}
+	}
+
+	return addEvents(tx, events)
+}
+
+func diffPal(owner database.PalOwner, playerUid string, pal *database.Pal, nicknames map[string]string, now time.Time) []database.Event {
+	var events []database.Event
+	name := palName(pal.Type, pal.Nickname)
+	if owner.PlayerUid != playerUid {
+		data := palData(pal.InstanceId, pal.Type, pal.Nickname)
+		data["from_player_uid"] = owner.PlayerUid
+		data["to_player_uid"] = playerUid
+		events = append(events, database.Event{
+			Type:      EventPalTraded,
+			Time:      now,
+			PlayerUid: playerUid,
+			Message:   fmt.Sprintf("%s went from %s to %s", name, nicknameOr(nicknames, owner.PlayerUid), nicknameOr(nicknames, playerUid)),
+			Data:      data,
+		})
+	}
+	// moves between the party and the palbox are too frequent to be of interest
+	if owner.Location != "" && pal.Location != "" && (owner.Location == "base") != (pal.Location == "base") {
+		data := palData(pal.InstanceId, pal.Type, pal.Nickname)
+		data["from"] = owner.Location
+		data["to"] = pal.Location
+		events = append(events, database.Event{
+			Type:      EventPalMoved,
+			Time:      now,
+			PlayerUid: playerUid,
+			Message:   fmt.Sprintf("%s of %s moved from %s to %s", name, nicknameOr(nicknames, playerUid), owner.Location, pal.Location),
+			Data:      data,
+		})
+	}
+	return events
+}
+
+func palData(instanceId, palType, nickname string) map[string]string {
+	return map[string]string{"instance_id": instanceId, "type": palType, "nickname": nickname}
+}
+
+func palName(palType, nickname string) string {
+	if nickname != "" {
+		return fmt.Sprintf("%s (%s)", nickname, palType)
+	}
+	return palType
+}
+
+func nicknameOr(nicknames map[string]string, playerUid string) string {
+	if nickname := nicknames[playerUid]; nickname != "" {
+		return nickname
+	}
+	return playerUid
+}
+
+func GetPalOwner(db *bbolt.DB, instanceId string) (database.PalOwner, error) {
+	var owner database.PalOwner
+	err := db.View(func(tx *bbolt.Tx) error {
+		v := tx.Bucket([]byte("pal_owners")).Get([]byte(instanceId))
+		if v == nil {
+			return ErrNoRecord
+		}
+		return json.Unmarshal(v, &owner)
+	})
+	return owner, err
+}
+
+// ListPalHistory returns the events of a pal instance, newest first.
+func ListPalHistory(db *bbolt.DB, instanceId string, limit int) ([]database.Event, error) {
+	return ListEvents(db, EventFilter{
+		Type:  "pal.*",
+		Data:  map[string]string{"instance_id": instanceId},
+		Limit: limit,
+	})
+}
@@ -0,0 +1,193 @@
+package service
+
+import (
+	"encoding/json"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"go.etcd.io/bbolt"
+)
+
+type PingSample struct {
+	Time time.Time `json:"time"`
+	Ping float64   `json:"ping"`
+}
+
+type PingStats struct {
+	PlayerUid string  `json:"player_uid"`
+	Samples   int     `json:"samples"`
+	Min       float64 `json:"min"`
+	Max       float64 `json:"max"`
+	Avg       float64 `json:"avg"`
+	P50       float64 `json:"p50"`
+	P90       float64 `json:"p90"`
+	P99       float64 `json:"p99"`
+	// Series is the history averaged down to at most the requested points,
+	// for a sparkline
+	Series []PingSample `json:"series"`
+}
+
+// RecordPings adds the ping of the online players to their history of size
+// samples. It returns the histories whose last highSamples pings all went
+// above highMs for the first time, highMs 0 reports none.
+func RecordPings(db *bbolt.DB, players []database.OnlinePlayer, now time.Time, size int, highMs float64, highSamples int) ([]database.PingHistory, error) {
+	var high []database.PingHistory
+	err := db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte("ping_history"))
+		for _, p := range players {
+			if p.PlayerUid == "" {
+				continue
+			}
+			h := database.PingHistory{PlayerUid: p.PlayerUid}
+			if v := b.Get([]byte(p.PlayerUid)); v != nil {
+				if err := json.Unmarshal(v, &h); err != nil {
+					return err
+				}
+			}
+			addPingSample(&h, now.Unix(), float32(p.Ping), size)
+			if highMs > 0 {
+				sustained := pingSustainedAbove(h, highMs, highSamples)
+				if sustained && !h.High {
+					high = append(high, h)
+				}
+				h.High = sustained
+			}
+			v, err := json.Marshal(h)
+			if err != nil {
+				return err
+			}
+			if err := b.Put([]byte(p.PlayerUid), v); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return high, nil
+}
+
+func addPingSample(h *database.PingHistory, t int64, ping float32, size int) {
+	if size <= 0 {
+		size = 1
+	}
+	if len(h.Pings) > size {
+		// the size was lowered, keep the newest
+		samples := PingSamples(*h)[len(h.Pings)-size:]
+		h.Times, h.Pings, h.Next = h.Times[:0], h.Pings[:0], 0
+		for _, s := range samples {
+			h.Times = append(h.Times, s.Time.Unix())
+			h.Pings = append(h.Pings, float32(s.Ping))
+		}
+	}
+	if len(h.Pings) < size {
+		h.Times = append(h.Times, t)
+		h.Pings = append(h.Pings, ping)
+		h.Next = len(h.Pings) % size
+		return
+	}
+	h.Times[h.Next] = t
+	h.Pings[h.Next] = ping
+	h.Next = (h.Next + 1) % size
+}
+
+func pingSustainedAbove(h database.PingHistory, highMs float64, samples int) bool {
+	if samples <= 0 {
+		samples = 1
+	}
+	ordered := PingSamples(h)
+	if len(ordered) < samples {
+		return false
+	}
+	for _, s := range ordered[len(ordered)-samples:] {
+		if s.Ping <= highMs {
+			return false
+		}
+	}
+	return true
+}
+
+// PingSamples returns the samples of the ring buffer oldest first.
+func PingSamples(h database.PingHistory) []PingSample {
+	samples := make([]PingSample, 0, len(h.Pings))
+	for i := range h.Pings {
+		j := i
+		if len(h.Pings) == len(h.Times) && h.Next < len(h.Pings) {
+			j = (h.Next + i) % len(h.Pings)
+		}
+		samples = append(samples, PingSample{Time: time.Unix(h.Times[j], 0), Ping: float64(h.Pings[j])})
+	}
+	return samples
+}
+
+func GetPingHistory(db *bbolt.DB, playerUid string) (database.PingHistory, error) {
+	var h database.PingHistory
+	err := db.View(func(tx *bbolt.Tx) error {
+		v := tx.Bucket([]byte("ping_history")).Get([]byte(playerUid))
+		if v == nil {
+			return ErrNoRecord
+		}
+		return json.Unmarshal(v, &h)
+	})
+	return h, err
+}
+
+// GetPingStats summarizes the ping history of a player with a series of at
+// most points samples.
+func GetPingStats(db *bbolt.DB, playerUid string, points int) (PingStats, error) {
+	h, err := GetPingHistory(db, playerUid)
+	if err != nil {
+		return PingStats{}, err
+	}
+	samples := PingSamples(h)
+	stats := PingStats{PlayerUid: playerUid, Samples: len(samples), Series: downsamplePings(samples, points)}
+	if len(samples) == 0 {
+		return stats, nil
+	}
+	pings := make([]float64, len(samples))
+	var sum float64
+	for i, s := range samples {
+		pings[i] = s.Ping
+		sum += s.Ping
+	}
+	sort.Float64s(pings)
+	stats.Min = pings[0]
+	stats.Max = pings[len(pings)-1]
+	stats.Avg = math.Round(sum/float64(len(pings))*100) / 100
+	stats.P50 = percentile(pings, 50)
+	stats.P90 = percentile(pings, 90)
+	stats.P99 = percentile(pings, 99)
+	return stats, nil
+}
+
+// percentile uses the nearest rank of sorted values.
+func percentile(sorted []float64, p float64) float64 {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// downsamplePings averages consecutive samples into at most points buckets.
+func downsamplePings(samples []PingSample, points int) []PingSample {
+	if points <= 0 || len(samples) <= points {
+		return samples
+	}
+	series := make([]PingSample, 0, points)
+	for i := 0; i < points; i++ {
+		start, end := i*len(samples)/points, (i+1)*len(samples)/points
+		var sum float64
+		for _, s := range samples[start:end] {
+			sum += s.Ping
+		}
+		series = append(series, PingSample{
+			Time: samples[end-1].Time,
+			Ping: math.Round(sum/float64(end-start)*100) / 100,
+		})
+	}
+	return series
+}
@@ -0,0 +1,32 @@
+package service
+
+import "strings"
+
+const (
+	PlatformSteam       = "steam"
+	PlatformXbox        = "xbox"
+	PlatformPlayStation = "playstation"
+)
+
+// userIdPlatforms maps the prefix of the REST userId to the platform.
+var userIdPlatforms = map[string]string{
+	"steam": PlatformSteam,
+	"xbox":  PlatformXbox,
+	"xbl":   PlatformXbox,
+	"gdk":   PlatformXbox,
+	"ps":    PlatformPlayStation,
+	"ps5":   PlatformPlayStation,
+	"psn":   PlatformPlayStation,
+}
+
+// PlatformOf tells the platform from a REST userId like "steam_7656...", or
+// from a bare steam id when that's all the save has. Unknown is empty.
+func PlatformOf(userId string) string {
+	if prefix, _, ok := strings.Cut(userId, "_"); ok {
+		return userIdPlatforms[strings.ToLower(prefix)]
+	}
+	if len(userId) == 17 && strings.HasPrefix(userId, "7656119") {
+		return PlatformSteam
+	}
+	return ""
+}
@@ -1,111 +1,284 @@
 package service
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/internal/validate"
 	"go.etcd.io/bbolt"
 )
 
-func PutPlayers(db *bbolt.DB, players []database.Player) error {
-	return db.Update(func(tx *bbolt.Tx) error {
-		b := tx.Bucket([]byte("players"))
+// PutPlayers replaces the stored players with the synced ones. Characters
+// gone from the save or reset to level 1, a known Palworld bug, are recorded
+// as events with the newest backup from before, which are returned for
+// notification. The players it changes are kept in j.
+func PutPlayers(db *bbolt.DB, players []database.Player, j *StepJournal) ([]database.Event, error) {
+	if err := validatePlayers(players); err != nil {
+		return nil, err
+	}
+	var events []database.Event
+	err := db.Update(func(tx *bbolt.Tx) error {
+		var err error
+		events, err = putPlayers(tx, players, j)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
 
-		// get existing players
-		existingPlayers := make(map[string]database.Player)
-		err := b.ForEach(func(k, v []byte) error {
-			var player database.Player
-			if err := json.Unmarshal(v, &player); err != nil {
-				return err
-			}
-			existingPlayers[player.PlayerUid] = player
-			return nil
-		})
-		if err != nil {
+func putPlayers(tx *bbolt.Tx, players []database.Player, j *StepJournal) ([]database.Event, error) {
+	var events []database.Event
+	b := tx.Bucket([]byte("players"))
+
+	// get existing players
+	existingPlayers := make(map[string]database.Player)
+	err := b.ForEach(func(k, v []byte) error {
+		var player database.Player
+		if err := json.Unmarshal(v, &player); err != nil {
 			return err
 		}
+		existingPlayers[player.PlayerUid] = player
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
 
-		// build new players map
-		newPlayers := make(map[string]database.Player)
-		for _, p := range players {
-			newPlayers[p.PlayerUid] = p
-		}
+	// build new players map
+	newPlayers := make(map[string]database.Player)
+	for i := range players {
+		players[i].PlayerUid = CanonicalPlayerUid(players[i].PlayerUid)
+		newPlayers[players[i].PlayerUid] = players[i]
+	}
 
-		// process new and existing players
-		for _, p := range players {
-			existingPlayer, exists := existingPlayers[p.PlayerUid]
+	now := time.Now()
+	// the first sync only imports players, and an empty one is a save
+	// that failed to parse more likely than everyone lost
+	if len(existingPlayers) > 0 && len(players) > 0 {
+		events = diffPlayers(tx, existingPlayers, newPlayers, now)
+	}
 
-			if exists {
-				if p.SteamId == "" {
-					p.SteamId = existingPlayer.SteamId
-				}
-				p.Ip = existingPlayer.Ip
-				p.Ping = existingPlayer.Ping
-				p.LocationX = existingPlayer.LocationX
-				p.LocationY = existingPlayer.LocationY
-			}
+	// process new and existing players
+	for _, p := range players {
+		existingPlayer, exists := existingPlayers[p.PlayerUid]
 
-			if p.SaveLastOnline != "" {
-				if parsedTime, err := time.Parse(time.RFC3339, p.SaveLastOnline); err == nil {
-					p.LastOnline = parsedTime
-				}
+		if exists {
+			if p.SteamId == "" {
+				p.SteamId = existingPlayer.SteamId
 			}
+			p.Ip = existingPlayer.Ip
+			p.Ping = existingPlayer.Ping
+			p.LocationX = existingPlayer.LocationX
+			p.LocationY = existingPlayer.LocationY
+			p.Platform = existingPlayer.Platform
+		}
+		if p.Platform == "" {
+			p.Platform = PlatformOf(p.SteamId)
+		}
 
-			v, err := json.Marshal(p)
-			if err != nil {
-				return err
-			}
-			if err := b.Put([]byte(p.PlayerUid), v); err != nil {
-				return err
+		p.LastOnline, p.LastOnlineSource = saveLastOnline(existingPlayer, p.SaveLastOnline, now)
+
+		j.before(tx, b, []byte(p.PlayerUid))
+		touch(tx, "players", []byte(p.PlayerUid))
+		if err := putPlayer(b, p, now); err != nil {
+			return nil, err
+		}
+	}
+
+	// delete old players
+	for uid := range existingPlayers {
+		if _, exists := newPlayers[uid]; !exists {
+			j.before(tx, b, []byte(uid))
+			touch(tx, "players", []byte(uid))
+			if err := b.Delete([]byte(uid)); err != nil {
+				return nil, err
 			}
 		}
+	}
+	j.after(tx, b)
 
-		// delete old players
-		for uid := range existingPlayers {
-			if _, exists := newPlayers[uid]; !exists {
-				if err := b.Delete([]byte(uid)); err != nil {
-					return err
-				}
+	return addEvents(tx, events)
+}
+
+func diffPlayers(tx *bbolt.Tx, existingPlayers, newPlayers map[string]database.Player, now time.Time) []database.Event {
+	events := make([]database.Event, 0)
+	for uid, old := range existingPlayers {
+		if strings.Contains(uid, "000000") {
+			continue
+		}
+		p, exists := newPlayers[uid]
+		var event database.Event
+		switch {
+		case !exists:
+			event = database.Event{
+				Type:    EventCharacterLost,
+				Message: fmt.Sprintf("Character of %s (level %d) is gone from the save", old.Nickname, old.Level),
+			}
+		case old.Level > 1 && p.Level <= 1:
+			event = database.Event{
+				Type:    EventCharacterReset,
+				Message: fmt.Sprintf("Character of %s was reset from level %d to %d", old.Nickname, old.Level, p.Level),
 			}
+		case technologyLost(old.Technology, p.Technology):
+			event = database.Event{
+				Type: EventTechnologyLost,
+				Message: fmt.Sprintf("%s lost technology, from %d unlocks and %d+%d points to %d and %d+%d",
+					old.Nickname, len(old.Technology.Unlocked), old.Technology.Points, old.Technology.AncientPoints,
+					len(p.Technology.Unlocked), p.Technology.Points, p.Technology.AncientPoints),
+			}
+		default:
+			continue
+		}
+		event.Time = now
+		event.PlayerUid = uid
+		event.Data = map[string]string{
+			"nickname": old.Nickname,
+			"level":    strconv.Itoa(int(old.Level)),
+		}
+		if event.Type == EventTechnologyLost {
+			event.Data["points"] = strconv.Itoa(int(old.Technology.Points))
+			event.Data["ancient_points"] = strconv.Itoa(int(old.Technology.AncientPoints))
+			event.Data["unlocked"] = strconv.Itoa(len(old.Technology.Unlocked))
+		}
+		if old.UpdatedAt.IsZero() {
+			// stored before records had UpdatedAt, when it was good is unknown
+			events = append(events, event)
+			continue
 		}
+		event.Data["last_good"] = old.UpdatedAt.Format(time.RFC3339)
+		// the stored record is what the save held at UpdatedAt
+		if backup, ok := backupBefore(tx, old.UpdatedAt); ok {
+			event.Data["backup_id"] = backup.BackupId
+			event.Data["backup_time"] = backup.SaveTime.Format(time.RFC3339)
+			event.Message += fmt.Sprintf(", last backup before is %s of %s", backup.BackupId, backup.SaveTime.Format(time.DateTime))
+		} else {
+			event.Message += ", no backup from before"
+		}
+		events = append(events, event)
+	}
+	return events
+}
 
-		return nil
-	})
+// technologyLost is true when unlocks are gone or points went down without
+// anything unlocked for them, as spending points on research is the only way
+// to lose them.
+func technologyLost(old, t *database.Technology) bool {
+	if old == nil || t == nil {
+		return false
+	}
+	if len(t.Unlocked) < len(old.Unlocked) {
+		return true
+	}
+	spent := t.Points < old.Points || t.AncientPoints < old.AncientPoints
+	return spent && len(t.Unlocked) == len(old.Unlocked)
+}
+
+// backupBefore returns the newest backup taken at or before t.
+func backupBefore(tx *bbolt.Tx, t time.Time) (database.Backup, bool) {
+	c := tx.Bucket([]byte("backup_times")).Cursor()
+	// the first key after t, the time part of the key is big endian
+	k, _ := c.Seek(backupTimeKey(database.Backup{SaveTime: t.Add(time.Nanosecond)}))
+	if k == nil {
+		k, _ = c.Last()
+	} else {
+		k, _ = c.Prev()
+	}
+	if k == nil {
+		return database.Backup{}, false
+	}
+	v := tx.Bucket([]byte("backups")).Get(k[8:])
+	if v == nil {
+		return database.Backup{}, false
+	}
+	var backup database.Backup
+	if err := json.Unmarshal(v, &backup); err != nil {
+		return database.Backup{}, false
+	}
+	return backup, true
+}
+
+// The sources of last_online.
+const (
+	LastOnlineSave = "save"
+	LastOnlineLive = "live"
+)
+
+// saveLastOnline returns when the player was last online after a save sync,
+// in UTC, with its source. A time the save puts in the future, written by a
+// server clock ahead of pst's, is taken as now, and a later one polled live
+// is kept since the save is older than the poll.
+func saveLastOnline(existing database.Player, saveLastOnline string, now time.Time) (time.Time, string) {
+	lastOnline, source := existing.LastOnline.UTC(), existing.LastOnlineSource
+	t, err := time.Parse(time.RFC3339, saveLastOnline)
+	if err != nil {
+		return lastOnline, source
+	}
+	if t.After(now) {
+		t = now
+	}
+	if source == LastOnlineLive && lastOnline.After(t) {
+		return lastOnline, source
+	}
+	return t.UTC(), LastOnlineSave
 }
 
+// lastOnlineResolution is how stale last_online may get before an online
+// player with nothing else changed is written again, so frequent polls don't
+// rewrite every online record.
+const lastOnlineResolution = 30 * time.Second
+
+// locationResolution is how far, in cm, an online player has to move within
+// lastOnlineResolution for the record to be written again. Smaller moves
+// and ping changes are only stored along with other changes.
+const locationResolution = 500.0
+
+// PutPlayersOnline reads the records of the online players in one pass and
+// only opens a write transaction for those that changed.
 func PutPlayersOnline(db *bbolt.DB, players []database.OnlinePlayer) error {
-	return db.Update(func(tx *bbolt.Tx) error {
+	now := time.Now()
+	var changed []database.OnlinePlayer
+	err := db.View(func(tx *bbolt.Tx) error {
 		b := tx.Bucket([]byte("players"))
 		for _, p := range players {
-			existingPlayerData := b.Get([]byte(p.PlayerUid))
-			var player database.Player
-			if existingPlayerData == nil {
-				// player online but not in database
-				player.PlayerUid = p.PlayerUid
-				player.SteamId = p.SteamId
-				player.Nickname = p.Nickname
-			} else {
-				if err := json.Unmarshal(existingPlayerData, &player); err != nil {
-					return err
-				}
-				if player.SteamId == "" || strings.Contains(player.SteamId, "000000") {
-					player.SteamId = p.SteamId
-				}
+			p.PlayerUid = CanonicalPlayerUid(p.PlayerUid)
+			if p.PlayerUid == "" {
+				continue
 			}
-			player.Ip = p.Ip
-			player.Ping = p.Ping
-			player.LocationX = p.LocationX
-			player.LocationY = p.LocationY
-			player.Level = p.Level
-			player.LastOnline = time.Now()
-
-			v, err := json.Marshal(player)
+			v, err := mergeOnlinePlayer(b.Get([]byte(p.PlayerUid)), p, now)
 			if err != nil {
 				return err
 			}
+			if v != nil {
+				changed = append(changed, p)
+			}
+		}
+		return nil
+	})
+	if err != nil || len(changed) == 0 {
+		return err
+	}
+	return db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte("players"))
+		for _, p := range changed {
+			// merged again, a save sync may have written in between
+			v, err := mergeOnlinePlayer(b.Get([]byte(p.PlayerUid)), p, now)
+			if err != nil {
+				return err
+			}
+			if v == nil {
+				continue
+			}
 			if err := b.Put([]byte(p.PlayerUid), v); err != nil {
 				return err
 			}
@@ -114,21 +287,99 @@ func PutPlayersOnline(db *bbolt.DB, players []database.OnlinePlayer) error {
 	})
 }
 
+// mergeOnlinePlayer applies the online state to the stored record and
+// returns the record to write, or nil when it's unchanged but for ping and
+// location jitter and last_online is within lastOnlineResolution.
+func mergeOnlinePlayer(existing []byte, p database.OnlinePlayer, now time.Time) ([]byte, error) {
+	var player database.Player
+	if existing == nil {
+		// player online but not in database
+		player.PlayerUid = p.PlayerUid
+		player.SteamId = p.SteamId
+		player.Nickname = p.Nickname
+	} else {
+		if err := json.Unmarshal(existing, &player); err != nil {
+			return nil, err
+		}
+		if player.SteamId == "" || strings.Contains(player.SteamId, "000000") {
+			player.SteamId = p.SteamId
+		}
+	}
+	player.Ip = p.Ip
+	player.Level = p.Level
+	if p.Platform != "" {
+		player.Platform = p.Platform
+	}
+	if math.Hypot(p.LocationX-player.LocationX, p.LocationY-player.LocationY) >= locationResolution {
+		player.LocationX = p.LocationX
+		player.LocationY = p.LocationY
+	}
+
+	if existing != nil && now.Sub(player.LastOnline) < lastOnlineResolution {
+		v, err := json.Marshal(player)
+		if err != nil {
+			return nil, err
+		}
+		if bytes.Equal(v, existing) {
+			return nil, nil
+		}
+	}
+	player.Ping = p.Ping
+	player.LocationX = p.LocationX
+	player.LocationY = p.LocationY
+	player.LastOnline = now.UTC()
+	player.LastOnlineSource = LastOnlineLive
+	player.UpdatedAt = now
+	return json.Marshal(player)
+}
+
+// putPlayer stores the player, setting UpdatedAt to now only when the record
+// differs from the stored one.
+func putPlayer(b *bbolt.Bucket, player database.Player, now time.Time) error {
+	existing := b.Get([]byte(player.PlayerUid))
+	if existing != nil {
+		var stored database.TersePlayer
+		if err := json.Unmarshal(existing, &stored); err != nil {
+			return err
+		}
+		player.UpdatedAt = stored.UpdatedAt
+		player.Revision = stored.Revision
+	}
+	v, err := json.Marshal(player)
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(v, existing) {
+		return nil
+	}
+	player.UpdatedAt = now
+	player.Revision++
+	if v, err = json.Marshal(player); err != nil {
+		return err
+	}
+	return b.Put([]byte(player.PlayerUid), v)
+}
+
 func ListPlayers(db *bbolt.DB) ([]database.TersePlayer, error) {
 	players := make([]database.TersePlayer, 0)
-	err := db.View(func(tx *bbolt.Tx) error {
-		b := tx.Bucket([]byte("players"))
-		return b.ForEach(func(k, v []byte) error {
-			if strings.Contains(string(k), "000000") {
-				return nil
-			}
-			var player database.TersePlayer
-			if err := json.Unmarshal(v, &player); err != nil {
-				return err
-			}
+	err := StreamPlayers(context.Background(), db, func(player database.TersePlayer) error {
+		players = append(players, player)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return players, nil
+}
+
+// ListPlayersBySteamId returns the characters of a Steam account.
+func ListPlayersBySteamId(db *bbolt.DB, steamId string) ([]database.TersePlayer, error) {
+	players := make([]database.TersePlayer, 0)
+	err := StreamPlayers(context.Background(), db, func(player database.TersePlayer) error {
+		if player.SteamId == steamId {
 			players = append(players, player)
-			return nil
-		})
+		}
+		return nil
 	})
 	if err != nil {
 		return nil, err
@@ -136,11 +387,65 @@ func ListPlayers(db *bbolt.DB) ([]database.TersePlayer, error) {
 	return players, nil
 }
 
+// streamPage is how many players StreamPlayers reads in one transaction.
+const streamPage = 100
+
+// StreamPlayers calls fn for each player in uid order without collecting
+// them. The players are read a page at a time and fn is only called once
+// the read transaction of its page is closed, so a slow fn doesn't hold one
+// open, pages can come from different syncs. It stops at the first error
+// of fn or when ctx is done.
+func StreamPlayers(ctx context.Context, db *bbolt.DB, fn func(player database.TersePlayer) error) error {
+	var after []byte
+	for {
+		players := make([]database.TersePlayer, 0, streamPage)
+		err := db.View(func(tx *bbolt.Tx) error {
+			c := tx.Bucket([]byte("players")).Cursor()
+			k, v := c.First()
+			if after != nil {
+				k, v = c.Seek(after)
+				if bytes.Equal(k, after) {
+					k, v = c.Next()
+				}
+			}
+			for ; k != nil && len(players) < streamPage; k, v = c.Next() {
+				after = append(after[:0], k...)
+				if strings.Contains(string(k), "000000") {
+					continue
+				}
+				var player database.TersePlayer
+				if err := json.Unmarshal(v, &player); err != nil {
+					return err
+				}
+				players = append(players, player)
+			}
+			if k == nil {
+				after = nil
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, player := range players {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if err := fn(player); err != nil {
+				return err
+			}
+		}
+		if after == nil {
+			return nil
+		}
+	}
+}
+
 func GetPlayer(db *bbolt.DB, playerUid string) (database.Player, error) {
 	var player database.Player
 	err := db.View(func(tx *bbolt.Tx) error {
 		b := tx.Bucket([]byte("players"))
-		v := b.Get([]byte(playerUid))
+		v := b.Get([]byte(CanonicalPlayerUid(playerUid)))
 		if v == nil {
 			return ErrNoRecord
 		}
@@ -156,42 +461,54 @@ func GetPlayer(db *bbolt.DB, playerUid string) (database.Player, error) {
 }
 
 func AddWhitelist(db *bbolt.DB, player database.PlayerW) error {
+	var v validate.Validator
+	validateWhitelistEntry(&v, "", player)
+	if err := v.Err(); err != nil {
+		return err
+	}
+	player.PlayerUID = CanonicalPlayerUid(player.PlayerUID)
 	return db.Update(func(tx *bbolt.Tx) error {
-		// 获取或创建白名单bucket
-		b, err := tx.CreateBucketIfNotExists([]byte("whitelist"))
-		if err != nil {
-			return err
-		}
+		return putWhitelistEntry(tx, player)
+	})
+}
 
-		// 序列化玩家数据为JSON
-		playerData, err := json.Marshal(player)
-		if err != nil {
-			return err
-		}
+func putWhitelistEntry(tx *bbolt.Tx, player database.PlayerW) error {
+	// 获取或创建白名单bucket
+	b, err := tx.CreateBucketIfNotExists([]byte("whitelist"))
+	if err != nil {
+		return err
+	}
 
-		// 使用 findPlayerKey 检查玩家是否已经在白名单中
-		key, err := findPlayerKey(b, player)
-		if err != nil {
-			return err
-		}
+	// 使用 findPlayerKey 检查玩家是否已经在白名单中
+	key, err := findPlayerKey(b, player)
+	if err != nil {
+		return err
+	}
 
-		// 如果玩家已存在，更新其信息；如果不存在，创建新的键
-		if key != nil {
-			// 玩家已存在，更新其信息
-			if err := b.Put(key, playerData); err != nil {
-				return err
-			}
-		} else {
-			// 玩家不存在，添加新玩家
-			// 生成新玩家的唯一键
-			newPlayerKey := []byte(player.Name + "|" + player.SteamID + "|" + player.PlayerUID)
-			if err := b.Put(newPlayerKey, playerData); err != nil {
-				return err
-			}
+	// 如果玩家已存在，更新其信息；如果不存在，创建新的键
+	var current database.PlayerW
+	if key != nil {
+		if err := json.Unmarshal(b.Get(key), &current); err != nil {
+			return err
 		}
+	} else {
+		// 玩家不存在，添加新玩家
+		// 生成新玩家的唯一键
+		key = []byte(player.Name + "|" + player.SteamID + "|" + player.PlayerUID)
+	}
+	// a revision for an entry not found means it was removed since
+	if err := checkRevision(player.Revision, current.Revision); err != nil {
+		return err
+	}
+	player.Revision = current.Revision + 1
 
-		return nil
-	})
+	// 序列化玩家数据为JSON
+	playerData, err := json.Marshal(player)
+	if err != nil {
+		return err
+	}
+	touch(tx, "whitelist", key)
+	return b.Put(key, playerData)
 }
 
 func ListWhitelist(db *bbolt.DB) ([]database.PlayerW, error) {
@@ -216,6 +533,58 @@ func ListWhitelist(db *bbolt.DB) ([]database.PlayerW, error) {
 	return players, err
 }
 
+type WhitelistFilter struct {
+	// Query matches part of the name, steam id or player uid
+	Query string
+	// OrderBy is name, steam_id or player_uid, without it the entries keep
+	// the order they are stored in
+	OrderBy string
+	Desc    bool
+	Offset  int
+	Limit   int
+}
+
+func (f WhitelistFilter) Match(player database.PlayerW) bool {
+	if f.Query == "" {
+		return true
+	}
+	q := strings.ToLower(f.Query)
+	return strings.Contains(strings.ToLower(player.Name), q) || strings.Contains(player.SteamID, q) || strings.Contains(player.PlayerUID, q)
+}
+
+// SearchWhitelist returns a page of the entries matching filter and how
+// many match in all.
+func SearchWhitelist(db *bbolt.DB, filter WhitelistFilter) ([]database.PlayerW, int, error) {
+	players, err := ListWhitelist(db)
+	if err != nil {
+		return nil, 0, err
+	}
+	matched := make([]database.PlayerW, 0, len(players))
+	for _, player := range players {
+		if filter.Match(player) {
+			matched = append(matched, player)
+		}
+	}
+	var key func(player database.PlayerW) string
+	switch filter.OrderBy {
+	case "name":
+		key = func(player database.PlayerW) string { return strings.ToLower(player.Name) }
+	case "steam_id":
+		key = func(player database.PlayerW) string { return player.SteamID }
+	case "player_uid":
+		key = func(player database.PlayerW) string { return player.PlayerUID }
+	}
+	if key != nil {
+		sort.SliceStable(matched, func(i, j int) bool {
+			if filter.Desc {
+				return key(matched[i]) > key(matched[j])
+			}
+			return key(matched[i]) < key(matched[j])
+		})
+	}
+	return page(matched, filter.Offset, filter.Limit), len(matched), nil
+}
+
 // findPlayerKey tries to find a player in the whitelist and returns the key if found.
 func findPlayerKey(b *bbolt.Bucket, player database.PlayerW) ([]byte, error) {
 	var keyFound []byte
@@ -238,24 +607,41 @@ func findPlayerKey(b *bbolt.Bucket, player database.PlayerW) ([]byte, error) {
 	return nil, err
 }
 
-// RemoveWhitelist removes a player from the whitelist.
-func RemoveWhitelist(db *bbolt.DB, player database.PlayerW) error {
+// RemoveWhitelist removes a player from the whitelist, keeping the entry in
+// the history of removed entries.
+func RemoveWhitelist(db *bbolt.DB, player database.PlayerW, removal Removal) error {
+	player.PlayerUID = CanonicalPlayerUid(player.PlayerUID)
 	return db.Update(func(tx *bbolt.Tx) error {
-		b := tx.Bucket([]byte("whitelist"))
-		if b == nil {
-			return errors.New("whitelist bucket does not exist")
-		}
+		return removeWhitelist(tx, player, removal)
+	})
+}
 
-		key, err := findPlayerKey(b, player)
-		if err != nil {
-			return err
-		}
-		if key == nil {
-			return errors.New("player not found in whitelist")
-		}
+func removeWhitelist(tx *bbolt.Tx, player database.PlayerW, removal Removal) error {
+	b := tx.Bucket([]byte("whitelist"))
+	if b == nil {
+		return errors.New("whitelist bucket does not exist")
+	}
 
-		return b.Delete(key)
-	})
+	key, err := findPlayerKey(b, player)
+	if err != nil {
+		return err
+	}
+	if key == nil {
+		return errors.New("player not found in whitelist")
+	}
+
+	var removed database.PlayerW
+	if err := json.Unmarshal(b.Get(key), &removed); err != nil {
+		return err
+	}
+	if err := checkRevision(player.Revision, removed.Revision); err != nil {
+		return err
+	}
+	if err := addRemoved(tx, database.RemovedEntry{Kind: RemovedWhitelist, Player: &removed}, removal); err != nil {
+		return err
+	}
+	touch(tx, "whitelist", key)
+	return b.Delete(key)
 }
 
 // matchesCriteria checks if the given player matches the criteria.
@@ -276,39 +662,141 @@ func matchesCriteria(existingPlayer, player database.PlayerW) bool {
 	return false
 }
 
-func PutWhitelist(db *bbolt.DB, players []database.PlayerW) error {
+const (
+	// WhitelistReplace drops the entries left out
+	WhitelistReplace = "replace"
+	// WhitelistMerge adds or updates each player like AddWhitelist
+	WhitelistMerge = "merge"
+	// WhitelistAppend only adds the players not in the whitelist yet
+	WhitelistAppend = "append"
+)
+
+// PutWhitelist puts players in the whitelist by mode. In replace mode the
+// entries left out are kept in the history of removed entries, the other
+// modes keep them.
+func PutWhitelist(db *bbolt.DB, players []database.PlayerW, mode string, removal Removal) error {
+	if err := validateWhitelist(players); err != nil {
+		return err
+	}
 	return db.Update(func(tx *bbolt.Tx) error {
-		// 获取或创建白名单bucket
-		b, err := tx.CreateBucketIfNotExists([]byte("whitelist"))
-		if err != nil {
+		return putWhitelist(tx, players, mode, removal)
+	})
+}
+
+func putWhitelist(tx *bbolt.Tx, players []database.PlayerW, mode string, removal Removal) error {
+	switch mode {
+	case WhitelistReplace:
+		return replaceWhitelist(tx, players, removal)
+	case WhitelistMerge, WhitelistAppend:
+	default:
+		return fmt.Errorf("unknown whitelist mode %q", mode)
+	}
+	b, err := tx.CreateBucketIfNotExists([]byte("whitelist"))
+	if err != nil {
+		return err
+	}
+	for _, player := range players {
+		player.PlayerUID = CanonicalPlayerUid(player.PlayerUID)
+		if player.PlayerUID == "" && player.SteamID == "" && player.Name == "" {
+			continue
+		}
+		if mode == WhitelistAppend {
+			key, err := findPlayerKey(b, player)
+			if err != nil {
+				return err
+			}
+			if key != nil {
+				continue
+			}
+		}
+		if err := putWhitelistEntry(tx, player); err != nil {
 			return err
 		}
+	}
+	return nil
+}
 
-		// 清空现有的白名单
-		err = b.ForEach(func(k, v []byte) error {
-			return b.Delete(k)
-		})
-		if err != nil {
+func replaceWhitelist(tx *bbolt.Tx, players []database.PlayerW, removal Removal) error {
+	// 获取或创建白名单bucket
+	b, err := tx.CreateBucketIfNotExists([]byte("whitelist"))
+	if err != nil {
+		return err
+	}
+
+	// 清空现有的白名单
+	var keys [][]byte
+	var existing []database.PlayerW
+	err = b.ForEach(func(k, v []byte) error {
+		var player database.PlayerW
+		if err := json.Unmarshal(v, &player); err != nil {
 			return err
 		}
-
-		// 遍历并添加新的玩家数据到白名单
+		keys = append(keys, k)
+		existing = append(existing, player)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, k := range keys {
+		touch(tx, "whitelist", k)
+		if err := b.Delete(k); err != nil {
+			return err
+		}
+	}
+	for i := range players {
+		players[i].PlayerUID = CanonicalPlayerUid(players[i].PlayerUID)
+	}
+	for i := range existing {
+		kept := false
 		for _, player := range players {
-			playerData, err := json.Marshal(player)
-			if err != nil {
+			if replacesEntry(player, existing[i]) {
+				kept = true
+				break
+			}
+		}
+		if !kept {
+			if err := addRemoved(tx, database.RemovedEntry{Kind: RemovedWhitelist, Player: &existing[i]}, removal); err != nil {
 				return err
 			}
-			identifier := player.PlayerUID
-			if identifier == "" {
-				if identifier = player.SteamID; identifier == "" {
-					continue
-				}
+		}
+	}
+
+	// 遍历并添加新的玩家数据到白名单
+	for _, player := range players {
+		identifier := player.PlayerUID
+		if identifier == "" {
+			if identifier = player.SteamID; identifier == "" {
+				continue
 			}
-			if err := b.Put([]byte(identifier), playerData); err != nil {
-				return err
+		}
+		var current uint64
+		for _, entry := range existing {
+			if replacesEntry(player, entry) {
+				current = entry.Revision
+				break
 			}
 		}
+		if err := checkRevision(player.Revision, current); err != nil {
+			return err
+		}
+		player.Revision = current + 1
+		playerData, err := json.Marshal(player)
+		if err != nil {
+			return err
+		}
+		touch(tx, "whitelist", []byte(identifier))
+		if err := b.Put([]byte(identifier), playerData); err != nil {
+			return err
+		}
+	}
 
-		return nil
-	})
+	return nil
+}
+
+// replacesEntry reports whether player takes the place of the entry when the
+// whitelist is replaced.
+func replacesEntry(player, entry database.PlayerW) bool {
+	return (player.PlayerUID != "" && player.PlayerUID == entry.PlayerUID) ||
+		(player.SteamID != "" && player.SteamID == entry.SteamID)
 }
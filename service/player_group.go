@@ -0,0 +1,139 @@
+package service
+
+import (
+	"encoding/json"
+
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/internal/validate"
+	"go.etcd.io/bbolt"
+)
+
+func PutPlayerGroup(db *bbolt.DB, group database.PlayerGroup) error {
+	var v validate.Validator
+	v.Required("name", group.Name)
+	for i, member := range group.Members {
+		validateGroupMember(&v, validate.Index("members", i, ""), member)
+	}
+	if err := v.Err(); err != nil {
+		return err
+	}
+	return db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte("player_groups"))
+		v, err := json.Marshal(group)
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte(group.Name), v)
+	})
+}
+
+func GetPlayerGroup(db *bbolt.DB, name string) (database.PlayerGroup, error) {
+	var group database.PlayerGroup
+	err := db.View(func(tx *bbolt.Tx) error {
+		v := tx.Bucket([]byte("player_groups")).Get([]byte(name))
+		if v == nil {
+			return ErrNoRecord
+		}
+		return json.Unmarshal(v, &group)
+	})
+	return group, err
+}
+
+func ListPlayerGroups(db *bbolt.DB) ([]database.PlayerGroup, error) {
+	groups := make([]database.PlayerGroup, 0)
+	err := db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket([]byte("player_groups")).ForEach(func(k, v []byte) error {
+			var group database.PlayerGroup
+			if err := json.Unmarshal(v, &group); err != nil {
+				return err
+			}
+			groups = append(groups, group)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return groups, nil
+}
+
+func RemovePlayerGroup(db *bbolt.DB, name string) error {
+	return db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket([]byte("player_groups")).Delete([]byte(name))
+	})
+}
+
+// AddGroupMember adds the member to the group unless it is already in,
+// matched by player uid or steam id.
+func AddGroupMember(db *bbolt.DB, name string, member database.GroupMember) error {
+	if err := ValidateGroupMember(member); err != nil {
+		return err
+	}
+	return updatePlayerGroup(db, name, func(group *database.PlayerGroup) {
+		for _, m := range group.Members {
+			if IsGroupMember(m, member.PlayerUid, member.SteamId) {
+				return
+			}
+		}
+		group.Members = append(group.Members, member)
+	})
+}
+
+func RemoveGroupMember(db *bbolt.DB, name string, member database.GroupMember) error {
+	if member.PlayerUid == "" && member.SteamId == "" {
+		return validate.Errors{{Field: "player_uid", Message: "player_uid or steam_id is required"}}
+	}
+	return updatePlayerGroup(db, name, func(group *database.PlayerGroup) {
+		members := group.Members[:0]
+		for _, m := range group.Members {
+			if !IsGroupMember(m, member.PlayerUid, member.SteamId) {
+				members = append(members, m)
+			}
+		}
+		group.Members = members
+	})
+}
+
+func updatePlayerGroup(db *bbolt.DB, name string, fn func(group *database.PlayerGroup)) error {
+	return db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte("player_groups"))
+		v := b.Get([]byte(name))
+		if v == nil {
+			return ErrNoRecord
+		}
+		var group database.PlayerGroup
+		if err := json.Unmarshal(v, &group); err != nil {
+			return err
+		}
+		fn(&group)
+		v, err := json.Marshal(group)
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte(name), v)
+	})
+}
+
+// IsGroupMember reports whether the member is the player, by uid or steam id.
+func IsGroupMember(member database.GroupMember, playerUid, steamId string) bool {
+	return (member.PlayerUid != "" && member.PlayerUid == playerUid) ||
+		(member.SteamId != "" && member.SteamId == steamId)
+}
+
+// ListGroupsOf returns the groups the player is a member of.
+func ListGroupsOf(db *bbolt.DB, playerUid, steamId string) ([]database.PlayerGroup, error) {
+	groups, err := ListPlayerGroups(db)
+	if err != nil {
+		return nil, err
+	}
+	var of []database.PlayerGroup
+	for _, group := range groups {
+		for _, member := range group.Members {
+			if IsGroupMember(member, playerUid, steamId) {
+				of = append(of, group)
+				break
+			}
+		}
+	}
+	return of, nil
+}
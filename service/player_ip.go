@@ -0,0 +1,137 @@
+package service
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"go.etcd.io/bbolt"
+)
+
+// maxIpSessions caps the sessions kept per player and ip
+const maxIpSessions = 50
+
+type AltAccounts struct {
+	Ip      string              `json:"ip"`
+	Players []database.PlayerIp `json:"players"`
+	// Concurrent is true when some of the players were online at the same
+	// time, more like a shared network than one person on several accounts
+	Concurrent bool      `json:"concurrent"`
+	LastSeen   time.Time `json:"last_seen"`
+}
+
+func playerIpKey(playerUid, ip string) []byte {
+	return []byte(playerUid + "|" + ip)
+}
+
+// RecordPlayerIps extends the current session of each online player and ip, or
+// starts a new one when the last ended more than maxGap ago.
+func RecordPlayerIps(db *bbolt.DB, players []database.OnlinePlayer, now time.Time, maxGap time.Duration) error {
+	return db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte("player_ips"))
+		for _, p := range players {
+			if p.PlayerUid == "" || p.Ip == "" {
+				continue
+			}
+			key := playerIpKey(p.PlayerUid, p.Ip)
+			record := database.PlayerIp{PlayerUid: p.PlayerUid, Ip: p.Ip}
+			if v := b.Get(key); v != nil {
+				if err := json.Unmarshal(v, &record); err != nil {
+					return err
+				}
+			}
+			record.SteamId = p.SteamId
+			record.Nickname = p.Nickname
+			if n := len(record.Sessions); n > 0 && now.Sub(record.Sessions[n-1].End) <= maxGap {
+				record.Sessions[n-1].End = now
+			} else {
+				record.Sessions = append(record.Sessions, database.IpSession{Start: now, End: now})
+			}
+			if len(record.Sessions) > maxIpSessions {
+				record.Sessions = record.Sessions[len(record.Sessions)-maxIpSessions:]
+			}
+			v, err := json.Marshal(record)
+			if err != nil {
+				return err
+			}
+			if err := b.Put(key, v); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ListPlayerIps returns the ip addresses a player was seen from.
+func ListPlayerIps(db *bbolt.DB, playerUid string) ([]database.PlayerIp, error) {
+	records := make([]database.PlayerIp, 0)
+	err := db.View(func(tx *bbolt.Tx) error {
+		c := tx.Bucket([]byte("player_ips")).Cursor()
+		prefix := []byte(playerUid + "|")
+		for k, v := c.Seek(prefix); k != nil && strings.HasPrefix(string(k), string(prefix)); k, v = c.Next() {
+			var record database.PlayerIp
+			if err := json.Unmarshal(v, &record); err != nil {
+				return err
+			}
+			records = append(records, record)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// ListAltAccounts groups the players seen from the same ip address, the most
+// recently active groups first.
+func ListAltAccounts(db *bbolt.DB) ([]AltAccounts, error) {
+	byIp := make(map[string][]database.PlayerIp)
+	err := db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket([]byte("player_ips")).ForEach(func(k, v []byte) error {
+			var record database.PlayerIp
+			if err := json.Unmarshal(v, &record); err != nil {
+				return err
+			}
+			byIp[record.Ip] = append(byIp[record.Ip], record)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	alts := make([]AltAccounts, 0)
+	for ip, players := range byIp {
+		if len(players) < 2 {
+			continue
+		}
+		group := AltAccounts{Ip: ip, Players: players}
+		for i, a := range players {
+			if n := len(a.Sessions); n > 0 && a.Sessions[n-1].End.After(group.LastSeen) {
+				group.LastSeen = a.Sessions[n-1].End
+			}
+			for _, b := range players[i+1:] {
+				if sessionsOverlap(a.Sessions, b.Sessions) {
+					group.Concurrent = true
+				}
+			}
+		}
+		alts = append(alts, group)
+	}
+	sort.Slice(alts, func(i, j int) bool { return alts[i].LastSeen.After(alts[j].LastSeen) })
+	return alts, nil
+}
+
+func sessionsOverlap(a, b []database.IpSession) bool {
+	for _, x := range a {
+		for _, y := range b {
+			if !x.Start.After(y.End) && !y.Start.After(x.End) {
+				return true
+			}
+		}
+	}
+	return false
+}
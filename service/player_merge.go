@@ -0,0 +1,431 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"go.etcd.io/bbolt"
+)
+
+var (
+	ErrSamePlayer      = errors.New("can't merge a player into itself")
+	ErrMergeUndone     = errors.New("merge was already undone")
+	ErrMergeUndoPassed = errors.New("undo window has passed")
+)
+
+// recordWriter writes to buckets, remembering the previous value of each key
+// when previous is set.
+type recordWriter struct {
+	tx       *bbolt.Tx
+	previous map[string]map[string][]byte
+}
+
+func (w *recordWriter) remember(bucket string, key []byte) {
+	if w.previous == nil {
+		return
+	}
+	keys, ok := w.previous[bucket]
+	if !ok {
+		keys = make(map[string][]byte)
+		w.previous[bucket] = keys
+	}
+	if _, ok := keys[string(key)]; ok {
+		return
+	}
+	// values are only valid during the transaction
+	if v := w.tx.Bucket([]byte(bucket)).Get(key); v != nil {
+		keys[string(key)] = append([]byte(nil), v...)
+	} else {
+		keys[string(key)] = nil
+	}
+}
+
+func (w *recordWriter) put(bucket string, key []byte, record any) error {
+	v, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	w.remember(bucket, key)
+	return w.tx.Bucket([]byte(bucket)).Put(key, v)
+}
+
+func (w *recordWriter) delete(bucket string, key []byte) error {
+	w.remember(bucket, key)
+	return w.tx.Bucket([]byte(bucket)).Delete(key)
+}
+
+// moveRecord moves the record at from to to, merging it into a record already
+// there.
+func moveRecord[T any](w *recordWriter, bucket, from, to string, setUid func(*T, string), merge func(dst, src T) T) error {
+	b := w.tx.Bucket([]byte(bucket))
+	v := b.Get([]byte(from))
+	if v == nil {
+		return nil
+	}
+	var record T
+	if err := json.Unmarshal(v, &record); err != nil {
+		return err
+	}
+	if v := b.Get([]byte(to)); v != nil {
+		var existing T
+		if err := json.Unmarshal(v, &existing); err != nil {
+			return err
+		}
+		record = merge(existing, record)
+	}
+	setUid(&record, to)
+	if err := w.put(bucket, []byte(to), record); err != nil {
+		return err
+	}
+	return w.delete(bucket, []byte(from))
+}
+
+// MergePlayers merges the records of two players into one, summing playtime
+// and points and moving ip sessions, point transactions, whitelist entries
+// and group memberships. keep picks the uid to keep, by default the canonical
+// one or else the one online last. revisions are the revisions of the
+// players the merge was decided on, by uid. The merge can be undone within
+// undo.
+func MergePlayers(db *bbolt.DB, playerUid, otherPlayerUid, keep string, revisions map[string]uint64, undo time.Duration) (database.PlayerMerge, error) {
+	if playerUid == otherPlayerUid {
+		return database.PlayerMerge{}, ErrSamePlayer
+	}
+	var merge database.PlayerMerge
+	err := db.Update(func(tx *bbolt.Tx) error {
+		players := tx.Bucket([]byte("players"))
+		var first, second database.Player
+		for uid, p := range map[string]*database.Player{playerUid: &first, otherPlayerUid: &second} {
+			v := players.Get([]byte(uid))
+			if v == nil {
+				return ErrNoRecord
+			}
+			if err := json.Unmarshal(v, p); err != nil {
+				return err
+			}
+			if err := checkRevision(revisions[uid], p.Revision); err != nil {
+				return err
+			}
+		}
+		switch keep {
+		case "":
+			keep = chooseKeptPlayer(first, second)
+		case playerUid, otherPlayerUid:
+		default:
+			return errors.New("keep must be one of the merged players")
+		}
+		from := playerUid
+		if keep == playerUid {
+			from = otherPlayerUid
+		}
+
+		w := &recordWriter{tx: tx, previous: make(map[string]map[string][]byte)}
+		if err := mergePlayerRecords(w, from, keep); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		merge = database.PlayerMerge{
+			PlayerUid:       keep,
+			MergedPlayerUid: from,
+			Time:            now,
+			UndoUntil:       now.Add(undo),
+			Previous:        w.previous,
+		}
+		b := tx.Bucket([]byte("player_merges"))
+		if err := dropExpiredUndo(b, now); err != nil {
+			return err
+		}
+		id, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+		merge.Id = id
+		v, err := json.Marshal(merge)
+		if err != nil {
+			return err
+		}
+		return b.Put(eventKey(id), v)
+	})
+	merge.Previous = nil
+	return merge, err
+}
+
+// dropExpiredUndo clears the records kept for merges that can't be undone
+// anymore.
+func dropExpiredUndo(b *bbolt.Bucket, now time.Time) error {
+	var expired []database.PlayerMerge
+	err := b.ForEach(func(_, v []byte) error {
+		var merge database.PlayerMerge
+		if err := json.Unmarshal(v, &merge); err != nil {
+			return err
+		}
+		if merge.Previous != nil && now.After(merge.UndoUntil) {
+			merge.Previous = nil
+			expired = append(expired, merge)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, merge := range expired {
+		v, err := json.Marshal(merge)
+		if err != nil {
+			return err
+		}
+		if err := b.Put(eventKey(merge.Id), v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// chooseKeptPlayer prefers the uid in canonical form, then the player seen
+// last, which is the uid the game uses now.
+func chooseKeptPlayer(a, b database.Player) string {
+	aCanonical := CanonicalPlayerUid(a.PlayerUid) == a.PlayerUid
+	bCanonical := CanonicalPlayerUid(b.PlayerUid) == b.PlayerUid
+	if aCanonical != bCanonical {
+		if aCanonical {
+			return a.PlayerUid
+		}
+		return b.PlayerUid
+	}
+	if b.LastOnline.After(a.LastOnline) {
+		return b.PlayerUid
+	}
+	return a.PlayerUid
+}
+
+func mergePlayerRecords(w *recordWriter, from, to string) error {
+	if err := moveRecord(w, "players", from, to, func(p *database.Player, uid string) { p.PlayerUid = uid }, mergePlayer); err != nil {
+		return err
+	}
+	if err := moveRecord(w, "playtimes", from, to, func(p *database.Playtime, uid string) { p.PlayerUid = uid }, mergePlaytime); err != nil {
+		return err
+	}
+	if err := moveRecord(w, "badges", from, to, func(b *database.PlayerBadges, uid string) { b.PlayerUid = uid }, mergePlayerBadges); err != nil {
+		return err
+	}
+	if err := moveRecord(w, "points", from, to, func(a *database.PointsAccount, uid string) { a.PlayerUid = uid }, mergePointsAccount); err != nil {
+		return err
+	}
+	if err := moveRecord(w, "ping_history", from, to, func(h *database.PingHistory, uid string) { h.PlayerUid = uid },
+		func(dst, _ database.PingHistory) database.PingHistory { return dst }); err != nil {
+		return err
+	}
+	if err := mergePlayerIps(w, from, to); err != nil {
+		return err
+	}
+	if err := mergePointsTransactions(w, from, to); err != nil {
+		return err
+	}
+	if err := mergeWhitelist(w, from, to); err != nil {
+		return err
+	}
+	return mergeGroupMembers(w, from, to)
+}
+
+func mergePlayerIps(w *recordWriter, from, to string) error {
+	c := w.tx.Bucket([]byte("player_ips")).Cursor()
+	prefix := from + "|"
+	var ips []string
+	for k, _ := c.Seek([]byte(prefix)); k != nil && strings.HasPrefix(string(k), prefix); k, _ = c.Next() {
+		ips = append(ips, strings.TrimPrefix(string(k), prefix))
+	}
+	for _, ip := range ips {
+		err := moveRecord(w, "player_ips", string(playerIpKey(from, ip)), string(playerIpKey(to, ip)),
+			func(r *database.PlayerIp, _ string) { r.PlayerUid = to }, mergePlayerIp)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func mergePlayerIp(dst, src database.PlayerIp) database.PlayerIp {
+	dst.Sessions = append(dst.Sessions, src.Sessions...)
+	sort.Slice(dst.Sessions, func(i, j int) bool { return dst.Sessions[i].Start.Before(dst.Sessions[j].Start) })
+	if len(dst.Sessions) > maxIpSessions {
+		dst.Sessions = dst.Sessions[len(dst.Sessions)-maxIpSessions:]
+	}
+	return dst
+}
+
+func mergePointsTransactions(w *recordWriter, from, to string) error {
+	var moved []database.PointsTransaction
+	err := w.tx.Bucket([]byte("point_transactions")).ForEach(func(_, v []byte) error {
+		var t database.PointsTransaction
+		if err := json.Unmarshal(v, &t); err != nil {
+			return err
+		}
+		if t.PlayerUid == from {
+			t.PlayerUid = to
+			moved = append(moved, t)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, t := range moved {
+		if err := w.put("point_transactions", eventKey(t.Id), t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mergeWhitelist points the whitelist entries of from to to, dropping them
+// when to is already whitelisted.
+func mergeWhitelist(w *recordWriter, from, to string) error {
+	entries := make(map[string]database.PlayerW)
+	listed := false
+	err := w.tx.Bucket([]byte("whitelist")).ForEach(func(k, v []byte) error {
+		var entry database.PlayerW
+		if err := json.Unmarshal(v, &entry); err != nil {
+			return err
+		}
+		switch entry.PlayerUID {
+		case from:
+			entries[string(k)] = entry
+		case to:
+			listed = true
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for key, entry := range entries {
+		if err := w.delete("whitelist", []byte(key)); err != nil {
+			return err
+		}
+		if listed {
+			continue
+		}
+		entry.PlayerUID = to
+		entry.Revision++
+		if key == from {
+			key = to
+		}
+		if err := w.put("whitelist", []byte(key), entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func mergeGroupMembers(w *recordWriter, from, to string) error {
+	var groups []database.PlayerGroup
+	err := w.tx.Bucket([]byte("player_groups")).ForEach(func(_, v []byte) error {
+		var group database.PlayerGroup
+		if err := json.Unmarshal(v, &group); err != nil {
+			return err
+		}
+		members := make([]database.GroupMember, 0, len(group.Members))
+		changed := false
+		for _, m := range group.Members {
+			if m.PlayerUid == from {
+				changed = true
+				m.PlayerUid = to
+			}
+			if m.PlayerUid == to && containsMember(members, to) {
+				continue
+			}
+			members = append(members, m)
+		}
+		if changed {
+			group.Members = members
+			groups = append(groups, group)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, group := range groups {
+		if err := w.put("player_groups", []byte(group.Name), group); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func containsMember(members []database.GroupMember, playerUid string) bool {
+	for _, m := range members {
+		if m.PlayerUid == playerUid {
+			return true
+		}
+	}
+	return false
+}
+
+// UndoPlayerMerge restores the records changed by a merge. Changes made to
+// them since the merge are lost.
+func UndoPlayerMerge(db *bbolt.DB, id uint64) (database.PlayerMerge, error) {
+	var merge database.PlayerMerge
+	err := db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte("player_merges"))
+		v := b.Get(eventKey(id))
+		if v == nil {
+			return ErrNoRecord
+		}
+		if err := json.Unmarshal(v, &merge); err != nil {
+			return err
+		}
+		if merge.Undone {
+			return ErrMergeUndone
+		}
+		if time.Now().After(merge.UndoUntil) {
+			return ErrMergeUndoPassed
+		}
+		for bucket, keys := range merge.Previous {
+			rb := tx.Bucket([]byte(bucket))
+			for key, value := range keys {
+				var err error
+				if value == nil {
+					err = rb.Delete([]byte(key))
+				} else {
+					err = rb.Put([]byte(key), value)
+				}
+				if err != nil {
+					return err
+				}
+			}
+		}
+		merge.Undone = true
+		merge.Previous = nil
+		v, err := json.Marshal(merge)
+		if err != nil {
+			return err
+		}
+		return b.Put(eventKey(id), v)
+	})
+	return merge, err
+}
+
+// ListPlayerMerges returns the merges, newest first.
+func ListPlayerMerges(db *bbolt.DB) ([]database.PlayerMerge, error) {
+	merges := make([]database.PlayerMerge, 0)
+	err := db.View(func(tx *bbolt.Tx) error {
+		c := tx.Bucket([]byte("player_merges")).Cursor()
+		for k, v := c.Last(); k != nil; k, v = c.Prev() {
+			var merge database.PlayerMerge
+			if err := json.Unmarshal(v, &merge); err != nil {
+				return err
+			}
+			merge.Previous = nil
+			merges = append(merges, merge)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return merges, nil
+}
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"encoding/json"
+
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"go.etcd.io/bbolt"
+)
+
+func PutPlayerSegment(db *bbolt.DB, segment database.PlayerSegment) error {
+	return db.Update(func(tx *bbolt.Tx) error {
+		v, err := json.Marshal(segment)
+		if err != nil {
+			return err
+		}
+		return tx.Bucket([]byte("player_segments")).Put([]byte(segment.Name), v)
+	})
+}
+
+func GetPlayerSegment(db *bbolt.DB, name string) (database.PlayerSegment, error) {
+	var segment database.PlayerSegment
+	err := db.View(func(tx *bbolt.Tx) error {
+		v := tx.Bucket([]byte("player_segments")).Get([]byte(name))
+		if v == nil {
+			return ErrNoRecord
+		}
+		return json.Unmarshal(v, &segment)
+	})
+	return segment, err
+}
+
+func ListPlayerSegments(db *bbolt.DB) ([]database.PlayerSegment, error) {
+	segments := make([]database.PlayerSegment, 0)
+	err := db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket([]byte("player_segments")).ForEach(func(k, v []byte) error {
+			var segment database.PlayerSegment
+			if err := json.Unmarshal(v, &segment); err != nil {
+				return err
+			}
+			segments = append(segments, segment)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return segments, nil
+}
+
+func RemovePlayerSegment(db *bbolt.DB, name string) error {
+	return db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket([]byte("player_segments")).Delete([]byte(name))
+	})
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"go.etcd.io/bbolt"
+)
+
+func TestStreamPlayersPages(t *testing.T) {
+	db := openTestDB(t)
+	players := make([]database.Player, 0, 2*streamPage+50)
+	for i := 0; i < cap(players); i++ {
+		players = append(players, database.Player{TersePlayer: database.TersePlayer{PlayerUid: fmt.Sprintf("%04d", 1000+i), Nickname: "p", Level: 1}})
+	}
+	if _, err := PutPlayers(db, players, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var uids []string
+	err := StreamPlayers(context.Background(), db, func(player database.TersePlayer) error {
+		uids = append(uids, player.PlayerUid)
+		// the read transaction is closed, a write doesn't wait for it
+		return db.Update(func(tx *bbolt.Tx) error { return nil })
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(uids) != len(players) {
+		t.Fatalf("streamed %d players, want %d", len(uids), len(players))
+	}
+	for i, uid := range uids {
+		if uid != players[i].PlayerUid {
+			t.Fatalf("player %d is %s, want %s", i, uid, players[i].PlayerUid)
+		}
+	}
+
+	stop := errors.New("stop")
+	n := 0
+	err = StreamPlayers(context.Background(), db, func(database.TersePlayer) error {
+		if n++; n == streamPage+1 {
+			return stop
+		}
+		return nil
+	})
+	if err != stop || n != streamPage+1 {
+		t.Errorf("stopped after %d players with %v, want %d with stop", n, err, streamPage+1)
+	}
+}
+
+func TestMergeOnlinePlayerJitter(t *testing.T) {
+	now := time.Now()
+	stored := database.Player{TersePlayer: database.TersePlayer{PlayerUid: "1000", Nickname: "alice", Level: 10, LastOnlineSource: LastOnlineLive, OnlinePlayer: database.OnlinePlayer{
+		Ping: 40, LocationX: 1000, LocationY: 2000, LastOnline: now.Add(-10 * time.Second).UTC(),
+	}}}
+	existing, err := json.Marshal(stored)
+	if err != nil {
+		t.Fatal(err)
+	}
+	online := database.OnlinePlayer{PlayerUid: "1000", Nickname: "alice", Level: 10, Ping: 55, LocationX: 1300, LocationY: 2100}
+	v, err := mergeOnlinePlayer(existing, online, now)
+	if err != nil || v != nil {
+		t.Fatalf("ping and a 3m move wrote %s, %v, want nothing", v, err)
+	}
+
+	online.LocationX = 1600
+	v, err = mergeOnlinePlayer(existing, online, now)
+	if err != nil || v == nil {
+		t.Fatalf("a 6m move wrote nothing, %v", err)
+	}
+	var player database.Player
+	if err := json.Unmarshal(v, &player); err != nil {
+		t.Fatal(err)
+	}
+	if player.Ping != 55 || player.LocationX != 1600 || player.LocationY != 2100 || !player.UpdatedAt.Equal(now) {
+		t.Errorf("merged %+v, want the current ping, location and UpdatedAt", player.TersePlayer)
+	}
+}
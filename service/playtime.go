@@ -0,0 +1,98 @@
+package service
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"go.etcd.io/bbolt"
+)
+
+// AddPlaytime counts the time since the last poll for the online players and
+// returns their playtimes. A gap longer than maxGap, like a restart of the
+// tool, is not counted.
+func AddPlaytime(db *bbolt.DB, players []database.OnlinePlayer, now time.Time, maxGap time.Duration) ([]database.Playtime, error) {
+	playtimes := make([]database.Playtime, 0, len(players))
+	err := db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte("playtimes"))
+		for _, p := range players {
+			if p.PlayerUid == "" {
+				continue
+			}
+			playtime := database.Playtime{PlayerUid: p.PlayerUid, Tags: []string{}}
+			if v := b.Get([]byte(p.PlayerUid)); v != nil {
+				if err := json.Unmarshal(v, &playtime); err != nil {
+					return err
+				}
+			}
+			if gap := now.Sub(playtime.LastSeen); !playtime.LastSeen.IsZero() && gap > 0 && gap <= maxGap {
+				playtime.Seconds += int64(gap.Seconds())
+			}
+			playtime.Nickname = p.Nickname
+			playtime.LastSeen = now
+			v, err := json.Marshal(playtime)
+			if err != nil {
+				return err
+			}
+			if err := b.Put([]byte(p.PlayerUid), v); err != nil {
+				return err
+			}
+			playtimes = append(playtimes, playtime)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return playtimes, nil
+}
+
+func PutPlaytime(db *bbolt.DB, playtime database.Playtime) error {
+	return db.Update(func(tx *bbolt.Tx) error {
+		return putPlaytime(tx, playtime)
+	})
+}
+
+func putPlaytime(tx *bbolt.Tx, playtime database.Playtime) error {
+	v, err := json.Marshal(playtime)
+	if err != nil {
+		return err
+	}
+	touch(tx, "playtimes", []byte(playtime.PlayerUid))
+	return tx.Bucket([]byte("playtimes")).Put([]byte(playtime.PlayerUid), v)
+}
+
+func GetPlaytime(db *bbolt.DB, playerUid string) (database.Playtime, error) {
+	var playtime database.Playtime
+	err := db.View(func(tx *bbolt.Tx) error {
+		v := tx.Bucket([]byte("playtimes")).Get([]byte(playerUid))
+		if v == nil {
+			return ErrNoRecord
+		}
+		return json.Unmarshal(v, &playtime)
+	})
+	return playtime, err
+}
+
+// ListPlaytimes returns the playtimes, longest first.
+func ListPlaytimes(db *bbolt.DB) ([]database.Playtime, error) {
+	playtimes := make([]database.Playtime, 0)
+	err := db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket([]byte("playtimes")).ForEach(func(k, v []byte) error {
+			var playtime database.Playtime
+			if err := json.Unmarshal(v, &playtime); err != nil {
+				return err
+			}
+			playtimes = append(playtimes, playtime)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(playtimes, func(i, j int) bool {
+		return playtimes[i].Seconds > playtimes[j].Seconds
+	})
+	return playtimes, nil
+}
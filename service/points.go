@@ -0,0 +1,215 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"go.etcd.io/bbolt"
+)
+
+// PointsReasonOnline is the reason of the daily online earnings.
+const PointsReasonOnline = "online"
+
+var ErrInsufficientPoints = errors.New("insufficient points")
+
+// EarnPoints pays perMinute points for each whole minute the online players
+// stayed since the last poll. A gap longer than maxGap is not counted, like
+// in AddPlaytime.
+func EarnPoints(db *bbolt.DB, players []database.OnlinePlayer, now time.Time, maxGap time.Duration, perMinute int64) error {
+	return db.Update(func(tx *bbolt.Tx) error {
+		for _, p := range players {
+			if p.PlayerUid == "" {
+				continue
+			}
+			account, err := getPointsAccount(tx, p.PlayerUid)
+			if err != nil && err != ErrNoRecord {
+				return err
+			}
+			if gap := now.Sub(account.LastSeen); !account.LastSeen.IsZero() && gap > 0 && gap <= maxGap {
+				account.OnlineSeconds += int64(gap.Seconds())
+			}
+			account.PlayerUid = p.PlayerUid
+			account.Nickname = p.Nickname
+			account.LastSeen = now
+			if amount := account.OnlineSeconds / 60 * perMinute; amount > 0 {
+				account.OnlineSeconds %= 60
+				account.Balance += amount
+				if err := addOnlineTransaction(tx, &account, amount, now); err != nil {
+					return err
+				}
+			}
+			if err := putPointsAccount(tx, account); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// addOnlineTransaction adds amount to the online transaction of the day of
+// now, so polls don't flood the history.
+func addOnlineTransaction(tx *bbolt.Tx, account *database.PointsAccount, amount int64, now time.Time) error {
+	b := tx.Bucket([]byte("point_transactions"))
+	if account.OnlineTransaction != 0 {
+		if v := b.Get(eventKey(account.OnlineTransaction)); v != nil {
+			var t database.PointsTransaction
+			if err := json.Unmarshal(v, &t); err != nil {
+				return err
+			}
+			if t.Time.Local().Format("2006-01-02") == now.Local().Format("2006-01-02") {
+				t.Amount += amount
+				t.Time = now
+				return putPointsTransaction(b, t)
+			}
+		}
+	}
+	t, err := addPointsTransaction(b, database.PointsTransaction{
+		PlayerUid: account.PlayerUid,
+		Amount:    amount,
+		Reason:    PointsReasonOnline,
+		Time:      now,
+	})
+	if err != nil {
+		return err
+	}
+	account.OnlineTransaction = t.Id
+	return nil
+}
+
+// AdjustPoints grants a positive amount or spends a negative one, spending
+// more than the balance fails with ErrInsufficientPoints. The player must
+// be known from a save sync or have earned points before.
+func AdjustPoints(db *bbolt.DB, playerUid string, amount int64, reason string) (database.PointsAccount, error) {
+	var account database.PointsAccount
+	err := db.Update(func(tx *bbolt.Tx) error {
+		var err error
+		account, err = getPointsAccount(tx, playerUid)
+		if err == ErrNoRecord {
+			v := tx.Bucket([]byte("players")).Get([]byte(playerUid))
+			if v == nil {
+				return ErrNoRecord
+			}
+			var player database.Player
+			if err := json.Unmarshal(v, &player); err != nil {
+				return err
+			}
+			account = database.PointsAccount{PlayerUid: playerUid, Nickname: player.Nickname}
+		} else if err != nil {
+			return err
+		}
+		if account.Balance+amount < 0 {
+			return ErrInsufficientPoints
+		}
+		account.Balance += amount
+		_, err = addPointsTransaction(tx.Bucket([]byte("point_transactions")), database.PointsTransaction{
+			PlayerUid: playerUid,
+			Amount:    amount,
+			Reason:    reason,
+			Time:      time.Now(),
+		})
+		if err != nil {
+			return err
+		}
+		return putPointsAccount(tx, account)
+	})
+	if err != nil {
+		return database.PointsAccount{}, err
+	}
+	return account, nil
+}
+
+func GetPoints(db *bbolt.DB, playerUid string) (database.PointsAccount, error) {
+	var account database.PointsAccount
+	err := db.View(func(tx *bbolt.Tx) error {
+		var err error
+		account, err = getPointsAccount(tx, playerUid)
+		return err
+	})
+	return account, err
+}
+
+// ListPoints returns the accounts, highest balance first.
+func ListPoints(db *bbolt.DB) ([]database.PointsAccount, error) {
+	accounts := make([]database.PointsAccount, 0)
+	err := db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket([]byte("points")).ForEach(func(k, v []byte) error {
+			var account database.PointsAccount
+			if err := json.Unmarshal(v, &account); err != nil {
+				return err
+			}
+			accounts = append(accounts, account)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(accounts, func(i, j int) bool { return accounts[i].Balance > accounts[j].Balance })
+	return accounts, nil
+}
+
+// ListPointsTransactions returns the transactions of a player, or of
+// everyone when playerUid is empty, newest first.
+func ListPointsTransactions(db *bbolt.DB, playerUid string, limit int) ([]database.PointsTransaction, error) {
+	transactions := make([]database.PointsTransaction, 0)
+	err := db.View(func(tx *bbolt.Tx) error {
+		c := tx.Bucket([]byte("point_transactions")).Cursor()
+		for k, v := c.Last(); k != nil; k, v = c.Prev() {
+			var t database.PointsTransaction
+			if err := json.Unmarshal(v, &t); err != nil {
+				return err
+			}
+			if playerUid != "" && t.PlayerUid != playerUid {
+				continue
+			}
+			transactions = append(transactions, t)
+			if limit > 0 && len(transactions) >= limit {
+				break
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return transactions, nil
+}
+
+func getPointsAccount(tx *bbolt.Tx, playerUid string) (database.PointsAccount, error) {
+	var account database.PointsAccount
+	v := tx.Bucket([]byte("points")).Get([]byte(playerUid))
+	if v == nil {
+		return account, ErrNoRecord
+	}
+	err := json.Unmarshal(v, &account)
+	return account, err
+}
+
+func putPointsAccount(tx *bbolt.Tx, account database.PointsAccount) error {
+	v, err := json.Marshal(account)
+	if err != nil {
+		return err
+	}
+	return tx.Bucket([]byte("points")).Put([]byte(account.PlayerUid), v)
+}
+
+// addPointsTransaction stores t with an increasing id, like addEvents.
+func addPointsTransaction(b *bbolt.Bucket, t database.PointsTransaction) (database.PointsTransaction, error) {
+	id, err := b.NextSequence()
+	if err != nil {
+		return t, err
+	}
+	t.Id = id
+	return t, putPointsTransaction(b, t)
+}
+
+func putPointsTransaction(b *bbolt.Bucket, t database.PointsTransaction) error {
+	v, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+	return b.Put(eventKey(t.Id), v)
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"go.etcd.io/bbolt"
+)
+
+const (
+	RemovedWhitelist = "whitelist"
+	RemovedIpBan     = "ipban"
+	RemovedBan       = "ban"
+)
+
+var ErrAlreadyRestored = errors.New("entry already restored")
+
+// Removal says who removed an entry and why, for the history.
+type Removal struct {
+	Reason string
+	// By is the address of the request, the bot or cli
+	By string
+}
+
+// addRemoved keeps entry in the history of removed entries.
+func addRemoved(tx *bbolt.Tx, entry database.RemovedEntry, removal Removal) error {
+	b := tx.Bucket([]byte("removed_entries"))
+	id, err := b.NextSequence()
+	if err != nil {
+		return err
+	}
+	entry.Id = id
+	entry.Reason = removal.Reason
+	entry.RemovedBy = removal.By
+	entry.RemovedAt = time.Now()
+	v, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	touch(tx, "removed_entries", eventKey(id))
+	return b.Put(eventKey(id), v)
+}
+
+// AddRemovedBan records that player was unbanned on the game server, bans
+// aren't stored by pst otherwise.
+func AddRemovedBan(db *bbolt.DB, player database.PlayerW, removal Removal) error {
+	return db.Update(func(tx *bbolt.Tx) error {
+		return addRemoved(tx, database.RemovedEntry{Kind: RemovedBan, Player: &player}, removal)
+	})
+}
+
+// ListRemoved returns the removed entries of kind, or of every kind when it
+// is empty, newest first.
+func ListRemoved(db *bbolt.DB, kind string, limit int) ([]database.RemovedEntry, error) {
+	entries := make([]database.RemovedEntry, 0)
+	err := db.View(func(tx *bbolt.Tx) error {
+		c := tx.Bucket([]byte("removed_entries")).Cursor()
+		for k, v := c.Last(); k != nil; k, v = c.Prev() {
+			var entry database.RemovedEntry
+			if err := json.Unmarshal(v, &entry); err != nil {
+				return err
+			}
+			if kind != "" && entry.Kind != kind {
+				continue
+			}
+			entries = append(entries, entry)
+			if limit > 0 && len(entries) >= limit {
+				break
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func GetRemoved(db *bbolt.DB, id uint64) (database.RemovedEntry, error) {
+	var entry database.RemovedEntry
+	err := db.View(func(tx *bbolt.Tx) error {
+		v := tx.Bucket([]byte("removed_entries")).Get(eventKey(id))
+		if v == nil {
+			return ErrNoRecord
+		}
+		return json.Unmarshal(v, &entry)
+	})
+	return entry, err
+}
+
+// RestoreRemoved puts a removed whitelist entry or ip ban back and marks it
+// restored. A ban is only marked, the caller bans the player again on the
+// game server first.
+func RestoreRemoved(db *bbolt.DB, id uint64) (database.RemovedEntry, error) {
+	var entry database.RemovedEntry
+	err := db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte("removed_entries"))
+		v := b.Get(eventKey(id))
+		if v == nil {
+			return ErrNoRecord
+		}
+		if err := json.Unmarshal(v, &entry); err != nil {
+			return err
+		}
+		if !entry.RestoredAt.IsZero() {
+			return ErrAlreadyRestored
+		}
+		switch entry.Kind {
+		case RemovedWhitelist:
+			player := *entry.Player
+			// restored whatever was written since
+			player.Revision = 0
+			if err := putWhitelistEntry(tx, player); err != nil {
+				return err
+			}
+		case RemovedIpBan:
+			v, err := json.Marshal(entry.IpBan)
+			if err != nil {
+				return err
+			}
+			if err := tx.Bucket([]byte("ipbans")).Put([]byte(entry.IpBan.Ip), v); err != nil {
+				return err
+			}
+		}
+		entry.RestoredAt = time.Now()
+		v, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		return b.Put(eventKey(id), v)
+	})
+	if err != nil {
+		return database.RemovedEntry{}, err
+	}
+	return entry, nil
+}
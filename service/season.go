@@ -0,0 +1,183 @@
+package service
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"go.etcd.io/bbolt"
+)
+
+// seasonBuckets hold the stats of the world, emptied when a season ends.
+// Settings, moderation with its removed entries and the backup catalog carry
+// over.
+var seasonBuckets = []string{
+	"players",
+	"guilds",
+	"playtimes",
+	"points",
+	"point_transactions",
+	"events",
+	"heatmaps",
+	"map_objects",
+	"guild_stats",
+	"daily_snapshots",
+	"daily_reports",
+	"ping_history",
+	"metrics",
+	"pal_owners",
+	"guild_storage",
+	"storage_changes",
+	"badges",
+}
+
+var ErrSeasonExists = errors.New("season already exists")
+
+// EndSeason copies the database to dir as the archive of the season named
+// name, labels the backups not in a season yet with it and empties the
+// stats for the next season.
+func EndSeason(db *bbolt.DB, name string, dir string) (database.Season, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return database.Season{}, errors.New("season name is required")
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return database.Season{}, err
+	}
+	season := database.Season{Name: name, End: time.Now()}
+	err := db.Update(func(tx *bbolt.Tx) error {
+		sb := tx.Bucket([]byte("seasons"))
+		if err := sb.ForEach(func(k, v []byte) error {
+			var s database.Season
+			if err := json.Unmarshal(v, &s); err != nil {
+				return err
+			}
+			if s.Name == name {
+				return ErrSeasonExists
+			}
+			if s.End.After(season.Start) {
+				season.Start = s.End
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+		var err error
+		if season.Id, err = sb.NextSequence(); err != nil {
+			return err
+		}
+		season.Path = fmt.Sprintf("season-%d.db", season.Id)
+		// the copy is of the database as the transaction sees it, before
+		// anything below is changed
+		if err := tx.CopyFile(filepath.Join(dir, season.Path), 0600); err != nil {
+			return err
+		}
+
+		if err := tx.Bucket([]byte("players")).ForEach(func(k, v []byte) error {
+			if !strings.Contains(string(k), "000000") {
+				season.Players++
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+		season.Guilds = tx.Bucket([]byte("guilds")).Stats().KeyN
+
+		bb := tx.Bucket([]byte("backups"))
+		var labeled []database.Backup
+		if err := bb.ForEach(func(k, v []byte) error {
+			var backup database.Backup
+			if err := json.Unmarshal(v, &backup); err != nil {
+				return err
+			}
+			if backup.Season == "" {
+				backup.Season = name
+				labeled = append(labeled, backup)
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+		for _, backup := range labeled {
+			v, err := json.Marshal(backup)
+			if err != nil {
+				return err
+			}
+			if err := bb.Put([]byte(backup.BackupId), v); err != nil {
+				return err
+			}
+		}
+		season.Backups = len(labeled)
+
+		for _, bucket := range seasonBuckets {
+			if err := tx.DeleteBucket([]byte(bucket)); err != nil && err != bbolt.ErrBucketNotFound {
+				return err
+			}
+			if _, err := tx.CreateBucket([]byte(bucket)); err != nil {
+				return err
+			}
+		}
+
+		v, err := json.Marshal(season)
+		if err != nil {
+			return err
+		}
+		return sb.Put(seasonKey(season.Id), v)
+	})
+	if err != nil {
+		if season.Path != "" && !errors.Is(err, ErrSeasonExists) {
+			os.Remove(filepath.Join(dir, season.Path))
+		}
+		return database.Season{}, err
+	}
+	return season, nil
+}
+
+func seasonKey(id uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, id)
+	return key
+}
+
+// ListSeasons returns the ended seasons, the first first.
+func ListSeasons(db *bbolt.DB) ([]database.Season, error) {
+	seasons := make([]database.Season, 0)
+	err := db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket([]byte("seasons")).ForEach(func(k, v []byte) error {
+			var season database.Season
+			if err := json.Unmarshal(v, &season); err != nil {
+				return err
+			}
+			seasons = append(seasons, season)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return seasons, nil
+}
+
+func GetSeason(db *bbolt.DB, id uint64) (database.Season, error) {
+	var season database.Season
+	err := db.View(func(tx *bbolt.Tx) error {
+		v := tx.Bucket([]byte("seasons")).Get(seasonKey(id))
+		if v == nil {
+			return ErrNoRecord
+		}
+		return json.Unmarshal(v, &season)
+	})
+	return season, err
+}
+
+// OpenSeason opens the archived database of the season in dir read-only,
+// the caller closes it.
+func OpenSeason(season database.Season, dir string) (*bbolt.DB, error) {
+	return bbolt.Open(filepath.Join(dir, season.Path), 0600, &bbolt.Options{ReadOnly: true, Timeout: time.Second})
+}
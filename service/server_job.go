@@ -0,0 +1,57 @@
+package service
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"go.etcd.io/bbolt"
+)
+
+func PutServerJob(db *bbolt.DB, job database.ServerJob) error {
+	return db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte("server_jobs"))
+		v, err := json.Marshal(job)
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte(job.Id), v)
+	})
+}
+
+func GetServerJob(db *bbolt.DB, id string) (database.ServerJob, error) {
+	var job database.ServerJob
+	err := db.View(func(tx *bbolt.Tx) error {
+		v := tx.Bucket([]byte("server_jobs")).Get([]byte(id))
+		if v == nil {
+			return ErrNoRecord
+		}
+		return json.Unmarshal(v, &job)
+	})
+	return job, err
+}
+
+// ListServerJobs returns the latest jobs first, all of them when limit is 0.
+func ListServerJobs(db *bbolt.DB, limit int) ([]database.ServerJob, error) {
+	jobs := make([]database.ServerJob, 0)
+	err := db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket([]byte("server_jobs")).ForEach(func(k, v []byte) error {
+			var job database.ServerJob
+			if err := json.Unmarshal(v, &job); err != nil {
+				return err
+			}
+			jobs = append(jobs, job)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].CreatedAt.After(jobs[j].CreatedAt)
+	})
+	if limit > 0 && len(jobs) > limit {
+		jobs = jobs[:limit]
+	}
+	return jobs, nil
+}
@@ -3,3 +3,28 @@ package service
 import "errors"
 
 var ErrNoRecord = errors.New("record not found")
+
+// ErrRevisionConflict refuses a write made with the revision of a record
+// that has changed since.
+var ErrRevisionConflict = errors.New("record was changed since it was read, reload it and retry")
+
+// checkRevision returns ErrRevisionConflict when a write made with revision
+// isn't based on the current one, a revision of 0 skips the check.
+func checkRevision(revision, current uint64) error {
+	if revision != 0 && revision != current {
+		return ErrRevisionConflict
+	}
+	return nil
+}
+
+// page returns limit items from offset on, all from offset without a limit.
+func page[T any](items []T, offset, limit int) []T {
+	if offset >= len(items) {
+		return items[:0]
+	}
+	items = items[offset:]
+	if limit > 0 && limit < len(items) {
+		items = items[:limit]
+	}
+	return items
+}
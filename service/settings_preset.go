@@ -0,0 +1,58 @@
+package service
+
+import (
+	"encoding/json"
+
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"go.etcd.io/bbolt"
+)
+
+func PutSettingsPreset(db *bbolt.DB, preset database.SettingsPreset) error {
+	return db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte("settings_presets"))
+		v, err := json.Marshal(preset)
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte(preset.Name), v)
+	})
+}
+
+func GetSettingsPreset(db *bbolt.DB, name string) (database.SettingsPreset, error) {
+	var preset database.SettingsPreset
+	err := db.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte("settings_presets"))
+		v := b.Get([]byte(name))
+		if v == nil {
+			return ErrNoRecord
+		}
+		return json.Unmarshal(v, &preset)
+	})
+	return preset, err
+}
+
+func ListSettingsPresets(db *bbolt.DB) ([]database.SettingsPreset, error) {
+	presets := make([]database.SettingsPreset, 0)
+	err := db.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte("settings_presets"))
+		return b.ForEach(func(k, v []byte) error {
+			var preset database.SettingsPreset
+			if err := json.Unmarshal(v, &preset); err != nil {
+				return err
+			}
+			presets = append(presets, preset)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return presets, nil
+}
+
+func RemoveSettingsPreset(db *bbolt.DB, name string) error {
+	return db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte("settings_presets"))
+		return b.Delete([]byte(name))
+	})
+}
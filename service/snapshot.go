@@ -0,0 +1,168 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"go.etcd.io/bbolt"
+)
+
+const (
+	SnapshotWhitelistReplace = "whitelist.replace"
+	SnapshotWhitelistMerge   = "whitelist.merge"
+	SnapshotWhitelistAppend  = "whitelist.append"
+	SnapshotSettingsApply    = "settings.apply"
+	// SnapshotRevert is taken before another snapshot is reverted, so the
+	// revert can be undone too
+	SnapshotRevert = "snapshot.revert"
+)
+
+// maxSnapshots is how many snapshots are kept, the oldest go first.
+const maxSnapshots = 100
+
+var ErrAlreadyReverted = errors.New("snapshot already reverted")
+
+// TakeSnapshot stores the records of buckets and the name of a settings
+// backup, before operation changes them.
+func TakeSnapshot(db *bbolt.DB, operation, by string, buckets []string, settingsBackup string) (database.Snapshot, error) {
+	snapshot := database.Snapshot{
+		Operation:      operation,
+		By:             by,
+		Time:           time.Now(),
+		SettingsBackup: settingsBackup,
+	}
+	err := db.Update(func(tx *bbolt.Tx) error {
+		if len(buckets) > 0 {
+			snapshot.Buckets = make(map[string]map[string][]byte, len(buckets))
+		}
+		for _, name := range buckets {
+			records := make(map[string][]byte)
+			// the whitelist bucket is created on the first entry
+			if b := tx.Bucket([]byte(name)); b != nil {
+				err := b.ForEach(func(k, v []byte) error {
+					records[string(k)] = append([]byte(nil), v...)
+					return nil
+				})
+				if err != nil {
+					return err
+				}
+			}
+			snapshot.Buckets[name] = records
+		}
+
+		b := tx.Bucket([]byte("snapshots"))
+		id, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+		snapshot.Id = id
+		v, err := json.Marshal(snapshot)
+		if err != nil {
+			return err
+		}
+		if err := b.Put(eventKey(id), v); err != nil {
+			return err
+		}
+		if id > maxSnapshots {
+			c := b.Cursor()
+			for k, _ := c.First(); k != nil && string(k) <= string(eventKey(id-maxSnapshots)); k, _ = c.Next() {
+				if err := c.Delete(); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return database.Snapshot{}, err
+	}
+	return snapshot, nil
+}
+
+// ListSnapshots returns the snapshots newest first, without their records.
+func ListSnapshots(db *bbolt.DB, limit int) ([]database.Snapshot, error) {
+	snapshots := make([]database.Snapshot, 0)
+	err := db.View(func(tx *bbolt.Tx) error {
+		c := tx.Bucket([]byte("snapshots")).Cursor()
+		for k, v := c.Last(); k != nil; k, v = c.Prev() {
+			var snapshot database.Snapshot
+			if err := json.Unmarshal(v, &snapshot); err != nil {
+				return err
+			}
+			snapshot.Buckets = nil
+			snapshots = append(snapshots, snapshot)
+			if limit > 0 && len(snapshots) >= limit {
+				break
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return snapshots, nil
+}
+
+func GetSnapshot(db *bbolt.DB, id uint64) (database.Snapshot, error) {
+	var snapshot database.Snapshot
+	err := db.View(func(tx *bbolt.Tx) error {
+		v := tx.Bucket([]byte("snapshots")).Get(eventKey(id))
+		if v == nil {
+			return ErrNoRecord
+		}
+		return json.Unmarshal(v, &snapshot)
+	})
+	return snapshot, err
+}
+
+// RevertSnapshot puts the records of the snapshot buckets back in place of
+// the current ones and marks it reverted. The settings backup is restored by
+// the caller first.
+func RevertSnapshot(db *bbolt.DB, id uint64) (database.Snapshot, error) {
+	var snapshot database.Snapshot
+	err := db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte("snapshots"))
+		v := b.Get(eventKey(id))
+		if v == nil {
+			return ErrNoRecord
+		}
+		if err := json.Unmarshal(v, &snapshot); err != nil {
+			return err
+		}
+		if !snapshot.RevertedAt.IsZero() {
+			return ErrAlreadyReverted
+		}
+		for name, records := range snapshot.Buckets {
+			// keys handed out since stay used
+			var sequence uint64
+			if old := tx.Bucket([]byte(name)); old != nil {
+				sequence = old.Sequence()
+				if err := tx.DeleteBucket([]byte(name)); err != nil {
+					return err
+				}
+			}
+			bucket, err := tx.CreateBucket([]byte(name))
+			if err != nil {
+				return err
+			}
+			if err := bucket.SetSequence(sequence); err != nil {
+				return err
+			}
+			for k, v := range records {
+				if err := bucket.Put([]byte(k), v); err != nil {
+					return err
+				}
+			}
+		}
+		snapshot.RevertedAt = time.Now()
+		v, err := json.Marshal(snapshot)
+		if err != nil {
+			return err
+		}
+		return b.Put(eventKey(id), v)
+	})
+	snapshot.Buckets = nil
+	return snapshot, err
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"go.etcd.io/bbolt"
+)
+
+type BucketStats struct {
+	Name string `json:"name"`
+	Keys int    `json:"keys"`
+	// Bytes is what the pages of the bucket take in the file, InUse the
+	// part of them holding records
+	Bytes         int64   `json:"bytes"`
+	InUse         int64   `json:"in_use"`
+	AverageRecord float64 `json:"average_record"`
+	// KeysGrowth and BytesGrowth are the change since the first sample of
+	// the range
+	KeysGrowth  int   `json:"keys_growth"`
+	BytesGrowth int64 `json:"bytes_growth"`
+}
+
+type StorageStats struct {
+	Path     string `json:"path"`
+	FileSize int64  `json:"file_size"`
+	// FreeBytes are in free pages the file keeps for reuse
+	FreeBytes int64 `json:"free_bytes"`
+	// Since is the time of the sample the growth is measured from, zero
+	// without samples in the range
+	Since time.Time `json:"since"`
+	// Buckets are the largest first
+	Buckets []BucketStats            `json:"buckets"`
+	History []database.StorageSample `json:"history"`
+}
+
+// measureStorage returns the size of the database and its buckets.
+func measureStorage(tx *bbolt.Tx) (database.StorageSample, map[string]bbolt.BucketStats, error) {
+	sample := database.StorageSample{FileSize: tx.Size(), Buckets: make(map[string]database.BucketSize)}
+	stats := make(map[string]bbolt.BucketStats)
+	err := tx.ForEach(func(name []byte, b *bbolt.Bucket) error {
+		s := b.Stats()
+		stats[string(name)] = s
+		// a small bucket is inline in the page of its parent
+		sample.Buckets[string(name)] = database.BucketSize{Keys: s.KeyN, Bytes: int64(s.BranchAlloc + s.LeafAlloc + s.InlineBucketInuse)}
+		return nil
+	})
+	return sample, stats, err
+}
+
+// SampleStorage stores the current sizes for the growth of the buckets and
+// drops the samples older than keep.
+func SampleStorage(db *bbolt.DB, keep time.Duration) error {
+	return db.Update(func(tx *bbolt.Tx) error {
+		sample, _, err := measureStorage(tx)
+		if err != nil {
+			return err
+		}
+		sample.Time = time.Now()
+		b := tx.Bucket([]byte("storage_samples"))
+		v, err := json.Marshal(sample)
+		if err != nil {
+			return err
+		}
+		if err := b.Put(metricKey(sample.Time), v); err != nil {
+			return err
+		}
+		if keep <= 0 {
+			return nil
+		}
+		c := b.Cursor()
+		cutoff := metricKey(sample.Time.Add(-keep))
+		for k, _ := c.First(); k != nil && string(k) < string(cutoff); k, _ = c.First() {
+			if err := c.Delete(); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetStorageStats measures the buckets now, with their growth since the
+// first sample after since and the samples from there on.
+func GetStorageStats(db *bbolt.DB, since time.Time) (StorageStats, error) {
+	stats := StorageStats{
+		Path:      db.Path(),
+		FreeBytes: int64(db.Stats().FreeAlloc),
+		Buckets:   make([]BucketStats, 0),
+		History:   make([]database.StorageSample, 0),
+	}
+	err := db.View(func(tx *bbolt.Tx) error {
+		c := tx.Bucket([]byte("storage_samples")).Cursor()
+		for k, v := c.Seek(metricKey(since)); k != nil; k, v = c.Next() {
+			var sample database.StorageSample
+			if err := json.Unmarshal(v, &sample); err != nil {
+				return err
+			}
+			stats.History = append(stats.History, sample)
+		}
+
+		now, buckets, err := measureStorage(tx)
+		if err != nil {
+			return err
+		}
+		stats.FileSize = now.FileSize
+		var first database.StorageSample
+		if len(stats.History) > 0 {
+			first = stats.History[0]
+			stats.Since = first.Time
+		}
+		for name, s := range buckets {
+			bucket := BucketStats{
+				Name:  name,
+				Keys:  s.KeyN,
+				Bytes: now.Buckets[name].Bytes,
+				InUse: int64(s.BranchInuse + s.LeafInuse + s.InlineBucketInuse),
+			}
+			if bucket.Keys > 0 {
+				bucket.AverageRecord = float64(bucket.InUse) / float64(bucket.Keys)
+			}
+			if first.Buckets != nil {
+				bucket.KeysGrowth = bucket.Keys - first.Buckets[name].Keys
+				bucket.BytesGrowth = bucket.Bytes - first.Buckets[name].Bytes
+			}
+			stats.Buckets = append(stats.Buckets, bucket)
+		}
+		return nil
+	})
+	sort.Slice(stats.Buckets, func(i, j int) bool {
+		if stats.Buckets[i].Bytes != stats.Buckets[j].Bytes {
+			return stats.Buckets[i].Bytes > stats.Buckets[j].Bytes
+		}
+		return stats.Buckets[i].Name < stats.Buckets[j].Name
+	})
+	return stats, err
+}
@@ -0,0 +1,443 @@
+package service
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"go.etcd.io/bbolt"
+)
+
+// The steps of a save sync, named after the bucket each writes.
+const (
+	SyncStepPlayers    = "players"
+	SyncStepGuilds     = "guilds"
+	SyncStepMapObjects = "map_objects"
+)
+
+const (
+	SyncStatusRunning = "running"
+	SyncStatusDone    = "done"
+	// SyncStatusPartial is a sync that finished without a step, or with a
+	// failed one
+	SyncStatusPartial    = "partial"
+	SyncStatusFailed     = "failed"
+	SyncStatusRolledBack = "rolled_back"
+)
+
+var (
+	ErrSyncBatchRunning    = errors.New("sync batch is still running")
+	ErrSyncBatchRolledBack = errors.New("sync batch already rolled back")
+	ErrNotLatestBatch      = errors.New("only the latest sync batch not rolled back can be rolled back")
+)
+
+// syncJournalKey is the key of the payload or the replaced records of a
+// step in the sync_journal bucket.
+func syncJournalKey(batchId uint64, step, kind string) []byte {
+	return append(eventKey(batchId), step+"/"+kind...)
+}
+
+// BeginSyncBatch journals a new sync batch and returns it with its id,
+// ErrSyncBatchRunning while another one runs.
+func BeginSyncBatch(db *bbolt.DB, batch database.SyncBatch) (database.SyncBatch, error) {
+	batch.Start = time.Now()
+	batch.Status = SyncStatusRunning
+	batch.Steps = make([]database.SyncStep, 0)
+	err := db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte("sync_batches"))
+		if _, v := b.Cursor().Last(); v != nil {
+			var last database.SyncBatch
+			if err := json.Unmarshal(v, &last); err != nil {
+				return err
+			}
+			if last.Status == SyncStatusRunning {
+				return ErrSyncBatchRunning
+			}
+		}
+		id, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+		batch.Id = id
+		return putSyncBatch(tx, batch)
+	})
+	return batch, err
+}
+
+// FailInterruptedSyncBatches marks the batches still running as failed,
+// pst stopped during them. It returns how many there were.
+func FailInterruptedSyncBatches(db *bbolt.DB) (int, error) {
+	n := 0
+	err := db.Update(func(tx *bbolt.Tx) error {
+		var interrupted []database.SyncBatch
+		err := tx.Bucket([]byte("sync_batches")).ForEach(func(k, v []byte) error {
+			var batch database.SyncBatch
+			if err := json.Unmarshal(v, &batch); err != nil {
+				return err
+			}
+			if batch.Status == SyncStatusRunning {
+				interrupted = append(interrupted, batch)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, batch := range interrupted {
+			batch.Status = SyncStatusFailed
+			batch.Error = "interrupted"
+			batch.End = time.Now()
+			if err := putSyncBatch(tx, batch); err != nil {
+				return err
+			}
+		}
+		n = len(interrupted)
+		return nil
+	})
+	return n, err
+}
+
+func putSyncBatch(tx *bbolt.Tx, batch database.SyncBatch) error {
+	v, err := json.Marshal(batch)
+	if err != nil {
+		return err
+	}
+	return tx.Bucket([]byte("sync_batches")).Put(eventKey(batch.Id), v)
+}
+
+func getSyncBatch(tx *bbolt.Tx, id uint64) (database.SyncBatch, error) {
+	var batch database.SyncBatch
+	v := tx.Bucket([]byte("sync_batches")).Get(eventKey(id))
+	if v == nil {
+		return batch, ErrNoRecord
+	}
+	err := json.Unmarshal(v, &batch)
+	return batch, err
+}
+
+// StepJournal keeps the records a sync step changed, with what they were
+// before and after it. The step's writes take them in their own
+// transactions, so writes of others during the sync, like the online
+// players, aren't mistaken for the step's. A nil StepJournal keeps nothing.
+type StepJournal struct {
+	changes map[string]journalRecord
+	// mode is how the step wrote the records, for steps that have modes
+	mode string
+	// pending are the records of the transaction tx, kept once it commits
+	tx      *bbolt.Tx
+	pending map[string]journalRecord
+}
+
+// journalRecord is a record before and after a step, nil when it didn't
+// exist.
+type journalRecord struct {
+	Before []byte `json:"before"`
+	After  []byte `json:"after"`
+}
+
+// setMode keeps the mode the step writes its records by, to replay it the
+// same way.
+func (j *StepJournal) setMode(mode string) {
+	if j != nil {
+		j.mode = mode
+	}
+}
+
+// before keeps the record of key as it is before tx writes it, call it
+// before every put or delete of the step.
+func (j *StepJournal) before(tx *bbolt.Tx, b *bbolt.Bucket, key []byte) {
+	if j == nil {
+		return
+	}
+	if j.tx != tx {
+		j.tx, j.pending = tx, make(map[string]journalRecord)
+	}
+	if _, ok := j.changes[string(key)]; ok {
+		return
+	}
+	if _, ok := j.pending[string(key)]; ok {
+		return
+	}
+	var r journalRecord
+	if v := b.Get(key); v != nil {
+		r.Before = append([]byte{}, v...)
+	}
+	j.pending[string(key)] = r
+}
+
+// after takes the records tx wrote as they are now, call it last in the
+// transaction. They are kept once it commits, unless they didn't change.
+func (j *StepJournal) after(tx *bbolt.Tx, b *bbolt.Bucket) {
+	if j == nil || j.tx != tx {
+		return
+	}
+	pending := j.pending
+	for k, r := range pending {
+		if v := b.Get([]byte(k)); v != nil {
+			r.After = append([]byte{}, v...)
+		}
+		pending[k] = r
+	}
+	j.tx, j.pending = nil, nil
+	tx.OnCommit(func() {
+		if j.changes == nil {
+			j.changes = make(map[string]journalRecord)
+		}
+		for k, r := range pending {
+			if bytes.Equal(r.Before, r.After) && (r.Before == nil) == (r.After == nil) {
+				continue
+			}
+			j.changes[k] = r
+		}
+	})
+}
+
+// JournalStep runs apply, the step of the batch writing the records sent in
+// payload, and journals the payload with the records apply changed. With
+// batch 0 apply just runs, with a nil journal.
+func JournalStep(db *bbolt.DB, batchId uint64, step string, payload any, records int, apply func(j *StepJournal) error) error {
+	if batchId == 0 {
+		return apply(nil)
+	}
+	data, err := gzipJSON(payload)
+	if err != nil {
+		return err
+	}
+	j := &StepJournal{}
+	applyErr := apply(j)
+	changes := j.changes
+	if changes == nil {
+		changes = make(map[string]journalRecord)
+	}
+	changesData, err := gzipJSON(changes)
+	if err != nil {
+		return err
+	}
+	err = db.Update(func(tx *bbolt.Tx) error {
+		batch, err := getSyncBatch(tx, batchId)
+		if err != nil {
+			return err
+		}
+		s := database.SyncStep{Name: step, Mode: j.mode, Time: time.Now(), Records: records, Changed: len(changes)}
+		if applyErr != nil {
+			s.Error = applyErr.Error()
+		}
+		batch.Steps = append(batch.Steps, s)
+		b := tx.Bucket([]byte("sync_journal"))
+		if err := b.Put(syncJournalKey(batchId, step, "payload"), data); err != nil {
+			return err
+		}
+		if err := b.Put(syncJournalKey(batchId, step, "changes"), changesData); err != nil {
+			return err
+		}
+		return putSyncBatch(tx, batch)
+	})
+	if applyErr != nil {
+		return applyErr
+	}
+	return err
+}
+
+func gzipJSON(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if err := json.NewEncoder(zw).Encode(v); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func gunzipJSON(data []byte, v any) error {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	b, err := io.ReadAll(zr)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+// FinishSyncBatch records how the batch ended, partial when the players or
+// guilds step is missing or a step failed, and drops the batches before the
+// keep latest.
+func FinishSyncBatch(db *bbolt.DB, batchId uint64, syncErr error, keep int) (database.SyncBatch, error) {
+	var batch database.SyncBatch
+	err := db.Update(func(tx *bbolt.Tx) error {
+		var err error
+		if batch, err = getSyncBatch(tx, batchId); err != nil {
+			return err
+		}
+		batch.End = time.Now()
+		batch.Status = SyncStatusDone
+		steps := make(map[string]bool)
+		for _, step := range batch.Steps {
+			steps[step.Name] = step.Error == ""
+		}
+		if !steps[SyncStepPlayers] || !steps[SyncStepGuilds] {
+			batch.Status = SyncStatusPartial
+		}
+		if syncErr != nil {
+			batch.Error = syncErr.Error()
+			if len(batch.Steps) == 0 {
+				batch.Status = SyncStatusFailed
+			} else {
+				batch.Status = SyncStatusPartial
+			}
+		}
+		if err := putSyncBatch(tx, batch); err != nil {
+			return err
+		}
+		if keep > 0 && batchId > uint64(keep) {
+			return deleteSyncBatches(tx, batchId-uint64(keep))
+		}
+		return nil
+	})
+	return batch, err
+}
+
+// deleteSyncBatches deletes the batches up to id with their journal.
+func deleteSyncBatches(tx *bbolt.Tx, id uint64) error {
+	last := eventKey(id)
+	for _, name := range []string{"sync_batches", "sync_journal"} {
+		c := tx.Bucket([]byte(name)).Cursor()
+		for k, _ := c.First(); k != nil && bytes.Compare(k[:8], last) <= 0; k, _ = c.First() {
+			if err := c.Delete(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ListSyncBatches returns the journaled batches newest first.
+func ListSyncBatches(db *bbolt.DB, limit int) ([]database.SyncBatch, error) {
+	batches := make([]database.SyncBatch, 0)
+	err := db.View(func(tx *bbolt.Tx) error {
+		c := tx.Bucket([]byte("sync_batches")).Cursor()
+		for k, v := c.Last(); k != nil; k, v = c.Prev() {
+			var batch database.SyncBatch
+			if err := json.Unmarshal(v, &batch); err != nil {
+				return err
+			}
+			batches = append(batches, batch)
+			if limit > 0 && len(batches) >= limit {
+				break
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return batches, nil
+}
+
+func GetSyncBatch(db *bbolt.DB, id uint64) (database.SyncBatch, error) {
+	var batch database.SyncBatch
+	err := db.View(func(tx *bbolt.Tx) error {
+		var err error
+		batch, err = getSyncBatch(tx, id)
+		return err
+	})
+	return batch, err
+}
+
+// GetSyncPayload decodes what the save sent for the step of the batch into
+// v, ErrNoRecord when the step didn't run.
+func GetSyncPayload(db *bbolt.DB, id uint64, step string, v any) error {
+	var data []byte
+	err := db.View(func(tx *bbolt.Tx) error {
+		data = tx.Bucket([]byte("sync_journal")).Get(syncJournalKey(id, step, "payload"))
+		if data == nil {
+			return ErrNoRecord
+		}
+		data = append([]byte(nil), data...)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return gunzipJSON(data, v)
+}
+
+// RollbackSyncBatch puts back the players, guilds and map objects the batch
+// replaced and removes the ones it added. A record changed since, like a
+// player who came online, is skipped and counted in RollbackSkipped. Only
+// the latest batch not rolled back yet can be, the ones after it would be
+// undone with it otherwise. Records derived during the sync, like events,
+// stay.
+func RollbackSyncBatch(db *bbolt.DB, id uint64) (database.SyncBatch, error) {
+	var batch database.SyncBatch
+	err := db.Update(func(tx *bbolt.Tx) error {
+		c := tx.Bucket([]byte("sync_batches")).Cursor()
+		for k, v := c.Last(); k != nil; k, v = c.Prev() {
+			var b database.SyncBatch
+			if err := json.Unmarshal(v, &b); err != nil {
+				return err
+			}
+			if b.Status == SyncStatusRolledBack {
+				continue
+			}
+			if b.Id != id {
+				target, err := getSyncBatch(tx, id)
+				if err != nil {
+					return err
+				}
+				if target.Status == SyncStatusRolledBack {
+					return ErrSyncBatchRolledBack
+				}
+				return ErrNotLatestBatch
+			}
+			batch = b
+			break
+		}
+		if batch.Id == 0 {
+			return ErrNoRecord
+		}
+		if batch.Status == SyncStatusRunning {
+			return ErrSyncBatchRunning
+		}
+		journal := tx.Bucket([]byte("sync_journal"))
+		for i := len(batch.Steps) - 1; i >= 0; i-- {
+			step := batch.Steps[i].Name
+			data := journal.Get(syncJournalKey(id, step, "changes"))
+			if data == nil {
+				continue
+			}
+			var changes map[string]journalRecord
+			if err := gunzipJSON(data, &changes); err != nil {
+				return err
+			}
+			b := tx.Bucket([]byte(step))
+			for k, r := range changes {
+				// changed since, by a later write
+				if current := b.Get([]byte(k)); !bytes.Equal(current, r.After) || (current == nil) != (r.After == nil) {
+					batch.RollbackSkipped++
+					continue
+				}
+				var err error
+				if r.Before == nil {
+					err = b.Delete([]byte(k))
+				} else {
+					err = b.Put([]byte(k), r.Before)
+				}
+				if err != nil {
+					return err
+				}
+			}
+		}
+		batch.Status = SyncStatusRolledBack
+		batch.RolledBackAt = time.Now()
+		return putSyncBatch(tx, batch)
+	})
+	return batch, err
+}
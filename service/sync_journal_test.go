@@ -0,0 +1,115 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"go.etcd.io/bbolt"
+)
+
+func storedNickname(t *testing.T, db *bbolt.DB, uid string) string {
+	t.Helper()
+	player, err := GetPlayer(db, uid)
+	if err == ErrNoRecord {
+		return ""
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return player.Nickname
+}
+
+func TestRollbackSyncBatch(t *testing.T) {
+	db := openTestDB(t)
+	if _, err := PutPlayers(db, []database.Player{
+		{TersePlayer: database.TersePlayer{PlayerUid: "1001", Nickname: "alice", Level: 10}},
+		{TersePlayer: database.TersePlayer{PlayerUid: "1002", Nickname: "bob", Level: 10}},
+		{TersePlayer: database.TersePlayer{PlayerUid: "1005", Nickname: "erin", Level: 10}},
+	}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	batch, err := BeginSyncBatch(db, database.SyncBatch{Source: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	players := []database.Player{
+		{TersePlayer: database.TersePlayer{PlayerUid: "1001", Nickname: "alice2", Level: 11}},
+		{TersePlayer: database.TersePlayer{PlayerUid: "1003", Nickname: "carol", Level: 1}},
+		{TersePlayer: database.TersePlayer{PlayerUid: "1005", Nickname: "erin2", Level: 11}},
+	}
+	err = JournalStep(db, batch.Id, SyncStepPlayers, players, len(players), func(j *StepJournal) error {
+		if _, err := PutPlayers(db, players, j); err != nil {
+			return err
+		}
+		// dave joins while the step still runs, his record isn't the sync's
+		return PutPlayersOnline(db, []database.OnlinePlayer{{PlayerUid: "1004", Nickname: "dave"}})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := FinishSyncBatch(db, batch.Id, nil, 0); err != nil {
+		t.Fatal(err)
+	}
+	// erin comes online after the sync, the rollback leaves her
+	if err := PutPlayersOnline(db, []database.OnlinePlayer{{PlayerUid: "1005", Nickname: "erin2", Ip: "203.0.113.5"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := GetSyncBatch(db, batch.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// alice and erin changed, bob removed, carol added
+	if changed := got.Steps[0].Changed; changed != 4 {
+		t.Errorf("step changed %d records, want 4", changed)
+	}
+
+	rolledBack, err := RollbackSyncBatch(db, batch.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rolledBack.Status != SyncStatusRolledBack {
+		t.Errorf("status %q, want %q", rolledBack.Status, SyncStatusRolledBack)
+	}
+	if rolledBack.RollbackSkipped != 1 {
+		t.Errorf("skipped %d records, want 1", rolledBack.RollbackSkipped)
+	}
+	for uid, want := range map[string]string{
+		"1001": "alice",
+		"1002": "bob",
+		"1003": "",
+		"1004": "dave",
+		"1005": "erin2",
+	} {
+		if got := storedNickname(t, db, uid); got != want {
+			t.Errorf("player %s is %q after the rollback, want %q", uid, got, want)
+		}
+	}
+
+	if _, err := RollbackSyncBatch(db, batch.Id); err != ErrNoRecord && err != ErrSyncBatchRolledBack {
+		t.Errorf("second rollback: %v, want it refused", err)
+	}
+}
+
+func TestJournalStepFailedTransaction(t *testing.T) {
+	db := openTestDB(t)
+	batch, err := BeginSyncBatch(db, database.SyncBatch{Source: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	invalid := []database.Player{{TersePlayer: database.TersePlayer{PlayerUid: "not a uid"}}}
+	if err := JournalStep(db, batch.Id, SyncStepPlayers, invalid, 1, func(j *StepJournal) error {
+		_, err := PutPlayers(db, invalid, j)
+		return err
+	}); err == nil {
+		t.Fatal("invalid players were put")
+	}
+	got, err := GetSyncBatch(db, batch.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Steps) != 1 || got.Steps[0].Changed != 0 || got.Steps[0].Error == "" {
+		t.Errorf("steps %+v, want one failed step without changes", got.Steps)
+	}
+}
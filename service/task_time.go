@@ -0,0 +1,32 @@
+package service
+
+import (
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+// GetTaskTime returns the time stored for a task, like when the email digest
+// was last sent, zero when there's none.
+func GetTaskTime(db *bbolt.DB, name string) (time.Time, error) {
+	var t time.Time
+	err := db.View(func(tx *bbolt.Tx) error {
+		v := tx.Bucket([]byte("task_times")).Get([]byte(name))
+		if v == nil {
+			return nil
+		}
+		return t.UnmarshalText(v)
+	})
+	return t, err
+}
+
+// PutTaskTime stores the time of a task, so it's kept across restarts.
+func PutTaskTime(db *bbolt.DB, name string, t time.Time) error {
+	v, err := t.MarshalText()
+	if err != nil {
+		return err
+	}
+	return db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket([]byte("task_times")).Put([]byte(name), v)
+	})
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"go.etcd.io/bbolt"
+)
+
+type TechnologyUnlock struct {
+	Name string `json:"name"`
+	// Players are the players who researched it
+	Players int     `json:"players"`
+	Percent float64 `json:"percent"`
+}
+
+type TechnologyStats struct {
+	// Players are the players with technology data
+	Players              int     `json:"players"`
+	AverageUnlocked      float64 `json:"average_unlocked"`
+	AveragePoints        float64 `json:"average_points"`
+	AverageAncientPoints float64 `json:"average_ancient_points"`
+	// Unlocks are the researched technologies, the most common first
+	Unlocks []TechnologyUnlock `json:"unlocks"`
+}
+
+// GetTechnologyStats sums up the research progress of the stored players.
+func GetTechnologyStats(db *bbolt.DB) (TechnologyStats, error) {
+	stats := TechnologyStats{Unlocks: make([]TechnologyUnlock, 0)}
+	counts := make(map[string]int)
+	var unlocked, points, ancientPoints int
+	err := db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket([]byte("players")).ForEach(func(k, v []byte) error {
+			var player database.Player
+			if err := json.Unmarshal(v, &player); err != nil {
+				return err
+			}
+			if player.Technology == nil {
+				return nil
+			}
+			stats.Players++
+			unlocked += len(player.Technology.Unlocked)
+			points += int(player.Technology.Points)
+			ancientPoints += int(player.Technology.AncientPoints)
+			for _, name := range player.Technology.Unlocked {
+				counts[name]++
+			}
+			return nil
+		})
+	})
+	if err != nil || stats.Players == 0 {
+		return stats, err
+	}
+	n := float64(stats.Players)
+	stats.AverageUnlocked = float64(unlocked) / n
+	stats.AveragePoints = float64(points) / n
+	stats.AverageAncientPoints = float64(ancientPoints) / n
+	for name, count := range counts {
+		stats.Unlocks = append(stats.Unlocks, TechnologyUnlock{
+			Name:    name,
+			Players: count,
+			Percent: float64(count) / n * 100,
+		})
+	}
+	sort.Slice(stats.Unlocks, func(i, j int) bool {
+		if stats.Unlocks[i].Players != stats.Unlocks[j].Players {
+			return stats.Unlocks[i].Players > stats.Unlocks[j].Players
+		}
+		return stats.Unlocks[i].Name < stats.Unlocks[j].Name
+	})
+	return stats, nil
+}
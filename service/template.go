@@ -0,0 +1,37 @@
+package service
+
+import (
+	"go.etcd.io/bbolt"
+)
+
+// PutTemplate stores the template overriding a message key.
+func PutTemplate(db *bbolt.DB, key, source string) error {
+	return db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket([]byte("templates")).Put([]byte(key), []byte(source))
+	})
+}
+
+// ListTemplates returns the templates by message key.
+func ListTemplates(db *bbolt.DB) (map[string]string, error) {
+	templates := make(map[string]string)
+	err := db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket([]byte("templates")).ForEach(func(k, v []byte) error {
+			templates[string(k)] = string(v)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
+
+func RemoveTemplate(db *bbolt.DB, key string) error {
+	return db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte("templates"))
+		if b.Get([]byte(key)) == nil {
+			return ErrNoRecord
+		}
+		return b.Delete([]byte(key))
+	})
+}
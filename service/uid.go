@@ -0,0 +1,200 @@
+package service
+
+import (
+	"encoding/json"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"go.etcd.io/bbolt"
+)
+
+// CanonicalPlayerUid converts the formats a player uid shows up in, the
+// REST playerId "8A6A3ABC000000000000000000000000" or the save guid
+// "8a6a3abc-0000-0000-0000-000000000000", to the decimal uid the REST sync
+// has always stored. A bare "8a6a3abc" isn't taken for hex, "12345678"
+// would be both. Placeholder uids with a zero first part and anything
+// unrecognized are kept as they are.
+func CanonicalPlayerUid(uid string) string {
+	uid = strings.TrimSpace(uid)
+	if uid == "" {
+		return uid
+	}
+	// a uint32 has at most 10 digits, longer ones are padded guids
+	if len(uid) <= 10 {
+		if n, err := strconv.ParseUint(uid, 10, 32); err == nil {
+			return strconv.FormatUint(n, 10)
+		}
+	}
+	hex := strings.ReplaceAll(uid, "-", "")
+	if len(hex) != 32 || (hex != uid && len(uid) != 36) {
+		return uid
+	}
+	for _, r := range hex {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", r) {
+			return uid
+		}
+	}
+	n, _ := strconv.ParseUint(hex[:8], 16, 32)
+	if n == 0 {
+		return uid
+	}
+	return strconv.FormatUint(n, 10)
+}
+
+// NormalizePlayerUids moves the records stored under a non canonical uid to
+// the canonical one, merging them into a record already there. It returns
+// how many records were moved.
+func NormalizePlayerUids(db *bbolt.DB) (int, error) {
+	moved := 0
+	err := db.Update(func(tx *bbolt.Tx) error {
+		counts := []func() (int, error){
+			func() (int, error) {
+				return normalizeBucket(tx, "players", func(p *database.Player, uid string) { p.PlayerUid = uid }, mergePlayer)
+			},
+			func() (int, error) {
+				return normalizeBucket(tx, "playtimes", func(p *database.Playtime, uid string) { p.PlayerUid = uid }, mergePlaytime)
+			},
+			func() (int, error) {
+				return normalizeBucket(tx, "badges", func(b *database.PlayerBadges, uid string) { b.PlayerUid = uid }, mergePlayerBadges)
+			},
+			func() (int, error) {
+				return normalizeBucket(tx, "points", func(a *database.PointsAccount, uid string) { a.PlayerUid = uid }, mergePointsAccount)
+			},
+			func() (int, error) {
+				// the history of the canonical uid is the one still growing
+				return normalizeBucket(tx, "ping_history", func(h *database.PingHistory, uid string) { h.PlayerUid = uid },
+					func(dst, _ database.PingHistory) database.PingHistory { return dst })
+			},
+			func() (int, error) {
+				return normalizeBucket(tx, "guilds", normalizeGuild, func(dst, _ database.Guild) database.Guild { return dst })
+			},
+		}
+		for _, count := range counts {
+			n, err := count()
+			if err != nil {
+				return err
+			}
+			moved += n
+		}
+		return normalizePointsTransactions(tx)
+	})
+	return moved, err
+}
+
+// normalizeBucket rekeys the records of a bucket keyed by player uid.
+func normalizeBucket[T any](tx *bbolt.Tx, name string, setUid func(*T, string), merge func(dst, src T) T) (int, error) {
+	var keys []string
+	err := tx.Bucket([]byte(name)).ForEach(func(k, _ []byte) error {
+		if CanonicalPlayerUid(string(k)) != string(k) {
+			keys = append(keys, string(k))
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	w := &recordWriter{tx: tx}
+	for _, key := range keys {
+		if err := moveRecord(w, name, key, CanonicalPlayerUid(key), setUid, merge); err != nil {
+			return 0, err
+		}
+	}
+	return len(keys), nil
+}
+
+// mergePlayer keeps the record seen last, filling in what it lacks.
+func mergePlayer(dst, src database.Player) database.Player {
+	if src.LastOnline.After(dst.LastOnline) {
+		dst, src = src, dst
+	}
+	if dst.SteamId == "" {
+		dst.SteamId = src.SteamId
+	}
+	if dst.Nickname == "" {
+		dst.Nickname = src.Nickname
+	}
+	if dst.Platform == "" {
+		dst.Platform = src.Platform
+	}
+	if src.UpdatedAt.After(dst.UpdatedAt) {
+		dst.UpdatedAt = src.UpdatedAt
+	}
+	dst.Revision = max(dst.Revision, src.Revision) + 1
+	return dst
+}
+
+func mergePlaytime(dst, src database.Playtime) database.Playtime {
+	dst.Seconds += src.Seconds
+	if src.LastSeen.After(dst.LastSeen) {
+		dst.LastSeen = src.LastSeen
+		dst.Nickname = src.Nickname
+	}
+	for _, tag := range src.Tags {
+		if !contains(dst.Tags, tag) {
+			dst.Tags = append(dst.Tags, tag)
+		}
+	}
+	return dst
+}
+
+// mergePlayerBadges keeps the earlier of a badge both have and the longer
+// streak.
+func mergePlayerBadges(dst, src database.PlayerBadges) database.PlayerBadges {
+	for _, badge := range src.Badges {
+		i := slices.IndexFunc(dst.Badges, func(b database.EarnedBadge) bool { return b.Id == badge.Id })
+		switch {
+		case i < 0:
+			dst.Badges = append(dst.Badges, badge)
+		case badge.Time.Before(dst.Badges[i].Time):
+			dst.Badges[i] = badge
+		}
+	}
+	if src.StreakDay > dst.StreakDay || (src.StreakDay == dst.StreakDay && src.Streak > dst.Streak) {
+		dst.Streak, dst.StreakDay = src.Streak, src.StreakDay
+	}
+	return dst
+}
+
+func mergePointsAccount(dst, src database.PointsAccount) database.PointsAccount {
+	dst.Balance += src.Balance
+	dst.OnlineSeconds += src.OnlineSeconds
+	if src.LastSeen.After(dst.LastSeen) {
+		dst.LastSeen = src.LastSeen
+		dst.Nickname = src.Nickname
+	}
+	return dst
+}
+
+func normalizeGuild(g *database.Guild, uid string) {
+	g.AdminPlayerUid = uid
+	for _, p := range g.Players {
+		p.PlayerUid = CanonicalPlayerUid(p.PlayerUid)
+	}
+}
+
+func normalizePointsTransactions(tx *bbolt.Tx) error {
+	b := tx.Bucket([]byte("point_transactions"))
+	var changed []database.PointsTransaction
+	err := b.ForEach(func(_, v []byte) error {
+		var t database.PointsTransaction
+		if err := json.Unmarshal(v, &t); err != nil {
+			return err
+		}
+		if uid := CanonicalPlayerUid(t.PlayerUid); uid != t.PlayerUid {
+			t.PlayerUid = uid
+			changed = append(changed, t)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, t := range changed {
+		if err := putPointsTransaction(b, t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
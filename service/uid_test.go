@@ -0,0 +1,23 @@
+package service
+
+import "testing"
+
+func TestCanonicalPlayerUid(t *testing.T) {
+	for _, tt := range []struct {
+		uid, want string
+	}{
+		{"2322282172", "2322282172"},
+		{"0042", "42"},
+		{"8A6A3ABC000000000000000000000000", "2322217660"},
+		{"8a6a3abc-0000-0000-0000-000000000000", "2322217660"},
+		// bare hex is kept, all digits it would be read as decimal
+		{"8a6a3abc", "8a6a3abc"},
+		{"12345678", "12345678"},
+		{"00000000000000000000000000000001", "00000000000000000000000000000001"},
+		{"8a6a3abc-00000000-0000-000000000000", "8a6a3abc-00000000-0000-000000000000"},
+	} {
+		if got := CanonicalPlayerUid(tt.uid); got != tt.want {
+			t.Errorf("CanonicalPlayerUid(%q) = %q, want %q", tt.uid, got, tt.want)
+		}
+	}
+}
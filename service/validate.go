@@ -0,0 +1,90 @@
+package service
+
+import (
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/internal/validate"
+)
+
+func validatePlayers(players []database.Player) error {
+	var v validate.Validator
+	for i, p := range players {
+		field := func(name string) string { return validate.Index("", i, name) }
+		v.Required(field("player_uid"), p.PlayerUid)
+		v.PlayerUid(field("player_uid"), p.PlayerUid)
+		v.SteamId(field("steam_id"), p.SteamId)
+		v.Name(field("nickname"), p.Nickname)
+		v.Coordinate(field("location_x"), p.LocationX)
+		v.Coordinate(field("location_y"), p.LocationY)
+	}
+	return v.Err()
+}
+
+func validateGuilds(guilds []database.Guild) error {
+	var v validate.Validator
+	for i, guild := range guilds {
+		field := func(name string) string { return validate.Index("", i, name) }
+		v.Name(field("name"), guild.Name)
+		v.Required(field("admin_player_uid"), guild.AdminPlayerUid)
+		v.PlayerUid(field("admin_player_uid"), guild.AdminPlayerUid)
+		for j, p := range guild.Players {
+			if p == nil {
+				continue
+			}
+			v.PlayerUid(validate.Index(field("players"), j, "player_uid"), p.PlayerUid)
+			v.Name(validate.Index(field("players"), j, "nickname"), p.Nickname)
+		}
+		for j, base := range guild.BaseCamp {
+			v.Coordinate(validate.Index(field("base_camp"), j, "location_x"), base.LocationX)
+			v.Coordinate(validate.Index(field("base_camp"), j, "location_y"), base.LocationY)
+		}
+	}
+	return v.Err()
+}
+
+// validateWhitelistEntry checks an entry has something to match a player by,
+// field is the prefix of the entry in a list.
+func validateWhitelistEntry(v *validate.Validator, field string, player database.PlayerW) {
+	if player.Name == "" && player.SteamID == "" && player.PlayerUID == "" {
+		v.Add(field+"player_uid", "name, steam_id or player_uid is required")
+	}
+	v.Name(field+"name", player.Name)
+	v.SteamId(field+"steam_id", player.SteamID)
+	v.PlayerUid(field+"player_uid", player.PlayerUID)
+}
+
+func validateWhitelist(players []database.PlayerW) error {
+	var v validate.Validator
+	for i, player := range players {
+		validateWhitelistEntry(&v, validate.Index("", i, ""), player)
+	}
+	return v.Err()
+}
+
+func validateMapObjects(objects []database.MapObject) error {
+	var v validate.Validator
+	for i, object := range objects {
+		field := func(name string) string { return validate.Index("", i, name) }
+		v.Required(field("instance_id"), object.InstanceId)
+		v.Coordinate(field("location_x"), object.LocationX)
+		v.Coordinate(field("location_y"), object.LocationY)
+		v.PlayerUid(field("build_player_uid"), object.BuildPlayerUid)
+	}
+	return v.Err()
+}
+
+func ValidateGroupMember(member database.GroupMember) error {
+	var v validate.Validator
+	validateGroupMember(&v, "", member)
+	return v.Err()
+}
+
+// validateGroupMember checks a member has a player uid or steam id to match,
+// field is the prefix of the member in a list.
+func validateGroupMember(v *validate.Validator, field string, member database.GroupMember) {
+	if member.PlayerUid == "" && member.SteamId == "" {
+		v.Add(field+"player_uid", "player_uid or steam_id is required")
+	}
+	v.Name(field+"name", member.Name)
+	v.SteamId(field+"steam_id", member.SteamId)
+	v.PlayerUid(field+"player_uid", member.PlayerUid)
+}
@@ -0,0 +1,224 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"net/mail"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/zaigie/palworld-server-tool/internal/database"
+	"github.com/zaigie/palworld-server-tool/internal/validate"
+	"go.etcd.io/bbolt"
+)
+
+const (
+	ApplicationPending  = "pending"
+	ApplicationApproved = "approved"
+	ApplicationDenied   = "denied"
+
+	ApplicationSourceForm  = "form"
+	ApplicationSourceSteam = "steam"
+)
+
+// MaxAnswerLength is the most characters of an answer to an application
+// question.
+const MaxAnswerLength = 2000
+
+var (
+	ErrApplicationPending = errors.New("an application of this steam id is already pending")
+	ErrAlreadyWhitelisted = errors.New("steam id is already whitelisted")
+	ErrApplicationDecided = errors.New("application was already decided")
+)
+
+// ApplicationDecision says who decided an application and why.
+type ApplicationDecision struct {
+	Approve bool
+	Reason  string
+	// By is the address of the request or the bot
+	By string
+}
+
+func validateApplication(app database.WhitelistApplication, questions []string) error {
+	var v validate.Validator
+	v.Required("name", app.Name)
+	v.Name("name", app.Name)
+	v.Required("steam_id", app.SteamId)
+	v.SteamId("steam_id", app.SteamId)
+	v.PlayerUid("player_uid", app.PlayerUid)
+	if app.Email != "" {
+		if _, err := mail.ParseAddress(app.Email); err != nil {
+			v.Add("email", "is not an email address")
+		}
+	}
+	for _, question := range questions {
+		answer := app.Answers[question]
+		v.Required("answers."+question, answer)
+		if utf8.RuneCountInString(answer) > MaxAnswerLength {
+			v.Add("answers."+question, "is longer than %d characters", MaxAnswerLength)
+		}
+	}
+	return v.Err()
+}
+
+// AddWhitelistApplication queues app as pending with the answers to
+// questions, the answers to other questions are dropped. A steam id already
+// whitelisted or with an application pending is refused.
+func AddWhitelistApplication(db *bbolt.DB, app database.WhitelistApplication, questions []string) (database.WhitelistApplication, error) {
+	app.Name = strings.TrimSpace(app.Name)
+	app.Email = strings.TrimSpace(app.Email)
+	if err := validateApplication(app, questions); err != nil {
+		return app, err
+	}
+	answers := make(map[string]string, len(questions))
+	for _, question := range questions {
+		answers[question] = strings.TrimSpace(app.Answers[question])
+	}
+	app.Answers = answers
+	app.PlayerUid = CanonicalPlayerUid(app.PlayerUid)
+	app.Status = ApplicationPending
+	app.Time = time.Now()
+	app.DecidedBy = ""
+	app.DecidedAt = time.Time{}
+	app.Reason = ""
+	err := db.Update(func(tx *bbolt.Tx) error {
+		whitelisted := false
+		if b := tx.Bucket([]byte("whitelist")); b != nil {
+			err := b.ForEach(func(k, v []byte) error {
+				var entry database.PlayerW
+				if err := json.Unmarshal(v, &entry); err != nil {
+					return err
+				}
+				whitelisted = whitelisted || entry.SteamID == app.SteamId
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		if whitelisted {
+			return ErrAlreadyWhitelisted
+		}
+		b := tx.Bucket([]byte("whitelist_applications"))
+		err := b.ForEach(func(k, v []byte) error {
+			var other database.WhitelistApplication
+			if err := json.Unmarshal(v, &other); err != nil {
+				return err
+			}
+			if other.SteamId == app.SteamId && other.Status == ApplicationPending {
+				return ErrApplicationPending
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		id, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+		app.Id = id
+		return putApplication(tx, app)
+	})
+	return app, err
+}
+
+func putApplication(tx *bbolt.Tx, app database.WhitelistApplication) error {
+	v, err := json.Marshal(app)
+	if err != nil {
+		return err
+	}
+	return tx.Bucket([]byte("whitelist_applications")).Put(eventKey(app.Id), v)
+}
+
+// ListWhitelistApplications returns the applications of status, or all of
+// them when it is empty, newest first.
+func ListWhitelistApplications(db *bbolt.DB, status string, limit int) ([]database.WhitelistApplication, error) {
+	apps := make([]database.WhitelistApplication, 0)
+	err := db.View(func(tx *bbolt.Tx) error {
+		c := tx.Bucket([]byte("whitelist_applications")).Cursor()
+		for k, v := c.Last(); k != nil; k, v = c.Prev() {
+			var app database.WhitelistApplication
+			if err := json.Unmarshal(v, &app); err != nil {
+				return err
+			}
+			if status != "" && app.Status != status {
+				continue
+			}
+			apps = append(apps, app)
+			if limit > 0 && len(apps) >= limit {
+				break
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return apps, nil
+}
+
+func GetWhitelistApplication(db *bbolt.DB, id uint64) (database.WhitelistApplication, error) {
+	var app database.WhitelistApplication
+	err := db.View(func(tx *bbolt.Tx) error {
+		v := tx.Bucket([]byte("whitelist_applications")).Get(eventKey(id))
+		if v == nil {
+			return ErrNoRecord
+		}
+		return json.Unmarshal(v, &app)
+	})
+	return app, err
+}
+
+// GetLatestApplication returns the last application of the steam id,
+// ErrNoRecord when it never applied.
+func GetLatestApplication(db *bbolt.DB, steamId string) (database.WhitelistApplication, error) {
+	var app database.WhitelistApplication
+	err := db.View(func(tx *bbolt.Tx) error {
+		c := tx.Bucket([]byte("whitelist_applications")).Cursor()
+		for k, v := c.Last(); k != nil; k, v = c.Prev() {
+			var other database.WhitelistApplication
+			if err := json.Unmarshal(v, &other); err != nil {
+				return err
+			}
+			if other.SteamId == steamId {
+				app = other
+				return nil
+			}
+		}
+		return ErrNoRecord
+	})
+	return app, err
+}
+
+// DecideWhitelistApplication approves or denies a pending application, an
+// approval adds the applicant to the whitelist in the same transaction.
+func DecideWhitelistApplication(db *bbolt.DB, id uint64, decision ApplicationDecision) (database.WhitelistApplication, error) {
+	var app database.WhitelistApplication
+	err := db.Update(func(tx *bbolt.Tx) error {
+		v := tx.Bucket([]byte("whitelist_applications")).Get(eventKey(id))
+		if v == nil {
+			return ErrNoRecord
+		}
+		if err := json.Unmarshal(v, &app); err != nil {
+			return err
+		}
+		if app.Status != ApplicationPending {
+			return ErrApplicationDecided
+		}
+		app.Status = ApplicationDenied
+		if decision.Approve {
+			app.Status = ApplicationApproved
+			entry := database.PlayerW{Name: app.Name, SteamID: app.SteamId, PlayerUID: app.PlayerUid}
+			if err := putWhitelistEntry(tx, entry); err != nil {
+				return err
+			}
+		}
+		app.Reason = decision.Reason
+		app.DecidedBy = decision.By
+		app.DecidedAt = time.Now()
+		return putApplication(tx, app)
+	})
+	return app, err
+}
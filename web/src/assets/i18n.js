@@ -33,6 +33,8 @@ const messages = {
       copyempty: "Copy content is empty!",
       autherr: "Password error!",
       authsuccess: "Auth success!",
+      uiversionmismatch:
+        "This page was built for {ui} but the server runs {api}, reload without cache if it misbehaves",
       requireauth: "Please auth first!",
       bantitle: "Ban Player",
       banwarn: "Are you sure to ban this player?",
@@ -194,6 +196,7 @@ const messages = {
       copyempty: "复制内容为空!",
       autherr: "密码错误!",
       authsuccess: "认证成功!",
+      uiversionmismatch: "页面版本 {ui} 与服务端 {api} 不一致，若显示异常请清除缓存后刷新",
       requireauth: "请先进入管理模式!",
       bantitle: "封禁玩家",
       banwarn: "您确定要封禁此玩家吗?",
@@ -353,6 +356,8 @@ const messages = {
       copyempty: "コピー内容が空です！",
       autherr: "パスワードが正しくありません！",
       authsuccess: "ログインに成功しました！",
+      uiversionmismatch:
+        "このページは {ui} 用ですが、サーバーは {api} です。表示がおかしい場合はキャッシュを消して再読み込みしてください",
       requireauth: "先にログインをしてください！",
       bantitle: "プレイヤーをBANする",
       banwarn: "このプレイヤーを本当にBANしますか？",
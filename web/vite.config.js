@@ -8,11 +8,38 @@ import Components from "unplugin-vue-components/vite";
 import { NaiveUiResolver } from "unplugin-vue-components/resolvers";
 import { VantResolver } from "unplugin-vue-components/resolvers";
 import { fileURLToPath } from "url";
+import { execSync } from "child_process";
+
+// the pst release the bundle is built for, checked against the API version
+const uiVersion = (() => {
+  if (process.env.PST_VERSION) {
+    return process.env.PST_VERSION;
+  }
+  try {
+    return execSync("git describe --tags --abbrev=0").toString().trim();
+  } catch {
+    return "Develop";
+  }
+})();
+
+const uiVersionFile = () => ({
+  name: "pst-ui-version",
+  generateBundle() {
+    this.emitFile({
+      type: "asset",
+      fileName: "assets/ui-version.json",
+      source: JSON.stringify({ version: uiVersion }),
+    });
+  },
+});
 
 // const debugMode = process.env.APP_ENV !== 'prod'
 
 export default defineConfig({
   base: "./",
+  define: {
+    __PST_UI_VERSION__: JSON.stringify(uiVersion),
+  },
   build: {
     outDir: "../",
   },
@@ -36,6 +63,7 @@ export default defineConfig({
       resolvers: [NaiveUiResolver(), VantResolver()],
     }),
     UnoCSS(),
+    uiVersionFile(),
   ],
   resolve: {
     alias: {